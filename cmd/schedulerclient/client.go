@@ -33,6 +33,14 @@ import (
 
 const (
 	address = "localhost:3333"
+	rmID    = "schedulerclient"
+	config  = `
+partitions:
+  - name: default
+    queues:
+      - name: root
+        submitacl: "*"
+`
 )
 
 func main() {
@@ -52,7 +60,12 @@ func runApp() error {
 
 	ctx, cancel := context.WithTimeout(context.Background(), time.Hour*100000)
 	defer cancel()
-	_, err = c.RegisterResourceManager(ctx, &si.RegisterResourceManagerRequest{})
+	_, err = c.RegisterResourceManager(ctx, &si.RegisterResourceManagerRequest{
+		RmID:        rmID,
+		PolicyGroup: "queues",
+		Version:     "0.0.1",
+		Config:      config,
+	})
 	if err != nil {
 		return fmt.Errorf("could not greet: %v", err)
 	}
@@ -68,7 +81,7 @@ func runApp() error {
 	// first goroutine sends requests
 	go func() {
 		for i := 1; i <= 10; i++ {
-			req := si.AllocationRequest{}
+			req := si.AllocationRequest{RmID: rmID}
 			if err := stream.Send(&req); err != nil {
 				log.Fatalf("can not send %v", err)
 			}
@@ -20,20 +20,82 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/apache/yunikorn-core/pkg/entrypoint"
+	"github.com/apache/yunikorn-core/pkg/leaderelection"
+	"github.com/apache/yunikorn-core/pkg/scheduler/accounting"
+	"github.com/apache/yunikorn-core/pkg/scheduler/state"
 )
 
 var (
 	endpoint = flag.String("endpoint", "tcp://localhost:3333", "YuniKorn endpoint")
+	stateDir = flag.String("state-dir", "", "directory to persist the scheduler state in, state is not persisted if not set")
+	leaseDir = flag.String("lease-dir", "", "directory of the leader election lease shared by all instances, requires a state directory")
+	id       = flag.String("id", "", "unique ID of the instance in the leader election, defaults to the host name")
+	ledger   = flag.String("accounting-dir", "", "directory to persist the chargeback ledger in, the ledger is kept in memory only if not set")
 )
 
+// main starts the scheduler core with a gRPC server on the endpoint.
+// Resource managers connect to the endpoint to register and send their updates.
+// With a state directory the scheduler restores its state from the directory after a restart.
+// With a lease directory the instance runs as a hot standby until it acquires the lease.
+// With an accounting directory the chargeback ledger survives a restart.
 func main() {
 	flag.Parse()
-	handle()
+	if *leaseDir != "" && *stateDir == "" {
+		fmt.Fprintln(os.Stderr, "leader election requires a state directory")
+		os.Exit(1)
+	}
+	options := entrypoint.ServiceOptions{
+		GRPCEndpoint: *endpoint,
+	}
+	if *stateDir != "" {
+		store, err := state.NewFileStore(*stateDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to create state store: %v\n", err)
+			os.Exit(1)
+		}
+		options.StateStore = store
+	}
+	if *ledger != "" {
+		store, err := accounting.NewFileStore(*ledger)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to create accounting store: %v\n", err)
+			os.Exit(1)
+		}
+		options.AccountingStore = store
+	}
+	if *leaseDir != "" {
+		options.Election = newElectionConfig()
+	}
+	serviceContext := entrypoint.StartAllServicesWithOptions(options)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	<-signals
+	serviceContext.StopAll()
 	os.Exit(0)
 }
 
-func handle() {
-	scheduler := &SimpleScheduler{}
-	scheduler.Run(*endpoint)
+func newElectionConfig() *leaderelection.Config {
+	lease, err := leaderelection.NewFileLease(*leaseDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to create lease: %v\n", err)
+		os.Exit(1)
+	}
+	instanceID := *id
+	if instanceID == "" {
+		if instanceID, err = os.Hostname(); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to get host name: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	return &leaderelection.Config{
+		ID:    instanceID,
+		Lease: lease,
+	}
 }
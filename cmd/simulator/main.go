@@ -0,0 +1,102 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+// simulator replays a workload trace against the core scheduler without a real resource manager.
+// The nodes and the trace are read from CSV or JSON files, the scheduler runs on a virtual clock and
+// a report with the queue wait times, utilisation and fairness is written at the end.
+func main() {
+	configFile := flag.String("config", "", "scheduler configuration file, uses a single root queue creating child queues if not set")
+	nodesFile := flag.String("nodes", "", "node definitions (CSV or JSON)")
+	traceFile := flag.String("trace", "", "workload trace (CSV or JSON)")
+	tick := flag.Int64("tick", 1, "virtual seconds per simulation tick")
+	maxSteps := flag.Int("max-steps", 1000, "maximum scheduling cycles per tick")
+	maxTime := flag.Int64("max-time", 0, "virtual time in seconds at which the simulation stops, 0 runs the full trace")
+	format := flag.String("format", "text", "report format: text or json")
+	output := flag.String("output", "", "report file, standard output if not set")
+	flag.Parse()
+
+	if err := run(*configFile, *nodesFile, *traceFile, *format, *output, options{
+		tick:     *tick,
+		maxSteps: *maxSteps,
+		maxTime:  *maxTime,
+	}); err != nil {
+		fmt.Fprintf(os.Stderr, "simulation failed: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(configFile, nodesFile, traceFile, format, output string, opts options) error {
+	if nodesFile == "" || traceFile == "" {
+		return fmt.Errorf("both the nodes and the trace file must be set")
+	}
+	if opts.tick <= 0 || opts.maxSteps <= 0 {
+		return fmt.Errorf("tick and max-steps must be positive")
+	}
+	if format != "text" && format != "json" {
+		return fmt.Errorf("unknown report format: %s", format)
+	}
+	var config string
+	if configFile != "" {
+		content, err := os.ReadFile(configFile)
+		if err != nil {
+			return err
+		}
+		config = string(content)
+	}
+	nodes, err := loadNodes(nodesFile)
+	if err != nil {
+		return err
+	}
+	trace, err := loadTrace(traceFile)
+	if err != nil {
+		return err
+	}
+
+	sim, err := newSimulator(config, nodes, trace, opts)
+	if err != nil {
+		return err
+	}
+	report, err := sim.Run()
+	sim.Stop()
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if output != "" {
+		file, err := os.Create(output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		w = file
+	}
+	if format == "json" {
+		return report.writeJSON(w)
+	}
+	return report.writeText(w)
+}
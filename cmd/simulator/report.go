@@ -0,0 +1,241 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+)
+
+// Report is the outcome of a simulation run.
+type Report struct {
+	// virtual seconds simulated
+	Duration int64 `json:"duration"`
+	// time averaged fraction of the cluster capacity allocated per resource type
+	Utilisation map[string]float64 `json:"utilisation"`
+	// Jain's fairness index over the dominant resource share of the queues: 1 is perfectly fair
+	Fairness float64        `json:"fairness"`
+	Queues   []*QueueReport `json:"queues"`
+}
+
+// QueueReport contains the statistics of one leaf queue.
+type QueueReport struct {
+	Queue     string `json:"queue"`
+	Submitted int    `json:"submitted"`
+	Allocated int    `json:"allocated"`
+	Pending   int    `json:"pending"`
+	Rejected  int    `json:"rejected"`
+	// time between submit and allocation in virtual seconds
+	WaitAvg float64 `json:"waitAvg"`
+	WaitP50 int64   `json:"waitP50"`
+	WaitP95 int64   `json:"waitP95"`
+	WaitMax int64   `json:"waitMax"`
+	// allocated resources integrated over time per resource type
+	ResourceSeconds map[string]float64 `json:"resourceSeconds"`
+	// highest share of the cluster capacity used over all resource types
+	DominantShare float64 `json:"dominantShare"`
+}
+
+// reportCollector integrates the sampled usage while the simulation runs.
+type reportCollector struct {
+	capacity   *resources.Resource
+	elapsed    int64
+	cluster    map[string]float64
+	queues     map[string]map[string]float64
+	rejections map[string]int
+}
+
+func newReportCollector(capacity *resources.Resource) *reportCollector {
+	return &reportCollector{
+		capacity:   capacity,
+		cluster:    make(map[string]float64),
+		queues:     make(map[string]map[string]float64),
+		rejections: make(map[string]int),
+	}
+}
+
+// sample adds the usage of one tick.
+func (rc *reportCollector) sample(tick int64, root *resources.Resource, queues map[string]*resources.Resource) {
+	rc.elapsed += tick
+	if root != nil {
+		for name, value := range root.Resources {
+			rc.cluster[name] += float64(value) * float64(tick)
+		}
+	}
+	for queuePath, allocated := range queues {
+		usage, ok := rc.queues[queuePath]
+		if !ok {
+			usage = make(map[string]float64)
+			rc.queues[queuePath] = usage
+		}
+		if allocated == nil {
+			continue
+		}
+		for name, value := range allocated.Resources {
+			usage[name] += float64(value) * float64(tick)
+		}
+	}
+}
+
+// rejected tracks the asks of an application that was rejected by the placement.
+func (rc *reportCollector) rejected(record *TraceRecord) {
+	rc.rejections[record.Queue]++
+}
+
+// build creates the report from the collected samples and the tasks.
+func (rc *reportCollector) build(now int64, done []*task, pending, running map[string]*task) *Report {
+	report := &Report{
+		Duration:    now,
+		Utilisation: make(map[string]float64),
+	}
+	for name, value := range rc.capacity.Resources {
+		if value > 0 && rc.elapsed > 0 {
+			report.Utilisation[name] = rc.cluster[name] / (float64(value) * float64(rc.elapsed))
+		}
+	}
+
+	queues := make(map[string]*QueueReport)
+	waits := make(map[string][]int64)
+	getQueue := func(queuePath string) *QueueReport {
+		qr, ok := queues[queuePath]
+		if !ok {
+			qr = &QueueReport{Queue: queuePath, ResourceSeconds: make(map[string]float64)}
+			queues[queuePath] = qr
+		}
+		return qr
+	}
+	addTask := func(t *task) {
+		qr := getQueue(t.queue)
+		qr.Submitted++
+		if t.allocated < 0 {
+			qr.Pending++
+			return
+		}
+		qr.Allocated++
+		waits[t.queue] = append(waits[t.queue], t.allocated-t.submitted)
+	}
+	for _, t := range done {
+		addTask(t)
+	}
+	for _, t := range running {
+		addTask(t)
+	}
+	for _, t := range pending {
+		addTask(t)
+	}
+	for queuePath, count := range rc.rejections {
+		qr := getQueue(queuePath)
+		qr.Submitted += count
+		qr.Rejected += count
+	}
+	for queuePath, usage := range rc.queues {
+		qr := getQueue(queuePath)
+		for name, value := range usage {
+			qr.ResourceSeconds[name] = value
+			if capacity := rc.capacity.Resources[name]; capacity > 0 && rc.elapsed > 0 {
+				qr.DominantShare = math.Max(qr.DominantShare, value/(float64(capacity)*float64(rc.elapsed)))
+			}
+		}
+	}
+
+	shares := make([]float64, 0, len(queues))
+	for queuePath, qr := range queues {
+		qr.WaitAvg, qr.WaitP50, qr.WaitP95, qr.WaitMax = waitStats(waits[queuePath])
+		report.Queues = append(report.Queues, qr)
+		if qr.Allocated > 0 {
+			shares = append(shares, qr.DominantShare)
+		}
+	}
+	sort.Slice(report.Queues, func(i, j int) bool {
+		return report.Queues[i].Queue < report.Queues[j].Queue
+	})
+	report.Fairness = jainIndex(shares)
+	return report
+}
+
+// waitStats returns the average, median, 95th percentile and maximum of the wait times.
+func waitStats(waits []int64) (float64, int64, int64, int64) {
+	if len(waits) == 0 {
+		return 0, 0, 0, 0
+	}
+	sort.Slice(waits, func(i, j int) bool { return waits[i] < waits[j] })
+	var total int64
+	for _, wait := range waits {
+		total += wait
+	}
+	return float64(total) / float64(len(waits)), percentile(waits, 50), percentile(waits, 95), waits[len(waits)-1]
+}
+
+// percentile uses the nearest rank method on the sorted values.
+func percentile(sorted []int64, p int) int64 {
+	rank := int(math.Ceil(float64(p)/100*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	return sorted[rank]
+}
+
+// jainIndex calculates (sum x)^2 / (n * sum x^2), which is 1 if all values are equal and 1/n if one value
+// has everything. No values or all zero values are considered fair.
+func jainIndex(values []float64) float64 {
+	var sum, squares float64
+	for _, value := range values {
+		sum += value
+		squares += value * value
+	}
+	if squares == 0 {
+		return 1
+	}
+	return sum * sum / (float64(len(values)) * squares)
+}
+
+// writeJSON writes the report as indented JSON.
+func (r *Report) writeJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// writeText writes the report as a human readable table.
+func (r *Report) writeText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "simulated time:\t%ds\n", r.Duration)
+	fmt.Fprintf(tw, "fairness index:\t%.3f\n", r.Fairness)
+	names := make([]string, 0, len(r.Utilisation))
+	for name := range r.Utilisation {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(tw, "utilisation %s:\t%.1f%%\n", name, r.Utilisation[name]*100)
+	}
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, "QUEUE\tSUBMITTED\tALLOCATED\tPENDING\tREJECTED\tWAIT AVG\tWAIT P50\tWAIT P95\tWAIT MAX\tDOMINANT SHARE")
+	for _, qr := range r.Queues {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\t%.1fs\t%ds\t%ds\t%ds\t%.1f%%\n", qr.Queue, qr.Submitted, qr.Allocated,
+			qr.Pending, qr.Rejected, qr.WaitAvg, qr.WaitP50, qr.WaitP95, qr.WaitMax, qr.DominantShare*100)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,137 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+)
+
+func TestWaitStats(t *testing.T) {
+	avg, p50, p95, maxWait := waitStats(nil)
+	assert.Equal(t, avg, float64(0))
+	assert.Equal(t, p50+p95+maxWait, int64(0))
+
+	avg, p50, p95, maxWait = waitStats([]int64{10, 0, 4, 2})
+	assert.Equal(t, avg, float64(4))
+	assert.Equal(t, p50, int64(2))
+	assert.Equal(t, p95, int64(10))
+	assert.Equal(t, maxWait, int64(10))
+}
+
+func TestJainIndex(t *testing.T) {
+	assert.Equal(t, jainIndex(nil), float64(1), "no values should be fair")
+	assert.Equal(t, jainIndex([]float64{0, 0}), float64(1), "zero values should be fair")
+	assert.Equal(t, jainIndex([]float64{0.5, 0.5, 0.5}), float64(1), "equal values should be fair")
+	assert.Assert(t, math.Abs(jainIndex([]float64{1, 0, 0, 0})-0.25) < 1e-9, "one value with everything should be 1/n")
+}
+
+func TestReportBuild(t *testing.T) {
+	capacity := resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 10})
+	rc := newReportCollector(capacity)
+	half := resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 5})
+	for i := 0; i < 10; i++ {
+		rc.sample(1, half, map[string]*resources.Resource{"root.a": half, "root.b": nil})
+	}
+	rc.rejected(&TraceRecord{Queue: "root.c"})
+
+	done := []*task{{queue: "root.a", submitted: 0, allocated: 2}}
+	running := map[string]*task{"ask-2": {queue: "root.a", submitted: 1, allocated: 7}}
+	pending := map[string]*task{"ask-3": {queue: "root.b", submitted: 3, allocated: -1}}
+	report := rc.build(10, done, pending, running)
+
+	assert.Equal(t, report.Duration, int64(10))
+	assert.Assert(t, math.Abs(report.Utilisation["vcore"]-0.5) < 1e-9, "unexpected utilisation: %f", report.Utilisation["vcore"])
+	// only root.a has allocations: one queue is always fair
+	assert.Equal(t, report.Fairness, float64(1))
+	assert.Equal(t, len(report.Queues), 3)
+
+	qa := report.Queues[0]
+	assert.Equal(t, qa.Queue, "root.a")
+	assert.Equal(t, qa.Submitted, 2)
+	assert.Equal(t, qa.Allocated, 2)
+	assert.Equal(t, qa.WaitAvg, float64(4))
+	assert.Equal(t, qa.WaitMax, int64(6))
+	assert.Equal(t, qa.ResourceSeconds["vcore"], float64(50))
+	assert.Assert(t, math.Abs(qa.DominantShare-0.5) < 1e-9, "unexpected dominant share: %f", qa.DominantShare)
+
+	qb := report.Queues[1]
+	assert.Equal(t, qb.Queue, "root.b")
+	assert.Equal(t, qb.Pending, 1)
+	assert.Equal(t, qb.Allocated, 0)
+	assert.Equal(t, qb.DominantShare, float64(0))
+
+	qc := report.Queues[2]
+	assert.Equal(t, qc.Queue, "root.c")
+	assert.Equal(t, qc.Submitted, 1)
+	assert.Equal(t, qc.Rejected, 1)
+}
+
+func TestReportWrite(t *testing.T) {
+	report := &Report{
+		Duration:    60,
+		Utilisation: map[string]float64{"vcore": 0.25, "memory": 0.5},
+		Fairness:    0.75,
+		Queues:      []*QueueReport{{Queue: "root.a", Submitted: 3, Allocated: 2, Pending: 1, WaitAvg: 1.5, WaitMax: 3, DominantShare: 0.4}},
+	}
+	var buf bytes.Buffer
+	assert.NilError(t, report.writeText(&buf), "text report should be written")
+	text := buf.String()
+	assert.Assert(t, strings.Contains(text, "60s\n"), "missing duration: %s", text)
+	assert.Assert(t, strings.Contains(text, "0.750\n"), "missing fairness: %s", text)
+	assert.Assert(t, strings.Index(text, "utilisation memory") < strings.Index(text, "utilisation vcore"), "utilisation should be sorted: %s", text)
+	assert.Assert(t, strings.Contains(text, "root.a"), "missing queue: %s", text)
+
+	buf.Reset()
+	assert.NilError(t, report.writeJSON(&buf), "json report should be written")
+	var decoded Report
+	assert.NilError(t, json.Unmarshal(buf.Bytes(), &decoded), "json report should decode")
+	assert.DeepEqual(t, decoded, *report)
+}
+
+func TestRun(t *testing.T) {
+	nodes := writeFile(t, "nodes.csv", "nodeID,resources\nnode-1,vcore=2000\n")
+	trace := writeFile(t, "trace.csv", strings.Join([]string{
+		"time,application,queue,user,duration,resources",
+		"0,app-1,root.a,alice,10,vcore=2000",
+		"0,app-2,root.b,bob,10,vcore=2000",
+	}, "\n"))
+	output := filepath.Join(t.TempDir(), "report.json")
+	assert.NilError(t, run("", nodes, trace, "json", output, options{tick: 1, maxSteps: 10}), "simulation should run")
+
+	content := readFile(t, output)
+	var report Report
+	assert.NilError(t, json.Unmarshal(content, &report), "report should decode")
+	assert.Equal(t, len(report.Queues), 2)
+	// the node fits one ask at a time: the second queue waits for the first ask to finish
+	assert.Equal(t, report.Queues[0].Allocated+report.Queues[1].Allocated, 2)
+	assert.Equal(t, report.Queues[0].WaitMax+report.Queues[1].WaitMax, int64(10))
+
+	assert.ErrorContains(t, run("", "", trace, "json", "", options{tick: 1, maxSteps: 10}), "both the nodes and the trace file must be set")
+	assert.ErrorContains(t, run("", nodes, trace, "xml", "", options{tick: 1, maxSteps: 10}), "unknown report format")
+	assert.ErrorContains(t, run("", nodes, trace, "json", "", options{tick: 0, maxSteps: 10}), "tick and max-steps must be positive")
+}
@@ -0,0 +1,494 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/apache/yunikorn-core/pkg/common"
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+	"github.com/apache/yunikorn-core/pkg/entrypoint"
+	"github.com/apache/yunikorn-core/pkg/locking"
+	"github.com/apache/yunikorn-core/pkg/mock"
+	"github.com/apache/yunikorn-core/pkg/scheduler"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/api"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+const (
+	rmID          = "simulator"
+	partitionName = "default"
+	// maximum wall clock time to wait for the core to process an update
+	processTimeout = 10 * time.Second
+)
+
+// defaultConfig creates any queue the trace references below the root
+const defaultConfig = `
+partitions:
+  - name: default
+    placementrules:
+      - name: provided
+        create: true
+    queues:
+      - name: root
+        submitacl: "*"
+`
+
+// simRMCallback is the fake RM: it records the responses of the core like the mock RM in the scheduler tests.
+type simRMCallback struct {
+	mock.ResourceManagerCallback
+	acceptedApplications map[string]bool
+	rejectedApplications map[string]bool
+	acceptedNodes        map[string]bool
+	newAllocations       []*si.Allocation
+
+	locking.RWMutex
+}
+
+func newSimRMCallback() *simRMCallback {
+	return &simRMCallback{
+		acceptedApplications: make(map[string]bool),
+		rejectedApplications: make(map[string]bool),
+		acceptedNodes:        make(map[string]bool),
+	}
+}
+
+func (m *simRMCallback) UpdateApplication(response *si.ApplicationResponse) error {
+	m.Lock()
+	defer m.Unlock()
+	for _, app := range response.Accepted {
+		m.acceptedApplications[app.ApplicationID] = true
+	}
+	for _, app := range response.Rejected {
+		m.rejectedApplications[app.ApplicationID] = true
+	}
+	return nil
+}
+
+func (m *simRMCallback) UpdateAllocation(response *si.AllocationResponse) error {
+	m.Lock()
+	defer m.Unlock()
+	m.newAllocations = append(m.newAllocations, response.New...)
+	return nil
+}
+
+func (m *simRMCallback) UpdateNode(response *si.NodeResponse) error {
+	m.Lock()
+	defer m.Unlock()
+	for _, node := range response.Accepted {
+		m.acceptedNodes[node.NodeID] = true
+	}
+	return nil
+}
+
+// drainAllocations returns the allocations received since the last call.
+func (m *simRMCallback) drainAllocations() []*si.Allocation {
+	m.Lock()
+	defer m.Unlock()
+	allocs := m.newAllocations
+	m.newAllocations = nil
+	return allocs
+}
+
+// applicationDecided returns true if the application was accepted or rejected, and if it was accepted.
+func (m *simRMCallback) applicationDecided(appID string) (bool, bool) {
+	m.RLock()
+	defer m.RUnlock()
+	return m.acceptedApplications[appID] || m.rejectedApplications[appID], m.acceptedApplications[appID]
+}
+
+func (m *simRMCallback) acceptedNodeCount() int {
+	m.RLock()
+	defer m.RUnlock()
+	return len(m.acceptedNodes)
+}
+
+// task tracks a submitted ask through the simulation. Times are in virtual seconds.
+type task struct {
+	record    *TraceRecord
+	queue     string
+	submitted int64
+	allocated int64
+	end       int64
+	nodeID    string
+}
+
+// options control the virtual clock of the simulation.
+type options struct {
+	// virtual seconds per tick
+	tick int64
+	// maximum number of scheduling cycles per tick
+	maxSteps int
+	// virtual time at which the simulation stops, 0 runs until the trace is done
+	maxTime int64
+}
+
+// Simulator replays a trace against the core scheduler running with a manual scheduler.
+// Each tick of the virtual clock releases finished tasks, submits the due trace records,
+// runs the scheduler until nothing more is allocated and samples the queue usage.
+type Simulator struct {
+	opts           options
+	serviceContext *entrypoint.ServiceContext
+	proxy          api.SchedulerAPI
+	scheduler      *scheduler.Scheduler
+	callback       *simRMCallback
+	partition      string
+
+	trace   []*TraceRecord
+	next    int
+	now     int64
+	apps    map[string]string
+	pending map[string]*task
+	running map[string]*task
+	done    []*task
+
+	report *reportCollector
+}
+
+// newSimulator starts the core, registers the fake RM with the configuration and adds the nodes.
+func newSimulator(config string, nodes []*NodeSpec, trace []*TraceRecord, opts options) (*Simulator, error) {
+	if config == "" {
+		config = defaultConfig
+	}
+	serviceContext := entrypoint.StartAllServicesWithManualScheduler()
+	s := &Simulator{
+		opts:           opts,
+		serviceContext: serviceContext,
+		proxy:          serviceContext.RMProxy,
+		scheduler:      serviceContext.Scheduler,
+		callback:       newSimRMCallback(),
+		partition:      common.GetNormalizedPartitionName(partitionName, rmID),
+		trace:          trace,
+		apps:           make(map[string]string),
+		pending:        make(map[string]*task),
+		running:        make(map[string]*task),
+	}
+	_, err := s.proxy.RegisterResourceManager(&si.RegisterResourceManagerRequest{
+		RmID:        rmID,
+		PolicyGroup: "queues",
+		Version:     "0.0.1",
+		Config:      config,
+	}, s.callback)
+	if err != nil {
+		s.Stop()
+		return nil, err
+	}
+	if err = s.addNodes(nodes); err != nil {
+		s.Stop()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Simulator) Stop() {
+	s.serviceContext.StopAll()
+}
+
+func (s *Simulator) addNodes(nodes []*NodeSpec) error {
+	capacity := resources.NewResource()
+	infos := make([]*si.NodeInfo, 0, len(nodes))
+	for _, node := range nodes {
+		res := toResource(node.Resources)
+		capacity.AddTo(res)
+		infos = append(infos, &si.NodeInfo{
+			NodeID:              node.NodeID,
+			Attributes:          node.Attributes,
+			SchedulableResource: res.ToProto(),
+			Action:              si.NodeInfo_CREATE,
+		})
+	}
+	if err := s.proxy.UpdateNode(&si.NodeRequest{Nodes: infos, RmID: rmID}); err != nil {
+		return err
+	}
+	if err := common.WaitForCondition(time.Millisecond, processTimeout, func() bool {
+		return s.callback.acceptedNodeCount() == len(nodes)
+	}); err != nil {
+		return fmt.Errorf("not all nodes were accepted by the scheduler: %w", err)
+	}
+	s.report = newReportCollector(capacity)
+	return nil
+}
+
+// Run replays the trace until all records are processed and no task can make progress anymore,
+// or the maximum virtual time is reached.
+func (s *Simulator) Run() (*Report, error) {
+	for {
+		if err := s.releaseFinished(); err != nil {
+			return nil, err
+		}
+		if err := s.submitDue(); err != nil {
+			return nil, err
+		}
+		allocated := s.schedule()
+		s.sample()
+		if s.finished(allocated) {
+			break
+		}
+		s.now += s.opts.tick
+	}
+	return s.report.build(s.now, s.done, s.pending, s.running), nil
+}
+
+// finished returns true if the trace is done and the state cannot change anymore.
+func (s *Simulator) finished(allocated bool) bool {
+	if s.opts.maxTime > 0 && s.now >= s.opts.maxTime {
+		return true
+	}
+	if s.next < len(s.trace) || allocated {
+		return false
+	}
+	for _, t := range s.running {
+		if t.end > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// releaseFinished releases all running tasks that reached their end time.
+func (s *Simulator) releaseFinished() error {
+	var releases []*si.AllocationRelease
+	var finished []*task
+	for key, t := range s.running {
+		if t.end > 0 && t.end <= s.now {
+			releases = append(releases, &si.AllocationRelease{
+				ApplicationID:   t.record.ApplicationID,
+				AllocationKey:   key,
+				PartitionName:   partitionName,
+				TerminationType: si.TerminationType_STOPPED_BY_RM,
+			})
+			finished = append(finished, t)
+			delete(s.running, key)
+		}
+	}
+	if len(releases) == 0 {
+		return nil
+	}
+	if err := s.proxy.UpdateAllocation(&si.AllocationRequest{
+		Releases: &si.AllocationReleasesRequest{AllocationsToRelease: releases},
+		RmID:     rmID,
+	}); err != nil {
+		return err
+	}
+	s.done = append(s.done, finished...)
+	return common.WaitForCondition(time.Millisecond, processTimeout, func() bool {
+		for _, t := range finished {
+			node := s.scheduler.GetClusterContext().GetNode(t.nodeID, s.partition)
+			if node != nil && node.GetAllocation(t.record.AllocationKey) != nil {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// submitDue processes all trace records up to the current time.
+func (s *Simulator) submitDue() error {
+	var due []*TraceRecord
+	for s.next < len(s.trace) && s.trace[s.next].Time <= s.now {
+		due = append(due, s.trace[s.next])
+		s.next++
+	}
+	if len(due) == 0 {
+		return nil
+	}
+	if err := s.addApplications(due); err != nil {
+		return err
+	}
+	if err := s.addAsks(due); err != nil {
+		return err
+	}
+	return s.completeApplications(due)
+}
+
+// addApplications adds the applications that are referenced for the first time and waits for the decision.
+func (s *Simulator) addApplications(due []*TraceRecord) error {
+	var newApps []*si.AddApplicationRequest
+	for _, record := range due {
+		if _, ok := s.apps[record.ApplicationID]; ok || record.Type != RecordSubmit {
+			continue
+		}
+		s.apps[record.ApplicationID] = ""
+		newApps = append(newApps, &si.AddApplicationRequest{
+			ApplicationID: record.ApplicationID,
+			QueueName:     record.Queue,
+			PartitionName: partitionName,
+			Ugi:           &si.UserGroupInformation{User: record.User},
+		})
+	}
+	if len(newApps) == 0 {
+		return nil
+	}
+	if err := s.proxy.UpdateApplication(&si.ApplicationRequest{New: newApps, RmID: rmID}); err != nil {
+		return err
+	}
+	for _, app := range newApps {
+		var accepted bool
+		if err := common.WaitForCondition(time.Millisecond, processTimeout, func() bool {
+			var decided bool
+			decided, accepted = s.callback.applicationDecided(app.ApplicationID)
+			return decided
+		}); err != nil {
+			return fmt.Errorf("application %s was not processed: %w", app.ApplicationID, err)
+		}
+		if accepted {
+			s.apps[app.ApplicationID] = s.scheduler.GetClusterContext().GetApplication(app.ApplicationID, s.partition).GetQueuePath()
+		}
+	}
+	return nil
+}
+
+// addAsks submits the asks for accepted applications. Asks for rejected applications are never scheduled.
+func (s *Simulator) addAsks(due []*TraceRecord) error {
+	var asks []*si.Allocation
+	for _, record := range due {
+		if record.Type != RecordSubmit {
+			continue
+		}
+		t := &task{
+			record:    record,
+			queue:     s.apps[record.ApplicationID],
+			submitted: s.now,
+			allocated: -1,
+		}
+		if t.queue == "" {
+			s.report.rejected(record)
+			continue
+		}
+		s.pending[record.AllocationKey] = t
+		asks = append(asks, &si.Allocation{
+			AllocationKey:    record.AllocationKey,
+			ApplicationID:    record.ApplicationID,
+			PartitionName:    partitionName,
+			ResourcePerAlloc: toResource(record.Resources).ToProto(),
+		})
+	}
+	if len(asks) == 0 {
+		return nil
+	}
+	if err := s.proxy.UpdateAllocation(&si.AllocationRequest{Allocations: asks, RmID: rmID}); err != nil {
+		return err
+	}
+	return common.WaitForCondition(time.Millisecond, processTimeout, func() bool {
+		for _, ask := range asks {
+			app := s.scheduler.GetClusterContext().GetApplication(ask.ApplicationID, s.partition)
+			if app == nil || app.GetAllocationAsk(ask.AllocationKey) == nil {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// completeApplications removes the applications: running tasks finish and pending tasks are dropped.
+func (s *Simulator) completeApplications(due []*TraceRecord) error {
+	var remove []*si.RemoveApplicationRequest
+	for _, record := range due {
+		if record.Type != RecordComplete || s.apps[record.ApplicationID] == "" {
+			continue
+		}
+		remove = append(remove, &si.RemoveApplicationRequest{
+			ApplicationID: record.ApplicationID,
+			PartitionName: partitionName,
+		})
+		for key, t := range s.running {
+			if t.record.ApplicationID == record.ApplicationID {
+				t.end = s.now
+				s.done = append(s.done, t)
+				delete(s.running, key)
+			}
+		}
+		for key, t := range s.pending {
+			if t.record.ApplicationID == record.ApplicationID {
+				s.done = append(s.done, t)
+				delete(s.pending, key)
+			}
+		}
+	}
+	if len(remove) == 0 {
+		return nil
+	}
+	if err := s.proxy.UpdateApplication(&si.ApplicationRequest{Remove: remove, RmID: rmID}); err != nil {
+		return err
+	}
+	return common.WaitForCondition(time.Millisecond, processTimeout, func() bool {
+		for _, app := range remove {
+			if s.scheduler.GetClusterContext().GetApplication(app.ApplicationID, s.partition) != nil {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// schedule runs scheduling cycles until a cycle does not allocate anything.
+// Returns true if at least one task was allocated.
+func (s *Simulator) schedule() bool {
+	allocated := false
+	for step := 0; step < s.opts.maxSteps && len(s.pending) > 0; step++ {
+		s.scheduler.MultiStepSchedule(1)
+		allocs := s.callback.drainAllocations()
+		if len(allocs) == 0 {
+			break
+		}
+		for _, alloc := range allocs {
+			t, ok := s.pending[alloc.AllocationKey]
+			if !ok {
+				continue
+			}
+			allocated = true
+			t.allocated = s.now
+			t.nodeID = alloc.NodeID
+			if t.record.Duration > 0 {
+				t.end = s.now + t.record.Duration
+			}
+			delete(s.pending, alloc.AllocationKey)
+			s.running[alloc.AllocationKey] = t
+		}
+	}
+	return allocated
+}
+
+// sample records the allocated resources of the cluster and all queues for the current tick.
+func (s *Simulator) sample() {
+	cc := s.scheduler.GetClusterContext()
+	queues := make(map[string]*resources.Resource)
+	for _, queuePath := range s.apps {
+		if queuePath == "" {
+			continue
+		}
+		if queue := cc.GetQueue(queuePath, s.partition); queue != nil {
+			queues[queuePath] = queue.GetAllocatedResource()
+		}
+	}
+	var root *resources.Resource
+	if queue := cc.GetQueue("root", s.partition); queue != nil {
+		root = queue.GetAllocatedResource()
+	}
+	s.report.sample(s.opts.tick, root, queues)
+}
+
+func toResource(res map[string]int64) *resources.Resource {
+	quantities := make(map[string]resources.Quantity, len(res))
+	for name, value := range res {
+		quantities[name] = resources.Quantity(value)
+	}
+	return resources.NewResourceFromMap(quantities)
+}
@@ -0,0 +1,244 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// RecordSubmit submits an ask, the application is created on first use
+	RecordSubmit = "submit"
+	// RecordComplete completes the application: all its allocations are released
+	RecordComplete = "complete"
+)
+
+// NodeSpec describes a node in the inventory.
+type NodeSpec struct {
+	NodeID     string            `json:"nodeID"`
+	Resources  map[string]int64  `json:"resources"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// TraceRecord is one timestamped entry of the workload trace.
+// Time and Duration are in seconds of virtual time. A submitted ask with a zero duration
+// runs until the application is completed or the simulation ends.
+type TraceRecord struct {
+	Time          int64            `json:"time"`
+	Type          string           `json:"type,omitempty"`
+	ApplicationID string           `json:"application"`
+	Queue         string           `json:"queue,omitempty"`
+	User          string           `json:"user,omitempty"`
+	AllocationKey string           `json:"key,omitempty"`
+	Duration      int64            `json:"duration,omitempty"`
+	Resources     map[string]int64 `json:"resources,omitempty"`
+}
+
+// loadNodes reads the node inventory. Files with a .csv extension are read as CSV with the columns
+// nodeID and resources, all other files are read as a JSON array.
+func loadNodes(path string) ([]*NodeSpec, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var nodes []*NodeSpec
+	if isCSV(path) {
+		nodes, err = parseNodesCSV(file)
+	} else {
+		err = json.NewDecoder(file).Decode(&nodes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load node inventory %s: %w", path, err)
+	}
+	for i, node := range nodes {
+		if node.NodeID == "" {
+			return nil, fmt.Errorf("node %d in inventory has no ID", i)
+		}
+	}
+	return nodes, nil
+}
+
+// loadTrace reads the workload trace and returns the records sorted on time. Files with a .csv extension
+// are read as CSV with a header row naming the columns, all other files are read as a JSON array.
+func loadTrace(path string) ([]*TraceRecord, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var records []*TraceRecord
+	if isCSV(path) {
+		records, err = parseTraceCSV(file)
+	} else {
+		err = json.NewDecoder(file).Decode(&records)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load trace %s: %w", path, err)
+	}
+	if err = validateTrace(records); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Time < records[j].Time
+	})
+	return records, nil
+}
+
+func validateTrace(records []*TraceRecord) error {
+	keys := make(map[string]bool)
+	for i, record := range records {
+		if record.Type == "" {
+			record.Type = RecordSubmit
+		}
+		if record.ApplicationID == "" {
+			return fmt.Errorf("trace record %d has no application", i)
+		}
+		if record.Time < 0 || record.Duration < 0 {
+			return fmt.Errorf("trace record %d has a negative time or duration", i)
+		}
+		switch record.Type {
+		case RecordSubmit:
+			if record.AllocationKey == "" {
+				record.AllocationKey = fmt.Sprintf("%s-%d", record.ApplicationID, i)
+			}
+			if keys[record.AllocationKey] {
+				return fmt.Errorf("trace record %d has a duplicate key %s", i, record.AllocationKey)
+			}
+			keys[record.AllocationKey] = true
+			if len(record.Resources) == 0 {
+				return fmt.Errorf("trace record %d has no resources", i)
+			}
+		case RecordComplete:
+		default:
+			return fmt.Errorf("trace record %d has an unknown type %s", i, record.Type)
+		}
+	}
+	return nil
+}
+
+func parseNodesCSV(reader io.Reader) ([]*NodeSpec, error) {
+	rows, err := readCSV(reader)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]*NodeSpec, 0, len(rows))
+	for _, row := range rows {
+		var res map[string]int64
+		if res, err = parseResources(row["resources"]); err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, &NodeSpec{
+			NodeID:    row["nodeID"],
+			Resources: res,
+		})
+	}
+	return nodes, nil
+}
+
+func parseTraceCSV(reader io.Reader) ([]*TraceRecord, error) {
+	rows, err := readCSV(reader)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]*TraceRecord, 0, len(rows))
+	for _, row := range rows {
+		record := &TraceRecord{
+			Type:          row["type"],
+			ApplicationID: row["application"],
+			Queue:         row["queue"],
+			User:          row["user"],
+			AllocationKey: row["key"],
+		}
+		if record.Time, err = parseInt(row["time"]); err != nil {
+			return nil, err
+		}
+		if record.Duration, err = parseInt(row["duration"]); err != nil {
+			return nil, err
+		}
+		if record.Resources, err = parseResources(row["resources"]); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// readCSV returns all rows as a map from the column name in the header row to the value.
+func readCSV(reader io.Reader) ([]map[string]string, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.TrimLeadingSpace = true
+	lines, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 {
+		return nil, nil
+	}
+	header := lines[0]
+	rows := make([]map[string]string, 0, len(lines)-1)
+	for _, line := range lines[1:] {
+		row := make(map[string]string, len(header))
+		for i, column := range header {
+			row[strings.TrimSpace(column)] = strings.TrimSpace(line[i])
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// parseResources parses resources in the form "vcore=1000;memory=1024".
+func parseResources(value string) (map[string]int64, error) {
+	res := make(map[string]int64)
+	if value == "" {
+		return res, nil
+	}
+	for _, entry := range strings.Split(value, ";") {
+		name, quantity, found := strings.Cut(entry, "=")
+		name, quantity = strings.TrimSpace(name), strings.TrimSpace(quantity)
+		if !found || name == "" {
+			return nil, fmt.Errorf("invalid resource entry %q, expected name=quantity", entry)
+		}
+		q, err := strconv.ParseInt(quantity, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		res[name] = q
+	}
+	return res, nil
+}
+
+func parseInt(value string) (int64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+func isCSV(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".csv")
+}
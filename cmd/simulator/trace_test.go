@@ -0,0 +1,138 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.NilError(t, os.WriteFile(path, []byte(content), 0o600), "failed to write test file")
+	return path
+}
+
+func TestParseResources(t *testing.T) {
+	res, err := parseResources("vcore=1000; memory=2048")
+	assert.NilError(t, err, "valid resources should parse")
+	assert.DeepEqual(t, res, map[string]int64{"vcore": 1000, "memory": 2048})
+
+	res, err = parseResources("")
+	assert.NilError(t, err, "empty resources should parse")
+	assert.Equal(t, len(res), 0)
+
+	for _, value := range []string{"vcore", "=10", "vcore=ten"} {
+		_, err = parseResources(value)
+		assert.Assert(t, err != nil, "expected error for resources: %s", value)
+	}
+}
+
+func TestLoadNodes(t *testing.T) {
+	nodes, err := loadNodes(writeFile(t, "nodes.csv", "nodeID,resources\nnode-1,vcore=4000;memory=8192\nnode-2,vcore=2000\n"))
+	assert.NilError(t, err, "csv nodes should load")
+	assert.Equal(t, len(nodes), 2)
+	assert.Equal(t, nodes[0].NodeID, "node-1")
+	assert.DeepEqual(t, nodes[0].Resources, map[string]int64{"vcore": 4000, "memory": 8192})
+
+	nodes, err = loadNodes(writeFile(t, "nodes.json", `[{"nodeID":"node-1","resources":{"vcore":1000},"attributes":{"zone":"a"}}]`))
+	assert.NilError(t, err, "json nodes should load")
+	assert.Equal(t, len(nodes), 1)
+	assert.Equal(t, nodes[0].Attributes["zone"], "a")
+
+	_, err = loadNodes(writeFile(t, "nodes.json", `[{"resources":{"vcore":1000}}]`))
+	assert.ErrorContains(t, err, "node 0 in inventory has no ID")
+	_, err = loadNodes(writeFile(t, "nodes.json", `{"nodeID":"node-1"}`))
+	assert.ErrorContains(t, err, "failed to load node inventory")
+	_, err = loadNodes(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Assert(t, err != nil, "missing file should fail")
+}
+
+func TestLoadTrace(t *testing.T) {
+	csvTrace := strings.Join([]string{
+		"time,type,application,queue,user,key,duration,resources",
+		"20,complete,app-1,,,,,",
+		"0,submit,app-1,root.a,alice,ask-1,10,vcore=1000",
+		"5,,app-2,root.b,bob,,0,vcore=500;memory=100",
+	}, "\n")
+	records, err := loadTrace(writeFile(t, "trace.CSV", csvTrace))
+	assert.NilError(t, err, "csv trace should load")
+	assert.Equal(t, len(records), 3)
+	// sorted on time with the defaults filled in
+	assert.Equal(t, records[0].AllocationKey, "ask-1")
+	assert.Equal(t, records[0].Duration, int64(10))
+	assert.Equal(t, records[0].User, "alice")
+	assert.Equal(t, records[1].Type, RecordSubmit)
+	assert.Equal(t, records[1].AllocationKey, "app-2-2")
+	assert.DeepEqual(t, records[1].Resources, map[string]int64{"vcore": 500, "memory": 100})
+	assert.Equal(t, records[2].Type, RecordComplete)
+
+	records, err = loadTrace(writeFile(t, "trace.json", `[{"time":1,"application":"app-1","resources":{"vcore":1}}]`))
+	assert.NilError(t, err, "json trace should load")
+	assert.Equal(t, len(records), 1)
+	assert.Equal(t, records[0].AllocationKey, "app-1-0")
+
+	_, err = loadTrace(writeFile(t, "trace.csv", "time,application,resources\nsoon,app-1,vcore=1\n"))
+	assert.Assert(t, err != nil, "invalid time should fail")
+	_, err = loadTrace(writeFile(t, "trace.json", `[{"time":1,"application":"app-1"}]`))
+	assert.ErrorContains(t, err, "trace record 0 has no resources")
+}
+
+func TestValidateTrace(t *testing.T) {
+	res := map[string]int64{"vcore": 1}
+	tests := []struct {
+		name    string
+		records []*TraceRecord
+		err     string
+	}{
+		{"valid", []*TraceRecord{
+			{ApplicationID: "app-1", AllocationKey: "ask-1", Resources: res},
+			{ApplicationID: "app-1", Type: RecordComplete, Time: 10},
+		}, ""},
+		{"no application", []*TraceRecord{{Resources: res}}, "trace record 0 has no application"},
+		{"negative time", []*TraceRecord{{ApplicationID: "app-1", Time: -1, Resources: res}}, "negative time or duration"},
+		{"negative duration", []*TraceRecord{{ApplicationID: "app-1", Duration: -1, Resources: res}}, "negative time or duration"},
+		{"duplicate key", []*TraceRecord{
+			{ApplicationID: "app-1", AllocationKey: "ask-1", Resources: res},
+			{ApplicationID: "app-2", AllocationKey: "ask-1", Resources: res},
+		}, "trace record 1 has a duplicate key ask-1"},
+		{"no resources", []*TraceRecord{{ApplicationID: "app-1"}}, "trace record 0 has no resources"},
+		{"unknown type", []*TraceRecord{{ApplicationID: "app-1", Type: "kill"}}, "trace record 0 has an unknown type kill"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateTrace(tt.records)
+			if tt.err == "" {
+				assert.NilError(t, err, "trace should be valid")
+				return
+			}
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
+
+func readFile(t *testing.T, path string) []byte {
+	content, err := os.ReadFile(path)
+	assert.NilError(t, err, "failed to read test file")
+	return content
+}
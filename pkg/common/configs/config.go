@@ -52,6 +52,7 @@ type PartitionConfig struct {
 	Limits         []Limit                   `yaml:",omitempty" json:",omitempty"`
 	Preemption     PartitionPreemptionConfig `yaml:",omitempty" json:",omitempty"`
 	NodeSortPolicy NodeSortingPolicy         `yaml:",omitempty" json:",omitempty"`
+	Topology       TopologyConfig            `yaml:",omitempty" json:",omitempty"`
 }
 
 // The partition preemption configuration
@@ -67,6 +68,7 @@ type PartitionPreemptionConfig struct {
 // - ACL for submit and or admin access
 // - a list of sub or child queues
 // - a list of users specifying limits on a queue
+// - a list of time windows that change the resources and maximum applications of the queue
 type QueueConfig struct {
 	Name            string
 	Parent          bool              `yaml:",omitempty" json:",omitempty"`
@@ -78,6 +80,21 @@ type QueueConfig struct {
 	ChildTemplate   ChildTemplate     `yaml:",omitempty" json:",omitempty"`
 	Queues          []QueueConfig     `yaml:",omitempty" json:",omitempty"`
 	Limits          []Limit           `yaml:",omitempty" json:",omitempty"`
+	Schedules       []Schedule        `yaml:",omitempty" json:",omitempty"`
+}
+
+// The capacity schedule object for a queue:
+// - the name of the schedule, unique within the queue
+// - a cron expression for the start of the time window (minute hour day-of-month month day-of-week)
+// - the length of the time window as a duration
+// - the resources and maximum applications set on the queue while the window is active
+// Values that are not set in the schedule are taken from the queue. The first active schedule in the list is used.
+type Schedule struct {
+	Name            string
+	Start           string
+	Duration        string
+	Resources       Resources `yaml:",omitempty" json:",omitempty"`
+	MaxApplications uint64    `yaml:",omitempty" json:",omitempty"`
 }
 
 type ChildTemplate struct {
@@ -90,9 +107,11 @@ type ChildTemplate struct {
 // The mapping to "known" resources is not handled here.
 // - guaranteed resources
 // - max resources
+// - lendable resources: the part of the guaranteed resources other queues can borrow while unused
 type Resources struct {
 	Guaranteed map[string]string `yaml:",omitempty" json:",omitempty"`
 	Max        map[string]string `yaml:",omitempty" json:",omitempty"`
+	Lendable   map[string]string `yaml:",omitempty" json:",omitempty"`
 }
 
 // The queue placement rule definition
@@ -133,19 +152,44 @@ type Limits struct {
 // - list of groups (maybe empty)
 // - maximum resources as a resource object to allow for the user or group
 // - maximum number of applications the user or group can have running
+// - maximum number of pending asks the user or group can have
+// - maximum number of running allocations the user or group can have
+// - maximum number of applications the user or group can submit per minute
+// - guaranteed resources for the user or group, used in sorting and preemption inside the queue
 type Limit struct {
-	Limit           string
-	Users           []string          `yaml:",omitempty" json:",omitempty"`
-	Groups          []string          `yaml:",omitempty" json:",omitempty"`
-	MaxResources    map[string]string `yaml:",omitempty" json:",omitempty"`
-	MaxApplications uint64            `yaml:",omitempty" json:",omitempty"`
+	Limit                   string
+	Users                   []string          `yaml:",omitempty" json:",omitempty"`
+	Groups                  []string          `yaml:",omitempty" json:",omitempty"`
+	MaxResources            map[string]string `yaml:",omitempty" json:",omitempty"`
+	MaxApplications         uint64            `yaml:",omitempty" json:",omitempty"`
+	MaxPendingAsks          uint64            `yaml:",omitempty" json:",omitempty"`
+	MaxAllocations          uint64            `yaml:",omitempty" json:",omitempty"`
+	MaxSubmissionsPerMinute uint64            `yaml:",omitempty" json:",omitempty"`
+	GuaranteedResources     map[string]string `yaml:",omitempty" json:",omitempty"`
 }
 
 // Global Node Sorting Policy section
 // - type: different type of policies supported (binpacking, fair etc)
+// - scorers: weighted node scorers, replace the scoring of the type if set
 type NodeSortingPolicy struct {
 	Type            string
 	ResourceWeights map[string]float64 `yaml:",omitempty" json:",omitempty"`
+	Scorers         []NodeScorerConfig `yaml:",omitempty" json:",omitempty"`
+}
+
+// Node scorer: a registered scorer with its weight and arguments, weight defaults to 1
+type NodeScorerConfig struct {
+	Name   string
+	Weight float64           `yaml:",omitempty" json:",omitempty"`
+	Args   map[string]string `yaml:",omitempty" json:",omitempty"`
+}
+
+// Topology section: node attributes that define the zone and rack of a node
+// - zonekey: attribute with the zone of the node, defaults to topology.kubernetes.io/zone
+// - rackkey: attribute with the rack of the node, defaults to si/rackname
+type TopologyConfig struct {
+	ZoneKey string `yaml:",omitempty" json:",omitempty"`
+	RackKey string `yaml:",omitempty" json:",omitempty"`
 }
 
 func LoadSchedulerConfigFromByteArray(content []byte) (*SchedulerConfig, error) {
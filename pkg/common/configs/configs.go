@@ -27,11 +27,51 @@ import (
 
 const (
 	// prefixes
-	PrefixEvent  = "event."
-	PrefixHealth = "health."
+	PrefixAccounting = "accounting."
+	PrefixEvent      = "event."
+	PrefixFairShare  = "fairShare."
+	PrefixHealth     = "health."
+	PrefixScheduling = "scheduling."
+	PrefixSink       = "sink."
+	PrefixState      = "state."
+	PrefixWebservice = "webservice."
 
 	HealthCheckInterval = PrefixHealth + "checkInterval"
 
+	// maximum number of allocations per partition in one scheduling cycle
+	SchedulingBatchSize = PrefixScheduling + "batchSize"
+	// schedule each partition on its own routine
+	SchedulingParallelPartitions = PrefixScheduling + "parallelPartitions"
+
+	// state snapshot
+	StateSnapshotInterval = PrefixState + "snapshotInterval" // time between two snapshots, 0 only saves on stop
+	StateReconcileDelay   = PrefixState + "reconcileDelay"   // time the RM has to recover after registration
+
+	// chargeback accounting
+	AccountingEnabled         = PrefixAccounting + "enabled"         // record the usage of applications
+	AccountingCollectInterval = PrefixAccounting + "collectInterval" // time between two collections of the running usage
+	AccountingBillingPeriod   = PrefixAccounting + "billingPeriod"   // hour, day, week or month
+	AccountingRetainedPeriods = PrefixAccounting + "retainedPeriods" // number of billing periods kept
+	AccountingPrices          = PrefixAccounting + "prices"          // JSON price table: instance type to resource price per hour
+
+	// application record sinks
+	SinkQueueSize      = PrefixSink + "queueSize"       // records buffered per sink, new records are dropped when full
+	SinkBatchSize      = PrefixSink + "batchSize"       // maximum number of records in one write
+	SinkFlushInterval  = PrefixSink + "flushInterval"   // maximum time a record waits for a batch to fill
+	SinkMaxRetries     = PrefixSink + "maxRetries"      // retries of a failed write before the batch is dropped
+	SinkRetryInterval  = PrefixSink + "retryInterval"   // wait before the first retry, doubles on each retry
+	SinkFilePath       = PrefixSink + "file.path"       // JSON-lines file, no file sink if not set
+	SinkFileMaxSize    = PrefixSink + "file.maxSize"    // file size in bytes that triggers a rotation
+	SinkFileMaxBackups = PrefixSink + "file.maxBackups" // number of rotated files kept
+	SinkWebhookURL     = PrefixSink + "webhook.url"     // URL records are posted to, no webhook sink if not set
+	SinkWebhookTimeout = PrefixSink + "webhook.timeout" // timeout of one post
+
+	// fair share
+	FairShareHalfLife = PrefixFairShare + "halfLife" // half-life of the usage history used by fair sorting, 0 disables the history
+
+	// trust the caller identity set in the request headers by an authenticating proxy, admin requests are rejected if not set
+	WebserviceTrustIdentityHeaders = PrefixWebservice + "trustIdentityHeaders"
+
 	// events
 	CMEventTrackingEnabled    = PrefixEvent + "trackingEnabled"    // Application Tracking
 	CMEventRequestCapacity    = PrefixEvent + "requestCapacity"    // Request Capacity
@@ -41,14 +81,32 @@ const (
 	CMRESTResponseSize        = PrefixEvent + "RESTResponseSize"
 
 	// defaults
-	DefaultHealthCheckInterval     = 30 * time.Second
-	DefaultEventTrackingEnabled    = true
-	DefaultEventRequestCapacity    = 1000
-	DefaultEventRingBufferCapacity = 100000
-	DefaultEventChannelSize        = 100000
-	DefaultMaxStreams              = uint64(100)
-	DefaultMaxStreamsPerHost       = uint64(15)
-	DefaultRESTResponseSize        = uint64(10000)
+	DefaultHealthCheckInterval          = 30 * time.Second
+	DefaultSchedulingBatchSize          = 1
+	DefaultSchedulingParallelPartitions = false
+	DefaultStateSnapshotInterval        = time.Minute
+	DefaultStateReconcileDelay          = 2 * time.Minute
+	DefaultAccountingEnabled            = true
+	DefaultAccountingCollectInterval    = time.Minute
+	DefaultAccountingBillingPeriod      = "month"
+	DefaultAccountingRetainedPeriods    = uint64(12)
+	DefaultSinkQueueSize                = 10000
+	DefaultSinkBatchSize                = 100
+	DefaultSinkFlushInterval            = time.Second
+	DefaultSinkMaxRetries               = 3
+	DefaultSinkRetryInterval            = 500 * time.Millisecond
+	DefaultSinkFileMaxSize              = uint64(100 * 1024 * 1024)
+	DefaultSinkFileMaxBackups           = 5
+	DefaultSinkWebhookTimeout           = 10 * time.Second
+	DefaultFairShareHalfLife            = time.Duration(0)
+	DefaultTrustIdentityHeaders         = false
+	DefaultEventTrackingEnabled         = true
+	DefaultEventRequestCapacity         = 1000
+	DefaultEventRingBufferCapacity      = 100000
+	DefaultEventChannelSize             = 100000
+	DefaultMaxStreams                   = uint64(100)
+	DefaultMaxStreamsPerHost            = uint64(15)
+	DefaultRESTResponseSize             = uint64(10000)
 )
 
 var ConfigContext *SchedulerConfigContext
@@ -23,6 +23,7 @@ import (
 	"math"
 	"reflect"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -30,6 +31,7 @@ import (
 
 	"github.com/apache/yunikorn-core/pkg/common"
 	"github.com/apache/yunikorn-core/pkg/common/resources"
+	"github.com/apache/yunikorn-core/pkg/locking"
 	"github.com/apache/yunikorn-core/pkg/log"
 	"github.com/apache/yunikorn-core/pkg/scheduler/placement/types"
 	"github.com/apache/yunikorn-core/pkg/scheduler/policies"
@@ -47,6 +49,9 @@ const (
 	PriorityOffset          = "priority.offset"
 	PreemptionPolicy        = "preemption.policy"
 	PreemptionDelay         = "preemption.delay"
+	DRFResourceWeights      = "drf.resource.weights"
+	QueueWeight             = "weight"
+	ReclaimGracePeriod      = "reclaim.grace.period"
 
 	// app sort priority values
 	ApplicationSortPriorityEnabled  = "enabled"
@@ -67,6 +72,12 @@ var MaxPriority int32 = math.MaxInt32
 
 var DefaultPreemptionDelay = 30 * time.Second
 
+// DefaultReclaimGracePeriod is the time a borrower gets to return lent resources before they are reclaimed
+var DefaultReclaimGracePeriod = 30 * time.Second
+
+// DefaultQueueWeight is the share weight of a queue without the weight property
+var DefaultQueueWeight = 1.0
+
 // A queue can be a username with the dot replaced. Most systems allow a 32 character user name.
 // The queue name must thus allow for at least that length with the replacement of dots.
 var QueueNameRegExp = regexp.MustCompile(`^[a-zA-Z0-9_:#/@-]{1,64}$`)
@@ -85,6 +96,23 @@ var SpecialRegExp = regexp.MustCompile(`[\^$*+?()\[{}|]`)
 // The rule maps to a go identifier check that regexp only
 var RuleNameRegExp = regexp.MustCompile(`^[_a-zA-Z][a-zA-Z0-9_]*$`)
 
+// The node scorers are registered in the scheduler, the check is set from there
+var nodeScorerValidator func(name string, args map[string]string) error
+var nodeScorerValidatorLock locking.RWMutex
+
+// SetNodeScorerValidator registers the check for a configured node scorer name and its arguments.
+func SetNodeScorerValidator(validator func(name string, args map[string]string) error) {
+	nodeScorerValidatorLock.Lock()
+	defer nodeScorerValidatorLock.Unlock()
+	nodeScorerValidator = validator
+}
+
+func getNodeScorerValidator() func(name string, args map[string]string) error {
+	nodeScorerValidatorLock.RLock()
+	defer nodeScorerValidatorLock.RUnlock()
+	return nodeScorerValidator
+}
+
 type placementStaticPath struct {
 	path           string
 	ruleChain      string
@@ -310,6 +338,14 @@ func checkResourceConfig(cur QueueConfig) (*resources.Resource, *resources.Resou
 	if !m.FitInMaxUndef(g) {
 		return nil, nil, fmt.Errorf("guaranteed resource %s is larger than maximum resource %s for queue %s", g.String(), m.String(), cur.Name)
 	}
+	var l *resources.Resource
+	l, err = resources.NewResourceFromConf(cur.Resources.Lendable)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !g.FitIn(l) {
+		return nil, nil, fmt.Errorf("lendable resource %s is larger than guaranteed resource %s for queue %s", l.String(), g.String(), cur.Name)
+	}
 	return g, m, nil
 }
 
@@ -542,8 +578,25 @@ func checkLimit(limit Limit, existingUserName map[string]bool, existingGroupName
 			return fmt.Errorf("MaxResources should be greater than zero in '%s' limit", limit.Limit)
 		}
 	}
+	// check the guaranteed resource (if defined), must fit in the maximum
+	if len(limit.GuaranteedResources) != 0 {
+		var guaranteed *resources.Resource
+		guaranteed, err = resources.NewResourceFromConf(limit.GuaranteedResources)
+		if err != nil {
+			log.Log(log.Config).Debug("resource parsing failed",
+				zap.Error(err))
+			return err
+		}
+		if !resources.StrictlyGreaterThanZero(guaranteed) {
+			return fmt.Errorf("GuaranteedResources should be greater than zero in '%s' limit", limit.Limit)
+		}
+		if !limitResource.FitInMaxUndef(guaranteed) {
+			return fmt.Errorf("GuaranteedResources %s is greater than MaxResources %s in '%s' limit", guaranteed.String(), limitResource.String(), limit.Limit)
+		}
+	}
 	// at least some resource should be not null
-	if limit.MaxApplications == 0 && len(limit.MaxResources) == 0 {
+	if limit.MaxApplications == 0 && len(limit.MaxResources) == 0 && limit.MaxPendingAsks == 0 &&
+		limit.MaxAllocations == 0 && limit.MaxSubmissionsPerMinute == 0 && len(limit.GuaranteedResources) == 0 {
 		return fmt.Errorf("invalid resource combination for limit %s all resource limits are null", limit.Limit)
 	}
 
@@ -627,6 +680,36 @@ func checkNodeSortingPolicy(partition *PartitionConfig) error {
 		}
 	}
 
+	validator := getNodeScorerValidator()
+	names := make(map[string]bool, len(policy.Scorers))
+	for _, scorer := range policy.Scorers {
+		name := strings.ToLower(scorer.Name)
+		if name == "" {
+			return fmt.Errorf("node scorer name cannot be empty")
+		}
+		if names[name] {
+			return fmt.Errorf("duplicate node scorer %s", scorer.Name)
+		}
+		names[name] = true
+		if scorer.Weight < float64(0) {
+			return fmt.Errorf("negative weight for node scorer %s is not allowed", scorer.Name)
+		}
+		if validator != nil {
+			if err = validator(scorer.Name, scorer.Args); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Check the topology attribute keys: zone and rack must use different attributes
+func checkTopology(partition *PartitionConfig) error {
+	topology := partition.Topology
+	if topology.ZoneKey != "" && topology.ZoneKey == topology.RackKey {
+		return fmt.Errorf("topology zone and rack key cannot be the same attribute: %s", topology.ZoneKey)
+	}
 	return nil
 }
 
@@ -651,6 +734,22 @@ func checkQueues(queue *QueueConfig, level int) error {
 		return err
 	}
 
+	// check the sorting related properties for the queue and the child template
+	err = checkQueueProperties(queue.Name, queue.Properties)
+	if err != nil {
+		return err
+	}
+	err = checkQueueProperties(queue.Name, queue.ChildTemplate.Properties)
+	if err != nil {
+		return err
+	}
+
+	// check the capacity schedules (if defined)
+	err = checkSchedules(queue)
+	if err != nil {
+		return err
+	}
+
 	// check this level for name compliance and uniqueness
 	queueMap := make(map[string]bool)
 	for _, child := range queue.Queues {
@@ -675,6 +774,150 @@ func checkQueues(queue *QueueConfig, level int) error {
 	return nil
 }
 
+// Check the capacity schedules of the queue:
+// - not allowed on the root queue
+// - name is set and unique for the queue
+// - start is a valid cron expression and duration is positive and not longer than a week
+// - the resources that apply while the schedule is active are valid
+// The schedule resources are not checked against the hierarchy as the parent and children can change over time.
+func checkSchedules(queue *QueueConfig) error {
+	if len(queue.Schedules) == 0 {
+		return nil
+	}
+	if queue.Name == RootQueue {
+		return fmt.Errorf("capacity schedules cannot be set on the root queue")
+	}
+	names := make(map[string]bool)
+	for _, schedule := range queue.Schedules {
+		if schedule.Name == "" {
+			return fmt.Errorf("capacity schedule name cannot be empty for queue %s", queue.Name)
+		}
+		if names[schedule.Name] {
+			return fmt.Errorf("duplicate capacity schedule %s for queue %s", schedule.Name, queue.Name)
+		}
+		names[schedule.Name] = true
+		if _, err := common.ParseCron(schedule.Start); err != nil {
+			return fmt.Errorf("invalid start for capacity schedule %s of queue %s: %w", schedule.Name, queue.Name, err)
+		}
+		if _, err := ParseScheduleDuration(schedule.Duration); err != nil {
+			return fmt.Errorf("invalid duration for capacity schedule %s of queue %s: %w", schedule.Name, queue.Name, err)
+		}
+		effective := QueueConfig{Name: queue.Name, Resources: MergeScheduleResources(queue.Resources, schedule.Resources)}
+		if _, _, err := checkResourceConfig(effective); err != nil {
+			return fmt.Errorf("invalid resources for capacity schedule %s: %w", schedule.Name, err)
+		}
+	}
+	return nil
+}
+
+// ParseScheduleDuration parses the length of the time window of a capacity schedule.
+func ParseScheduleDuration(value string) (time.Duration, error) {
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if duration <= 0 || duration > common.MaxCronWindow {
+		return 0, fmt.Errorf("duration must be positive and at most %s: %s", common.MaxCronWindow, value)
+	}
+	return duration, nil
+}
+
+// MergeScheduleResources returns the resources of the queue with the max and guaranteed resources replaced by
+// the ones set in the schedule.
+func MergeScheduleResources(queue, schedule Resources) Resources {
+	merged := queue
+	if len(schedule.Max) != 0 {
+		merged.Max = schedule.Max
+	}
+	if len(schedule.Guaranteed) != 0 {
+		merged.Guaranteed = schedule.Guaranteed
+	}
+	return merged
+}
+
+// Check the sort policy, the DRF resource weights, the queue weight and the reclaim grace period set as queue properties.
+// An unknown sort policy does not fail the config: the queue falls back to the default policy.
+func checkQueueProperties(queueName string, properties map[string]string) error {
+	if value, ok := properties[ApplicationSortPolicy]; ok {
+		if _, err := policies.SortPolicyFromString(value); err != nil {
+			log.Log(log.Config).Warn("unknown application sort policy, queue uses the default policy",
+				zap.String("queue", queueName),
+				zap.String("policy", value))
+		}
+	}
+	if value, ok := properties[DRFResourceWeights]; ok {
+		if _, err := ParseDRFWeights(value); err != nil {
+			return fmt.Errorf("invalid %s for queue %s: %w", DRFResourceWeights, queueName, err)
+		}
+	}
+	if value, ok := properties[QueueWeight]; ok {
+		if _, err := ParseQueueWeight(value); err != nil {
+			return fmt.Errorf("invalid %s for queue %s: %w", QueueWeight, queueName, err)
+		}
+	}
+	if value, ok := properties[ReclaimGracePeriod]; ok {
+		if _, err := ParseReclaimGracePeriod(value); err != nil {
+			return fmt.Errorf("invalid %s for queue %s: %w", ReclaimGracePeriod, queueName, err)
+		}
+	}
+	return nil
+}
+
+// ParseReclaimGracePeriod parses the time a borrower gets before lent resources are reclaimed.
+// Zero reclaims immediately, the default grace period is returned on error.
+func ParseReclaimGracePeriod(value string) (time.Duration, error) {
+	period, err := time.ParseDuration(value)
+	if err != nil {
+		return DefaultReclaimGracePeriod, err
+	}
+	if period < 0 {
+		return DefaultReclaimGracePeriod, fmt.Errorf("grace period cannot be negative: %s", value)
+	}
+	return period, nil
+}
+
+// ParseQueueWeight parses the share weight of a queue relative to its siblings.
+// The weight must be a positive number, the default weight is returned on error.
+func ParseQueueWeight(value string) (float64, error) {
+	weight, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil {
+		return DefaultQueueWeight, fmt.Errorf("weight is not a number: %s", value)
+	}
+	if weight <= 0 || math.IsInf(weight, 0) || math.IsNaN(weight) {
+		return DefaultQueueWeight, fmt.Errorf("weight must be a positive number: %s", value)
+	}
+	return weight, nil
+}
+
+// ParseDRFWeights parses the weights per resource type used by the DRF sort policy.
+// The value is a comma separated list of resource=weight pairs, for example: vcore=1,memory=1,nvidia.com/gpu=4
+// A weight must be a non-negative number, a weight of 0 excludes the resource type from the dominant share.
+func ParseDRFWeights(value string) (map[string]float64, error) {
+	weights := make(map[string]float64)
+	if strings.TrimSpace(value) == "" {
+		return weights, nil
+	}
+	for _, pair := range strings.Split(value, ",") {
+		name, weight, found := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !found || name == "" {
+			return nil, fmt.Errorf("weight must be set as resource=weight: %s", pair)
+		}
+		if _, ok := weights[name]; ok {
+			return nil, fmt.Errorf("duplicate weight for resource %s", name)
+		}
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(weight), 64)
+		if err != nil {
+			return nil, fmt.Errorf("weight for resource %s is not a number: %s", name, weight)
+		}
+		if parsed < 0 || math.IsInf(parsed, 0) || math.IsNaN(parsed) {
+			return nil, fmt.Errorf("weight for resource %s must be a non-negative number: %s", name, weight)
+		}
+		weights[name] = parsed
+	}
+	return weights, nil
+}
+
 func IsQueueNameValid(queueName string) error {
 	if !QueueNameRegExp.MatchString(queueName) {
 		return common.InvalidQueueName
@@ -783,6 +1026,10 @@ func Validate(newConfig *SchedulerConfig) error {
 		if err != nil {
 			return err
 		}
+		err = checkTopology(&partition)
+		if err != nil {
+			return err
+		}
 
 		err = checkQueueMaxApplications(partition.Queues[0])
 		if err != nil {
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"gotest.tools/v3/assert"
 
@@ -131,6 +132,24 @@ func TestCheckResourceConfigurationsForQueue(t *testing.T) {
 				Guaranteed: higherResourceMap,
 			},
 		}, true},
+		{"Higher lendable than guaranteed resource", QueueConfig{
+			Resources: Resources{
+				Guaranteed: lowerResourceMap,
+				Lendable:   higherResourceMap,
+			},
+		}, true},
+		{"Lendable resource not guaranteed", QueueConfig{
+			Resources: Resources{
+				Guaranteed: undefinedVcoresResourceMap,
+				Lendable:   lowerResourceMap,
+			},
+		}, true},
+		{"Valid lendable resource", QueueConfig{
+			Resources: Resources{
+				Guaranteed: higherResourceMap,
+				Lendable:   lowerResourceMap,
+			},
+		}, false},
 		{"Valid configuration",
 			QueueConfig{
 				Resources: Resources{
@@ -1783,6 +1802,66 @@ func TestCheckLimits(t *testing.T) { //nolint:funlen
 			},
 			errMsg: "invalid resource combination",
 		},
+		{
+			name: "only count limits set",
+			config: QueueConfig{
+				Name: "parent",
+				Limits: []Limit{
+					{
+						Limit:                   "user-limit",
+						Users:                   []string{"test-user"},
+						MaxPendingAsks:          100,
+						MaxAllocations:          10,
+						MaxSubmissionsPerMinute: 5,
+					},
+				},
+			},
+			errMsg: "",
+		},
+		{
+			name: "only guaranteed resources set",
+			config: QueueConfig{
+				Name: "parent",
+				Limits: []Limit{
+					{
+						Limit:               "user-limit",
+						Users:               []string{"test-user"},
+						GuaranteedResources: map[string]string{"memory": "10"},
+					},
+				},
+			},
+			errMsg: "",
+		},
+		{
+			name: "guaranteed resources exceed max resources",
+			config: QueueConfig{
+				Name: "parent",
+				Limits: []Limit{
+					{
+						Limit:               "user-limit",
+						Users:               []string{"test-user"},
+						MaxResources:        map[string]string{"memory": "10"},
+						GuaranteedResources: map[string]string{"memory": "20"},
+					},
+				},
+			},
+			errMsg: "GuaranteedResources map[memory:20] is greater than MaxResources map[memory:10]",
+		},
+		{
+			name: "zero guaranteed resources",
+			config: QueueConfig{
+				Name: "parent",
+				Limits: []Limit{
+					{
+						Limit:               "user-limit",
+						Users:               []string{"test-user"},
+						MaxApplications:     1,
+						GuaranteedResources: map[string]string{"memory": "0"},
+					},
+				},
+			},
+			errMsg: "GuaranteedResources should be greater than zero",
+		},
 		{
 			name: "user maxresources exceed queue limits",
 			config: QueueConfig{
@@ -2327,6 +2406,147 @@ func TestCheckQueues(t *testing.T) { //nolint:funlen
 				assert.Equal(t, 2, len(q.Queues), "Expected two queues")
 			},
 		},
+		{
+			name: "DRF Sort Policy With Weights",
+			queue: &QueueConfig{
+				Name:       "root",
+				Properties: map[string]string{ApplicationSortPolicy: "drf", DRFResourceWeights: "vcore=1,nvidia.com/gpu=4"},
+				Queues:     []QueueConfig{{Name: "leaf"}},
+			},
+			level: 0,
+		},
+		{
+			name: "Unknown Sort Policy",
+			queue: &QueueConfig{
+				Name:       "root",
+				Properties: map[string]string{ApplicationSortPolicy: "unknown"},
+			},
+			level: 0,
+		},
+		{
+			name: "Invalid DRF Weights in Child Template",
+			queue: &QueueConfig{
+				Name:          "root",
+				ChildTemplate: ChildTemplate{Properties: map[string]string{DRFResourceWeights: "vcore=-1"}},
+			},
+			level:            0,
+			expectedErrorMsg: "invalid drf.resource.weights for queue root",
+		},
+		{
+			name: "Invalid Queue Weight",
+			queue: &QueueConfig{
+				Name:   "root",
+				Queues: []QueueConfig{{Name: "leaf", Properties: map[string]string{QueueWeight: "0"}}},
+			},
+			level:            0,
+			expectedErrorMsg: "invalid weight for queue leaf",
+		},
+		{
+			name: "Invalid Queue Weight in Child Template",
+			queue: &QueueConfig{
+				Name:          "root",
+				ChildTemplate: ChildTemplate{Properties: map[string]string{QueueWeight: "heavy"}},
+			},
+			level:            0,
+			expectedErrorMsg: "invalid weight for queue root",
+		},
+		{
+			name: "Invalid Reclaim Grace Period",
+			queue: &QueueConfig{
+				Name: "root",
+				Queues: []QueueConfig{{
+					Name:       "lender",
+					Properties: map[string]string{ReclaimGracePeriod: "-5s"},
+				}},
+			},
+			level:            0,
+			expectedErrorMsg: "invalid reclaim.grace.period for queue lender",
+		},
+		{
+			name: "Valid Capacity Schedules",
+			queue: &QueueConfig{
+				Name: "root",
+				Queues: []QueueConfig{{
+					Name:      "batch",
+					Resources: Resources{Max: map[string]string{"memory": "100"}},
+					Schedules: []Schedule{
+						{Name: "night", Start: "0 20 * * *", Duration: "10h", Resources: Resources{Max: map[string]string{"memory": "500"}}},
+						{Name: "weekend", Start: "0 0 * * 6", Duration: "48h", MaxApplications: 10},
+					},
+				}},
+			},
+			level: 0,
+		},
+		{
+			name: "Capacity Schedule On Root",
+			queue: &QueueConfig{
+				Name:      "root",
+				Schedules: []Schedule{{Name: "night", Start: "0 20 * * *", Duration: "10h"}},
+			},
+			level:            0,
+			expectedErrorMsg: "capacity schedules cannot be set on the root queue",
+		},
+		{
+			name: "Capacity Schedule Without Name",
+			queue: &QueueConfig{
+				Name:   "root",
+				Queues: []QueueConfig{{Name: "batch", Schedules: []Schedule{{Start: "0 20 * * *", Duration: "10h"}}}},
+			},
+			level:            0,
+			expectedErrorMsg: "capacity schedule name cannot be empty for queue batch",
+		},
+		{
+			name: "Duplicate Capacity Schedule",
+			queue: &QueueConfig{
+				Name: "root",
+				Queues: []QueueConfig{{Name: "batch", Schedules: []Schedule{
+					{Name: "night", Start: "0 20 * * *", Duration: "10h"},
+					{Name: "night", Start: "0 22 * * *", Duration: "1h"},
+				}}},
+			},
+			level:            0,
+			expectedErrorMsg: "duplicate capacity schedule night for queue batch",
+		},
+		{
+			name: "Capacity Schedule Invalid Start",
+			queue: &QueueConfig{
+				Name:   "root",
+				Queues: []QueueConfig{{Name: "batch", Schedules: []Schedule{{Name: "night", Start: "0 25 * * *", Duration: "10h"}}}},
+			},
+			level:            0,
+			expectedErrorMsg: "invalid start for capacity schedule night of queue batch",
+		},
+		{
+			name: "Capacity Schedule Invalid Duration",
+			queue: &QueueConfig{
+				Name:   "root",
+				Queues: []QueueConfig{{Name: "batch", Schedules: []Schedule{{Name: "night", Start: "0 20 * * *", Duration: "200h"}}}},
+			},
+			level:            0,
+			expectedErrorMsg: "invalid duration for capacity schedule night of queue batch",
+		},
+		{
+			name: "Capacity Schedule Guaranteed Larger Than Max",
+			queue: &QueueConfig{
+				Name: "root",
+				Queues: []QueueConfig{{
+					Name:      "batch",
+					Resources: Resources{Max: map[string]string{"memory": "100"}},
+					Schedules: []Schedule{{Name: "night", Start: "0 20 * * *", Duration: "10h", Resources: Resources{Guaranteed: map[string]string{"memory": "200"}}}},
+				}},
+			},
+			level:            0,
+			expectedErrorMsg: "invalid resources for capacity schedule night",
+		},
+		{
+			name: "Invalid DRF Weights in Child Queue",
+			queue: &QueueConfig{
+				Name:   "root",
+				Queues: []QueueConfig{{Name: "leaf", Properties: map[string]string{DRFResourceWeights: "vcore"}}},
+			},
+			level:            0,
+			expectedErrorMsg: "invalid drf.resource.weights for queue leaf",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -2345,6 +2565,50 @@ func TestCheckQueues(t *testing.T) { //nolint:funlen
 	}
 }
 
+func TestParseDRFWeights(t *testing.T) {
+	weights, err := ParseDRFWeights("")
+	assert.NilError(t, err, "empty value should not fail")
+	assert.Equal(t, len(weights), 0, "empty value should not set weights")
+	weights, err = ParseDRFWeights("vcore=1, memory = 0.5,nvidia.com/gpu=4,pods=0")
+	assert.NilError(t, err, "valid weights should not fail")
+	assert.DeepEqual(t, weights, map[string]float64{"vcore": 1, "memory": 0.5, "nvidia.com/gpu": 4, "pods": 0})
+
+	for _, value := range []string{"vcore", "=1", "vcore=a", "vcore=-1", "vcore=1,vcore=2", "vcore=Inf", "vcore=NaN", "vcore=1,"} {
+		_, err = ParseDRFWeights(value)
+		assert.Assert(t, err != nil, "expected error for weights: %s", value)
+	}
+}
+
+func TestParseReclaimGracePeriod(t *testing.T) {
+	period, err := ParseReclaimGracePeriod("2m")
+	assert.NilError(t, err, "valid grace period should not fail")
+	assert.Equal(t, period, 2*time.Minute)
+	period, err = ParseReclaimGracePeriod("0s")
+	assert.NilError(t, err, "zero grace period should not fail")
+	assert.Equal(t, period, time.Duration(0))
+
+	for _, value := range []string{"", "soon", "-1s"} {
+		period, err = ParseReclaimGracePeriod(value)
+		assert.Assert(t, err != nil, "expected error for grace period: %s", value)
+		assert.Equal(t, period, DefaultReclaimGracePeriod, "expected default grace period for: %s", value)
+	}
+}
+
+func TestParseQueueWeight(t *testing.T) {
+	weight, err := ParseQueueWeight("2.5")
+	assert.NilError(t, err, "valid weight should not fail")
+	assert.Equal(t, weight, 2.5)
+	weight, err = ParseQueueWeight(" 3 ")
+	assert.NilError(t, err, "valid weight should not fail")
+	assert.Equal(t, weight, 3.0)
+
+	for _, value := range []string{"", "heavy", "0", "-1", "Inf", "NaN"} {
+		weight, err = ParseQueueWeight(value)
+		assert.Assert(t, err != nil, "expected error for weight: %s", value)
+		assert.Equal(t, weight, DefaultQueueWeight, "expected default weight for: %s", value)
+	}
+}
+
 func TestCheckNodeSortingPolicy(t *testing.T) { //nolint:funlen
 	testCases := []struct {
 		name             string
@@ -2385,6 +2649,50 @@ func TestCheckNodeSortingPolicy(t *testing.T) { //nolint:funlen
 			},
 			expectedErrorMsg: "undefined policy: undefinedPolicy",
 		},
+		{
+			name: "Valid Scorers",
+			partition: &PartitionConfig{
+				NodeSortPolicy: NodeSortingPolicy{
+					Scorers: []NodeScorerConfig{{Name: "leastallocated", Weight: 2}, {Name: "instancetype", Args: map[string]string{"types": "m5"}}},
+				},
+			},
+		},
+		{
+			name: "Scorer Without Name",
+			partition: &PartitionConfig{
+				NodeSortPolicy: NodeSortingPolicy{
+					Scorers: []NodeScorerConfig{{Weight: 1}},
+				},
+			},
+			expectedErrorMsg: "node scorer name cannot be empty",
+		},
+		{
+			name: "Duplicate Scorer",
+			partition: &PartitionConfig{
+				NodeSortPolicy: NodeSortingPolicy{
+					Scorers: []NodeScorerConfig{{Name: "mostallocated"}, {Name: "MostAllocated"}},
+				},
+			},
+			expectedErrorMsg: "duplicate node scorer MostAllocated",
+		},
+		{
+			name: "Negative Scorer Weight",
+			partition: &PartitionConfig{
+				NodeSortPolicy: NodeSortingPolicy{
+					Scorers: []NodeScorerConfig{{Name: "mostallocated", Weight: -1}},
+				},
+			},
+			expectedErrorMsg: "negative weight for node scorer mostallocated is not allowed",
+		},
+		{
+			name: "Rejected Scorer",
+			partition: &PartitionConfig{
+				NodeSortPolicy: NodeSortingPolicy{
+					Scorers: []NodeScorerConfig{{Name: "mostallocated"}, {Name: "unknown"}},
+				},
+			},
+			expectedErrorMsg: "unknown node scorer unknown",
+		},
 		{
 			name: "Valid Policy with Multiple Resources",
 			partition: &PartitionConfig{
@@ -2400,6 +2708,13 @@ func TestCheckNodeSortingPolicy(t *testing.T) { //nolint:funlen
 		},
 	}
 
+	SetNodeScorerValidator(func(name string, _ map[string]string) error {
+		if name == "unknown" {
+			return fmt.Errorf("unknown node scorer %s", name)
+		}
+		return nil
+	})
+	defer SetNodeScorerValidator(nil)
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			err := checkNodeSortingPolicy(tc.partition)
@@ -2416,6 +2731,28 @@ func TestCheckNodeSortingPolicy(t *testing.T) { //nolint:funlen
 	}
 }
 
+func TestCheckTopology(t *testing.T) {
+	testCases := []struct {
+		name             string
+		topology         TopologyConfig
+		expectedErrorMsg string
+	}{
+		{"Defaults", TopologyConfig{}, ""},
+		{"Custom Keys", TopologyConfig{ZoneKey: "zone", RackKey: "rack"}, ""},
+		{"Same Keys", TopologyConfig{ZoneKey: "zone", RackKey: "zone"}, "topology zone and rack key cannot be the same attribute: zone"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkTopology(&PartitionConfig{Topology: tc.topology})
+			if tc.expectedErrorMsg != "" {
+				assert.ErrorContains(t, err, tc.expectedErrorMsg, "Error message mismatch")
+			} else {
+				assert.NilError(t, err, "No error is expected")
+			}
+		})
+	}
+}
+
 func TestIsQueueNameValid(t *testing.T) {
 	assert.NilError(t, IsQueueNameValid("parent_Child_test-a_b_#_c_#_d_/_e@dom:ain"))
 	err := IsQueueNameValid("invalid!queue")
@@ -0,0 +1,203 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package common
+
+import (
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxCronWindow is the longest time window that can be attached to a cron expression.
+const MaxCronWindow = 7 * 24 * time.Hour
+
+// cronField describes the allowed values for one field of a cron expression
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = [...]cronField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// CronSchedule is a parsed cron expression with the five standard fields:
+// minute, hour, day of month, month and day of week (0 and 7 are Sunday).
+// Each field supports a wildcard, single values, ranges, lists and steps: "*", "5", "1-5", "1,3,5", "*/15", "8-18/2".
+// Like cron, a time matches the days if either the day of month or the day of week matches when both are restricted.
+// A field starting with "*", like "*/2", is not restricted: both fields must then match.
+type CronSchedule struct {
+	spec   string
+	fields [5]uint64 // bit set of the allowed values per field
+	anyDom bool
+	anyDow bool
+}
+
+// ParseCron parses a cron expression with five fields separated by white space.
+func ParseCron(spec string) (*CronSchedule, error) {
+	parts := strings.Fields(spec)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("cron expression must have %d fields: %q", len(cronFields), spec)
+	}
+	cs := &CronSchedule{spec: spec}
+	for i, part := range parts {
+		bits, err := parseCronField(part, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("cron expression %q: %w", spec, err)
+		}
+		cs.fields[i] = bits
+	}
+	// Sunday can be set as 0 or 7
+	if cs.fields[4]&(1<<7) != 0 {
+		cs.fields[4] |= 1
+	}
+	cs.anyDom = strings.HasPrefix(parts[2], "*")
+	cs.anyDow = strings.HasPrefix(parts[4], "*")
+	return cs, nil
+}
+
+func parseCronField(value string, field cronField) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(value, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step in %s field: %s", field.name, item)
+			}
+		}
+		start, end := field.min, field.max
+		if rangePart != "*" {
+			low, high, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if start, err = strconv.Atoi(low); err != nil {
+				return 0, fmt.Errorf("invalid value in %s field: %s", field.name, item)
+			}
+			end = start
+			if isRange {
+				if end, err = strconv.Atoi(high); err != nil {
+					return 0, fmt.Errorf("invalid value in %s field: %s", field.name, item)
+				}
+			} else if hasStep {
+				// "5/15" means starting at 5 until the end of the range
+				end = field.max
+			}
+		}
+		if start < field.min || end > field.max || start > end {
+			return 0, fmt.Errorf("value out of range [%d-%d] in %s field: %s", field.min, field.max, field.name, item)
+		}
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// String returns the cron expression as it was parsed.
+func (cs *CronSchedule) String() string {
+	return cs.spec
+}
+
+// Matches returns true if the minute of the time matches the cron expression.
+func (cs *CronSchedule) Matches(t time.Time) bool {
+	return cs.has(0, t.Minute()) && cs.has(1, t.Hour()) && cs.has(3, int(t.Month())) && cs.matchesDay(t)
+}
+
+func (cs *CronSchedule) matchesDay(t time.Time) bool {
+	domMatch := cs.has(2, t.Day())
+	dowMatch := cs.has(4, int(t.Weekday()))
+	if cs.anyDom || cs.anyDow {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+func (cs *CronSchedule) has(field, value int) bool {
+	return cs.fields[field]&(1<<uint(value)) != 0
+}
+
+// lower returns the highest allowed value of the field that is below the value.
+func (cs *CronSchedule) lower(field, value int) (int, bool) {
+	allowed := cs.fields[field] & (1<<uint(value) - 1)
+	if allowed == 0 {
+		return 0, false
+	}
+	return bits.Len64(allowed) - 1, true
+}
+
+// ActiveWindow returns the start of the window of the given length that contains the time.
+// A window starts at each time the cron expression matches. If windows overlap the latest start is returned.
+// Returns false if the time is not inside a window, or if the window length is not positive or larger than MaxCronWindow.
+func (cs *CronSchedule) ActiveWindow(now time.Time, window time.Duration) (time.Time, bool) {
+	if window <= 0 || window > MaxCronWindow {
+		return time.Time{}, false
+	}
+	start := cs.previous(now.Truncate(time.Minute), now.Add(-window))
+	return start, !start.IsZero()
+}
+
+// previous returns the latest time at or before t that matches the cron expression and is after oldest.
+// Each field that does not match moves the time back to the last minute of the previous allowed value, which skips
+// the non-matching months, days and hours as a whole. Returns the zero time if there is no match.
+func (cs *CronSchedule) previous(t, oldest time.Time) time.Time {
+	loc := t.Location()
+	for t.After(oldest) {
+		year, month, day := t.Date()
+		hour, minute := t.Hour(), t.Minute()
+		switch {
+		case !cs.has(3, int(month)):
+			t = time.Date(year, month, 1, 0, 0, 0, 0, loc).Add(-time.Minute)
+		case !cs.matchesDay(t):
+			t = time.Date(year, month, day, 0, 0, 0, 0, loc).Add(-time.Minute)
+		case !cs.has(1, hour):
+			t = cs.previousHour(t, loc)
+		case !cs.has(0, minute):
+			// step back within the hour: a repeated wall clock hour must not resolve to its first occurrence
+			prev, ok := cs.lower(0, minute)
+			if !ok {
+				prev = -1
+			}
+			t = t.Add(-time.Duration(minute-prev) * time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// previousHour moves the time back to the last minute of the closest allowed hour of the same day, or to the last
+// minute before the current hour if that wall clock time does not exist or is not earlier (daylight saving change).
+func (cs *CronSchedule) previousHour(t time.Time, loc *time.Location) time.Time {
+	year, month, day := t.Date()
+	hour := t.Hour()
+	if prev, ok := cs.lower(1, hour); ok {
+		if candidate := time.Date(year, month, day, prev, 59, 0, 0, loc); candidate.Before(t) {
+			return candidate
+		}
+	}
+	return t.Add(-time.Duration(t.Minute()+1) * time.Minute)
+}
@@ -0,0 +1,136 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package common
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestParseCron(t *testing.T) {
+	for _, spec := range []string{"* * * * *", "0 8 * * 1-5", "*/15 0-6,22-23 1,15 * 0", "5/10 * * 1-12/3 7"} {
+		cs, err := ParseCron(spec)
+		assert.NilError(t, err, "valid expression failed: %s", spec)
+		assert.Equal(t, cs.String(), spec)
+	}
+	for _, spec := range []string{"", "* * * *", "* * * * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *",
+		"* * * * 8", "5-1 * * * *", "*/0 * * * *", "a * * * *", "1-a * * * *", "*/x * * * *"} {
+		_, err := ParseCron(spec)
+		assert.Assert(t, err != nil, "expected error for expression: %q", spec)
+	}
+}
+
+func TestCronMatches(t *testing.T) {
+	// 2024-01-01 is a Monday
+	monday := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	cs, err := ParseCron("0 8 * * 1-5")
+	assert.NilError(t, err)
+	assert.Assert(t, cs.Matches(monday), "office hours start should match")
+	assert.Assert(t, cs.Matches(monday.Add(30*time.Second)), "seconds should be ignored")
+	assert.Assert(t, !cs.Matches(monday.Add(time.Minute)), "next minute should not match")
+	assert.Assert(t, !cs.Matches(monday.AddDate(0, 0, 5)), "saturday should not match")
+
+	// sunday as 7, and day of month or day of week when both are set
+	cs, err = ParseCron("*/20 * 15 * 7")
+	assert.NilError(t, err)
+	assert.Assert(t, cs.Matches(monday.AddDate(0, 0, 6)), "sunday should match")
+	assert.Assert(t, cs.Matches(monday.AddDate(0, 0, 14).Add(40*time.Minute)), "day of month should match")
+	assert.Assert(t, !cs.Matches(monday.AddDate(0, 0, 14).Add(30*time.Minute)), "minute step should not match")
+	assert.Assert(t, !cs.Matches(monday), "monday the 1st should not match")
+
+	// a stepped wildcard does not restrict the days: both fields must match
+	cs, err = ParseCron("0 9 */2 * 1")
+	assert.NilError(t, err)
+	assert.Assert(t, cs.matchesDay(monday), "monday the 1st should match")
+	assert.Assert(t, !cs.matchesDay(monday.AddDate(0, 0, 7)), "monday the 8th should not match")
+	assert.Assert(t, !cs.matchesDay(monday.AddDate(0, 0, 2)), "wednesday the 3rd should not match")
+}
+
+func TestCronActiveWindow(t *testing.T) {
+	// window from 20:00 until 06:00 on each day
+	cs, err := ParseCron("0 20 * * *")
+	assert.NilError(t, err)
+	start := time.Date(2024, 1, 1, 20, 0, 0, 0, time.UTC)
+	window := 10 * time.Hour
+
+	got, active := cs.ActiveWindow(start, window)
+	assert.Assert(t, active, "window should be active at the start")
+	assert.Equal(t, got, start)
+	got, active = cs.ActiveWindow(start.Add(5*time.Hour+30*time.Second), window)
+	assert.Assert(t, active, "window should be active after midnight")
+	assert.Equal(t, got, start)
+	_, active = cs.ActiveWindow(start.Add(window), window)
+	assert.Assert(t, !active, "window should end after the duration")
+	_, active = cs.ActiveWindow(start.Add(-time.Minute), window)
+	assert.Assert(t, !active, "window should not be active before the start")
+
+	_, active = cs.ActiveWindow(start, 0)
+	assert.Assert(t, !active, "zero window should never be active")
+	_, active = cs.ActiveWindow(start, MaxCronWindow+time.Minute)
+	assert.Assert(t, !active, "window larger than the max should never be active")
+}
+
+func TestCronActiveWindowSearch(t *testing.T) {
+	// reference: check each minute going back from the time
+	scan := func(cs *CronSchedule, now time.Time, window time.Duration) (time.Time, bool) {
+		for start := now.Truncate(time.Minute); start.After(now.Add(-window)); start = start.Add(-time.Minute) {
+			if cs.Matches(start) {
+				return start, true
+			}
+		}
+		return time.Time{}, false
+	}
+	specs := []string{"0 20 * * *", "*/15 0-6,22-23 1,15 * 0", "5/10 * * 1-12/3 7", "30 9 29 2 *", "0 0 1 * 1-5", "59 23 31 12 *", "* * * * *"}
+	// 2024-02-28 is a Wednesday, the times cross a leap day, month and year ends
+	times := []time.Time{
+		time.Date(2024, 2, 29, 9, 31, 15, 0, time.UTC),
+		time.Date(2024, 3, 1, 0, 10, 0, 0, time.UTC),
+		time.Date(2025, 1, 1, 6, 0, 0, 0, time.UTC),
+		time.Date(2024, 12, 15, 23, 59, 59, 0, time.UTC),
+	}
+	windows := []time.Duration{time.Minute, 90 * time.Minute, 26 * time.Hour, MaxCronWindow}
+	for _, spec := range specs {
+		cs, err := ParseCron(spec)
+		assert.NilError(t, err)
+		for _, now := range times {
+			for _, window := range windows {
+				want, wantActive := scan(cs, now, window)
+				got, active := cs.ActiveWindow(now, window)
+				assert.Equal(t, active, wantActive, "%s at %s for %s", spec, now, window)
+				assert.Equal(t, got, want, "%s at %s for %s", spec, now, window)
+			}
+		}
+	}
+
+	// daylight saving changes: 02:00-03:00 is skipped in March and repeated in October
+	loc, err := time.LoadLocation("Europe/Amsterdam")
+	if err != nil {
+		return
+	}
+	cs, err := ParseCron("30 1,2 * * *")
+	assert.NilError(t, err)
+	for _, now := range []time.Time{time.Date(2024, 3, 31, 3, 10, 0, 0, loc), time.Date(2024, 10, 27, 2, 0, 0, 0, loc).Add(time.Hour + 45*time.Minute)} {
+		want, wantActive := scan(cs, now, 3*time.Hour)
+		got, active := cs.ActiveWindow(now, 3*time.Hour)
+		assert.Equal(t, active, wantActive, "daylight saving at %s", now)
+		assert.Assert(t, got.Equal(want), "daylight saving at %s: got %s want %s", now, got, want)
+	}
+}
@@ -598,6 +598,51 @@ func CompUsageRatio(left, right, total *Resource) int {
 	return compareShares(lshares, rshares)
 }
 
+// DominantShare returns the largest weighted share of the resource in the total, as used by dominant
+// resource fairness. A resource type without a weight has a weight of 1, a weight of 0 ignores the type.
+// The share is the usage if the total is nil or zero for the resource type, like for the other share calculations.
+func DominantShare(res, total *Resource, weights map[string]float64) float64 {
+	if res == nil || len(res.Resources) == 0 {
+		return 0
+	}
+	var dominant float64
+	for k, v := range res.Resources {
+		if v == 0 {
+			continue
+		}
+		weight := 1.0
+		if w, ok := weights[k]; ok {
+			weight = w
+		}
+		share := float64(v)
+		if total != nil && total.Resources[k] != 0 {
+			share /= float64(total.Resources[k])
+		}
+		if share*weight > dominant {
+			dominant = share * weight
+		}
+	}
+	return dominant
+}
+
+// CompDominantShare compares the weighted dominant share of left and right in the total.
+// This returns the same value as compareShares does:
+// 0 for equal shares
+// 1 if the left share is larger
+// -1 if the right share is larger
+func CompDominantShare(left, right, total *Resource, weights map[string]float64) int {
+	lshare := DominantShare(left, total, weights)
+	rshare := DominantShare(right, total, weights)
+	switch {
+	case lshare > rshare:
+		return 1
+	case lshare < rshare:
+		return -1
+	default:
+		return 0
+	}
+}
+
 // Calculate share for left of total and right of total separately.
 // This returns the same value as compareShares does:
 // 0 for equal shares
@@ -1659,6 +1659,48 @@ func TestCompUsageRatio(t *testing.T) {
 	}
 }
 
+func TestDominantShare(t *testing.T) {
+	total := NewResourceFromMap(map[string]Quantity{"vcore": 100, "memory": 1000, "gpu": 10})
+	tests := []struct {
+		res      *Resource
+		total    *Resource
+		weights  map[string]float64
+		expected float64
+		message  string
+	}{
+		{nil, total, nil, 0, "nil resource"},
+		{NewResource(), total, nil, 0, "empty resource"},
+		{NewResourceFromMap(map[string]Quantity{"vcore": 10, "memory": 500}), total, nil, 0.5, "memory dominant"},
+		{NewResourceFromMap(map[string]Quantity{"vcore": 10, "gpu": 2}), total, nil, 0.2, "gpu dominant"},
+		{NewResourceFromMap(map[string]Quantity{"vcore": 10, "gpu": 2}), total, map[string]float64{"vcore": 4}, 0.4, "weighted vcore dominant"},
+		{NewResourceFromMap(map[string]Quantity{"vcore": 10, "gpu": 2}), total, map[string]float64{"gpu": 0}, 0.1, "gpu ignored"},
+		{NewResourceFromMap(map[string]Quantity{"other": 3}), total, nil, 3, "type not in total"},
+		{NewResourceFromMap(map[string]Quantity{"vcore": 10}), nil, nil, 10, "nil total"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.message, func(t *testing.T) {
+			assert.Equal(t, DominantShare(tc.res, tc.total, tc.weights), tc.expected)
+		})
+	}
+}
+
+func TestCompDominantShare(t *testing.T) {
+	total := NewResourceFromMap(map[string]Quantity{"vcore": 100, "memory": 1000, "gpu": 10})
+	cpuHeavy := NewResourceFromMap(map[string]Quantity{"vcore": 40, "memory": 100})
+	gpuHeavy := NewResourceFromMap(map[string]Quantity{"vcore": 10, "memory": 100, "gpu": 3})
+	// lexicographic comparison and DRF agree on the largest share
+	assert.Equal(t, CompDominantShare(cpuHeavy, gpuHeavy, total, nil), 1)
+	assert.Equal(t, CompDominantShare(gpuHeavy, cpuHeavy, total, nil), -1)
+	// a gpu is worth more than a vcore
+	assert.Equal(t, CompDominantShare(cpuHeavy, gpuHeavy, total, map[string]float64{"gpu": 2}), -1)
+	// only the dominant share is compared, other resources are not considered
+	left := NewResourceFromMap(map[string]Quantity{"vcore": 50, "memory": 100})
+	right := NewResourceFromMap(map[string]Quantity{"vcore": 50, "memory": 400})
+	assert.Equal(t, CompDominantShare(left, right, total, nil), 0)
+	assert.Equal(t, CompUsageRatio(left, right, total), -1)
+	assert.Equal(t, CompDominantShare(nil, nil, total, nil), 0)
+}
+
 func TestCompareShares(t *testing.T) {
 	tests := []struct {
 		left     []float64
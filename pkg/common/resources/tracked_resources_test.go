@@ -217,6 +217,14 @@ func TestTrackedResourceAggregateTrackedResource(t *testing.T) {
 	assert.Assert(t, tr.TrackedResourceMap != nil && len(tr.TrackedResourceMap) == 0)
 }
 
+func TestTrackedResourceAggregateTrackedResourceAt(t *testing.T) {
+	bindTime := time.Unix(1700000000, 0)
+	tr := NewTrackedResource()
+	tr.AggregateTrackedResourceAt("instanceType1", NewResourceFromMap(map[string]Quantity{"first": 2}), bindTime, bindTime.Add(90*time.Second))
+	tr.AggregateTrackedResourceAt("instanceType1", nil, bindTime, bindTime.Add(time.Hour))
+	assert.Assert(t, tr.EqualsDAO(map[string]map[string]int64{"instanceType1": {"first": 180}}), "unexpected usage: %s", tr)
+}
+
 func TestEqualsTracked(t *testing.T) {
 	var tests = []struct {
 		caseName string
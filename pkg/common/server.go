@@ -58,7 +58,12 @@ type nonBlockingGRPCServer struct {
 func (s *nonBlockingGRPCServer) Start(endpoint string, ss si.SchedulerServer) {
 	s.wg.Add(1)
 
-	go s.serve(endpoint, ss)
+	// create the server before returning: a stop directly after the start must not fail
+	s.server = grpc.NewServer(withServerUnaryInterceptor())
+	if ss != nil {
+		si.RegisterSchedulerServer(s.server, ss)
+	}
+	go s.serve(endpoint)
 }
 
 func (s *nonBlockingGRPCServer) Wait() {
@@ -104,7 +109,8 @@ func withServerUnaryInterceptor() grpc.ServerOption {
 	return grpc.UnaryInterceptor(logGRPC)
 }
 
-func (s *nonBlockingGRPCServer) serve(endpoint string, ss si.SchedulerServer) {
+func (s *nonBlockingGRPCServer) serve(endpoint string) {
+	defer s.wg.Done()
 	proto, addr, err := ParseEndpoint(endpoint)
 	if err != nil {
 		log.Log(log.RPC).Fatal("fatal error", zap.Error(err))
@@ -126,17 +132,10 @@ func (s *nonBlockingGRPCServer) serve(endpoint string, ss si.SchedulerServer) {
 			zap.Error(err))
 	}
 
-	server := grpc.NewServer(withServerUnaryInterceptor())
-	s.server = server
-
-	if ss != nil {
-		si.RegisterSchedulerServer(server, ss)
-	}
-
 	log.Log(log.RPC).Info("listening for connections",
 		zap.Stringer("address", listener.Addr()))
 
-	if err = server.Serve(listener); err != nil {
+	if err = s.server.Serve(listener); err != nil {
 		log.Log(log.RPC).Fatal("failed to serve", zap.Error(err))
 	}
 }
@@ -277,3 +277,19 @@ func GetConfigurationInt(configs map[string]string, key string, defaultValue int
 	}
 	return int(intVal)
 }
+
+func GetConfigurationDuration(configs map[string]string, key string, defaultValue time.Duration) time.Duration {
+	value, ok := configs[key]
+	if !ok {
+		return defaultValue
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Log(log.Events).Warn("Failed to parse configuration value",
+			zap.String("key", key),
+			zap.String("value", value),
+			zap.Error(err))
+		return defaultValue
+	}
+	return duration
+}
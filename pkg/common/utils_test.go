@@ -383,6 +383,46 @@ func TestGetConfigurationInt(t *testing.T) {
 	}
 }
 
+func TestGetConfigurationDuration(t *testing.T) {
+	testCases := []struct {
+		name          string
+		configs       map[string]string
+		defaultValue  time.Duration
+		expectedValue time.Duration
+	}{
+		{
+			name:          "configs is nil",
+			configs:       nil,
+			defaultValue:  time.Second,
+			expectedValue: time.Second,
+		},
+		{
+			name:          "key not exist",
+			configs:       map[string]string{},
+			defaultValue:  time.Second,
+			expectedValue: time.Second,
+		},
+		{
+			name:          "key exist, value is not a duration",
+			configs:       map[string]string{testKey: "10"},
+			defaultValue:  time.Second,
+			expectedValue: time.Second,
+		},
+		{
+			name:          "key exist, value is different from default value",
+			configs:       map[string]string{testKey: "500ms"},
+			defaultValue:  time.Second,
+			expectedValue: 500 * time.Millisecond,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expectedValue, GetConfigurationDuration(tc.configs, testKey, tc.defaultValue))
+		})
+	}
+}
+
 func TestZeroTimeInUnixNano(t *testing.T) {
 	// zero time
 	var nilValue *int64 = nil
@@ -21,13 +21,19 @@ package entrypoint
 import (
 	"go.uber.org/zap"
 
+	"github.com/apache/yunikorn-core/pkg/common"
 	"github.com/apache/yunikorn-core/pkg/events"
 	"github.com/apache/yunikorn-core/pkg/handler"
+	"github.com/apache/yunikorn-core/pkg/leaderelection"
 	"github.com/apache/yunikorn-core/pkg/log"
 	"github.com/apache/yunikorn-core/pkg/metrics"
 	"github.com/apache/yunikorn-core/pkg/metrics/history"
 	"github.com/apache/yunikorn-core/pkg/rmproxy"
+	"github.com/apache/yunikorn-core/pkg/rpc"
 	"github.com/apache/yunikorn-core/pkg/scheduler"
+	"github.com/apache/yunikorn-core/pkg/scheduler/accounting"
+	"github.com/apache/yunikorn-core/pkg/scheduler/sink"
+	"github.com/apache/yunikorn-core/pkg/scheduler/state"
 	"github.com/apache/yunikorn-core/pkg/webservice"
 )
 
@@ -36,6 +42,10 @@ type startupOptions struct {
 	manualScheduleFlag bool
 	startWebAppFlag    bool
 	metricsHistorySize int
+	grpcEndpoint       string
+	stateStore         state.Store
+	accountingStore    accounting.Store
+	election           *leaderelection.Config
 }
 
 func StartAllServices() *ServiceContext {
@@ -64,6 +74,43 @@ func StartAllServicesWithLogger(logger *zap.Logger, zapConfigs *zap.Config) *Ser
 	return StartAllServices()
 }
 
+// ServiceOptions are the optional services started by StartAllServicesWithOptions. The options can be combined.
+type ServiceOptions struct {
+	// GRPCEndpoint of the gRPC server for resource managers running out of process, in the form tcp://host:port
+	// or unix://path. The gRPC server is not started if empty.
+	GRPCEndpoint string
+	// StateStore persists the scheduler state. The state from the last snapshot in the store is restored when
+	// the RM registers. The state is not persisted if nil.
+	StateStore state.Store
+	// AccountingStore persists the chargeback ledger. The ledger is kept in memory only if nil.
+	AccountingStore accounting.Store
+	// Election runs the instance as one of multiple instances competing for the lease. Only the leader schedules
+	// and accepts updates from the RM. The other instances are hot standbys that keep a replica of the state of
+	// the leader from the shared state store, and take over from the last snapshot without a resync of the RM
+	// when the lease of the leader expires. Requires a state store. The ID and lease are required, the callbacks
+	// are set by the service context.
+	Election *leaderelection.Config
+}
+
+// StartAllServicesWithOptions starts all services like StartAllServices and the optional services.
+func StartAllServicesWithOptions(options ServiceOptions) *ServiceContext {
+	log.Log(log.Entrypoint).Info("ServiceContext start all services with options",
+		zap.String("grpcEndpoint", options.GRPCEndpoint),
+		zap.Bool("stateStore", options.StateStore != nil),
+		zap.Bool("accountingStore", options.AccountingStore != nil),
+		zap.Bool("leaderElection", options.Election != nil))
+	return startAllServicesWithParameters(
+		startupOptions{
+			manualScheduleFlag: false,
+			startWebAppFlag:    true,
+			metricsHistorySize: 1440,
+			grpcEndpoint:       options.GRPCEndpoint,
+			stateStore:         options.StateStore,
+			accountingStore:    options.AccountingStore,
+			election:           options.Election,
+		})
+}
+
 // Visible by tests
 func StartAllServicesWithManualScheduler() *ServiceContext {
 	log.Log(log.Entrypoint).Info("ServiceContext start all services (manual scheduler)")
@@ -78,6 +125,8 @@ func StartAllServicesWithManualScheduler() *ServiceContext {
 func startAllServicesWithParameters(opts startupOptions) *ServiceContext {
 	log.Log(log.Entrypoint).Info("Starting event system")
 	events.GetEventSystem().StartService()
+	log.Log(log.Entrypoint).Info("Starting application record sinks")
+	sink.GetDispatcher().StartService()
 
 	sched := scheduler.NewScheduler()
 	proxy := rmproxy.NewRMProxy(sched)
@@ -86,6 +135,45 @@ func startAllServicesWithParameters(opts startupOptions) *ServiceContext {
 		RMProxyEventHandler:   proxy,
 	}
 
+	// a standby must be set up before the RM can register
+	var elector *leaderelection.Elector
+	if opts.election != nil {
+		if opts.stateStore == nil {
+			log.Log(log.Entrypoint).Fatal("leader election requires a state store")
+		}
+		log.Log(log.Entrypoint).Info("ServiceContext start as standby",
+			zap.String("id", opts.election.ID))
+		sched.EnableStandby()
+		proxy.SetStandby(true)
+		opts.election.OnStartedLeading = func() {
+			sched.Promote()
+			proxy.SetStandby(false)
+		}
+		opts.election.OnStoppedLeading = func() {
+			proxy.SetStandby(true)
+			sched.Demote()
+		}
+		var err error
+		if elector, err = leaderelection.NewElector(*opts.election); err != nil {
+			log.Log(log.Entrypoint).Fatal("failed to create leader elector", zap.Error(err))
+		}
+	}
+
+	// restore the state before the RM can register
+	if opts.stateStore != nil {
+		log.Log(log.Entrypoint).Info("ServiceContext enable scheduler state store")
+		if err := sched.EnableStateStore(opts.stateStore); err != nil {
+			log.Log(log.Entrypoint).Fatal("failed to load scheduler state", zap.Error(err))
+		}
+	}
+
+	if opts.accountingStore != nil {
+		log.Log(log.Entrypoint).Info("ServiceContext enable accounting store")
+		if err := sched.EnableAccountingStore(opts.accountingStore); err != nil {
+			log.Log(log.Entrypoint).Fatal("failed to load accounting ledger", zap.Error(err))
+		}
+	}
+
 	// start services
 	log.Log(log.Entrypoint).Info("ServiceContext start scheduling services")
 	sched.StartService(eventHandler, opts.manualScheduleFlag)
@@ -112,5 +200,19 @@ func startAllServicesWithParameters(opts startupOptions) *ServiceContext {
 		context.WebApp = webapp
 	}
 
+	if opts.grpcEndpoint != "" {
+		log.Log(log.Entrypoint).Info("ServiceContext start gRPC service",
+			zap.String("endpoint", opts.grpcEndpoint))
+		grpcServer := common.NewNonBlockingGRPCServer()
+		grpcServer.Start(opts.grpcEndpoint, rpc.NewSchedulerServer(proxy))
+		context.GRPCServer = grpcServer
+	}
+
+	if elector != nil {
+		log.Log(log.Entrypoint).Info("ServiceContext start leader election")
+		elector.Start()
+		context.Elector = elector
+	}
+
 	return context
 }
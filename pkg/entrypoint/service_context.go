@@ -21,10 +21,13 @@ package entrypoint
 import (
 	"go.uber.org/zap"
 
+	"github.com/apache/yunikorn-core/pkg/common"
 	"github.com/apache/yunikorn-core/pkg/events"
+	"github.com/apache/yunikorn-core/pkg/leaderelection"
 	"github.com/apache/yunikorn-core/pkg/log"
 	"github.com/apache/yunikorn-core/pkg/metrics"
 	"github.com/apache/yunikorn-core/pkg/scheduler"
+	"github.com/apache/yunikorn-core/pkg/scheduler/sink"
 	"github.com/apache/yunikorn-core/pkg/webservice"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/api"
 )
@@ -34,10 +37,18 @@ type ServiceContext struct {
 	Scheduler        *scheduler.Scheduler
 	WebApp           *webservice.WebService
 	MetricsCollector metrics.InternalMetricsCollector
+	GRPCServer       common.NonBlockingGRPCServer
+	Elector          *leaderelection.Elector
 }
 
 func (s *ServiceContext) StopAll() {
 	log.Log(log.Entrypoint).Info("ServiceContext stop all services")
+	if s.Elector != nil {
+		s.Elector.Stop()
+	}
+	if s.GRPCServer != nil {
+		s.GRPCServer.Stop()
+	}
 	if s.WebApp != nil {
 		if err := s.WebApp.StopWebApp(); err != nil {
 			log.Log(log.Entrypoint).Error("failed to stop web-app",
@@ -49,5 +60,6 @@ func (s *ServiceContext) StopAll() {
 	}
 	s.Scheduler.Stop()
 	s.RMProxy.Stop()
+	sink.GetDispatcher().Stop()
 	events.GetEventSystem().Stop()
 }
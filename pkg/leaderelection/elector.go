@@ -0,0 +1,173 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package leaderelection
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-core/pkg/locking"
+	"github.com/apache/yunikorn-core/pkg/log"
+)
+
+const (
+	DefaultLeaseDuration = 15 * time.Second
+	DefaultRetryPeriod   = 2 * time.Second
+)
+
+// Config of the elector. Only the ID and the lease are required.
+type Config struct {
+	// ID of the scheduler instance, must be unique for all instances sharing the lease
+	ID    string
+	Lease Lease
+	// time the lease is held without renewal, the standby takes over after this time if the leader fails
+	LeaseDuration time.Duration
+	// time between two attempts to acquire or renew the lease, must be smaller than the lease duration
+	RetryPeriod time.Duration
+	// called when the instance becomes the leader
+	OnStartedLeading func()
+	// called when the instance lost the lease
+	OnStoppedLeading func()
+}
+
+// Elector competes for the lease and tracks if this instance is the leader.
+// The callbacks are called from the elector go routine, and from Start for the first attempt.
+type Elector struct {
+	config      Config
+	leader      bool
+	lastRenewal time.Time
+	stopChan    chan struct{}
+	stopped     chan struct{}
+
+	locking.RWMutex
+}
+
+func NewElector(config Config) (*Elector, error) {
+	if config.ID == "" || config.Lease == nil {
+		return nil, fmt.Errorf("leader election requires an ID and a lease")
+	}
+	if config.LeaseDuration == 0 {
+		config.LeaseDuration = DefaultLeaseDuration
+	}
+	if config.RetryPeriod == 0 {
+		config.RetryPeriod = DefaultRetryPeriod
+	}
+	if config.RetryPeriod >= config.LeaseDuration {
+		return nil, fmt.Errorf("retry period %s must be smaller than the lease duration %s", config.RetryPeriod, config.LeaseDuration)
+	}
+	return &Elector{config: config}, nil
+}
+
+// Start makes the first attempt to acquire the lease and keeps competing in the background.
+func (e *Elector) Start() {
+	log.Log(log.Leader).Info("Starting leader election",
+		zap.String("id", e.config.ID),
+		zap.Duration("leaseDuration", e.config.LeaseDuration),
+		zap.Duration("retryPeriod", e.config.RetryPeriod))
+	e.Lock()
+	e.stopChan = make(chan struct{})
+	e.stopped = make(chan struct{})
+	stopChan := e.stopChan
+	stopped := e.stopped
+	e.Unlock()
+	e.tryAcquire()
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(e.config.RetryPeriod)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopChan:
+				return
+			case <-ticker.C:
+				e.tryAcquire()
+			}
+		}
+	}()
+}
+
+// Stop leaves the election: the lease is released if held so a standby can take over immediately.
+// The stopped leading callback is not called, the instance is shutting down.
+func (e *Elector) Stop() {
+	e.Lock()
+	stopChan := e.stopChan
+	stopped := e.stopped
+	e.stopChan = nil
+	e.Unlock()
+	if stopChan == nil {
+		return
+	}
+	close(stopChan)
+	<-stopped
+	e.Lock()
+	defer e.Unlock()
+	if e.leader {
+		e.leader = false
+		if err := e.config.Lease.Release(e.config.ID); err != nil {
+			log.Log(log.Leader).Warn("Failed to release lease", zap.Error(err))
+		}
+	}
+}
+
+// IsLeader returns true if this instance holds the lease.
+func (e *Elector) IsLeader() bool {
+	e.RLock()
+	defer e.RUnlock()
+	return e.leader
+}
+
+// tryAcquire acquires or renews the lease and calls the callbacks on a change of leadership.
+// A leader that cannot reach the lease stays leader until the lease would have expired.
+func (e *Elector) tryAcquire() {
+	acquired, err := e.config.Lease.TryAcquire(e.config.ID, e.config.LeaseDuration)
+	now := time.Now()
+	e.Lock()
+	wasLeader := e.leader
+	switch {
+	case err != nil:
+		log.Log(log.Leader).Warn("Failed to acquire or renew lease",
+			zap.String("id", e.config.ID),
+			zap.Error(err))
+		if e.leader && now.Sub(e.lastRenewal) >= e.config.LeaseDuration {
+			e.leader = false
+		}
+	case acquired:
+		e.leader = true
+		e.lastRenewal = now
+	default:
+		e.leader = false
+	}
+	isLeader := e.leader
+	e.Unlock()
+
+	if isLeader && !wasLeader {
+		log.Log(log.Leader).Info("Acquired lease, instance is the leader", zap.String("id", e.config.ID))
+		if e.config.OnStartedLeading != nil {
+			e.config.OnStartedLeading()
+		}
+	}
+	if !isLeader && wasLeader {
+		log.Log(log.Leader).Warn("Lost lease, instance is no longer the leader", zap.String("id", e.config.ID))
+		if e.config.OnStoppedLeading != nil {
+			e.config.OnStoppedLeading()
+		}
+	}
+}
@@ -0,0 +1,144 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package leaderelection
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-core/pkg/locking"
+)
+
+// testLease is a lease in memory that can be made to fail for one holder.
+type testLease struct {
+	holder  string
+	expires time.Time
+	failFor string
+
+	locking.Mutex
+}
+
+func (tl *testLease) TryAcquire(holder string, duration time.Duration) (bool, error) {
+	tl.Lock()
+	defer tl.Unlock()
+	if tl.failFor == holder {
+		return false, errors.New("lease not available")
+	}
+	if tl.holder != "" && tl.holder != holder && time.Now().Before(tl.expires) {
+		return false, nil
+	}
+	tl.holder = holder
+	tl.expires = time.Now().Add(duration)
+	return true, nil
+}
+
+func (tl *testLease) Release(holder string) error {
+	tl.Lock()
+	defer tl.Unlock()
+	if tl.holder == holder {
+		tl.holder = ""
+	}
+	return nil
+}
+
+func (tl *testLease) Holder() (string, error) {
+	tl.Lock()
+	defer tl.Unlock()
+	return tl.holder, nil
+}
+
+func (tl *testLease) setFailFor(holder string) {
+	tl.Lock()
+	defer tl.Unlock()
+	tl.failFor = holder
+}
+
+func TestNewElector(t *testing.T) {
+	_, err := NewElector(Config{Lease: &testLease{}})
+	assert.ErrorContains(t, err, "requires an ID and a lease")
+	_, err = NewElector(Config{ID: "a"})
+	assert.ErrorContains(t, err, "requires an ID and a lease")
+	_, err = NewElector(Config{ID: "a", Lease: &testLease{}, LeaseDuration: time.Second, RetryPeriod: time.Second})
+	assert.ErrorContains(t, err, "must be smaller than the lease duration")
+	var elector *Elector
+	elector, err = NewElector(Config{ID: "a", Lease: &testLease{}})
+	assert.NilError(t, err, "failed to create elector")
+	assert.Equal(t, elector.config.LeaseDuration, DefaultLeaseDuration)
+	assert.Equal(t, elector.config.RetryPeriod, DefaultRetryPeriod)
+	assert.Assert(t, !elector.IsLeader(), "elector should not lead before start")
+	// stop before start is a no-op
+	elector.Stop()
+}
+
+func TestElectorFailover(t *testing.T) {
+	lease := &testLease{}
+	var startedA, stoppedA, startedB atomic.Int32
+	newConfig := func(id string, started, stopped *atomic.Int32) Config {
+		return Config{
+			ID:               id,
+			Lease:            lease,
+			LeaseDuration:    100 * time.Millisecond,
+			RetryPeriod:      10 * time.Millisecond,
+			OnStartedLeading: func() { started.Add(1) },
+			OnStoppedLeading: func() { stopped.Add(1) },
+		}
+	}
+	electorA, err := NewElector(newConfig("a", &startedA, &stoppedA))
+	assert.NilError(t, err, "failed to create elector a")
+	electorB, err := NewElector(newConfig("b", &startedB, &atomic.Int32{}))
+	assert.NilError(t, err, "failed to create elector b")
+
+	// first attempt is made on start
+	electorA.Start()
+	assert.Assert(t, electorA.IsLeader(), "a should lead after start")
+	assert.Equal(t, startedA.Load(), int32(1))
+	electorB.Start()
+	defer electorB.Stop()
+	assert.Assert(t, !electorB.IsLeader(), "b should be standby")
+
+	// a keeps the lease while renewing
+	time.Sleep(200 * time.Millisecond)
+	assert.Assert(t, electorA.IsLeader(), "a should keep leading")
+	assert.Assert(t, !electorB.IsLeader(), "b should still be standby")
+
+	// a cannot reach the lease: leadership is lost once the lease expired and b takes over
+	lease.setFailFor("a")
+	time.Sleep(50 * time.Millisecond)
+	assert.Assert(t, electorA.IsLeader(), "a should lead until the lease expires")
+	time.Sleep(150 * time.Millisecond)
+	assert.Assert(t, !electorA.IsLeader(), "a should have lost the lead")
+	assert.Equal(t, stoppedA.Load(), int32(1))
+	assert.Assert(t, electorB.IsLeader(), "b should have taken over")
+	lease.setFailFor("")
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, startedB.Load(), int32(1))
+	assert.Assert(t, !electorA.IsLeader(), "a should not lead while b holds the lease")
+
+	// stopping the leader releases the lease without a callback
+	electorA.Stop()
+	electorB.Stop()
+	holder, err := lease.Holder()
+	assert.NilError(t, err, "failed to read holder")
+	assert.Equal(t, holder, "", "lease should have been released")
+	assert.Equal(t, stoppedA.Load(), int32(1))
+}
@@ -0,0 +1,163 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package leaderelection
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	leaseFile = "leader.lease"
+	lockFile  = "leader.lock"
+	// a lock file older than this was left behind by a crashed process
+	staleLockAge = 10 * time.Second
+	lockRetry    = 10 * time.Millisecond
+	lockTimeout  = time.Second
+)
+
+// leaseRecord is the content of the lease file.
+type leaseRecord struct {
+	Holder  string    `json:"holder"`
+	Expires time.Time `json:"expires"`
+}
+
+// FileLease is a lease in a local directory shared by the scheduler instances. The read-modify-write of the
+// lease file is protected by an exclusively created lock file, which works on every file system that supports
+// exclusive creates. It is meant for local testing and single host deployments.
+type FileLease struct {
+	leasePath string
+	lockPath  string
+}
+
+// NewFileLease creates a lease in the directory, the directory is created if it does not exist.
+func NewFileLease(dir string) (*FileLease, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, err
+	}
+	return &FileLease{
+		leasePath: filepath.Join(dir, leaseFile),
+		lockPath:  filepath.Join(dir, lockFile),
+	}, nil
+}
+
+func (fl *FileLease) TryAcquire(holder string, duration time.Duration) (bool, error) {
+	if holder == "" {
+		return false, fmt.Errorf("lease holder cannot be empty")
+	}
+	unlock, err := fl.lock()
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+	record, err := fl.read()
+	if err != nil {
+		return false, err
+	}
+	now := time.Now()
+	if record != nil && record.Holder != holder && now.Before(record.Expires) {
+		return false, nil
+	}
+	return true, fl.write(&leaseRecord{Holder: holder, Expires: now.Add(duration)})
+}
+
+func (fl *FileLease) Release(holder string) error {
+	unlock, err := fl.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	record, err := fl.read()
+	if err != nil || record == nil || record.Holder != holder {
+		return err
+	}
+	return os.Remove(fl.leasePath)
+}
+
+func (fl *FileLease) Holder() (string, error) {
+	unlock, err := fl.lock()
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+	record, err := fl.read()
+	if err != nil || record == nil || time.Now().After(record.Expires) {
+		return "", err
+	}
+	return record.Holder, nil
+}
+
+// lock creates the lock file, waiting for another process to remove it. A stale lock file is removed.
+// Returns the function that removes the lock file.
+func (fl *FileLease) lock() (func(), error) {
+	deadline := time.Now().Add(lockTimeout)
+	for {
+		file, err := os.OpenFile(fl.lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+		if err == nil {
+			file.Close()
+			return func() {
+				os.Remove(fl.lockPath)
+			}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+		if info, statErr := os.Stat(fl.lockPath); statErr == nil && time.Since(info.ModTime()) > staleLockAge {
+			os.Remove(fl.lockPath)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("timeout waiting for lease lock %s", fl.lockPath)
+		}
+		time.Sleep(lockRetry)
+	}
+}
+
+// read returns the lease record, nil if there is no lease file.
+func (fl *FileLease) read() (*leaseRecord, error) {
+	data, err := os.ReadFile(fl.leasePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	record := &leaseRecord{}
+	if err = json.Unmarshal(data, record); err != nil {
+		return nil, fmt.Errorf("invalid lease file %s: %w", fl.leasePath, err)
+	}
+	return record, nil
+}
+
+// write replaces the lease file atomically.
+func (fl *FileLease) write(record *leaseRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	tmp := fl.leasePath + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, fl.leasePath)
+}
@@ -0,0 +1,97 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package leaderelection
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestFileLease(t *testing.T) {
+	dir := t.TempDir()
+	lease, err := NewFileLease(dir)
+	assert.NilError(t, err, "failed to create lease")
+	holder, err := lease.Holder()
+	assert.NilError(t, err, "failed to read holder")
+	assert.Equal(t, holder, "", "new lease should not have a holder")
+
+	_, err = lease.TryAcquire("", time.Minute)
+	assert.ErrorContains(t, err, "lease holder cannot be empty")
+	acquired, err := lease.TryAcquire("a", time.Minute)
+	assert.NilError(t, err, "failed to acquire lease")
+	assert.Assert(t, acquired, "a should have acquired the lease")
+	acquired, err = lease.TryAcquire("b", time.Minute)
+	assert.NilError(t, err, "failed to try lease")
+	assert.Assert(t, !acquired, "b should not acquire a held lease")
+	acquired, err = lease.TryAcquire("a", time.Minute)
+	assert.NilError(t, err, "failed to renew lease")
+	assert.Assert(t, acquired, "a should renew its lease")
+	holder, err = lease.Holder()
+	assert.NilError(t, err, "failed to read holder")
+	assert.Equal(t, holder, "a", "unexpected holder")
+
+	// releasing by another holder is ignored
+	assert.NilError(t, lease.Release("b"), "release by other holder should not fail")
+	holder, err = lease.Holder()
+	assert.NilError(t, err, "failed to read holder")
+	assert.Equal(t, holder, "a", "lease should still be held")
+	assert.NilError(t, lease.Release("a"), "failed to release lease")
+	acquired, err = lease.TryAcquire("b", 10*time.Millisecond)
+	assert.NilError(t, err, "failed to acquire released lease")
+	assert.Assert(t, acquired, "b should acquire the released lease")
+
+	// expired lease can be taken over
+	time.Sleep(20 * time.Millisecond)
+	holder, err = lease.Holder()
+	assert.NilError(t, err, "failed to read holder")
+	assert.Equal(t, holder, "", "expired lease should not have a holder")
+	acquired, err = lease.TryAcquire("a", time.Minute)
+	assert.NilError(t, err, "failed to acquire expired lease")
+	assert.Assert(t, acquired, "a should take over the expired lease")
+}
+
+func TestFileLeaseLock(t *testing.T) {
+	dir := t.TempDir()
+	lease, err := NewFileLease(dir)
+	assert.NilError(t, err, "failed to create lease")
+	lockPath := filepath.Join(dir, lockFile)
+
+	// held lock times out
+	assert.NilError(t, os.WriteFile(lockPath, nil, 0o600), "failed to create lock file")
+	_, err = lease.TryAcquire("a", time.Minute)
+	assert.ErrorContains(t, err, "timeout waiting for lease lock")
+
+	// stale lock is removed
+	stale := time.Now().Add(-2 * staleLockAge)
+	assert.NilError(t, os.Chtimes(lockPath, stale, stale), "failed to age lock file")
+	acquired, err := lease.TryAcquire("a", time.Minute)
+	assert.NilError(t, err, "stale lock should have been removed")
+	assert.Assert(t, acquired, "a should have acquired the lease")
+	_, err = os.Stat(lockPath)
+	assert.Assert(t, os.IsNotExist(err), "lock file should be removed after use")
+
+	// corrupt lease file
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, leaseFile), []byte("{"), 0o600), "failed to corrupt lease file")
+	_, err = lease.Holder()
+	assert.ErrorContains(t, err, "invalid lease file")
+}
@@ -0,0 +1,35 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package leaderelection
+
+import (
+	"time"
+)
+
+// Lease is a lock with an expiry that is shared by all scheduler instances. The instance holding the lease
+// is the leader. Implementations must be safe for concurrent use by multiple processes.
+type Lease interface {
+	// TryAcquire acquires the lease for the holder, or renews it if the holder already owns it.
+	// Returns true if the holder owns the lease for the duration after the call.
+	TryAcquire(holder string, duration time.Duration) (bool, error)
+	// Release gives up the lease if it is held by the holder, it is a no-op otherwise.
+	Release(holder string) error
+	// Holder returns the current holder of the lease, empty if the lease is free or expired.
+	Holder() (string, error)
+}
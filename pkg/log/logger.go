@@ -83,6 +83,10 @@ var (
 	Security         = &LoggerHandle{id: 26, name: "core.security"}
 	Utils            = &LoggerHandle{id: 27, name: "core.utils"}
 	Diagnostics      = &LoggerHandle{id: 28, name: "core.diagnostics"}
+	SchedState       = &LoggerHandle{id: 29, name: "core.scheduler.state"}
+	Leader           = &LoggerHandle{id: 30, name: "core.leader"}
+	Accounting       = &LoggerHandle{id: 31, name: "core.accounting"}
+	Sink             = &LoggerHandle{id: 32, name: "core.sink"}
 )
 
 // this tracks all the known logger handles, used to preallocate the real logger instances when configuration changes
@@ -90,6 +94,7 @@ var loggers = []*LoggerHandle{
 	Core, Test, Deprecation, Config, Entrypoint, Events, OpenTracing, Resources, REST, RMProxy, RPC, Metrics,
 	Scheduler, SchedAllocation, SchedApplication, SchedAppUsage, SchedContext, SchedFSM, SchedHealth, SchedNode,
 	SchedPartition, SchedPreemption, SchedQueue, SchedReservation, SchedUGM, SchedNodesUsage, Security, Utils, Diagnostics,
+	SchedState, Leader, Accounting, Sink,
 }
 
 // structure to hold all current logger configuration state
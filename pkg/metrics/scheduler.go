@@ -62,6 +62,7 @@ type SchedulerMetrics struct {
 	node                  *prometheus.GaugeVec
 	nodeResourceUsage     map[string]*prometheus.GaugeVec
 	schedulingLatency     prometheus.Histogram
+	partitionLatency      *prometheus.HistogramVec
 	sortingLatency        *prometheus.HistogramVec
 	tryNodeLatency        prometheus.Histogram
 	tryPreemptionLatency  prometheus.Histogram
@@ -117,6 +118,14 @@ func InitSchedulerMetrics() *SchedulerMetrics {
 			Buckets:   prometheus.ExponentialBuckets(0.0001, 10, 8), // start from 0.1ms
 		},
 	)
+	s.partitionLatency = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: Namespace,
+			Subsystem: SchedulerSubsystem,
+			Name:      "partition_scheduling_cycle_latency_milliseconds",
+			Help:      "Latency of one scheduling cycle of a partition scheduled on its own routine, in seconds.",
+			Buckets:   prometheus.ExponentialBuckets(0.0001, 10, 8), // start from 0.1ms
+		}, []string{"partition"})
 	s.sortingLatency = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Namespace: Namespace,
@@ -153,6 +162,7 @@ func InitSchedulerMetrics() *SchedulerMetrics {
 		s.application,
 		s.node,
 		s.schedulingLatency,
+		s.partitionLatency,
 		s.sortingLatency,
 		s.tryNodeLatency,
 		s.tryPreemptionLatency,
@@ -182,6 +192,15 @@ func (m *SchedulerMetrics) ObserveSchedulingLatency(start time.Time) {
 	m.schedulingLatency.Observe(SinceInSeconds(start))
 }
 
+func (m *SchedulerMetrics) ObservePartitionSchedulingLatency(partition string, start time.Time) {
+	m.partitionLatency.WithLabelValues(partition).Observe(SinceInSeconds(start))
+}
+
+// RemovePartitionSchedulingLatency removes the latency of a partition that is no longer scheduled.
+func (m *SchedulerMetrics) RemovePartitionSchedulingLatency(partition string) {
+	m.partitionLatency.DeleteLabelValues(partition)
+}
+
 func (m *SchedulerMetrics) ObserveAppSortingLatency(start time.Time) {
 	m.sortingLatency.WithLabelValues(SortingApp).Observe(SinceInSeconds(start))
 }
@@ -60,6 +60,20 @@ func TestTryPreemptionLatency(t *testing.T) {
 	verifyHistogram(t, "trypreemption_latency_milliseconds", 60, 1)
 }
 
+func TestPartitionSchedulingLatency(t *testing.T) {
+	sm = getSchedulerMetrics(t)
+	defer unregisterMetrics()
+
+	sm.ObservePartitionSchedulingLatency("default", time.Now().Add(-1*time.Minute))
+	verifyHistogram(t, "partition_scheduling_cycle_latency_milliseconds", 60, 1)
+	sm.RemovePartitionSchedulingLatency("default")
+	mfs, err := prometheus.DefaultGatherer.Gather()
+	assert.NilError(t, err)
+	for _, metric := range mfs {
+		assert.Assert(t, !strings.Contains(metric.GetName(), "partition_scheduling_cycle_latency"), "partition latency should have been removed")
+	}
+}
+
 func TestSchedulerApplicationsNew(t *testing.T) {
 	sm = getSchedulerMetrics(t)
 	defer unregisterMetrics()
@@ -223,6 +237,7 @@ func unregisterMetrics() {
 	prometheus.Unregister(sm.application)
 	prometheus.Unregister(sm.node)
 	prometheus.Unregister(sm.schedulingLatency)
+	prometheus.Unregister(sm.partitionLatency)
 	prometheus.Unregister(sm.sortingLatency)
 	prometheus.Unregister(sm.tryNodeLatency)
 	prometheus.Unregister(sm.tryPreemptionLatency)
@@ -19,6 +19,7 @@
 package rmproxy
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 	"strconv"
@@ -46,10 +47,14 @@ type RMProxy struct {
 	pendingRMEvents chan interface{}
 
 	rmIDToCallback map[string]api.ResourceManagerCallback
+	standby        bool // standby schedulers only accept registration and configuration updates
 
 	locking.RWMutex
 }
 
+// ErrStandby is returned for updates sent to a scheduler that is not the leader.
+var ErrStandby = errors.New("scheduler is in standby mode, updates must be sent to the leader")
+
 func (rmp *RMProxy) GetRMEventHandler() handler.EventHandler {
 	return rmp
 }
@@ -262,7 +267,24 @@ func (rmp *RMProxy) GetResourceManagerCallback(rmID string) api.ResourceManagerC
 	return rmp.rmIDToCallback[rmID]
 }
 
+// SetStandby switches the proxy between standby and leader mode. In standby mode all allocation,
+// application and node updates are rejected with ErrStandby.
+func (rmp *RMProxy) SetStandby(standby bool) {
+	rmp.Lock()
+	defer rmp.Unlock()
+	rmp.standby = standby
+}
+
+func (rmp *RMProxy) IsStandby() bool {
+	rmp.RLock()
+	defer rmp.RUnlock()
+	return rmp.standby
+}
+
 func (rmp *RMProxy) UpdateAllocation(request *si.AllocationRequest) error {
+	if rmp.IsStandby() {
+		return ErrStandby
+	}
 	if rmp.GetResourceManagerCallback(request.RmID) == nil {
 		return fmt.Errorf("received AllocationRequest, but RmID=\"%s\" not registered", request.RmID)
 	}
@@ -282,6 +304,9 @@ func (rmp *RMProxy) UpdateAllocation(request *si.AllocationRequest) error {
 }
 
 func (rmp *RMProxy) UpdateApplication(request *si.ApplicationRequest) error {
+	if rmp.IsStandby() {
+		return ErrStandby
+	}
 	if rmp.GetResourceManagerCallback(request.RmID) == nil {
 		return fmt.Errorf("received ApplicationRequest, but RmID=\"%s\" not registered", request.RmID)
 	}
@@ -301,6 +326,9 @@ func (rmp *RMProxy) UpdateApplication(request *si.ApplicationRequest) error {
 }
 
 func (rmp *RMProxy) UpdateNode(request *si.NodeRequest) error {
+	if rmp.IsStandby() {
+		return ErrStandby
+	}
 	if rmp.GetResourceManagerCallback(request.RmID) == nil {
 		return fmt.Errorf("received NodeRequest, but RmID=\"%s\" not registered", request.RmID)
 	}
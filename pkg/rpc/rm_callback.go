@@ -0,0 +1,132 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package rpc
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-core/pkg/log"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+const (
+	// size of the per RM queues that buffer responses until they are sent on the stream
+	responseQueueSize = 1024
+	// maximum time to wait for space in a full response queue before the response is rejected
+	responseQueueTimeout = 5 * time.Second
+)
+
+// responseQueue buffers the responses of one type for an RM until they are sent on the stream of that type.
+type responseQueue[T any] struct {
+	responses chan T
+	streams   atomic.Int32 // number of streams sending the responses
+}
+
+func newResponseQueue[T any]() *responseQueue[T] {
+	return &responseQueue[T]{
+		responses: make(chan T, responseQueueSize),
+	}
+}
+
+// rmCallback is the ResourceManagerCallback registered with the RMProxy for a remote RM.
+// Responses are queued and sent to the RM on the matching bidirectional stream.
+// The remote RM does not provide predicates: all predicate checks pass.
+type rmCallback struct {
+	rmID         string
+	timeout      time.Duration
+	allocations  *responseQueue[*si.AllocationResponse]
+	applications *responseQueue[*si.ApplicationResponse]
+	nodes        *responseQueue[*si.NodeResponse]
+}
+
+func newRMCallback(rmID string) *rmCallback {
+	return &rmCallback{
+		rmID:         rmID,
+		timeout:      responseQueueTimeout,
+		allocations:  newResponseQueue[*si.AllocationResponse](),
+		applications: newResponseQueue[*si.ApplicationResponse](),
+		nodes:        newResponseQueue[*si.NodeResponse](),
+	}
+}
+
+func (cb *rmCallback) UpdateAllocation(response *si.AllocationResponse) error {
+	return enqueueResponse(cb.allocations, response, cb.rmID, cb.timeout)
+}
+
+func (cb *rmCallback) UpdateApplication(response *si.ApplicationResponse) error {
+	return enqueueResponse(cb.applications, response, cb.rmID, cb.timeout)
+}
+
+func (cb *rmCallback) UpdateNode(response *si.NodeResponse) error {
+	return enqueueResponse(cb.nodes, response, cb.rmID, cb.timeout)
+}
+
+func (cb *rmCallback) Predicates(_ *si.PredicatesArgs) error {
+	return nil
+}
+
+func (cb *rmCallback) PreemptionPredicates(args *si.PreemptionPredicatesArgs) *si.PreemptionPredicatesResponse {
+	return &si.PreemptionPredicatesResponse{
+		Success: true,
+		Index:   args.StartIndex,
+	}
+}
+
+// SendEvent drops the events: the scheduler interface does not define a stream for events.
+func (cb *rmCallback) SendEvent(events []*si.EventRecord) {
+	log.Log(log.RPC).Debug("dropping events for remote RM",
+		zap.String("rmID", cb.rmID),
+		zap.Int("count", len(events)))
+}
+
+// UpdateContainerSchedulingState drops the update: the scheduler interface does not define a stream for it.
+func (cb *rmCallback) UpdateContainerSchedulingState(request *si.UpdateContainerSchedulingStateRequest) {
+	log.Log(log.RPC).Debug("dropping container scheduling state update for remote RM",
+		zap.String("rmID", cb.rmID),
+		zap.String("allocationKey", request.GetAllocationKey()))
+}
+
+// enqueueResponse adds the response to the queue. The caller is the RMProxy event loop.
+// If the queue is full and a stream is sending the responses, the caller is blocked until there is space, which slows
+// down the scheduler to the pace of the RM. The response is rejected if there is no space after the timeout.
+// Without a stream nothing drains the queue: the response is rejected immediately to never block the caller.
+func enqueueResponse[T any](queue *responseQueue[T], response T, rmID string, timeout time.Duration) error {
+	select {
+	case queue.responses <- response:
+		return nil
+	default:
+	}
+	if queue.streams.Load() == 0 {
+		return fmt.Errorf("response queue full for RM %s, no stream connected", rmID)
+	}
+	log.Log(log.RPC).Debug("response queue full, waiting for remote RM",
+		zap.String("rmID", rmID))
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case queue.responses <- response:
+		return nil
+	case <-timer.C:
+		return fmt.Errorf("response queue full for RM %s after waiting %s", rmID, timeout)
+	}
+}
@@ -0,0 +1,54 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package rpc
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestEnqueueResponse(t *testing.T) {
+	queue := &responseQueue[int]{responses: make(chan int, 1)}
+	assert.NilError(t, enqueueResponse(queue, 1, testRMID, time.Second), "queue with space should not block")
+
+	// a full queue without a stream rejects the response without waiting
+	start := time.Now()
+	err := enqueueResponse(queue, 2, testRMID, time.Minute)
+	assert.ErrorContains(t, err, "no stream connected")
+	assert.Assert(t, time.Since(start) < time.Second, "response without stream should not wait")
+
+	// a full queue with a stream waits for the stream
+	queue.streams.Add(1)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		<-queue.responses
+	}()
+	assert.NilError(t, enqueueResponse(queue, 3, testRMID, time.Second), "response should be queued once there is space")
+	assert.Equal(t, <-queue.responses, 3)
+
+	// a stream that does not send rejects the response after the timeout
+	queue.responses <- 4
+	start = time.Now()
+	err = enqueueResponse(queue, 5, testRMID, 20*time.Millisecond)
+	assert.ErrorContains(t, err, "response queue full for RM "+testRMID+" after waiting")
+	assert.Assert(t, time.Since(start) >= 20*time.Millisecond, "response rejected before the timeout")
+	assert.Equal(t, <-queue.responses, 4)
+}
@@ -0,0 +1,167 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package rpc
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/apache/yunikorn-core/pkg/locking"
+	"github.com/apache/yunikorn-core/pkg/log"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/api"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+// SchedulerServer exposes the scheduler API over gRPC for resource managers that run out of process.
+// Requests received on the streams are passed to the scheduler API. Responses from the scheduler are
+// sent back to the RM on the stream of the same type. An RM must register before opening the streams,
+// and should open only one stream of each type.
+type SchedulerServer struct {
+	si.UnimplementedSchedulerServer
+
+	proxy     api.SchedulerAPI
+	callbacks map[string]*rmCallback
+
+	locking.RWMutex
+}
+
+// request is implemented by all stream request messages
+type request interface {
+	GetRmID() string
+}
+
+// stream is implemented by all bidirectional server streams
+type stream[Req request, Resp any] interface {
+	Send(Resp) error
+	Recv() (Req, error)
+	Context() context.Context
+}
+
+func NewSchedulerServer(proxy api.SchedulerAPI) *SchedulerServer {
+	return &SchedulerServer{
+		proxy:     proxy,
+		callbacks: make(map[string]*rmCallback),
+	}
+}
+
+// RegisterResourceManager registers the RM with the scheduler. A re-registration of the same RM keeps the
+// response queues: streams that are open stay connected.
+func (s *SchedulerServer) RegisterResourceManager(_ context.Context, request *si.RegisterResourceManagerRequest) (*si.RegisterResourceManagerResponse, error) {
+	if request.GetRmID() == "" {
+		return nil, status.Error(codes.InvalidArgument, "registration request without RM ID")
+	}
+	s.Lock()
+	callback, ok := s.callbacks[request.RmID]
+	if !ok {
+		callback = newRMCallback(request.RmID)
+		s.callbacks[request.RmID] = callback
+	}
+	s.Unlock()
+	response, err := s.proxy.RegisterResourceManager(request, callback)
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	log.Log(log.RPC).Info("registered remote RM",
+		zap.String("rmID", request.RmID))
+	return response, nil
+}
+
+func (s *SchedulerServer) UpdateAllocation(conn si.Scheduler_UpdateAllocationServer) error {
+	return serveStream[*si.AllocationRequest, *si.AllocationResponse](s, conn,
+		func(cb *rmCallback) *responseQueue[*si.AllocationResponse] { return cb.allocations },
+		s.proxy.UpdateAllocation)
+}
+
+func (s *SchedulerServer) UpdateApplication(conn si.Scheduler_UpdateApplicationServer) error {
+	return serveStream[*si.ApplicationRequest, *si.ApplicationResponse](s, conn,
+		func(cb *rmCallback) *responseQueue[*si.ApplicationResponse] { return cb.applications },
+		s.proxy.UpdateApplication)
+}
+
+func (s *SchedulerServer) UpdateNode(conn si.Scheduler_UpdateNodeServer) error {
+	return serveStream[*si.NodeRequest, *si.NodeResponse](s, conn,
+		func(cb *rmCallback) *responseQueue[*si.NodeResponse] { return cb.nodes },
+		s.proxy.UpdateNode)
+}
+
+func (s *SchedulerServer) getCallback(rmID string) *rmCallback {
+	s.RLock()
+	defer s.RUnlock()
+	return s.callbacks[rmID]
+}
+
+// serveStream passes all requests received on the stream to the scheduler using the update function.
+// The first request links the stream to the RM: responses queued for the RM are sent on the stream
+// until the stream is closed.
+func serveStream[Req request, Resp any](s *SchedulerServer, conn stream[Req, Resp], responses func(*rmCallback) *responseQueue[Resp], update func(Req) error) error {
+	ctx := conn.Context()
+	sendErr := make(chan error, 1)
+	var rmID string
+	for {
+		req, err := conn.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if rmID == "" {
+			rmID = req.GetRmID()
+			callback := s.getCallback(rmID)
+			if callback == nil {
+				return status.Errorf(codes.FailedPrecondition, "RM %q is not registered", rmID)
+			}
+			go sendResponses(ctx, conn, responses(callback), sendErr)
+		} else if req.GetRmID() != rmID {
+			return status.Errorf(codes.InvalidArgument, "stream is linked to RM %q, received request for RM %q", rmID, req.GetRmID())
+		}
+		if err = update(req); err != nil {
+			return status.Error(codes.FailedPrecondition, err.Error())
+		}
+		select {
+		case err = <-sendErr:
+			return err
+		default:
+		}
+	}
+}
+
+// sendResponses sends the queued responses on the stream until the stream context is done or a send fails.
+func sendResponses[Req request, Resp any](ctx context.Context, conn stream[Req, Resp], queue *responseQueue[Resp], sendErr chan<- error) {
+	queue.streams.Add(1)
+	defer queue.streams.Add(-1)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case response := <-queue.responses:
+			if err := conn.Send(response); err != nil {
+				log.Log(log.RPC).Warn("failed to send response to remote RM",
+					zap.Error(err))
+				sendErr <- err
+				return
+			}
+		}
+	}
+}
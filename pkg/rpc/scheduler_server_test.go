@@ -0,0 +1,180 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package rpc
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-core/pkg/common"
+	"github.com/apache/yunikorn-core/pkg/events"
+	"github.com/apache/yunikorn-core/pkg/handler"
+	"github.com/apache/yunikorn-core/pkg/rmproxy"
+	"github.com/apache/yunikorn-core/pkg/scheduler"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+const (
+	testRMID   = "rm-grpc"
+	testConfig = `
+partitions:
+  - name: default
+    queues:
+      - name: root
+        submitacl: "*"
+        queues:
+          - name: default
+`
+)
+
+// startServer starts a manual scheduler with a gRPC server on an in memory listener.
+// Returns the scheduler, a connected client and the cleanup function.
+func startServer(t *testing.T) (*scheduler.Scheduler, si.SchedulerClient, func()) {
+	events.Init()
+	sched := scheduler.NewScheduler()
+	proxy := rmproxy.NewRMProxy(sched)
+	sched.StartService(handler.EventHandlers{SchedulerEventHandler: sched, RMProxyEventHandler: proxy}, true)
+	proxy.StartService()
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	si.RegisterSchedulerServer(server, NewSchedulerServer(proxy))
+	go func() {
+		_ = server.Serve(listener) //nolint:errcheck
+	}()
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NilError(t, err, "client connection failed")
+	return sched, si.NewSchedulerClient(conn), func() {
+		_ = conn.Close()
+		server.Stop()
+		proxy.Stop()
+		sched.Stop()
+	}
+}
+
+func TestRegisterResourceManager(t *testing.T) {
+	_, client, cleanup := startServer(t)
+	defer cleanup()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := client.RegisterResourceManager(ctx, &si.RegisterResourceManagerRequest{})
+	assert.Equal(t, status.Code(err), codes.InvalidArgument, "missing RM ID should be rejected")
+	_, err = client.RegisterResourceManager(ctx, &si.RegisterResourceManagerRequest{RmID: testRMID, Config: "partitions: [invalid"})
+	assert.Equal(t, status.Code(err), codes.FailedPrecondition, "invalid config should fail registration")
+	_, err = client.RegisterResourceManager(ctx, &si.RegisterResourceManagerRequest{RmID: testRMID, PolicyGroup: "queues", Config: testConfig})
+	assert.NilError(t, err, "registration failed")
+}
+
+func TestStreamNotRegistered(t *testing.T) {
+	_, client, cleanup := startServer(t)
+	defer cleanup()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stream, err := client.UpdateNode(ctx)
+	assert.NilError(t, err, "stream creation failed")
+	err = stream.Send(&si.NodeRequest{RmID: "unknown"})
+	assert.NilError(t, err, "send failed")
+	_, err = stream.Recv()
+	assert.Equal(t, status.Code(err), codes.FailedPrecondition, "unregistered RM should be rejected")
+}
+
+func TestSchedulingOverStreams(t *testing.T) {
+	sched, client, cleanup := startServer(t)
+	defer cleanup()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := client.RegisterResourceManager(ctx, &si.RegisterResourceManagerRequest{RmID: testRMID, PolicyGroup: "queues", Config: testConfig})
+	assert.NilError(t, err, "registration failed")
+
+	// add a node and wait for it to be accepted
+	nodeStream, err := client.UpdateNode(ctx)
+	assert.NilError(t, err, "node stream creation failed")
+	err = nodeStream.Send(&si.NodeRequest{
+		RmID: testRMID,
+		Nodes: []*si.NodeInfo{{
+			NodeID:              "node-1",
+			Action:              si.NodeInfo_CREATE,
+			SchedulableResource: &si.Resource{Resources: map[string]*si.Quantity{"vcore": {Value: 10}}},
+		}},
+	})
+	assert.NilError(t, err, "node send failed")
+	nodeResponse, err := nodeStream.Recv()
+	assert.NilError(t, err, "node response failed")
+	assert.Equal(t, len(nodeResponse.GetAccepted()), 1, "node should have been accepted")
+
+	// add an application and wait for it to be accepted
+	appStream, err := client.UpdateApplication(ctx)
+	assert.NilError(t, err, "application stream creation failed")
+	err = appStream.Send(&si.ApplicationRequest{
+		RmID: testRMID,
+		New: []*si.AddApplicationRequest{{
+			ApplicationID: "app-1",
+			QueueName:     "root.default",
+			PartitionName: "default",
+			Ugi:           &si.UserGroupInformation{User: "testuser"},
+		}},
+	})
+	assert.NilError(t, err, "application send failed")
+	appResponse, err := appStream.Recv()
+	assert.NilError(t, err, "application response failed")
+	assert.Equal(t, len(appResponse.GetAccepted()), 1, "application should have been accepted")
+
+	// add an ask, schedule and wait for the allocation
+	allocStream, err := client.UpdateAllocation(ctx)
+	assert.NilError(t, err, "allocation stream creation failed")
+	err = allocStream.Send(&si.AllocationRequest{
+		RmID: testRMID,
+		Allocations: []*si.Allocation{{
+			AllocationKey:    "alloc-1",
+			ApplicationID:    "app-1",
+			PartitionName:    "default",
+			ResourcePerAlloc: &si.Resource{Resources: map[string]*si.Quantity{"vcore": {Value: 1}}},
+		}},
+	})
+	assert.NilError(t, err, "allocation send failed")
+	partitionName := common.GetNormalizedPartitionName("default", testRMID)
+	err = common.WaitForCondition(10*time.Millisecond, 5*time.Second, func() bool {
+		app := sched.GetClusterContext().GetApplication("app-1", partitionName)
+		return app != nil && app.GetAllocationAsk("alloc-1") != nil
+	})
+	assert.NilError(t, err, "ask was not added to the application")
+	sched.MultiStepSchedule(1)
+	allocResponse, err := allocStream.Recv()
+	assert.NilError(t, err, "allocation response failed")
+	allocated := allocResponse.GetNew()
+	assert.Equal(t, len(allocated), 1, "expected one allocation")
+	assert.Equal(t, allocated[0].GetAllocationKey(), "alloc-1", "unexpected allocation returned")
+	assert.Equal(t, allocated[0].GetNodeID(), "node-1", "unexpected node returned")
+}
@@ -0,0 +1,468 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package accounting
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-core/pkg/common"
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-core/pkg/locking"
+	"github.com/apache/yunikorn-core/pkg/log"
+	"github.com/apache/yunikorn-core/pkg/scheduler/objects"
+)
+
+// SnapshotVersion is the version of the ledger layout written by this scheduler.
+// A snapshot with a different version is not restored.
+const SnapshotVersion = 1
+
+// Billing periods supported by the ledger. All periods start at a boundary in UTC, weeks start on Monday.
+const (
+	PeriodHour  = "hour"
+	PeriodDay   = "day"
+	PeriodWeek  = "week"
+	PeriodMonth = "month"
+)
+
+var once sync.Once
+var ledger *Ledger
+
+// Store persists the ledger. Only the last saved snapshot needs to be kept.
+// Implementations must be safe for concurrent use.
+type Store interface {
+	// Save replaces the stored snapshot.
+	Save(snapshot *Snapshot) error
+	// Load returns the stored snapshot, or nil without an error if nothing has been stored yet.
+	Load() (*Snapshot, error)
+}
+
+// Snapshot is the content of the ledger at a point in time.
+type Snapshot struct {
+	Version      int                 `json:"version"`
+	Time         time.Time           `json:"time"`
+	Periods      []*Period           `json:"periods,omitempty"`
+	Applications []*ApplicationUsage `json:"applications,omitempty"`
+}
+
+// Period is one billing period with the usage recorded in it.
+type Period struct {
+	Start   time.Time      `json:"start"`
+	End     time.Time      `json:"end"`
+	Records []*UsageRecord `json:"records,omitempty"`
+
+	index map[recordKey]*UsageRecord
+}
+
+// UsageRecord is the usage of one user and group in a queue on one instance type.
+// The usage is expressed in resource-seconds per resource type.
+type UsageRecord struct {
+	Partition    string           `json:"partition"`
+	Queue        string           `json:"queue"`
+	User         string           `json:"user"`
+	Group        string           `json:"group,omitempty"`
+	InstanceType string           `json:"instanceType"`
+	Usage        map[string]int64 `json:"usage,omitempty"`
+}
+
+// ApplicationUsage is the usage of an application that has been recorded already.
+// The usage is keyed on the instance type and resource type.
+// A completed application is kept until the next collection to ignore a collection running concurrently.
+type ApplicationUsage struct {
+	Partition     string                      `json:"partition"`
+	ApplicationID string                      `json:"applicationID"`
+	Usage         map[string]map[string]int64 `json:"usage,omitempty"`
+	Completed     bool                        `json:"completed,omitempty"`
+}
+
+type recordKey struct {
+	partition    string
+	queue        string
+	user         string
+	group        string
+	instanceType string
+}
+
+// Ledger aggregates the usage of applications per billing period for chargeback.
+// The usage of running applications is recorded as a delta against what has been recorded before: the usage
+// is attributed to the period in which it was collected. Completed applications record their final usage.
+type Ledger struct {
+	enabled    bool
+	periodType string
+	retained   int
+	prices     PriceTable
+	periods    []*Period                    // oldest first, the last period is the current one
+	accounted  map[string]*ApplicationUsage // usage recorded for running applications, keyed on partition and application ID
+
+	locking.RWMutex
+}
+
+// GetLedger returns the ledger singleton. The configuration is read from the config map on creation
+// and updated when the config map changes.
+func GetLedger() *Ledger {
+	once.Do(func() {
+		ledger = newLedger()
+		configs.AddConfigMapCallback("accounting", ledger.updateConfig)
+	})
+	return ledger
+}
+
+func newLedger() *Ledger {
+	l := &Ledger{
+		accounted: make(map[string]*ApplicationUsage),
+	}
+	l.updateConfig()
+	return l
+}
+
+// updateConfig reads the settings from the config map. An invalid billing period or price table is logged
+// and the previous value is kept.
+func (l *Ledger) updateConfig() {
+	configMap := configs.GetConfigMap()
+	enabled := common.GetConfigurationBool(configMap, configs.AccountingEnabled, configs.DefaultAccountingEnabled)
+	retained := common.GetConfigurationUint(configMap, configs.AccountingRetainedPeriods, configs.DefaultAccountingRetainedPeriods)
+	periodType := configs.DefaultAccountingBillingPeriod
+	if value, ok := configMap[configs.AccountingBillingPeriod]; ok {
+		periodType = strings.ToLower(value)
+	}
+	var prices PriceTable
+	var err error
+	if value, ok := configMap[configs.AccountingPrices]; ok {
+		if prices, err = ParsePriceTable(value); err != nil {
+			log.Log(log.Accounting).Warn("Failed to parse price table, keeping current prices",
+				zap.Error(err))
+		}
+	}
+
+	l.Lock()
+	defer l.Unlock()
+	l.enabled = enabled
+	l.retained = int(max(retained, 1))
+	switch periodType {
+	case PeriodHour, PeriodDay, PeriodWeek, PeriodMonth:
+		l.periodType = periodType
+	default:
+		log.Log(log.Accounting).Warn("Unknown billing period, keeping current period",
+			zap.String("billingPeriod", periodType))
+		if l.periodType == "" {
+			l.periodType = configs.DefaultAccountingBillingPeriod
+		}
+	}
+	if err == nil {
+		l.prices = prices
+	}
+	l.pruneLocked()
+}
+
+// IsEnabled returns true if usage is recorded.
+func (l *Ledger) IsEnabled() bool {
+	l.RLock()
+	defer l.RUnlock()
+	return l.enabled
+}
+
+// GetPrices returns the price table used for the reports.
+func (l *Ledger) GetPrices() PriceTable {
+	l.RLock()
+	defer l.RUnlock()
+	return l.prices
+}
+
+// Record adds the usage of the application that has not been recorded yet to the current billing period.
+// A completed application is not charged any further: the summary must contain the final usage.
+func (l *Ledger) Record(partition string, summary *objects.ApplicationSummary, completed bool) {
+	l.recordAt(time.Now(), partition, summary, completed)
+}
+
+func (l *Ledger) recordAt(now time.Time, partition string, summary *objects.ApplicationSummary, completed bool) {
+	if summary == nil {
+		return
+	}
+	usage := totalUsage(summary)
+	group := ""
+	if len(summary.Groups) > 0 {
+		group = summary.Groups[0]
+	}
+	appKey := partition + "/" + summary.ApplicationID
+
+	l.Lock()
+	defer l.Unlock()
+	if !l.enabled {
+		return
+	}
+	var previous map[string]map[string]int64
+	if recorded, ok := l.accounted[appKey]; ok {
+		// the summary of a collection that raced with the completion is outdated
+		if recorded.Completed && !completed {
+			return
+		}
+		previous = recorded.Usage
+	}
+	period := l.getPeriodLocked(now)
+	for instType, resUsage := range usage {
+		for resType, value := range resUsage {
+			// usage can only grow: a lower value is left from before a restart of the scheduler
+			delta := value - previous[instType][resType]
+			if delta <= 0 {
+				continue
+			}
+			period.add(recordKey{
+				partition:    partition,
+				queue:        summary.Queue,
+				user:         summary.User,
+				group:        group,
+				instanceType: instType,
+			}, resType, delta)
+		}
+	}
+	// keep the highest value recorded to not charge twice after a drop
+	for instType, resUsage := range previous {
+		for resType, value := range resUsage {
+			if value > usage[instType][resType] {
+				if usage[instType] == nil {
+					usage[instType] = make(map[string]int64)
+				}
+				usage[instType][resType] = value
+			}
+		}
+	}
+	l.accounted[appKey] = &ApplicationUsage{
+		Partition:     partition,
+		ApplicationID: summary.ApplicationID,
+		Usage:         usage,
+		Completed:     completed,
+	}
+}
+
+// RemoveCompleted stops tracking the applications that completed since the last call.
+// Must be called before a collection of the running usage starts, not while it runs.
+func (l *Ledger) RemoveCompleted() {
+	l.Lock()
+	defer l.Unlock()
+	for appKey, usage := range l.accounted {
+		if usage.Completed {
+			delete(l.accounted, appKey)
+		}
+	}
+}
+
+// totalUsage combines the used, preempted and placeholder usage of the application: all of it is charged.
+func totalUsage(summary *objects.ApplicationSummary) map[string]map[string]int64 {
+	usage := make(map[string]map[string]int64)
+	for _, tracked := range []map[string]map[string]int64{
+		summary.ResourceUsage.DAOMap(),
+		summary.PreemptedResource.DAOMap(),
+		summary.PlaceholderResource.DAOMap(),
+	} {
+		for instType, resUsage := range tracked {
+			if usage[instType] == nil {
+				usage[instType] = make(map[string]int64)
+			}
+			for resType, value := range resUsage {
+				usage[instType][resType] += value
+			}
+		}
+	}
+	return usage
+}
+
+// getPeriodLocked returns the period the time falls in, a new period is started if the current one has ended.
+// Must be called while holding the lock.
+func (l *Ledger) getPeriodLocked(now time.Time) *Period {
+	var last *Period
+	if len(l.periods) > 0 {
+		last = l.periods[len(l.periods)-1]
+		if now.Before(last.End) {
+			return last
+		}
+	}
+	start, end := periodBounds(now, l.periodType)
+	// a change of the billing period type must not create overlapping periods
+	if last != nil && start.Before(last.End) {
+		start = last.End
+	}
+	period := &Period{
+		Start: start,
+		End:   end,
+		index: make(map[recordKey]*UsageRecord),
+	}
+	l.periods = append(l.periods, period)
+	l.pruneLocked()
+	return period
+}
+
+// pruneLocked removes the oldest periods above the retention limit. Must be called while holding the lock.
+func (l *Ledger) pruneLocked() {
+	if excess := len(l.periods) - l.retained; l.retained > 0 && excess > 0 {
+		l.periods = l.periods[excess:]
+	}
+}
+
+// periodBounds returns the start and end of the billing period of the given type that contains the time.
+func periodBounds(t time.Time, periodType string) (time.Time, time.Time) {
+	t = t.UTC()
+	switch periodType {
+	case PeriodHour:
+		start := t.Truncate(time.Hour)
+		return start, start.Add(time.Hour)
+	case PeriodDay:
+		start := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 0, 1)
+	case PeriodWeek:
+		// weeks start on Monday
+		offset := (int(t.Weekday()) + 6) % 7
+		start := time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 0, 7)
+	default:
+		start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, 0)
+	}
+}
+
+func (p *Period) add(key recordKey, resType string, value int64) {
+	record, ok := p.index[key]
+	if !ok {
+		record = &UsageRecord{
+			Partition:    key.partition,
+			Queue:        key.queue,
+			User:         key.user,
+			Group:        key.group,
+			InstanceType: key.instanceType,
+			Usage:        make(map[string]int64),
+		}
+		p.index[key] = record
+		p.Records = append(p.Records, record)
+	}
+	// saturate instead of wrapping around on overflow
+	if record.Usage[resType] > math.MaxInt64-value {
+		record.Usage[resType] = math.MaxInt64
+		return
+	}
+	record.Usage[resType] += value
+}
+
+// clone returns a deep copy of the period.
+func (p *Period) clone() *Period {
+	result := &Period{
+		Start: p.Start,
+		End:   p.End,
+	}
+	for _, record := range p.Records {
+		usage := make(map[string]int64, len(record.Usage))
+		for resType, value := range record.Usage {
+			usage[resType] = value
+		}
+		clone := *record
+		clone.Usage = usage
+		result.Records = append(result.Records, &clone)
+	}
+	return result
+}
+
+// GetPeriods returns a copy of the retained billing periods, oldest first.
+func (l *Ledger) GetPeriods() []*Period {
+	l.RLock()
+	defer l.RUnlock()
+	periods := make([]*Period, 0, len(l.periods))
+	for _, period := range l.periods {
+		periods = append(periods, period.clone())
+	}
+	return periods
+}
+
+// Snapshot returns the content of the ledger to persist.
+func (l *Ledger) Snapshot() *Snapshot {
+	l.RLock()
+	defer l.RUnlock()
+	snapshot := &Snapshot{
+		Version: SnapshotVersion,
+		Time:    time.Now(),
+	}
+	for _, period := range l.periods {
+		snapshot.Periods = append(snapshot.Periods, period.clone())
+	}
+	for _, usage := range l.accounted {
+		snapshot.Applications = append(snapshot.Applications, usage)
+	}
+	sort.Slice(snapshot.Applications, func(i, j int) bool {
+		if snapshot.Applications[i].Partition != snapshot.Applications[j].Partition {
+			return snapshot.Applications[i].Partition < snapshot.Applications[j].Partition
+		}
+		return snapshot.Applications[i].ApplicationID < snapshot.Applications[j].ApplicationID
+	})
+	return snapshot
+}
+
+// Restore replaces the content of the ledger with the snapshot. The usage recorded for running applications
+// is restored to not charge it again when the applications are recovered.
+func (l *Ledger) Restore(snapshot *Snapshot) {
+	if snapshot == nil {
+		return
+	}
+	l.Lock()
+	defer l.Unlock()
+	l.periods = nil
+	for _, period := range snapshot.Periods {
+		restored := period.clone()
+		restored.index = make(map[recordKey]*UsageRecord, len(restored.Records))
+		for _, record := range restored.Records {
+			restored.index[recordKey{
+				partition:    record.Partition,
+				queue:        record.Queue,
+				user:         record.User,
+				group:        record.Group,
+				instanceType: record.InstanceType,
+			}] = record
+		}
+		l.periods = append(l.periods, restored)
+	}
+	l.pruneLocked()
+	l.accounted = make(map[string]*ApplicationUsage, len(snapshot.Applications))
+	for _, usage := range snapshot.Applications {
+		l.accounted[usage.Partition+"/"+usage.ApplicationID] = usage
+	}
+}
+
+// Clear removes all recorded usage.
+// Visible for testing
+func (l *Ledger) Clear() {
+	l.Lock()
+	defer l.Unlock()
+	l.periods = nil
+	l.accounted = make(map[string]*ApplicationUsage)
+}
+
+// decode unmarshals and checks the version of a stored snapshot.
+func decode(data []byte) (*Snapshot, error) {
+	snapshot := &Snapshot{}
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, fmt.Errorf("invalid accounting snapshot: %w", err)
+	}
+	if snapshot.Version != SnapshotVersion {
+		return nil, fmt.Errorf("unsupported accounting snapshot version %d, expected %d", snapshot.Version, SnapshotVersion)
+	}
+	return snapshot, nil
+}
@@ -0,0 +1,209 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package accounting
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+	"github.com/apache/yunikorn-core/pkg/scheduler/objects"
+)
+
+const testPartition = "[rm-1]default"
+
+func newSummary(appID, queue string, used map[string]map[string]resources.Quantity) *objects.ApplicationSummary {
+	return &objects.ApplicationSummary{
+		ApplicationID:       appID,
+		User:                "testuser",
+		Groups:              []string{"testgroup", "other"},
+		Queue:               queue,
+		ResourceUsage:       resources.NewTrackedResourceFromMap(used),
+		PreemptedResource:   resources.NewTrackedResource(),
+		PlaceholderResource: resources.NewTrackedResource(),
+	}
+}
+
+func TestPeriodBounds(t *testing.T) {
+	// Wednesday
+	now := time.Date(2026, 10, 14, 13, 45, 10, 0, time.UTC)
+	tests := []struct {
+		periodType string
+		start      time.Time
+		end        time.Time
+	}{
+		{PeriodHour, time.Date(2026, 10, 14, 13, 0, 0, 0, time.UTC), time.Date(2026, 10, 14, 14, 0, 0, 0, time.UTC)},
+		{PeriodDay, time.Date(2026, 10, 14, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC)},
+		{PeriodWeek, time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC), time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)},
+		{PeriodMonth, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.periodType, func(t *testing.T) {
+			start, end := periodBounds(now, tt.periodType)
+			assert.Equal(t, start, tt.start, "unexpected period start")
+			assert.Equal(t, end, tt.end, "unexpected period end")
+		})
+	}
+	// a Sunday still belongs to the week that started on Monday
+	start, _ := periodBounds(time.Date(2026, 10, 18, 23, 0, 0, 0, time.UTC), PeriodWeek)
+	assert.Equal(t, start, time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC), "unexpected week start")
+}
+
+func TestRecord(t *testing.T) {
+	l := newLedger()
+	now := time.Date(2026, 10, 14, 13, 0, 0, 0, time.UTC)
+
+	// first collection records everything
+	l.recordAt(now, testPartition, newSummary("app-1", "root.a", map[string]map[string]resources.Quantity{
+		"m5.large": {"vcore": 100, "memory": 1000},
+	}), false)
+	// second collection only records the growth
+	summary := newSummary("app-1", "root.a", map[string]map[string]resources.Quantity{
+		"m5.large": {"vcore": 150, "memory": 1000},
+		"c5.large": {"vcore": 10},
+	})
+	summary.PreemptedResource = resources.NewTrackedResourceFromMap(map[string]map[string]resources.Quantity{"m5.large": {"vcore": 5}})
+	l.recordAt(now, testPartition, summary, false)
+	periods := l.GetPeriods()
+	assert.Equal(t, len(periods), 1, "one period expected")
+	assert.Equal(t, len(periods[0].Records), 2, "one record per instance type expected")
+	for _, record := range periods[0].Records {
+		assert.Equal(t, record.User, "testuser")
+		assert.Equal(t, record.Group, "testgroup", "primary group expected")
+		assert.Equal(t, record.Queue, "root.a")
+		switch record.InstanceType {
+		case "m5.large":
+			assert.DeepEqual(t, record.Usage, map[string]int64{"vcore": 155, "memory": 1000})
+		case "c5.large":
+			assert.DeepEqual(t, record.Usage, map[string]int64{"vcore": 10})
+		default:
+			t.Fatalf("unexpected instance type %s", record.InstanceType)
+		}
+	}
+
+	// a lower usage after a restart is not charged again until it passes the recorded usage
+	l.recordAt(now, testPartition, newSummary("app-1", "root.a", map[string]map[string]resources.Quantity{
+		"m5.large": {"vcore": 160, "memory": 500},
+	}), false)
+	periods = l.GetPeriods()
+	for _, record := range periods[0].Records {
+		if record.InstanceType == "m5.large" {
+			assert.DeepEqual(t, record.Usage, map[string]int64{"vcore": 160, "memory": 1000})
+		}
+	}
+
+	// completion records the final usage, a collection that raced with it is ignored
+	l.recordAt(now, testPartition, newSummary("app-1", "root.a", map[string]map[string]resources.Quantity{
+		"m5.large": {"vcore": 170, "memory": 1000},
+	}), true)
+	l.recordAt(now, testPartition, newSummary("app-1", "root.a", map[string]map[string]resources.Quantity{
+		"m5.large": {"vcore": 165, "memory": 1000},
+	}), false)
+	l.RemoveCompleted()
+	assert.Equal(t, len(l.accounted), 0, "completed application should be removed")
+	periods = l.GetPeriods()
+	for _, record := range periods[0].Records {
+		if record.InstanceType == "m5.large" {
+			assert.DeepEqual(t, record.Usage, map[string]int64{"vcore": 170, "memory": 1000})
+		}
+	}
+
+	// a nil summary of an application that never ran is ignored
+	l.recordAt(now, testPartition, nil, true)
+
+	// nothing is recorded when disabled
+	configs.SetConfigMap(map[string]string{configs.AccountingEnabled: "false"})
+	defer configs.SetConfigMap(map[string]string{})
+	l.updateConfig()
+	assert.Assert(t, !l.IsEnabled(), "ledger should be disabled")
+	l.recordAt(now, testPartition, newSummary("app-2", "root.a", map[string]map[string]resources.Quantity{
+		"m5.large": {"vcore": 1},
+	}), true)
+	assert.Equal(t, len(l.GetPeriods()[0].Records), 2, "no new record expected")
+}
+
+func TestPeriodRetention(t *testing.T) {
+	configs.SetConfigMap(map[string]string{
+		configs.AccountingBillingPeriod:   "hour",
+		configs.AccountingRetainedPeriods: "2",
+	})
+	defer configs.SetConfigMap(map[string]string{})
+	l := newLedger()
+	start := time.Date(2026, 10, 14, 13, 30, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		l.recordAt(start.Add(time.Duration(i)*time.Hour), testPartition, newSummary("app-1", "root.a", map[string]map[string]resources.Quantity{
+			"m5.large": {"vcore": resources.Quantity(10 * (i + 1))},
+		}), false)
+	}
+	periods := l.GetPeriods()
+	assert.Equal(t, len(periods), 2, "oldest period should be removed")
+	assert.Equal(t, periods[0].Start, time.Date(2026, 10, 14, 14, 0, 0, 0, time.UTC))
+	assert.Equal(t, periods[1].Start, time.Date(2026, 10, 14, 15, 0, 0, 0, time.UTC))
+	// usage is attributed to the period it was collected in
+	assert.DeepEqual(t, periods[0].Records[0].Usage, map[string]int64{"vcore": 10})
+	assert.DeepEqual(t, periods[1].Records[0].Usage, map[string]int64{"vcore": 10})
+
+	// switching to a longer period does not overlap the current period
+	configs.SetConfigMap(map[string]string{configs.AccountingBillingPeriod: "day"})
+	l.updateConfig()
+	l.recordAt(start.Add(3*time.Hour), testPartition, newSummary("app-1", "root.a", map[string]map[string]resources.Quantity{
+		"m5.large": {"vcore": 40},
+	}), false)
+	periods = l.GetPeriods()
+	last := periods[len(periods)-1]
+	assert.Equal(t, last.Start, time.Date(2026, 10, 14, 16, 0, 0, 0, time.UTC), "period should start at the end of the previous one")
+	assert.Equal(t, last.End, time.Date(2026, 10, 15, 0, 0, 0, 0, time.UTC))
+
+	// an unknown period keeps the current setting
+	configs.SetConfigMap(map[string]string{configs.AccountingBillingPeriod: "year"})
+	l.updateConfig()
+	assert.Equal(t, l.periodType, PeriodDay, "billing period should not change")
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	l := newLedger()
+	now := time.Date(2026, 10, 14, 13, 0, 0, 0, time.UTC)
+	l.recordAt(now, testPartition, newSummary("app-1", "root.a", map[string]map[string]resources.Quantity{
+		"m5.large": {"vcore": 100},
+	}), false)
+	store := NewMemoryStore()
+	assert.NilError(t, store.Save(l.Snapshot()), "save failed")
+
+	restored := newLedger()
+	snapshot, err := store.Load()
+	assert.NilError(t, err, "load failed")
+	restored.Restore(snapshot)
+	assert.DeepEqual(t, restored.Snapshot().Periods, l.Snapshot().Periods, cmpopts.IgnoreUnexported(Period{}))
+	assert.DeepEqual(t, restored.Snapshot().Applications, l.Snapshot().Applications)
+
+	// the running application is not charged twice and records are merged
+	restored.recordAt(now, testPartition, newSummary("app-1", "root.a", map[string]map[string]resources.Quantity{
+		"m5.large": {"vcore": 120},
+	}), false)
+	periods := restored.GetPeriods()
+	assert.Equal(t, len(periods[0].Records), 1, "usage should be added to the restored record")
+	assert.DeepEqual(t, periods[0].Records[0].Usage, map[string]int64{"vcore": 120})
+
+	restored.Clear()
+	assert.Equal(t, len(restored.GetPeriods()), 0, "ledger should be empty")
+}
@@ -0,0 +1,90 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package accounting
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"gotest.tools/v3/assert"
+)
+
+func testSnapshot() *Snapshot {
+	return &Snapshot{
+		Version: SnapshotVersion,
+		Time:    time.Unix(1700000000, 0).UTC(),
+		Periods: []*Period{{
+			Start: time.Date(2023, 11, 1, 0, 0, 0, 0, time.UTC),
+			End:   time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC),
+			Records: []*UsageRecord{{
+				Partition:    "[rm-1]default",
+				Queue:        "root.default",
+				User:         "testuser",
+				Group:        "testgroup",
+				InstanceType: "m5.large",
+				Usage:        map[string]int64{"vcore": 3600},
+			}},
+		}},
+		Applications: []*ApplicationUsage{{
+			Partition:     "[rm-1]default",
+			ApplicationID: "app-1",
+			Usage:         map[string]map[string]int64{"m5.large": {"vcore": 3600}},
+		}},
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "accounting")
+	store, err := NewFileStore(dir)
+	assert.NilError(t, err, "store create failed")
+
+	// nothing saved yet
+	var snapshot *Snapshot
+	snapshot, err = store.Load()
+	assert.NilError(t, err, "load of empty store should not fail")
+	assert.Assert(t, snapshot == nil, "no snapshot expected")
+
+	expected := testSnapshot()
+	assert.NilError(t, store.Save(expected), "save failed")
+	snapshot, err = store.Load()
+	assert.NilError(t, err, "load failed")
+	assert.DeepEqual(t, snapshot, expected, cmpopts.IgnoreUnexported(Period{}))
+
+	// replace and check no temp files are left behind
+	expected.Applications = nil
+	assert.NilError(t, store.Save(expected), "second save failed")
+	var entries []os.DirEntry
+	entries, err = os.ReadDir(dir)
+	assert.NilError(t, err, "read dir failed")
+	assert.Equal(t, len(entries), 1, "only the snapshot file expected")
+	snapshot, err = store.Load()
+	assert.NilError(t, err, "load failed")
+	assert.Equal(t, len(snapshot.Applications), 0, "replaced snapshot expected")
+
+	// corrupt and old snapshots are not restored
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, snapshotFile), []byte("{not json"), 0o600))
+	_, err = store.Load()
+	assert.ErrorContains(t, err, "invalid accounting snapshot")
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, snapshotFile), []byte(`{"version": 0}`), 0o600))
+	_, err = store.Load()
+	assert.ErrorContains(t, err, "unsupported accounting snapshot version 0")
+}
@@ -0,0 +1,58 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package accounting
+
+import (
+	"encoding/json"
+
+	"github.com/apache/yunikorn-core/pkg/locking"
+)
+
+// MemoryStore keeps the encoded snapshot in memory. The snapshot is encoded like the FileStore does it
+// so the restored snapshot never shares objects with the saved one. Used in tests and if the ledger should
+// survive the restart of the scheduler inside the same process only.
+type MemoryStore struct {
+	data []byte
+
+	locking.Mutex
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+func (ms *MemoryStore) Save(snapshot *Snapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	ms.Lock()
+	defer ms.Unlock()
+	ms.data = data
+	return nil
+}
+
+func (ms *MemoryStore) Load() (*Snapshot, error) {
+	ms.Lock()
+	defer ms.Unlock()
+	if ms.data == nil {
+		return nil, nil
+	}
+	return decode(ms.data)
+}
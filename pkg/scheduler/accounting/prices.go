@@ -0,0 +1,63 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package accounting
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// DefaultInstanceType is the key in the price table with the prices for all instance types that are not listed.
+const DefaultInstanceType = "*"
+
+// PriceTable contains the price per unit of a resource type for one hour, keyed on the instance type and
+// the resource type. Resource types without a price are not charged.
+type PriceTable map[string]map[string]float64
+
+// ParsePriceTable parses the JSON price table from the config map, an empty value is an empty table.
+func ParsePriceTable(value string) (PriceTable, error) {
+	prices := PriceTable{}
+	if value == "" {
+		return prices, nil
+	}
+	if err := json.Unmarshal([]byte(value), &prices); err != nil {
+		return nil, fmt.Errorf("invalid price table: %w", err)
+	}
+	for instType, resPrices := range prices {
+		for resType, price := range resPrices {
+			if price < 0 {
+				return nil, fmt.Errorf("negative price %f for resource %s on instance type %s", price, resType, instType)
+			}
+		}
+	}
+	return prices, nil
+}
+
+// Price returns the price of the resource type on the instance type, falling back to the default instance type.
+func (pt PriceTable) Price(instType, resType string) float64 {
+	if price, ok := pt[instType][resType]; ok {
+		return price
+	}
+	return pt[DefaultInstanceType][resType]
+}
+
+// Cost returns the cost of the usage, in resource-seconds, of the resource type on the instance type.
+func (pt PriceTable) Cost(instType, resType string, usage int64) float64 {
+	return float64(usage) * pt.Price(instType, resType) / 3600
+}
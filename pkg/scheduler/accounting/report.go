@@ -0,0 +1,177 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package accounting
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Dimensions the usage in a report can be grouped by.
+const (
+	GroupByUser         = "user"
+	GroupByGroup        = "group"
+	GroupByQueue        = "queue"
+	GroupByInstanceType = "instanceType"
+)
+
+var (
+	ErrUnknownGroupBy = errors.New("unknown report grouping")
+	ErrNoPeriod       = errors.New("no billing period found")
+)
+
+// Report is the usage and cost of one partition in a billing period grouped by one dimension.
+type Report struct {
+	Partition string
+	Start     time.Time
+	End       time.Time
+	GroupBy   string
+	Entries   []*ReportEntry
+}
+
+// ReportEntry is the usage, in resource-seconds, and cost of one value of the grouping dimension.
+type ReportEntry struct {
+	Name      string
+	Usage     map[string]int64
+	Cost      map[string]float64
+	TotalCost float64
+}
+
+// GetReport builds the report of the partition for the billing period that contains the time, grouped by
+// the dimension. A zero time selects the current period. The cost is calculated with the current prices.
+func (l *Ledger) GetReport(partition string, at time.Time, groupBy string) (*Report, error) {
+	switch groupBy {
+	case GroupByUser, GroupByGroup, GroupByQueue, GroupByInstanceType:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownGroupBy, groupBy)
+	}
+	l.RLock()
+	defer l.RUnlock()
+	var period *Period
+	if at.IsZero() {
+		at = time.Now()
+	}
+	for _, p := range l.periods {
+		if !at.Before(p.Start) && at.Before(p.End) {
+			period = p
+			break
+		}
+	}
+	if period == nil {
+		// nothing recorded yet in the current period is not an error
+		if len(l.periods) == 0 || !at.Before(l.periods[len(l.periods)-1].End) {
+			start, end := periodBounds(at, l.periodType)
+			period = &Period{Start: start, End: end}
+		} else {
+			return nil, fmt.Errorf("%w: %s", ErrNoPeriod, at.UTC().Format(time.RFC3339))
+		}
+	}
+	report := &Report{
+		Partition: partition,
+		Start:     period.Start,
+		End:       period.End,
+		GroupBy:   groupBy,
+	}
+	// sum the usage per instance type first: the cost is calculated once per instance and resource type
+	usage := make(map[string]map[string]map[string]int64)
+	for _, record := range period.Records {
+		if record.Partition != partition {
+			continue
+		}
+		name := record.dimension(groupBy)
+		if usage[name] == nil {
+			usage[name] = make(map[string]map[string]int64)
+		}
+		if usage[name][record.InstanceType] == nil {
+			usage[name][record.InstanceType] = make(map[string]int64)
+		}
+		for resType, value := range record.Usage {
+			usage[name][record.InstanceType][resType] += value
+		}
+	}
+	for name, instUsage := range usage {
+		entry := &ReportEntry{
+			Name:  name,
+			Usage: make(map[string]int64),
+			Cost:  make(map[string]float64),
+		}
+		for instType, resUsage := range instUsage {
+			for resType, value := range resUsage {
+				cost := l.prices.Cost(instType, resType, value)
+				entry.Usage[resType] += value
+				entry.Cost[resType] += cost
+				entry.TotalCost += cost
+			}
+		}
+		report.Entries = append(report.Entries, entry)
+	}
+	sort.Slice(report.Entries, func(i, j int) bool {
+		return report.Entries[i].Name < report.Entries[j].Name
+	})
+	return report, nil
+}
+
+func (r *UsageRecord) dimension(groupBy string) string {
+	switch groupBy {
+	case GroupByGroup:
+		return r.Group
+	case GroupByQueue:
+		return r.Queue
+	case GroupByInstanceType:
+		return r.InstanceType
+	default:
+		return r.User
+	}
+}
+
+// WriteCSV writes the report as CSV with one row per entry and resource type.
+func (r *Report) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"periodStart", "periodEnd", r.GroupBy, "resource", "usage", "cost"}); err != nil {
+		return err
+	}
+	start := r.Start.UTC().Format(time.RFC3339)
+	end := r.End.UTC().Format(time.RFC3339)
+	for _, entry := range r.Entries {
+		resTypes := make([]string, 0, len(entry.Usage))
+		for resType := range entry.Usage {
+			resTypes = append(resTypes, resType)
+		}
+		sort.Strings(resTypes)
+		for _, resType := range resTypes {
+			if err := writer.Write([]string{
+				start,
+				end,
+				entry.Name,
+				resType,
+				strconv.FormatInt(entry.Usage[resType], 10),
+				strconv.FormatFloat(entry.Cost[resType], 'f', -1, 64),
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
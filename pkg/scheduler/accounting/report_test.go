@@ -0,0 +1,126 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package accounting
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+)
+
+func TestParsePriceTable(t *testing.T) {
+	prices, err := ParsePriceTable("")
+	assert.NilError(t, err, "empty table should parse")
+	assert.Equal(t, len(prices), 0, "empty table expected")
+
+	prices, err = ParsePriceTable(`{"*": {"vcore": 0.036, "memory": 0.0036}, "m5.large": {"vcore": 0.072}}`)
+	assert.NilError(t, err, "table should parse")
+	assert.Equal(t, prices.Price("m5.large", "vcore"), 0.072, "instance type price expected")
+	assert.Equal(t, prices.Price("m5.large", "memory"), 0.0036, "default price expected")
+	assert.Equal(t, prices.Price("c5.large", "vcore"), 0.036, "default price expected")
+	assert.Equal(t, prices.Price("c5.large", "gpu"), 0.0, "unpriced resource expected")
+	assert.Equal(t, prices.Cost("m5.large", "vcore", 7200), 0.144, "two hours of a vcore expected")
+
+	_, err = ParsePriceTable(`{"*": {"vcore": -1}}`)
+	assert.ErrorContains(t, err, "negative price")
+	_, err = ParsePriceTable(`not json`)
+	assert.ErrorContains(t, err, "invalid price table")
+
+	// an invalid table keeps the current prices
+	configs.SetConfigMap(map[string]string{configs.AccountingPrices: `{"*": {"vcore": 1}}`})
+	defer configs.SetConfigMap(map[string]string{})
+	l := newLedger()
+	configs.SetConfigMap(map[string]string{configs.AccountingPrices: `{"*": {"vcore": "free"}}`})
+	l.updateConfig()
+	assert.Equal(t, l.GetPrices().Price("any", "vcore"), 1.0, "prices should not change")
+}
+
+func TestGetReport(t *testing.T) {
+	configs.SetConfigMap(map[string]string{configs.AccountingPrices: `{"*": {"vcore": 3.6}, "gpu.large": {"vcore": 36}}`})
+	defer configs.SetConfigMap(map[string]string{})
+	l := newLedger()
+	now := time.Now()
+	summary := newSummary("app-1", "root.a", map[string]map[string]resources.Quantity{
+		"m5.large":  {"vcore": 100, "memory": 1000},
+		"gpu.large": {"vcore": 10},
+	})
+	l.recordAt(now, testPartition, summary, true)
+	summary = newSummary("app-2", "root.b", map[string]map[string]resources.Quantity{
+		"m5.large": {"vcore": 50},
+	})
+	summary.User = "other"
+	summary.Groups = nil
+	l.recordAt(now, testPartition, summary, true)
+	l.recordAt(now, "[rm-1]other", newSummary("app-3", "root.a", map[string]map[string]resources.Quantity{
+		"m5.large": {"vcore": 1000},
+	}), true)
+
+	report, err := l.GetReport(testPartition, time.Time{}, GroupByUser)
+	assert.NilError(t, err, "report failed")
+	assert.Equal(t, report.GroupBy, GroupByUser)
+	assert.Assert(t, !now.Before(report.Start) && now.Before(report.End), "current period expected")
+	assert.Equal(t, len(report.Entries), 2, "entry per user expected")
+	assert.Equal(t, report.Entries[0].Name, "other")
+	assert.DeepEqual(t, report.Entries[0].Usage, map[string]int64{"vcore": 50})
+	assert.Equal(t, report.Entries[0].TotalCost, 0.05)
+	assert.Equal(t, report.Entries[1].Name, "testuser")
+	assert.DeepEqual(t, report.Entries[1].Usage, map[string]int64{"vcore": 110, "memory": 1000})
+	assert.DeepEqual(t, report.Entries[1].Cost, map[string]float64{"vcore": 0.2, "memory": 0})
+
+	report, err = l.GetReport(testPartition, now, GroupByGroup)
+	assert.NilError(t, err, "report failed")
+	assert.Equal(t, len(report.Entries), 2, "entry per group expected")
+	assert.Equal(t, report.Entries[0].Name, "", "no group expected")
+	assert.Equal(t, report.Entries[1].Name, "testgroup")
+
+	report, err = l.GetReport(testPartition, now, GroupByQueue)
+	assert.NilError(t, err, "report failed")
+	assert.Equal(t, len(report.Entries), 2, "entry per queue expected")
+	assert.Equal(t, report.Entries[0].Name, "root.a")
+
+	report, err = l.GetReport(testPartition, now, GroupByInstanceType)
+	assert.NilError(t, err, "report failed")
+	assert.Equal(t, len(report.Entries), 2, "entry per instance type expected")
+	assert.Equal(t, report.Entries[0].Name, "gpu.large")
+	assert.Equal(t, report.Entries[0].TotalCost, 0.1)
+	assert.DeepEqual(t, report.Entries[1].Usage, map[string]int64{"vcore": 150, "memory": 1000})
+
+	var buf bytes.Buffer
+	assert.NilError(t, report.WriteCSV(&buf), "CSV export failed")
+	start := report.Start.UTC().Format(time.RFC3339)
+	end := report.End.UTC().Format(time.RFC3339)
+	assert.Equal(t, buf.String(), "periodStart,periodEnd,instanceType,resource,usage,cost\n"+
+		start+","+end+",gpu.large,vcore,10,0.1\n"+
+		start+","+end+",m5.large,memory,1000,0\n"+
+		start+","+end+",m5.large,vcore,150,0.15\n")
+
+	_, err = l.GetReport(testPartition, now, "node")
+	assert.Assert(t, errors.Is(err, ErrUnknownGroupBy), "unknown grouping should fail")
+	_, err = l.GetReport(testPartition, report.Start.Add(-time.Second), GroupByUser)
+	assert.Assert(t, errors.Is(err, ErrNoPeriod), "period before the retained periods should fail")
+	report, err = l.GetReport(testPartition, report.End, GroupByUser)
+	assert.NilError(t, err, "future period should return an empty report")
+	assert.Equal(t, len(report.Entries), 0, "no entries expected")
+}
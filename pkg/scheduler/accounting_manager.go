@@ -0,0 +1,153 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-core/pkg/locking"
+	"github.com/apache/yunikorn-core/pkg/log"
+	"github.com/apache/yunikorn-core/pkg/scheduler/accounting"
+)
+
+// accountingManager periodically records the usage of the running applications in the ledger and saves the
+// ledger in the store. Completed applications are recorded by the partition when they are removed.
+// A standby scheduler does not collect or save: the leader owns the ledger.
+type accountingManager struct {
+	context  *ClusterContext
+	store    accounting.Store // nil if the ledger is not persisted
+	standby  func() bool
+	stopChan chan struct{}
+
+	collectLock locking.Mutex // serialises the collections
+	locking.Mutex
+}
+
+// newAccountingManager restores the ledger from the store. A ledger that cannot be loaded fails the start:
+// silently starting with an empty ledger would lose the recorded usage.
+func newAccountingManager(cc *ClusterContext, store accounting.Store, standby func() bool) (*accountingManager, error) {
+	am := &accountingManager{
+		context: cc,
+		store:   store,
+		standby: standby,
+	}
+	if err := am.load(); err != nil {
+		return nil, err
+	}
+	return am, nil
+}
+
+// load restores the ledger from the store, a no-op if the ledger is not persisted.
+func (am *accountingManager) load() error {
+	if am.store == nil {
+		return nil
+	}
+	snapshot, err := am.store.Load()
+	if err != nil {
+		return err
+	}
+	if snapshot != nil {
+		accounting.GetLedger().Restore(snapshot)
+		log.Log(log.Accounting).Info("Restored accounting ledger",
+			zap.Time("snapshotTime", snapshot.Time),
+			zap.Int("periods", len(snapshot.Periods)))
+	}
+	return nil
+}
+
+// promote reloads the ledger saved by the previous leader. The ledger loaded at start is kept if the store
+// cannot be read.
+func (am *accountingManager) promote() {
+	if err := am.load(); err != nil {
+		log.Log(log.Accounting).Error("Failed to reload accounting ledger on promotion", zap.Error(err))
+	}
+}
+
+// start the periodic collection, the interval is read from the config map.
+func (am *accountingManager) start() {
+	interval := readDuration(configs.AccountingCollectInterval, configs.DefaultAccountingCollectInterval)
+	if interval <= 0 {
+		log.Log(log.Accounting).Info("Periodic usage collection disabled, only completed applications are recorded")
+		return
+	}
+	am.Lock()
+	defer am.Unlock()
+	stopChan := make(chan struct{})
+	am.stopChan = stopChan
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopChan:
+				return
+			case <-ticker.C:
+				am.collectAndSave()
+			}
+		}
+	}()
+}
+
+// stop the periodic collection and save the final ledger.
+func (am *accountingManager) stop() {
+	am.Lock()
+	if am.stopChan != nil {
+		close(am.stopChan)
+		am.stopChan = nil
+	}
+	am.Unlock()
+	am.collectAndSave()
+}
+
+func (am *accountingManager) collectAndSave() {
+	if am.standby() {
+		return
+	}
+	am.collect()
+	if err := am.save(); err != nil {
+		log.Log(log.Accounting).Error("Failed to save accounting ledger", zap.Error(err))
+	}
+}
+
+// collect records the usage of all running applications up to now.
+func (am *accountingManager) collect() {
+	ledger := accounting.GetLedger()
+	if !ledger.IsEnabled() {
+		return
+	}
+	am.collectLock.Lock()
+	defer am.collectLock.Unlock()
+	ledger.RemoveCompleted()
+	for _, pc := range am.context.GetPartitionMapClone() {
+		for _, app := range pc.GetApplications() {
+			ledger.Record(pc.Name, app.GetUsageSummary(pc.RmID), false)
+		}
+	}
+}
+
+// save writes the ledger to the store, a no-op if the ledger is not persisted.
+func (am *accountingManager) save() error {
+	if am.store == nil {
+		return nil
+	}
+	return am.store.Save(accounting.GetLedger().Snapshot())
+}
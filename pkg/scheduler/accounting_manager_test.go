@@ -0,0 +1,100 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+	"github.com/apache/yunikorn-core/pkg/scheduler/accounting"
+	"github.com/apache/yunikorn-core/pkg/scheduler/objects"
+)
+
+func TestAccountingManager(t *testing.T) {
+	setupUGM()
+	ledger := accounting.GetLedger()
+	ledger.Clear()
+	defer ledger.Clear()
+	store := accounting.NewMemoryStore()
+	res, err := resources.NewResourceFromConf(map[string]string{"vcore": "1"})
+	assert.NilError(t, err, "failed to create resource")
+
+	context := createTestContext(t, pName)
+	partition := context.GetPartition(pName)
+	addStateTestNodes(t, partition)
+	app := newApplication(appID1, pName, defQueue)
+	assert.NilError(t, partition.AddApplication(app), "failed to add app-1")
+	_, _, err = partition.UpdateAllocation(newAllocation(allocKey, appID1, nodeID1, res))
+	assert.NilError(t, err, "failed to add allocation alloc-1")
+	app.GetAllocation(allocKey).SetBindTime(time.Now().Add(-10 * time.Second))
+
+	// the running application is collected and the ledger saved
+	am, err := newAccountingManager(context, store, func() bool { return false })
+	assert.NilError(t, err, "failed to create accounting manager")
+	am.collectAndSave()
+	report, err := ledger.GetReport(partition.Name, time.Time{}, accounting.GroupByUser)
+	assert.NilError(t, err, "failed to get report")
+	assert.Equal(t, len(report.Entries), 1, "expected usage of one user")
+	assert.Equal(t, report.Entries[0].Name, "testuser")
+	running := report.Entries[0].Usage["vcore"]
+	assert.Assert(t, running >= 10, "expected at least 10 vcore seconds, got %d", running)
+
+	// the completed application records its final usage once
+	app.RemoveAllAllocations()
+	app.SetState(objects.Completed.String())
+	partition.moveTerminatedApp(appID1)
+	am.collect()
+	report, err = ledger.GetReport(partition.Name, time.Time{}, accounting.GroupByQueue)
+	assert.NilError(t, err, "failed to get report")
+	assert.Equal(t, report.Entries[0].Name, defQueue)
+	final := report.Entries[0].Usage["vcore"]
+	assert.Assert(t, final >= running && final <= running+1, "completion should only add the remaining usage, got %d", final)
+	assert.Equal(t, len(ledger.Snapshot().Applications), 0, "completed application should not be tracked")
+
+	// restart restores the ledger from the store
+	assert.NilError(t, am.save(), "failed to save ledger")
+	ledger.Clear()
+	_, err = newAccountingManager(context, store, func() bool { return false })
+	assert.NilError(t, err, "failed to restore ledger")
+	report, err = ledger.GetReport(partition.Name, time.Time{}, accounting.GroupByUser)
+	assert.NilError(t, err, "failed to get report")
+	assert.Equal(t, report.Entries[0].Usage["vcore"], final, "usage not restored")
+
+	// a standby neither collects nor saves
+	standbyStore := accounting.NewMemoryStore()
+	am, err = newAccountingManager(context, standbyStore, func() bool { return true })
+	assert.NilError(t, err, "failed to create accounting manager")
+	am.stop()
+	snapshot, err := standbyStore.Load()
+	assert.NilError(t, err, "failed to load ledger")
+	assert.Assert(t, snapshot == nil, "standby should not save the ledger")
+
+	// promotion reloads the ledger saved by the leader
+	ledger.Clear()
+	am, err = newAccountingManager(context, store, func() bool { return true })
+	assert.NilError(t, err, "failed to create accounting manager")
+	ledger.Clear()
+	am.promote()
+	report, err = ledger.GetReport(partition.Name, time.Time{}, accounting.GroupByUser)
+	assert.NilError(t, err, "failed to get report")
+	assert.Equal(t, report.Entries[0].Usage["vcore"], final, "ledger not reloaded on promotion")
+}
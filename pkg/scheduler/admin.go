@@ -0,0 +1,241 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-core/pkg/common/security"
+	"github.com/apache/yunikorn-core/pkg/log"
+	"github.com/apache/yunikorn-core/pkg/scheduler/objects"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+// ErrAdminAccessDenied is returned when the requesting user is not an administrator of the queue the action applies to.
+var ErrAdminAccessDenied = errors.New("admin access denied")
+
+// checkAdminAccess returns an ErrAdminAccessDenied based error if the user has no admin access to the queue.
+func checkAdminAccess(queue *objects.Queue, user security.UserGroup) error {
+	if queue == nil || !queue.CheckAdminAccess(user) {
+		queuePath := ""
+		if queue != nil {
+			queuePath = queue.QueuePath
+		}
+		return fmt.Errorf("%w: user %s on queue %s", ErrAdminAccessDenied, user.User, queuePath)
+	}
+	return nil
+}
+
+// nodeStateEvent is a node state change requested by an administrator, processed on the scheduler event loop.
+type nodeStateEvent struct {
+	partition   *PartitionContext
+	nodeID      string
+	event       objects.NodeEvent
+	reason      string
+	gracePeriod time.Duration
+	release     bool
+	result      chan error
+}
+
+func requestedBy(user security.UserGroup) string {
+	return "requested by " + user.User
+}
+
+// ChangeQueueState stops or starts the queue on request of an administrator of the queue.
+func (pc *PartitionContext) ChangeQueueState(queuePath string, event objects.ObjectEvent, user security.UserGroup) error {
+	queue := pc.GetQueue(queuePath)
+	if queue == nil {
+		return fmt.Errorf("queue %s not found in partition %s", queuePath, pc.Name)
+	}
+	if err := checkAdminAccess(queue, user); err != nil {
+		return err
+	}
+	if err := queue.ChangeState(event, requestedBy(user)); err != nil {
+		return err
+	}
+	log.Log(log.SchedPartition).Info("queue state changed by administrator",
+		zap.String("queue", queuePath),
+		zap.String("event", event.String()),
+		zap.String("user", user.User))
+	return nil
+}
+
+// MoveApplication moves the application to another leaf queue on request of an administrator of both the
+// current and the target queue.
+func (pc *PartitionContext) MoveApplication(appID, queuePath string, user security.UserGroup) error {
+	app := pc.GetApplication(appID)
+	if app == nil {
+		return fmt.Errorf("application %s not found in partition %s", appID, pc.Name)
+	}
+	target := pc.GetQueue(queuePath)
+	if target == nil {
+		return fmt.Errorf("queue %s not found in partition %s", queuePath, pc.Name)
+	}
+	if err := checkAdminAccess(app.GetQueue(), user); err != nil {
+		return err
+	}
+	if err := checkAdminAccess(target, user); err != nil {
+		return err
+	}
+	return app.MoveQueue(target, requestedBy(user))
+}
+
+// SetApplicationPriority sets the priority of the application on request of an administrator of the queue.
+func (pc *PartitionContext) SetApplicationPriority(appID string, priority int32, user security.UserGroup) error {
+	app := pc.GetApplication(appID)
+	if app == nil {
+		return fmt.Errorf("application %s not found in partition %s", appID, pc.Name)
+	}
+	if err := checkAdminAccess(app.GetQueue(), user); err != nil {
+		return err
+	}
+	app.SetPriority(priority, requestedBy(user))
+	log.Log(log.SchedPartition).Info("application priority changed by administrator",
+		zap.String("appID", appID),
+		zap.Int32("priority", priority),
+		zap.String("user", user.User))
+	return nil
+}
+
+// checkAdvanceReservationAccess checks the user is an administrator of the booking queue. A reservation that books
+// capacity on all nodes of the partition requires admin access to the root queue.
+func (pc *PartitionContext) checkAdvanceReservationAccess(ar *AdvanceReservation, user security.UserGroup) error {
+	if len(ar.Nodes) == 0 {
+		return checkAdminAccess(pc.root, user)
+	}
+	return checkAdminAccess(pc.GetQueue(ar.QueuePath), user)
+}
+
+// BookAdvanceReservation adds the advance reservation on request of an administrator of the booking queue.
+func (pc *PartitionContext) BookAdvanceReservation(ar *AdvanceReservation, now time.Time, user security.UserGroup) error {
+	if ar == nil {
+		return fmt.Errorf("advance reservation must have an ID")
+	}
+	if pc.GetQueue(ar.QueuePath) == nil {
+		return fmt.Errorf("advance reservation %s: queue %s does not exist", ar.ID, ar.QueuePath)
+	}
+	if err := pc.checkAdvanceReservationAccess(ar, user); err != nil {
+		return err
+	}
+	if err := pc.AddAdvanceReservation(ar, now); err != nil {
+		return err
+	}
+	log.Log(log.SchedPartition).Info("advance reservation booked by administrator",
+		zap.String("id", ar.ID),
+		zap.String("user", user.User))
+	return nil
+}
+
+// CancelAdvanceReservation removes the advance reservation on request of an administrator of the booking queue.
+func (pc *PartitionContext) CancelAdvanceReservation(id string, user security.UserGroup) error {
+	ar := pc.getAdvanceReservation(id)
+	if ar == nil {
+		return fmt.Errorf("advance reservation %s does not exist", id)
+	}
+	if err := pc.checkAdvanceReservationAccess(ar, user); err != nil {
+		return err
+	}
+	if err := pc.RemoveAdvanceReservation(id); err != nil {
+		return err
+	}
+	log.Log(log.SchedPartition).Info("advance reservation cancelled by administrator",
+		zap.String("id", id),
+		zap.String("user", user.User))
+	return nil
+}
+
+// ForceReleaseAllocation releases the allocation on request of an administrator of the queue. The allocation is
+// removed from the scheduler directly and the RM is notified of the release.
+func (cc *ClusterContext) ForceReleaseAllocation(partition *PartitionContext, appID, allocationKey string, user security.UserGroup) error {
+	app := partition.GetApplication(appID)
+	if app == nil {
+		return fmt.Errorf("application %s not found in partition %s", appID, partition.Name)
+	}
+	if err := checkAdminAccess(app.GetQueue(), user); err != nil {
+		return err
+	}
+	alloc := app.GetAllocation(allocationKey)
+	if alloc == nil {
+		return fmt.Errorf("allocation %s not found for application %s", allocationKey, appID)
+	}
+	// the release is started by the scheduler: the RM must still stop the workload of the allocation
+	message := "allocation force released, " + requestedBy(user)
+	partition.removeAllocation(&si.AllocationRelease{
+		PartitionName:   partition.Name,
+		ApplicationID:   appID,
+		AllocationKey:   allocationKey,
+		TerminationType: si.TerminationType_PREEMPTED_BY_SCHEDULER,
+		Message:         message,
+	})
+	// scheduler initiated releases are not returned by the partition, check the application instead
+	if app.GetAllocation(allocationKey) != nil {
+		return fmt.Errorf("allocation %s could not be released", allocationKey)
+	}
+	app.RecordForceRelease(alloc, requestedBy(user))
+	cc.notifyRMAllocationReleased(partition.RmID, partition.Name, []*objects.Allocation{alloc}, si.TerminationType_PREEMPTED_BY_SCHEDULER, message)
+	log.Log(log.SchedContext).Info("allocation force released by administrator",
+		zap.String("appID", appID),
+		zap.String("allocationKey", allocationKey),
+		zap.String("user", user.User))
+	return nil
+}
+
+// ChangeNodeState moves the node to the next state of the node lifecycle on request of an administrator of the root
+// queue. The change is processed on the scheduler event loop, the call blocks until the change is processed.
+func (cc *ClusterContext) ChangeNodeState(partition *PartitionContext, nodeID string, event objects.NodeEvent, reason string, gracePeriod time.Duration, release bool, user security.UserGroup) error {
+	if err := checkAdminAccess(partition.root, user); err != nil {
+		return err
+	}
+	if reason == "" {
+		reason = requestedBy(user)
+	} else {
+		reason += ", " + requestedBy(user)
+	}
+	ev := &nodeStateEvent{
+		partition:   partition,
+		nodeID:      nodeID,
+		event:       event,
+		reason:      reason,
+		gracePeriod: gracePeriod,
+		release:     release,
+		result:      make(chan error, 1),
+	}
+	// a context outside the event system processes the change directly
+	if cc.schedulerEventHandler == nil {
+		cc.handleNodeStateEvent(ev)
+	} else {
+		cc.schedulerEventHandler.HandleEvent(ev)
+	}
+	if err := <-ev.result; err != nil {
+		return err
+	}
+	log.Log(log.SchedContext).Info("node state changed by administrator",
+		zap.String("nodeID", nodeID),
+		zap.String("event", event.String()),
+		zap.String("user", user.User))
+	return nil
+}
+
+func (cc *ClusterContext) handleNodeStateEvent(ev *nodeStateEvent) {
+	ev.result <- cc.UpdateNodeState(ev.partition, ev.nodeID, ev.event, ev.reason, ev.gracePeriod, ev.release)
+}
@@ -0,0 +1,186 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"errors"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+	"github.com/apache/yunikorn-core/pkg/common/security"
+	"github.com/apache/yunikorn-core/pkg/scheduler/objects"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+var (
+	adminUser = security.UserGroup{User: "admin", Groups: []string{"admins"}}
+	otherUser = security.UserGroup{User: "other", Groups: []string{"others"}}
+)
+
+// context with a partition: root (admin ACL) -> default, other, small (max memory 5)
+// and a single node with memory 100
+func createAdminTestContext(t *testing.T) (*ClusterContext, *PartitionContext) {
+	context := createTestContext(t, pName)
+	conf := configs.PartitionConfig{
+		Name: pName,
+		Queues: []configs.QueueConfig{
+			{
+				Name:      "root",
+				Parent:    true,
+				SubmitACL: "*",
+				AdminACL:  "admin",
+				Queues: []configs.QueueConfig{
+					{Name: "default"},
+					{Name: "other"},
+					{Name: "small", Resources: configs.Resources{Max: map[string]string{"memory": "5"}}},
+				},
+			},
+		},
+	}
+	partition, err := newPartitionContext(conf, rmID, context, false)
+	assert.NilError(t, err, "partition create should not have failed with error")
+	context.partitions[partition.Name] = partition
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 100})
+	assert.NilError(t, partition.AddNode(newNodeMaxResource(nodeID1, res)), "node add failed")
+	return context, partition
+}
+
+func TestChangeQueueState(t *testing.T) {
+	_, partition := createAdminTestContext(t)
+	err := partition.ChangeQueueState("root.unknown", objects.Stop, adminUser)
+	assert.ErrorContains(t, err, "not found")
+	err = partition.ChangeQueueState("root.default", objects.Stop, otherUser)
+	assert.Assert(t, errors.Is(err, ErrAdminAccessDenied), "non admin should be denied: %v", err)
+
+	queue := partition.GetQueue("root.default")
+	assert.NilError(t, partition.ChangeQueueState("root.default", objects.Stop, adminUser), "stop failed")
+	assert.Assert(t, queue.IsStopped(), "queue not stopped")
+	assert.NilError(t, partition.ChangeQueueState("root.default", objects.Start, adminUser), "start failed")
+	assert.Assert(t, queue.IsRunning(), "queue not started")
+	assert.Assert(t, partition.ChangeQueueState("root.default", objects.Remove, adminUser) != nil, "remove should not be allowed")
+	assert.Assert(t, partition.ChangeQueueState("root", objects.Stop, adminUser) != nil, "root stop should not be allowed")
+}
+
+func TestMoveApplication(t *testing.T) {
+	_, partition := createAdminTestContext(t)
+	app := newApplication(appID1, pName, "root.default")
+	assert.NilError(t, partition.AddApplication(app), "add application to partition should not have failed")
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 10})
+	_, _, err := partition.UpdateAllocation(newAllocation(allocKey, appID1, nodeID1, res))
+	assert.NilError(t, err, "allocation add failed")
+	assert.NilError(t, app.AddAllocationAsk(newAllocationAsk(allocKey2, appID1, res)), "ask add failed")
+
+	err = partition.MoveApplication("unknown", "root.other", adminUser)
+	assert.ErrorContains(t, err, "not found")
+	err = partition.MoveApplication(appID1, "root.unknown", adminUser)
+	assert.ErrorContains(t, err, "not found")
+	err = partition.MoveApplication(appID1, "root.other", otherUser)
+	assert.Assert(t, errors.Is(err, ErrAdminAccessDenied), "non admin should be denied: %v", err)
+	err = partition.MoveApplication(appID1, "root", adminUser)
+	assert.ErrorContains(t, err, "leaf queue")
+	err = partition.MoveApplication(appID1, "root.default", adminUser)
+	assert.ErrorContains(t, err, "already in queue")
+
+	source := partition.GetQueue("root.default")
+	small := partition.GetQueue("root.small")
+	// no headroom in the target: nothing changes
+	err = partition.MoveApplication(appID1, "root.small", adminUser)
+	assert.ErrorContains(t, err, "over maximum")
+	assert.Assert(t, resources.Equals(source.GetAllocatedResource(), res), "source usage changed on failed move")
+	assert.Assert(t, resources.IsZero(small.GetAllocatedResource()), "target usage changed on failed move")
+	assert.Equal(t, app.GetQueuePath(), "root.default")
+
+	target := partition.GetQueue("root.other")
+	assert.NilError(t, partition.MoveApplication(appID1, "root.other", adminUser), "move failed")
+	assert.Equal(t, app.GetQueuePath(), "root.other")
+	assert.Equal(t, app.GetQueue(), target)
+	assert.Assert(t, source.GetApplication(appID1) == nil, "app still in source queue")
+	assert.Assert(t, target.GetApplication(appID1) != nil, "app not in target queue")
+	assert.Assert(t, resources.IsZero(source.GetAllocatedResource()), "source usage not released")
+	assert.Assert(t, resources.IsZero(source.GetPendingResource()), "source pending not released")
+	assert.Assert(t, resources.Equals(target.GetAllocatedResource(), res), "target usage not set")
+	assert.Assert(t, resources.Equals(target.GetPendingResource(), res), "target pending not set")
+	assert.Assert(t, resources.Equals(partition.GetQueue("root").GetAllocatedResource(), res), "root usage changed")
+}
+
+func TestSetApplicationPriority(t *testing.T) {
+	_, partition := createAdminTestContext(t)
+	app := newApplication(appID1, pName, "root.default")
+	assert.NilError(t, partition.AddApplication(app), "add application to partition should not have failed")
+
+	err := partition.SetApplicationPriority("unknown", 10, adminUser)
+	assert.ErrorContains(t, err, "not found")
+	err = partition.SetApplicationPriority(appID1, 10, otherUser)
+	assert.Assert(t, errors.Is(err, ErrAdminAccessDenied), "non admin should be denied: %v", err)
+	assert.Assert(t, app.GetPriorityOverride() == nil, "priority should not be set")
+
+	assert.NilError(t, partition.SetApplicationPriority(appID1, 10, adminUser), "set priority failed")
+	assert.Equal(t, app.GetPriority(), int32(10))
+	// asks do not change the priority set by the administrator
+	ask := newAllocationAskPriority(allocKey, appID1, resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 1}), 100)
+	assert.NilError(t, app.AddAllocationAsk(ask), "ask add failed")
+	assert.Equal(t, app.GetPriority(), int32(10))
+	assert.Equal(t, app.GetAskMaxPriority(), int32(100))
+}
+
+func TestForceReleaseAllocation(t *testing.T) {
+	context, partition := createAdminTestContext(t)
+	app := newApplication(appID1, pName, "root.default")
+	assert.NilError(t, partition.AddApplication(app), "add application to partition should not have failed")
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 10})
+	_, _, err := partition.UpdateAllocation(newAllocation(allocKey, appID1, nodeID1, res))
+	assert.NilError(t, err, "allocation add failed")
+
+	err = context.ForceReleaseAllocation(partition, appID1, "unknown", adminUser)
+	assert.ErrorContains(t, err, "not found")
+	err = context.ForceReleaseAllocation(partition, appID1, allocKey, otherUser)
+	assert.Assert(t, errors.Is(err, ErrAdminAccessDenied), "non admin should be denied: %v", err)
+
+	assert.NilError(t, context.ForceReleaseAllocation(partition, appID1, allocKey, adminUser), "release failed")
+	assert.Assert(t, app.GetAllocation(allocKey) == nil, "allocation not removed from app")
+	assert.Assert(t, resources.IsZero(partition.GetQueue("root.default").GetAllocatedResource()), "queue usage not released")
+	assert.Equal(t, len(partition.GetNode(nodeID1).GetYunikornAllocations()), 0, "allocation not removed from node")
+	handler := context.rmEventHandler.(*mockEventHandler) //nolint:errcheck
+	assert.Equal(t, len(handler.releasedAllocs), 1, "RM not notified")
+	assert.Equal(t, handler.releasedAllocs[0].AllocationKey, allocKey)
+	assert.Equal(t, handler.releasedAllocs[0].TerminationType, si.TerminationType_PREEMPTED_BY_SCHEDULER, "RM must stop the workload")
+}
+
+func TestChangeNodeState(t *testing.T) {
+	context, partition := createAdminTestContext(t)
+	err := context.ChangeNodeState(partition, nodeID1, objects.CordonNode, "", 0, false, otherUser)
+	assert.Assert(t, errors.Is(err, ErrAdminAccessDenied), "non admin should be denied: %v", err)
+	assert.Assert(t, partition.GetNode(nodeID1).IsSchedulable(), "node should not be cordoned")
+
+	// the change is processed on the event loop of the scheduler
+	sched := NewScheduler()
+	sched.clusterContext = context
+	context.schedulerEventHandler = sched
+	go sched.handleRMEvent()
+	defer close(sched.stop)
+	assert.NilError(t, context.ChangeNodeState(partition, nodeID1, objects.CordonNode, "maintenance", 0, false, adminUser), "cordon failed")
+	assert.Equal(t, partition.GetNode(nodeID1).GetNodeState(), objects.NodeCordoned.String())
+	err = context.ChangeNodeState(partition, nodeID1, objects.CompleteDrain, "", 0, false, adminUser)
+	assert.ErrorContains(t, err, "completes when all allocations are removed")
+	assert.NilError(t, context.ChangeNodeState(partition, nodeID1, objects.DecommissionNode, "", 0, false, adminUser), "decommission failed")
+	assert.Assert(t, partition.GetNode(nodeID1) == nil, "decommissioned node not removed")
+}
@@ -0,0 +1,389 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+	"github.com/apache/yunikorn-core/pkg/locking"
+	"github.com/apache/yunikorn-core/pkg/log"
+	"github.com/apache/yunikorn-core/pkg/scheduler/objects"
+)
+
+// DefaultAdvanceReservationLeadTime is the time before the start of the window the booked capacity is protected
+var DefaultAdvanceReservationLeadTime = time.Hour
+
+// advanceRefreshInterval limits how often the usage of the booking queue on the pinned nodes is recalculated
+var advanceRefreshInterval = 5 * time.Second
+
+// AdvanceReservation books capacity for a queue, and all its children, on a set of nodes during a future time window.
+type AdvanceReservation struct {
+	ID        string              // unique ID of the reservation in the partition
+	QueuePath string              // queue the capacity is booked for
+	Nodes     []string            // nodes to book the capacity on, all nodes if empty
+	Resource  *resources.Resource // capacity to book
+	Priority  int32               // allocations with a lower priority are preempted at the start of the window
+	Start     time.Time           // start of the window
+	End       time.Time           // end of the window
+	LeadTime  time.Duration       // time before the start new allocations that would conflict are no longer placed
+}
+
+// Advance reservation states
+const (
+	AdvanceReservationPending = "Pending" // window not approaching yet
+	AdvanceReservationPinned  = "Pinned"  // capacity is protected on the nodes
+	AdvanceReservationActive  = "Active"  // window has started
+)
+
+type advanceEntry struct {
+	reservation *AdvanceReservation
+	pinned      map[string]*resources.Resource // node ID to the capacity pinned on the node
+	remaining   map[string]*resources.Resource // node ID to the pinned capacity not used by the booking queue
+	refreshed   time.Time                      // last time the remaining capacity was calculated
+	state       string
+}
+
+// advanceReservations tracks the advance reservations of a partition.
+type advanceReservations struct {
+	entries map[string]*advanceEntry
+
+	locking.Mutex
+}
+
+func newAdvanceReservations() *advanceReservations {
+	return &advanceReservations{
+		entries: make(map[string]*advanceEntry),
+	}
+}
+
+// AddAdvanceReservation books capacity for a queue during a future time window.
+func (pc *PartitionContext) AddAdvanceReservation(ar *AdvanceReservation, now time.Time) error {
+	if ar == nil || ar.ID == "" {
+		return fmt.Errorf("advance reservation must have an ID")
+	}
+	if !ar.End.After(ar.Start) {
+		return fmt.Errorf("advance reservation %s: end %s must be after start %s", ar.ID, ar.End, ar.Start)
+	}
+	if !ar.End.After(now) {
+		return fmt.Errorf("advance reservation %s: window has already ended", ar.ID)
+	}
+	if !resources.StrictlyGreaterThanZero(ar.Resource) {
+		return fmt.Errorf("advance reservation %s: resource must be larger than zero", ar.ID)
+	}
+	if ar.LeadTime < 0 {
+		return fmt.Errorf("advance reservation %s: lead time cannot be negative", ar.ID)
+	}
+	if pc.GetQueue(ar.QueuePath) == nil {
+		return fmt.Errorf("advance reservation %s: queue %s does not exist", ar.ID, ar.QueuePath)
+	}
+	for _, nodeID := range ar.Nodes {
+		if pc.GetNode(nodeID) == nil {
+			return fmt.Errorf("advance reservation %s: node %s does not exist", ar.ID, nodeID)
+		}
+	}
+	booked := *ar
+	booked.Resource = ar.Resource.Clone()
+	booked.Nodes = append([]string(nil), ar.Nodes...)
+	if booked.LeadTime == 0 {
+		booked.LeadTime = DefaultAdvanceReservationLeadTime
+	}
+	pc.advance.Lock()
+	defer pc.advance.Unlock()
+	if _, ok := pc.advance.entries[booked.ID]; ok {
+		return fmt.Errorf("advance reservation %s already exists", booked.ID)
+	}
+	pc.advance.entries[booked.ID] = &advanceEntry{
+		reservation: &booked,
+		state:       AdvanceReservationPending,
+	}
+	log.Log(log.SchedPartition).Info("advance reservation added",
+		zap.String("partition", pc.Name),
+		zap.String("id", booked.ID),
+		zap.String("queue", booked.QueuePath),
+		zap.Stringer("resource", booked.Resource),
+		zap.Time("start", booked.Start),
+		zap.Time("end", booked.End))
+	return nil
+}
+
+// RemoveAdvanceReservation removes the advance reservation and releases the protected capacity.
+func (pc *PartitionContext) RemoveAdvanceReservation(id string) error {
+	pc.advance.Lock()
+	defer pc.advance.Unlock()
+	entry, ok := pc.advance.entries[id]
+	if !ok {
+		return fmt.Errorf("advance reservation %s does not exist", id)
+	}
+	pc.unpinAdvanceReservation(entry)
+	delete(pc.advance.entries, id)
+	log.Log(log.SchedPartition).Info("advance reservation removed",
+		zap.String("partition", pc.Name),
+		zap.String("id", id))
+	return nil
+}
+
+// getAdvanceReservation returns a copy of the advance reservation or nil if it does not exist.
+func (pc *PartitionContext) getAdvanceReservation(id string) *AdvanceReservation {
+	pc.advance.Lock()
+	defer pc.advance.Unlock()
+	entry, ok := pc.advance.entries[id]
+	if !ok {
+		return nil
+	}
+	ar := *entry.reservation
+	return &ar
+}
+
+// GetAdvanceReservations returns a copy of all advance reservations with their state, sorted by start time.
+func (pc *PartitionContext) GetAdvanceReservations() ([]*AdvanceReservation, []string) {
+	pc.advance.Lock()
+	defer pc.advance.Unlock()
+	entries := make([]*advanceEntry, 0, len(pc.advance.entries))
+	for _, entry := range pc.advance.entries {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].reservation.Start.Equal(entries[j].reservation.Start) {
+			return entries[i].reservation.ID < entries[j].reservation.ID
+		}
+		return entries[i].reservation.Start.Before(entries[j].reservation.Start)
+	})
+	list := make([]*AdvanceReservation, len(entries))
+	states := make([]string, len(entries))
+	for i, entry := range entries {
+		ar := *entry.reservation
+		list[i] = &ar
+		states[i] = entry.state
+	}
+	return list, states
+}
+
+// updateAdvanceReservations progresses the advance reservations: capacity is pinned to the nodes when the window
+// approaches and released when the window ends. During the window allocations with a lower priority are selected for
+// preemption if the booking queue needs the pinned capacity. Returns the allocations to release.
+func (pc *PartitionContext) updateAdvanceReservations(now time.Time) []*objects.Allocation {
+	pc.advance.Lock()
+	defer pc.advance.Unlock()
+	if len(pc.advance.entries) == 0 {
+		return nil
+	}
+	var victims []*objects.Allocation
+	for id, entry := range pc.advance.entries {
+		ar := entry.reservation
+		if !now.Before(ar.End) {
+			pc.unpinAdvanceReservation(entry)
+			delete(pc.advance.entries, id)
+			log.Log(log.SchedPartition).Info("advance reservation window ended",
+				zap.String("partition", pc.Name),
+				zap.String("id", id))
+			continue
+		}
+		if entry.state == AdvanceReservationPending && !now.Before(ar.Start.Add(-ar.LeadTime)) {
+			pc.pinAdvanceReservation(entry)
+		}
+		if entry.state == AdvanceReservationPending {
+			continue
+		}
+		// the window start always uses the current usage, preemption depends on it
+		if entry.remaining == nil || now.Sub(entry.refreshed) >= advanceRefreshInterval ||
+			(entry.state != AdvanceReservationActive && !now.Before(ar.Start)) {
+			entry.remaining = pc.refreshAdvanceReservation(entry)
+			entry.refreshed = now
+		}
+		if now.Before(ar.Start) {
+			continue
+		}
+		if entry.state != AdvanceReservationActive {
+			entry.state = AdvanceReservationActive
+			log.Log(log.SchedPartition).Info("advance reservation window started",
+				zap.String("partition", pc.Name),
+				zap.String("id", id))
+		}
+		queue := pc.GetQueue(ar.QueuePath)
+		if queue == nil || resources.IsZero(queue.GetPendingResource()) {
+			continue
+		}
+		victims = append(victims, pc.preemptForAdvanceReservation(ar, entry.remaining)...)
+	}
+	return victims
+}
+
+// pinAdvanceReservation spreads the booked capacity over the nodes of the reservation, in node order.
+func (pc *PartitionContext) pinAdvanceReservation(entry *advanceEntry) {
+	ar := entry.reservation
+	var nodes []*objects.Node
+	if len(ar.Nodes) == 0 {
+		nodes = pc.GetNodes()
+		sort.Slice(nodes, func(i, j int) bool { return nodes[i].NodeID < nodes[j].NodeID })
+	} else {
+		for _, nodeID := range ar.Nodes {
+			if node := pc.GetNode(nodeID); node != nil {
+				nodes = append(nodes, node)
+			}
+		}
+	}
+	entry.pinned = make(map[string]*resources.Resource)
+	entry.remaining = nil
+	toPin := ar.Resource.Clone()
+	for _, node := range nodes {
+		if toPin.IsEmpty() {
+			break
+		}
+		pinned := intersectMin(toPin, node.GetCapacity())
+		if pinned.IsEmpty() {
+			continue
+		}
+		toPin = intersectMin(toPin, resources.SubEliminateNegative(toPin, pinned))
+		entry.pinned[node.NodeID] = pinned
+		node.SetBooking(&objects.Booking{
+			ID:        ar.ID,
+			QueuePath: ar.QueuePath,
+			Resource:  pinned,
+			Remaining: pinned.Clone(),
+			Start:     ar.Start,
+			End:       ar.End,
+		})
+	}
+	entry.state = AdvanceReservationPinned
+	if !toPin.IsEmpty() {
+		log.Log(log.SchedPartition).Warn("advance reservation larger than node capacity, booking partially",
+			zap.String("partition", pc.Name),
+			zap.String("id", ar.ID),
+			zap.Stringer("unbooked", toPin))
+	}
+	log.Log(log.SchedPartition).Info("advance reservation pinned to nodes",
+		zap.String("partition", pc.Name),
+		zap.String("id", ar.ID),
+		zap.Int("nodes", len(entry.pinned)))
+}
+
+func (pc *PartitionContext) unpinAdvanceReservation(entry *advanceEntry) {
+	for nodeID := range entry.pinned {
+		if node := pc.GetNode(nodeID); node != nil {
+			node.RemoveBooking(entry.reservation.ID)
+		}
+	}
+	entry.pinned = nil
+	entry.remaining = nil
+}
+
+// refreshAdvanceReservation updates the pinned capacity not yet used by the booking queue on each node.
+// Returns the remaining capacity per node.
+func (pc *PartitionContext) refreshAdvanceReservation(entry *advanceEntry) map[string]*resources.Resource {
+	ar := entry.reservation
+	// only the allocations of the booking queue count: walk the applications of the queue, not all allocations
+	used := make(map[string]*resources.Resource, len(entry.pinned))
+	for nodeID := range entry.pinned {
+		used[nodeID] = resources.NewResource()
+	}
+	if queue := pc.GetQueue(ar.QueuePath); queue != nil {
+		addBookingQueueUsage(queue, used)
+	}
+	remaining := make(map[string]*resources.Resource, len(entry.pinned))
+	for nodeID, pinned := range entry.pinned {
+		node := pc.GetNode(nodeID)
+		if node == nil {
+			continue
+		}
+		left := intersectMin(pinned, resources.SubEliminateNegative(pinned, used[nodeID]))
+		node.SetBookingRemaining(ar.ID, left)
+		remaining[nodeID] = left
+	}
+	return remaining
+}
+
+// addBookingQueueUsage adds the allocations of the applications in the queue and all its children to the usage of
+// the nodes in the map. Allocations on other nodes are ignored.
+func addBookingQueueUsage(queue *objects.Queue, used map[string]*resources.Resource) {
+	for _, app := range queue.GetCopyOfApps() {
+		for _, alloc := range app.GetAllAllocations() {
+			if nodeUsed, ok := used[alloc.GetNodeID()]; ok {
+				nodeUsed.AddTo(alloc.GetAllocatedResource())
+			}
+		}
+	}
+	for _, child := range queue.GetCopyOfChildren() {
+		addBookingQueueUsage(child, used)
+	}
+}
+
+func (pc *PartitionContext) inBookingQueue(ar *AdvanceReservation, app *objects.Application) bool {
+	booking := objects.Booking{QueuePath: ar.QueuePath}
+	return booking.InBookingQueue(app.GetQueuePath())
+}
+
+// preemptForAdvanceReservation selects allocations with a lower priority from other queues on the nodes that do not
+// have the remaining pinned capacity free. The lowest priority and newest allocations are selected first.
+func (pc *PartitionContext) preemptForAdvanceReservation(ar *AdvanceReservation, remaining map[string]*resources.Resource) []*objects.Allocation {
+	var victims []*objects.Allocation
+	for nodeID, left := range remaining {
+		node := pc.GetNode(nodeID)
+		if node == nil || left.IsEmpty() {
+			continue
+		}
+		need := intersectMin(left, resources.SubEliminateNegative(left, node.GetAvailableResource()))
+		if need.IsEmpty() {
+			continue
+		}
+		var candidates []*objects.Allocation
+		for _, alloc := range node.GetYunikornAllocations() {
+			if alloc.IsPreempted() || alloc.IsOriginator() || alloc.GetPriority() >= ar.Priority {
+				continue
+			}
+			if app := pc.getApplication(alloc.GetApplicationID()); app == nil || pc.inBookingQueue(ar, app) {
+				continue
+			}
+			candidates = append(candidates, alloc)
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			if candidates[i].GetPriority() != candidates[j].GetPriority() {
+				return candidates[i].GetPriority() < candidates[j].GetPriority()
+			}
+			return candidates[i].GetCreateTime().After(candidates[j].GetCreateTime())
+		})
+		for _, alloc := range candidates {
+			if need.IsEmpty() {
+				break
+			}
+			if intersectMin(need, alloc.GetAllocatedResource()).IsEmpty() {
+				continue
+			}
+			if app := pc.getApplication(alloc.GetApplicationID()); app != nil {
+				if queue := app.GetQueue(); queue != nil {
+					queue.IncPreemptingResource(alloc.GetAllocatedResource())
+				}
+			}
+			alloc.MarkPreempted()
+			alloc.SendPreemptedBySchedulerEvent("", "", ar.QueuePath)
+			need = intersectMin(need, resources.SubEliminateNegative(need, alloc.GetAllocatedResource()))
+			victims = append(victims, alloc)
+			log.Log(log.SchedPartition).Info("preempting allocation for advance reservation",
+				zap.String("id", ar.ID),
+				zap.String("nodeID", nodeID),
+				zap.String("applicationID", alloc.GetApplicationID()),
+				zap.String("allocationKey", alloc.GetAllocationKey()))
+		}
+	}
+	return victims
+}
@@ -0,0 +1,164 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+)
+
+func TestAddAdvanceReservation(t *testing.T) {
+	partition := createQueuesNodes(t)
+	now := time.Now()
+	res, err := resources.NewResourceFromConf(map[string]string{"vcore": "8"})
+	assert.NilError(t, err, "failed to create resource")
+	valid := func() *AdvanceReservation {
+		return &AdvanceReservation{ID: "ar-1", QueuePath: "root.leaf", Resource: res, Start: now.Add(time.Hour), End: now.Add(2 * time.Hour)}
+	}
+	tests := map[string]func(ar *AdvanceReservation){
+		"no id":            func(ar *AdvanceReservation) { ar.ID = "" },
+		"end equals start": func(ar *AdvanceReservation) { ar.End = ar.Start },
+		"ended window":     func(ar *AdvanceReservation) { ar.Start = now.Add(-2 * time.Hour); ar.End = now.Add(-time.Hour) },
+		"zero resource":    func(ar *AdvanceReservation) { ar.Resource = resources.NewResource() },
+		"negative lead":    func(ar *AdvanceReservation) { ar.LeadTime = -time.Minute },
+		"unknown queue":    func(ar *AdvanceReservation) { ar.QueuePath = "root.unknown" },
+		"unknown node":     func(ar *AdvanceReservation) { ar.Nodes = []string{"unknown"} },
+	}
+	for name, change := range tests {
+		t.Run(name, func(t *testing.T) {
+			ar := valid()
+			change(ar)
+			assert.Assert(t, partition.AddAdvanceReservation(ar, now) != nil, "invalid reservation should have been rejected")
+		})
+	}
+	assert.NilError(t, partition.AddAdvanceReservation(valid(), now), "valid reservation rejected")
+	assert.Assert(t, partition.AddAdvanceReservation(valid(), now) != nil, "duplicate reservation should have been rejected")
+	list, states := partition.GetAdvanceReservations()
+	assert.Equal(t, len(list), 1)
+	assert.Equal(t, list[0].LeadTime, DefaultAdvanceReservationLeadTime, "default lead time not set")
+	assert.Equal(t, states[0], AdvanceReservationPending)
+	assert.NilError(t, partition.RemoveAdvanceReservation("ar-1"), "remove failed")
+	assert.Assert(t, partition.RemoveAdvanceReservation("ar-1") != nil, "remove of unknown reservation should fail")
+}
+
+func TestAdvanceReservationBlocksAllocation(t *testing.T) {
+	partition := createQueuesNodes(t)
+	now := time.Now()
+	res, err := resources.NewResourceFromConf(map[string]string{"vcore": "8"})
+	assert.NilError(t, err, "failed to create resource")
+	ar := &AdvanceReservation{ID: "ar-1", QueuePath: "root.parent", Nodes: []string{nodeID1}, Resource: res,
+		Start: now.Add(2 * time.Hour), End: now.Add(3 * time.Hour), LeadTime: time.Hour}
+	assert.NilError(t, partition.AddAdvanceReservation(ar, now), "failed to add reservation")
+
+	// not pinned before the lead time
+	assert.Equal(t, len(partition.updateAdvanceReservations(now)), 0, "no preemption expected")
+	assert.Assert(t, partition.GetNode(nodeID1).GetBooking("ar-1") == nil, "booking pinned too early")
+
+	// pinned within the lead time, allocations that run into the window are kept off the booked capacity
+	ar.Start = now.Add(30 * time.Minute)
+	ar.End = now.Add(time.Hour)
+	assert.NilError(t, partition.RemoveAdvanceReservation("ar-1"), "failed to remove reservation")
+	assert.NilError(t, partition.AddAdvanceReservation(ar, now), "failed to add reservation")
+	assert.Equal(t, len(partition.updateAdvanceReservations(now)), 0, "no preemption expected")
+	booking := partition.GetNode(nodeID1).GetBooking("ar-1")
+	assert.Assert(t, booking != nil, "booking not pinned")
+	assert.Assert(t, resources.Equals(booking.Resource, res), "unexpected booked resource")
+	_, states := partition.GetAdvanceReservations()
+	assert.Equal(t, states[0], AdvanceReservationPinned)
+
+	app := newApplication(appID1, "default", "root.leaf")
+	assert.NilError(t, partition.AddApplication(app), "failed to add app")
+	askRes, err := resources.NewResourceFromConf(map[string]string{"vcore": "5"})
+	assert.NilError(t, err, "failed to create resource")
+	assert.NilError(t, app.AddAllocationAsk(newAllocationAsk(allocKey, appID1, askRes)), "failed to add ask")
+	assert.NilError(t, app.AddAllocationAsk(newAllocationAsk(allocKey2, appID1, askRes)), "failed to add ask")
+	for i := 0; i < 2; i++ {
+		result := partition.tryAllocate()
+		assert.Assert(t, result != nil && result.Request != nil, "allocation expected")
+		assert.Equal(t, result.NodeID, nodeID2, "allocation placed on booked capacity")
+	}
+}
+
+func TestAdvanceReservationPreemption(t *testing.T) {
+	partition := createQueuesNodes(t)
+	now := time.Now()
+	allocRes, err := resources.NewResourceFromConf(map[string]string{"vcore": "6"})
+	assert.NilError(t, err, "failed to create resource")
+	app1 := newApplication(appID1, "default", "root.leaf")
+	assert.NilError(t, partition.AddApplication(app1), "failed to add app")
+	alloc := newAllocation(allocKey, appID1, nodeID1, allocRes)
+	_, _, err = partition.UpdateAllocation(alloc)
+	assert.NilError(t, err, "failed to add allocation")
+
+	res, err := resources.NewResourceFromConf(map[string]string{"vcore": "8"})
+	assert.NilError(t, err, "failed to create resource")
+	ar := &AdvanceReservation{ID: "ar-1", QueuePath: "root.parent", Nodes: []string{nodeID1}, Resource: res,
+		Priority: 10, Start: now.Add(-time.Minute), End: now.Add(time.Hour)}
+	assert.NilError(t, partition.AddAdvanceReservation(ar, now), "failed to add reservation")
+
+	// window started but nothing pending for the booking queue
+	assert.Equal(t, len(partition.updateAdvanceReservations(now)), 0, "no preemption expected")
+	_, states := partition.GetAdvanceReservations()
+	assert.Equal(t, states[0], AdvanceReservationActive)
+
+	app2 := newApplication(appID2, "default", "root.parent.sub-leaf")
+	assert.NilError(t, partition.AddApplication(app2), "failed to add app")
+	assert.NilError(t, app2.AddAllocationAsk(newAllocationAsk(allocKey2, appID2, res)), "failed to add ask")
+	victims := partition.updateAdvanceReservations(now)
+	assert.Equal(t, len(victims), 1, "expected allocation to be preempted")
+	assert.Equal(t, victims[0].GetAllocationKey(), allocKey)
+	assert.Assert(t, victims[0].IsPreempted(), "victim not marked preempted")
+	assert.Equal(t, len(partition.updateAdvanceReservations(now)), 0, "victim selected twice")
+
+	// window ends: booking removed
+	assert.Equal(t, len(partition.updateAdvanceReservations(now.Add(time.Hour))), 0, "no preemption expected")
+	assert.Assert(t, partition.GetNode(nodeID1).GetBooking("ar-1") == nil, "booking not removed")
+	list, _ := partition.GetAdvanceReservations()
+	assert.Equal(t, len(list), 0, "reservation not removed")
+}
+
+func TestAdvanceReservationRefresh(t *testing.T) {
+	partition := createQueuesNodes(t)
+	now := time.Now()
+	res, err := resources.NewResourceFromConf(map[string]string{"vcore": "8"})
+	assert.NilError(t, err, "failed to create resource")
+	ar := &AdvanceReservation{ID: "ar-1", QueuePath: "root.parent", Nodes: []string{nodeID1}, Resource: res,
+		Start: now.Add(30 * time.Minute), End: now.Add(time.Hour), LeadTime: time.Hour}
+	assert.NilError(t, partition.AddAdvanceReservation(ar, now), "failed to add reservation")
+	assert.Equal(t, len(partition.updateAdvanceReservations(now)), 0, "no preemption expected")
+	assert.Assert(t, resources.Equals(partition.GetNode(nodeID1).GetBooking("ar-1").Remaining, res), "unexpected remaining capacity")
+
+	// usage of a child of the booking queue is only picked up after the refresh interval
+	app := newApplication(appID1, "default", "root.parent.sub-leaf")
+	assert.NilError(t, partition.AddApplication(app), "failed to add app")
+	allocRes, err := resources.NewResourceFromConf(map[string]string{"vcore": "3"})
+	assert.NilError(t, err, "failed to create resource")
+	_, _, err = partition.UpdateAllocation(newAllocation(allocKey, appID1, nodeID1, allocRes))
+	assert.NilError(t, err, "failed to add allocation")
+	partition.updateAdvanceReservations(now.Add(time.Second))
+	assert.Assert(t, resources.Equals(partition.GetNode(nodeID1).GetBooking("ar-1").Remaining, res), "remaining capacity refreshed too early")
+	partition.updateAdvanceReservations(now.Add(advanceRefreshInterval))
+	expected, err := resources.NewResourceFromConf(map[string]string{"vcore": "5"})
+	assert.NilError(t, err, "failed to create resource")
+	assert.Assert(t, resources.Equals(partition.GetNode(nodeID1).GetBooking("ar-1").Remaining, expected), "remaining capacity not refreshed")
+}
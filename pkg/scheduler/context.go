@@ -22,6 +22,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"strconv"
 	"time"
 
 	"go.uber.org/zap"
@@ -48,12 +49,17 @@ type ClusterContext struct {
 	rmEventHandler handler.EventHandler
 	uuid           string
 
+	schedulerEventHandler handler.EventHandler // nil if the context is used outside of the event system
+
 	// config values that change scheduling behaviour
 	needPreemption      bool
 	reservationDisabled bool
+	batchSize           int  // maximum allocations per partition per cycle
+	parallelPartitions  bool // schedule each partition on its own routine
 
-	rmInfo    map[string]*RMInformation
-	startTime time.Time
+	rmInfo       map[string]*RMInformation
+	startTime    time.Time
+	stateManager *stateManager // nil if the state is not persisted
 
 	locking.RWMutex
 
@@ -79,6 +85,7 @@ func NewClusterContext(rmID, policyGroup string, config []byte) (*ClusterContext
 		reservationDisabled: common.GetBoolEnvVar(disableReservation, false),
 		startTime:           time.Now(),
 		uuid:                common.GetNewUUID(),
+		batchSize:           configs.DefaultSchedulingBatchSize,
 	}
 	// If reservation is turned off set the reservation delay to the maximum duration defined.
 	// The time package does not export maxDuration so use the equivalent from the math package.
@@ -120,38 +127,128 @@ func (cc *ClusterContext) setEventHandler(rmHandler handler.EventHandler) {
 func (cc *ClusterContext) schedule() bool {
 	// schedule each partition defined in the cluster
 	activity := false
+	batchSize := cc.getBatchSize()
 	for _, psc := range cc.GetPartitionMapClone() {
-		// if there are no resources in the partition just skip
-		if psc.root.GetMaxResource() == nil {
-			continue
-		}
-		// a stopped partition does not allocate
-		if psc.isStopped() {
-			continue
+		if cc.schedulePartition(psc, batchSize) {
+			activity = true
 		}
-		// try reservations first
-		schedulingStart := time.Now()
-		result := psc.tryReservedAllocate()
+	}
+	return activity
+}
+
+// schedulePartition runs one scheduling cycle for the partition. Returns true if anything was allocated.
+// Partitions do not share scheduling state: the cycle can run in parallel with cycles of other partitions.
+func (cc *ClusterContext) schedulePartition(psc *PartitionContext, batchSize int) bool {
+	// a partition restored from the state snapshot is reconciled before the next allocation
+	if sm := cc.getStateManager(); sm != nil {
+		sm.reconcileIfDue(psc)
+	}
+	// if there are no resources in the partition just skip
+	if psc.root.GetMaxResource() == nil {
+		return false
+	}
+	// a stopped partition does not allocate
+	if psc.isStopped() {
+		return false
+	}
+	// try reservations first
+	schedulingStart := time.Now()
+	var results []*objects.AllocationResult
+	result := psc.tryReservedAllocate()
+	if result == nil {
+		// placeholder replacement second
+		result = psc.tryPlaceholderAllocate()
+		// nothing reserved that can be allocated try normal allocate
 		if result == nil {
-			// placeholder replacement second
-			result = psc.tryPlaceholderAllocate()
-			// nothing reserved that can be allocated try normal allocate
-			if result == nil {
+			if batchSize > 1 {
+				results = psc.tryBatchAllocate(batchSize)
+			} else {
 				result = psc.tryAllocate()
 			}
 		}
-		metrics.GetSchedulerMetrics().ObserveSchedulingLatency(schedulingStart)
-		if result != nil {
-			if result.ResultType == objects.Replaced {
-				// communicate the removal to the RM
-				cc.notifyRMAllocationReleased(psc.RmID, psc.Name, []*objects.Allocation{result.Request.GetRelease()}, si.TerminationType_PLACEHOLDER_REPLACED, "replacing allocationKey: "+result.Request.GetAllocationKey())
-			} else {
-				cc.notifyRMNewAllocation(psc.RmID, result.Request)
+	}
+	metrics.GetSchedulerMetrics().ObserveSchedulingLatency(schedulingStart)
+	if result != nil {
+		results = append(results, result)
+	}
+	for _, result = range results {
+		if result.ResultType == objects.Replaced {
+			// communicate the removal to the RM
+			cc.notifyRMAllocationReleased(psc.RmID, psc.Name, []*objects.Allocation{result.Request.GetRelease()}, si.TerminationType_PLACEHOLDER_REPLACED, "replacing allocationKey: "+result.Request.GetAllocationKey())
+		} else {
+			cc.notifyRMNewAllocation(psc.RmID, result.Request)
+			for _, member := range result.GangMembers {
+				cc.notifyRMNewAllocation(psc.RmID, member.Request)
 			}
-			activity = true
 		}
 	}
-	return activity
+	// return lent resources to lenders that waited out their grace period
+	for lender, victims := range psc.lending.reclaim(time.Now()) {
+		cc.notifyRMAllocationReleased(psc.RmID, psc.Name, victims, si.TerminationType_PREEMPTED_BY_SCHEDULER, "reclaiming lent resources for queue "+lender)
+	}
+	// free the capacity booked for advance reservations that have started
+	if victims := psc.updateAdvanceReservations(time.Now()); len(victims) > 0 {
+		cc.notifyRMAllocationReleased(psc.RmID, psc.Name, victims, si.TerminationType_PREEMPTED_BY_SCHEDULER, "preempted for advance reservation")
+	}
+	// complete node drains and release the allocations left after the drain deadline
+	if released := psc.updateDrainingNodes(time.Now()); len(released) > 0 {
+		cc.notifyRMAllocationReleased(psc.RmID, psc.Name, released, si.TerminationType_TIMEOUT, "node drain deadline passed")
+	}
+	return len(results) > 0
+}
+
+func (cc *ClusterContext) getStateManager() *stateManager {
+	cc.RLock()
+	defer cc.RUnlock()
+	return cc.stateManager
+}
+
+// isParallelPartitions returns true if each partition is scheduled on its own routine.
+func (cc *ClusterContext) isParallelPartitions() bool {
+	cc.RLock()
+	defer cc.RUnlock()
+	return cc.parallelPartitions
+}
+
+// getBatchSize returns the maximum number of allocations per partition in one scheduling cycle.
+func (cc *ClusterContext) getBatchSize() int {
+	cc.RLock()
+	defer cc.RUnlock()
+	return cc.batchSize
+}
+
+// readParallelPartitions reads the parallel partition scheduling flag from the config map.
+// Must be called after the config map was updated.
+func readParallelPartitions() bool {
+	value, ok := configs.GetConfigMap()[configs.SchedulingParallelPartitions]
+	if !ok {
+		return configs.DefaultSchedulingParallelPartitions
+	}
+	parallel, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Log(log.SchedContext).Warn("Invalid parallel partition scheduling flag, using default",
+			zap.String("value", value),
+			zap.Bool("default", configs.DefaultSchedulingParallelPartitions))
+		return configs.DefaultSchedulingParallelPartitions
+	}
+	return parallel
+}
+
+// readBatchSize reads the batch size from the config map, invalid values fall back to the default.
+// Must be called after the config map was updated.
+func readBatchSize() int {
+	value, ok := configs.GetConfigMap()[configs.SchedulingBatchSize]
+	if !ok {
+		return configs.DefaultSchedulingBatchSize
+	}
+	size, err := strconv.Atoi(value)
+	if err != nil || size < 1 {
+		log.Log(log.SchedContext).Warn("Invalid scheduling batch size, using default",
+			zap.String("value", value),
+			zap.Int("default", configs.DefaultSchedulingBatchSize))
+		return configs.DefaultSchedulingBatchSize
+	}
+	return size
 }
 
 func (cc *ClusterContext) processRMRegistrationEvent(event *rmevent.RMRegistrationEvent) {
@@ -170,6 +267,8 @@ func (cc *ClusterContext) processRMRegistrationEvent(event *rmevent.RMRegistrati
 	policyGroup := event.Registration.PolicyGroup
 	config := event.Registration.Config
 	configs.SetConfigMap(event.Registration.ExtraConfig)
+	cc.batchSize = readBatchSize()
+	cc.parallelPartitions = readParallelPartitions()
 
 	// load the config this returns a validated configuration
 	if len(config) == 0 {
@@ -215,6 +314,8 @@ func (cc *ClusterContext) processRMConfigUpdateEvent(event *rmevent.RMConfigUpda
 
 	// set extra configuration
 	configs.SetConfigMap(event.ExtraConfig)
+	cc.batchSize = readBatchSize()
+	cc.parallelPartitions = readParallelPartitions()
 
 	// load the config this returns a validated configuration
 	config := event.Config
@@ -385,6 +486,9 @@ func (cc *ClusterContext) updateSchedulerConfig(conf *configs.SchedulerConfig, r
 			}
 			go part.partitionManager.Run()
 			cc.partitions[partitionName] = part
+			if cc.stateManager != nil {
+				cc.stateManager.restorePartition(part)
+			}
 		}
 		// add it to the partitions to update
 		visited[p.Name] = true
@@ -534,6 +638,9 @@ func (cc *ClusterContext) handleRMUpdateApplicationEvent(event *rmevent.RMUpdate
 				zap.Error(err))
 			continue
 		}
+		if cc.stateManager != nil {
+			cc.stateManager.restoreApplication(partition.Name, schedApp)
+		}
 		acceptedApps = append(acceptedApps, &si.AcceptedApplication{
 			ApplicationID: schedApp.ApplicationID,
 		})
@@ -617,7 +724,11 @@ func (cc *ClusterContext) addNode(nodeInfo *si.NodeInfo, schedulable bool) error
 	}
 
 	if !sn.IsSchedulable() {
-		metrics.GetSchedulerMetrics().IncDrainingNodes()
+		if _, err = partition.drainNode(sn.NodeID, "node added draining", 0, false); err != nil {
+			log.Log(log.SchedContext).Warn("Failed to drain new node",
+				zap.String("nodeID", sn.NodeID),
+				zap.Error(err))
+		}
 	}
 	log.Log(log.SchedContext).Info("successfully added node",
 		zap.String("nodeID", sn.NodeID),
@@ -661,25 +772,51 @@ func (cc *ClusterContext) updateNode(nodeInfo *si.NodeInfo) {
 		if sr := nodeInfo.SchedulableResource; sr != nil {
 			partition.updatePartitionResource(node.SetCapacity(resources.NewResourceFromProto(sr)))
 		}
+		if len(nodeInfo.Attributes) > 0 {
+			node.UpdateAttributes(nodeInfo.Attributes)
+		}
 	case si.NodeInfo_DRAIN_NODE:
 		if node.IsSchedulable() {
-			// set the state to not schedulable
-			node.SetSchedulable(false)
-			metrics.GetSchedulerMetrics().IncDrainingNodes()
+			cc.logNodeStateError(cc.UpdateNodeState(partition, node.NodeID, objects.DrainNode, "drain requested by RM", 0, false))
 		}
 	case si.NodeInfo_DRAIN_TO_SCHEDULABLE:
 		if !node.IsSchedulable() {
-			metrics.GetSchedulerMetrics().DecDrainingNodes()
-			// set the state to schedulable
-			node.SetSchedulable(true)
+			cc.logNodeStateError(cc.UpdateNodeState(partition, node.NodeID, objects.UncordonNode, "schedulable requested by RM", 0, false))
 		}
 	case si.NodeInfo_DECOMISSION:
-		if !node.IsSchedulable() {
-			metrics.GetSchedulerMetrics().DecDrainingNodes()
+		cc.logNodeStateError(cc.UpdateNodeState(partition, node.NodeID, objects.DecommissionNode, "decommission requested by RM", 0, false))
+	default:
+		log.Log(log.SchedContext).Debug("unknown action for node update",
+			zap.String("nodeID", nodeInfo.NodeID),
+			zap.String("partitionName", nodeInfo.Attributes[siCommon.NodePartition]),
+			zap.Stringer("nodeAction", nodeInfo.Action))
+	}
+}
+
+// UpdateNodeState moves the node in the partition to the next state of the node lifecycle. Draining can request a
+// graceful release of the allocations on the node from the shim and release the allocations left after the grace
+// period. A decommissioned node is removed from the partition.
+func (cc *ClusterContext) UpdateNodeState(partition *PartitionContext, nodeID string, event objects.NodeEvent, reason string, gracePeriod time.Duration, release bool) error {
+	node := partition.GetNode(nodeID)
+	if node == nil {
+		return fmt.Errorf("node %s not found in partition %s", nodeID, partition.Name)
+	}
+	switch event {
+	case objects.DrainNode:
+		allocations, err := partition.drainNode(nodeID, reason, gracePeriod, release)
+		if err != nil {
+			return err
+		}
+		if len(allocations) != 0 {
+			cc.notifyRMAllocationReleased(partition.RmID, partition.Name, allocations, si.TerminationType_PREEMPTED_BY_SCHEDULER,
+				fmt.Sprintf("Node %s draining", nodeID))
+		}
+	case objects.DecommissionNode:
+		if err := node.HandleNodeEvent(objects.DecommissionNode, reason); err != nil {
+			return err
 		}
 		metrics.GetSchedulerMetrics().IncTotalDecommissionedNodes()
-		// set the state to not schedulable then tell the partition to clean up
-		node.SetSchedulable(false)
+		// tell the partition to clean up
 		released, confirmed := partition.removeNode(node.NodeID)
 		node.SendNodeRemovedEvent()
 		// notify the shim allocations have been released from node
@@ -690,11 +827,18 @@ func (cc *ClusterContext) updateNode(nodeInfo *si.NodeInfo) {
 		for _, confirm := range confirmed {
 			cc.notifyRMNewAllocation(partition.RmID, confirm)
 		}
+	case objects.CompleteDrain:
+		return fmt.Errorf("drain of node %s completes when all allocations are removed", nodeID)
 	default:
-		log.Log(log.SchedContext).Debug("unknown action for node update",
-			zap.String("nodeID", nodeInfo.NodeID),
-			zap.String("partitionName", nodeInfo.Attributes[siCommon.NodePartition]),
-			zap.Stringer("nodeAction", nodeInfo.Action))
+		return node.HandleNodeEvent(event, reason)
+	}
+	return nil
+}
+
+func (cc *ClusterContext) logNodeStateError(err error) {
+	if err != nil {
+		log.Log(log.SchedContext).Warn("node state update failed",
+			zap.Error(err))
 	}
 }
 
@@ -40,6 +40,7 @@ type mockEventHandler struct {
 	eventHandled    bool
 	rejectedNodes   []*si.RejectedNode
 	acceptedNodes   []*si.AcceptedNode
+	releasedAllocs  []*si.AllocationRelease
 	newAllocHandler func(*rmevent.RMNewAllocationsEvent)
 }
 
@@ -61,6 +62,13 @@ func (m *mockEventHandler) HandleEvent(ev interface{}) {
 	if allocEvent, ok := ev.(*rmevent.RMNewAllocationsEvent); ok && m.newAllocHandler != nil {
 		m.newAllocHandler(allocEvent)
 	}
+
+	if releaseEvent, ok := ev.(*rmevent.RMReleaseAllocationEvent); ok {
+		m.releasedAllocs = append(m.releasedAllocs, releaseEvent.ReleasedAllocations...)
+		go func() {
+			releaseEvent.Channel <- &rmevent.Result{Succeeded: true}
+		}()
+	}
 }
 
 func createTestContext(t *testing.T, partitionName string) *ClusterContext {
@@ -209,6 +217,31 @@ func TestContext_AddNodeDrained(t *testing.T) {
 	assert.Equal(t, expectedDraining, draining, "wrong draining node count")
 }
 
+func TestContext_ReadBatchSize(t *testing.T) {
+	defer configs.SetConfigMap(map[string]string{})
+	tests := []struct {
+		name  string
+		value string
+		want  int
+	}{
+		{"not set", "", configs.DefaultSchedulingBatchSize},
+		{"valid", "100", 100},
+		{"zero", "0", configs.DefaultSchedulingBatchSize},
+		{"negative", "-10", configs.DefaultSchedulingBatchSize},
+		{"not a number", "many", configs.DefaultSchedulingBatchSize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configMap := map[string]string{}
+			if tt.value != "" {
+				configMap[configs.SchedulingBatchSize] = tt.value
+			}
+			configs.SetConfigMap(configMap)
+			assert.Equal(t, readBatchSize(), tt.want, "unexpected batch size")
+		})
+	}
+}
+
 func TestContext_AddRMBuildInformation(t *testing.T) {
 	context := createTestContext(t, pName)
 
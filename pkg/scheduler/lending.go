@@ -0,0 +1,238 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+	"github.com/apache/yunikorn-core/pkg/locking"
+	"github.com/apache/yunikorn-core/pkg/log"
+	"github.com/apache/yunikorn-core/pkg/scheduler/objects"
+)
+
+// loan is the part of an allocation that uses resources lent by another queue.
+type loan struct {
+	alloc    *objects.Allocation
+	lender   *objects.Queue
+	borrower *objects.Queue
+	resource *resources.Resource
+}
+
+// lendingTracker tracks the allocations in a partition that borrow lendable guaranteed resources from another queue.
+// A lender that needs the lent resources back has them reclaimed after its grace period.
+type lendingTracker struct {
+	loans        map[string]*loan     // allocation key to loan
+	reclaimStart map[string]time.Time // lender queue path to the time the lender started waiting for the resources
+
+	locking.Mutex
+}
+
+func newLendingTracker() *lendingTracker {
+	return &lendingTracker{
+		loans:        make(map[string]*loan),
+		reclaimStart: make(map[string]time.Time),
+	}
+}
+
+// track checks if the allocation made in the queue uses resources above the guarantee of the queue, and borrows that
+// part from the nearest queue in the hierarchy with lendable resources available.
+// The allocation must already be added to the queue usage.
+func (lt *lendingTracker) track(alloc *objects.Allocation, queue *objects.Queue) {
+	if alloc == nil || queue == nil {
+		return
+	}
+	over := resources.SubEliminateNegative(queue.GetAllocatedResource(), queue.GetGuaranteedResource())
+	wanted := intersectMin(alloc.GetAllocatedResource(), over)
+	if wanted.IsEmpty() {
+		return
+	}
+	lt.Lock()
+	defer lt.Unlock()
+	if _, ok := lt.loans[alloc.GetAllocationKey()]; ok {
+		return
+	}
+	lender, borrowed := findLender(queue, wanted)
+	if lender == nil {
+		return
+	}
+	lt.loans[alloc.GetAllocationKey()] = &loan{
+		alloc:    alloc,
+		lender:   lender,
+		borrower: queue,
+		resource: borrowed,
+	}
+	lender.IncLentResource(borrowed)
+	queue.IncBorrowedResource(borrowed)
+	alloc.SetBorrowedFrom(lender.GetQueuePath())
+	log.Log(log.SchedPartition).Debug("allocation borrowed lendable resources",
+		zap.String("allocationKey", alloc.GetAllocationKey()),
+		zap.String("borrower", queue.GetQueuePath()),
+		zap.String("lender", lender.GetQueuePath()),
+		zap.Stringer("borrowed", borrowed))
+}
+
+// release returns the resources borrowed by the allocation to the lender.
+func (lt *lendingTracker) release(alloc *objects.Allocation) {
+	if alloc == nil {
+		return
+	}
+	lt.Lock()
+	defer lt.Unlock()
+	l, ok := lt.loans[alloc.GetAllocationKey()]
+	if !ok || l.alloc != alloc {
+		return
+	}
+	delete(lt.loans, alloc.GetAllocationKey())
+	l.lender.DecLentResource(l.resource)
+	l.borrower.DecBorrowedResource(l.resource)
+	alloc.SetBorrowedFrom("")
+}
+
+// reclaim checks all lenders with outstanding loans. A lender with pending requests that fit in its unused guarantee
+// needs the lent resources back. After the grace period of the lender the newest borrowing allocations are marked
+// preempted until the need is covered. Returns the allocations to release per lender queue path.
+func (lt *lendingTracker) reclaim(now time.Time) map[string][]*objects.Allocation {
+	lt.Lock()
+	defer lt.Unlock()
+	if len(lt.loans) == 0 {
+		return nil
+	}
+	byLender := make(map[*objects.Queue][]*loan)
+	for _, l := range lt.loans {
+		byLender[l.lender] = append(byLender[l.lender], l)
+	}
+	var victims map[string][]*objects.Allocation
+	for lender, loans := range byLender {
+		path := lender.GetQueuePath()
+		need := reclaimNeed(lender)
+		// allocations reclaimed earlier that are not released yet already cover part of the need
+		for _, l := range loans {
+			if l.alloc.IsPreempted() {
+				need = intersectMin(need, resources.SubEliminateNegative(need, l.resource))
+			}
+		}
+		if need.IsEmpty() {
+			delete(lt.reclaimStart, path)
+			continue
+		}
+		start, waiting := lt.reclaimStart[path]
+		if !waiting {
+			start = now
+			lt.reclaimStart[path] = now
+			log.Log(log.SchedPartition).Info("lender needs lent resources, reclaim grace period started",
+				zap.String("lender", path),
+				zap.Stringer("need", need),
+				zap.Stringer("gracePeriod", lender.GetReclaimGracePeriod()))
+		}
+		if now.Sub(start) < lender.GetReclaimGracePeriod() {
+			continue
+		}
+		// newest allocations are reclaimed first
+		sort.SliceStable(loans, func(i, j int) bool {
+			return loans[i].alloc.GetCreateTime().After(loans[j].alloc.GetCreateTime())
+		})
+		for _, l := range loans {
+			if need.IsEmpty() {
+				break
+			}
+			if l.alloc.IsPreempted() || intersectMin(l.resource, need).IsEmpty() {
+				continue
+			}
+			l.borrower.IncPreemptingResource(l.alloc.GetAllocatedResource())
+			l.alloc.MarkPreempted()
+			l.alloc.SendPreemptedBySchedulerEvent("", "", path)
+			need = intersectMin(need, resources.SubEliminateNegative(need, l.resource))
+			if victims == nil {
+				victims = make(map[string][]*objects.Allocation)
+			}
+			victims[path] = append(victims[path], l.alloc)
+			log.Log(log.SchedPartition).Info("reclaiming lent resources",
+				zap.String("lender", path),
+				zap.String("borrower", l.borrower.GetQueuePath()),
+				zap.String("applicationID", l.alloc.GetApplicationID()),
+				zap.String("allocationKey", l.alloc.GetAllocationKey()),
+				zap.Stringer("borrowed", l.resource))
+		}
+		// keep the grace period expired until the need is covered: the remainder is reclaimed without waiting again
+		if need.IsEmpty() {
+			delete(lt.reclaimStart, path)
+		}
+	}
+	return victims
+}
+
+// reclaimNeed returns the lent resources the lender needs back for its own pending requests: the pending requests
+// limited to the unused guarantee, minus the part of the unused guarantee that was not lent out.
+func reclaimNeed(lender *objects.Queue) *resources.Resource {
+	lent := lender.GetLentResource()
+	unused := resources.SubEliminateNegative(lender.GetGuaranteedResource(), lender.GetAllocatedResource())
+	wanted := intersectMin(lender.GetPendingResource(), unused)
+	free := resources.SubEliminateNegative(unused, lent)
+	return intersectMin(resources.SubEliminateNegative(wanted, free), lent)
+}
+
+// findLender walks up the hierarchy from the borrower and returns the first queue outside the path of the borrower
+// with lendable resources available, and the part of the wanted resources it lends.
+func findLender(borrower *objects.Queue, wanted *resources.Resource) (*objects.Queue, *resources.Resource) {
+	child := borrower
+	for parent := borrower.GetParent(); parent != nil; parent = parent.GetParent() {
+		for _, sibling := range parent.GetCopyOfChildren() {
+			if sibling == child {
+				continue
+			}
+			if lender, lent := findLenderInSubtree(sibling, wanted); lender != nil {
+				return lender, lent
+			}
+		}
+		child = parent
+	}
+	return nil, nil
+}
+
+func findLenderInSubtree(queue *objects.Queue, wanted *resources.Resource) (*objects.Queue, *resources.Resource) {
+	if lent := intersectMin(wanted, queue.GetAvailableToLend()); !lent.IsEmpty() {
+		return queue, lent
+	}
+	for _, child := range queue.GetCopyOfChildren() {
+		if lender, lent := findLenderInSubtree(child, wanted); lender != nil {
+			return lender, lent
+		}
+	}
+	return nil, nil
+}
+
+// intersectMin returns the smallest positive quantity for the resource types set in both resources.
+func intersectMin(left, right *resources.Resource) *resources.Resource {
+	out := resources.NewResource()
+	if left == nil || right == nil {
+		return out
+	}
+	for k, v := range left.Resources {
+		if r, ok := right.Resources[k]; ok {
+			if m := min(v, r); m > 0 {
+				out.Resources[k] = m
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,240 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+	"github.com/apache/yunikorn-core/pkg/scheduler/objects"
+	siCommon "github.com/apache/yunikorn-scheduler-interface/lib/go/common"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+func createLendingQueues(t *testing.T) (*objects.Queue, *objects.Queue) {
+	root, err := objects.NewConfiguredQueue(configs.QueueConfig{Name: "root", Parent: true}, nil, false)
+	assert.NilError(t, err, "failed to create root queue")
+	lender, err := objects.NewConfiguredQueue(configs.QueueConfig{
+		Name: "lender",
+		Resources: configs.Resources{
+			Guaranteed: map[string]string{"memory": "10"},
+			Lendable:   map[string]string{"memory": "6"},
+		},
+		Properties: map[string]string{configs.ReclaimGracePeriod: "1m"},
+	}, root, false)
+	assert.NilError(t, err, "failed to create lender queue")
+	parent, err := objects.NewConfiguredQueue(configs.QueueConfig{Name: "parent", Parent: true}, root, false)
+	assert.NilError(t, err, "failed to create parent queue")
+	borrower, err := objects.NewConfiguredQueue(configs.QueueConfig{Name: "borrower"}, parent, false)
+	assert.NilError(t, err, "failed to create borrower queue")
+	return lender, borrower
+}
+
+func newBorrowingAllocation(allocKey string, res *resources.Resource, created int64) *objects.Allocation {
+	return objects.NewAllocationFromSI(&si.Allocation{
+		AllocationKey:    allocKey,
+		ApplicationID:    appID1,
+		PartitionName:    "test",
+		NodeID:           nodeID1,
+		ResourcePerAlloc: res.ToProto(),
+		AllocationTags:   map[string]string{siCommon.CreationTime: strconv.FormatInt(created, 10)},
+	})
+}
+
+func TestLendingTrackAndRelease(t *testing.T) {
+	lender, borrower := createLendingQueues(t)
+	lt := newLendingTracker()
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 4})
+
+	alloc1 := newAllocation(allocKey, appID1, nodeID1, res)
+	borrower.IncAllocatedResource(res)
+	lt.track(alloc1, borrower)
+	assert.Equal(t, alloc1.GetBorrowedFrom(), "root.lender", "allocation should borrow from lender")
+	assert.Assert(t, resources.Equals(lender.GetLentResource(), res), "unexpected lent resource")
+	assert.Assert(t, resources.Equals(borrower.GetBorrowedResource(), res), "unexpected borrowed resource")
+	assert.Assert(t, resources.Equals(lender.GetAvailableToLend(), resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 2})), "unexpected available to lend")
+
+	// only the part still available is lent
+	alloc2 := newAllocation(allocKey2, appID1, nodeID1, res)
+	borrower.IncAllocatedResource(res)
+	lt.track(alloc2, borrower)
+	assert.Equal(t, alloc2.GetBorrowedFrom(), "root.lender", "allocation should borrow from lender")
+	assert.Assert(t, resources.Equals(lender.GetLentResource(), resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 6})), "unexpected lent resource")
+
+	// nothing left to lend
+	alloc3 := newAllocation(allocKey3, appID1, nodeID1, res)
+	borrower.IncAllocatedResource(res)
+	lt.track(alloc3, borrower)
+	assert.Equal(t, alloc3.GetBorrowedFrom(), "", "allocation should not borrow")
+	assert.Equal(t, len(lt.loans), 2, "unexpected number of loans")
+
+	lt.release(alloc3)
+	lt.release(alloc1)
+	assert.Equal(t, alloc1.GetBorrowedFrom(), "", "released allocation should not borrow")
+	assert.Assert(t, resources.Equals(lender.GetLentResource(), resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 2})), "unexpected lent resource after release")
+	assert.Assert(t, resources.Equals(borrower.GetBorrowedResource(), resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 2})), "unexpected borrowed resource after release")
+	lt.release(alloc2)
+	assert.Assert(t, resources.IsZero(lender.GetLentResource()), "lent resource should be zero")
+	assert.Equal(t, len(lt.loans), 0, "loans should be empty")
+}
+
+func TestLendingReclaim(t *testing.T) {
+	lender, borrower := createLendingQueues(t)
+	lt := newLendingTracker()
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 3})
+
+	alloc1 := newBorrowingAllocation(allocKey, res, 100)
+	borrower.IncAllocatedResource(res)
+	lt.track(alloc1, borrower)
+	alloc2 := newBorrowingAllocation(allocKey2, res, 200)
+	borrower.IncAllocatedResource(res)
+	lt.track(alloc2, borrower)
+	assert.Assert(t, resources.Equals(lender.GetLentResource(), resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 6})), "unexpected lent resource")
+
+	// lender has no demand: nothing to reclaim
+	now := time.Now()
+	assert.Equal(t, len(lt.reclaim(now)), 0, "nothing should be reclaimed without demand")
+
+	// lender asks for resources it lent out
+	app := newApplication(appID2, "default", "root.lender")
+	app.SetQueue(lender)
+	lender.AddApplication(app)
+	err := app.AddAllocationAsk(newAllocationAsk(allocKey3, appID2, resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 6})))
+	assert.NilError(t, err, "failed to add ask")
+	assert.Equal(t, len(lt.reclaim(now)), 0, "nothing should be reclaimed during the grace period")
+	assert.Equal(t, len(lt.reclaim(now.Add(30*time.Second))), 0, "nothing should be reclaimed during the grace period")
+
+	// newest allocation first, lender needs 6-(10-6)=2 so only one allocation is reclaimed
+	victims := lt.reclaim(now.Add(time.Minute))
+	assert.Equal(t, len(victims["root.lender"]), 1, "expected one allocation to be reclaimed")
+	assert.Equal(t, victims["root.lender"][0], alloc2, "newest allocation should be reclaimed")
+	assert.Assert(t, alloc2.IsPreempted(), "reclaimed allocation should be preempted")
+	assert.Assert(t, !alloc1.IsPreempted(), "older allocation should not be preempted")
+	assert.Assert(t, resources.Equals(borrower.GetPreemptingResource(), res), "unexpected preempting resource")
+
+	// preempted allocations are not selected again
+	victims = lt.reclaim(now.Add(3 * time.Minute))
+	assert.Equal(t, len(victims), 0, "no new allocations should be reclaimed")
+	_, waiting := lt.reclaimStart["root.lender"]
+	assert.Assert(t, !waiting, "grace period should be cleared once the need is covered")
+}
+
+func TestLendingReclaimPartial(t *testing.T) {
+	lender, borrower := createLendingQueues(t)
+	lt := newLendingTracker()
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 3})
+	alloc1 := newBorrowingAllocation(allocKey, res, 100)
+	borrower.IncAllocatedResource(res)
+	lt.track(alloc1, borrower)
+	// lent resources that cannot be reclaimed: the need is never fully covered by the loans
+	extra := resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 2})
+	lender.IncLentResource(extra)
+
+	app := newApplication(appID2, "default", "root.lender")
+	app.SetQueue(lender)
+	lender.AddApplication(app)
+	err := app.AddAllocationAsk(newAllocationAsk(allocKey3, appID2, resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 10})))
+	assert.NilError(t, err, "failed to add ask")
+	now := time.Now()
+	assert.Equal(t, len(lt.reclaim(now)), 0, "nothing should be reclaimed during the grace period")
+	victims := lt.reclaim(now.Add(time.Minute))
+	assert.Equal(t, len(victims["root.lender"]), 1, "expected the only loan to be reclaimed")
+	_, waiting := lt.reclaimStart["root.lender"]
+	assert.Assert(t, waiting, "grace period should be kept while the need is not covered")
+
+	// a new loan is reclaimed at once: no new grace period for the remainder
+	alloc2 := newBorrowingAllocation(allocKey2, res, 200)
+	borrower.IncAllocatedResource(res)
+	lt.track(alloc2, borrower)
+	victims = lt.reclaim(now.Add(time.Minute + time.Second))
+	assert.Equal(t, len(victims["root.lender"]), 1, "expected the new loan to be reclaimed without waiting")
+	assert.Equal(t, victims["root.lender"][0], alloc2)
+
+	// need covered by the reclaimed allocations: grace period cleared
+	lender.DecLentResource(extra)
+	assert.Equal(t, len(lt.reclaim(now.Add(2*time.Minute))), 0, "nothing left to reclaim")
+	_, waiting = lt.reclaimStart["root.lender"]
+	assert.Assert(t, !waiting, "grace period should be cleared once the need is covered")
+}
+
+func TestLendingPlaceholderReplace(t *testing.T) {
+	setupUGM()
+	conf := configs.PartitionConfig{
+		Name: "test",
+		Queues: []configs.QueueConfig{
+			{
+				Name:      "root",
+				Parent:    true,
+				SubmitACL: "*",
+				Queues: []configs.QueueConfig{
+					{
+						Name: "lender",
+						Resources: configs.Resources{
+							Guaranteed: map[string]string{"memory": "10"},
+							Lendable:   map[string]string{"memory": "6"},
+						},
+					},
+					{Name: "borrower"},
+				},
+			},
+		},
+	}
+	partition, err := newPartitionContext(conf, rmID, nil, false)
+	assert.NilError(t, err, "partition create failed")
+	setupNode(t, nodeID1, partition, resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 100}))
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 4})
+	app := newApplicationTG(appID1, "test", "root.borrower", res)
+	assert.NilError(t, partition.AddApplication(app), "app should have been added to the partition")
+
+	// the placeholder borrows from the lender
+	assert.NilError(t, app.AddAllocationAsk(newAllocationAskTG(phID, appID1, taskGroup, res, true)), "failed to add placeholder ask")
+	result := partition.tryAllocate()
+	if result == nil || result.Request == nil {
+		t.Fatal("expected placeholder to be allocated")
+	}
+	lender := partition.GetQueue("root.lender")
+	borrower := partition.GetQueue("root.borrower")
+	assert.Equal(t, result.Request.GetBorrowedFrom(), "root.lender", "placeholder should borrow from lender")
+	assert.Assert(t, resources.Equals(lender.GetLentResource(), res), "unexpected lent resource")
+
+	// the real allocation replaces the placeholder and keeps the loan
+	assert.NilError(t, app.AddAllocationAsk(newAllocationAskTG(allocKey, appID1, taskGroup, res, false)), "failed to add ask")
+	result = partition.tryPlaceholderAllocate()
+	if result == nil || result.Request == nil {
+		t.Fatal("allocation should have matched placeholder")
+	}
+	_, confirmed := partition.removeAllocation(&si.AllocationRelease{
+		PartitionName:   "test",
+		ApplicationID:   appID1,
+		AllocationKey:   phID,
+		TerminationType: si.TerminationType_PLACEHOLDER_REPLACED,
+	})
+	if confirmed == nil {
+		t.Fatal("confirmed allocation should not be nil")
+	}
+	assert.Equal(t, confirmed.GetBorrowedFrom(), "root.lender", "real allocation should borrow from lender")
+	assert.Assert(t, resources.Equals(lender.GetLentResource(), res), "lent resource changed by the replacement")
+	assert.Assert(t, resources.Equals(borrower.GetBorrowedResource(), res), "borrowed resource changed by the replacement")
+	assert.Equal(t, len(partition.lending.loans), 1, "unexpected number of loans")
+}
@@ -0,0 +1,123 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-core/pkg/log"
+	"github.com/apache/yunikorn-core/pkg/scheduler/objects"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+// drainNode moves the node into the draining state. Reservations on the node are removed so the asks can be placed
+// on other nodes. If release is set all allocations on the node are returned to request a graceful release from the
+// shim. After the grace period, if set, allocations still left on the node are released by the scheduler.
+// NOTE: this is a lock free call. It must NOT be called holding the PartitionContext lock.
+func (pc *PartitionContext) drainNode(nodeID, reason string, gracePeriod time.Duration, release bool) ([]*objects.Allocation, error) {
+	node := pc.GetNode(nodeID)
+	if node == nil {
+		return nil, fmt.Errorf("node %s not found in partition %s", nodeID, pc.Name)
+	}
+	if err := node.HandleNodeEvent(objects.DrainNode, reason); err != nil {
+		return nil, err
+	}
+	if gracePeriod > 0 {
+		node.SetDrainDeadline(time.Now().Add(gracePeriod))
+	}
+	for _, r := range node.GetReservations() {
+		_, app, ask := r.GetObjects()
+		pc.unReserve(app, node, ask)
+	}
+	pc.Lock()
+	pc.drainingNodes[nodeID] = true
+	pc.Unlock()
+	log.Log(log.SchedPartition).Info("node draining",
+		zap.String("partition", pc.Name),
+		zap.String("nodeID", nodeID),
+		zap.Duration("gracePeriod", gracePeriod),
+		zap.Bool("releaseAllocations", release))
+	if !release {
+		return nil, nil
+	}
+	return node.GetYunikornAllocations(), nil
+}
+
+// updateDrainingNodes completes the drain of nodes without allocations and releases the allocations left on nodes
+// past their drain deadline. Returns the allocations released.
+// NOTE: this is a lock free call. It must NOT be called holding the PartitionContext lock.
+func (pc *PartitionContext) updateDrainingNodes(now time.Time) []*objects.Allocation {
+	pc.RLock()
+	if len(pc.drainingNodes) == 0 {
+		pc.RUnlock()
+		return nil
+	}
+	nodeIDs := make([]string, 0, len(pc.drainingNodes))
+	for nodeID := range pc.drainingNodes {
+		nodeIDs = append(nodeIDs, nodeID)
+	}
+	pc.RUnlock()
+
+	var released []*objects.Allocation
+	for _, nodeID := range nodeIDs {
+		node := pc.GetNode(nodeID)
+		if node == nil || !node.IsDraining() {
+			pc.removeDrainingNode(nodeID)
+			continue
+		}
+		allocations := node.GetYunikornAllocations()
+		if deadline := node.GetDrainDeadline(); len(allocations) > 0 && !deadline.IsZero() && now.After(deadline) {
+			log.Log(log.SchedPartition).Info("drain deadline passed, releasing allocations",
+				zap.String("nodeID", nodeID),
+				zap.Int("allocations", len(allocations)))
+			// a TIMEOUT release is not returned by removeAllocation: track what was removed from the node
+			for _, alloc := range allocations {
+				pc.removeAllocation(&si.AllocationRelease{
+					PartitionName:   pc.Name,
+					ApplicationID:   alloc.GetApplicationID(),
+					AllocationKey:   alloc.GetAllocationKey(),
+					TerminationType: si.TerminationType_TIMEOUT,
+					Message:         "node drain deadline passed",
+				})
+				if node.GetAllocation(alloc.GetAllocationKey()) == nil {
+					released = append(released, alloc)
+				}
+			}
+			allocations = node.GetYunikornAllocations()
+		}
+		if len(allocations) == 0 {
+			if err := node.HandleNodeEvent(objects.CompleteDrain, "all allocations removed"); err != nil {
+				log.Log(log.SchedPartition).Warn("node drain completion failed",
+					zap.String("nodeID", nodeID),
+					zap.Error(err))
+			}
+			pc.removeDrainingNode(nodeID)
+		}
+	}
+	return released
+}
+
+func (pc *PartitionContext) removeDrainingNode(nodeID string) {
+	pc.Lock()
+	defer pc.Unlock()
+	delete(pc.drainingNodes, nodeID)
+}
@@ -0,0 +1,87 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+	"github.com/apache/yunikorn-core/pkg/scheduler/objects"
+)
+
+func TestDrainNode(t *testing.T) {
+	partition := createQueuesNodes(t)
+	_, err := partition.drainNode("unknown", "test", 0, false)
+	assert.Assert(t, err != nil, "unknown node should have failed")
+
+	app := newApplication(appID1, "default", "root.leaf")
+	assert.NilError(t, partition.AddApplication(app), "add application to partition should not have failed")
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 1000})
+	_, _, err = partition.UpdateAllocation(newAllocation(allocKey, appID1, nodeID1, res))
+	assert.NilError(t, err, "allocation add failed")
+	ask := newAllocationAsk(allocKey2, appID1, res)
+	assert.NilError(t, app.AddAllocationAsk(ask), "ask add failed")
+	node := partition.GetNode(nodeID1)
+	partition.reserve(app, node, ask)
+	assert.Equal(t, partition.getReservationCount(), 1, "reservation not added")
+
+	released, err := partition.drainNode(nodeID1, "test", 0, true)
+	assert.NilError(t, err, "drain should not have failed")
+	assert.Equal(t, len(released), 1, "allocations not returned for release")
+	assert.Equal(t, released[0].GetAllocationKey(), allocKey)
+	assert.Equal(t, partition.getReservationCount(), 0, "reservation not removed from draining node")
+	assert.Assert(t, node.IsDraining(), "node not draining")
+	assert.Assert(t, !node.IsSchedulable(), "draining node should not be schedulable")
+	assert.Assert(t, node.GetDrainDeadline().IsZero(), "deadline set without grace period")
+
+	// draining twice is not allowed
+	_, err = partition.drainNode(nodeID1, "test", 0, false)
+	assert.Assert(t, err != nil, "draining a draining node should have failed")
+}
+
+func TestUpdateDrainingNodes(t *testing.T) {
+	partition := createQueuesNodes(t)
+	assert.Assert(t, partition.updateDrainingNodes(time.Now()) == nil, "nothing should be released")
+
+	app := newApplication(appID1, "default", "root.leaf")
+	assert.NilError(t, partition.AddApplication(app), "add application to partition should not have failed")
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 1000})
+	_, _, err := partition.UpdateAllocation(newAllocation(allocKey, appID1, nodeID1, res))
+	assert.NilError(t, err, "allocation add failed")
+
+	// empty node completes the drain directly
+	_, err = partition.drainNode(nodeID2, "test", 0, false)
+	assert.NilError(t, err, "drain should not have failed")
+	// node with an allocation drains until the deadline
+	_, err = partition.drainNode(nodeID1, "test", time.Minute, false)
+	assert.NilError(t, err, "drain should not have failed")
+	assert.Equal(t, len(partition.updateDrainingNodes(time.Now())), 0, "nothing should be released before the deadline")
+	assert.Equal(t, partition.GetNode(nodeID2).GetNodeState(), objects.NodeDrained.String())
+	assert.Equal(t, partition.GetNode(nodeID1).GetNodeState(), objects.NodeDraining.String())
+
+	released := partition.updateDrainingNodes(time.Now().Add(2 * time.Minute))
+	assert.Equal(t, len(released), 1, "allocation not released after deadline")
+	assert.Equal(t, released[0].GetAllocationKey(), allocKey)
+	assert.Equal(t, len(partition.GetNode(nodeID1).GetYunikornAllocations()), 0, "allocation not removed from node")
+	assert.Equal(t, partition.GetNode(nodeID1).GetNodeState(), objects.NodeDrained.String())
+	assert.Equal(t, len(partition.drainingNodes), 0, "draining nodes not cleaned up")
+}
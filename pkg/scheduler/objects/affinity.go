@@ -0,0 +1,275 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package objects
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Allocation tags carrying the placement rules of an ask. Each rule is a comma separated list of terms, all terms must
+// match for the rule to match. A term is one of:
+//   - "key=v1|v2": the label exists and has one of the values
+//   - "key!=v1|v2": the label does not exist or has none of the values
+//   - "key": the label exists
+//   - "!key": the label does not exist
+//
+// Node rules are evaluated against the node attributes, anti-affinity rules against the tags of the allocations,
+// from any application, already running on the node.
+const (
+	AllocTagNodeSelector          = "affinity.nodeSelector"   // only "key=value" terms
+	AllocTagNodeAffinity          = "affinity.node.required"  // node must match
+	AllocTagNodeAffinityPreferred = "affinity.node.preferred" // each matching term ranks the node higher
+	AllocTagAntiAffinity          = "affinity.anti.required"  // no allocation on the node may match
+	AllocTagAntiAffinityPreferred = "affinity.anti.preferred" // nodes without a matching allocation rank higher
+)
+
+// ErrAffinityMismatch is returned when the node does not satisfy the required placement rules of the ask.
+var ErrAffinityMismatch = errors.New("node does not match the affinity rules")
+
+type selectorOperator int
+
+const (
+	selectorIn selectorOperator = iota
+	selectorNotIn
+	selectorExists
+	selectorDoesNotExist
+)
+
+type selectorTerm struct {
+	key      string
+	operator selectorOperator
+	values   []string
+}
+
+func (st selectorTerm) matches(labels map[string]string) bool {
+	value, ok := labels[st.key]
+	switch st.operator {
+	case selectorIn:
+		return ok && contains(st.values, value)
+	case selectorNotIn:
+		return !ok || !contains(st.values, value)
+	case selectorExists:
+		return ok
+	default:
+		return !ok
+	}
+}
+
+// labelSelector matches if all terms match, an empty selector matches everything.
+type labelSelector []selectorTerm
+
+func (ls labelSelector) matches(labels map[string]string) bool {
+	for _, term := range ls {
+		if !term.matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// Affinity contains the placement rules of an ask evaluated in the core.
+type Affinity struct {
+	nodeRequired  labelSelector
+	nodePreferred []selectorTerm
+	antiRequired  labelSelector
+	antiPreferred labelSelector
+}
+
+// ParseAffinity builds the placement rules from the allocation tags. Returns nil if the tags contain no rules.
+func ParseAffinity(tags map[string]string) (*Affinity, error) {
+	affinity := &Affinity{}
+	found := false
+	for _, tag := range []string{AllocTagNodeSelector, AllocTagNodeAffinity, AllocTagNodeAffinityPreferred, AllocTagAntiAffinity, AllocTagAntiAffinityPreferred} {
+		value, ok := tags[tag]
+		if !ok {
+			continue
+		}
+		selector, err := parseSelector(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s tag: %w", tag, err)
+		}
+		found = true
+		switch tag {
+		case AllocTagNodeSelector:
+			for _, term := range selector {
+				if term.operator != selectorIn || len(term.values) != 1 {
+					return nil, fmt.Errorf("invalid %s tag: only key=value terms allowed", tag)
+				}
+			}
+			affinity.nodeRequired = append(affinity.nodeRequired, selector...)
+		case AllocTagNodeAffinity:
+			affinity.nodeRequired = append(affinity.nodeRequired, selector...)
+		case AllocTagNodeAffinityPreferred:
+			affinity.nodePreferred = selector
+		case AllocTagAntiAffinity:
+			affinity.antiRequired = selector
+		case AllocTagAntiAffinityPreferred:
+			affinity.antiPreferred = selector
+		}
+	}
+	if !found {
+		return nil, nil
+	}
+	return affinity, nil
+}
+
+func parseSelector(value string) (labelSelector, error) {
+	var selector labelSelector
+	for _, raw := range strings.Split(value, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		var term selectorTerm
+		switch {
+		case strings.Contains(raw, "!="):
+			parts := strings.SplitN(raw, "!=", 2)
+			term = selectorTerm{key: strings.TrimSpace(parts[0]), operator: selectorNotIn, values: splitValues(parts[1])}
+		case strings.Contains(raw, "="):
+			parts := strings.SplitN(raw, "=", 2)
+			term = selectorTerm{key: strings.TrimSpace(parts[0]), operator: selectorIn, values: splitValues(parts[1])}
+		case strings.HasPrefix(raw, "!"):
+			term = selectorTerm{key: strings.TrimSpace(raw[1:]), operator: selectorDoesNotExist}
+		default:
+			term = selectorTerm{key: raw, operator: selectorExists}
+		}
+		if term.key == "" {
+			return nil, fmt.Errorf("term %q has no key", raw)
+		}
+		if (term.operator == selectorIn || term.operator == selectorNotIn) && len(term.values) == 0 {
+			return nil, fmt.Errorf("term %q has no values", raw)
+		}
+		selector = append(selector, term)
+	}
+	if len(selector) == 0 {
+		return nil, fmt.Errorf("no terms found")
+	}
+	return selector, nil
+}
+
+func splitValues(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, "|") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPreferred returns true if the ask has preferred rules that rank the nodes.
+func (af *Affinity) HasPreferred() bool {
+	return af != nil && (len(af.nodePreferred) > 0 || len(af.antiPreferred) > 0)
+}
+
+// matchesNode checks the required rules against the node. The ask itself is ignored when checking the allocations
+// on the node.
+func (af *Affinity) matchesNode(node *Node, allocationKey string) bool {
+	if af == nil {
+		return true
+	}
+	if !af.nodeRequired.matches(node.GetAttributes()) {
+		return false
+	}
+	return len(af.antiRequired) == 0 || !node.hasMatchingAllocation(af.antiRequired, allocationKey)
+}
+
+// preferredScore returns the number of preferred rules the node satisfies.
+func (af *Affinity) preferredScore(node *Node, allocationKey string) int {
+	if af == nil {
+		return 0
+	}
+	score := 0
+	attributes := node.GetAttributes()
+	for _, term := range af.nodePreferred {
+		if term.matches(attributes) {
+			score++
+		}
+	}
+	if len(af.antiPreferred) > 0 && !node.hasMatchingAllocation(af.antiPreferred, allocationKey) {
+		score++
+	}
+	return score
+}
+
+// hasMatchingAllocation returns true if the tags of an allocation on the node, other than the excluded one, match.
+func (sn *Node) hasMatchingAllocation(selector labelSelector, excludeKey string) bool {
+	sn.RLock()
+	defer sn.RUnlock()
+	for key, alloc := range sn.allocations {
+		if key != excludeKey && selector.matches(alloc.tags) {
+			return true
+		}
+	}
+	return false
+}
+
+// preAffinityCheck checks the required placement rules of the ask against the node.
+func (sn *Node) preAffinityCheck(ask *Allocation) error {
+	if !ask.GetAffinity().matchesNode(sn, ask.GetAllocationKey()) {
+		return ErrAffinityMismatch
+	}
+	return nil
+}
+
+// preferredNodeIterator ranks the nodes of the wrapped iterator by the preferred rules of the ask. Nodes with the same
+// score keep the order of the wrapped iterator.
+type preferredNodeIterator struct {
+	iterator NodeIterator
+	ask      *Allocation
+}
+
+func newPreferredNodeIterator(iterator NodeIterator, ask *Allocation) NodeIterator {
+	if !ask.GetAffinity().HasPreferred() {
+		return iterator
+	}
+	return &preferredNodeIterator{iterator: iterator, ask: ask}
+}
+
+func (pi *preferredNodeIterator) ForEachNode(f func(*Node) bool) {
+	affinity := pi.ask.GetAffinity()
+	allocationKey := pi.ask.GetAllocationKey()
+	var nodes []*Node
+	scores := make(map[string]int)
+	pi.iterator.ForEachNode(func(node *Node) bool {
+		nodes = append(nodes, node)
+		scores[node.NodeID] = affinity.preferredScore(node, allocationKey)
+		return true
+	})
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return scores[nodes[i].NodeID] > scores[nodes[j].NodeID]
+	})
+	for _, node := range nodes {
+		if !f(node) {
+			return
+		}
+	}
+}
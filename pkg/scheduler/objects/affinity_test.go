@@ -0,0 +1,155 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package objects
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+func newAllocationAffinity(allocKey, appID, nodeID string, res *resources.Resource, tags map[string]string) *Allocation {
+	return NewAllocationFromSI(&si.Allocation{
+		AllocationKey:    allocKey,
+		ApplicationID:    appID,
+		PartitionName:    "default",
+		NodeID:           nodeID,
+		ResourcePerAlloc: res.ToProto(),
+		AllocationTags:   tags,
+	})
+}
+
+func newNodeAttributes(nodeID string, res *resources.Resource, attributes map[string]string) *Node {
+	node := newNodeRes(nodeID, res)
+	node.initializeAttribute(attributes)
+	return node
+}
+
+func TestParseAffinity(t *testing.T) {
+	tests := []struct {
+		name  string
+		tags  map[string]string
+		valid bool
+		nilAf bool
+	}{
+		{"no tags", nil, true, true},
+		{"unrelated tags", map[string]string{"foo": "bar"}, true, true},
+		{"node selector", map[string]string{AllocTagNodeSelector: "zone=a, disk=ssd"}, true, false},
+		{"node selector with set", map[string]string{AllocTagNodeSelector: "zone=a|b"}, false, false},
+		{"node selector exists", map[string]string{AllocTagNodeSelector: "zone"}, false, false},
+		{"all operators", map[string]string{AllocTagNodeAffinity: "zone=a|b,zone!=c,gpu,!spot"}, true, false},
+		{"empty", map[string]string{AllocTagNodeAffinity: " , "}, false, false},
+		{"no key", map[string]string{AllocTagAntiAffinity: "=x"}, false, false},
+		{"no values", map[string]string{AllocTagAntiAffinityPreferred: "app="}, false, false},
+		{"preferred", map[string]string{AllocTagNodeAffinityPreferred: "zone=a", AllocTagAntiAffinityPreferred: "app=web"}, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			affinity, err := ParseAffinity(tt.tags)
+			if !tt.valid {
+				assert.Assert(t, err != nil, "expected parse error")
+				return
+			}
+			assert.NilError(t, err, "unexpected parse error")
+			assert.Equal(t, affinity == nil, tt.nilAf, "unexpected affinity")
+		})
+	}
+}
+
+func TestAffinityMatchesNode(t *testing.T) {
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"first": 10})
+	node := newNodeAttributes(nodeID1, res, map[string]string{"zone": "a", "gpu": "true"})
+	node.AddAllocation(newAllocationAffinity("running", appID2, nodeID1, res, map[string]string{"app": "db"}))
+	tests := []struct {
+		name  string
+		tags  map[string]string
+		match bool
+		score int
+	}{
+		{"selector match", map[string]string{AllocTagNodeSelector: "zone=a"}, true, 0},
+		{"selector mismatch", map[string]string{AllocTagNodeSelector: "zone=b"}, false, 0},
+		{"in set", map[string]string{AllocTagNodeAffinity: "zone=b|a"}, true, 0},
+		{"not in set", map[string]string{AllocTagNodeAffinity: "zone!=a"}, false, 0},
+		{"not in set missing label", map[string]string{AllocTagNodeAffinity: "rack!=r1"}, true, 0},
+		{"exists", map[string]string{AllocTagNodeAffinity: "gpu"}, true, 0},
+		{"does not exist", map[string]string{AllocTagNodeAffinity: "!gpu"}, false, 0},
+		{"anti-affinity match", map[string]string{AllocTagAntiAffinity: "app=db"}, false, 0},
+		{"anti-affinity no match", map[string]string{AllocTagAntiAffinity: "app=web"}, true, 0},
+		{"preferred", map[string]string{AllocTagNodeAffinityPreferred: "zone=a,gpu,spot", AllocTagAntiAffinityPreferred: "app=web"}, true, 3},
+		{"preferred anti match", map[string]string{AllocTagAntiAffinityPreferred: "app=db"}, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ask := newAllocationAffinity(aKey, appID1, "", res, tt.tags)
+			assert.Assert(t, ask.GetAffinity() != nil, "affinity not parsed")
+			assert.Equal(t, node.preAffinityCheck(ask) == nil, tt.match, "unexpected match result")
+			assert.Equal(t, ask.GetAffinity().preferredScore(node, aKey), tt.score, "unexpected preferred score")
+		})
+	}
+	// the allocation itself is ignored
+	self := newAllocationAffinity("running", appID2, nodeID1, res, map[string]string{"app": "db", AllocTagAntiAffinity: "app=db"})
+	assert.NilError(t, node.preAffinityCheck(self), "allocation should not conflict with itself")
+}
+
+func TestTryAllocateAffinity(t *testing.T) {
+	setupUGM()
+	res := func(v int64) *resources.Resource {
+		return resources.NewResourceFromMap(map[string]resources.Quantity{"first": resources.Quantity(v)})
+	}
+	node1 := newNodeAttributes("node-1", res(10), map[string]string{"zone": "a"})
+	node2 := newNodeAttributes("node-2", res(10), map[string]string{"zone": "b"})
+	node3 := newNodeAttributes("node-3", res(10), map[string]string{"zone": "b", "gpu": "true"})
+	node1.AddAllocation(newAllocationAffinity("db", appID2, "node-1", res(1), map[string]string{"app": "db"}))
+	nodes := map[string]*Node{"node-1": node1, "node-2": node2, "node-3": node3}
+	iterator := getNodeIteratorFn(node1, node2, node3)
+	getNode := func(nodeID string) *Node { return nodes[nodeID] }
+	root, err := createRootQueue(map[string]string{"first": "30"})
+	assert.NilError(t, err, "queue create failed")
+	queue, err := createManagedQueue(root, "leaf", false, nil)
+	assert.NilError(t, err, "queue create failed")
+	app := newApplication(appID1, "default", "root.leaf")
+	app.SetQueue(queue)
+	queue.AddApplication(app)
+	preemptionAttemptsRemaining := 0
+
+	tests := []struct {
+		allocKey string
+		tags     map[string]string
+		nodeID   string
+	}{
+		{"required", map[string]string{AllocTagNodeAffinity: "zone=b,!gpu"}, "node-2"},
+		{"preferred", map[string]string{AllocTagNodeAffinityPreferred: "gpu"}, "node-3"},
+		{"anti-affinity", map[string]string{AllocTagNodeSelector: "zone=a", AllocTagAntiAffinity: "app=db"}, ""},
+		{"preferred anti-affinity", map[string]string{AllocTagAntiAffinityPreferred: "app=db"}, "node-2"},
+	}
+	for _, tt := range tests {
+		assert.NilError(t, app.AddAllocationAsk(newAllocationAffinity(tt.allocKey, appID1, "", res(1), tt.tags)), "failed to add ask")
+		result := app.tryAllocate(res(30), false, 0, &preemptionAttemptsRemaining, iterator, iterator, getNode)
+		if tt.nodeID == "" {
+			assert.Assert(t, result == nil || result.ResultType != Allocated, "%s: ask should not be allocated", tt.allocKey)
+			app.RemoveAllocationAsk(tt.allocKey)
+			continue
+		}
+		assert.Assert(t, result != nil, "%s: ask should be allocated", tt.allocKey)
+		assert.Equal(t, result.NodeID, tt.nodeID, "%s: unexpected node", tt.allocKey)
+	}
+}
@@ -19,6 +19,7 @@
 package objects
 
 import (
+	"errors"
 	"fmt"
 	"strconv"
 	"time"
@@ -35,6 +36,10 @@ import (
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
 )
 
+// AllocTagExpectedRuntime is the allocation tag with the expected runtime of the allocation as a duration, i.e. "30m".
+// Allocations with an expected runtime can be backfilled on reserved nodes.
+const AllocTagExpectedRuntime = "expectedRuntime"
+
 type Allocation struct {
 	// Read-only fields
 	allocationKey     string
@@ -50,6 +55,10 @@ type Allocation struct {
 	tags              map[string]string
 	foreign           bool
 	preemptable       bool
+	expectedRuntime   time.Duration       // expected runtime of the allocation, zero if unknown
+	affinity          *Affinity           // placement rules evaluated in the core, nil if none
+	topology          *TopologyConstraint // topology placement of the application allocations, nil if none
+	tagsErr           error               // invalid affinity or topology tags, nil if the tags are valid
 
 	// Mutable fields which need protection
 	allocated            bool
@@ -72,6 +81,7 @@ type Allocation struct {
 	release               *Allocation // placeholder to be released for this allocation
 	preempted             bool        // whether this allocation has been marked for preemption
 	instType              string      // the instance type of the node at the time this allocation was bound
+	borrowedFrom          string      // the queue this allocation borrowed resources from, if any
 
 	locking.RWMutex
 }
@@ -120,6 +130,21 @@ func NewAllocationFromSI(alloc *si.Allocation) *Allocation {
 		}
 	}
 
+	var expectedRuntime time.Duration
+	if value, ok := alloc.AllocationTags[AllocTagExpectedRuntime]; ok {
+		expectedRuntime, err = time.ParseDuration(value)
+		if err != nil || expectedRuntime < 0 {
+			log.Log(log.SchedAllocation).Debug("Expected runtime on the Allocation object is invalid",
+				zap.String("expectedRuntime", value))
+			expectedRuntime = 0
+		}
+	}
+
+	// invalid placement rules reject the ask when it is added to the application
+	affinity, affinityErr := ParseAffinity(alloc.AllocationTags)
+	topology, topologyErr := ParseTopologyConstraint(alloc.AllocationTags)
+	tagsErr := errors.Join(affinityErr, topologyErr)
+
 	var allocated bool
 	var nodeID string
 	var bindTime time.Time
@@ -149,6 +174,10 @@ func NewAllocationFromSI(alloc *si.Allocation) *Allocation {
 		bindTime:          bindTime,
 		foreign:           foreign,
 		preemptable:       preemptable,
+		expectedRuntime:   expectedRuntime,
+		affinity:          affinity,
+		topology:          topology,
+		tagsErr:           tagsErr,
 	}
 }
 
@@ -196,6 +225,21 @@ func (a *Allocation) GetTaskGroup() string {
 	return a.taskGroupName
 }
 
+// GetExpectedRuntime returns the expected runtime of the allocation, zero if unknown.
+func (a *Allocation) GetExpectedRuntime() time.Duration {
+	return a.expectedRuntime
+}
+
+// GetAffinity returns the placement rules of the allocation, nil if none.
+func (a *Allocation) GetAffinity() *Affinity {
+	return a.affinity
+}
+
+// GetTopologyConstraint returns the topology placement of the allocation, nil if none.
+func (a *Allocation) GetTopologyConstraint() *TopologyConstraint {
+	return a.topology
+}
+
 // GetCreateTime returns the time this allocation was created.
 func (a *Allocation) GetCreateTime() time.Time {
 	return a.createTime
@@ -354,6 +398,20 @@ func (a *Allocation) MarkPreempted() {
 	a.preempted = true
 }
 
+// SetBorrowedFrom sets the path of the queue the allocation borrowed resources from, empty to clear.
+func (a *Allocation) SetBorrowedFrom(queuePath string) {
+	a.Lock()
+	defer a.Unlock()
+	a.borrowedFrom = queuePath
+}
+
+// GetBorrowedFrom returns the path of the queue the allocation borrowed resources from, empty if not borrowing.
+func (a *Allocation) GetBorrowedFrom() string {
+	a.RLock()
+	defer a.RUnlock()
+	return a.borrowedFrom
+}
+
 // IsPreempted returns whether the allocation has been marked for preemption or not.
 func (a *Allocation) IsPreempted() bool {
 	a.RLock()
@@ -476,6 +534,22 @@ func (a *Allocation) SendRequiredNodePreemptionFailedEvent(node string) {
 	a.askEvents.SendRequiredNodePreemptionFailed(a.allocationKey, a.applicationID, node, a.GetAllocatedResource())
 }
 
+// SendRequestExceedsUserLimitEvent updates the event system with the rejection of the ask by a user or group limit.
+func (a *Allocation) SendRequestExceedsUserLimitEvent(reason string) {
+	a.askEvents.SendRequestExceedsUserLimit(a.allocationKey, a.applicationID, reason, a.GetAllocatedResource())
+}
+
+// SendRequestExceedsAllocationLimitEvent updates the event system with an ask that waits for a user or group
+// allocations limit.
+func (a *Allocation) SendRequestExceedsAllocationLimitEvent(reason string) {
+	a.askEvents.SendRequestExceedsAllocationLimit(a.allocationKey, a.applicationID, reason, a.GetAllocatedResource())
+}
+
+// SendInvalidTagsEvent updates the event system with the rejection of the ask for invalid allocation tags.
+func (a *Allocation) SendInvalidTagsEvent(reason string) {
+	a.askEvents.SendInvalidRequestTags(a.allocationKey, a.applicationID, reason, a.GetAllocatedResource())
+}
+
 // SendPreemptedBySchedulerEvent updates the event system with the preemption event.
 func (a *Allocation) SendPreemptedBySchedulerEvent(preemptorAllocKey, preemptorAppId, preemptorQueuePath string) {
 	a.askEvents.SendPreemptedByScheduler(a.allocationKey, a.applicationID, preemptorAllocKey, preemptorAppId, preemptorQueuePath, a.GetAllocatedResource())
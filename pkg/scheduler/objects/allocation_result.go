@@ -41,6 +41,7 @@ type AllocationResult struct {
 	NodeID                string
 	ReservedNodeID        string
 	CancelledReservations int
+	GangMembers           []*AllocationResult // other members of a gang allocated together with this request
 }
 
 func (ar *AllocationResult) String() string {
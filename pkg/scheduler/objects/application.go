@@ -20,6 +20,7 @@ package objects
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math"
 	"strconv"
@@ -41,6 +42,7 @@ import (
 	"github.com/apache/yunikorn-core/pkg/metrics"
 	"github.com/apache/yunikorn-core/pkg/rmproxy/rmevent"
 	schedEvt "github.com/apache/yunikorn-core/pkg/scheduler/objects/events"
+	"github.com/apache/yunikorn-core/pkg/scheduler/sink"
 	"github.com/apache/yunikorn-core/pkg/scheduler/ugm"
 	siCommon "github.com/apache/yunikorn-scheduler-interface/lib/go/common"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
@@ -86,6 +88,7 @@ type Application struct {
 	queuePath         string
 	queue             *Queue                  // queue the application is running in
 	pending           *resources.Resource     // pending resources from asks for the app
+	pendingAsks       uint64                  // number of asks that are not allocated
 	reservations      map[string]*reservation // a map of reservations
 	requests          map[string]*Allocation  // a map of allocations, pending or satisfied
 	sortedRequests    sortedRequests          // list of requests pre-sorted
@@ -111,15 +114,20 @@ type Application struct {
 	stateLog             []*StateLogEntry            // state log for this application
 	placeholderData      map[string]*PlaceholderData // track placeholder and gang related info
 	askMaxPriority       int32                       // highest priority value of outstanding asks
+	priorityOverride     *int32                      // priority set by an administrator, replaces askMaxPriority if set
 	hasPlaceholderAlloc  bool                        // Whether there is at least one allocated placeholder
 	runnableInQueue      bool                        // whether the application is runnable/schedulable in the queue. Default is true.
 	runnableByUserLimit  bool                        // whether the application is runnable/schedulable based on user/group quota. Default is true.
+	gangMembers          map[string]int              // minimum members per task group for a gang without placeholders, nil if not a gang
+	gangScheduled        bool                        // whether all members of the gang have been allocated
+	gangTimer            *time.Timer                 // gang allocation timer
 
 	rmEventHandler        handler.EventHandler
 	rmID                  string
 	terminatedCallback    func(appID string)
 	appEvents             *schedEvt.ApplicationEvents
 	sendStateChangeEvents bool // whether to send state-change events or not (simplifies testing)
+	replaying             bool // state changes restored from a snapshot are replayed: no events or records are sent
 
 	locking.RWMutex
 }
@@ -162,6 +170,17 @@ func NewApplication(siApp *si.AddApplicationRequest, ugi security.UserGroup, eve
 	}
 	app.gangSchedulingStyle = gangSchedStyle
 	app.execTimeout = placeholderTimeout
+	if value := app.tags[AppTagGangMembers]; value != "" {
+		members, err := ParseGangMembers(value)
+		if err != nil {
+			log.Log(log.SchedApplication).Warn("Invalid gang members, scheduling application without gang",
+				zap.String("appID", app.ApplicationID),
+				zap.String("gang members", value),
+				zap.Error(err))
+		} else {
+			app.gangMembers = members
+		}
+	}
 	app.user = ugi
 	app.rmEventHandler = eventHandler
 	app.rmID = rmID
@@ -184,10 +203,14 @@ func (sa *Application) SetState(state string) {
 
 func (sa *Application) recordState(appState string) {
 	// lock not acquired here as it is already held during HandleApplicationEvent() / OnStateChange()
-	sa.stateLog = append(sa.stateLog, &StateLogEntry{
+	entry := &StateLogEntry{
 		Time:             time.Now(),
 		ApplicationState: appState,
-	})
+	}
+	sa.stateLog = append(sa.stateLog, entry)
+	if !sa.replaying {
+		sa.publishStateRecord(appState, entry.Time)
+	}
 }
 
 func (sa *Application) GetStateLog() []*StateLogEntry {
@@ -277,7 +300,7 @@ func (sa *Application) HandleApplicationEventWithInfo(event applicationEvent, ev
 // The only state that does not generate an event is Rejected.
 func (sa *Application) OnStateChange(event *fsm.Event, eventInfo string) {
 	sa.recordState(event.Dst)
-	if event.Dst == Rejected.String() || sa.rmEventHandler == nil {
+	if event.Dst == Rejected.String() || sa.rmEventHandler == nil || sa.replaying {
 		return
 	}
 	var message string
@@ -468,6 +491,13 @@ func (sa *Application) GetAllocationAsk(allocationKey string) *Allocation {
 	return sa.requests[allocationKey]
 }
 
+// GetAllocation returns the allocation for the key, nil if not found
+func (sa *Application) GetAllocation(allocationKey string) *Allocation {
+	sa.RLock()
+	defer sa.RUnlock()
+	return sa.allocations[allocationKey]
+}
+
 // GetAllocatedResource returns the currently allocated resources for this application
 func (sa *Application) GetAllocatedResource() *resources.Resource {
 	sa.RLock()
@@ -533,13 +563,14 @@ func (sa *Application) removeAsksInternal(allocKey string, detail si.EventRecord
 		// Cleanup total pending resource
 		deltaPendingResource = sa.pending
 		sa.pending = resources.NewResource()
+		sa.pendingAsks = 0
 		for _, ask := range sa.requests {
 			sa.appEvents.SendRemoveAskEvent(sa.ApplicationID, ask.allocationKey, ask.GetAllocatedResource(), detail)
 		}
 		sa.requests = make(map[string]*Allocation)
 		sa.sortedRequests = sortedRequests{}
 		sa.askMaxPriority = configs.MinPriority
-		sa.queue.UpdateApplicationPriority(sa.ApplicationID, sa.askMaxPriority)
+		sa.queue.UpdateApplicationPriority(sa.ApplicationID, sa.getPriorityInternal())
 	} else {
 		// cleanup the reservation for this allocation
 		if reserve, ok := sa.reservations[allocKey]; ok {
@@ -553,6 +584,7 @@ func (sa *Application) removeAsksInternal(allocKey string, detail si.EventRecord
 				deltaPendingResource = ask.GetAllocatedResource()
 				sa.pending = resources.Sub(sa.pending, deltaPendingResource)
 				sa.pending.Prune()
+				sa.decPendingAsks()
 			}
 			delete(sa.requests, allocKey)
 			sa.sortedRequests.remove(ask)
@@ -564,6 +596,7 @@ func (sa *Application) removeAsksInternal(allocKey string, detail si.EventRecord
 	}
 	// clean up the queue pending resources
 	sa.queue.decPendingResource(deltaPendingResource)
+	sa.updateUserCounts()
 	// Check if we need to change state based on the removal:
 	// 1) if pending is zero (no more asks left)
 	// 2) if confirmed allocations is zero (no real tasks running)
@@ -597,12 +630,23 @@ func (sa *Application) AddAllocationAsk(ask *Allocation) error {
 	if ask.IsAllocated() || resources.IsZero(ask.GetAllocatedResource()) {
 		return fmt.Errorf("invalid ask added to app %s: %v", sa.ApplicationID, ask)
 	}
+	if ask.tagsErr != nil {
+		ask.SendInvalidTagsEvent(ask.tagsErr.Error())
+		return fmt.Errorf("ask %s rejected for app %s: %w", ask.GetAllocationKey(), sa.ApplicationID, ask.tagsErr)
+	}
 	delta := ask.GetAllocatedResource().Clone()
 
 	var oldAskResource *resources.Resource = nil
 	if oldAsk := sa.requests[ask.GetAllocationKey()]; oldAsk != nil && !oldAsk.IsAllocated() {
 		oldAskResource = oldAsk.GetAllocatedResource().Clone()
 	}
+	// an update of a pending ask does not change the number of pending asks
+	if oldAskResource == nil {
+		if err := ugm.GetUserManager().CheckPendingAskLimit(sa.queuePath, sa.ApplicationID, sa.user); err != nil {
+			ask.SendRequestExceedsUserLimitEvent(err.Error())
+			return fmt.Errorf("ask %s rejected for app %s: %w", ask.GetAllocationKey(), sa.ApplicationID, err)
+		}
+	}
 
 	// Check if we need to change state based on the ask added, there are two cases:
 	// 1) first ask added on a new app: state is New
@@ -622,6 +666,10 @@ func (sa *Application) AddAllocationAsk(ask *Allocation) error {
 	sa.pending = resources.Add(sa.pending, delta)
 	sa.pending.Prune()
 	sa.queue.incPendingResource(delta)
+	if oldAskResource == nil {
+		sa.pendingAsks++
+		sa.updateUserCounts()
+	}
 
 	log.Log(log.SchedApplication).Info("ask added successfully to application",
 		zap.String("appID", sa.ApplicationID),
@@ -631,6 +679,8 @@ func (sa *Application) AddAllocationAsk(ask *Allocation) error {
 		zap.Stringer("pendingDelta", delta))
 	sa.sortedRequests.insert(ask)
 	sa.appEvents.SendNewAskEvent(sa.ApplicationID, ask.allocationKey, ask.GetAllocatedResource())
+	// the gang timeout starts when the first ask is added
+	sa.initGangTimer()
 
 	return nil
 }
@@ -724,7 +774,7 @@ func (sa *Application) addAllocationAskInternal(ask *Allocation) {
 	priority := ask.GetPriority()
 	if !allocated && priority > sa.askMaxPriority {
 		sa.askMaxPriority = priority
-		sa.queue.UpdateApplicationPriority(sa.ApplicationID, sa.askMaxPriority)
+		sa.queue.UpdateApplicationPriority(sa.ApplicationID, sa.getPriorityInternal())
 	}
 
 	if ask.IsPlaceholder() {
@@ -765,6 +815,8 @@ func (sa *Application) allocateAsk(ask *Allocation) (*resources.Resource, error)
 	sa.pending.Prune()
 	// update the pending of the queue with the same delta
 	sa.queue.decPendingResource(delta)
+	sa.decPendingAsks()
+	sa.updateUserCounts()
 
 	return delta, nil
 }
@@ -778,13 +830,15 @@ func (sa *Application) deallocateAsk(ask *Allocation) (*resources.Resource, erro
 	if askPriority > sa.askMaxPriority {
 		// increase app priority
 		sa.askMaxPriority = askPriority
-		sa.queue.UpdateApplicationPriority(sa.ApplicationID, askPriority)
+		sa.queue.UpdateApplicationPriority(sa.ApplicationID, sa.getPriorityInternal())
 	}
 
 	delta := ask.GetAllocatedResource()
 	sa.pending = resources.Add(sa.pending, delta)
 	// update the pending of the queue with the same delta
 	sa.queue.incPendingResource(delta)
+	sa.pendingAsks++
+	sa.updateUserCounts()
 
 	return delta, nil
 }
@@ -983,6 +1037,14 @@ func (sa *Application) tryAllocate(headRoom *resources.Resource, allowPreemption
 	}
 	// calculate the users' headroom, includes group check which requires the applicationID
 	userHeadroom := ugm.GetUserManager().Headroom(sa.queuePath, sa.ApplicationID, sa.user)
+	// a gang is allocated as a whole before any other request, the allocations limit is checked for the whole gang
+	if sa.isGangPending() {
+		return sa.tryGangAllocate(headRoom, userHeadroom, nodeIterator, getNodeFn)
+	}
+	// a new allocation is not possible if the user or group has reached the maximum number of allocations
+	if !sa.checkAllocationLimit() {
+		return nil
+	}
 	// get all the requests from the app sorted in order
 	for _, request := range sa.sortedRequests {
 		if request.IsAllocated() {
@@ -1037,10 +1099,22 @@ func (sa *Application) tryAllocate(headRoom *resources.Resource, allowPreemption
 
 		iterator := nodeIterator()
 		if iterator != nil {
-			if result := sa.tryNodes(request, iterator); result != nil {
+			iterator = sa.newTopologyNodeIterator(request, iterator, getNodeFn)
+			result := sa.tryNodes(request, iterator)
+			if result != nil && result.ResultType != Reserved {
 				// have a candidate return it
 				return result
 			}
+			// backfill on a reserved node before reserving a node for the request
+			if fullIterator := fullNodeIterator(); fullIterator != nil {
+				fullIterator = sa.newTopologyNodeIterator(request, fullIterator, getNodeFn)
+				if backfill := sa.tryBackfill(request, fullIterator); backfill != nil {
+					return backfill
+				}
+			}
+			if result != nil {
+				return result
+			}
 
 			// no nodes qualify, attempt preemption
 			if allowPreemption && *preemptAttemptsRemaining > 0 {
@@ -1286,12 +1360,35 @@ func (sa *Application) checkHeadRooms(ask *Allocation, userHeadroom *resources.R
 	return userHeadroom.FitInMaxUndef(ask.GetAllocatedResource()) && headRoom.FitInMaxUndef(ask.GetAllocatedResource())
 }
 
+// checkAllocationLimit returns true if the user and group can have one more running allocation. If not, the first
+// pending request is informed of the limit.
+// NOTE: this is a lock free call. It must only be called holding the application lock.
+func (sa *Application) checkAllocationLimit() bool {
+	err := ugm.GetUserManager().CheckAllocationLimit(sa.queuePath, sa.ApplicationID, sa.user, 1)
+	if err == nil {
+		return true
+	}
+	for _, request := range sa.sortedRequests {
+		if !request.IsAllocated() {
+			request.SendRequestExceedsAllocationLimitEvent(err.Error())
+			break
+		}
+	}
+	log.Log(log.SchedApplication).Debug("Maximum allocations reached",
+		zap.String("appID", sa.ApplicationID),
+		zap.Error(err))
+	return false
+}
+
 // tryReservedAllocate tries allocating an outstanding reservation
 func (sa *Application) tryReservedAllocate(headRoom *resources.Resource, nodeIterator func() NodeIterator) *AllocationResult {
 	sa.Lock()
 	defer sa.Unlock()
 	// calculate the users' headroom, includes group check which requires the applicationID
 	userHeadroom := ugm.GetUserManager().Headroom(sa.queuePath, sa.ApplicationID, sa.user)
+	if !sa.checkAllocationLimit() {
+		return nil
+	}
 
 	// process all outstanding reservations and pick the first one that fits
 	for _, reserve := range sa.reservations {
@@ -1371,7 +1468,14 @@ func (sa *Application) tryPreemption(headRoom *resources.Resource, preemptionDel
 	defer metrics.GetSchedulerMetrics().ObserveTryPreemptionLatency(tryPreemptionStart)
 
 	// attempt preemption
-	return preemptor.TryPreemption()
+	if result, ok := preemptor.TryPreemption(); ok {
+		return result, true
+	}
+	// queue guarantees do not help: reclaim from other users or groups if the user or group is below its guarantee
+	if ugm.GetUserManager().HasGuarantees() {
+		return NewUserGuaranteePreemptor(sa, headRoom, ask, iterator).TryPreemption()
+	}
+	return nil, false
 }
 
 func (sa *Application) tryRequiredNodePreemption(reserve *reservation, ask *Allocation) bool {
@@ -1443,7 +1547,7 @@ func (sa *Application) tryNodes(ask *Allocation, iterator NodeIterator) *Allocat
 	reserved := sa.reservations[allocKey]
 	var allocResult *AllocationResult
 	var predicateErrors map[string]int
-	iterator.ForEachNode(func(node *Node) bool {
+	newPreferredNodeIterator(iterator, ask).ForEachNode(func(node *Node) bool {
 		// skip the node if the node is not schedulable
 		if !node.IsSchedulable() {
 			log.Log(log.SchedApplication).Debug("skipping node for ask as state is unschedulable",
@@ -1492,7 +1596,7 @@ func (sa *Application) tryNodes(ask *Allocation, iterator NodeIterator) *Allocat
 		}
 		// nothing allocated should we look at a reservation?
 		askAge := time.Since(ask.GetCreateTime())
-		if reserved == nil && askAge > reservationDelay {
+		if reserved == nil && askAge > reservationDelay && !errors.Is(err, ErrAffinityMismatch) {
 			log.Log(log.SchedApplication).Debug("app reservation check",
 				zap.String("allocationKey", allocKey),
 				zap.Time("createTime", ask.GetCreateTime()),
@@ -1544,19 +1648,60 @@ func (sa *Application) tryNode(node *Node, ask *Allocation) (*AllocationResult,
 		// skip schedule onto node
 		return nil, nil
 	}
+	// skip the node if the ask would use capacity booked for another queue
+	if !node.preBookingCheck(ask, sa.queuePath, time.Now()) {
+		return nil, nil
+	}
+	// skip the node if the affinity rules of the ask are not satisfied
+	if err := node.preAffinityCheck(ask); err != nil {
+		return nil, err
+	}
 	// skip the node if conditions can not be satisfied
 	if err := node.preAllocateConditions(ask); err != nil {
 		return nil, err
 	}
+	return sa.allocateOnNode(node, ask), nil
+}
+
+// tryBackfill tries to allocate the ask on a reserved node without delaying the reservations on that node.
+// Only asks with an expected runtime that ends before the node is expected to be ready for its reservation qualify.
+func (sa *Application) tryBackfill(ask *Allocation, iterator NodeIterator) *AllocationResult {
+	if ask.GetExpectedRuntime() <= 0 || sa.reservations[ask.GetAllocationKey()] != nil {
+		return nil
+	}
+	now := time.Now()
+	var allocResult *AllocationResult
+	iterator.ForEachNode(func(node *Node) bool {
+		if !node.IsReserved() || !node.IsSchedulable() || !node.preBackfillCheck(ask, now) || !node.preBookingCheck(ask, sa.queuePath, now) {
+			return true
+		}
+		if node.preAffinityCheck(ask) != nil || node.preAllocateConditions(ask) != nil {
+			return true
+		}
+		if allocResult = sa.allocateOnNode(node, ask); allocResult != nil {
+			log.Log(log.SchedApplication).Info("backfilled allocation on reserved node",
+				zap.String("appID", sa.ApplicationID),
+				zap.String("allocationKey", ask.GetAllocationKey()),
+				zap.String("nodeID", node.NodeID),
+				zap.Duration("expectedRuntime", ask.GetExpectedRuntime()))
+			return false
+		}
+		return true
+	})
+	return allocResult
+}
 
-	// everything OK really allocate
+// allocateOnNode adds the ask to the node, queue and application. All checks must have been performed.
+// Returns nil if the ask no longer fits on the node or in the queue.
+func (sa *Application) allocateOnNode(node *Node, ask *Allocation) *AllocationResult {
+	allocationKey := ask.GetAllocationKey()
 	if node.TryAddAllocation(ask) {
 		if err := sa.queue.TryIncAllocatedResource(ask.GetAllocatedResource()); err != nil {
 			log.Log(log.SchedApplication).DPanic("queue update failed unexpectedly",
 				zap.Error(err))
 			// revert the node update
 			node.RemoveAllocation(allocationKey)
-			return nil, nil
+			return nil
 		}
 		// mark this alloc as allocated
 		_, err := sa.allocateAsk(ask)
@@ -1567,9 +1712,9 @@ func (sa *Application) tryNode(node *Node, ask *Allocation) (*AllocationResult,
 		// all is OK, last update for the app
 		result := newAllocatedAllocationResult(node.NodeID, ask)
 		sa.addAllocationInternal(result.ResultType, ask)
-		return result, nil
+		return result
 	}
-	return nil, nil
+	return nil
 }
 
 func (sa *Application) GetQueuePath() string {
@@ -1603,6 +1748,144 @@ func (sa *Application) SetQueue(queue *Queue) {
 	metrics.GetSchedulerMetrics().IncTotalApplicationsNew()
 }
 
+// MoveQueue moves the application to the target leaf queue. Only new, accepted and running applications can be
+// moved. The move is rejected if the user of the application has no submit access to the target queue, or if the
+// target queue or the user and group quota of the target queue cannot accommodate the application.
+// The pending, allocated, placeholder and preempting resources, the reservations, the running application count
+// and the priority of the application are transferred from the current queue to the target queue.
+func (sa *Application) MoveQueue(target *Queue, reason string) error {
+	sa.Lock()
+	defer sa.Unlock()
+	source := sa.queue
+	switch {
+	case source == nil:
+		return fmt.Errorf("application %s is not linked to a queue", sa.ApplicationID)
+	case target == nil || !target.IsLeafQueue():
+		return fmt.Errorf("target queue for application %s must be a leaf queue", sa.ApplicationID)
+	case source == target:
+		return fmt.Errorf("application %s is already in queue %s", sa.ApplicationID, target.QueuePath)
+	case !target.IsRunning():
+		return fmt.Errorf("target queue %s is not active", target.QueuePath)
+	case !sa.IsNew() && !sa.IsAccepted() && !sa.IsRunning():
+		return fmt.Errorf("application %s cannot be moved in state %s", sa.ApplicationID, sa.CurrentState())
+	case !target.CheckSubmitAccess(sa.user):
+		return fmt.Errorf("user %s has no access to submit to queue %s", sa.user.User, target.QueuePath)
+	}
+
+	appID := sa.ApplicationID
+	allocated := resources.Add(sa.allocatedResource, sa.allocatedPlaceholder)
+	running := sa.IsRunning()
+	accepted := source.isAllocatingAccepted(appID)
+	// the usage must be released from the source before checking the target: queues and user trackers shared
+	// between source and target must not count the application twice
+	tracked := running || !resources.IsZero(allocated)
+	if tracked {
+		ugm.GetUserManager().DecreaseTrackedResource(source.QueuePath, appID, allocated, sa.user, true)
+	}
+	rollbackUser := func() {
+		if tracked {
+			ugm.GetUserManager().IncreaseTrackedResource(source.QueuePath, appID, allocated, sa.user)
+		}
+	}
+	if tracked {
+		headroom := ugm.GetUserManager().Headroom(target.QueuePath, appID, sa.user)
+		if !headroom.FitInMaxUndef(allocated) {
+			rollbackUser()
+			return fmt.Errorf("application %s does not fit in the user quota of queue %s", appID, target.QueuePath)
+		}
+		if !ugm.GetUserManager().CanRunApp(target.QueuePath, appID, sa.user) {
+			rollbackUser()
+			return fmt.Errorf("application %s exceeds the user maximum applications of queue %s", appID, target.QueuePath)
+		}
+	}
+	if running {
+		source.decRunningApps()
+	}
+	if (running || accepted) && !target.canRunApp(appID) {
+		if running {
+			source.incRunningApps(appID)
+		}
+		rollbackUser()
+		return fmt.Errorf("application %s exceeds the maximum applications of queue %s", appID, target.QueuePath)
+	}
+	if !resources.IsZero(allocated) {
+		if err := source.DecAllocatedResource(allocated); err != nil {
+			if running {
+				source.incRunningApps(appID)
+			}
+			rollbackUser()
+			return err
+		}
+		if err := target.TryIncAllocatedResource(allocated); err != nil {
+			source.IncAllocatedResource(allocated)
+			if running {
+				source.incRunningApps(appID)
+			}
+			rollbackUser()
+			return err
+		}
+	}
+
+	// all checks passed: nothing can fail after this point
+	if tracked {
+		ugm.GetUserManager().IncreaseTrackedResource(target.QueuePath, appID, allocated, sa.user)
+	}
+	if running {
+		target.incRunningApps(appID)
+		metrics.GetQueueMetrics(source.QueuePath).DecQueueApplicationsRunning()
+		metrics.GetQueueMetrics(target.QueuePath).IncQueueApplicationsRunning()
+	}
+	if accepted {
+		source.unsetAllocatingAccepted(appID)
+		target.setAllocatingAccepted(appID)
+	}
+	if sa.IsNew() {
+		metrics.GetQueueMetrics(source.QueuePath).DecQueueApplicationsNew()
+		metrics.GetQueueMetrics(target.QueuePath).IncQueueApplicationsNew()
+	}
+	if sa.IsAccepted() {
+		metrics.GetQueueMetrics(source.QueuePath).DecQueueApplicationsAccepted()
+		metrics.GetQueueMetrics(target.QueuePath).IncQueueApplicationsAccepted()
+	}
+	if !resources.IsZero(sa.pending) {
+		source.decPendingResource(sa.pending)
+		target.incPendingResource(sa.pending)
+	}
+	preempting := resources.NewResource()
+	for _, alloc := range sa.allocations {
+		if alloc.IsPreempted() {
+			preempting.AddTo(alloc.GetAllocatedResource())
+		}
+	}
+	if !resources.IsZero(preempting) {
+		source.DecPreemptingResource(preempting)
+		target.IncPreemptingResource(preempting)
+	}
+	if reserved := len(sa.reservations); reserved > 0 {
+		source.UnReserve(appID, reserved)
+		for i := 0; i < reserved; i++ {
+			target.Reserve(appID)
+		}
+	}
+	source.detachApplication(appID)
+	target.AddApplication(sa)
+	sa.queue = target
+	sa.queuePath = target.QueuePath
+	sa.updateUserCounts()
+	target.UpdateApplicationPriority(appID, sa.getPriorityInternal())
+	sa.appEvents.SendQueueMovedEvent(appID, source.QueuePath, target.QueuePath, reason)
+	log.Log(log.SchedApplication).Info("application moved to queue",
+		zap.String("appID", appID),
+		zap.String("source", source.QueuePath),
+		zap.String("target", target.QueuePath))
+	return nil
+}
+
+// RecordForceRelease records the release of the allocation on request of an administrator.
+func (sa *Application) RecordForceRelease(alloc *Allocation, reason string) {
+	sa.appEvents.SendForceReleaseEvent(sa.ApplicationID, alloc.GetAllocationKey(), alloc.GetAllocatedResource(), reason)
+}
+
 // remove the leaf queue the application runs in, used when completing the app
 func (sa *Application) UnSetQueue() {
 	if sa.queue != nil {
@@ -1721,7 +2004,23 @@ func (sa *Application) addAllocationInternal(allocType AllocationResultType, all
 		sa.maxAllocatedResource = resources.ComponentWiseMax(sa.allocatedResource, sa.maxAllocatedResource)
 	}
 	sa.appEvents.SendNewAllocationEvent(sa.ApplicationID, alloc.allocationKey, alloc.GetAllocatedResource())
+	sa.publishAllocationRecord(alloc, sink.AllocationAdded, si.TerminationType_UNKNOWN_TERMINATION_TYPE)
 	sa.allocations[alloc.GetAllocationKey()] = alloc
+	sa.updateUserCounts()
+}
+
+// updateUserCounts reports the number of pending asks and allocations of the application to the user manager.
+// No locking must be called while holding the lock
+func (sa *Application) updateUserCounts() {
+	ugm.GetUserManager().UpdateApplicationCounts(sa.queuePath, sa.ApplicationID, sa.user, sa.pendingAsks, uint64(len(sa.allocations)))
+}
+
+// decPendingAsks decreases the number of pending asks, never below zero.
+// No locking must be called while holding the lock
+func (sa *Application) decPendingAsks() {
+	if sa.pendingAsks > 0 {
+		sa.pendingAsks--
+	}
 }
 
 // Increase user resource usage
@@ -1886,7 +2185,9 @@ func (sa *Application) removeAllocationInternal(allocationKey string, releaseTyp
 		}
 	}
 	delete(sa.allocations, allocationKey)
+	sa.updateUserCounts()
 	sa.appEvents.SendRemoveAllocationEvent(sa.ApplicationID, alloc.allocationKey, alloc.GetAllocatedResource(), releaseType)
+	sa.publishAllocationRecord(alloc, sink.AllocationReleased, releaseType)
 	return alloc
 }
 
@@ -1899,7 +2200,7 @@ func (sa *Application) updateAskMaxPriority() {
 		value = max(value, v.GetPriority())
 	}
 	sa.askMaxPriority = value
-	sa.queue.UpdateApplicationPriority(sa.ApplicationID, value)
+	sa.queue.UpdateApplicationPriority(sa.ApplicationID, sa.getPriorityInternal())
 }
 
 func (sa *Application) hasZeroAllocations() bool {
@@ -1924,6 +2225,7 @@ func (sa *Application) RemoveAllAllocations() []*Allocation {
 		// Aggregate the resources used by this alloc to the application's user resource tracker
 		sa.trackCompletedResource(alloc)
 		sa.appEvents.SendRemoveAllocationEvent(sa.ApplicationID, alloc.allocationKey, alloc.GetAllocatedResource(), si.TerminationType_STOPPED_BY_RM)
+		sa.publishAllocationRecord(alloc, sink.AllocationReleased, si.TerminationType_STOPPED_BY_RM)
 	}
 
 	// if an app doesn't have any allocations and the user doesn't have other applications,
@@ -1935,6 +2237,7 @@ func (sa *Application) RemoveAllAllocations() []*Allocation {
 	sa.allocatedResource = resources.NewResource()
 	sa.allocatedPlaceholder = resources.NewResource()
 	sa.allocations = make(map[string]*Allocation)
+	sa.updateUserCounts()
 	// When the resource trackers are zero we should not expect anything to come in later.
 	if resources.IsZero(sa.pending) {
 		if err := sa.HandleApplicationEvent(CompleteApplication); err != nil {
@@ -1944,6 +2247,7 @@ func (sa *Application) RemoveAllAllocations() []*Allocation {
 		}
 	}
 	sa.clearPlaceholderTimer()
+	sa.clearGangTimer()
 	sa.clearStateTimer()
 	return allocationsToRelease
 }
@@ -2047,6 +2351,62 @@ func (sa *Application) GetRejectedMessage() string {
 	return sa.rejectedMessage
 }
 
+// GetTagsClone returns a copy of the application tags.
+func (sa *Application) GetTagsClone() map[string]string {
+	return CloneAllocationTags(sa.tags)
+}
+
+// RestoreHistory restores the submission time and the state log of an application that was tracked before
+// the scheduler restarted. The restored state log entries are placed before the entries of the current run.
+func (sa *Application) RestoreHistory(submissionTime time.Time, stateLog []*StateLogEntry) {
+	sa.Lock()
+	defer sa.Unlock()
+	sa.SubmissionTime = submissionTime
+	restored := make([]*StateLogEntry, 0, len(stateLog)+len(sa.stateLog))
+	restored = append(restored, stateLog...)
+	sa.stateLog = append(restored, sa.stateLog...)
+}
+
+// RestoreTerminated moves an application restored from a state snapshot into the terminated state it was in
+// before the restart. The transitions to the state are replayed without sending events or records, and are not
+// added to the restored state log. The application expires after the remainder of the terminated timeout.
+func (sa *Application) RestoreTerminated(state string, startTime, finishedTime time.Time, rejectedMessage string) error {
+	sa.Lock()
+	defer sa.Unlock()
+	var replay []applicationEvent
+	switch state {
+	case Completed.String():
+		replay = []applicationEvent{RunApplication, CompleteApplication, CompleteApplication}
+	case Failed.String():
+		replay = []applicationEvent{FailApplication, FailApplication}
+	case Rejected.String():
+		replay = []applicationEvent{RejectApplication}
+	default:
+		return fmt.Errorf("application %s cannot be restored in state %s", sa.ApplicationID, state)
+	}
+	logged := len(sa.stateLog)
+	sa.replaying = true
+	defer func() {
+		sa.replaying = false
+	}()
+	for _, event := range replay {
+		if err := sa.HandleApplicationEvent(event); err != nil {
+			return err
+		}
+	}
+	sa.stateLog = sa.stateLog[:logged]
+	sa.startTime = startTime
+	sa.finishedTime = finishedTime
+	sa.rejectedMessage = rejectedMessage
+	remaining := terminatedTimeout - time.Since(finishedTime)
+	if remaining < 0 {
+		remaining = 0
+	}
+	sa.clearStateTimer()
+	sa.setStateTimer(remaining, state, ExpireApplication)
+	return nil
+}
+
 func (sa *Application) addPlaceholderData(ask *Allocation) {
 	if sa.placeholderData == nil {
 		sa.placeholderData = make(map[string]*PlaceholderData)
@@ -2077,9 +2437,46 @@ func (sa *Application) GetAskMaxPriority() int32 {
 	return sa.askMaxPriority
 }
 
+// GetPriority returns the priority of the application used for sorting: the priority set by an administrator or,
+// if not set, the highest priority of the outstanding asks.
+func (sa *Application) GetPriority() int32 {
+	sa.RLock()
+	defer sa.RUnlock()
+	return sa.getPriorityInternal()
+}
+
+// GetPriorityOverride returns the priority set by an administrator, nil if not set.
+func (sa *Application) GetPriorityOverride() *int32 {
+	sa.RLock()
+	defer sa.RUnlock()
+	if sa.priorityOverride == nil {
+		return nil
+	}
+	priority := *sa.priorityOverride
+	return &priority
+}
+
+// SetPriority sets the priority of the application replacing the priority derived from the outstanding asks.
+func (sa *Application) SetPriority(priority int32, reason string) {
+	sa.Lock()
+	defer sa.Unlock()
+	sa.priorityOverride = &priority
+	sa.queue.UpdateApplicationPriority(sa.ApplicationID, priority)
+	sa.appEvents.SendPriorityChangedEvent(sa.ApplicationID, priority, reason)
+}
+
+func (sa *Application) getPriorityInternal() int32 {
+	if sa.priorityOverride != nil {
+		return *sa.priorityOverride
+	}
+	return sa.askMaxPriority
+}
+
 func (sa *Application) cleanupAsks() {
 	sa.requests = make(map[string]*Allocation)
 	sa.sortedRequests = nil
+	sa.pendingAsks = 0
+	sa.updateUserCounts()
 }
 
 func (sa *Application) cleanupTrackedResource() {
@@ -2103,6 +2500,7 @@ func (sa *Application) getApplicationSummary(rmID string) *ApplicationSummary {
 		StartTime:           sa.startTime,
 		FinishTime:          sa.finishedTime,
 		User:                sa.user.User,
+		Groups:              sa.user.Groups,
 		Queue:               sa.queuePath,
 		State:               sa.stateMachine.Current(),
 		RmID:                rmID,
@@ -2112,18 +2510,44 @@ func (sa *Application) getApplicationSummary(rmID string) *ApplicationSummary {
 	}
 }
 
+// GetUsageSummary returns the application summary with the usage of the allocations that are still
+// running included up to now. Returns nil if the application has never run or the usage was cleaned up.
+func (sa *Application) GetUsageSummary(rmID string) *ApplicationSummary {
+	sa.RLock()
+	defer sa.RUnlock()
+	if sa.startTime.IsZero() || sa.usedResource == nil {
+		return nil
+	}
+	appSummary := sa.getApplicationSummary(rmID)
+	now := time.Now()
+	for _, alloc := range sa.allocations {
+		if alloc.IsPlaceholder() {
+			appSummary.PlaceholderResource.AggregateTrackedResourceAt(alloc.GetInstanceType(),
+				alloc.GetAllocatedResource(), alloc.GetBindTime(), now)
+		} else {
+			appSummary.ResourceUsage.AggregateTrackedResourceAt(alloc.GetInstanceType(),
+				alloc.GetAllocatedResource(), alloc.GetBindTime(), now)
+		}
+	}
+	return appSummary
+}
+
 // LogAppSummary log the summary details for the application if it has run at any point in time.
 // The application summary only contains correct data when the application is in the Completed state.
 // Logging the data in any other state will show incomplete or inconsistent data.
 // After the data is logged the objects are cleaned up to lower overhead of Completed application tracking.
-func (sa *Application) LogAppSummary(rmID string) {
+// The logged summary is returned, nil if the application has never run.
+func (sa *Application) LogAppSummary(rmID string) *ApplicationSummary {
 	sa.Lock()
 	defer sa.Unlock()
+	var appSummary *ApplicationSummary
 	if !sa.startTime.IsZero() {
-		appSummary := sa.getApplicationSummary(rmID)
+		appSummary = sa.getApplicationSummary(rmID)
 		appSummary.DoLogging()
+		sa.publishSummaryRecord(appSummary)
 	}
 	sa.cleanupTrackedResource()
+	return appSummary
 }
 
 // GetTrackedDAOMap returns the tracked resources type specified in which as a DAO similar to the normal resources.
@@ -0,0 +1,371 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package objects
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+	"github.com/apache/yunikorn-core/pkg/log"
+	"github.com/apache/yunikorn-core/pkg/scheduler/ugm"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+// AppTagGangMembers declares a gang scheduled without placeholders: the minimum number of members per task group
+// that must be allocated together, for example "driver=1,worker=4".
+const AppTagGangMembers = "application.gang.members"
+
+// NotEnoughGangResources is logged on the members of a gang that does not fit as a whole.
+const NotEnoughGangResources = "Not enough resources for gang"
+
+// ParseGangMembers parses the gang members tag value into the minimum number of members per task group.
+func ParseGangMembers(value string) (map[string]int, error) {
+	members := make(map[string]int)
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, count, ok := strings.Cut(entry, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid gang member entry: %s", entry)
+		}
+		if _, ok = members[name]; ok {
+			return nil, fmt.Errorf("duplicate task group in gang members: %s", name)
+		}
+		minMembers, err := strconv.Atoi(strings.TrimSpace(count))
+		if err != nil {
+			return nil, err
+		}
+		if minMembers <= 0 {
+			return nil, fmt.Errorf("gang members must be positive for task group %s: %d", name, minMembers)
+		}
+		members[name] = minMembers
+	}
+	if len(members) == 0 {
+		return nil, fmt.Errorf("no task groups in gang members: %s", value)
+	}
+	return members, nil
+}
+
+// IsGangPending returns true if the application is a gang that has not been allocated yet.
+func (sa *Application) IsGangPending() bool {
+	sa.RLock()
+	defer sa.RUnlock()
+	return sa.isGangPending()
+}
+
+func (sa *Application) isGangPending() bool {
+	return sa.gangMembers != nil && !sa.gangScheduled
+}
+
+func (sa *Application) initGangTimer() {
+	if sa.gangTimer != nil || !sa.isGangPending() || sa.execTimeout <= 0 {
+		return
+	}
+	log.Log(log.SchedApplication).Debug("Application gang timer initiated",
+		zap.String("AppID", sa.ApplicationID),
+		zap.Duration("Timeout", sa.execTimeout))
+	sa.gangTimer = time.AfterFunc(sa.execTimeout, sa.timeoutGangProcessing)
+}
+
+func (sa *Application) clearGangTimer() {
+	if sa == nil || sa.gangTimer == nil {
+		return
+	}
+	sa.gangTimer.Stop()
+	sa.gangTimer = nil
+	log.Log(log.SchedApplication).Debug("Application gang timer cleared",
+		zap.String("AppID", sa.ApplicationID))
+}
+
+// timeoutGangProcessing handles a gang that could not be allocated as a whole before the timeout.
+// Nothing of the gang has been allocated, the soft style resumes as a normal application and the hard style fails
+// the application and releases all pending asks.
+func (sa *Application) timeoutGangProcessing() {
+	sa.Lock()
+	defer sa.Unlock()
+	sa.gangTimer = nil
+	if !sa.isGangPending() {
+		return
+	}
+	if sa.gangSchedulingStyle == Hard {
+		if err := sa.HandleApplicationEventWithInfo(FailApplication, "ResourceReservationTimeout"); err != nil {
+			log.Log(log.SchedApplication).Debug("Application state change failed when gang timed out",
+				zap.String("AppID", sa.ApplicationID),
+				zap.String("currentState", sa.CurrentState()),
+				zap.Error(err))
+		}
+		var pendingRelease []*Allocation
+		for _, ask := range sa.requests {
+			if !ask.IsAllocated() {
+				ask.SetReleased(true)
+				pendingRelease = append(pendingRelease, ask)
+			}
+		}
+		log.Log(log.SchedApplication).Info("Gang timeout, failing application and releasing pending asks",
+			zap.String("AppID", sa.ApplicationID),
+			zap.Int("pending asks", len(pendingRelease)))
+		sa.removeAsksInternal("", si.EventRecord_REQUEST_TIMEOUT)
+		sa.notifyRMAllocationReleased(pendingRelease, si.TerminationType_TIMEOUT, "releasing pending asks on gang timeout")
+		// nothing was allocated for the gang: the application can finish failing
+		if sa.IsFailing() && len(sa.allocations) == 0 {
+			if err := sa.HandleApplicationEvent(FailApplication); err != nil {
+				log.Log(log.SchedApplication).Warn("Application state not changed to Failed after gang timeout",
+					zap.String("currentState", sa.CurrentState()),
+					zap.Error(err))
+			}
+		}
+		return
+	}
+	// soft style: drop the gang requirement and schedule the asks as normal requests
+	log.Log(log.SchedApplication).Info("Gang timeout, resuming application without gang scheduling",
+		zap.String("AppID", sa.ApplicationID))
+	sa.gangMembers = nil
+	if err := sa.HandleApplicationEventWithInfo(ResumeApplication, "ResourceReservationTimeout"); err != nil {
+		log.Log(log.SchedApplication).Debug("Application state change failed when gang timed out",
+			zap.String("AppID", sa.ApplicationID),
+			zap.String("currentState", sa.CurrentState()),
+			zap.Error(err))
+		return
+	}
+	if err := sa.HandleApplicationEvent(RunApplication); err != nil {
+		log.Log(log.SchedApplication).Warn("Application state not changed to Accepted after gang timeout",
+			zap.String("currentState", sa.CurrentState()),
+			zap.Error(err))
+	}
+}
+
+// getGangRequests returns the pending requests that form the gang: the first minimum number of requests for each
+// task group in sorted order. Returns nil if a task group does not have enough requests yet.
+func (sa *Application) getGangRequests() []*Allocation {
+	needed := make(map[string]int, len(sa.gangMembers))
+	total := 0
+	for name, count := range sa.gangMembers {
+		needed[name] = count
+		total += count
+	}
+	members := make([]*Allocation, 0, total)
+	for _, request := range sa.sortedRequests {
+		if request.IsAllocated() {
+			continue
+		}
+		if needed[request.GetTaskGroup()] > 0 {
+			needed[request.GetTaskGroup()]--
+			members = append(members, request)
+		}
+	}
+	if len(members) != total {
+		return nil
+	}
+	return members
+}
+
+// tryGangAllocate allocates all members of the gang in one go or nothing at all.
+// The members are first placed on the nodes without changing anything, only when all members have a node the
+// allocations are made. A failure while allocating rolls back the members already added.
+// The returned result is for the first member, all other members are linked to it.
+func (sa *Application) tryGangAllocate(headRoom, userHeadroom *resources.Resource, nodeIterator func() NodeIterator, getNodeFn func(string) *Node) *AllocationResult {
+	members := sa.getGangRequests()
+	if len(members) == 0 {
+		return nil
+	}
+	if err := ugm.GetUserManager().CheckAllocationLimit(sa.queuePath, sa.ApplicationID, sa.user, uint64(len(members))); err != nil {
+		for _, member := range members {
+			member.SendRequestExceedsAllocationLimitEvent(err.Error())
+		}
+		log.Log(log.SchedApplication).Debug("Gang exceeds the maximum allocations",
+			zap.String("appID", sa.ApplicationID),
+			zap.Int("members", len(members)),
+			zap.Error(err))
+		return nil
+	}
+	total := resources.NewResource()
+	for _, member := range members {
+		total.AddTo(member.GetAllocatedResource())
+	}
+	if !userHeadroom.FitInMaxUndef(total) {
+		for _, member := range members {
+			member.LogAllocationFailure(NotEnoughUserQuota, true)
+		}
+		return nil
+	}
+	if !headRoom.FitInMaxUndef(total) {
+		for _, member := range members {
+			member.LogAllocationFailure(NotEnoughQueueQuota, true)
+		}
+		return nil
+	}
+	placement := sa.placeGang(members, nodeIterator, getNodeFn)
+	if placement == nil {
+		for _, member := range members {
+			member.LogAllocationFailure(NotEnoughGangResources, true)
+		}
+		return nil
+	}
+	// add all members to their nodes, undo on the first failure
+	for i, member := range members {
+		member.SetSchedulingAttempted(true)
+		if !placement[i].TryAddAllocation(member) {
+			log.Log(log.SchedApplication).Info("gang member no longer fits on node, rolling back gang",
+				zap.String("appID", sa.ApplicationID),
+				zap.String("allocationKey", member.GetAllocationKey()),
+				zap.String("nodeID", placement[i].NodeID))
+			sa.rollbackGang(members[:i], placement)
+			return nil
+		}
+	}
+	if err := sa.queue.TryIncAllocatedResource(total); err != nil {
+		log.Log(log.SchedApplication).Info("gang does not fit in queue, rolling back gang",
+			zap.String("appID", sa.ApplicationID),
+			zap.Error(err))
+		sa.rollbackGang(members, placement)
+		return nil
+	}
+	var result *AllocationResult
+	for i, member := range members {
+		if _, err := sa.allocateAsk(member); err != nil {
+			log.Log(log.SchedApplication).Warn("allocation of gang member failed unexpectedly",
+				zap.Error(err))
+		}
+		memberResult := newAllocatedAllocationResult(placement[i].NodeID, member)
+		sa.addAllocationInternal(memberResult.ResultType, member)
+		if result == nil {
+			result = memberResult
+		} else {
+			result.GangMembers = append(result.GangMembers, memberResult)
+		}
+	}
+	sa.gangScheduled = true
+	sa.clearGangTimer()
+	log.Log(log.SchedApplication).Info("gang allocated",
+		zap.String("appID", sa.ApplicationID),
+		zap.Int("members", len(members)),
+		zap.Stringer("resource", total))
+	return result
+}
+
+// placeGang finds a node for each member of the gang without allocating. Members are placed in order on the first
+// node with enough room left after the earlier members. The topology constraint of a member is applied counting the
+// earlier members as allocations of the application. Returns nil if one of the members cannot be placed.
+func (sa *Application) placeGang(members []*Allocation, nodeIterator func() NodeIterator, getNodeFn func(string) *Node) []*Node {
+	var nodes []*Node
+	if iterator := nodeIterator(); iterator != nil {
+		iterator.ForEachNode(func(node *Node) bool {
+			if node.IsSchedulable() {
+				nodes = append(nodes, node)
+			}
+			return true
+		})
+	}
+	now := time.Now()
+	available := make(map[string]*resources.Resource)
+	placement := make([]*Node, len(members))
+	for i, member := range members {
+		candidates := nodes
+		if requiredNode := member.GetRequiredNode(); requiredNode != "" {
+			node := getNodeFn(requiredNode)
+			if node == nil || !node.IsSchedulable() {
+				return nil
+			}
+			candidates = []*Node{node}
+		} else if constraint := member.GetTopologyConstraint(); constraint != nil {
+			counts := sa.topologyCounts(constraint.Level, getNodeFn)
+			for _, placed := range placement[:i] {
+				counts[placed.getTopologyDomain(constraint.Level)]++
+			}
+			candidates = constraint.orderNodes(nodes, counts)
+		}
+		for _, node := range candidates {
+			free, ok := available[node.NodeID]
+			if !ok {
+				free = node.GetAvailableResource()
+				available[node.NodeID] = free
+			}
+			if !free.FitIn(member.GetAllocatedResource()) || !node.preAllocateCheck(member.GetAllocatedResource(), member.GetAllocationKey()) {
+				continue
+			}
+			if !node.preBookingCheck(member, sa.queuePath, now) || node.preAffinityCheck(member) != nil || node.preAllocateConditions(member) != nil {
+				continue
+			}
+			free.SubFrom(member.GetAllocatedResource())
+			placement[i] = node
+			break
+		}
+		if placement[i] == nil {
+			return nil
+		}
+	}
+	return placement
+}
+
+// rollbackGang removes the gang members that were added to their nodes.
+func (sa *Application) rollbackGang(added []*Allocation, placement []*Node) {
+	for i, member := range added {
+		placement[i].RemoveAllocation(member.GetAllocationKey())
+	}
+}
+
+// RollbackGangAllocation undoes a gang allocation that the partition could not process completely. The members are
+// removed from their nodes, the application and the queue and are pending again. Members that were already removed,
+// with their node or on an earlier failure, are only made pending again.
+func (sa *Application) RollbackGangAllocation(members []*AllocationResult, getNodeFn func(string) *Node) {
+	sa.Lock()
+	defer sa.Unlock()
+	for _, member := range members {
+		alloc := member.Request
+		allocKey := alloc.GetAllocationKey()
+		if node := getNodeFn(member.NodeID); node != nil {
+			node.RemoveAllocation(allocKey)
+		}
+		if _, ok := sa.allocations[allocKey]; ok {
+			delete(sa.allocations, allocKey)
+			delta := alloc.GetAllocatedResource()
+			sa.allocatedResource = resources.Sub(sa.allocatedResource, delta)
+			sa.allocatedResource.Prune()
+			if err := sa.queue.DecAllocatedResource(delta); err != nil {
+				log.Log(log.SchedApplication).Warn("failed to remove gang member usage from queue",
+					zap.String("appID", sa.ApplicationID),
+					zap.String("allocationKey", allocKey),
+					zap.Error(err))
+			}
+			sa.decUserResourceUsage(delta, false)
+			sa.appEvents.SendRemoveAllocationEvent(sa.ApplicationID, allocKey, delta, si.TerminationType_STOPPED_BY_RM)
+		}
+		if alloc.IsAllocated() {
+			if _, err := sa.deallocateAsk(alloc); err != nil {
+				log.Log(log.SchedApplication).Warn("failed to make gang member pending again",
+					zap.String("appID", sa.ApplicationID),
+					zap.String("allocationKey", allocKey),
+					zap.Error(err))
+			}
+		}
+	}
+	sa.updateUserCounts()
+	sa.gangScheduled = false
+	log.Log(log.SchedApplication).Info("gang allocation rolled back",
+		zap.String("appID", sa.ApplicationID),
+		zap.Int("members", len(members)))
+}
@@ -0,0 +1,243 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package objects
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-core/pkg/common"
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+	"github.com/apache/yunikorn-core/pkg/events/mock"
+	"github.com/apache/yunikorn-core/pkg/rmproxy"
+	"github.com/apache/yunikorn-core/pkg/rmproxy/rmevent"
+	schedEvt "github.com/apache/yunikorn-core/pkg/scheduler/objects/events"
+	"github.com/apache/yunikorn-core/pkg/scheduler/ugm"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+func TestParseGangMembers(t *testing.T) {
+	members, err := ParseGangMembers("driver=1, worker = 4")
+	assert.NilError(t, err, "valid gang members should not fail")
+	assert.DeepEqual(t, members, map[string]int{"driver": 1, "worker": 4})
+
+	for _, value := range []string{"", "worker", "=2", "worker=0", "worker=-1", "worker=two", "worker=1,worker=2"} {
+		_, err = ParseGangMembers(value)
+		assert.Assert(t, err != nil, "expected error for gang members: %s", value)
+	}
+}
+
+func newGangApplication(t *testing.T, members string, style string) (*Application, *Queue, *rmproxy.MockedRMProxy) {
+	setupUGM()
+	root, err := createRootQueue(map[string]string{"first": "100"})
+	assert.NilError(t, err, "queue create failed")
+	queue, err := createManagedQueue(root, "gang", false, nil)
+	assert.NilError(t, err, "queue create failed")
+	app := newApplicationWithTags(appID1, "default", "root.gang", map[string]string{AppTagGangMembers: members})
+	handler := rmproxy.NewMockedRMProxy()
+	app.rmEventHandler = handler
+	app.gangSchedulingStyle = style
+	app.SetQueue(queue)
+	queue.AddApplication(app)
+	return app, queue, handler
+}
+
+func TestTryGangAllocate(t *testing.T) {
+	app, queue, _ := newGangApplication(t, "driver=1,worker=2", Soft)
+	assert.Assert(t, app.IsGangPending(), "application should be a pending gang")
+	defer app.clearGangTimer()
+
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"first": 3})
+	node1 := newNode("node-1", map[string]resources.Quantity{"first": 5})
+	node2 := newNode("node-2", map[string]resources.Quantity{"first": 5})
+	nodeMap := map[string]*Node{"node-1": node1, "node-2": node2}
+	getNode := func(nodeID string) *Node {
+		return nodeMap[nodeID]
+	}
+	iterator := getNodeIteratorFn(node1, node2)
+	preemptionAttemptsRemaining := 0
+	tryAllocate := func() *AllocationResult {
+		return app.tryAllocate(resources.NewResourceFromMap(map[string]resources.Quantity{"first": 100}), false, 0, &preemptionAttemptsRemaining, iterator, iterator, getNode)
+	}
+
+	// not all members requested yet: nothing is allocated
+	assert.NilError(t, app.AddAllocationAsk(newAllocationAskAll("driver-1", appID1, "driver", res, false, 1)))
+	assert.NilError(t, app.AddAllocationAsk(newAllocationAskAll("worker-1", appID1, "worker", res, false, 1)))
+	assert.Assert(t, tryAllocate() == nil, "incomplete gang should not allocate")
+
+	// all members requested but only two fit: nothing is allocated
+	assert.NilError(t, app.AddAllocationAsk(newAllocationAskAll("worker-2", appID1, "worker", res, false, 1)))
+	assert.Assert(t, tryAllocate() == nil, "gang that does not fit should not allocate")
+	assert.Assert(t, resources.IsZero(node1.GetAllocatedResource()), "node-1 should not have allocations")
+	assert.Assert(t, resources.IsZero(node2.GetAllocatedResource()), "node-2 should not have allocations")
+	assert.Assert(t, resources.IsZero(queue.GetAllocatedResource()), "queue should not have allocations")
+	assert.Equal(t, len(app.GetAllAllocations()), 0, "application should not have allocations")
+
+	// a third node makes the gang fit: all members are allocated in one go
+	node3 := newNode("node-3", map[string]resources.Quantity{"first": 5})
+	nodeMap["node-3"] = node3
+	iterator = getNodeIteratorFn(node1, node2, node3)
+	result := tryAllocate()
+	assert.Assert(t, result != nil, "gang should be allocated")
+	assert.Equal(t, len(result.GangMembers), 2, "all members should be linked to the result")
+	nodes := map[string]bool{result.NodeID: true}
+	for _, member := range result.GangMembers {
+		assert.Equal(t, member.ResultType, Allocated)
+		nodes[member.NodeID] = true
+	}
+	assert.Equal(t, len(nodes), 3, "each member should be on its own node")
+	assert.Assert(t, !app.IsGangPending(), "gang should be allocated")
+	assert.Assert(t, app.IsRunning(), "application should be running: %s", app.CurrentState())
+	assert.Equal(t, len(app.GetAllAllocations()), 3, "all members should be allocated")
+	assert.Assert(t, resources.Equals(queue.GetAllocatedResource(), resources.Multiply(res, 3)), "queue usage not updated")
+	assert.Assert(t, resources.IsZero(app.GetPendingResource()), "no pending resources expected")
+	assert.Assert(t, app.gangTimer == nil, "gang timer should be cleared")
+
+	// later requests are allocated one by one
+	assert.NilError(t, app.AddAllocationAsk(newAllocationAskAll("worker-3", appID1, "worker", resources.NewResourceFromMap(map[string]resources.Quantity{"first": 2}), false, 1)))
+	result = tryAllocate()
+	assert.Assert(t, result != nil, "request after gang should be allocated")
+	assert.Equal(t, len(result.GangMembers), 0, "request after gang should not be a gang")
+}
+
+func TestTryGangAllocateQueueHeadroom(t *testing.T) {
+	app, queue, _ := newGangApplication(t, "worker=2", Soft)
+	defer app.clearGangTimer()
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"first": 3})
+	node := newNode("node-1", map[string]resources.Quantity{"first": 10})
+	iterator := getNodeIteratorFn(node)
+	getNode := func(string) *Node { return node }
+	assert.NilError(t, app.AddAllocationAsk(newAllocationAskAll("worker-1", appID1, "worker", res, false, 1)))
+	assert.NilError(t, app.AddAllocationAsk(newAllocationAskAll("worker-2", appID1, "worker", res, false, 1)))
+
+	// headroom fits one member only
+	preemptionAttemptsRemaining := 0
+	headRoom := resources.NewResourceFromMap(map[string]resources.Quantity{"first": 5})
+	result := app.tryAllocate(headRoom, false, 0, &preemptionAttemptsRemaining, iterator, iterator, getNode)
+	assert.Assert(t, result == nil, "gang larger than headroom should not allocate")
+	assert.Assert(t, resources.IsZero(queue.GetAllocatedResource()), "queue should not have allocations")
+	assert.Assert(t, resources.IsZero(node.GetAllocatedResource()), "node should not have allocations")
+}
+
+func TestTryGangAllocateAllocationLimit(t *testing.T) {
+	app, queue, _ := newGangApplication(t, "worker=2", Soft)
+	defer app.clearGangTimer()
+	defer setupUGM()
+	conf := configs.QueueConfig{
+		Name:      "root",
+		Parent:    true,
+		SubmitACL: "*",
+		Limits: []configs.Limit{
+			{
+				Limit:          "allocations limit",
+				Users:          []string{"testuser"},
+				MaxAllocations: 1,
+			},
+		},
+	}
+	assert.NilError(t, ugm.GetUserManager().UpdateConfig(conf, "root"))
+
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"first": 3})
+	node := newNode("node-1", map[string]resources.Quantity{"first": 10})
+	iterator := getNodeIteratorFn(node)
+	getNode := func(string) *Node { return node }
+	eventSystem := mock.NewEventSystem()
+	for _, key := range []string{"worker-1", "worker-2"} {
+		ask := newAllocationAskAll(key, appID1, "worker", res, false, 1)
+		ask.askEvents = schedEvt.NewAskEvents(eventSystem)
+		assert.NilError(t, app.AddAllocationAsk(ask))
+	}
+
+	// the whole gang does not fit in the allocations limit: nothing is allocated and each member is informed
+	preemptionAttemptsRemaining := 0
+	headRoom := resources.NewResourceFromMap(map[string]resources.Quantity{"first": 100})
+	result := app.tryAllocate(headRoom, false, 0, &preemptionAttemptsRemaining, iterator, iterator, getNode)
+	assert.Assert(t, result == nil, "gang larger than the allocations limit should not allocate")
+	assert.Assert(t, resources.IsZero(queue.GetAllocatedResource()), "queue should not have allocations")
+	assert.Equal(t, len(eventSystem.Events), 2, "each member should have a limit event")
+	for _, event := range eventSystem.Events {
+		assert.Equal(t, event.Type, si.EventRecord_REQUEST)
+		assert.Assert(t, event.Message != "", "limit event should have a message")
+	}
+	assert.Equal(t, eventSystem.Events[0].ObjectID, "worker-1")
+	assert.Equal(t, eventSystem.Events[1].ObjectID, "worker-2")
+
+	// limit reached for a normal request: the first pending request is informed
+	app.gangMembers = nil
+	ugm.GetUserManager().UpdateApplicationCounts("root.gang", appID1, app.user, 2, 1)
+	eventSystem.Reset()
+	for _, ask := range app.requests {
+		ask.askEvents = schedEvt.NewAskEvents(eventSystem)
+	}
+	result = app.tryAllocate(headRoom, false, 0, &preemptionAttemptsRemaining, iterator, iterator, getNode)
+	assert.Assert(t, result == nil, "request over the allocations limit should not allocate")
+	assert.Equal(t, len(eventSystem.Events), 1, "first pending request should have a limit event")
+	assert.Equal(t, eventSystem.Events[0].ObjectID, "worker-1")
+	ugm.GetUserManager().UpdateApplicationCounts("root.gang", appID1, app.user, 0, 0)
+}
+
+func TestGangTimeoutSoft(t *testing.T) {
+	app, _, _ := newGangApplication(t, "worker=2", Soft)
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"first": 3})
+	assert.NilError(t, app.AddAllocationAsk(newAllocationAskAll("worker-1", appID1, "worker", res, false, 1)))
+	assert.Assert(t, app.IsAccepted(), "application should be accepted")
+	assert.Assert(t, app.gangTimer != nil, "gang timer should be started")
+	app.clearGangTimer()
+
+	app.timeoutGangProcessing()
+	assert.Assert(t, app.IsAccepted(), "application should be accepted after resume: %s", app.CurrentState())
+	assert.Assert(t, !app.IsGangPending(), "gang requirement should be dropped")
+	assert.Equal(t, len(app.GetAllRequests()), 1, "requests should be kept")
+	assert.Assert(t, app.gangTimer == nil, "gang timer should be cleared")
+}
+
+func TestGangTimeoutHard(t *testing.T) {
+	app, queue, handler := newGangApplication(t, "worker=2", Hard)
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"first": 3})
+	assert.NilError(t, app.AddAllocationAsk(newAllocationAskAll("worker-1", appID1, "worker", res, false, 1)))
+	app.clearGangTimer()
+
+	app.timeoutGangProcessing()
+	assert.Assert(t, app.IsFailed(), "application should be failed: %s", app.CurrentState())
+	assert.Equal(t, len(app.GetAllRequests()), 0, "requests should be removed")
+	assert.Assert(t, resources.IsZero(queue.GetPendingResource()), "queue pending should be removed")
+	var releases []*rmevent.RMReleaseAllocationEvent
+	for _, event := range handler.GetEvents() {
+		if release, ok := event.(*rmevent.RMReleaseAllocationEvent); ok {
+			releases = append(releases, release)
+		}
+	}
+	assert.Equal(t, len(releases), 1, "expected one release event")
+	assert.Equal(t, len(releases[0].ReleasedAllocations), 1, "pending ask should be released")
+}
+
+func TestGangTimer(t *testing.T) {
+	originalPhTimeout := defaultPlaceholderTimeout
+	defaultPlaceholderTimeout = 5 * time.Millisecond
+	defer func() { defaultPlaceholderTimeout = originalPhTimeout }()
+	app, _, _ := newGangApplication(t, "worker=2", Soft)
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"first": 3})
+	assert.NilError(t, app.AddAllocationAsk(newAllocationAskAll("worker-1", appID1, "worker", res, false, 1)))
+	assert.NilError(t, common.WaitForCondition(10*time.Millisecond, time.Second, func() bool {
+		return !app.IsGangPending()
+	}), "gang timer did not fire")
+}
@@ -0,0 +1,99 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package objects
+
+import (
+	"time"
+
+	"github.com/apache/yunikorn-core/pkg/scheduler/sink"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+// publishStateRecord publishes the state change of the application to the record sinks.
+// No locking must be called while holding the application lock.
+func (sa *Application) publishStateRecord(state string, changeTime time.Time) {
+	if !sink.GetDispatcher().IsEnabled() {
+		return
+	}
+	sink.GetDispatcher().Publish(&sink.Record{
+		Type:          sink.RecordAppState,
+		Time:          changeTime,
+		ApplicationID: sa.ApplicationID,
+		Partition:     sa.Partition,
+		Queue:         sa.queuePath,
+		User:          sa.user.User,
+		State:         state,
+	})
+}
+
+// publishSummaryRecord publishes the summary of the terminated application to the record sinks.
+func (sa *Application) publishSummaryRecord(appSummary *ApplicationSummary) {
+	if !sink.GetDispatcher().IsEnabled() {
+		return
+	}
+	sink.GetDispatcher().Publish(&sink.Record{
+		Type:          sink.RecordAppSummary,
+		Time:          time.Now(),
+		ApplicationID: appSummary.ApplicationID,
+		Partition:     sa.Partition,
+		Queue:         appSummary.Queue,
+		User:          appSummary.User,
+		State:         appSummary.State,
+		Summary: &sink.SummaryRecord{
+			SubmissionTime:      appSummary.SubmissionTime,
+			StartTime:           appSummary.StartTime,
+			FinishTime:          appSummary.FinishTime,
+			RmID:                appSummary.RmID,
+			ResourceUsage:       appSummary.ResourceUsage.DAOMap(),
+			PreemptedResource:   appSummary.PreemptedResource.DAOMap(),
+			PlaceholderResource: appSummary.PlaceholderResource.DAOMap(),
+		},
+	})
+}
+
+// publishAllocationRecord publishes the allocation that was added to or released from the application to the
+// record sinks. The termination type is only set for a release.
+// No locking must be called while holding the application lock.
+func (sa *Application) publishAllocationRecord(alloc *Allocation, event string, releaseType si.TerminationType) {
+	if !sink.GetDispatcher().IsEnabled() {
+		return
+	}
+	record := &sink.AllocationRecord{
+		AllocationKey: alloc.GetAllocationKey(),
+		Event:         event,
+		NodeID:        alloc.GetNodeID(),
+		InstanceType:  alloc.GetInstanceType(),
+		Resource:      alloc.GetAllocatedResource().DAOMap(),
+		Placeholder:   alloc.IsPlaceholder(),
+		Preempted:     alloc.IsPreempted(),
+		BindTime:      alloc.GetBindTime(),
+	}
+	if event == sink.AllocationReleased {
+		record.TerminationType = releaseType.String()
+	}
+	sink.GetDispatcher().Publish(&sink.Record{
+		Type:          sink.RecordAllocation,
+		Time:          time.Now(),
+		ApplicationID: sa.ApplicationID,
+		Partition:     sa.Partition,
+		Queue:         sa.queuePath,
+		User:          sa.user.User,
+		Allocation:    record,
+	})
+}
@@ -163,7 +163,7 @@ func callbacks() fsm.Callbacks {
 					zap.String("state", event.Dst))
 				return
 			}
-			if app.sendStateChangeEvents {
+			if app.sendStateChangeEvents && !app.replaying {
 				app.appEvents.SendStateChangeEvent(app.ApplicationID, eventDetails, eventInfo)
 			}
 		},
@@ -252,6 +252,7 @@ func callbacks() fsm.Callbacks {
 			app.setStateTimer(terminatedTimeout, app.stateMachine.Current(), ExpireApplication)
 			app.executeTerminatedCallback()
 			app.clearPlaceholderTimer()
+			app.clearGangTimer()
 			app.cleanupAsks()
 		},
 		fmt.Sprintf("enter_%s", Failed.String()): func(_ context.Context, event *fsm.Event) {
@@ -260,6 +261,7 @@ func callbacks() fsm.Callbacks {
 			metrics.GetQueueMetrics(app.queuePath).IncQueueApplicationsFailed()
 			app.setStateTimer(terminatedTimeout, app.stateMachine.Current(), ExpireApplication)
 			app.executeTerminatedCallback()
+			app.clearGangTimer()
 			app.cleanupAsks()
 		},
 	}
@@ -32,6 +32,7 @@ type ApplicationSummary struct {
 	StartTime           time.Time
 	FinishTime          time.Time
 	User                string
+	Groups              []string
 	Queue               string
 	State               string
 	RmID                string
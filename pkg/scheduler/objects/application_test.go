@@ -34,11 +34,13 @@ import (
 	"github.com/apache/yunikorn-core/pkg/events"
 	"github.com/apache/yunikorn-core/pkg/events/mock"
 	"github.com/apache/yunikorn-core/pkg/handler"
+	"github.com/apache/yunikorn-core/pkg/locking"
 	mockCommon "github.com/apache/yunikorn-core/pkg/mock"
 	"github.com/apache/yunikorn-core/pkg/plugins"
 	"github.com/apache/yunikorn-core/pkg/rmproxy"
 	"github.com/apache/yunikorn-core/pkg/rmproxy/rmevent"
 	schedEvt "github.com/apache/yunikorn-core/pkg/scheduler/objects/events"
+	"github.com/apache/yunikorn-core/pkg/scheduler/sink"
 	"github.com/apache/yunikorn-core/pkg/scheduler/ugm"
 	siCommon "github.com/apache/yunikorn-scheduler-interface/lib/go/common"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
@@ -57,6 +59,7 @@ func setupUGM() {
 	userManager := ugm.GetUserManager()
 	userManager.ClearUserTrackers()
 	userManager.ClearGroupTrackers()
+	userManager.ClearApplicationCounts()
 }
 
 // basic app creating with timeout checks
@@ -1289,6 +1292,99 @@ func TestResourceUsageAggregation(t *testing.T) {
 	assertResourceUsage(t, appSummary, 600, 60)
 }
 
+func TestGetUsageSummary(t *testing.T) {
+	setupUGM()
+
+	app := newApplication(appID1, "default", "root.a")
+	assert.Assert(t, app.GetUsageSummary("default") == nil, "application that never ran has no usage")
+	res, err := resources.NewResourceFromConf(map[string]string{"memory": "100", "vcores": "10"})
+	assert.NilError(t, err, "failed to create resource with error")
+	alloc := newAllocation(appID1, nodeID1, res)
+	alloc.SetInstanceType(instType1)
+	alloc.SetBindTime(time.Now().Add(-3 * time.Second))
+	app.AddAllocation(alloc)
+	err = app.HandleApplicationEvent(RunApplication)
+	assert.NilError(t, err, "no error expected new to accepted")
+
+	// the running allocation is included up to now but not tracked in the application
+	appSummary := app.GetUsageSummary("default")
+	assert.DeepEqual(t, appSummary.Groups, []string{"testgroup"})
+	assertResourceUsage(t, appSummary, 300, 30)
+	assertResourceUsage(t, app.GetApplicationSummary("default"), 0, 0)
+
+	// the logged summary is returned and the tracked usage cleaned up
+	app.RemoveAllAllocations()
+	appSummary = app.LogAppSummary("default")
+	assert.Assert(t, appSummary != nil, "summary of a started application expected")
+	assertResourceUsage(t, appSummary, 300, 30)
+	assert.Assert(t, app.GetUsageSummary("default") == nil, "no usage expected after cleanup")
+	assert.Assert(t, newApplication(appID2, "default", "root.a").LogAppSummary("default") == nil, "no summary expected for an application that never ran")
+}
+
+// recordSink collects the records published by the application.
+type recordSink struct {
+	records []*sink.Record
+	locking.Mutex
+}
+
+func (rs *recordSink) Write(records []*sink.Record) error {
+	rs.Lock()
+	defer rs.Unlock()
+	rs.records = append(rs.records, records...)
+	return nil
+}
+
+func (rs *recordSink) Close() error {
+	return nil
+}
+
+func TestPublishRecords(t *testing.T) {
+	setupUGM()
+	rs := &recordSink{}
+	sink.GetDispatcher().Register("test", rs, sink.Options{QueueSize: 100, BatchSize: 100, FlushInterval: time.Hour})
+
+	app := newApplication(appID1, "default", "root.a")
+	res, err := resources.NewResourceFromConf(map[string]string{"memory": "100", "vcores": "10"})
+	assert.NilError(t, err, "failed to create resource with error")
+	alloc := newAllocation(appID1, nodeID1, res)
+	alloc.SetInstanceType(instType1)
+	app.AddAllocation(alloc)
+	err = app.HandleApplicationEvent(RunApplication)
+	assert.NilError(t, err, "no error expected accepted to running")
+	app.RemoveAllocation(alloc.GetAllocationKey(), si.TerminationType_STOPPED_BY_RM)
+	app.LogAppSummary("default")
+	// unregister flushes the records
+	sink.GetDispatcher().Unregister("test")
+
+	var states []string
+	var allocEvents []string
+	var summary *sink.Record
+	for _, record := range rs.records {
+		assert.Equal(t, record.ApplicationID, appID1)
+		assert.Equal(t, record.Queue, "root.a")
+		assert.Equal(t, record.User, "testuser")
+		switch record.Type {
+		case sink.RecordAppState:
+			states = append(states, record.State)
+		case sink.RecordAllocation:
+			allocEvents = append(allocEvents, record.Allocation.Event)
+			assert.Equal(t, record.Allocation.NodeID, nodeID1)
+			assert.Equal(t, record.Allocation.InstanceType, instType1)
+			assert.DeepEqual(t, record.Allocation.Resource, map[string]int64{"memory": 100, "vcores": 10})
+			if record.Allocation.Event == sink.AllocationReleased {
+				assert.Equal(t, record.Allocation.TerminationType, si.TerminationType_STOPPED_BY_RM.String())
+			}
+		case sink.RecordAppSummary:
+			summary = record
+		}
+	}
+	assert.DeepEqual(t, states, []string{Accepted.String(), Running.String(), Completing.String()})
+	assert.DeepEqual(t, allocEvents, []string{sink.AllocationAdded, sink.AllocationReleased})
+	assert.Assert(t, summary != nil, "summary record expected")
+	assert.Equal(t, summary.State, Completing.String())
+	assert.Assert(t, summary.Summary.ResourceUsage[instType1] != nil, "resource usage expected in the summary")
+}
+
 func TestRejected(t *testing.T) {
 	terminatedTimeout = time.Millisecond * 100
 	defer func() {
@@ -2762,6 +2858,89 @@ func TestRequestDoesNotFitUserQuotaQueueEvents(t *testing.T) {
 	assert.Equal(t, "Request 'alloc-0' fits in the available user quota", event.Message)
 }
 
+func TestAddAllocAskUserLimit(t *testing.T) {
+	setupUGM()
+	defer setupUGM()
+	// create config with a pending asks limit for "testuser"
+	conf := configs.QueueConfig{
+		Name:      "root",
+		Parent:    true,
+		SubmitACL: "*",
+		Limits: []configs.Limit{
+			{
+				Limit:          "pending asks limit",
+				Users:          []string{"testuser"},
+				MaxPendingAsks: 1,
+			},
+		},
+	}
+	err := ugm.GetUserManager().UpdateConfig(conf, "root")
+	assert.NilError(t, err)
+
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"first": 5})
+	app := newApplication(appID1, "default", "root")
+	app.disableStateChangeEvents()
+	app.resetAppEvents()
+	queue, err := createRootQueue(nil)
+	assert.NilError(t, err, "queue create failed")
+	app.queue = queue
+	eventSystem := mock.NewEventSystem()
+
+	ask := newAllocationAsk(aKey, appID1, res)
+	assert.NilError(t, app.AddAllocationAsk(ask), "first ask should have been added to app")
+	assert.Equal(t, app.pendingAsks, uint64(1))
+	// an update of the same ask is not limited
+	ask = newAllocationAsk(aKey, appID1, res)
+	assert.NilError(t, app.AddAllocationAsk(ask), "ask update should have been allowed")
+	assert.Equal(t, app.pendingAsks, uint64(1))
+
+	// second ask is rejected with an event
+	ask2 := newAllocationAsk(aKey2, appID1, res)
+	ask2.askEvents = schedEvt.NewAskEvents(eventSystem)
+	err = app.AddAllocationAsk(ask2)
+	assert.ErrorContains(t, err, "user testuser has reached the maximum of 1 pending asks in queue root")
+	assert.Equal(t, len(app.requests), 1)
+	assert.Assert(t, resources.Equals(app.GetPendingResource(), res))
+	assert.Equal(t, 1, len(eventSystem.Events))
+	assert.Equal(t, si.EventRecord_REQUEST, eventSystem.Events[0].Type)
+	assert.Equal(t, aKey2, eventSystem.Events[0].ObjectID)
+
+	// allocating the first ask frees up the pending slot
+	_, err = app.AllocateAsk(aKey)
+	assert.NilError(t, err)
+	assert.Equal(t, app.pendingAsks, uint64(0))
+	assert.NilError(t, app.AddAllocationAsk(ask2), "second ask should have been added to app")
+	// removing all asks resets the count
+	app.RemoveAllocationAsk("")
+	assert.Equal(t, app.pendingAsks, uint64(0))
+	assert.NilError(t, ugm.GetUserManager().CheckPendingAskLimit("root", appID1, app.user))
+}
+
+func TestAddAllocAskInvalidTags(t *testing.T) {
+	setupUGM()
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"first": 5})
+	app := newApplication(appID1, "default", "root")
+	queue, err := createRootQueue(nil)
+	assert.NilError(t, err, "queue create failed")
+	app.queue = queue
+	eventSystem := mock.NewEventSystem()
+
+	for i, tags := range []map[string]string{
+		{AllocTagNodeAffinity: "=x"},
+		{AllocTagTopologySpread: "region"},
+	} {
+		ask := newAllocationAffinity(aKey, appID1, "", res, tags)
+		ask.askEvents = schedEvt.NewAskEvents(eventSystem)
+		err = app.AddAllocationAsk(ask)
+		assert.ErrorContains(t, err, "ask alloc-1 rejected for app app-1")
+		assert.Equal(t, len(app.requests), 0, "ask with invalid tags should not be added")
+		assert.Assert(t, resources.IsZero(app.GetPendingResource()), "pending resource should not change")
+		assert.Equal(t, i+1, len(eventSystem.Events))
+		assert.Equal(t, si.EventRecord_REQUEST, eventSystem.Events[i].Type)
+		assert.Equal(t, aKey, eventSystem.Events[i].ObjectID)
+	}
+}
+
 func TestAllocationFailures(t *testing.T) {
 	setupUGM()
 
@@ -3650,3 +3829,30 @@ func TestTryNodesNoReserve(t *testing.T) {
 	assert.Equal(t, result.ResultType, AllocatedReserved, "result type should be AllocatedReserved")
 	assert.Equal(t, result.ReservedNodeID, node1.NodeID, "reserved node should be node1")
 }
+
+func TestRestoreTerminated(t *testing.T) {
+	finished := time.Now().Add(-time.Minute).Truncate(time.Second)
+	started := finished.Add(-time.Hour)
+	stateLog := []*StateLogEntry{
+		{Time: started, ApplicationState: Running.String()},
+		{Time: finished, ApplicationState: Completed.String()},
+	}
+	for _, state := range []string{Completed.String(), Failed.String(), Rejected.String()} {
+		t.Run(state, func(t *testing.T) {
+			app := newApplication(appID1, "default", "root.unknown")
+			app.RestoreHistory(started, stateLog)
+			assert.NilError(t, app.RestoreTerminated(state, started, finished, "restored"), "restore failed")
+			assert.Equal(t, app.CurrentState(), state)
+			assert.Equal(t, len(app.GetStateLog()), len(stateLog), "replayed transitions should not be logged")
+			assert.Assert(t, app.StartTime().Equal(started), "start time not restored")
+			assert.Assert(t, app.FinishedTime().Equal(finished), "finished time not restored")
+			assert.Equal(t, app.GetRejectedMessage(), "restored")
+			// the restored application expires like any other terminated application
+			assert.NilError(t, app.HandleApplicationEvent(ExpireApplication), "expire failed")
+			assert.Equal(t, app.CurrentState(), Expired.String())
+		})
+	}
+	app := newApplication(appID1, "default", "root.unknown")
+	assert.ErrorContains(t, app.RestoreTerminated(Running.String(), started, finished, ""), "cannot be restored")
+	assert.Equal(t, app.CurrentState(), New.String())
+}
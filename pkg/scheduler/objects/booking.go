@@ -0,0 +1,104 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package objects
+
+import (
+	"strings"
+	"time"
+
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+)
+
+// Booking is the part of an advance reservation pinned to a node: capacity on the node kept free for the booking
+// queue during a future time window.
+type Booking struct {
+	ID        string              // ID of the advance reservation
+	QueuePath string              // queue the capacity is booked for, includes all child queues
+	Resource  *resources.Resource // capacity booked on the node
+	Remaining *resources.Resource // booked capacity not yet used by the booking queue
+	Start     time.Time           // start of the window
+	End       time.Time           // end of the window
+}
+
+// InBookingQueue returns true if the queue is the booking queue or one of its children.
+func (b *Booking) InBookingQueue(queuePath string) bool {
+	return queuePath == b.QueuePath || strings.HasPrefix(queuePath, b.QueuePath+".")
+}
+
+// conflicts returns true if an ask from the queue with the expected runtime would run into the window.
+// An ask without an expected runtime is expected to run forever.
+func (b *Booking) conflicts(queuePath string, runtime time.Duration, now time.Time) bool {
+	if b.InBookingQueue(queuePath) || !now.Before(b.End) {
+		return false
+	}
+	return runtime <= 0 || now.Add(runtime).After(b.Start)
+}
+
+// SetBooking pins the booking to the node, replaces an existing booking with the same ID.
+func (sn *Node) SetBooking(booking *Booking) {
+	sn.Lock()
+	defer sn.Unlock()
+	if sn.bookings == nil {
+		sn.bookings = make(map[string]*Booking)
+	}
+	sn.bookings[booking.ID] = booking
+}
+
+// RemoveBooking removes the booking from the node.
+func (sn *Node) RemoveBooking(id string) {
+	sn.Lock()
+	defer sn.Unlock()
+	delete(sn.bookings, id)
+}
+
+// GetBooking returns the booking with the ID pinned to the node, nil if not pinned.
+func (sn *Node) GetBooking(id string) *Booking {
+	sn.RLock()
+	defer sn.RUnlock()
+	return sn.bookings[id]
+}
+
+// SetBookingRemaining updates the booked capacity on the node that is not used by the booking queue yet.
+func (sn *Node) SetBookingRemaining(id string, remaining *resources.Resource) {
+	sn.Lock()
+	defer sn.Unlock()
+	if booking, ok := sn.bookings[id]; ok {
+		booking.Remaining = remaining
+	}
+}
+
+// preBookingCheck checks that the ask from the queue does not use capacity booked on the node for another queue
+// during a window the ask would run into. Returns true if the ask can be placed on the node.
+func (sn *Node) preBookingCheck(ask *Allocation, queuePath string, now time.Time) bool {
+	sn.RLock()
+	defer sn.RUnlock()
+	if len(sn.bookings) == 0 {
+		return true
+	}
+	booked := resources.NewResource()
+	for _, booking := range sn.bookings {
+		if booking.conflicts(queuePath, ask.GetExpectedRuntime(), now) {
+			booked.AddTo(booking.Remaining)
+		}
+	}
+	if resources.IsZero(booked) {
+		return true
+	}
+	return resources.SubEliminateNegative(sn.availableResource, booked).FitIn(ask.GetAllocatedResource())
+}
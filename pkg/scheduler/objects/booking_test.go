@@ -0,0 +1,76 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package objects
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+)
+
+func TestBookingConflicts(t *testing.T) {
+	now := time.Now()
+	booking := &Booking{
+		ID:        "ar-1",
+		QueuePath: "root.booked",
+		Start:     now.Add(time.Hour),
+		End:       now.Add(2 * time.Hour),
+	}
+	assert.Assert(t, booking.InBookingQueue("root.booked"), "booking queue itself")
+	assert.Assert(t, booking.InBookingQueue("root.booked.child"), "child of booking queue")
+	assert.Assert(t, !booking.InBookingQueue("root.bookedother"), "queue with same prefix")
+
+	assert.Assert(t, !booking.conflicts("root.booked.child", 0, now), "booking queue never conflicts")
+	assert.Assert(t, booking.conflicts("root.other", 0, now), "unknown runtime must conflict")
+	assert.Assert(t, !booking.conflicts("root.other", 30*time.Minute, now), "short runtime ends before the window")
+	assert.Assert(t, booking.conflicts("root.other", 90*time.Minute, now), "long runtime runs into the window")
+	assert.Assert(t, booking.conflicts("root.other", 0, now.Add(90*time.Minute)), "inside the window")
+	assert.Assert(t, !booking.conflicts("root.other", 0, now.Add(2*time.Hour)), "window has ended")
+}
+
+func TestNodePreBookingCheck(t *testing.T) {
+	now := time.Now()
+	node := newNode(nodeID1, map[string]resources.Quantity{"first": 10})
+	ask := newAllocationAsk(aKey, appID1, resources.NewResourceFromMap(map[string]resources.Quantity{"first": 5}))
+	assert.Assert(t, node.preBookingCheck(ask, "root.other", now), "no bookings on the node")
+
+	node.SetBooking(&Booking{
+		ID:        "ar-1",
+		QueuePath: "root.booked",
+		Resource:  resources.NewResourceFromMap(map[string]resources.Quantity{"first": 8}),
+		Remaining: resources.NewResourceFromMap(map[string]resources.Quantity{"first": 8}),
+		Start:     now.Add(time.Hour),
+		End:       now.Add(2 * time.Hour),
+	})
+	assert.Assert(t, node.GetBooking("ar-1") != nil, "booking not set")
+	assert.Assert(t, !node.preBookingCheck(ask, "root.other", now), "ask should not fit next to the booking")
+	assert.Assert(t, node.preBookingCheck(ask, "root.booked", now), "booking queue can use the booked capacity")
+	short := newAllocationRuntime(aKey, appID1, "", resources.NewResourceFromMap(map[string]resources.Quantity{"first": 5}), "30m")
+	assert.Assert(t, node.preBookingCheck(short, "root.other", now), "short ask ends before the window")
+
+	// booking queue used part of the booking
+	node.SetBookingRemaining("ar-1", resources.NewResourceFromMap(map[string]resources.Quantity{"first": 5}))
+	assert.Assert(t, node.preBookingCheck(ask, "root.other", now), "ask should fit next to the remaining booking")
+
+	node.RemoveBooking("ar-1")
+	assert.Assert(t, node.GetBooking("ar-1") == nil, "booking not removed")
+}
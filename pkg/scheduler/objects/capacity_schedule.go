@@ -0,0 +1,79 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package objects
+
+import (
+	"time"
+
+	"github.com/apache/yunikorn-core/pkg/common"
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+)
+
+// capacitySchedule is a time window in which the queue resources and max applications differ from the config.
+type capacitySchedule struct {
+	name      string
+	start     *common.CronSchedule
+	duration  time.Duration
+	resources configs.Resources // queue resources merged with the schedule resources
+	maxApps   uint64            // zero if the queue value is used
+}
+
+// newCapacitySchedules converts the schedules from the queue config.
+// The config has been validated, an error should not happen.
+func newCapacitySchedules(conf configs.QueueConfig) ([]*capacitySchedule, error) {
+	if len(conf.Schedules) == 0 {
+		return nil, nil
+	}
+	schedules := make([]*capacitySchedule, 0, len(conf.Schedules))
+	for _, schedule := range conf.Schedules {
+		start, err := common.ParseCron(schedule.Start)
+		if err != nil {
+			return nil, err
+		}
+		var duration time.Duration
+		duration, err = configs.ParseScheduleDuration(schedule.Duration)
+		if err != nil {
+			return nil, err
+		}
+		schedules = append(schedules, &capacitySchedule{
+			name:      schedule.Name,
+			start:     start,
+			duration:  duration,
+			resources: configs.MergeScheduleResources(conf.Resources, schedule.Resources),
+			maxApps:   schedule.MaxApplications,
+		})
+	}
+	return schedules, nil
+}
+
+// isActive returns true if the time is inside a window of the schedule.
+func (cs *capacitySchedule) isActive(now time.Time) bool {
+	_, active := cs.start.ActiveWindow(now, cs.duration)
+	return active
+}
+
+// findActiveSchedule returns the first schedule that is active at the time, nil if none are active.
+func findActiveSchedule(schedules []*capacitySchedule, now time.Time) *capacitySchedule {
+	for _, schedule := range schedules {
+		if schedule.isActive(now) {
+			return schedule
+		}
+	}
+	return nil
+}
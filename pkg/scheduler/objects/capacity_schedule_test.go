@@ -0,0 +1,127 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package objects
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+)
+
+func TestQueueCapacitySchedule(t *testing.T) {
+	root, err := createRootQueue(nil)
+	assert.NilError(t, err, "failed to create basic root queue")
+	conf := configs.QueueConfig{
+		Name:            "batch",
+		Resources:       configs.Resources{Max: map[string]string{"first": "20"}},
+		MaxApplications: 5,
+		Schedules: []configs.Schedule{
+			{
+				Name:            "night",
+				Start:           "0 20 * * *",
+				Duration:        "10h",
+				Resources:       configs.Resources{Max: map[string]string{"first": "100"}},
+				MaxApplications: 50,
+			},
+			{
+				Name:      "weekend",
+				Start:     "0 0 * * 6",
+				Duration:  "48h",
+				Resources: configs.Resources{Guaranteed: map[string]string{"first": "10"}},
+			},
+		},
+	}
+	var leaf *Queue
+	leaf, err = NewConfiguredQueue(conf, root, false)
+	assert.NilError(t, err, "failed to create queue with schedules")
+	assert.Equal(t, len(leaf.schedules), 2)
+	first := func(v int64) *resources.Resource {
+		return resources.NewResourceFromMap(map[string]resources.Quantity{"first": resources.Quantity(v)})
+	}
+
+	// 2024-01-01 is a Monday
+	monday := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	leaf.UpdateCapacitySchedule(monday)
+	assert.Equal(t, leaf.GetActiveSchedule(), "")
+	assert.Assert(t, resources.Equals(leaf.GetMaxResource(), first(20)), "config max expected")
+	assert.Assert(t, leaf.GetGuaranteedResource() == nil, "no guaranteed expected")
+	assert.Equal(t, leaf.GetMaxApps(), uint64(5))
+
+	night := monday.Add(9 * time.Hour)
+	leaf.UpdateCapacitySchedule(night)
+	assert.Equal(t, leaf.GetActiveSchedule(), "night")
+	assert.Assert(t, resources.Equals(leaf.GetMaxResource(), first(100)), "schedule max expected")
+	assert.Equal(t, leaf.GetMaxApps(), uint64(50))
+	dao := leaf.GetPartitionQueueDAOInfo(false)
+	assert.Equal(t, dao.ActiveSchedule, "night")
+	assert.Equal(t, dao.ScheduleTransitionTime, night.UnixNano())
+	assert.DeepEqual(t, dao.Schedules, []string{"night", "weekend"})
+
+	// no change: transition time is not updated
+	leaf.UpdateCapacitySchedule(night.Add(time.Hour))
+	assert.Equal(t, leaf.GetPartitionQueueDAOInfo(false).ScheduleTransitionTime, night.UnixNano())
+
+	// saturday: weekend schedule only changes the guaranteed resource
+	saturday := monday.AddDate(0, 0, 5)
+	leaf.UpdateCapacitySchedule(saturday)
+	assert.Equal(t, leaf.GetActiveSchedule(), "weekend")
+	assert.Assert(t, resources.Equals(leaf.GetMaxResource(), first(20)), "config max expected")
+	assert.Assert(t, resources.Equals(leaf.GetGuaranteedResource(), first(10)), "schedule guaranteed expected")
+	assert.Equal(t, leaf.GetMaxApps(), uint64(5))
+
+	// both active: first schedule in the list wins
+	leaf.UpdateCapacitySchedule(saturday.Add(9 * time.Hour))
+	assert.Equal(t, leaf.GetActiveSchedule(), "night")
+	assert.Assert(t, resources.Equals(leaf.GetMaxResource(), first(100)), "schedule max expected")
+
+	// back to the config
+	leaf.UpdateCapacitySchedule(monday.AddDate(0, 0, 7))
+	assert.Equal(t, leaf.GetActiveSchedule(), "")
+	assert.Assert(t, resources.Equals(leaf.GetMaxResource(), first(20)), "config max expected")
+	assert.Assert(t, leaf.GetGuaranteedResource() == nil, "no guaranteed expected")
+	assert.Equal(t, leaf.GetMaxApps(), uint64(5))
+
+	// config update removes the schedules
+	conf.Schedules = nil
+	err = leaf.ApplyConf(conf)
+	assert.NilError(t, err, "failed to update queue config")
+	leaf.UpdateCapacitySchedule(night)
+	assert.Equal(t, leaf.GetActiveSchedule(), "")
+	assert.Assert(t, resources.Equals(leaf.GetMaxResource(), first(20)), "config max expected")
+}
+
+func TestQueueCapacityScheduleAlwaysActive(t *testing.T) {
+	root, err := createRootQueue(nil)
+	assert.NilError(t, err, "failed to create basic root queue")
+	conf := configs.QueueConfig{
+		Name:            "always",
+		MaxApplications: 5,
+		Schedules:       []configs.Schedule{{Name: "always", Start: "* * * * *", Duration: "1m", MaxApplications: 10}},
+	}
+	var leaf *Queue
+	leaf, err = NewConfiguredQueue(conf, root, false)
+	assert.NilError(t, err, "failed to create queue with schedules")
+	// the active schedule is applied when the queue is created
+	assert.Equal(t, leaf.GetActiveSchedule(), "always")
+	assert.Equal(t, leaf.GetMaxApps(), uint64(10))
+}
@@ -143,6 +143,42 @@ func (ae *ApplicationEvents) SendAppRunnableQuotaEvent(appID string) {
 	ae.eventSystem.AddEvent(event)
 }
 
+func (ae *ApplicationEvents) SendQueueMovedEvent(appID, fromQueue, toQueue, reason string) {
+	if !ae.eventSystem.IsEventTrackingEnabled() {
+		return
+	}
+	message := fmt.Sprintf("moved from queue %s to queue %s", fromQueue, toQueue)
+	if reason != "" {
+		message += ", " + reason
+	}
+	event := events.CreateAppEventRecord(appID, message, common.Empty, si.EventRecord_SET, si.EventRecord_DETAILS_NONE, nil)
+	ae.eventSystem.AddEvent(event)
+}
+
+func (ae *ApplicationEvents) SendPriorityChangedEvent(appID string, priority int32, reason string) {
+	if !ae.eventSystem.IsEventTrackingEnabled() {
+		return
+	}
+	message := fmt.Sprintf("priority: %d", priority)
+	if reason != "" {
+		message += ", " + reason
+	}
+	event := events.CreateAppEventRecord(appID, message, common.Empty, si.EventRecord_SET, si.EventRecord_DETAILS_NONE, nil)
+	ae.eventSystem.AddEvent(event)
+}
+
+func (ae *ApplicationEvents) SendForceReleaseEvent(appID, allocKey string, allocated *resources.Resource, reason string) {
+	if !ae.eventSystem.IsEventTrackingEnabled() {
+		return
+	}
+	message := "allocation force released"
+	if reason != "" {
+		message += ", " + reason
+	}
+	event := events.CreateAppEventRecord(appID, message, allocKey, si.EventRecord_NONE, si.EventRecord_ALLOC_CANCEL, allocated)
+	ae.eventSystem.AddEvent(event)
+}
+
 func NewApplicationEvents(es events.EventSystem) *ApplicationEvents {
 	return &ApplicationEvents{
 		eventSystem: es,
@@ -337,3 +337,32 @@ func TestSendAppRunnableByQuotaEvent(t *testing.T) {
 	assert.Equal(t, "", event.ReferenceID)
 	assert.Equal(t, "", event.Message)
 }
+
+func TestSendAdminActionEvents(t *testing.T) {
+	eventSystem := mock.NewEventSystemDisabled()
+	appEvents := NewApplicationEvents(eventSystem)
+	appEvents.SendQueueMovedEvent(appID, "root.a", "root.b", "requested by admin")
+	appEvents.SendPriorityChangedEvent(appID, 10, "requested by admin")
+	appEvents.SendForceReleaseEvent(appID, allocKey, resources.NewResource(), "requested by admin")
+	assert.Equal(t, 0, len(eventSystem.Events), "unexpected event")
+
+	eventSystem = mock.NewEventSystem()
+	appEvents = NewApplicationEvents(eventSystem)
+	appEvents.SendQueueMovedEvent(appID, "root.a", "root.b", "requested by admin")
+	appEvents.SendPriorityChangedEvent(appID, 10, "")
+	appEvents.SendForceReleaseEvent(appID, allocKey, resources.NewResource(), "requested by admin")
+	assert.Equal(t, 3, len(eventSystem.Events), "events were not generated")
+	event := eventSystem.Events[0]
+	assert.Equal(t, si.EventRecord_APP, event.Type)
+	assert.Equal(t, si.EventRecord_SET, event.EventChangeType)
+	assert.Equal(t, appID, event.ObjectID)
+	assert.Equal(t, "moved from queue root.a to queue root.b, requested by admin", event.Message)
+	event = eventSystem.Events[1]
+	assert.Equal(t, si.EventRecord_SET, event.EventChangeType)
+	assert.Equal(t, "priority: 10", event.Message)
+	event = eventSystem.Events[2]
+	assert.Equal(t, si.EventRecord_NONE, event.EventChangeType)
+	assert.Equal(t, si.EventRecord_ALLOC_CANCEL, event.EventChangeDetail)
+	assert.Equal(t, allocKey, event.ReferenceID)
+	assert.Equal(t, "allocation force released, requested by admin", event.Message)
+}
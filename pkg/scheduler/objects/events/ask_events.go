@@ -35,6 +35,7 @@ type AskEvents struct {
 	eventSystem      events.EventSystem
 	predicateLimiter *rate.Limiter
 	reqNodeLimiter   *rate.Limiter
+	allocLimiter     *rate.Limiter
 }
 
 func (ae *AskEvents) SendRequestExceedsQueueHeadroom(allocKey, appID string, headroom, allocatedResource *resources.Resource, queuePath string) {
@@ -73,6 +74,33 @@ func (ae *AskEvents) SendRequestFitsInUserQuota(allocKey, appID string, allocate
 	ae.eventSystem.AddEvent(event)
 }
 
+func (ae *AskEvents) SendRequestExceedsUserLimit(allocKey, appID, reason string, allocatedResource *resources.Resource) {
+	if !ae.eventSystem.IsEventTrackingEnabled() {
+		return
+	}
+	message := fmt.Sprintf("Request '%s' rejected: %s", allocKey, reason)
+	event := events.CreateRequestEventRecord(allocKey, appID, message, allocatedResource)
+	ae.eventSystem.AddEvent(event)
+}
+
+func (ae *AskEvents) SendRequestExceedsAllocationLimit(allocKey, appID, reason string, allocatedResource *resources.Resource) {
+	if !ae.eventSystem.IsEventTrackingEnabled() || !ae.allocLimiter.Allow() {
+		return
+	}
+	message := fmt.Sprintf("Request '%s' cannot be allocated: %s", allocKey, reason)
+	event := events.CreateRequestEventRecord(allocKey, appID, message, allocatedResource)
+	ae.eventSystem.AddEvent(event)
+}
+
+func (ae *AskEvents) SendInvalidRequestTags(allocKey, appID, reason string, allocatedResource *resources.Resource) {
+	if !ae.eventSystem.IsEventTrackingEnabled() {
+		return
+	}
+	message := fmt.Sprintf("Request '%s' rejected, invalid allocation tags: %s", allocKey, reason)
+	event := events.CreateRequestEventRecord(allocKey, appID, message, allocatedResource)
+	ae.eventSystem.AddEvent(event)
+}
+
 func (ae *AskEvents) SendPredicatesFailed(allocKey, appID string, predicateErrors map[string]int, allocatedResource *resources.Resource) {
 	if !ae.eventSystem.IsEventTrackingEnabled() || !ae.predicateLimiter.Allow() {
 		return
@@ -123,5 +151,6 @@ func newAskEventsWithRate(evt events.EventSystem, interval time.Duration, burst
 		eventSystem:      evt,
 		predicateLimiter: rate.NewLimiter(rate.Every(interval), burst),
 		reqNodeLimiter:   rate.NewLimiter(rate.Every(interval), burst),
+		allocLimiter:     rate.NewLimiter(rate.Every(interval), burst),
 	}
 }
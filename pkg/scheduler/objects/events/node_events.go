@@ -80,6 +80,19 @@ func (n *NodeEvents) SendNodeSchedulableChangedEvent(nodeID string, ready bool)
 	n.eventSystem.AddEvent(event)
 }
 
+func (n *NodeEvents) SendNodeStateChangedEvent(nodeID, state, reason string) {
+	if !n.eventSystem.IsEventTrackingEnabled() {
+		return
+	}
+	message := "state: " + state
+	if reason != "" {
+		message += ", " + reason
+	}
+	event := events.CreateNodeEventRecord(nodeID, message, common.Empty, si.EventRecord_SET,
+		si.EventRecord_NODE_SCHEDULABLE, nil)
+	n.eventSystem.AddEvent(event)
+}
+
 func (n *NodeEvents) SendNodeCapacityChangedEvent(nodeID string, total *resources.Resource) {
 	if !n.eventSystem.IsEventTrackingEnabled() {
 		return
@@ -191,6 +191,29 @@ func TestNodeSchedulableChangedEvent(t *testing.T) {
 	assert.Equal(t, 0, len(event.Resource.Resources))
 }
 
+func TestNodeStateChangedEvent(t *testing.T) {
+	eventSystem := mock.NewEventSystemDisabled()
+	ne := NewNodeEvents(eventSystem)
+	ne.SendNodeStateChangedEvent(nodeID1, "Draining", "maintenance")
+	assert.Equal(t, 0, len(eventSystem.Events), "unexpected event")
+
+	eventSystem = mock.NewEventSystem()
+	ne = NewNodeEvents(eventSystem)
+	ne.SendNodeStateChangedEvent(nodeID1, "Draining", "maintenance")
+	assert.Equal(t, 1, len(eventSystem.Events), "event was not generated")
+	event := eventSystem.Events[0]
+	assert.Equal(t, nodeID1, event.ObjectID)
+	assert.Equal(t, common.Empty, event.ReferenceID)
+	assert.Equal(t, "state: Draining, maintenance", event.Message)
+	assert.Equal(t, si.EventRecord_SET, event.EventChangeType)
+	assert.Equal(t, si.EventRecord_NODE_SCHEDULABLE, event.EventChangeDetail)
+
+	eventSystem.Reset()
+	ne.SendNodeStateChangedEvent(nodeID1, "Schedulable", "")
+	assert.Equal(t, 1, len(eventSystem.Events), "event was not generated")
+	assert.Equal(t, "state: Schedulable", eventSystem.Events[0].Message)
+}
+
 func TestNodeReservationEvent(t *testing.T) {
 	resource := resources.NewResourceFromMap(map[string]resources.Quantity{"first": 1})
 	eventSystem := mock.NewEventSystemDisabled()
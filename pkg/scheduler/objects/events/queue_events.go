@@ -104,6 +104,32 @@ func (q *QueueEvents) SendTypeChangedEvent(queuePath string, isLeaf bool) {
 	q.eventSystem.AddEvent(event)
 }
 
+func (q *QueueEvents) SendCapacityScheduleEvent(queuePath, schedule string) {
+	if !q.eventSystem.IsEventTrackingEnabled() {
+		return
+	}
+	message := "capacity schedule: " + schedule
+	if schedule == "" {
+		message = "capacity schedule: none"
+	}
+	event := events.CreateQueueEventRecord(queuePath, message, common.Empty, si.EventRecord_SET,
+		si.EventRecord_QUEUE_CONFIG, nil)
+	q.eventSystem.AddEvent(event)
+}
+
+func (q *QueueEvents) SendStateChangedEvent(queuePath, state, reason string) {
+	if !q.eventSystem.IsEventTrackingEnabled() {
+		return
+	}
+	message := "state: " + state
+	if reason != "" {
+		message += ", " + reason
+	}
+	event := events.CreateQueueEventRecord(queuePath, message, common.Empty, si.EventRecord_SET,
+		si.EventRecord_QUEUE_CONFIG, nil)
+	q.eventSystem.AddEvent(event)
+}
+
 func NewQueueEvents(evt events.EventSystem) *QueueEvents {
 	return &QueueEvents{
 		eventSystem: evt,
@@ -201,3 +201,42 @@ func TestSendGuaranteedResourceChangedEvent(t *testing.T) {
 	protoRes := resources.NewResourceFromProto(event.Resource)
 	assert.DeepEqual(t, guaranteed, protoRes)
 }
+
+func TestSendCapacityScheduleEvent(t *testing.T) {
+	eventSystem := mock.NewEventSystemDisabled()
+	nq := NewQueueEvents(eventSystem)
+	nq.SendCapacityScheduleEvent(testQueuePath, "night")
+	assert.Equal(t, 0, len(eventSystem.Events), "unexpected event")
+
+	eventSystem = mock.NewEventSystem()
+	nq = NewQueueEvents(eventSystem)
+	nq.SendCapacityScheduleEvent(testQueuePath, "night")
+	nq.SendCapacityScheduleEvent(testQueuePath, "")
+	assert.Equal(t, 2, len(eventSystem.Events), "events were not generated")
+	event := eventSystem.Events[0]
+	assert.Equal(t, si.EventRecord_QUEUE, event.Type)
+	assert.Equal(t, testQueuePath, event.ObjectID)
+	assert.Equal(t, common.Empty, event.ReferenceID)
+	assert.Equal(t, "capacity schedule: night", event.Message)
+	assert.Equal(t, si.EventRecord_SET, event.EventChangeType)
+	assert.Equal(t, si.EventRecord_QUEUE_CONFIG, event.EventChangeDetail)
+	assert.Equal(t, "capacity schedule: none", eventSystem.Events[1].Message)
+}
+
+func TestSendStateChangedEvent(t *testing.T) {
+	eventSystem := mock.NewEventSystemDisabled()
+	nq := NewQueueEvents(eventSystem)
+	nq.SendStateChangedEvent(testQueuePath, "Stopped", "requested by admin")
+	assert.Equal(t, 0, len(eventSystem.Events), "unexpected event")
+
+	eventSystem = mock.NewEventSystem()
+	nq = NewQueueEvents(eventSystem)
+	nq.SendStateChangedEvent(testQueuePath, "Stopped", "requested by admin")
+	assert.Equal(t, 1, len(eventSystem.Events), "event was not generated")
+	event := eventSystem.Events[0]
+	assert.Equal(t, si.EventRecord_QUEUE, event.Type)
+	assert.Equal(t, testQueuePath, event.ObjectID)
+	assert.Equal(t, "state: Stopped, requested by admin", event.Message)
+	assert.Equal(t, si.EventRecord_SET, event.EventChangeType)
+	assert.Equal(t, si.EventRecord_QUEUE_CONFIG, event.EventChangeDetail)
+}
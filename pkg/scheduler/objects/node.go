@@ -20,7 +20,9 @@ package objects
 
 import (
 	"fmt"
+	"time"
 
+	"github.com/looplab/fsm"
 	"go.uber.org/zap"
 
 	"github.com/apache/yunikorn-core/pkg/common"
@@ -54,8 +56,13 @@ type Node struct {
 	availableResource *resources.Resource
 	allocations       map[string]*Allocation
 	schedulable       bool
+	stateMachine      *fsm.FSM  // node state: only a schedulable node is used in scheduling
+	drainDeadline     time.Time // allocations left on a draining node are released after the deadline
 
 	reservations map[string]*reservation // a map of reservations
+	bookings     map[string]*Booking     // advance reservations pinned to the node
+	zone         string                  // zone of the node in the partition topology
+	rack         string                  // rack of the node in the partition topology
 	listeners    []NodeListener          // a list of node listeners
 	nodeEvents   *schedEvt.NodeEvents
 
@@ -76,6 +83,7 @@ func NewNode(proto *si.NodeInfo) *Node {
 		occupiedResource:  resources.NewResource(),
 		allocations:       make(map[string]*Allocation),
 		schedulable:       true,
+		stateMachine:      NewNodeState(),
 		listeners:         make([]NodeListener, 0),
 	}
 	sn.nodeEvents = schedEvt.NewNodeEvents(events.GetEventSystem())
@@ -117,15 +125,52 @@ func (sn *Node) initializeAttribute(newAttributes map[string]string) {
 
 // Get an attribute by name. The most used attributes can be directly accessed via the
 // fields: HostName, RackName and Partition.
-// This is a lock free call. All attributes are considered read only
 func (sn *Node) GetAttribute(key string) string {
+	sn.RLock()
+	defer sn.RUnlock()
 	return sn.attributes[key]
 }
 
+// Get the attributes of the node. The returned map must be treated as read only, an update of the attributes
+// replaces the map.
 func (sn *Node) GetAttributes() map[string]string {
+	sn.RLock()
+	defer sn.RUnlock()
 	return sn.attributes
 }
 
+// UpdateAttributes merges the attributes into the attributes of the node and notifies the listeners if an attribute
+// changed. The fast access fields HostName, RackName and Partition are set on create and are not updated.
+func (sn *Node) UpdateAttributes(attributes map[string]string) {
+	if sn.mergeAttributes(attributes) {
+		sn.notifyListeners()
+	}
+}
+
+func (sn *Node) mergeAttributes(attributes map[string]string) bool {
+	sn.Lock()
+	defer sn.Unlock()
+	changed := false
+	for key, value := range attributes {
+		if current, ok := sn.attributes[key]; !ok || current != value {
+			changed = true
+			break
+		}
+	}
+	if !changed {
+		return false
+	}
+	merged := make(map[string]string, len(sn.attributes)+len(attributes))
+	for key, value := range sn.attributes {
+		merged[key] = value
+	}
+	for key, value := range attributes {
+		merged[key] = value
+	}
+	sn.attributes = merged
+	return true
+}
+
 // Get InstanceType of this node.
 // This is a lock free call because all attributes are considered read only
 func (sn *Node) GetInstanceType() string {
@@ -257,12 +302,20 @@ func (sn *Node) getAllocations(foreign bool) []*Allocation {
 
 // Set the node to unschedulable.
 // This will cause the node to be skipped during the scheduling cycle.
-// Visible for testing only
+// The state is set directly: an unschedulable node is cordoned, unless it is already draining or drained.
+// Use HandleNodeEvent to follow the node lifecycle.
 func (sn *Node) SetSchedulable(schedulable bool) {
 	defer sn.notifyListeners()
 	sn.Lock()
 	defer sn.Unlock()
 	sn.schedulable = schedulable
+	if sn.stateMachine != nil {
+		if schedulable {
+			sn.stateMachine.SetState(NodeSchedulable.String())
+		} else if sn.stateMachine.Current() == NodeSchedulable.String() {
+			sn.stateMachine.SetState(NodeCordoned.String())
+		}
+	}
 	sn.nodeEvents.SendNodeSchedulableChangedEvent(sn.NodeID, sn.schedulable)
 }
 
@@ -544,6 +597,48 @@ func (sn *Node) preAllocateCheck(res *resources.Resource, allocationKey string)
 	return sn.availableResource.FitIn(res)
 }
 
+// getReservationReadyTime estimates the earliest time one of the reservations on the node can be allocated.
+// Returns false if the node is not reserved or the time cannot be estimated for any of the reservations.
+func (sn *Node) getReservationReadyTime(now time.Time) (time.Time, bool) {
+	sn.RLock()
+	defer sn.RUnlock()
+	if len(sn.reservations) == 0 {
+		return time.Time{}, false
+	}
+	allocations := make([]*Allocation, 0, len(sn.allocations))
+	for _, alloc := range sn.allocations {
+		allocations = append(allocations, alloc)
+	}
+	var earliest time.Time
+	for _, r := range sn.reservations {
+		ready, ok := estimateReadyTime(sn.availableResource, allocations, r.alloc.GetAllocatedResource(), now)
+		if !ok {
+			return time.Time{}, false
+		}
+		if earliest.IsZero() || ready.Before(earliest) {
+			earliest = ready
+		}
+	}
+	return earliest, true
+}
+
+// preBackfillCheck checks if the ask can use the free resources of a reserved node without delaying the
+// reservations: the ask must have an expected runtime that ends before the earliest reservation is expected to be
+// allocatable.
+func (sn *Node) preBackfillCheck(ask *Allocation, now time.Time) bool {
+	runtime := ask.GetExpectedRuntime()
+	if runtime <= 0 || !resources.StrictlyGreaterThanZero(ask.GetAllocatedResource()) {
+		return false
+	}
+	ready, ok := sn.getReservationReadyTime(now)
+	if !ok || now.Add(runtime).After(ready) {
+		return false
+	}
+	sn.RLock()
+	defer sn.RUnlock()
+	return sn.availableResource.FitIn(ask.GetAllocatedResource())
+}
+
 // IsReserved returns true if the node has been reserved for an allocation
 func (sn *Node) IsReserved() bool {
 	sn.RLock()
@@ -567,6 +662,7 @@ func (sn *Node) isReservedForAllocation(key string) bool {
 // The reservation is checked against the node resources.
 // If the reservation fails the function returns an error, if the reservation is made it returns nil.
 func (sn *Node) Reserve(app *Application, ask *Allocation) error {
+	defer sn.notifyListeners()
 	sn.Lock()
 	defer sn.Unlock()
 	appReservation := newReservation(sn, app, ask, false)
@@ -623,6 +719,7 @@ func (sn *Node) unReserve(alloc *Allocation) int {
 	if alloc == nil {
 		return 0
 	}
+	defer sn.notifyListeners()
 	sn.Lock()
 	defer sn.Unlock()
 	if _, ok := sn.reservations[alloc.allocationKey]; ok {
@@ -49,6 +49,8 @@ type NodeCollection interface {
 	GetFullNodeIterator() NodeIterator
 	SetNodeSortingPolicy(policy NodeSortingPolicy)
 	GetNodeSortingPolicy() NodeSortingPolicy
+	SetTopologyKeys(zoneKey, rackKey string)
+	GetTopology() map[string]map[string][]string
 }
 
 type nodeRef struct {
@@ -77,6 +79,7 @@ type baseNodeCollection struct {
 	nsp         NodeSortingPolicy   // node sorting policy
 	nodes       map[string]*nodeRef // nodes assigned to this collection
 	sortedNodes *btree.BTree        // nodes sorted by score
+	topology    *topology           // nodes by zone and rack
 
 	unreservedIterator *treeIterator
 	fullIterator       *treeIterator
@@ -110,6 +113,7 @@ func (nc *baseNodeCollection) AddNode(node *Node) error {
 	}
 	nc.nodes[node.NodeID] = &nref
 	nc.sortedNodes.ReplaceOrInsert(nref)
+	nc.topology.addNode(node)
 	return nil
 }
 
@@ -128,6 +132,7 @@ func (nc *baseNodeCollection) RemoveNode(nodeID string) *Node {
 	// Remove node from list of tracked nodes
 	nc.sortedNodes.Delete(*nref)
 	delete(nc.nodes, nodeID)
+	nc.topology.removeNode(nref.node)
 	nref.node.RemoveListener(nc)
 
 	return nref.node
@@ -203,6 +208,23 @@ func (nc *baseNodeCollection) GetNodeSortingPolicy() NodeSortingPolicy {
 	return nc.nsp
 }
 
+// Sets the node attributes that define the zone and rack of a node and rebuilds the topology.
+func (nc *baseNodeCollection) SetTopologyKeys(zoneKey, rackKey string) {
+	nc.Lock()
+	defer nc.Unlock()
+	nc.topology = newTopology(zoneKey, rackKey)
+	for _, nref := range nc.nodes {
+		nc.topology.addNode(nref.node)
+	}
+}
+
+// Gets the topology of the collection: zone -> rack -> sorted node IDs.
+func (nc *baseNodeCollection) GetTopology() map[string]map[string][]string {
+	nc.RLock()
+	defer nc.RUnlock()
+	return nc.topology.domains()
+}
+
 // Callback method triggered when a node is updated.
 func (nc *baseNodeCollection) NodeUpdated(node *Node) {
 	nc.Lock()
@@ -213,6 +235,7 @@ func (nc *baseNodeCollection) NodeUpdated(node *Node) {
 		return
 	}
 
+	nc.topology.updateNode(node)
 	updatedScore := nc.scoreNode(node)
 	if nref.nodeScore != updatedScore {
 		nc.sortedNodes.Delete(*nref)
@@ -228,6 +251,7 @@ func NewNodeCollection(partition string) NodeCollection {
 		nsp:         NewNodeSortingPolicy(policies.FairSortPolicy.String(), nil),
 		nodes:       make(map[string]*nodeRef),
 		sortedNodes: btree.New(7), // Degree=7 here is experimentally the most efficient for up to around 5k nodes
+		topology:    newTopology("", ""),
 	}
 
 	unreservedIterator := NewTreeIterator(acceptUnreserved, bsc.cloneSortedNodes)
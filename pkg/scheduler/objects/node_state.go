@@ -0,0 +1,170 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package objects
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/looplab/fsm"
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-core/pkg/log"
+	"github.com/apache/yunikorn-core/pkg/metrics"
+)
+
+// ----------------------------------
+// node events
+// ----------------------------------
+type NodeEvent int
+
+const (
+	CordonNode NodeEvent = iota
+	UncordonNode
+	DrainNode
+	CompleteDrain
+	DecommissionNode
+)
+
+func (ne NodeEvent) String() string {
+	return [...]string{"cordonNode", "uncordonNode", "drainNode", "completeDrain", "decommissionNode"}[ne]
+}
+
+// NodeEventFromString converts the name of a node event, as used in the REST API, into the event.
+func NodeEventFromString(name string) (NodeEvent, error) {
+	for _, event := range []NodeEvent{CordonNode, UncordonNode, DrainNode, CompleteDrain, DecommissionNode} {
+		if event.String() == name {
+			return event, nil
+		}
+	}
+	return CordonNode, fmt.Errorf("unknown node event: %s", name)
+}
+
+// ----------------------------------
+// node states
+// ----------------------------------
+type NodeState int
+
+const (
+	NodeSchedulable NodeState = iota
+	NodeCordoned
+	NodeDraining
+	NodeDrained
+	NodeDecommissioned
+)
+
+func (ns NodeState) String() string {
+	return [...]string{"Schedulable", "Cordoned", "Draining", "Drained", "Decommissioned"}[ns]
+}
+
+// NewNodeState creates the state machine for a node. Only a schedulable node is considered during scheduling.
+// A draining node completes the drain when all allocations are removed.
+//
+// The first argument of an event must always be the Node, the second a string with the reason of the change.
+func NewNodeState() *fsm.FSM {
+	return fsm.NewFSM(
+		NodeSchedulable.String(), fsm.Events{
+			{
+				Name: CordonNode.String(),
+				Src:  []string{NodeSchedulable.String()},
+				Dst:  NodeCordoned.String(),
+			}, {
+				Name: UncordonNode.String(),
+				Src:  []string{NodeCordoned.String(), NodeDraining.String(), NodeDrained.String()},
+				Dst:  NodeSchedulable.String(),
+			}, {
+				Name: DrainNode.String(),
+				Src:  []string{NodeSchedulable.String(), NodeCordoned.String()},
+				Dst:  NodeDraining.String(),
+			}, {
+				Name: CompleteDrain.String(),
+				Src:  []string{NodeDraining.String()},
+				Dst:  NodeDrained.String(),
+			}, {
+				Name: DecommissionNode.String(),
+				Src:  []string{NodeSchedulable.String(), NodeCordoned.String(), NodeDraining.String(), NodeDrained.String()},
+				Dst:  NodeDecommissioned.String(),
+			},
+		},
+		fsm.Callbacks{
+			"enter_state": func(_ context.Context, event *fsm.Event) {
+				node := event.Args[0].(*Node)    //nolint:errcheck
+				reason := event.Args[1].(string) //nolint:errcheck
+				log.Log(log.SchedFSM).Info("node state transition",
+					zap.String("nodeID", node.NodeID),
+					zap.String("source", event.Src),
+					zap.String("destination", event.Dst),
+					zap.String("event", event.Event),
+					zap.String("reason", reason))
+				node.schedulable = event.Dst == NodeSchedulable.String()
+				if event.Src == NodeDraining.String() {
+					metrics.GetSchedulerMetrics().DecDrainingNodes()
+					node.drainDeadline = time.Time{}
+				}
+				if event.Dst == NodeDraining.String() {
+					metrics.GetSchedulerMetrics().IncDrainingNodes()
+				}
+				node.nodeEvents.SendNodeStateChangedEvent(node.NodeID, event.Dst, reason)
+			},
+		},
+	)
+}
+
+// HandleNodeEvent moves the node to the next state, the reason is added to the event sent.
+// Returns an error if the transition is not allowed from the current state.
+func (sn *Node) HandleNodeEvent(event NodeEvent, reason string) error {
+	defer sn.notifyListeners()
+	sn.Lock()
+	defer sn.Unlock()
+	err := sn.stateMachine.Event(context.Background(), event.String(), sn, reason)
+	var noTransitionErr fsm.NoTransitionError
+	if err != nil && !errors.As(err, &noTransitionErr) {
+		return err
+	}
+	return nil
+}
+
+// GetNodeState returns the current state of the node.
+func (sn *Node) GetNodeState() string {
+	sn.RLock()
+	defer sn.RUnlock()
+	return sn.stateMachine.Current()
+}
+
+// IsDraining returns true if the node is draining its allocations.
+func (sn *Node) IsDraining() bool {
+	return sn.GetNodeState() == NodeDraining.String()
+}
+
+// SetDrainDeadline sets the time after which the allocations left on a draining node are released.
+// A zero time means the allocations are not released by the scheduler.
+func (sn *Node) SetDrainDeadline(deadline time.Time) {
+	sn.Lock()
+	defer sn.Unlock()
+	sn.drainDeadline = deadline
+}
+
+// GetDrainDeadline returns the drain deadline, zero if not set.
+func (sn *Node) GetDrainDeadline() time.Time {
+	sn.RLock()
+	defer sn.RUnlock()
+	return sn.drainDeadline
+}
@@ -0,0 +1,104 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package objects
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+	evtMock "github.com/apache/yunikorn-core/pkg/events/mock"
+	"github.com/apache/yunikorn-core/pkg/metrics"
+	schedEvt "github.com/apache/yunikorn-core/pkg/scheduler/objects/events"
+)
+
+func TestNodeEventFromString(t *testing.T) {
+	for _, event := range []NodeEvent{CordonNode, UncordonNode, DrainNode, CompleteDrain, DecommissionNode} {
+		parsed, err := NodeEventFromString(event.String())
+		assert.NilError(t, err, "known event not parsed")
+		assert.Equal(t, parsed, event)
+	}
+	_, err := NodeEventFromString("unknown")
+	assert.Assert(t, err != nil, "unknown event should fail")
+}
+
+func TestNodeStateTransitions(t *testing.T) {
+	metrics.GetSchedulerMetrics().Reset()
+	mockEvents := evtMock.NewEventSystem()
+	node := newNode(nodeID1, map[string]resources.Quantity{"first": 10})
+	node.nodeEvents = schedEvt.NewNodeEvents(mockEvents)
+	assert.Equal(t, node.GetNodeState(), NodeSchedulable.String())
+
+	tests := []struct {
+		event       NodeEvent
+		valid       bool
+		state       NodeState
+		schedulable bool
+		draining    int
+	}{
+		{UncordonNode, false, NodeSchedulable, true, 0},
+		{CompleteDrain, false, NodeSchedulable, true, 0},
+		{CordonNode, true, NodeCordoned, false, 0},
+		{CordonNode, false, NodeCordoned, false, 0},
+		{DrainNode, true, NodeDraining, false, 1},
+		{UncordonNode, true, NodeSchedulable, true, 0},
+		{DrainNode, true, NodeDraining, false, 1},
+		{CompleteDrain, true, NodeDrained, false, 0},
+		{DrainNode, false, NodeDrained, false, 0},
+		{DecommissionNode, true, NodeDecommissioned, false, 0},
+		{UncordonNode, false, NodeDecommissioned, false, 0},
+	}
+	for _, tt := range tests {
+		mockEvents.Reset()
+		err := node.HandleNodeEvent(tt.event, "test")
+		if tt.valid {
+			assert.NilError(t, err, "%s should be allowed", tt.event)
+			assert.Equal(t, len(mockEvents.Events), 1, "%s should send an event", tt.event)
+			assert.Equal(t, mockEvents.Events[0].Message, "state: "+tt.state.String()+", test")
+		} else {
+			assert.Assert(t, err != nil, "%s should not be allowed", tt.event)
+			assert.Equal(t, len(mockEvents.Events), 0, "%s should not send an event", tt.event)
+		}
+		assert.Equal(t, node.GetNodeState(), tt.state.String(), "unexpected state after %s", tt.event)
+		assert.Equal(t, node.IsSchedulable(), tt.schedulable, "unexpected schedulable flag after %s", tt.event)
+		draining, err := metrics.GetSchedulerMetrics().GetDrainingNodes()
+		assert.NilError(t, err, "failed to get draining nodes")
+		assert.Equal(t, draining, tt.draining, "unexpected draining nodes after %s", tt.event)
+	}
+}
+
+func TestNodeSetSchedulableState(t *testing.T) {
+	node := newNode(nodeID1, map[string]resources.Quantity{"first": 10})
+	node.SetSchedulable(false)
+	assert.Equal(t, node.GetNodeState(), NodeCordoned.String())
+	node.SetSchedulable(true)
+	assert.Equal(t, node.GetNodeState(), NodeSchedulable.String())
+
+	// draining node stays draining
+	assert.NilError(t, node.HandleNodeEvent(DrainNode, "test"))
+	node.SetDrainDeadline(time.Unix(100, 0))
+	node.SetSchedulable(false)
+	assert.Assert(t, node.IsDraining(), "node should still be draining")
+	assert.Equal(t, node.GetDrainDeadline(), time.Unix(100, 0))
+	// leaving the draining state clears the deadline
+	assert.NilError(t, node.HandleNodeEvent(UncordonNode, "test"))
+	assert.Assert(t, node.GetDrainDeadline().IsZero(), "deadline should be cleared")
+}
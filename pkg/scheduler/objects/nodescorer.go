@@ -0,0 +1,312 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package objects
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-core/pkg/locking"
+	"github.com/apache/yunikorn-core/pkg/log"
+	"github.com/apache/yunikorn-core/pkg/scheduler/policies"
+)
+
+// NodeScorer scores a node for placement independent of the ask. A higher score is preferred.
+// Scores must be in the range [0, 1]. The score is recalculated each time the node is updated.
+type NodeScorer interface {
+	Score(node *Node) float64
+}
+
+// NodeScorerFactory creates a scorer from the configured arguments. The resource weights of the node sorting policy
+// are passed in for scorers that work on the resource usage.
+type NodeScorerFactory func(args map[string]string, resourceWeights map[string]float64) (NodeScorer, error)
+
+// Built-in scorers
+const (
+	ResourceFitScorer          = "resourcefit"
+	LeastAllocatedScorer       = "leastallocated"
+	MostAllocatedScorer        = "mostallocated"
+	AttributeMatchScorer       = "attributematch"
+	InstanceTypeScorer         = "instancetype"
+	ReservationAvoidanceScorer = "reservationavoidance"
+)
+
+var scorerRegistry = struct {
+	factories map[string]NodeScorerFactory
+	locking.RWMutex
+}{
+	factories: map[string]NodeScorerFactory{
+		ResourceFitScorer:          newResourceFitScorer,
+		LeastAllocatedScorer:       newLeastAllocatedScorer,
+		MostAllocatedScorer:        newMostAllocatedScorer,
+		AttributeMatchScorer:       newAttributeMatchScorer,
+		InstanceTypeScorer:         newInstanceTypeScorer,
+		ReservationAvoidanceScorer: newReservationAvoidanceScorer,
+	},
+}
+
+func init() {
+	configs.SetNodeScorerValidator(validateNodeScorer)
+}
+
+// RegisterNodeScorer makes a scorer available to the node sorting policy configuration under the name.
+// Registering under an existing name replaces the existing scorer.
+func RegisterNodeScorer(name string, factory NodeScorerFactory) {
+	scorerRegistry.Lock()
+	defer scorerRegistry.Unlock()
+	scorerRegistry.factories[strings.ToLower(name)] = factory
+}
+
+func getNodeScorerFactory(name string) NodeScorerFactory {
+	scorerRegistry.RLock()
+	defer scorerRegistry.RUnlock()
+	return scorerRegistry.factories[strings.ToLower(name)]
+}
+
+// validateNodeScorer checks that the scorer is registered and can be created with the configured arguments.
+func validateNodeScorer(name string, args map[string]string) error {
+	factory := getNodeScorerFactory(name)
+	if factory == nil {
+		return fmt.Errorf("unknown node scorer %s", name)
+	}
+	if _, err := factory(args, defaultResourceWeights()); err != nil {
+		return fmt.Errorf("invalid arguments for node scorer %s: %w", name, err)
+	}
+	return nil
+}
+
+// resourceFitScorer prefers nodes with a balanced usage over the resource types: a node with one resource type used
+// up and others free is less likely to fit new allocations.
+type resourceFitScorer struct {
+	resourceWeights map[string]float64
+}
+
+func newResourceFitScorer(_ map[string]string, resourceWeights map[string]float64) (NodeScorer, error) {
+	return resourceFitScorer{resourceWeights: resourceWeights}, nil
+}
+
+func (s resourceFitScorer) Score(node *Node) float64 {
+	var shares []float64
+	for name, share := range node.GetResourceUsageShares() {
+		if weight, ok := s.resourceWeights[name]; !ok || weight == 0 || math.IsNaN(share) {
+			continue
+		}
+		shares = append(shares, share)
+	}
+	if len(shares) < 2 {
+		return 1
+	}
+	minShare, maxShare := shares[0], shares[0]
+	for _, share := range shares[1:] {
+		minShare = math.Min(minShare, share)
+		maxShare = math.Max(maxShare, share)
+	}
+	return 1 - (maxShare - minShare)
+}
+
+// leastAllocatedScorer prefers nodes with the lowest weighted usage, spreading the load.
+type leastAllocatedScorer struct {
+	resourceWeights map[string]float64
+}
+
+func newLeastAllocatedScorer(_ map[string]string, resourceWeights map[string]float64) (NodeScorer, error) {
+	return leastAllocatedScorer{resourceWeights: resourceWeights}, nil
+}
+
+func (s leastAllocatedScorer) Score(node *Node) float64 {
+	return 1 - absResourceUsage(node, &s.resourceWeights)
+}
+
+// mostAllocatedScorer prefers nodes with the highest weighted usage, packing the load.
+type mostAllocatedScorer struct {
+	resourceWeights map[string]float64
+}
+
+func newMostAllocatedScorer(_ map[string]string, resourceWeights map[string]float64) (NodeScorer, error) {
+	return mostAllocatedScorer{resourceWeights: resourceWeights}, nil
+}
+
+func (s mostAllocatedScorer) Score(node *Node) float64 {
+	return absResourceUsage(node, &s.resourceWeights)
+}
+
+// attributeMatchScorer prefers nodes with the attribute set to one of the values, or set at all if no values are given.
+// Arguments: "key" the attribute name, "values" a comma separated list of values.
+type attributeMatchScorer struct {
+	key    string
+	values map[string]bool
+}
+
+func newAttributeMatchScorer(args map[string]string, _ map[string]float64) (NodeScorer, error) {
+	key := args["key"]
+	if key == "" {
+		return nil, fmt.Errorf("%s scorer requires a key argument", AttributeMatchScorer)
+	}
+	values := make(map[string]bool)
+	for _, value := range strings.Split(args["values"], ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values[value] = true
+		}
+	}
+	return attributeMatchScorer{key: key, values: values}, nil
+}
+
+func (s attributeMatchScorer) Score(node *Node) float64 {
+	value, ok := node.GetAttributes()[s.key]
+	if ok && (len(s.values) == 0 || s.values[value]) {
+		return 1
+	}
+	return 0
+}
+
+// instanceTypeScorer prefers instance types in the configured order, unlisted types score lowest.
+// Arguments: "types" a comma separated list of instance types, most preferred first.
+type instanceTypeScorer struct {
+	scores map[string]float64
+}
+
+func newInstanceTypeScorer(args map[string]string, _ map[string]float64) (NodeScorer, error) {
+	var types []string
+	for _, itype := range strings.Split(args["types"], ",") {
+		if itype = strings.TrimSpace(itype); itype != "" {
+			types = append(types, itype)
+		}
+	}
+	if len(types) == 0 {
+		return nil, fmt.Errorf("%s scorer requires a types argument", InstanceTypeScorer)
+	}
+	scores := make(map[string]float64, len(types))
+	for i, itype := range types {
+		if _, ok := scores[itype]; !ok {
+			scores[itype] = float64(len(types)-i) / float64(len(types))
+		}
+	}
+	return instanceTypeScorer{scores: scores}, nil
+}
+
+func (s instanceTypeScorer) Score(node *Node) float64 {
+	return s.scores[node.GetInstanceType()]
+}
+
+// reservationAvoidanceScorer prefers nodes without reservations.
+type reservationAvoidanceScorer struct{}
+
+func newReservationAvoidanceScorer(_ map[string]string, _ map[string]float64) (NodeScorer, error) {
+	return reservationAvoidanceScorer{}, nil
+}
+
+func (reservationAvoidanceScorer) Score(node *Node) float64 {
+	if node.IsReserved() {
+		return 0
+	}
+	return 1
+}
+
+type weightedScorer struct {
+	name   string
+	weight float64
+	scorer NodeScorer
+}
+
+// scoredNodeSortingPolicy orders the nodes by the weighted average of the configured scorers. The scorers replace
+// the scoring of the policy type, the type is kept for reporting.
+type scoredNodeSortingPolicy struct {
+	policyType      policies.SortingPolicy
+	resourceWeights map[string]float64
+	scorers         []weightedScorer
+}
+
+func (p scoredNodeSortingPolicy) PolicyType() policies.SortingPolicy {
+	return p.policyType
+}
+
+func (p scoredNodeSortingPolicy) ScoreNode(node *Node) float64 {
+	totalWeight := float64(0)
+	score := float64(0)
+	for _, ws := range p.scorers {
+		s := ws.scorer.Score(node)
+		if math.IsNaN(s) {
+			continue
+		}
+		score += math.Max(0, math.Min(1, s)) * ws.weight
+		totalWeight += ws.weight
+	}
+	if totalWeight == 0 {
+		return 0
+	}
+	// the node collection sorts ascending: the best node must have the lowest score
+	return 1 - score/totalWeight
+}
+
+func (p scoredNodeSortingPolicy) ResourceWeights() map[string]float64 {
+	return cloneWeights(p.resourceWeights)
+}
+
+func (p scoredNodeSortingPolicy) Scorers() map[string]float64 {
+	weights := make(map[string]float64, len(p.scorers))
+	for _, ws := range p.scorers {
+		weights[ws.name] = ws.weight
+	}
+	return weights
+}
+
+// NewScoredNodeSortingPolicy creates a node sorting policy from the configured scorers. Falls back to the policy type
+// based sorting if none of the scorers can be created. Unknown or invalid scorers are skipped.
+func NewScoredNodeSortingPolicy(policyType string, resourceWeights map[string]float64, scorerConf []configs.NodeScorerConfig) NodeSortingPolicy {
+	base := NewNodeSortingPolicy(policyType, resourceWeights)
+	if len(scorerConf) == 0 {
+		return base
+	}
+	weights := base.ResourceWeights()
+	var scorers []weightedScorer
+	for _, conf := range scorerConf {
+		factory := getNodeScorerFactory(conf.Name)
+		if factory == nil {
+			log.Log(log.SchedNode).Warn("unknown node scorer, skipping",
+				zap.String("scorer", conf.Name))
+			continue
+		}
+		scorer, err := factory(conf.Args, weights)
+		if err != nil {
+			log.Log(log.SchedNode).Warn("node scorer creation failed, skipping",
+				zap.String("scorer", conf.Name),
+				zap.Error(err))
+			continue
+		}
+		weight := conf.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		scorers = append(scorers, weightedScorer{name: strings.ToLower(conf.Name), weight: weight, scorer: scorer})
+	}
+	if len(scorers) == 0 {
+		return base
+	}
+	log.Log(log.SchedNode).Debug("new scored node sorting policy added",
+		zap.Int("scorers", len(scorers)))
+	return scoredNodeSortingPolicy{
+		policyType:      base.PolicyType(),
+		resourceWeights: weights,
+		scorers:         scorers,
+	}
+}
@@ -0,0 +1,152 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package objects
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+	"github.com/apache/yunikorn-core/pkg/scheduler/policies"
+	siCommon "github.com/apache/yunikorn-scheduler-interface/lib/go/common"
+)
+
+type constantScorer float64
+
+func (s constantScorer) Score(_ *Node) float64 {
+	return float64(s)
+}
+
+func newScorerNode(t *testing.T, nodeID string, vcore, memory int64, attributes map[string]string) *Node {
+	node := newNode(nodeID, map[string]resources.Quantity{"vcore": 10, "memory": 10})
+	node.initializeAttribute(attributes)
+	alloc := newAllocation("alloc-"+nodeID, appID1, resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": resources.Quantity(vcore), "memory": resources.Quantity(memory)}))
+	assert.Assert(t, node.TryAddAllocation(alloc), "allocation failed on node")
+	return node
+}
+
+func TestBuiltinNodeScorers(t *testing.T) {
+	weights := defaultResourceWeights()
+	balanced := newScorerNode(t, "balanced", 5, 5, map[string]string{siCommon.InstanceType: "m5", "disk": "ssd"})
+	skewed := newScorerNode(t, "skewed", 9, 1, map[string]string{siCommon.InstanceType: "c5"})
+
+	tests := []struct {
+		name     string
+		args     map[string]string
+		balanced float64
+		skewed   float64
+	}{
+		{ResourceFitScorer, nil, 1, 0.2},
+		{LeastAllocatedScorer, nil, 0.5, 0.5},
+		{MostAllocatedScorer, nil, 0.5, 0.5},
+		{AttributeMatchScorer, map[string]string{"key": "disk"}, 1, 0},
+		{AttributeMatchScorer, map[string]string{"key": "disk", "values": "hdd, nvme"}, 0, 0},
+		{InstanceTypeScorer, map[string]string{"types": "c5,m5"}, 0.5, 1},
+		{ReservationAvoidanceScorer, nil, 1, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scorer, err := getNodeScorerFactory(tt.name)(tt.args, weights)
+			assert.NilError(t, err, "scorer creation failed")
+			assert.Assert(t, almostEqual(scorer.Score(balanced), tt.balanced), "unexpected balanced node score %f", scorer.Score(balanced))
+			assert.Assert(t, almostEqual(scorer.Score(skewed), tt.skewed), "unexpected skewed node score %f", scorer.Score(skewed))
+		})
+	}
+
+	_, err := newAttributeMatchScorer(nil, weights)
+	assert.Assert(t, err != nil, "attribute match without key should fail")
+	_, err = newInstanceTypeScorer(nil, weights)
+	assert.Assert(t, err != nil, "instance type without types should fail")
+}
+
+func TestValidateNodeScorer(t *testing.T) {
+	assert.NilError(t, validateNodeScorer("LeastAllocated", nil), "built-in scorer should be valid")
+	assert.NilError(t, validateNodeScorer(InstanceTypeScorer, map[string]string{"types": "m5"}), "scorer with args should be valid")
+	assert.ErrorContains(t, validateNodeScorer("unknown", nil), "unknown node scorer unknown")
+	assert.ErrorContains(t, validateNodeScorer(AttributeMatchScorer, nil), "invalid arguments for node scorer attributematch")
+
+	// the partition config check uses the registered scorers
+	_, err := configs.ParseAndValidateConfig([]byte(`
+partitions:
+  - name: default
+    queues:
+      - name: root
+    nodesortpolicy:
+      scorers:
+        - name: instancetype
+`))
+	assert.ErrorContains(t, err, "invalid arguments for node scorer instancetype")
+}
+
+func TestScoredNodeSortingPolicy(t *testing.T) {
+	// no or invalid scorers fall back to the type based policy
+	policy := NewScoredNodeSortingPolicy(policies.BinPackingPolicy.String(), nil, nil)
+	assert.Equal(t, policy.PolicyType(), policies.BinPackingPolicy)
+	assert.Assert(t, policy.Scorers() == nil, "type based policy has no scorers")
+	policy = NewScoredNodeSortingPolicy("", nil, []configs.NodeScorerConfig{{Name: "unknown"}, {Name: AttributeMatchScorer}})
+	assert.Assert(t, policy.Scorers() == nil, "invalid scorers should be skipped")
+
+	RegisterNodeScorer("Constant", func(args map[string]string, _ map[string]float64) (NodeScorer, error) {
+		return constantScorer(0.5), nil
+	})
+	policy = NewScoredNodeSortingPolicy("", nil, []configs.NodeScorerConfig{
+		{Name: ReservationAvoidanceScorer, Weight: 3},
+		{Name: "constant"},
+	})
+	assert.DeepEqual(t, policy.Scorers(), map[string]float64{ReservationAvoidanceScorer: 3, "constant": 1})
+	node := newNode(nodeID1, map[string]resources.Quantity{"vcore": 10})
+	// (3 * 1 + 1 * 0.5) / 4 preferred, sorted ascending
+	assert.Assert(t, almostEqual(policy.ScoreNode(node), 1-3.5/4), "unexpected score %f", policy.ScoreNode(node))
+}
+
+func TestScoredNodeCollectionOrder(t *testing.T) {
+	nc := NewNodeCollection("test")
+	nc.SetNodeSortingPolicy(NewScoredNodeSortingPolicy("", nil, []configs.NodeScorerConfig{
+		{Name: InstanceTypeScorer, Weight: 2, Args: map[string]string{"types": "gpu"}},
+		{Name: MostAllocatedScorer},
+	}))
+	assert.NilError(t, nc.AddNode(newScorerNode(t, "node-1", 8, 8, nil)))
+	assert.NilError(t, nc.AddNode(newScorerNode(t, "node-2", 2, 2, map[string]string{siCommon.InstanceType: "gpu"})))
+	assert.NilError(t, nc.AddNode(newScorerNode(t, "node-3", 4, 4, nil)))
+
+	var order []string
+	nc.GetFullNodeIterator().ForEachNode(func(node *Node) bool {
+		order = append(order, node.NodeID)
+		return true
+	})
+	assert.DeepEqual(t, order, []string{"node-2", "node-1", "node-3"})
+
+	// a reservation changes the order when the reservation avoidance scorer is used
+	nc.SetNodeSortingPolicy(NewScoredNodeSortingPolicy("", nil, []configs.NodeScorerConfig{{Name: ReservationAvoidanceScorer}}))
+	app := newApplication(appID1, "default", "root.default")
+	ask := newAllocationAsk(aKey, appID1, resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 1}))
+	assert.NilError(t, nc.GetNode("node-1").Reserve(app, ask))
+	order = nil
+	nc.GetFullNodeIterator().ForEachNode(func(node *Node) bool {
+		order = append(order, node.NodeID)
+		return true
+	})
+	assert.Equal(t, order[len(order)-1], "node-1", "reserved node should be last")
+}
+
+func almostEqual(a, b float64) bool {
+	return a-b < 1e-9 && b-a < 1e-9
+}
@@ -31,6 +31,7 @@ type NodeSortingPolicy interface {
 	PolicyType() policies.SortingPolicy
 	ScoreNode(node *Node) float64
 	ResourceWeights() map[string]float64
+	Scorers() map[string]float64
 }
 
 type binPackingNodeSortingPolicy struct {
@@ -102,6 +103,14 @@ func (p fairnessNodeSortingPolicy) ResourceWeights() map[string]float64 {
 	return cloneWeights(p.resourceWeights)
 }
 
+func (p binPackingNodeSortingPolicy) Scorers() map[string]float64 {
+	return nil
+}
+
+func (p fairnessNodeSortingPolicy) Scorers() map[string]float64 {
+	return nil
+}
+
 // Return a default set of resource weights if not otherwise specified.
 func defaultResourceWeights() map[string]float64 {
 	weights := make(map[string]float64)
@@ -70,6 +70,12 @@ type Queue struct {
 	preemptionPolicy    policies.PreemptionPolicy // preemption policy
 	preemptionDelay     time.Duration             // time before preemption is considered
 	currentPriority     int32                     // the current scheduling priority of this queue
+	drfWeights          map[string]float64        // resource type weights for the DRF sort policy
+	weight              float64                   // share weight of the queue relative to its siblings
+	lendableResource    *resources.Resource       // part of the guaranteed resource other queues can borrow
+	lentResource        *resources.Resource       // resources lent to other queues
+	borrowedResource    *resources.Resource       // resources borrowed from other queues
+	reclaimGracePeriod  time.Duration             // time before lent resources are reclaimed
 
 	// The queue properties should be treated as immutable the value is a merge of the
 	// parent properties with the config for this queue only manipulated during creation
@@ -88,6 +94,11 @@ type Queue struct {
 	allocatingAcceptedApps map[string]bool
 	template               *template.Template
 	queueEvents            *schedEvt.QueueEvents
+	schedules              []*capacitySchedule // time windows overriding the configured resources
+	configResources        configs.Resources   // resources from the config, used outside the schedules
+	configMaxApps          uint64              // max applications from the config, used outside the schedules
+	activeSchedule         string              // name of the active schedule, empty if none is active
+	scheduleTime           time.Time           // last time the active schedule changed
 
 	locking.RWMutex
 }
@@ -107,6 +118,8 @@ func newBlankQueue() *Queue {
 		preemptingResource:     resources.NewResource(),
 		pending:                resources.NewResource(),
 		currentPriority:        configs.MinPriority,
+		weight:                 configs.DefaultQueueWeight,
+		reclaimGracePeriod:     configs.DefaultReclaimGracePeriod,
 		prioritySortEnabled:    true,
 		preemptionDelay:        configs.DefaultPreemptionDelay,
 		preemptionPolicy:       policies.DefaultPreemptionPolicy,
@@ -251,6 +264,10 @@ func (sq *Queue) mergeProperties(parent, config map[string]string) {
 	// Set the parent properties
 	if len(parent) != 0 {
 		for key, value := range parent {
+			// the weight is relative to the siblings of the queue that sets it and is never inherited
+			if key == configs.QueueWeight {
+				continue
+			}
 			sq.properties[key] = filterParentProperty(key, value)
 		}
 	}
@@ -342,8 +359,8 @@ func (sq *Queue) applyConf(conf configs.QueueConfig, silence bool) error {
 		sq.isManaged = true
 	}
 
-	// if the queue is marked for removal reverse that state
-	if !sq.IsRunning() {
+	// if the queue is marked for removal reverse that state, a stopped queue stays stopped
+	if sq.IsDraining() {
 		err = sq.handleQueueEvent(Start)
 		if err != nil {
 			log.Log(log.SchedQueue).Info("managed queue state change failed",
@@ -368,12 +385,20 @@ func (sq *Queue) applyConf(conf configs.QueueConfig, silence bool) error {
 	}
 
 	// Load the max & guaranteed resources and maxApps for all but the root queue
+	// The values of an active capacity schedule replace the configured values
 	if sq.Name != configs.RootQueue {
-		if err = sq.setResourcesFromConf(conf.Resources); err != nil {
+		if sq.schedules, err = newCapacitySchedules(conf); err != nil {
+			log.Log(log.SchedQueue).Error("parsing failed on capacity schedules this should not happen",
+				zap.String("queue", sq.QueuePath),
+				zap.Error(err))
+			return err
+		}
+		sq.configResources = conf.Resources
+		sq.configMaxApps = conf.MaxApplications
+		now := time.Now()
+		if err = sq.applyCapacitySchedule(findActiveSchedule(sq.schedules, now), now); err != nil {
 			return err
 		}
-		sq.maxRunningApps = conf.MaxApplications
-		sq.updateMaxRunningAppsMetrics()
 	}
 
 	sq.properties = conf.Properties
@@ -398,7 +423,19 @@ func (sq *Queue) setResourcesFromConf(resource configs.Resources) error {
 			zap.Error(err))
 		return err
 	}
+	var lendableResource *resources.Resource
+	lendableResource, err = resources.NewResourceFromConf(resource.Lendable)
+	if err != nil {
+		log.Log(log.SchedQueue).Error("parsing failed on lendable resources this should not happen",
+			zap.String("queue", sq.QueuePath),
+			zap.Error(err))
+		return err
+	}
 	sq.setResources(guaranteedResource, maxResource)
+	sq.lendableResource = nil
+	if resources.StrictlyGreaterThanZero(lendableResource) {
+		sq.lendableResource = lendableResource
+	}
 	return nil
 }
 
@@ -456,6 +493,69 @@ func (sq *Queue) setResources(guaranteedResource, maxResource *resources.Resourc
 	}
 }
 
+// applyCapacitySchedule sets the resources and max applications from the schedule, or from the config if the
+// schedule is nil. A change of the active schedule is logged and sent as a queue event.
+// Lock free call, must be called holding the queue lock.
+func (sq *Queue) applyCapacitySchedule(schedule *capacitySchedule, now time.Time) error {
+	conf := sq.configResources
+	maxApps := sq.configMaxApps
+	name := ""
+	if schedule != nil {
+		conf = schedule.resources
+		if schedule.maxApps != 0 {
+			maxApps = schedule.maxApps
+		}
+		name = schedule.name
+	}
+	if err := sq.setResourcesFromConf(conf); err != nil {
+		return err
+	}
+	sq.maxRunningApps = maxApps
+	sq.updateMaxRunningAppsMetrics()
+	if name != sq.activeSchedule {
+		log.Log(log.SchedQueue).Info("queue capacity schedule changed",
+			zap.String("queue", sq.QueuePath),
+			zap.String("previous", sq.activeSchedule),
+			zap.String("current", name))
+		sq.activeSchedule = name
+		sq.scheduleTime = now
+		if sq.queueEvents != nil {
+			sq.queueEvents.SendCapacityScheduleEvent(sq.QueuePath, name)
+		}
+	}
+	return nil
+}
+
+// UpdateCapacitySchedule checks which capacity schedule is active at the time and updates the queue resources
+// and max applications when the active schedule changed.
+func (sq *Queue) UpdateCapacitySchedule(now time.Time) {
+	sq.Lock()
+	defer sq.Unlock()
+	if len(sq.schedules) == 0 {
+		return
+	}
+	schedule := findActiveSchedule(sq.schedules, now)
+	name := ""
+	if schedule != nil {
+		name = schedule.name
+	}
+	if name == sq.activeSchedule {
+		return
+	}
+	if err := sq.applyCapacitySchedule(schedule, now); err != nil {
+		log.Log(log.SchedQueue).Warn("failed to apply capacity schedule",
+			zap.String("queue", sq.QueuePath),
+			zap.Error(err))
+	}
+}
+
+// GetActiveSchedule returns the name of the active capacity schedule, empty if none is active.
+func (sq *Queue) GetActiveSchedule() string {
+	sq.RLock()
+	defer sq.RUnlock()
+	return sq.activeSchedule
+}
+
 func (sq *Queue) SetResources(guaranteedResource, maxResource *resources.Resource) {
 	sq.Lock()
 	defer sq.Unlock()
@@ -497,6 +597,9 @@ func (sq *Queue) UpdateQueueProperties() {
 		// set the sorting type for parent queues
 		sq.sortType = policies.FairSortPolicy
 	}
+	sq.drfWeights = nil
+	sq.weight = configs.DefaultQueueWeight
+	sq.reclaimGracePeriod = configs.DefaultReclaimGracePeriod
 	// walk over all properties and process
 	var err error
 	for key, value := range sq.properties {
@@ -512,6 +615,27 @@ func (sq *Queue) UpdateQueueProperties() {
 				if sq.sortType == policies.Undefined {
 					sq.sortType = policies.FifoSortPolicy
 				}
+			} else if value == policies.DRFSortPolicy.String() {
+				// parent queues only support fair and drf for sorting the child queues
+				sq.sortType = policies.DRFSortPolicy
+			}
+		case configs.QueueWeight:
+			sq.weight, err = configs.ParseQueueWeight(value)
+			if err != nil {
+				log.Log(log.SchedQueue).Debug("queue weight configuration error",
+					zap.Error(err))
+			}
+		case configs.ReclaimGracePeriod:
+			sq.reclaimGracePeriod, err = configs.ParseReclaimGracePeriod(value)
+			if err != nil {
+				log.Log(log.SchedQueue).Debug("reclaim grace period configuration error",
+					zap.Error(err))
+			}
+		case configs.DRFResourceWeights:
+			sq.drfWeights, err = configs.ParseDRFWeights(value)
+			if err != nil {
+				log.Log(log.SchedQueue).Debug("DRF resource weights configuration error",
+					zap.Error(err))
 			}
 		case configs.ApplicationSortPriority:
 			sq.prioritySortEnabled, err = applicationSortPriorityEnabled(value)
@@ -600,6 +724,28 @@ func (sq *Queue) handleQueueEvent(event ObjectEvent) error {
 	return err
 }
 
+// ChangeState stops or starts the queue on request of an administrator. Removal of a queue is driven by the
+// configuration and cannot be requested. The root queue cannot be stopped and a queue that is being removed
+// cannot be started.
+func (sq *Queue) ChangeState(event ObjectEvent, reason string) error {
+	switch {
+	case event == Remove:
+		return fmt.Errorf("queue %s cannot be removed, update the configuration", sq.QueuePath)
+	case event == Stop && sq.isRoot():
+		return fmt.Errorf("root queue cannot be stopped")
+	case event == Start && sq.IsDraining():
+		return fmt.Errorf("queue %s is being removed and cannot be started", sq.QueuePath)
+	}
+	previous := sq.CurrentState()
+	if err := sq.handleQueueEvent(event); err != nil {
+		return err
+	}
+	if current := sq.CurrentState(); current != previous {
+		sq.queueEvents.SendStateChangedEvent(sq.QueuePath, current, reason)
+	}
+	return nil
+}
+
 // GetAllocatedResource returns a clone of the allocated resources for this queue.
 func (sq *Queue) GetAllocatedResource() *resources.Resource {
 	sq.RLock()
@@ -621,6 +767,76 @@ func (sq *Queue) GetGuaranteedResource() *resources.Resource {
 	return sq.guaranteedResource
 }
 
+// GetLendableResource returns the part of the guaranteed resource other queues can borrow, nil if not lending.
+func (sq *Queue) GetLendableResource() *resources.Resource {
+	sq.RLock()
+	defer sq.RUnlock()
+	return sq.lendableResource
+}
+
+// GetAvailableToLend returns the lendable resources that are not used by the queue itself and not lent yet.
+// The result only contains the lendable resource types, and is nil if the queue is not lending.
+func (sq *Queue) GetAvailableToLend() *resources.Resource {
+	sq.RLock()
+	defer sq.RUnlock()
+	if sq.lendableResource == nil {
+		return nil
+	}
+	unused := resources.SubEliminateNegative(sq.guaranteedResource, sq.allocatedResource)
+	return resources.SubEliminateNegative(resources.ComponentWiseMinOnlyExisting(sq.lendableResource, unused), sq.lentResource)
+}
+
+// GetLentResource returns a clone of the resources lent to other queues.
+func (sq *Queue) GetLentResource() *resources.Resource {
+	sq.RLock()
+	defer sq.RUnlock()
+	return sq.lentResource.Clone()
+}
+
+// GetBorrowedResource returns a clone of the resources borrowed from other queues.
+func (sq *Queue) GetBorrowedResource() *resources.Resource {
+	sq.RLock()
+	defer sq.RUnlock()
+	return sq.borrowedResource.Clone()
+}
+
+// IncLentResource tracks resources lent to another queue.
+func (sq *Queue) IncLentResource(delta *resources.Resource) {
+	sq.Lock()
+	defer sq.Unlock()
+	sq.lentResource = resources.Add(sq.lentResource, delta)
+}
+
+// DecLentResource tracks resources returned by another queue.
+func (sq *Queue) DecLentResource(delta *resources.Resource) {
+	sq.Lock()
+	defer sq.Unlock()
+	sq.lentResource = resources.SubEliminateNegative(sq.lentResource, delta)
+	sq.lentResource.Prune()
+}
+
+// IncBorrowedResource tracks resources borrowed from another queue.
+func (sq *Queue) IncBorrowedResource(delta *resources.Resource) {
+	sq.Lock()
+	defer sq.Unlock()
+	sq.borrowedResource = resources.Add(sq.borrowedResource, delta)
+}
+
+// DecBorrowedResource tracks resources returned to another queue.
+func (sq *Queue) DecBorrowedResource(delta *resources.Resource) {
+	sq.Lock()
+	defer sq.Unlock()
+	sq.borrowedResource = resources.SubEliminateNegative(sq.borrowedResource, delta)
+	sq.borrowedResource.Prune()
+}
+
+// GetReclaimGracePeriod returns the time a borrower gets before resources lent by this queue are reclaimed.
+func (sq *Queue) GetReclaimGracePeriod() time.Duration {
+	sq.RLock()
+	defer sq.RUnlock()
+	return sq.reclaimGracePeriod
+}
+
 // GetMaxApps returns the maximum number of applications that can run in this queue.
 func (sq *Queue) GetMaxApps() uint64 {
 	sq.RLock()
@@ -712,6 +928,17 @@ func (sq *Queue) GetPartitionQueueDAOInfo(include bool) dao.PartitionQueueDAOInf
 	queueInfo.PreemptionDelay = sq.preemptionDelay.String()
 	queueInfo.IsPriorityFence = sq.priorityPolicy == policies.FencePriorityPolicy
 	queueInfo.PriorityOffset = sq.priorityOffset
+	queueInfo.Weight = sq.weight
+	queueInfo.ActiveSchedule = sq.activeSchedule
+	queueInfo.LendableResource = sq.lendableResource.DAOMap()
+	queueInfo.LentResource = sq.lentResource.DAOMap()
+	queueInfo.BorrowedResource = sq.borrowedResource.DAOMap()
+	if !sq.scheduleTime.IsZero() {
+		queueInfo.ScheduleTransitionTime = sq.scheduleTime.UnixNano()
+	}
+	for _, schedule := range sq.schedules {
+		queueInfo.Schedules = append(queueInfo.Schedules, schedule.name)
+	}
 	queueInfo.Properties = make(map[string]string)
 	for k, v := range sq.properties {
 		queueInfo.Properties[k] = v
@@ -849,6 +1076,18 @@ func (sq *Queue) RemoveApplication(app *Application) {
 		zap.String("applicationID", appID))
 }
 
+// detachApplication removes the application from the queue without changing the queue usage. The usage must have
+// been transferred before, this is only used when the application moves to another queue.
+func (sq *Queue) detachApplication(appID string) {
+	sq.Lock()
+	delete(sq.applications, appID)
+	delete(sq.appPriorities, appID)
+	priority := sq.recalculatePriority()
+	sq.Unlock()
+	sq.queueEvents.SendRemoveApplicationEvent(sq.QueuePath, appID)
+	sq.parent.UpdateQueuePriority(sq.Name, priority)
+}
+
 func (sq *Queue) appExists(appID string) bool {
 	sq.RLock()
 	defer sq.RUnlock()
@@ -1010,6 +1249,12 @@ func (sq *Queue) RemoveQueue() bool {
 	return true
 }
 
+// GetParent returns the parent queue, nil for the root queue.
+// Lock free call, the parent link does not change after creation.
+func (sq *Queue) GetParent() *Queue {
+	return sq.parent
+}
+
 // IsLeafQueue returns true is the queue a leaf. Returns false for a parent queue.
 func (sq *Queue) IsLeafQueue() bool {
 	sq.RLock()
@@ -1199,7 +1444,21 @@ func (sq *Queue) sortApplications(withPlaceholdersOnly bool) []*Application {
 	}
 
 	// sort applications based on the sorting policy
-	return sortApplications(apps, sq.getSortType(), sq.IsPrioritySortEnabled(), sq.GetGuaranteedResource())
+	var sortedApps []*Application
+	sortType := sq.getSortType()
+	switch {
+	case sortType == policies.DRFSortPolicy:
+		sortedApps = sortApplicationsByDominantShare(apps, sq.getPartitionCapacity(), sq.getDRFWeights(), sq.IsPrioritySortEnabled())
+	case sortType == policies.FairSortPolicy && ugm.GetUserManager().IsUsageHistoryEnabled():
+		sortedApps = sortApplicationsByUsageHistory(apps, sq.getPartitionCapacity(), sq.GetGuaranteedResource(), sq.IsPrioritySortEnabled())
+	default:
+		sortedApps = sortApplications(apps, sortType, sq.IsPrioritySortEnabled(), sq.GetGuaranteedResource())
+	}
+	// users and groups below their guarantee go first, whatever the policy
+	if ugm.GetUserManager().HasGuarantees() {
+		sortedApps = sortApplicationsByUserGuarantee(sortedApps)
+	}
+	return sortedApps
 }
 
 // sortQueues returns a sorted shallow copy of the queues for this parent queue.
@@ -1225,7 +1484,16 @@ func (sq *Queue) sortQueues() []*Queue {
 		}
 	}
 	// Sort the queues
-	sortQueue(sortedQueues, sortedMaxFairResources, sq.getSortType(), sq.IsPrioritySortEnabled())
+	sortType := sq.getSortType()
+	if sortType == policies.DRFSortPolicy {
+		sortQueuesByDominantShare(sortedQueues, sq.getPartitionCapacity(), sq.getDRFWeights(), sq.IsPrioritySortEnabled())
+		return sortedQueues
+	}
+	if sortType == policies.FairSortPolicy && ugm.GetUserManager().IsUsageHistoryEnabled() {
+		sortQueuesByUsageHistory(sortedQueues, sortedMaxFairResources, sq.getPartitionCapacity(), sq.IsPrioritySortEnabled())
+		return sortedQueues
+	}
+	sortQueue(sortedQueues, sortedMaxFairResources, sortType, sq.IsPrioritySortEnabled())
 
 	return sortedQueues
 }
@@ -1300,14 +1568,23 @@ func (sq *Queue) GetMaxResource() *resources.Resource {
 // If the root includes an explicit 0 value for a Resource, do not include it in the accumulator and treat it as missing.
 // If no children provide a maximum capacity override, the resulting value will be the value found on the Root.
 // It is useful for fair-scheduling to allow a ratio to be produced representing the rough utilization % of a given queue.
+// The result is scaled by the weight of the queue: a queue with weight 3 reaches the same ratio as a sibling with
+// weight 1 only when it uses three times as much.
 func (sq *Queue) GetFairMaxResource() *resources.Resource {
-	var limit *resources.Resource
+	fairMax := sq.getFairMaxResource()
+	if weight := sq.GetWeight(); weight != configs.DefaultQueueWeight {
+		return resources.MultiplyBy(fairMax, weight)
+	}
+	return fairMax
+}
+
+// getFairMaxResource computes the fair max resources for the queue without applying the weight.
+// The weight of a parent queue only influences the order of the parent and its siblings, not the ratio of the children.
+func (sq *Queue) getFairMaxResource() *resources.Resource {
 	if sq.parent == nil {
 		return sq.GetMaxResource().Clone()
 	}
-
-	limit = sq.parent.GetFairMaxResource()
-	return sq.internalGetFairMaxResource(limit)
+	return sq.internalGetFairMaxResource(sq.parent.getFairMaxResource())
 }
 
 func (sq *Queue) internalGetFairMaxResource(limit *resources.Resource) *resources.Resource {
@@ -1469,6 +1746,95 @@ func (sq *Queue) TryAllocate(iterator func() NodeIterator, fullIterator func() N
 	return nil
 }
 
+// batchCandidate is an application in the sorted snapshot used by TryAllocateBatch.
+// The preemption attempts are shared by all applications in the same leaf queue.
+type batchCandidate struct {
+	queue                    *Queue
+	app                      *Application
+	preemptionDelay          time.Duration
+	preemptAttemptsRemaining *int
+}
+
+// TryAllocateBatch allocates up to limit pending requests from one sorted snapshot of the queue hierarchy.
+// The queues and applications are sorted once, the same way TryAllocate sorts them. The snapshot is then
+// processed in rounds: each round gives every application in sort order one allocation attempt, applications
+// that cannot allocate are dropped from the snapshot.
+// Every result is passed to commit before the next attempt. The headroom of the queue and the user quota are
+// calculated for each attempt so they include all allocations committed earlier in the batch. Commit returns
+// nil if the result was not an allocation (reservation, unreserve or the application was removed): the
+// application is dropped from the snapshot.
+// Returns the committed allocations in the order they were made.
+// Lock free call this all locks are taken when needed in called functions
+func (sq *Queue) TryAllocateBatch(limit int, iterator func() NodeIterator, fullIterator func() NodeIterator, getnode func(string) *Node, allowPreemption bool, commit func(*AllocationResult) *AllocationResult) []*AllocationResult {
+	candidates := sq.batchCandidates(nil)
+	var results []*AllocationResult
+	for len(candidates) > 0 && len(results) < limit {
+		next := candidates[:0]
+		for _, candidate := range candidates {
+			if len(results) >= limit {
+				break
+			}
+			app := candidate.app
+			// running an accepted app could push the queue or user over the maximum applications
+			if app.IsAccepted() {
+				runnableInQueue := candidate.queue.canRunApp(app.ApplicationID)
+				runnableByUserLimit := ugm.GetUserManager().CanRunApp(candidate.queue.QueuePath, app.ApplicationID, app.user)
+				app.updateRunnableStatus(runnableInQueue, runnableByUserLimit)
+				if !runnableInQueue || !runnableByUserLimit {
+					continue
+				}
+			}
+			result := app.tryAllocate(candidate.queue.getHeadRoom(), allowPreemption, candidate.preemptionDelay, candidate.preemptAttemptsRemaining, iterator, fullIterator, getnode)
+			if result == nil {
+				continue
+			}
+			log.Log(log.SchedQueue).Info("allocation found on queue",
+				zap.String("queueName", candidate.queue.QueuePath),
+				zap.String("appID", app.ApplicationID),
+				zap.Stringer("resultType", result.ResultType),
+				zap.Stringer("allocation", result.Request))
+			if app.IsAccepted() {
+				candidate.queue.setAllocatingAccepted(app.ApplicationID)
+			}
+			if result = commit(result); result == nil {
+				continue
+			}
+			results = append(results, result)
+			next = append(next, candidate)
+		}
+		candidates = next
+	}
+	return results
+}
+
+// batchCandidates adds the applications of the queue hierarchy to the candidates in scheduling order.
+// Applications that cannot run because of the queue or user maximum applications are skipped.
+func (sq *Queue) batchCandidates(candidates []batchCandidate) []batchCandidate {
+	if !sq.IsLeafQueue() {
+		for _, child := range sq.sortQueues() {
+			candidates = child.batchCandidates(candidates)
+		}
+		return candidates
+	}
+	preemptAttemptsRemaining := maxPreemptionsPerQueue
+	preemptionDelay := sq.GetPreemptionDelay()
+	for _, app := range sq.sortApplications(false) {
+		runnableInQueue := sq.canRunApp(app.ApplicationID)
+		runnableByUserLimit := ugm.GetUserManager().CanRunApp(sq.QueuePath, app.ApplicationID, app.user)
+		app.updateRunnableStatus(runnableInQueue, runnableByUserLimit)
+		if app.IsAccepted() && (!runnableInQueue || !runnableByUserLimit) {
+			continue
+		}
+		candidates = append(candidates, batchCandidate{
+			queue:                    sq,
+			app:                      app,
+			preemptionDelay:          preemptionDelay,
+			preemptAttemptsRemaining: &preemptAttemptsRemaining,
+		})
+	}
+	return candidates
+}
+
 // TryPlaceholderAllocate tries to replace a placeholders with a real allocation.
 // This only gets called if there is a pending request on this queue or its children.
 // This is a depth first algorithm: descend into the depth of the queue tree first. Child queues are sorted based on
@@ -1661,6 +2027,31 @@ func (sq *Queue) getSortType() policies.SortPolicy {
 	return sq.sortType
 }
 
+// GetWeight returns the share weight of the queue relative to its siblings.
+func (sq *Queue) GetWeight() float64 {
+	sq.RLock()
+	defer sq.RUnlock()
+	return sq.weight
+}
+
+// getDRFWeights returns the resource type weights used by the DRF sort policy, nil if none are configured.
+func (sq *Queue) getDRFWeights() map[string]float64 {
+	sq.RLock()
+	defer sq.RUnlock()
+	return sq.drfWeights
+}
+
+// getPartitionCapacity returns the capacity of the partition the queue belongs to.
+// The max resource of the root queue is kept in sync with the nodes registered in the partition.
+// Lock free call, the parent link does not change after creation.
+func (sq *Queue) getPartitionCapacity() *resources.Resource {
+	root := sq
+	for root.parent != nil {
+		root = root.parent
+	}
+	return root.GetMaxResource()
+}
+
 // SupportTaskGroup returns true if the queue supports task groups.
 // FIFO policy is required to support this.
 // NOTE: this call does not make sense for a parent queue, and always returns false
@@ -1787,6 +2178,26 @@ func (sq *Queue) setAllocatingAccepted(appID string) {
 	sq.allocatingAcceptedApps[appID] = true
 }
 
+// isAllocatingAccepted returns true if the application is tracked as an accepted application with allocations.
+func (sq *Queue) isAllocatingAccepted(appID string) bool {
+	sq.RLock()
+	defer sq.RUnlock()
+	return sq.allocatingAcceptedApps[appID]
+}
+
+// unsetAllocatingAccepted stops tracking the application in accepted state for this queue (recursively).
+func (sq *Queue) unsetAllocatingAccepted(appID string) {
+	if sq == nil {
+		return
+	}
+	if sq.parent != nil {
+		sq.parent.unsetAllocatingAccepted(appID)
+	}
+	sq.Lock()
+	defer sq.Unlock()
+	delete(sq.allocatingAcceptedApps, appID)
+}
+
 func (sq *Queue) GetPreemptionPolicy() policies.PreemptionPolicy {
 	sq.RLock()
 	defer sq.RUnlock()
@@ -36,7 +36,9 @@ import (
 	"github.com/apache/yunikorn-core/pkg/common/configs"
 	"github.com/apache/yunikorn-core/pkg/common/resources"
 	"github.com/apache/yunikorn-core/pkg/events"
+	evtMock "github.com/apache/yunikorn-core/pkg/events/mock"
 	"github.com/apache/yunikorn-core/pkg/metrics"
+	schedEvt "github.com/apache/yunikorn-core/pkg/scheduler/objects/events"
 	"github.com/apache/yunikorn-core/pkg/scheduler/objects/template"
 	"github.com/apache/yunikorn-core/pkg/scheduler/policies"
 	siCommon "github.com/apache/yunikorn-scheduler-interface/lib/go/common"
@@ -629,6 +631,37 @@ func TestSortQueue(t *testing.T) {
 	}
 }
 
+func TestQueueChangeState(t *testing.T) {
+	root, err := createRootQueue(nil)
+	assert.NilError(t, err, "queue create failed")
+	var leaf *Queue
+	leaf, err = createManagedQueue(root, "leaf", false, nil)
+	assert.NilError(t, err, "failed to create leaf queue")
+	mockEvents := evtMock.NewEventSystem()
+	leaf.queueEvents = schedEvt.NewQueueEvents(mockEvents)
+
+	assert.Assert(t, root.ChangeState(Stop, "test") != nil, "root queue should not be stopped")
+	assert.Assert(t, leaf.ChangeState(Remove, "test") != nil, "remove should not be allowed")
+	assert.NilError(t, leaf.ChangeState(Stop, "test"), "stop failed")
+	assert.Assert(t, leaf.IsStopped(), "leaf queue not stopped")
+	assert.Equal(t, len(mockEvents.Events), 1, "state change event not sent")
+	assert.Equal(t, mockEvents.Events[0].Message, "state: Stopped, test")
+	// no change no event
+	assert.NilError(t, leaf.ChangeState(Stop, "test"), "second stop failed")
+	assert.Equal(t, len(mockEvents.Events), 1, "unexpected event for unchanged state")
+
+	// configuration updates do not start a stopped queue
+	assert.NilError(t, leaf.ApplyConf(configs.QueueConfig{Name: "leaf"}), "apply conf failed")
+	assert.Assert(t, leaf.IsStopped(), "config update started stopped queue")
+	assert.NilError(t, leaf.ChangeState(Start, "test"), "start failed")
+	assert.Assert(t, leaf.IsRunning(), "leaf queue not started")
+
+	// queue being removed cannot be started
+	assert.NilError(t, leaf.handleQueueEvent(Remove), "remove failed")
+	assert.Assert(t, leaf.ChangeState(Start, "test") != nil, "draining queue should not be started")
+	assert.Assert(t, leaf.IsDraining(), "leaf queue should still be draining")
+}
+
 func TestHeadroom(t *testing.T) {
 	// create the root: nil test
 	root, err := createRootQueue(nil)
@@ -1020,6 +1053,101 @@ func TestGetFairMaxResource(t *testing.T) {
 	}
 }
 
+func TestQueueWeight(t *testing.T) {
+	root, err := createRootQueue(map[string]string{"first": "100"})
+	assert.NilError(t, err, "failed to create basic root queue")
+	var parent, heavy, light *Queue
+	parent, err = createManagedQueueWithProps(root, "parent", true, nil, map[string]string{configs.QueueWeight: "2"})
+	assert.NilError(t, err, "failed to create parent queue")
+	assert.Equal(t, parent.GetWeight(), 2.0)
+	// weight is not inherited, the parent weight does not change the fair max of the children
+	light, err = createManagedQueue(parent, "light", false, nil)
+	assert.NilError(t, err, "failed to create leaf queue")
+	assert.Equal(t, light.GetWeight(), configs.DefaultQueueWeight)
+	_, ok := light.getProperties()[configs.QueueWeight]
+	assert.Assert(t, !ok, "weight property should not be inherited")
+	heavy, err = createManagedQueueWithProps(parent, "heavy", false, nil, map[string]string{configs.QueueWeight: "6"})
+	assert.NilError(t, err, "failed to create leaf queue")
+	assert.Equal(t, heavy.GetWeight(), 6.0)
+	res := func(v int64) *resources.Resource {
+		return resources.NewResourceFromMap(map[string]resources.Quantity{"first": resources.Quantity(v)})
+	}
+	assert.Assert(t, resources.Equals(parent.GetFairMaxResource(), res(200)), "parent fair max not weighted")
+	assert.Assert(t, resources.Equals(light.GetFairMaxResource(), res(100)), "light fair max should not be weighted")
+	assert.Assert(t, resources.Equals(heavy.GetFairMaxResource(), res(600)), "heavy fair max not weighted")
+
+	// heavy deserves 6x the share of light: with less than 6x the usage it goes first
+	heavy.allocatedResource = res(50)
+	heavy.pending = res(1)
+	light.allocatedResource = res(20)
+	light.pending = res(1)
+	parent.pending = res(2)
+	assert.Equal(t, queueNames(parent.sortQueues()), queueNames([]*Queue{heavy, light}), "weighted share not used")
+	// with more than 6x the usage light goes first
+	heavy.allocatedResource = res(130)
+	assert.Equal(t, queueNames(parent.sortQueues()), queueNames([]*Queue{light, heavy}), "weighted share not used")
+	// equal weighted share: the heavy queue goes first
+	heavy.allocatedResource = res(120)
+	assert.Equal(t, queueNames(parent.sortQueues()), queueNames([]*Queue{heavy, light}), "weight not used for equal shares")
+
+	// weight from the template is applied to dynamic queues, invalid weights use the default
+	parent.template, err = template.FromConf(&configs.ChildTemplate{
+		Properties: map[string]string{configs.QueueWeight: "4"},
+	})
+	assert.NilError(t, err, "failed to create template")
+	var dynamic *Queue
+	dynamic, err = createDynamicQueue(parent, "dynamic", false)
+	assert.NilError(t, err, "failed to create dynamic queue")
+	assert.Equal(t, dynamic.GetWeight(), 4.0)
+	dynamic.properties[configs.QueueWeight] = "-1"
+	dynamic.UpdateQueueProperties()
+	assert.Equal(t, dynamic.GetWeight(), configs.DefaultQueueWeight)
+}
+
+func TestQueueLending(t *testing.T) {
+	root, err := createRootQueue(map[string]string{"first": "100"})
+	assert.NilError(t, err, "failed to create basic root queue")
+	var lender *Queue
+	lender, err = NewConfiguredQueue(configs.QueueConfig{
+		Name: "lender",
+		Resources: configs.Resources{
+			Guaranteed: map[string]string{"first": "10", "second": "5"},
+			Lendable:   map[string]string{"first": "6"},
+		},
+		Properties: map[string]string{configs.ReclaimGracePeriod: "2m"},
+	}, root, false)
+	assert.NilError(t, err, "failed to create lender queue")
+	res := func(v int64) *resources.Resource {
+		return resources.NewResourceFromMap(map[string]resources.Quantity{"first": resources.Quantity(v)})
+	}
+	assert.Assert(t, resources.Equals(lender.GetLendableResource(), res(6)), "unexpected lendable resource")
+	assert.Assert(t, resources.Equals(lender.GetAvailableToLend(), res(6)), "unexpected available to lend")
+	assert.Equal(t, lender.GetReclaimGracePeriod(), 2*time.Minute)
+
+	// own usage above the non lendable part limits what can be lent
+	lender.allocatedResource = res(7)
+	assert.Assert(t, resources.Equals(lender.GetAvailableToLend(), res(3)), "usage not taken into account")
+	lender.IncLentResource(res(2))
+	assert.Assert(t, resources.Equals(lender.GetAvailableToLend(), res(1)), "lent resources not taken into account")
+	dao := lender.GetPartitionQueueDAOInfo(false)
+	assert.DeepEqual(t, dao.LendableResource, map[string]int64{"first": 6})
+	assert.DeepEqual(t, dao.LentResource, map[string]int64{"first": 2})
+	lender.DecLentResource(res(2))
+	assert.Assert(t, resources.IsZero(lender.GetLentResource()), "lent resources not returned")
+
+	// queue without lendable resources does not lend and uses the default grace period
+	var leaf *Queue
+	leaf, err = createManagedQueueGuaranteed(root, "leaf", false, nil, map[string]string{"first": "10"})
+	assert.NilError(t, err, "failed to create leaf queue")
+	assert.Assert(t, leaf.GetLendableResource() == nil, "lendable resource should not be set")
+	assert.Assert(t, leaf.GetAvailableToLend() == nil, "nothing should be available to lend")
+	assert.Equal(t, leaf.GetReclaimGracePeriod(), configs.DefaultReclaimGracePeriod)
+	leaf.IncBorrowedResource(res(4))
+	assert.Assert(t, resources.Equals(leaf.GetBorrowedResource(), res(4)), "unexpected borrowed resource")
+	leaf.DecBorrowedResource(res(4))
+	assert.Assert(t, resources.IsZero(leaf.GetBorrowedResource()), "borrowed resources not returned")
+}
+
 func TestGetMaxResource(t *testing.T) {
 	// create the root
 	root, err := createRootQueue(nil)
@@ -1596,6 +1724,45 @@ func TestInheritedQueueProps(t *testing.T) {
 	assert.Equal(t, leaf.preemptionPolicy, policies.DisabledPreemptionPolicy)
 }
 
+func TestDRFQueueProps(t *testing.T) {
+	root, err := createRootQueue(nil)
+	assert.NilError(t, err, "failed to create basic root queue")
+	root.SetMaxResource(resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 100, "gpu": 10}))
+	props := map[string]string{configs.ApplicationSortPolicy: "drf", configs.DRFResourceWeights: "gpu=2"}
+	var parent *Queue
+	parent, err = createManagedQueueWithProps(root, "parent", true, nil, props)
+	assert.NilError(t, err, "failed to create parent queue")
+	assert.Equal(t, parent.getSortType(), policies.DRFSortPolicy)
+	assert.DeepEqual(t, parent.getDRFWeights(), map[string]float64{"gpu": 2})
+
+	// the policy and weights are inherited by the leaf queues
+	var leaf1, leaf2 *Queue
+	leaf1, err = createManagedQueue(parent, "leaf1", false, nil)
+	assert.NilError(t, err, "failed to create leaf queue")
+	assert.Equal(t, leaf1.getSortType(), policies.DRFSortPolicy)
+	assert.DeepEqual(t, leaf1.getDRFWeights(), map[string]float64{"gpu": 2})
+	assert.Assert(t, resources.Equals(leaf1.getPartitionCapacity(), root.GetMaxResource()), "partition capacity not taken from root")
+	leaf2, err = createManagedQueue(parent, "leaf2", false, nil)
+	assert.NilError(t, err, "failed to create leaf queue")
+
+	// leaf1: 0.3 vcore share, leaf2: 0.2 gpu share weighted to 0.4
+	pending := resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 1})
+	leaf1.allocatedResource = resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 30})
+	leaf1.pending = pending
+	leaf2.allocatedResource = resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 5, "gpu": 2})
+	leaf2.pending = pending
+	parent.pending = resources.Multiply(pending, 2)
+	sorted := parent.sortQueues()
+	assert.Equal(t, queueNames(sorted), queueNames([]*Queue{leaf1, leaf2}), "weighted gpu share not used")
+
+	// a parent without the drf policy sorts fair, invalid weights are ignored
+	props = map[string]string{configs.ApplicationSortPolicy: "fifo", configs.DRFResourceWeights: "gpu=x"}
+	parent, err = createManagedQueueWithProps(root, "parent", true, nil, props)
+	assert.NilError(t, err, "failed to create parent queue")
+	assert.Equal(t, parent.getSortType(), policies.FairSortPolicy)
+	assert.Assert(t, parent.getDRFWeights() == nil, "invalid weights should not be set")
+}
+
 func TestMaxResource(t *testing.T) {
 	resMap := map[string]string{"first": "10"}
 	res, err := resources.NewResourceFromConf(resMap)
@@ -1705,6 +1872,7 @@ func TestGetPartitionQueueDAOInfo(t *testing.T) {
 		configs.ApplicationSortPolicy: policies.FairSortPolicy.String(),
 		configs.PreemptionDelay:       "3600s",
 		configs.PreemptionPolicy:      policies.FencePreemptionPolicy.String(),
+		configs.QueueWeight:           "2.5",
 	}
 	leaf.UpdateQueueProperties()
 	leafDAO := leaf.GetPartitionQueueDAOInfo(false)
@@ -1715,6 +1883,7 @@ func TestGetPartitionQueueDAOInfo(t *testing.T) {
 	assert.Equal(t, leafDAO.IsPreemptionFence, true, "fence should have been set")
 	assert.Equal(t, leafDAO.PreemptionDelay, "1h0m0s", "incorrect delay returned")
 	assert.Equal(t, leafDAO.SortingPolicy, "fair", "incorrect policy returned")
+	assert.Equal(t, leafDAO.Weight, 2.5, "incorrect weight returned")
 
 	// special prop checks
 	leaf.properties = map[string]string{
@@ -1728,6 +1897,7 @@ func TestGetPartitionQueueDAOInfo(t *testing.T) {
 	assert.Equal(t, leafDAO.IsPreemptionFence, false, "queue should not be a fence")
 	assert.Equal(t, leafDAO.PreemptionDelay, "10s", "incorrect delay returned")
 	assert.Equal(t, leafDAO.SortingPolicy, "fifo", "incorrect policy returned")
+	assert.Equal(t, leafDAO.Weight, configs.DefaultQueueWeight, "incorrect weight returned")
 }
 
 func getAllocatingAcceptedApps() map[string]bool {
@@ -19,8 +19,12 @@
 package objects
 
 import (
+	"sort"
+	"time"
+
 	"go.uber.org/zap"
 
+	"github.com/apache/yunikorn-core/pkg/common/resources"
 	"github.com/apache/yunikorn-core/pkg/log"
 )
 
@@ -78,3 +82,42 @@ func (r *reservation) GetObjects() (*Node, *Application, *Allocation) {
 	}
 	return nil, nil, nil
 }
+
+// estimateReadyTime estimates when the request fits on a node with the available resources, based on the expected
+// end time of the allocations on the node. Allocations without an expected runtime never end. If the request fits
+// now, now is returned. Returns false if the time cannot be estimated.
+func estimateReadyTime(available *resources.Resource, allocations []*Allocation, request *resources.Resource, now time.Time) (time.Time, bool) {
+	free := available.Clone()
+	if free.FitIn(request) {
+		return now, true
+	}
+	type release struct {
+		end      time.Time
+		resource *resources.Resource
+	}
+	releases := make([]release, 0, len(allocations))
+	for _, alloc := range allocations {
+		runtime := alloc.GetExpectedRuntime()
+		if runtime <= 0 {
+			continue
+		}
+		start := alloc.GetBindTime()
+		if start.IsZero() {
+			start = now
+		}
+		releases = append(releases, release{end: start.Add(runtime), resource: alloc.GetAllocatedResource()})
+	}
+	sort.SliceStable(releases, func(i, j int) bool {
+		return releases[i].end.Before(releases[j].end)
+	})
+	for _, r := range releases {
+		free.AddTo(r.resource)
+		if free.FitIn(request) {
+			if r.end.Before(now) {
+				return now, true
+			}
+			return r.end, true
+		}
+	}
+	return time.Time{}, false
+}
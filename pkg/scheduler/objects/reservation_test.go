@@ -20,10 +20,12 @@ package objects
 
 import (
 	"testing"
+	"time"
 
 	"gotest.tools/v3/assert"
 
 	"github.com/apache/yunikorn-core/pkg/common/resources"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
 )
 
 func TestNewReservation(t *testing.T) {
@@ -105,3 +107,126 @@ func TestGetObjects(t *testing.T) {
 		t.Fatalf("nil reservation should return nil objects")
 	}
 }
+
+func newAllocationRuntime(allocKey, appID, nodeID string, res *resources.Resource, runtime string) *Allocation {
+	alloc := &si.Allocation{
+		AllocationKey:    allocKey,
+		ApplicationID:    appID,
+		PartitionName:    "default",
+		NodeID:           nodeID,
+		ResourcePerAlloc: res.ToProto(),
+	}
+	if runtime != "" {
+		alloc.AllocationTags = map[string]string{AllocTagExpectedRuntime: runtime}
+	}
+	return NewAllocationFromSI(alloc)
+}
+
+func TestEstimateReadyTime(t *testing.T) {
+	now := time.Now()
+	res := func(v int64) *resources.Resource {
+		return resources.NewResourceFromMap(map[string]resources.Quantity{"first": resources.Quantity(v)})
+	}
+	alloc1 := newAllocationRuntime("alloc-1", "app-1", "node-1", res(3), "10m")
+	alloc1.SetBindTime(now.Add(-5 * time.Minute))
+	alloc2 := newAllocationRuntime("alloc-2", "app-1", "node-1", res(2), "30m")
+	alloc2.SetBindTime(now)
+	alloc3 := newAllocationRuntime("alloc-3", "app-1", "node-1", res(4), "")
+	alloc3.SetBindTime(now)
+	allocs := []*Allocation{alloc3, alloc2, alloc1}
+
+	tests := []struct {
+		name     string
+		request  int64
+		expected time.Time
+		ok       bool
+	}{
+		{"fits now", 1, now, true},
+		{"first release", 5, now.Add(5 * time.Minute), true},
+		{"second release", 7, now.Add(30 * time.Minute), true},
+		{"no runtime", 8, time.Time{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ready, ok := estimateReadyTime(res(2), allocs, res(tt.request), now)
+			assert.Equal(t, ok, tt.ok, "unexpected estimate result")
+			assert.Assert(t, ready.Equal(tt.expected), "unexpected ready time: %v", ready)
+		})
+	}
+
+	// an allocation past its expected runtime is expected to end now
+	alloc1.SetBindTime(now.Add(-time.Hour))
+	ready, ok := estimateReadyTime(res(2), allocs, res(5), now)
+	assert.Assert(t, ok, "ready time should be estimated")
+	assert.Assert(t, ready.Equal(now), "overdue allocation should be ready now: %v", ready)
+}
+
+func TestNodeBackfill(t *testing.T) {
+	res := func(v int64) *resources.Resource {
+		return resources.NewResourceFromMap(map[string]resources.Quantity{"first": resources.Quantity(v)})
+	}
+	node := newNodeRes("node-1", res(10))
+	running := newAllocationRuntime("running", "app-1", "node-1", res(6), "10m")
+	node.AddAllocation(running)
+	app := newApplication("app-1", "default", "root.unknown")
+	large := newAllocationAsk("large", "app-1", res(8))
+	now := time.Now()
+
+	// not reserved: no backfill
+	assert.Assert(t, !node.preBackfillCheck(newAllocationRuntime("short", "app-2", "", res(2), "5m"), now), "unreserved node should not backfill")
+	_, ok := node.getReservationReadyTime(now)
+	assert.Assert(t, !ok, "unreserved node should not have a ready time")
+
+	assert.NilError(t, node.Reserve(app, large), "reservation failed")
+	ready, ok := node.getReservationReadyTime(now)
+	assert.Assert(t, ok, "reserved node should have a ready time")
+	assert.Assert(t, ready.After(now.Add(9*time.Minute)) && !ready.After(now.Add(10*time.Minute)), "unexpected ready time: %v", ready)
+
+	assert.Assert(t, node.preBackfillCheck(newAllocationRuntime("short", "app-2", "", res(2), "5m"), now), "short ask should backfill")
+	assert.Assert(t, !node.preBackfillCheck(newAllocationRuntime("long", "app-2", "", res(2), "20m"), now), "long ask should not backfill")
+	assert.Assert(t, !node.preBackfillCheck(newAllocationRuntime("unknown", "app-2", "", res(2), ""), now), "ask without runtime should not backfill")
+	assert.Assert(t, !node.preBackfillCheck(newAllocationRuntime("large", "app-2", "", res(5), "5m"), now), "ask that does not fit should not backfill")
+
+	// a running allocation without runtime makes the ready time unknown
+	node.AddAllocation(newAllocationRuntime("forever", "app-1", "node-1", res(1), ""))
+	assert.Assert(t, node.preBackfillCheck(newAllocationRuntime("short", "app-2", "", res(2), "5m"), now), "short ask should still backfill")
+	node.AddAllocation(newAllocationRuntime("forever-2", "app-1", "node-1", res(2), ""))
+	assert.Assert(t, !node.preBackfillCheck(newAllocationRuntime("short", "app-2", "", res(1), "5m"), now), "unknown ready time should not backfill")
+}
+
+func TestTryAllocateBackfill(t *testing.T) {
+	setupUGM()
+	res := func(v int64) *resources.Resource {
+		return resources.NewResourceFromMap(map[string]resources.Quantity{"first": resources.Quantity(v)})
+	}
+	node := newNodeRes("node-1", res(10))
+	node.AddAllocation(newAllocationRuntime("running", "app-1", "node-1", res(6), "10m"))
+	iterator := getNodeIteratorFn(node)
+	getNode := func(string) *Node { return node }
+	root, err := createRootQueue(map[string]string{"first": "10"})
+	assert.NilError(t, err, "queue create failed")
+	queue, err := createManagedQueue(root, "leaf", false, nil)
+	assert.NilError(t, err, "queue create failed")
+
+	reserver := newApplication("app-1", "default", "root.leaf")
+	reserver.SetQueue(queue)
+	queue.AddApplication(reserver)
+	assert.NilError(t, node.Reserve(reserver, newAllocationAsk("large", "app-1", res(8))), "reservation failed")
+
+	app := newApplication("app-2", "default", "root.leaf")
+	app.SetQueue(queue)
+	queue.AddApplication(app)
+	assert.NilError(t, app.AddAllocationAsk(newAllocationRuntime("long", "app-2", "", res(2), "1h")))
+	preemptionAttemptsRemaining := 0
+	result := app.tryAllocate(res(10), false, 0, &preemptionAttemptsRemaining, iterator, iterator, getNode)
+	assert.Assert(t, result == nil, "long running ask should not be backfilled")
+
+	assert.NilError(t, app.AddAllocationAsk(newAllocationRuntime("short", "app-2", "", res(2), "5m")))
+	result = app.tryAllocate(res(10), false, 0, &preemptionAttemptsRemaining, iterator, iterator, getNode)
+	assert.Assert(t, result != nil, "short ask should be backfilled")
+	assert.Equal(t, result.ResultType, Allocated)
+	assert.Equal(t, result.Request.GetAllocationKey(), "short")
+	assert.Equal(t, result.NodeID, "node-1")
+	assert.Assert(t, node.IsReserved(), "reservation should be kept")
+	assert.Assert(t, resources.Equals(node.GetAllocatedResource(), res(8)), "backfilled allocation not on node")
+}
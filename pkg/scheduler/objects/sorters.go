@@ -25,6 +25,7 @@ import (
 	"github.com/apache/yunikorn-core/pkg/common/resources"
 	"github.com/apache/yunikorn-core/pkg/metrics"
 	"github.com/apache/yunikorn-core/pkg/scheduler/policies"
+	"github.com/apache/yunikorn-core/pkg/scheduler/ugm"
 )
 
 func sortQueue(queues []*Queue, fairMaxResources []*resources.Resource, sortType policies.SortPolicy, considerPriority bool) {
@@ -70,7 +71,7 @@ func sortQueuesByPriorityAndFairness(queues []*Queue, fairMaxResources []*resour
 			r.GetAllocatedResource(), r.GetGuaranteedResource(), fairMaxResources[j])
 
 		if comp == 0 {
-			return resources.StrictlyGreaterThan(resources.Sub(l.GetPendingResource(), r.GetPendingResource()), resources.Zero)
+			return compareWeightAndPending(l, r)
 		}
 		return comp < 0
 	})
@@ -92,12 +93,93 @@ func sortQueuesByFairnessAndPriority(queues []*Queue, fairMaxResources []*resour
 			if lPriority < rPriority {
 				return false
 			}
-			return resources.StrictlyGreaterThan(resources.Sub(l.GetPendingResource(), r.GetPendingResource()), resources.Zero)
+			return compareWeightAndPending(l, r)
 		}
 		return comp < 0
 	})
 }
 
+// compareWeightAndPending breaks the tie between two queues with the same fair share: the queue with the
+// larger weight goes first, for equal weights the queue with the larger pending resource goes first.
+func compareWeightAndPending(l, r *Queue) bool {
+	lWeight := l.GetWeight()
+	rWeight := r.GetWeight()
+	if lWeight != rWeight {
+		return lWeight > rWeight
+	}
+	return resources.StrictlyGreaterThan(resources.Sub(l.GetPendingResource(), r.GetPendingResource()), resources.Zero)
+}
+
+// sortQueuesByDominantShare sorts the queues using dominant resource fairness: the queue with the smallest
+// dominant share of the partition capacity, using the resource weights and divided by the queue weight, is
+// scheduled first.
+func sortQueuesByDominantShare(queues []*Queue, capacity *resources.Resource, weights map[string]float64, considerPriority bool) {
+	sortingStart := time.Now()
+	shares := make(map[*Queue]float64, len(queues))
+	for _, queue := range queues {
+		shares[queue] = resources.DominantShare(queue.GetAllocatedResource(), capacity, weights) / queue.GetWeight()
+	}
+	sort.SliceStable(queues, func(i, j int) bool {
+		l := queues[i]
+		r := queues[j]
+		if considerPriority {
+			lPriority := l.GetCurrentPriority()
+			rPriority := r.GetCurrentPriority()
+			if lPriority != rPriority {
+				return lPriority > rPriority
+			}
+		}
+		if shares[l] != shares[r] {
+			return shares[l] < shares[r]
+		}
+		if !considerPriority {
+			lPriority := l.GetCurrentPriority()
+			rPriority := r.GetCurrentPriority()
+			if lPriority != rPriority {
+				return lPriority > rPriority
+			}
+		}
+		return compareWeightAndPending(l, r)
+	})
+	metrics.GetSchedulerMetrics().ObserveQueueSortingLatency(sortingStart)
+}
+
+// sortQueuesByUsageHistory sorts the queues using the decayed usage history: the queue with the smallest
+// share of historic usage of the partition capacity, divided by the queue weight, is scheduled first.
+// Queues with the same historic share fall back to the fair sorting on the current usage.
+func sortQueuesByUsageHistory(queues []*Queue, fairMaxResources []*resources.Resource, capacity *resources.Resource, considerPriority bool) {
+	sortingStart := time.Now()
+	userManager := ugm.GetUserManager()
+	shares := make(map[*Queue]float64, len(queues))
+	fairMax := make(map[*Queue]*resources.Resource, len(queues))
+	for i, queue := range queues {
+		shares[queue] = ugm.UsageShare(userManager.GetQueueUsageHistory(queue.GetQueuePath()), capacity) / queue.GetWeight()
+		fairMax[queue] = fairMaxResources[i]
+	}
+	sort.SliceStable(queues, func(i, j int) bool {
+		l := queues[i]
+		r := queues[j]
+		lPriority := l.GetCurrentPriority()
+		rPriority := r.GetCurrentPriority()
+		if considerPriority && lPriority != rPriority {
+			return lPriority > rPriority
+		}
+		if shares[l] != shares[r] {
+			return shares[l] < shares[r]
+		}
+		comp := resources.CompUsageRatioSeparately(l.GetAllocatedResource(), l.GetGuaranteedResource(), fairMax[l],
+			r.GetAllocatedResource(), r.GetGuaranteedResource(), fairMax[r])
+		if comp != 0 {
+			return comp < 0
+		}
+		if lPriority != rPriority {
+			return lPriority > rPriority
+		}
+		return compareWeightAndPending(l, r)
+	})
+	metrics.GetSchedulerMetrics().ObserveQueueSortingLatency(sortingStart)
+}
+
 func sortApplications(apps map[string]*Application, sortType policies.SortPolicy, considerPriority bool, globalResource *resources.Resource) []*Application {
 	sortingStart := time.Now()
 	sortedApps := filterOnPendingResources(apps)
@@ -119,6 +201,106 @@ func sortApplications(apps map[string]*Application, sortType policies.SortPolicy
 	return sortedApps
 }
 
+// sortApplicationsByDominantShare returns the applications with pending resources sorted using dominant resource
+// fairness: the application with the smallest weighted dominant share of the partition capacity is scheduled first.
+func sortApplicationsByDominantShare(apps map[string]*Application, capacity *resources.Resource, weights map[string]float64, considerPriority bool) []*Application {
+	sortingStart := time.Now()
+	sortedApps := filterOnPendingResources(apps)
+	sort.SliceStable(sortedApps, func(i, j int) bool {
+		l := sortedApps[i]
+		r := sortedApps[j]
+		leftPriority := l.GetPriority()
+		rightPriority := r.GetPriority()
+		if considerPriority && leftPriority != rightPriority {
+			return leftPriority > rightPriority
+		}
+		if comp := resources.CompDominantShare(l.GetAllocatedResource(), r.GetAllocatedResource(), capacity, weights); comp != 0 {
+			return comp < 0
+		}
+		if leftPriority != rightPriority {
+			return leftPriority > rightPriority
+		}
+		return l.SubmissionTime.Before(r.SubmissionTime)
+	})
+	metrics.GetSchedulerMetrics().ObserveAppSortingLatency(sortingStart)
+	return sortedApps
+}
+
+// sortApplicationsByUsageHistory returns the applications with pending resources sorted using the decayed usage
+// history of the groups and users: the application of the group with the smallest share of historic usage of the
+// partition capacity is scheduled first, within a group the application of the user with the smallest share.
+// An application that is not tracked against a group uses the share of its user for the group level. Applications
+// of the same user, or of users with the same historic shares, fall back to the fair sorting on the current usage.
+func sortApplicationsByUsageHistory(apps map[string]*Application, capacity, globalResource *resources.Resource, considerPriority bool) []*Application {
+	sortingStart := time.Now()
+	sortedApps := filterOnPendingResources(apps)
+	userManager := ugm.GetUserManager()
+	userShares := make(map[string]float64)
+	groupShares := make(map[string]float64)
+	appGroupShares := make(map[string]float64, len(sortedApps))
+	for _, app := range sortedApps {
+		user := app.GetUser().User
+		userShare, ok := userShares[user]
+		if !ok {
+			userShare = ugm.UsageShare(userManager.GetUserUsageHistory(user), capacity)
+			userShares[user] = userShare
+		}
+		group := userManager.GetApplicationGroup(user, app.ApplicationID)
+		if group == "" {
+			appGroupShares[app.ApplicationID] = userShare
+			continue
+		}
+		groupShare, ok := groupShares[group]
+		if !ok {
+			groupShare = ugm.UsageShare(userManager.GetGroupUsageHistory(group), capacity)
+			groupShares[group] = groupShare
+		}
+		appGroupShares[app.ApplicationID] = groupShare
+	}
+	sort.SliceStable(sortedApps, func(i, j int) bool {
+		l := sortedApps[i]
+		r := sortedApps[j]
+		leftPriority := l.GetPriority()
+		rightPriority := r.GetPriority()
+		if considerPriority && leftPriority != rightPriority {
+			return leftPriority > rightPriority
+		}
+		lShare := appGroupShares[l.ApplicationID]
+		rShare := appGroupShares[r.ApplicationID]
+		if lShare != rShare {
+			return lShare < rShare
+		}
+		lShare = userShares[l.GetUser().User]
+		rShare = userShares[r.GetUser().User]
+		if lShare != rShare {
+			return lShare < rShare
+		}
+		if comp := resources.CompUsageRatio(l.GetAllocatedResource(), r.GetAllocatedResource(), globalResource); comp != 0 {
+			return comp < 0
+		}
+		if leftPriority != rightPriority {
+			return leftPriority > rightPriority
+		}
+		return l.SubmissionTime.Before(r.SubmissionTime)
+	})
+	metrics.GetSchedulerMetrics().ObserveAppSortingLatency(sortingStart)
+	return sortedApps
+}
+
+// sortApplicationsByUserGuarantee moves the applications of users or groups that use less than their guaranteed
+// resources in front of the other applications. The order within both sets is not changed.
+func sortApplicationsByUserGuarantee(sortedApps []*Application) []*Application {
+	check := ugm.GetUserManager().NewGuaranteeCheck()
+	below := make(map[string]bool, len(sortedApps))
+	for _, app := range sortedApps {
+		below[app.ApplicationID] = check.IsBelowGuarantee(app.GetQueuePath(), app.ApplicationID, app.GetUser())
+	}
+	sort.SliceStable(sortedApps, func(i, j int) bool {
+		return below[sortedApps[i].ApplicationID] && !below[sortedApps[j].ApplicationID]
+	})
+	return sortedApps
+}
+
 func sortApplicationsByFairnessAndPriority(sortedApps []*Application, globalResource *resources.Resource) {
 	sort.SliceStable(sortedApps, func(i, j int) bool {
 		l := sortedApps[i]
@@ -126,7 +308,7 @@ func sortApplicationsByFairnessAndPriority(sortedApps []*Application, globalReso
 		if comp := resources.CompUsageRatio(l.GetAllocatedResource(), r.GetAllocatedResource(), globalResource); comp != 0 {
 			return comp < 0
 		}
-		return l.GetAskMaxPriority() > r.GetAskMaxPriority()
+		return l.GetPriority() > r.GetPriority()
 	})
 }
 
@@ -134,8 +316,8 @@ func sortApplicationsByPriorityAndFairness(sortedApps []*Application, globalReso
 	sort.SliceStable(sortedApps, func(i, j int) bool {
 		l := sortedApps[i]
 		r := sortedApps[j]
-		leftPriority := l.GetAskMaxPriority()
-		rightPriority := r.GetAskMaxPriority()
+		leftPriority := l.GetPriority()
+		rightPriority := r.GetPriority()
 		if leftPriority > rightPriority {
 			return true
 		}
@@ -156,7 +338,7 @@ func sortApplicationsBySubmissionTimeAndPriority(sortedApps []*Application) {
 		if r.SubmissionTime.Before(l.SubmissionTime) {
 			return false
 		}
-		return l.GetAskMaxPriority() > r.GetAskMaxPriority()
+		return l.GetPriority() > r.GetPriority()
 	})
 }
 
@@ -164,8 +346,8 @@ func sortApplicationsByPriorityAndSubmissionTime(sortedApps []*Application) {
 	sort.SliceStable(sortedApps, func(i, j int) bool {
 		l := sortedApps[i]
 		r := sortedApps[j]
-		leftPriority := l.GetAskMaxPriority()
-		rightPriority := r.GetAskMaxPriority()
+		leftPriority := l.GetPriority()
+		rightPriority := r.GetPriority()
 		if leftPriority > rightPriority {
 			return true
 		}
@@ -26,8 +26,11 @@ import (
 
 	"gotest.tools/v3/assert"
 
+	"github.com/apache/yunikorn-core/pkg/common/configs"
 	"github.com/apache/yunikorn-core/pkg/common/resources"
+	"github.com/apache/yunikorn-core/pkg/common/security"
 	"github.com/apache/yunikorn-core/pkg/scheduler/policies"
+	"github.com/apache/yunikorn-core/pkg/scheduler/ugm"
 )
 
 // verify queue ordering is working when explicity guarantees are provided
@@ -381,6 +384,273 @@ func queueNames(list []*Queue) string {
 
 // list of application and the location of the named applications inside that list
 // place[0] defines the location of the app-0 in the list of applications
+func TestSortAppsDRF(t *testing.T) {
+	capacity := resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 100, "memory": 1000, "gpu": 10})
+	pending := resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 1})
+	allocated := []map[string]resources.Quantity{
+		{"vcore": 30, "memory": 100},          // dominant vcore 0.3
+		{"vcore": 5, "memory": 100, "gpu": 2}, // dominant gpu 0.2
+		{"vcore": 10, "memory": 400},          // dominant memory 0.4
+		{"vcore": 10, "memory": 100},          // dominant vcore 0.1
+	}
+	input := make(map[string]*Application, 4)
+	for i := 0; i < 4; i++ {
+		appID := "app-" + strconv.Itoa(i)
+		app := newApplication(appID, "partition", "queue")
+		app.allocatedResource = resources.NewResourceFromMap(allocated[i])
+		app.pending = pending
+		input[appID] = app
+	}
+	// apps should come back in order: 3, 1, 0, 2
+	list := sortApplicationsByDominantShare(input, capacity, nil, false)
+	assertAppList(t, list, []int{2, 1, 3, 0}, "drf no weights")
+
+	// a gpu counts double: app-1 has a dominant share of 0.4, same as app-2: order is 3, 0, 1, 2 or 3, 0, 2, 1
+	input["app-2"].askMaxPriority = 5
+	list = sortApplicationsByDominantShare(input, capacity, map[string]float64{"gpu": 2}, false)
+	assertAppList(t, list, []int{1, 3, 2, 0}, "drf gpu weight, priority on equal share")
+
+	// priority first moves app-2 to the front
+	list = sortApplicationsByDominantShare(input, capacity, nil, true)
+	assertAppList(t, list, []int{3, 2, 0, 1}, "drf with priority")
+
+	// apps without pending resources are filtered
+	input["app-0"].pending = resources.NewResource()
+	list = sortApplicationsByDominantShare(input, capacity, nil, false)
+	assertAppListLength(t, list, []string{"app-3", "app-1", "app-2"}, "drf filter pending")
+}
+
+func TestSortQueuesDRF(t *testing.T) {
+	root, err := createRootQueue(nil)
+	assert.NilError(t, err, "queue create failed")
+	capacity := resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 100, "memory": 1000, "gpu": 10})
+	pending := resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 1})
+	var q0, q1, q2 *Queue
+	q0, err = createManagedQueue(root, "q0", false, nil)
+	assert.NilError(t, err, "failed to create leaf queue")
+	q0.allocatedResource = resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 30, "memory": 100})
+	q0.pending = pending
+	q1, err = createManagedQueue(root, "q1", false, nil)
+	assert.NilError(t, err, "failed to create leaf queue")
+	q1.allocatedResource = resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 5, "gpu": 2})
+	q1.pending = pending
+	q2, err = createManagedQueue(root, "q2", false, nil)
+	assert.NilError(t, err, "failed to create leaf queue")
+	q2.allocatedResource = resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 10, "memory": 400})
+	q2.pending = pending
+	q2.currentPriority = 1
+
+	queues := []*Queue{q0, q1, q2}
+	sortQueuesByDominantShare(queues, capacity, nil, false)
+	assert.Equal(t, queueNames(queues), queueNames([]*Queue{q1, q0, q2}), "drf no weights")
+
+	queues = []*Queue{q0, q1, q2}
+	sortQueuesByDominantShare(queues, capacity, map[string]float64{"gpu": 2, "memory": 0}, false)
+	assert.Equal(t, queueNames(queues), queueNames([]*Queue{q2, q0, q1}), "drf gpu weight, memory ignored")
+
+	queues = []*Queue{q0, q1, q2}
+	sortQueuesByDominantShare(queues, capacity, nil, true)
+	assert.Equal(t, queueNames(queues), queueNames([]*Queue{q2, q1, q0}), "drf with priority")
+
+	// the queue weight divides the dominant share: 0.3 / 3 for q0 is below 0.2 / 1 for q1 and 0.4 / 1 for q2
+	q0.weight = 3
+	queues = []*Queue{q0, q1, q2}
+	sortQueuesByDominantShare(queues, capacity, nil, false)
+	assert.Equal(t, queueNames(queues), queueNames([]*Queue{q0, q1, q2}), "drf with queue weight")
+
+	// equal weighted shares: 0.4 / 2 for q2 and 0.2 / 1 for q1, the larger weight goes first
+	q2.weight = 2
+	q2.currentPriority = 0
+	queues = []*Queue{q1, q2}
+	sortQueuesByDominantShare(queues, capacity, nil, false)
+	assert.Equal(t, queueNames(queues), queueNames([]*Queue{q2, q1}), "drf with equal weighted shares")
+}
+
+func TestSortAppsUsageHistory(t *testing.T) {
+	setupUGM()
+	userManager := ugm.GetUserManager()
+	configs.SetConfigMap(map[string]string{configs.FairShareHalfLife: "1h"})
+	defer func() {
+		configs.SetConfigMap(map[string]string{})
+		userManager.ClearUsageHistory()
+	}()
+	// the heavy user has used the cluster before
+	userManager.IncreaseTrackedResource("root.queue", "app-history", resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 50}), security.UserGroup{User: "heavy"})
+	time.Sleep(10 * time.Millisecond)
+
+	pending := resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 1})
+	users := []string{"heavy", "light", "light", "heavy"}
+	allocated := []resources.Quantity{0, 30, 10, 5}
+	input := make(map[string]*Application, 4)
+	for i := 0; i < 4; i++ {
+		appID := "app-" + strconv.Itoa(i)
+		app := newApplicationWithUserGroup(appID, "partition", "queue", users[i], nil)
+		app.allocatedResource = resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": allocated[i]})
+		app.pending = pending
+		input[appID] = app
+	}
+	// apps of the light user first, apps of the same user on current usage: 2, 1, 0, 3
+	list := sortApplicationsByUsageHistory(input, nil, nil, false)
+	assertAppList(t, list, []int{2, 1, 0, 3}, "usage history")
+
+	// priority first moves app-3 to the front
+	input["app-3"].askMaxPriority = 5
+	list = sortApplicationsByUsageHistory(input, nil, nil, true)
+	assertAppList(t, list, []int{3, 2, 1, 0}, "usage history with priority")
+
+	// the fair policy of the queue uses the history when enabled
+	leaf, err := createManagedQueue(nil, "root", false, nil)
+	assert.NilError(t, err, "failed to create queue")
+	leaf.sortType = policies.FairSortPolicy
+	for _, app := range input {
+		leaf.AddApplication(app)
+	}
+	input["app-3"].askMaxPriority = input["app-0"].askMaxPriority
+	list = leaf.sortApplications(false)
+	assertAppList(t, list, []int{2, 1, 0, 3}, "queue sort usage history")
+}
+
+func TestSortAppsGroupUsageHistory(t *testing.T) {
+	setupUGM()
+	defer setupUGM()
+	userManager := ugm.GetUserManager()
+	configs.SetConfigMap(map[string]string{configs.FairShareHalfLife: "1h"})
+	defer func() {
+		configs.SetConfigMap(map[string]string{})
+		userManager.ClearUsageHistory()
+	}()
+	conf := configs.QueueConfig{
+		Name:      "root",
+		Parent:    true,
+		SubmitACL: "*",
+		Limits: []configs.Limit{
+			{
+				Limit:           "group limit",
+				Groups:          []string{"heavy", "light"},
+				MaxApplications: 10,
+			},
+		},
+	}
+	assert.NilError(t, userManager.UpdateConfig(conf, "root"))
+	// another member of the heavy group has used the cluster before
+	usage := resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 50})
+	userManager.IncreaseTrackedResource("root.queue", "app-history", usage, security.UserGroup{User: "other", Groups: []string{"heavy"}})
+
+	// the users of both applications have the same history
+	pending := resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 1})
+	groups := []string{"heavy", "light"}
+	allocated := []resources.Quantity{0, 10}
+	input := make(map[string]*Application, 2)
+	for i := 0; i < 2; i++ {
+		appID := "app-" + strconv.Itoa(i)
+		user := security.UserGroup{User: "user-" + strconv.Itoa(i), Groups: []string{groups[i]}}
+		app := newApplicationWithUserGroup(appID, "partition", "root.queue", user.User, user.Groups)
+		app.allocatedResource = resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": allocated[i]})
+		app.pending = pending
+		input[appID] = app
+		userManager.IncreaseTrackedResource("root.queue", appID, pending, user)
+	}
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, userManager.GetApplicationGroup("user-0", "app-0"), "heavy")
+
+	// the application of the light group goes first, despite the higher current usage
+	list := sortApplicationsByUsageHistory(input, nil, nil, false)
+	assert.Equal(t, len(list), 2)
+	assert.Equal(t, list[0].ApplicationID, "app-1", "group usage history not used")
+	assert.Equal(t, list[1].ApplicationID, "app-0", "group usage history not used")
+}
+
+func TestSortAppsUserGuarantee(t *testing.T) {
+	setupUGM()
+	defer setupUGM()
+	defer setGuaranteeConfig(t, nil)
+	root, err := createRootQueue(nil)
+	assert.NilError(t, err, "failed to create root queue")
+	leaf, err := createManagedQueue(root, "leaf", false, nil)
+	assert.NilError(t, err, "failed to create leaf queue")
+	leaf.sortType = policies.FifoSortPolicy
+
+	pending := resources.NewResourceFromMap(map[string]resources.Quantity{"first": 1})
+	users := []string{"heavy", "heavy", "light", "light"}
+	now := time.Now()
+	input := make(map[string]*Application, 4)
+	for i := 0; i < 4; i++ {
+		appID := "app-" + strconv.Itoa(i)
+		app := newApplicationWithUserGroup(appID, "partition", "root.leaf", users[i], nil)
+		app.SubmissionTime = now.Add(time.Duration(i) * time.Second)
+		app.pending = pending
+		input[appID] = app
+		leaf.AddApplication(app)
+	}
+	ugm.GetUserManager().IncreaseTrackedResource("root.leaf", "app-2", pending, security.UserGroup{User: "light"})
+	list := leaf.sortApplications(false)
+	assertAppList(t, list, []int{0, 1, 2, 3}, "fifo without guarantees")
+
+	// the light user is below the guarantee and goes first, order within the users is kept
+	setGuaranteeConfig(t, []configs.Limit{
+		{Users: []string{"light"}, GuaranteedResources: map[string]string{"first": "2"}},
+	})
+	list = leaf.sortApplications(false)
+	assertAppList(t, list, []int{2, 3, 0, 1}, "fifo light user below guarantee")
+
+	// guarantee reached: back to the policy order
+	ugm.GetUserManager().IncreaseTrackedResource("root.leaf", "app-3", pending, security.UserGroup{User: "light"})
+	list = leaf.sortApplications(false)
+	assertAppList(t, list, []int{0, 1, 2, 3}, "fifo light user at guarantee")
+}
+
+func TestSortQueuesUsageHistory(t *testing.T) {
+	setupUGM()
+	userManager := ugm.GetUserManager()
+	configs.SetConfigMap(map[string]string{configs.FairShareHalfLife: "1h"})
+	defer func() {
+		configs.SetConfigMap(map[string]string{})
+		userManager.ClearUsageHistory()
+	}()
+	root, err := createRootQueue(nil)
+	assert.NilError(t, err, "queue create failed")
+	pending := resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 1})
+	guaranteed := resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 100})
+	var q0, q1, q2 *Queue
+	q0, err = createManagedQueue(root, "q0", false, nil)
+	assert.NilError(t, err, "failed to create leaf queue")
+	q0.pending = pending
+	q1, err = createManagedQueue(root, "q1", false, nil)
+	assert.NilError(t, err, "failed to create leaf queue")
+	q1.allocatedResource = resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 50})
+	q1.guaranteedResource = guaranteed
+	q1.pending = pending
+	q2, err = createManagedQueue(root, "q2", false, nil)
+	assert.NilError(t, err, "failed to create leaf queue")
+	q2.allocatedResource = resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 10})
+	q2.guaranteedResource = guaranteed
+	q2.pending = pending
+
+	// q0 has no current usage but used the cluster before
+	userManager.IncreaseTrackedResource("root.q0", "app-history", resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 50}), security.UserGroup{User: "testuser"})
+	time.Sleep(10 * time.Millisecond)
+	assert.Equal(t, queueNames(root.sortQueues()), queueNames([]*Queue{q2, q1, q0}), "usage history")
+
+	// q2 uses more than q0 did
+	userManager.IncreaseTrackedResource("root.q2", "app-history-2", resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 500}), security.UserGroup{User: "testuser"})
+	time.Sleep(10 * time.Millisecond)
+	queues := []*Queue{q0, q1, q2}
+	sortQueuesByUsageHistory(queues, []*resources.Resource{nil, nil, nil}, nil, false)
+	assert.Equal(t, queueNames(queues), queueNames([]*Queue{q1, q0, q2}), "usage history more usage")
+
+	// a large weight reduces the share of the history
+	q2.weight = 1e12
+	queues = []*Queue{q0, q1, q2}
+	sortQueuesByUsageHistory(queues, []*resources.Resource{nil, nil, nil}, nil, false)
+	assert.Equal(t, queueNames(queues), queueNames([]*Queue{q1, q2, q0}), "usage history with weight")
+
+	// priority first
+	q0.currentPriority = 1
+	queues = []*Queue{q0, q1, q2}
+	sortQueuesByUsageHistory(queues, []*resources.Resource{nil, nil, nil}, nil, true)
+	assert.Equal(t, queueNames(queues), queueNames([]*Queue{q0, q1, q2}), "usage history with priority")
+}
+
 func assertAppList(t *testing.T, list []*Application, place []int, name string) {
 	assert.Equal(t, "app-0", list[place[0]].ApplicationID, "test name: %s", name)
 	assert.Equal(t, "app-1", list[place[1]].ApplicationID, "test name: %s", name)
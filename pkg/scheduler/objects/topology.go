@@ -0,0 +1,280 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package objects
+
+import (
+	"fmt"
+	"sort"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-core/pkg/log"
+	siCommon "github.com/apache/yunikorn-scheduler-interface/lib/go/common"
+)
+
+// Default node attributes used to build the topology
+const (
+	DefaultTopologyZoneKey = "topology.kubernetes.io/zone"
+	DefaultTopologyRackKey = siCommon.RackName
+)
+
+// Allocation tags to constrain the placement of the allocations of an application within the topology.
+// The value is the topology level: "zone" or "rack".
+//   - pack: all allocations of the application are placed in the same domain as the existing allocations
+//   - spread: allocations are placed in the domain with the least allocations of the application first
+const (
+	AllocTagTopologyPack   = "topology.pack"
+	AllocTagTopologySpread = "topology.spread"
+
+	TopologyZone = "zone"
+	TopologyRack = "rack"
+)
+
+// topology tracks the nodes per zone and rack: zone -> rack -> node. Nodes without the attribute are tracked in the
+// empty domain. Not locked: protected by the node collection lock.
+type topology struct {
+	zoneKey string
+	rackKey string
+	zones   map[string]map[string]map[string]*Node
+}
+
+func newTopology(zoneKey, rackKey string) *topology {
+	if zoneKey == "" {
+		zoneKey = DefaultTopologyZoneKey
+	}
+	if rackKey == "" {
+		rackKey = DefaultTopologyRackKey
+	}
+	return &topology{
+		zoneKey: zoneKey,
+		rackKey: rackKey,
+		zones:   make(map[string]map[string]map[string]*Node),
+	}
+}
+
+func (t *topology) addNode(node *Node) {
+	zone := node.GetAttribute(t.zoneKey)
+	rack := node.GetAttribute(t.rackKey)
+	node.setTopology(zone, rack)
+	racks, ok := t.zones[zone]
+	if !ok {
+		racks = make(map[string]map[string]*Node)
+		t.zones[zone] = racks
+	}
+	nodes, ok := racks[rack]
+	if !ok {
+		nodes = make(map[string]*Node)
+		racks[rack] = nodes
+	}
+	nodes[node.NodeID] = node
+}
+
+func (t *topology) removeNode(node *Node) {
+	zone, rack := node.GetZone(), node.GetRack()
+	racks := t.zones[zone]
+	if racks == nil {
+		return
+	}
+	delete(racks[rack], node.NodeID)
+	if len(racks[rack]) == 0 {
+		delete(racks, rack)
+	}
+	if len(racks) == 0 {
+		delete(t.zones, zone)
+	}
+}
+
+// updateNode moves the node to its new domain if the zone or rack attribute of the node changed.
+func (t *topology) updateNode(node *Node) {
+	if node.GetAttribute(t.zoneKey) == node.GetZone() && node.GetAttribute(t.rackKey) == node.GetRack() {
+		return
+	}
+	t.removeNode(node)
+	t.addNode(node)
+}
+
+// domains returns the zone -> rack -> sorted node IDs view of the topology.
+func (t *topology) domains() map[string]map[string][]string {
+	result := make(map[string]map[string][]string, len(t.zones))
+	for zone, racks := range t.zones {
+		result[zone] = make(map[string][]string, len(racks))
+		for rack, nodes := range racks {
+			ids := make([]string, 0, len(nodes))
+			for id := range nodes {
+				ids = append(ids, id)
+			}
+			sort.Strings(ids)
+			result[zone][rack] = ids
+		}
+	}
+	return result
+}
+
+func (sn *Node) setTopology(zone, rack string) {
+	sn.Lock()
+	defer sn.Unlock()
+	sn.zone = zone
+	sn.rack = rack
+}
+
+// GetZone returns the zone of the node as set by the node collection.
+func (sn *Node) GetZone() string {
+	sn.RLock()
+	defer sn.RUnlock()
+	return sn.zone
+}
+
+// GetRack returns the rack of the node as set by the node collection. A rack is scoped to a zone.
+func (sn *Node) GetRack() string {
+	sn.RLock()
+	defer sn.RUnlock()
+	return sn.rack
+}
+
+// getTopologyDomain returns the domain of the node at the level: the zone or the zone scoped rack.
+func (sn *Node) getTopologyDomain(level string) string {
+	sn.RLock()
+	defer sn.RUnlock()
+	if level == TopologyZone {
+		return sn.zone
+	}
+	return sn.zone + "/" + sn.rack
+}
+
+// TopologyConstraint is the topology placement requested by an ask.
+type TopologyConstraint struct {
+	Pack  bool   // pack into one domain, spread across domains if false
+	Level string // zone or rack
+}
+
+// ParseTopologyConstraint builds the constraint from the allocation tags. Returns nil if there is no constraint.
+func ParseTopologyConstraint(tags map[string]string) (*TopologyConstraint, error) {
+	pack, hasPack := tags[AllocTagTopologyPack]
+	spread, hasSpread := tags[AllocTagTopologySpread]
+	if hasPack && hasSpread {
+		return nil, fmt.Errorf("%s and %s cannot be combined", AllocTagTopologyPack, AllocTagTopologySpread)
+	}
+	level := pack
+	if hasSpread {
+		level = spread
+	} else if !hasPack {
+		return nil, nil
+	}
+	if level != TopologyZone && level != TopologyRack {
+		return nil, fmt.Errorf("unknown topology level: %s", level)
+	}
+	return &TopologyConstraint{Pack: hasPack, Level: level}, nil
+}
+
+// topologyNodeIterator applies the topology constraint of an ask to the wrapped iterator.
+// Pack: only nodes in the domain holding most allocations of the application are returned, all nodes if the
+// application has no allocations yet.
+// Spread: nodes are returned by the number of allocations of the application in their domain, fewest first.
+type topologyNodeIterator struct {
+	iterator   NodeIterator
+	constraint *TopologyConstraint
+	counts     map[string]int
+}
+
+// newTopologyNodeIterator wraps the iterator if the ask has a topology constraint.
+// NOTE: this is a lock free call. It must only be called holding the application lock.
+func (sa *Application) newTopologyNodeIterator(ask *Allocation, iterator NodeIterator, getNodeFn func(string) *Node) NodeIterator {
+	constraint := ask.GetTopologyConstraint()
+	if constraint == nil {
+		return iterator
+	}
+	return &topologyNodeIterator{iterator: iterator, constraint: constraint, counts: sa.topologyCounts(constraint.Level, getNodeFn)}
+}
+
+// topologyCounts returns the number of allocations of the application per domain of the topology level.
+// NOTE: this is a lock free call. It must only be called holding the application lock.
+func (sa *Application) topologyCounts(level string, getNodeFn func(string) *Node) map[string]int {
+	counts := make(map[string]int)
+	for _, alloc := range sa.allocations {
+		if node := getNodeFn(alloc.GetNodeID()); node != nil {
+			counts[node.getTopologyDomain(level)]++
+		}
+	}
+	return counts
+}
+
+// packedDomain returns the domain holding most allocations, the lowest name wins a tie.
+func packedDomain(counts map[string]int) string {
+	packed := ""
+	for domain, count := range counts {
+		if count > counts[packed] || (count == counts[packed] && domain < packed) {
+			packed = domain
+		}
+	}
+	return packed
+}
+
+// orderNodes applies the constraint to the list of nodes: the nodes outside the packed domain are removed or the
+// nodes are sorted by the allocations in their domain. Returns a new list, the passed in list is not changed.
+func (tc *TopologyConstraint) orderNodes(nodes []*Node, counts map[string]int) []*Node {
+	if tc.Pack {
+		if len(counts) == 0 {
+			return nodes
+		}
+		packed := packedDomain(counts)
+		var result []*Node
+		for _, node := range nodes {
+			if node.getTopologyDomain(tc.Level) == packed {
+				result = append(result, node)
+			}
+		}
+		return result
+	}
+	result := make([]*Node, len(nodes))
+	copy(result, nodes)
+	sort.SliceStable(result, func(i, j int) bool {
+		return counts[result[i].getTopologyDomain(tc.Level)] < counts[result[j].getTopologyDomain(tc.Level)]
+	})
+	return result
+}
+
+func (ti *topologyNodeIterator) ForEachNode(f func(*Node) bool) {
+	if ti.constraint.Pack {
+		if len(ti.counts) == 0 {
+			ti.iterator.ForEachNode(f)
+			return
+		}
+		packed := packedDomain(ti.counts)
+		log.Log(log.SchedApplication).Debug("packing allocation into topology domain",
+			zap.String("level", ti.constraint.Level),
+			zap.String("domain", packed))
+		ti.iterator.ForEachNode(func(node *Node) bool {
+			if node.getTopologyDomain(ti.constraint.Level) != packed {
+				return true
+			}
+			return f(node)
+		})
+		return
+	}
+	var nodes []*Node
+	ti.iterator.ForEachNode(func(node *Node) bool {
+		nodes = append(nodes, node)
+		return true
+	})
+	for _, node := range ti.constraint.orderNodes(nodes, ti.counts) {
+		if !f(node) {
+			return
+		}
+	}
+}
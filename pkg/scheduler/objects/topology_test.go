@@ -0,0 +1,177 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package objects
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+)
+
+func TestParseTopologyConstraint(t *testing.T) {
+	tests := []struct {
+		name     string
+		tags     map[string]string
+		valid    bool
+		expected *TopologyConstraint
+	}{
+		{"no tags", nil, true, nil},
+		{"pack rack", map[string]string{AllocTagTopologyPack: TopologyRack}, true, &TopologyConstraint{Pack: true, Level: TopologyRack}},
+		{"spread zone", map[string]string{AllocTagTopologySpread: TopologyZone}, true, &TopologyConstraint{Pack: false, Level: TopologyZone}},
+		{"unknown level", map[string]string{AllocTagTopologySpread: "region"}, false, nil},
+		{"pack and spread", map[string]string{AllocTagTopologyPack: TopologyRack, AllocTagTopologySpread: TopologyZone}, false, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			constraint, err := ParseTopologyConstraint(tt.tags)
+			if !tt.valid {
+				assert.Assert(t, err != nil, "expected parse error")
+				return
+			}
+			assert.NilError(t, err, "unexpected parse error")
+			assert.DeepEqual(t, constraint, tt.expected)
+		})
+	}
+}
+
+func newTopologyNodes(t *testing.T) NodeCollection {
+	nc := NewNodeCollection("test")
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"first": 10})
+	for _, node := range []struct{ id, zone, rack string }{
+		{"node-1", "a", "r1"},
+		{"node-2", "a", "r1"},
+		{"node-3", "a", "r2"},
+		{"node-4", "b", "r1"},
+	} {
+		err := nc.AddNode(NewNode(newProto(node.id, res, map[string]string{DefaultTopologyZoneKey: node.zone, DefaultTopologyRackKey: node.rack})))
+		assert.NilError(t, err, "failed to add node")
+	}
+	return nc
+}
+
+func TestNodeCollectionTopology(t *testing.T) {
+	nc := newTopologyNodes(t)
+	assert.DeepEqual(t, nc.GetTopology(), map[string]map[string][]string{
+		"a": {"r1": {"node-1", "node-2"}, "r2": {"node-3"}},
+		"b": {"r1": {"node-4"}},
+	})
+	node := nc.GetNode("node-4")
+	assert.Equal(t, node.GetZone(), "b")
+	assert.Equal(t, node.GetRack(), "r1")
+	assert.Equal(t, node.getTopologyDomain(TopologyRack), "b/r1", "rack must be scoped to the zone")
+
+	// attribute update moves the node to the new rack
+	node.UpdateAttributes(map[string]string{DefaultTopologyRackKey: "r2"})
+	assert.DeepEqual(t, nc.GetTopology(), map[string]map[string][]string{
+		"a": {"r1": {"node-1", "node-2"}, "r2": {"node-3"}},
+		"b": {"r2": {"node-4"}},
+	})
+	assert.Equal(t, node.GetRack(), "r2")
+	assert.Equal(t, node.GetAttribute(DefaultTopologyZoneKey), "b", "other attributes should be kept")
+
+	nc.RemoveNode("node-3")
+	nc.RemoveNode("node-4")
+	assert.DeepEqual(t, nc.GetTopology(), map[string]map[string][]string{
+		"a": {"r1": {"node-1", "node-2"}},
+	})
+
+	// different keys: zone attribute is not set on the nodes
+	nc.SetTopologyKeys("custom/zone", "")
+	assert.DeepEqual(t, nc.GetTopology(), map[string]map[string][]string{
+		"": {"r1": {"node-1", "node-2"}},
+	})
+	assert.Equal(t, nc.GetNode("node-1").GetZone(), "")
+}
+
+func TestTryAllocateTopology(t *testing.T) {
+	setupUGM()
+	res := func(v int64) *resources.Resource {
+		return resources.NewResourceFromMap(map[string]resources.Quantity{"first": resources.Quantity(v)})
+	}
+	nc := newTopologyNodes(t)
+	root, err := createRootQueue(map[string]string{"first": "40"})
+	assert.NilError(t, err, "queue create failed")
+	queue, err := createManagedQueue(root, "leaf", false, nil)
+	assert.NilError(t, err, "queue create failed")
+	preemptionAttemptsRemaining := 0
+
+	// pack: follow the existing allocation into rack a/r2
+	app := newApplication(appID1, "default", "root.leaf")
+	app.SetQueue(queue)
+	queue.AddApplication(app)
+	existing := newAllocationAffinity("existing", appID1, "node-3", res(1), nil)
+	nc.GetNode("node-3").AddAllocation(existing)
+	app.AddAllocation(existing)
+	tags := map[string]string{AllocTagTopologyPack: TopologyRack}
+	assert.NilError(t, app.AddAllocationAsk(newAllocationAffinity("pack-1", appID1, "", res(5), tags)), "failed to add ask")
+	result := app.tryAllocate(res(40), false, 0, &preemptionAttemptsRemaining, nc.GetNodeIterator, nc.GetFullNodeIterator, nc.GetNode)
+	assert.Assert(t, result != nil, "ask should be allocated")
+	assert.Equal(t, result.NodeID, "node-3", "ask not packed into the rack")
+	assert.NilError(t, app.AddAllocationAsk(newAllocationAffinity("pack-2", appID1, "", res(5), tags)), "failed to add ask")
+	result = app.tryAllocate(res(40), false, 0, &preemptionAttemptsRemaining, nc.GetNodeIterator, nc.GetFullNodeIterator, nc.GetNode)
+	assert.Assert(t, result == nil || result.ResultType != Allocated, "ask should not be allocated outside the rack")
+
+	// spread: each allocation goes to the zone with the least allocations
+	app2 := newApplication(appID2, "default", "root.leaf")
+	app2.SetQueue(queue)
+	queue.AddApplication(app2)
+	tags = map[string]string{AllocTagTopologySpread: TopologyZone}
+	zones := make(map[string]int)
+	for _, key := range []string{"spread-1", "spread-2"} {
+		assert.NilError(t, app2.AddAllocationAsk(newAllocationAffinity(key, appID2, "", res(1), tags)), "failed to add ask")
+		result = app2.tryAllocate(res(40), false, 0, &preemptionAttemptsRemaining, nc.GetNodeIterator, nc.GetFullNodeIterator, nc.GetNode)
+		assert.Assert(t, result != nil, "ask should be allocated")
+		// the partition sets the node on the allocation
+		result.Request.SetNodeID(result.NodeID)
+		zones[nc.GetNode(result.NodeID).GetZone()]++
+	}
+	assert.DeepEqual(t, zones, map[string]int{"a": 1, "b": 1})
+}
+
+func TestPlaceGangTopology(t *testing.T) {
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"first": 1})
+	nc := newTopologyNodes(t)
+	app := newApplication(appID1, "default", "root.leaf")
+
+	// spread: the earlier members count as allocations of the application
+	tags := map[string]string{AllocTagTopologySpread: TopologyZone}
+	members := []*Allocation{
+		newAllocationAffinity("member-1", appID1, "", res, tags),
+		newAllocationAffinity("member-2", appID1, "", res, tags),
+	}
+	placement := app.placeGang(members, nc.GetNodeIterator, nc.GetNode)
+	assert.Equal(t, len(placement), 2, "gang should be placed")
+	assert.Assert(t, placement[0].GetZone() != placement[1].GetZone(), "members not spread over the zones")
+
+	// pack: follow the existing allocation into zone b
+	existing := newAllocationAffinity("existing", appID1, "node-4", res, nil)
+	nc.GetNode("node-4").AddAllocation(existing)
+	app.AddAllocation(existing)
+	tags = map[string]string{AllocTagTopologyPack: TopologyZone}
+	members = []*Allocation{
+		newAllocationAffinity("member-3", appID1, "", res, tags),
+		newAllocationAffinity("member-4", appID1, "", res, tags),
+	}
+	placement = app.placeGang(members, nc.GetNodeIterator, nc.GetNode)
+	assert.Equal(t, len(placement), 2, "gang should be placed")
+	assert.Equal(t, placement[0].NodeID, "node-4", "member not packed into the zone")
+	assert.Equal(t, placement[1].NodeID, "node-4", "member not packed into the zone")
+}
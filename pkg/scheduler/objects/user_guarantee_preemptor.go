@@ -0,0 +1,267 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package objects
+
+import (
+	"sort"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-core/pkg/common"
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+	"github.com/apache/yunikorn-core/pkg/log"
+	"github.com/apache/yunikorn-core/pkg/scheduler/policies"
+	"github.com/apache/yunikorn-core/pkg/scheduler/ugm"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+// UserGuaranteePreemptor selects victims for an ask of a user or group that uses less than its guaranteed resources
+// in a queue. The victims are allocations of other users or groups in the same queue, or any of its children, that
+// stay at or above their own guarantee when the victim is removed. A user or group without a guarantee can lose
+// all its allocations.
+// The preemptor itself is not thread safe, and assumes the application lock is held.
+type UserGuaranteePreemptor struct {
+	application *Application        // application containing ask
+	headRoom    *resources.Resource // current queue headroom
+	ask         *Allocation         // ask to be preempted for
+	iterator    NodeIterator        // iterator to enumerate all nodes
+
+	// lazily-populated work structures
+	guarantee   *ugm.Guarantee            // guarantee of the user or group the ask is preempting for
+	allocations []*Allocation             // potential victims in preemption order
+	owners      map[string]*ugm.Guarantee // guarantee of the victim owner by allocationKey
+	queues      map[string]*Queue         // leaf queue of the victim by allocationKey
+}
+
+// NewUserGuaranteePreemptor creates a new preemptor for the ask based on the user and group guarantees.
+func NewUserGuaranteePreemptor(application *Application, headRoom *resources.Resource, ask *Allocation, iterator NodeIterator) *UserGuaranteePreemptor {
+	return &UserGuaranteePreemptor{
+		application: application,
+		headRoom:    headRoom,
+		ask:         ask,
+		iterator:    iterator,
+		owners:      make(map[string]*ugm.Guarantee),
+		queues:      make(map[string]*Queue),
+	}
+}
+
+// findGuarantee finds the guarantee of the user, or group, of the application that the ask fits in.
+// The guarantee closest to the leaf queue is used, user guarantees are preferred over group guarantees.
+func (p *UserGuaranteePreemptor) findGuarantee() bool {
+	for _, g := range ugm.GetUserManager().GetGuarantees(p.application.queuePath, p.application.ApplicationID, p.application.user) {
+		if g.FitsWithin(p.ask.GetAllocatedResource()) {
+			p.guarantee = g
+			return true
+		}
+	}
+	return false
+}
+
+// filterAllocations collects the allocations of the other users or groups below the queue of the guarantee.
+func (p *UserGuaranteePreemptor) filterAllocations() {
+	queue := p.application.queue
+	for queue != nil && queue.QueuePath != p.guarantee.QueuePath {
+		queue = queue.parent
+	}
+	owners := make(map[string]*ugm.Guarantee)
+	p.collectAllocations(queue, owners)
+	sort.SliceStable(p.allocations, func(i, j int) bool {
+		return compareAllocationLess(p.allocations[i], p.allocations[j])
+	})
+}
+
+func (p *UserGuaranteePreemptor) collectAllocations(queue *Queue, owners map[string]*ugm.Guarantee) {
+	if queue == nil {
+		return
+	}
+	if !queue.IsLeafQueue() {
+		for _, child := range queue.GetCopyOfChildren() {
+			p.collectAllocations(child, owners)
+		}
+		return
+	}
+	if queue.GetPreemptionPolicy() == policies.DisabledPreemptionPolicy {
+		return
+	}
+	for _, app := range queue.GetCopyOfApps() {
+		// the lock of the ask application is held: never call into it
+		if app.ApplicationID == p.application.ApplicationID {
+			continue
+		}
+		owner := p.getOwner(app, owners)
+		if owner == nil {
+			continue
+		}
+		for _, alloc := range app.GetAllAllocations() {
+			if !p.ask.GetAllocatedResource().MatchAny(alloc.GetAllocatedResource()) ||
+				alloc.GetRequiredNode() != "" || alloc.IsReleased() || alloc.IsPreempted() ||
+				!alloc.IsAllowPreemptSelf() || alloc.GetPriority() > p.ask.GetPriority() {
+				continue
+			}
+			p.allocations = append(p.allocations, alloc)
+			p.owners[alloc.GetAllocationKey()] = owner
+			p.queues[alloc.GetAllocationKey()] = queue
+		}
+	}
+}
+
+// getOwner returns the guarantee of the user or group that owns the application, nil if the application belongs to
+// the same user or group as the ask.
+func (p *UserGuaranteePreemptor) getOwner(app *Application, owners map[string]*ugm.Guarantee) *ugm.Guarantee {
+	userManager := ugm.GetUserManager()
+	name := app.GetUser().User
+	if p.guarantee.Group {
+		name = userManager.GetApplicationGroup(name, app.ApplicationID)
+	}
+	if name == common.Empty || name == p.guarantee.Name {
+		return nil
+	}
+	if owner, ok := owners[name]; ok {
+		return owner
+	}
+	var owner *ugm.Guarantee
+	if p.guarantee.Group {
+		owner = userManager.GetGroupGuarantee(p.guarantee.QueuePath, name)
+	} else {
+		owner = userManager.GetUserGuarantee(p.guarantee.QueuePath, name)
+	}
+	owners[name] = owner
+	return owner
+}
+
+// findVictims returns the first node with the victims that need to be preempted to fit the ask on that node.
+// Victims in the queue of the ask also increase the headroom of the queue. If the ask does not fit in the headroom
+// only those victims count towards the headroom, victims in other queues only make room on the node.
+func (p *UserGuaranteePreemptor) findVictims() (string, []*Allocation) {
+	byNode := make(map[string][]*Allocation)
+	for _, alloc := range p.allocations {
+		byNode[alloc.GetNodeID()] = append(byNode[alloc.GetNodeID()], alloc)
+	}
+	askResource := p.ask.GetAllocatedResource()
+	var nodeID string
+	var victims []*Allocation
+	p.iterator.ForEachNode(func(node *Node) bool {
+		if !node.IsSchedulable() || (node.IsReserved() && !node.isReservedForAllocation(p.ask.GetAllocationKey())) || !node.FitInNode(askResource) {
+			return true
+		}
+		if candidates, ok := byNode[node.NodeID]; ok {
+			if found := p.selectVictims(node.GetAvailableResource(), candidates); found != nil {
+				nodeID = node.NodeID
+				victims = found
+				return false
+			}
+		}
+		return true
+	})
+	return nodeID, victims
+}
+
+// selectVictims selects victims from the candidates on one node until the ask fits, nil if the ask does not fit
+// after all candidates that can be preempted are selected.
+func (p *UserGuaranteePreemptor) selectVictims(available *resources.Resource, candidates []*Allocation) []*Allocation {
+	askResource := p.ask.GetAllocatedResource()
+	available = available.Clone()
+	headRoom := p.headRoom.Clone()
+	released := make(map[*ugm.Guarantee]*resources.Resource)
+	queueReleased := make(map[*Queue]*resources.Resource)
+	var victims []*Allocation
+	for _, victim := range candidates {
+		if available.FitIn(askResource) && headRoom.FitInMaxUndef(askResource) {
+			break
+		}
+		key := victim.GetAllocationKey()
+		res := victim.GetAllocatedResource()
+		owner := p.owners[key]
+		ownerReleased := resources.Add(released[owner], res)
+		if !owner.KeepsAfterRelease(ownerReleased) {
+			continue
+		}
+		queue := p.queues[key]
+		queueRelease := resources.Add(queueReleased[queue], res)
+		if queue != p.application.queue && !queueKeepsGuarantee(queue, queueRelease) {
+			continue
+		}
+		released[owner] = ownerReleased
+		queueReleased[queue] = queueRelease
+		available.AddTo(res)
+		if queue == p.application.queue && headRoom != nil {
+			headRoom.AddTo(res)
+		}
+		victims = append(victims, victim)
+	}
+	if len(victims) == 0 || !available.FitIn(askResource) || !headRoom.FitInMaxUndef(askResource) {
+		return nil
+	}
+	return victims
+}
+
+// queueKeepsGuarantee returns true if the queue allocation stays at or above the guaranteed resources of the queue
+// after the release of the resources.
+func queueKeepsGuarantee(queue *Queue, released *resources.Resource) bool {
+	guaranteed := queue.GetGuaranteedResource()
+	if guaranteed == nil {
+		return true
+	}
+	remaining := resources.Sub(queue.GetAllocatedResource(), released)
+	for name, quantity := range guaranteed.Resources {
+		if remaining.Resources[name] < quantity {
+			return false
+		}
+	}
+	return true
+}
+
+// TryPreemption preempts the victims and reserves the node for the ask if the user or group of the ask is below
+// its guarantee and victims are found on a node.
+func (p *UserGuaranteePreemptor) TryPreemption() (*AllocationResult, bool) {
+	if !p.findGuarantee() {
+		return nil, false
+	}
+	p.filterAllocations()
+	nodeID, victims := p.findVictims()
+	if len(victims) == 0 {
+		p.ask.LogAllocationFailure(common.NoVictimForUserGuarantee, true)
+		return nil, false
+	}
+	for _, victim := range victims {
+		queue := p.queues[victim.GetAllocationKey()]
+		queue.IncPreemptingResource(victim.GetAllocatedResource())
+		victim.MarkPreempted()
+		log.Log(log.SchedPreemption).Info("Preempting task for user guarantee",
+			zap.String("askApplicationID", p.ask.GetApplicationID()),
+			zap.String("askAllocationKey", p.ask.GetAllocationKey()),
+			zap.String("guaranteeQueue", p.guarantee.QueuePath),
+			zap.String("guaranteeName", p.guarantee.Name),
+			zap.Bool("guaranteeGroup", p.guarantee.Group),
+			zap.String("victimApplicationID", victim.GetApplicationID()),
+			zap.String("victimAllocationKey", victim.GetAllocationKey()),
+			zap.Stringer("victimAllocatedResource", victim.GetAllocatedResource()),
+			zap.String("victimNodeID", victim.GetNodeID()),
+			zap.String("victimQueue", queue.QueuePath))
+		victim.SendPreemptedBySchedulerEvent(p.ask.GetAllocationKey(), p.ask.GetApplicationID(), p.application.queuePath)
+	}
+	p.ask.MarkTriggeredPreemption()
+	p.application.notifyRMAllocationReleased(victims, si.TerminationType_PREEMPTED_BY_SCHEDULER,
+		"preempting allocations to free up resources for user guarantee to run ask: "+p.ask.GetAllocationKey())
+	log.Log(log.SchedPreemption).Info("Reserving node for ask after user guarantee preemption",
+		zap.String("allocationKey", p.ask.GetAllocationKey()),
+		zap.String("nodeID", nodeID),
+		zap.Int("victimCount", len(victims)))
+	return newReservedAllocationResult(nodeID, p.ask), true
+}
@@ -0,0 +1,147 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package objects
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+	"github.com/apache/yunikorn-core/pkg/scheduler/ugm"
+)
+
+func setGuaranteeConfig(t *testing.T, limits []configs.Limit) {
+	conf := configs.QueueConfig{
+		Name:      "root",
+		Parent:    true,
+		SubmitACL: "*",
+		Limits:    limits,
+	}
+	assert.NilError(t, ugm.GetUserManager().UpdateConfig(conf, "root"))
+}
+
+// createGuaranteeVictims creates an application for user2 with two allocations that fill up the node and queue
+func createGuaranteeVictims(t *testing.T, queue *Queue, node *Node) (*Allocation, *Allocation) {
+	app := newApplicationWithUserGroup(appID2, "default", queue.QueuePath, "user2", []string{"group2"})
+	app.SetQueue(queue)
+	queue.applications[appID2] = app
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"first": 5})
+	var allocs []*Allocation
+	for i, key := range []string{"alloc-old", "alloc-new"} {
+		alloc := createAllocationAsk(key, appID2, true, false, 0, res)
+		alloc.SetNodeID(node.NodeID)
+		alloc.createTime = time.Now().Add(time.Duration(i-2) * time.Minute)
+		app.AddAllocation(alloc)
+		assert.Assert(t, node.TryAddAllocation(alloc), "node allocation failed")
+		assert.NilError(t, queue.TryIncAllocatedResource(res))
+		allocs = append(allocs, alloc)
+	}
+	return allocs[0], allocs[1]
+}
+
+func TestUserGuaranteePreemption(t *testing.T) {
+	setupUGM()
+	defer setupUGM()
+	defer setGuaranteeConfig(t, nil)
+	node := newNode(nodeID1, map[string]resources.Quantity{"first": 10})
+	iterator := getNodeIteratorFn(node)
+	rootQ, err := createRootQueue(map[string]string{"first": "10"})
+	assert.NilError(t, err)
+	childQ, err := createManagedQueue(rootQ, "child", false, map[string]string{"first": "10"})
+	assert.NilError(t, err)
+	victimOld, victimNew := createGuaranteeVictims(t, childQ, node)
+
+	app := newApplicationWithUserGroup(appID1, "default", "root.child", "user1", []string{"group1"})
+	app.SetQueue(childQ)
+	childQ.applications[appID1] = app
+	ask := createAllocationAsk("alloc-ask", appID1, true, false, 0, resources.NewResourceFromMap(map[string]resources.Quantity{"first": 5}))
+	assert.NilError(t, app.AddAllocationAsk(ask))
+	headRoom := childQ.getHeadRoom()
+
+	// no guarantee for user1
+	_, ok := NewUserGuaranteePreemptor(app, headRoom, ask, iterator()).TryPreemption()
+	assert.Assert(t, !ok, "preemption without a guarantee")
+
+	// user2 is guaranteed everything it uses
+	setGuaranteeConfig(t, []configs.Limit{
+		{Users: []string{"user1"}, GuaranteedResources: map[string]string{"first": "5"}},
+		{Users: []string{"user2"}, GuaranteedResources: map[string]string{"first": "10"}},
+	})
+	_, ok = NewUserGuaranteePreemptor(app, headRoom, ask, iterator()).TryPreemption()
+	assert.Assert(t, !ok, "preemption below the guarantee of the victim")
+
+	// ask larger than the guarantee of user1
+	setGuaranteeConfig(t, []configs.Limit{
+		{Users: []string{"user1"}, GuaranteedResources: map[string]string{"first": "4"}},
+	})
+	_, ok = NewUserGuaranteePreemptor(app, headRoom, ask, iterator()).TryPreemption()
+	assert.Assert(t, !ok, "preemption for ask larger than the guarantee")
+
+	// user2 is above its guarantee: the newest allocation is preempted
+	setGuaranteeConfig(t, []configs.Limit{
+		{Users: []string{"user1"}, GuaranteedResources: map[string]string{"first": "5"}},
+		{Users: []string{"user2"}, GuaranteedResources: map[string]string{"first": "5"}},
+	})
+	result, ok := NewUserGuaranteePreemptor(app, headRoom, ask, iterator()).TryPreemption()
+	assert.Assert(t, ok, "preemption should have succeeded")
+	assert.Equal(t, result.ResultType, Reserved)
+	assert.Equal(t, result.NodeID, nodeID1)
+	assert.Assert(t, victimNew.IsPreempted(), "newest allocation should have been preempted")
+	assert.Assert(t, !victimOld.IsPreempted(), "oldest allocation should not have been preempted")
+	assert.Assert(t, ask.HasTriggeredPreemption())
+	assert.Assert(t, resources.Equals(childQ.GetPreemptingResource(), victimNew.GetAllocatedResource()))
+}
+
+func TestUserGuaranteePreemptionGroup(t *testing.T) {
+	setupUGM()
+	defer setupUGM()
+	defer setGuaranteeConfig(t, nil)
+	node := newNode(nodeID1, map[string]resources.Quantity{"first": 10})
+	iterator := getNodeIteratorFn(node)
+	rootQ, err := createRootQueue(map[string]string{"first": "10"})
+	assert.NilError(t, err)
+	childQ, err := createManagedQueue(rootQ, "child", false, map[string]string{"first": "10"})
+	assert.NilError(t, err)
+	setGuaranteeConfig(t, []configs.Limit{
+		{Groups: []string{"group1"}, GuaranteedResources: map[string]string{"first": "5"}},
+		{Groups: []string{"group2"}, MaxApplications: 10},
+	})
+	_, victimNew := createGuaranteeVictims(t, childQ, node)
+
+	// another user of the same group is not a victim
+	app := newApplicationWithUserGroup(appID1, "default", "root.child", "user1", []string{"group2"})
+	app.SetQueue(childQ)
+	childQ.applications[appID1] = app
+	ask := createAllocationAsk("alloc-ask", appID1, true, false, 0, resources.NewResourceFromMap(map[string]resources.Quantity{"first": 5}))
+	assert.NilError(t, app.AddAllocationAsk(ask))
+	_, ok := NewUserGuaranteePreemptor(app, childQ.getHeadRoom(), ask, iterator()).TryPreemption()
+	assert.Assert(t, !ok, "preemption without a group guarantee")
+
+	app3 := newApplicationWithUserGroup(appID3, "default", "root.child", "user3", []string{"group1"})
+	app3.SetQueue(childQ)
+	childQ.applications[appID3] = app3
+	ask = createAllocationAsk("alloc-ask3", appID3, true, false, 0, resources.NewResourceFromMap(map[string]resources.Quantity{"first": 5}))
+	assert.NilError(t, app3.AddAllocationAsk(ask))
+	_, ok = NewUserGuaranteePreemptor(app3, childQ.getHeadRoom(), ask, iterator()).TryPreemption()
+	assert.Assert(t, ok, "preemption for the group guarantee should have succeeded")
+	assert.Assert(t, victimNew.IsPreempted(), "newest allocation should have been preempted")
+}
@@ -189,6 +189,7 @@ func newNodeInternal(nodeID string, total, occupied *resources.Resource) *Node {
 		availableResource: resources.Sub(total, occupied),
 		allocations:       make(map[string]*Allocation),
 		schedulable:       true,
+		stateMachine:      NewNodeState(),
 		reservations:      make(map[string]*reservation),
 		nodeEvents:        schedEvt.NewNodeEvents(events.GetEventSystem()),
 	}
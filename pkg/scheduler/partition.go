@@ -37,6 +37,7 @@ import (
 	"github.com/apache/yunikorn-core/pkg/locking"
 	"github.com/apache/yunikorn-core/pkg/log"
 	"github.com/apache/yunikorn-core/pkg/metrics"
+	"github.com/apache/yunikorn-core/pkg/scheduler/accounting"
 	"github.com/apache/yunikorn-core/pkg/scheduler/objects"
 	"github.com/apache/yunikorn-core/pkg/scheduler/placement"
 	"github.com/apache/yunikorn-core/pkg/scheduler/policies"
@@ -66,6 +67,9 @@ type PartitionContext struct {
 	placeholderAllocations int                             // number of placeholder allocations
 	preemptionEnabled      bool                            // whether preemption is enabled or not
 	foreignAllocs          map[string]*objects.Allocation  // foreign (non-Yunikorn) allocations
+	lending                *lendingTracker                 // allocations borrowing lendable resources from other queues
+	advance                *advanceReservations            // capacity booked for future time windows
+	drainingNodes          map[string]bool                 // nodes draining their allocations
 
 	// The partition write lock must not be held while manipulating an application.
 	// Scheduling is running continuously as a lock free background task. Scheduling an application
@@ -96,6 +100,9 @@ func newPartitionContext(conf configs.PartitionConfig, rmID string, cc *ClusterC
 		completedApplications: make(map[string]*objects.Application),
 		nodes:                 objects.NewNodeCollection(conf.Name),
 		foreignAllocs:         make(map[string]*objects.Allocation),
+		lending:               newLendingTracker(),
+		advance:               newAdvanceReservations(),
+		drainingNodes:         make(map[string]bool),
 	}
 	pc.partitionManager = newPartitionManager(pc, cc)
 	if err := pc.initialPartitionFromConfig(conf, silence); err != nil {
@@ -158,7 +165,8 @@ func (pc *PartitionContext) updateNodeSortingPolicy(conf configs.PartitionConfig
 		log.Log(log.SchedPartition).Info("NodeSorting policy set from config",
 			zap.Stringer("policyName", configuredPolicy))
 	}
-	pc.nodes.SetNodeSortingPolicy(objects.NewNodeSortingPolicy(conf.NodeSortPolicy.Type, conf.NodeSortPolicy.ResourceWeights))
+	pc.nodes.SetNodeSortingPolicy(objects.NewScoredNodeSortingPolicy(conf.NodeSortPolicy.Type, conf.NodeSortPolicy.ResourceWeights, conf.NodeSortPolicy.Scorers))
+	pc.nodes.SetTopologyKeys(conf.Topology.ZoneKey, conf.Topology.RackKey)
 }
 
 // NOTE: this is a lock free call. It should only be called holding the PartitionContext lock.
@@ -381,6 +389,12 @@ func (pc *PartitionContext) AddApplication(app *objects.Application) error {
 			}
 		}
 	}
+	// check the submission rate limit of the user and groups, recovered applications are never rejected
+	if !isRecoveryQueue {
+		if err := ugm.GetUserManager().CheckSubmissionLimit(queue.QueuePath, appID, app.GetUser()); err != nil {
+			return fmt.Errorf("failed to add application %s to queue %s: %w", appID, queueName, err)
+		}
+	}
 	// all is OK update the app and add it to the partition
 	app.SetQueue(queue)
 	app.SetTerminatedCallback(pc.moveTerminatedApp)
@@ -411,6 +425,7 @@ func (pc *PartitionContext) removeApplication(appID string) []*objects.Allocatio
 		// track the number of allocations
 		pc.updateAllocationCount(-len(allocations))
 		for _, alloc := range allocations {
+			pc.lending.release(alloc)
 			currentAllocationKey := alloc.GetAllocationKey()
 			node := pc.GetNode(alloc.GetNodeID())
 			if node == nil {
@@ -782,6 +797,8 @@ func (pc *PartitionContext) removeNodeAllocations(node *objects.Node) ([]*object
 			pc.decPhAllocationCount(1)
 		}
 
+		pc.lending.release(alloc)
+
 		// the allocation is removed so add it to the list that we return
 		released = append(released, alloc)
 		metrics.GetQueueMetrics(queue.GetQueuePath()).IncReleasedContainer()
@@ -820,6 +837,17 @@ func (pc *PartitionContext) tryAllocate() *objects.AllocationResult {
 	return nil
 }
 
+// tryBatchAllocate allocates up to limit requests from one sorted snapshot of the queue hierarchy.
+// Each allocation is processed before the next one is tried, see Queue.TryAllocateBatch.
+// Lock free call this all locks are taken when needed in called functions
+func (pc *PartitionContext) tryBatchAllocate(limit int) []*objects.AllocationResult {
+	if !resources.StrictlyGreaterThanZero(pc.root.GetPendingResource()) {
+		// nothing to do just return
+		return nil
+	}
+	return pc.root.TryAllocateBatch(limit, pc.GetNodeIterator, pc.GetFullNodeIterator, pc.GetNode, pc.IsPreemptionEnabled(), pc.allocate)
+}
+
 // Try process reservations for the partition
 // Lock free call this all locks are taken when needed in called functions
 func (pc *PartitionContext) tryReservedAllocate() *objects.AllocationResult {
@@ -864,6 +892,9 @@ func (pc *PartitionContext) tryPlaceholderAllocate() *objects.AllocationResult {
 // Process the allocation and make the left over changes in the partition.
 // NOTE: this is a lock free call. It must NOT be called holding the PartitionContext lock.
 func (pc *PartitionContext) allocate(result *objects.AllocationResult) *objects.AllocationResult {
+	if len(result.GangMembers) > 0 {
+		return pc.allocateGang(result)
+	}
 	// find the app make sure it still exists
 	appID := result.Request.GetApplicationID()
 	app := pc.getApplication(appID)
@@ -943,6 +974,8 @@ func (pc *PartitionContext) allocate(result *objects.AllocationResult) *objects.
 	if result.Request.IsPlaceholder() {
 		pc.incPhAllocationCount()
 	}
+	// track the part of the allocation that uses resources lent by another queue
+	pc.lending.track(alloc, app.GetQueue())
 
 	log.Log(log.SchedPartition).Info("scheduler allocation processed",
 		zap.String("appID", result.Request.GetApplicationID()),
@@ -954,6 +987,37 @@ func (pc *PartitionContext) allocate(result *objects.AllocationResult) *objects.
 	return result
 }
 
+// allocateGang processes each member of a gang allocation. The gang is allocated completely or not at all: if one
+// of the members cannot be processed all members are rolled back and nil is returned.
+func (pc *PartitionContext) allocateGang(result *objects.AllocationResult) *objects.AllocationResult {
+	members := append([]*objects.AllocationResult{result}, result.GangMembers...)
+	result.GangMembers = nil
+	for i, member := range members {
+		if pc.allocate(member) != nil {
+			continue
+		}
+		appID := member.Request.GetApplicationID()
+		log.Log(log.SchedPartition).Info("gang member could not be allocated, rolling back gang",
+			zap.String("appID", appID),
+			zap.String("allocationKey", member.Request.GetAllocationKey()),
+			zap.String("nodeID", member.NodeID))
+		// undo the partition tracking of the members already processed
+		for _, confirmed := range members[:i] {
+			pc.updateAllocationCount(-1)
+			if confirmed.Request.IsPlaceholder() {
+				pc.decPhAllocationCount(1)
+			}
+			pc.lending.release(confirmed.Request)
+		}
+		if app := pc.getApplication(appID); app != nil {
+			app.RollbackGangAllocation(members, pc.GetNode)
+		}
+		return nil
+	}
+	result.GangMembers = members[1:]
+	return result
+}
+
 // Process the reservation in the scheduler
 // Lock free call this must be called holding the context lock
 func (pc *PartitionContext) reserve(app *objects.Application, node *objects.Node, ask *objects.Allocation) {
@@ -1529,6 +1593,7 @@ func (pc *PartitionContext) removeAllocation(release *si.AllocationRelease) ([]*
 		if alloc.IsPreempted() {
 			totalPreempting.AddTo(alloc.GetAllocatedResource())
 		}
+		pc.lending.release(alloc)
 	}
 
 	if resources.StrictlyGreaterThanZero(total) {
@@ -1547,6 +1612,8 @@ func (pc *PartitionContext) removeAllocation(release *si.AllocationRelease) ([]*
 	// that allocation was already released by the shim, so clean up released
 	if confirmed != nil {
 		released = nil
+		// the real allocation takes over the usage of the placeholder, including the borrowed part
+		pc.lending.track(confirmed, queue)
 	}
 	// track the number of allocations, when we replace the result is no change
 	if allocReleases := len(released); allocReleases > 0 {
@@ -1629,6 +1696,11 @@ func (pc *PartitionContext) GetNodeSortingResourceWeights() map[string]float64 {
 	return policy.ResourceWeights()
 }
 
+func (pc *PartitionContext) GetNodeSortingScorers() map[string]float64 {
+	policy := pc.nodes.GetNodeSortingPolicy()
+	return policy.Scorers()
+}
+
 func (pc *PartitionContext) IsPreemptionEnabled() bool {
 	pc.RLock()
 	defer pc.RUnlock()
@@ -1649,7 +1721,7 @@ func (pc *PartitionContext) moveTerminatedApp(appID string) {
 	log.Log(log.SchedPartition).Info("Removing terminated application from the application list",
 		zap.String("appID", appID),
 		zap.String("app status", app.CurrentState()))
-	app.LogAppSummary(pc.RmID)
+	accounting.GetLedger().Record(pc.Name, app.LogAppSummary(pc.RmID), true)
 	pc.Lock()
 	defer pc.Unlock()
 	delete(pc.applications, appID)
@@ -1668,6 +1740,22 @@ func (pc *PartitionContext) AddRejectedApplication(rejectedApplication *objects.
 	pc.rejectedApplications[rejectedApplication.ApplicationID] = rejectedApplication
 }
 
+// restoreApplicationHistory adds the completed and rejected applications restored from a state snapshot.
+func (pc *PartitionContext) restoreApplicationHistory(completed, rejected []*objects.Application) {
+	pc.Lock()
+	defer pc.Unlock()
+	for _, app := range completed {
+		// same key layout as used when the application was moved to the completed list
+		pc.completedApplications[app.ApplicationID+strconv.FormatInt(-app.FinishedTime().Unix(), 10)] = app
+	}
+	if pc.rejectedApplications == nil {
+		pc.rejectedApplications = make(map[string]*objects.Application)
+	}
+	for _, app := range rejected {
+		pc.rejectedApplications[app.ApplicationID] = app
+	}
+}
+
 func (pc *PartitionContext) incPhAllocationCount() {
 	pc.Lock()
 	defer pc.Unlock()
@@ -53,7 +53,8 @@ func newPartitionManager(pc *PartitionContext, cc *ClusterContext) *partitionMan
 }
 
 // Run the manager for the partition.
-// The manager has four tasks:
+// The manager has five tasks:
+// - apply the capacity schedules of the queues
 // - clean up the managed queues that are empty and removed from the configuration
 // - remove empty unmanaged queues
 // - remove completed applications from the partition
@@ -80,6 +81,7 @@ func (manager *partitionManager) cleanRoot() {
 			return
 		case <-time.After(cleanRootInterval):
 			runStart := time.Now()
+			manager.updateCapacitySchedules(manager.pc.root, runStart)
 			manager.cleanQueues(manager.pc.root)
 			log.Log(log.SchedPartition).Debug("time consumed for queue cleaner",
 				zap.Stringer("duration", time.Since(runStart)))
@@ -97,6 +99,18 @@ func (manager *partitionManager) Stop() {
 	manager.remove()
 }
 
+// Apply the capacity schedule active at the time to the queue and all its children.
+// Only called internally and recursive, no locking
+func (manager *partitionManager) updateCapacitySchedules(queue *objects.Queue, now time.Time) {
+	if queue == nil {
+		return
+	}
+	queue.UpdateCapacitySchedule(now)
+	for _, child := range queue.GetCopyOfChildren() {
+		manager.updateCapacitySchedules(child, now)
+	}
+}
+
 // Remove drained managed and empty unmanaged queues. Perform the action recursively.
 // Only called internally and recursive, no locking
 func (manager *partitionManager) cleanQueues(queue *objects.Queue) {
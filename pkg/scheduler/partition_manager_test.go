@@ -20,6 +20,7 @@ package scheduler
 
 import (
 	"testing"
+	"time"
 
 	"gotest.tools/v3/assert"
 
@@ -75,6 +76,46 @@ func TestCleanQueues(t *testing.T) {
 	assert.Equal(t, 0, len(p.root.GetCopyOfChildren()))
 }
 
+func TestUpdateCapacitySchedules(t *testing.T) {
+	conf := configs.PartitionConfig{
+		Name: "test",
+		Queues: []configs.QueueConfig{
+			{
+				Name:      "root",
+				Parent:    true,
+				SubmitACL: "*",
+				Queues: []configs.QueueConfig{
+					{
+						Name:   "parent",
+						Parent: true,
+						Queues: []configs.QueueConfig{
+							{
+								Name:            "batch",
+								MaxApplications: 1,
+								Schedules: []configs.Schedule{
+									{Name: "night", Start: "0 20 * * *", Duration: "10h", MaxApplications: 10},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	partition, err := newPartitionContext(conf, "test", &ClusterContext{}, false)
+	assert.NilError(t, err)
+	batch := partition.GetQueue("root.parent.batch")
+	assert.Assert(t, batch != nil)
+
+	night := time.Date(2024, 1, 1, 22, 0, 0, 0, time.UTC)
+	partition.partitionManager.updateCapacitySchedules(partition.root, night)
+	assert.Equal(t, batch.GetActiveSchedule(), "night")
+	assert.Equal(t, batch.GetMaxApps(), uint64(10))
+	partition.partitionManager.updateCapacitySchedules(partition.root, night.Add(10*time.Hour))
+	assert.Equal(t, batch.GetActiveSchedule(), "")
+	assert.Equal(t, batch.GetMaxApps(), uint64(1))
+}
+
 func TestRemoveAll(t *testing.T) {
 	p := createPartitionContext(t)
 
@@ -0,0 +1,100 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-core/pkg/log"
+	"github.com/apache/yunikorn-core/pkg/metrics"
+)
+
+// partitionScheduler schedules one partition on its own routine with its own pacing: a busy partition
+// cannot delay the scheduling cycles of other partitions.
+// Only used when parallel partition scheduling is enabled.
+type partitionScheduler struct {
+	partition       *PartitionContext
+	context         *ClusterContext
+	isStandby       func() bool
+	activityPending chan bool
+	stop            chan struct{}
+	stopped         chan struct{}
+}
+
+func newPartitionScheduler(partition *PartitionContext, context *ClusterContext, isStandby func() bool) *partitionScheduler {
+	return &partitionScheduler{
+		partition:       partition,
+		context:         context,
+		isStandby:       isStandby,
+		activityPending: make(chan bool, 1),
+		stop:            make(chan struct{}),
+		stopped:         make(chan struct{}),
+	}
+}
+
+// start the scheduling routine of the partition.
+func (ps *partitionScheduler) start() {
+	log.Log(log.Scheduler).Info("Starting partition scheduler",
+		zap.String("partition", ps.partition.Name))
+	go ps.run()
+}
+
+// stopAndWait stops the scheduling routine and waits for the running cycle to finish.
+func (ps *partitionScheduler) stopAndWait() {
+	close(ps.stop)
+	<-ps.stopped
+	metrics.GetSchedulerMetrics().RemovePartitionSchedulingLatency(ps.partition.Name)
+	log.Log(log.Scheduler).Info("Stopped partition scheduler",
+		zap.String("partition", ps.partition.Name))
+}
+
+func (ps *partitionScheduler) run() {
+	defer close(ps.stopped)
+	for {
+		select {
+		case <-ps.stop:
+			return
+		case <-ps.activityPending:
+			// activity pending
+		case <-time.After(100 * time.Millisecond):
+			// timeout, run scheduler anyway
+		}
+		if ps.isStandby() {
+			continue
+		}
+		cycleStart := time.Now()
+		activity := ps.context.schedulePartition(ps.partition, ps.context.getBatchSize())
+		metrics.GetSchedulerMetrics().ObservePartitionSchedulingLatency(ps.partition.Name, cycleStart)
+		if activity {
+			ps.registerActivity()
+		}
+	}
+}
+
+// registerActivity triggers the next cycle of the partition without waiting for the timeout.
+func (ps *partitionScheduler) registerActivity() {
+	select {
+	case ps.activityPending <- true:
+		// activity registered
+	default:
+		// buffer is full, activity will be processed at the next available opportunity
+	}
+}
@@ -0,0 +1,180 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-core/pkg/common"
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+	"github.com/apache/yunikorn-core/pkg/locking"
+	"github.com/apache/yunikorn-core/pkg/rmproxy/rmevent"
+)
+
+const otherPartition = "other"
+
+func TestPartitionScheduler_Isolation(t *testing.T) {
+	setupUGM()
+	context := createTestContext(t, pName)
+	addTestPartition(t, context, otherPartition)
+	allocated := newAllocationCounter()
+	handler, ok := context.rmEventHandler.(*mockEventHandler)
+	assert.Assert(t, ok, "unexpected event handler type")
+	handler.newAllocHandler = allocated.add
+	context.parallelPartitions = true
+	res, err := resources.NewResourceFromConf(map[string]string{"vcore": "1"})
+	assert.NilError(t, err, "failed to create resource")
+	for _, name := range []string{pName, otherPartition} {
+		addStateTestNodes(t, context.GetPartition(name))
+	}
+
+	// block the cycle of the default partition: the other partition must keep scheduling
+	blocked := context.GetPartition(pName)
+	blocked.root.Lock()
+	locked := true
+	defer func() {
+		if locked {
+			blocked.root.Unlock()
+		}
+	}()
+	sched := NewScheduler()
+	sched.clusterContext = context
+	go sched.internalSchedule()
+	defer close(sched.stop)
+
+	free := context.GetPartition(otherPartition)
+	app := newApplication(appID1, otherPartition, defQueue)
+	assert.NilError(t, free.AddApplication(app), "failed to add app-1 to the other partition")
+	assert.NilError(t, app.AddAllocationAsk(newAllocationAsk(allocKey, appID1, res)), "failed to add ask to app-1")
+	sched.registerActivity()
+	err = common.WaitForCondition(10*time.Millisecond, time.Second, func() bool {
+		return allocated.get(appID1) == 1
+	})
+	assert.NilError(t, err, "other partition did not allocate while the default partition was blocked")
+	assert.Equal(t, allocated.get(appID2), 0, "blocked partition should not have allocated")
+
+	// unblock: the default partition catches up
+	blocked.root.Unlock()
+	locked = false
+	app = newApplication(appID2, pName, defQueue)
+	assert.NilError(t, blocked.AddApplication(app), "failed to add app-2 to the default partition")
+	assert.NilError(t, app.AddAllocationAsk(newAllocationAsk(allocKey, appID2, res)), "failed to add ask to app-2")
+	sched.registerActivity()
+	err = common.WaitForCondition(10*time.Millisecond, time.Second, func() bool {
+		return allocated.get(appID2) == 1
+	})
+	assert.NilError(t, err, "default partition did not allocate after it was unblocked")
+}
+
+func TestScheduler_SyncPartitionSchedulers(t *testing.T) {
+	context := createTestContext(t, pName)
+	addTestPartition(t, context, otherPartition)
+	sched := NewScheduler()
+	sched.clusterContext = context
+
+	sched.syncPartitionSchedulers()
+	assert.Equal(t, len(sched.partitionSchedulers), 2, "expected a scheduler per partition")
+	first := sched.partitionSchedulers[pName]
+	sched.syncPartitionSchedulers()
+	assert.Equal(t, sched.partitionSchedulers[pName], first, "running scheduler should be kept")
+
+	// removed partition
+	context.removePartition(otherPartition)
+	sched.syncPartitionSchedulers()
+	assert.Equal(t, len(sched.partitionSchedulers), 1, "scheduler of removed partition should be stopped")
+	_, ok := sched.partitionSchedulers[pName]
+	assert.Assert(t, ok, "default partition scheduler should be running")
+
+	// standby partition schedulers do not schedule but keep running
+	sched.EnableStandby()
+	sched.registerActivity()
+	assert.Equal(t, len(sched.partitionSchedulers), 1, "standby should not stop the partition schedulers")
+
+	sched.stopPartitionSchedulers()
+	assert.Equal(t, len(sched.partitionSchedulers), 0, "all partition schedulers should be stopped")
+	select {
+	case <-first.stopped:
+	default:
+		t.Fatal("partition scheduler routine should have stopped")
+	}
+}
+
+func TestContext_ReadParallelPartitions(t *testing.T) {
+	defer configs.SetConfigMap(map[string]string{})
+	configs.SetConfigMap(map[string]string{})
+	assert.Equal(t, readParallelPartitions(), configs.DefaultSchedulingParallelPartitions)
+	configs.SetConfigMap(map[string]string{configs.SchedulingParallelPartitions: "true"})
+	assert.Assert(t, readParallelPartitions(), "parallel partitions should be enabled")
+	configs.SetConfigMap(map[string]string{configs.SchedulingParallelPartitions: "maybe"})
+	assert.Equal(t, readParallelPartitions(), configs.DefaultSchedulingParallelPartitions)
+}
+
+// addTestPartition adds a partition with the same queues as the partition created by createTestContext.
+func addTestPartition(t *testing.T, context *ClusterContext, name string) {
+	conf := configs.PartitionConfig{
+		Name: name,
+		Queues: []configs.QueueConfig{
+			{
+				Name:      "root",
+				Parent:    true,
+				SubmitACL: "*",
+				Queues: []configs.QueueConfig{
+					{
+						Name:      "default",
+						Parent:    false,
+						SubmitACL: "*",
+					},
+				},
+			},
+		},
+	}
+	partition, err := newPartitionContext(conf, "test", context, false)
+	assert.NilError(t, err, "partition create should not have failed with error")
+	context.Lock()
+	context.partitions[partition.Name] = partition
+	context.Unlock()
+}
+
+// allocationCounter counts the new allocations per application sent to the RM, safe for concurrent use.
+type allocationCounter struct {
+	counts map[string]int
+	locking.Mutex
+}
+
+func newAllocationCounter() *allocationCounter {
+	return &allocationCounter{counts: make(map[string]int)}
+}
+
+func (ac *allocationCounter) add(event *rmevent.RMNewAllocationsEvent) {
+	ac.Lock()
+	defer ac.Unlock()
+	for _, alloc := range event.Allocations {
+		ac.counts[alloc.ApplicationID]++
+	}
+}
+
+func (ac *allocationCounter) get(appID string) int {
+	ac.Lock()
+	defer ac.Unlock()
+	return ac.counts[appID]
+}
@@ -1614,6 +1614,72 @@ func TestTryAllocate(t *testing.T) {
 	assertUserGroupResourceMaxLimits(t, getTestUserGroup(), resources.Multiply(res, 3), expectedQueuesMaxLimits)
 }
 
+func TestTryAllocateGang(t *testing.T) {
+	setupUGM()
+	partition := createQueuesNodes(t)
+	assert.Assert(t, partition != nil, "partition create failed")
+
+	// two members that each need most of a node
+	app := newApplicationTags(appID1, "default", "root.parent.sub-leaf", map[string]string{objects.AppTagGangMembers: "worker=2"})
+	err := partition.AddApplication(app)
+	assert.NilError(t, err, "failed to add app-1 to partition")
+	res, err := resources.NewResourceFromConf(map[string]string{"vcore": "6"})
+	assert.NilError(t, err, "failed to create resource")
+	err = app.AddAllocationAsk(newAllocationAskTG(allocKey, appID1, "worker", res, false))
+	assert.NilError(t, err, "failed to add ask alloc-1 to app-1")
+	assert.Assert(t, partition.tryAllocate() == nil, "incomplete gang should not allocate")
+	err = app.AddAllocationAsk(newAllocationAskTG(allocKey2, appID1, "worker", res, false))
+	assert.NilError(t, err, "failed to add ask alloc-2 to app-1")
+
+	result := partition.tryAllocate()
+	assert.Assert(t, result != nil && result.Request != nil, "gang should be allocated")
+	assert.Equal(t, len(result.GangMembers), 1, "second member should be linked to the result")
+	assert.Assert(t, result.NodeID != result.GangMembers[0].NodeID, "members should be on different nodes")
+	assert.Equal(t, partition.GetTotalAllocationCount(), 2, "all members should be counted")
+	assert.Equal(t, partition.GetNode(result.NodeID).GetAllocation(result.Request.GetAllocationKey()), result.Request, "member not on node")
+	member := result.GangMembers[0]
+	assert.Equal(t, partition.GetNode(member.NodeID).GetAllocation(member.Request.GetAllocationKey()), member.Request, "member not on node")
+	assert.Assert(t, resources.IsZero(partition.root.GetPendingResource()), "pending resources should be set to zero")
+}
+
+func TestAllocateGangRollback(t *testing.T) {
+	setupUGM()
+	partition := createQueuesNodes(t)
+	assert.Assert(t, partition != nil, "partition create failed")
+	app := newApplicationTags(appID1, "default", "root.parent.sub-leaf", map[string]string{objects.AppTagGangMembers: "worker=2"})
+	err := partition.AddApplication(app)
+	assert.NilError(t, err, "failed to add app-1 to partition")
+	res, err := resources.NewResourceFromConf(map[string]string{"vcore": "6"})
+	assert.NilError(t, err, "failed to create resource")
+	err = app.AddAllocationAsk(newAllocationAskTG(allocKey, appID1, "worker", res, false))
+	assert.NilError(t, err, "failed to add ask alloc-1 to app-1")
+	err = app.AddAllocationAsk(newAllocationAskTG(allocKey2, appID1, "worker", res, false))
+	assert.NilError(t, err, "failed to add ask alloc-2 to app-1")
+
+	// the node of the second member disappears before the gang is processed by the partition
+	result := partition.root.TryAllocate(partition.GetNodeIterator, partition.GetFullNodeIterator, partition.GetNode, false)
+	assert.Assert(t, result != nil && len(result.GangMembers) == 1, "gang should be allocated by the queue")
+	first := result.Request
+	firstNode := result.NodeID
+	second := result.GangMembers[0]
+	assert.Assert(t, partition.nodes.RemoveNode(second.NodeID) != nil, "node not removed")
+	assert.Assert(t, partition.allocate(result) == nil, "partially processed gang should not be returned")
+
+	// nothing of the gang is left allocated, all members are pending again
+	assert.Equal(t, partition.GetTotalAllocationCount(), 0, "no members should be counted")
+	assert.Assert(t, partition.GetNode(firstNode).GetAllocation(first.GetAllocationKey()) == nil, "first member still on node")
+	assert.Equal(t, len(app.GetAllAllocations()), 0, "no members should be allocated on the app")
+	assert.Assert(t, resources.IsZero(app.GetAllocatedResource()), "app allocated resource should be zero")
+	assert.Assert(t, resources.IsZero(partition.root.GetAllocatedResource()), "queue allocated resource should be zero")
+	assert.Assert(t, !first.IsAllocated() && !second.Request.IsAllocated(), "members should be pending again")
+	assert.Assert(t, resources.Equals(app.GetPendingResource(), resources.Multiply(res, 2)), "pending resource not restored")
+	assert.Assert(t, resources.Equals(partition.root.GetPendingResource(), resources.Multiply(res, 2)), "queue pending resource not restored")
+	assertLimits(t, getTestUserGroup(), resources.Zero)
+
+	// the gang is tried again on the nodes that are left
+	assert.Assert(t, partition.tryAllocate() == nil, "gang should not fit on the remaining node")
+}
+
 // allocate ask request with required node
 func TestRequiredNodeReservation(t *testing.T) {
 	setupUGM()
@@ -3889,6 +3955,50 @@ func TestGetNodeSortingPolicyWhenNewPartitionFromConfig(t *testing.T) {
 	}
 }
 
+func TestTryBatchAllocate(t *testing.T) {
+	setupUGM()
+	partition, err := newConfiguredPartition()
+	assert.NilError(t, err, "test partition create failed with error")
+	var nodeRes *resources.Resource
+	nodeRes, err = resources.NewResourceFromConf(map[string]string{"vcore": "10", "memory": "10"})
+	assert.NilError(t, err, "failed to create node resource")
+	assert.NilError(t, partition.AddNode(newNodeMaxResource(nodeID1, nodeRes)), "test node1 add failed unexpected")
+	assert.NilError(t, partition.AddNode(newNodeMaxResource(nodeID2, nodeRes)), "test node2 add failed unexpected")
+	assert.Equal(t, len(partition.tryBatchAllocate(10)), 0, "empty cluster batch allocate returned allocations")
+
+	// sub-leaf queue max 2 vcore, the user quota on leaf is 5 memory
+	var res, maxRes *resources.Resource
+	res, err = resources.NewResourceFromConf(map[string]string{"vcore": "1", "memory": "1"})
+	assert.NilError(t, err, "failed to create resource")
+	maxRes, err = resources.NewResourceFromConf(map[string]string{"vcore": "2"})
+	assert.NilError(t, err, "failed to create max resource")
+	partition.getQueueInternal("root.parent.sub-leaf").SetResources(nil, maxRes)
+	app1 := newApplication(appID1, "default", "root.parent.sub-leaf")
+	assert.NilError(t, partition.AddApplication(app1), "failed to add app-1 to partition")
+	app2 := newApplication(appID2, "default", "root.leaf")
+	assert.NilError(t, partition.AddApplication(app2), "failed to add app-2 to partition")
+	for i := 0; i < 7; i++ {
+		assert.NilError(t, app1.AddAllocationAsk(newAllocationAsk(fmt.Sprintf("app1-alloc-%d", i), appID1, res)), "failed to add ask to app-1")
+		assert.NilError(t, app2.AddAllocationAsk(newAllocationAsk(fmt.Sprintf("app2-alloc-%d", i), appID2, res)), "failed to add ask to app-2")
+	}
+
+	// the batch is limited
+	results := partition.tryBatchAllocate(3)
+	assert.Equal(t, len(results), 3, "batch should be limited")
+	assert.Assert(t, results[0].Request.GetApplicationID() != results[1].Request.GetApplicationID(), "each app should get one allocation per round")
+
+	// queue headroom and user quota must hold within the batch
+	results = partition.tryBatchAllocate(100)
+	assert.Equal(t, len(results), 4, "unexpected number of allocations in the batch")
+	for _, result := range results {
+		assert.Equal(t, result.ResultType, objects.Allocated, "result type is not the expected allocated")
+	}
+	assert.Assert(t, resources.Equals(app1.GetAllocatedResource(), resources.Multiply(res, 2)), "app-1 should be limited by the queue headroom")
+	assert.Assert(t, resources.Equals(app2.GetAllocatedResource(), resources.Multiply(res, 5)), "app-2 should be limited by the user quota")
+	assert.Equal(t, partition.GetTotalAllocationCount(), 7, "allocations not tracked on the partition")
+	assert.Equal(t, len(partition.tryBatchAllocate(100)), 0, "nothing should fit anymore")
+}
+
 func TestTryAllocateMaxRunning(t *testing.T) {
 	const resType = "vcore"
 	partition := createQueuesNodes(t)
@@ -31,11 +31,12 @@ const (
 	FifoSortPolicy             SortPolicy = iota // first in first out, submit time
 	FairSortPolicy                               // fair based on usage
 	deprecatedStateAwarePolicy                   // deprecated: now alias for FIFO
+	DRFSortPolicy                                // dominant resource fairness, based on the partition capacity
 	Undefined                                    // not initialised or parsing failed
 )
 
 func (s SortPolicy) String() string {
-	return [...]string{"fifo", "fair", "stateaware", "undefined"}[s]
+	return [...]string{"fifo", "fair", "stateaware", "drf", "undefined"}[s]
 }
 
 func SortPolicyFromString(str string) (SortPolicy, error) {
@@ -45,6 +46,8 @@ func SortPolicyFromString(str string) (SortPolicy, error) {
 		return FifoSortPolicy, nil
 	case FairSortPolicy.String():
 		return FairSortPolicy, nil
+	case DRFSortPolicy.String():
+		return DRFSortPolicy, nil
 	case deprecatedStateAwarePolicy.String():
 		log.Log(log.Deprecation).Warn("Sort policy 'stateaware' is deprecated; using 'fifo' instead")
 		return FifoSortPolicy, nil
@@ -33,6 +33,7 @@ func TestAppFromString(t *testing.T) {
 		{"FifoString", "fifo", FifoSortPolicy, false},
 		{"FairString", "fair", FairSortPolicy, false},
 		{"StatusString", "stateaware", FifoSortPolicy, false},
+		{"DRFString", "drf", DRFSortPolicy, false},
 		{"UnknownString", "unknown", Undefined, true},
 	}
 	for _, tt := range tests {
@@ -57,6 +58,7 @@ func TestAppToString(t *testing.T) {
 		{"FifoString", FifoSortPolicy, "fifo"},
 		{"FairString", FairSortPolicy, "fair"},
 		{"StatusString", deprecatedStateAwarePolicy, "stateaware"},
+		{"DRFString", DRFSortPolicy, "drf"},
 		{"DefaultString", Undefined, "undefined"},
 		{"NoneString", someSP, "fifo"},
 	}
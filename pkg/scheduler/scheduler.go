@@ -20,15 +20,19 @@ package scheduler
 
 import (
 	"reflect"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/apache/yunikorn-core/pkg/common/resources"
 	"github.com/apache/yunikorn-core/pkg/handler"
+	"github.com/apache/yunikorn-core/pkg/locking"
 	"github.com/apache/yunikorn-core/pkg/log"
 	"github.com/apache/yunikorn-core/pkg/plugins"
 	"github.com/apache/yunikorn-core/pkg/rmproxy/rmevent"
+	"github.com/apache/yunikorn-core/pkg/scheduler/accounting"
+	"github.com/apache/yunikorn-core/pkg/scheduler/state"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
 )
 
@@ -40,14 +44,23 @@ type Scheduler struct {
 	stop            chan struct{}    // channel to signal stop request
 	healthChecker   *HealthChecker
 	nodesMonitor    *nodesResourceUsageMonitor
+	stateManager    *stateManager
+	accounting      *accountingManager
+	standby         atomic.Bool // standby schedulers process RM events but do not schedule
+
+	// partition schedulers used when partitions are scheduled in parallel, keyed on partition name
+	partitionSchedulers map[string]*partitionScheduler
+	psLock              locking.RWMutex
 }
 
 func NewScheduler() *Scheduler {
 	m := &Scheduler{}
 	m.clusterContext = newClusterContext()
+	m.clusterContext.schedulerEventHandler = m
 	m.pendingEvents = make(chan interface{}, 1024*1024)
 	m.activityPending = make(chan bool, 1)
 	m.stop = make(chan struct{})
+	m.partitionSchedulers = make(map[string]*partitionScheduler)
 	return m
 }
 
@@ -67,17 +80,105 @@ func (s *Scheduler) StartService(handlers handler.EventHandlers, manualSchedule
 	s.healthChecker = NewHealthChecker(s.clusterContext)
 	s.healthChecker.Start()
 
+	// Start collecting the usage for chargeback, without a store the ledger is kept in memory only
+	if s.accounting == nil {
+		s.accounting = &accountingManager{
+			context: s.clusterContext,
+			standby: s.IsStandby,
+		}
+	}
+	s.accounting.start()
+
 	if !manualSchedule {
 		go s.internalSchedule()
 		go s.internalInspectOutstandingRequests()
 	}
 }
 
+// EnableStateStore restores the state from the last snapshot in the store and starts saving snapshots.
+// Must be called before the RM registers: the state is restored when the partitions are created.
+func (s *Scheduler) EnableStateStore(store state.Store) error {
+	sm, err := newStateManager(s.clusterContext, store)
+	if err != nil {
+		return err
+	}
+	s.clusterContext.Lock()
+	s.clusterContext.stateManager = sm
+	s.clusterContext.Unlock()
+	s.stateManager = sm
+	sm.setStandby(s.IsStandby())
+	sm.start()
+	return nil
+}
+
+// EnableAccountingStore restores the chargeback ledger from the store and saves the ledger in the store
+// after each collection of the usage. Must be called before the scheduler is started.
+func (s *Scheduler) EnableAccountingStore(store accounting.Store) error {
+	am, err := newAccountingManager(s.clusterContext, store, s.IsStandby)
+	if err != nil {
+		return err
+	}
+	s.accounting = am
+	return nil
+}
+
+// SaveState saves a snapshot of the current state, a no-op if no state store is enabled.
+func (s *Scheduler) SaveState() error {
+	if s.stateManager == nil {
+		return nil
+	}
+	return s.stateManager.save()
+}
+
+// EnableStandby starts the scheduler as a hot standby: nothing is scheduled until the scheduler is promoted.
+// With a state store enabled the standby keeps a replica of the leader state that is applied on promotion.
+// Must be called before the scheduler is started.
+func (s *Scheduler) EnableStandby() {
+	s.standby.Store(true)
+	if s.stateManager != nil {
+		s.stateManager.setStandby(true)
+	}
+}
+
+// IsStandby returns true if the scheduler is not the leader.
+func (s *Scheduler) IsStandby() bool {
+	return s.standby.Load()
+}
+
+// Promote turns a standby scheduler into the leader: the state replica is applied and scheduling starts.
+func (s *Scheduler) Promote() {
+	if !s.IsStandby() {
+		return
+	}
+	log.Log(log.Scheduler).Info("Promoting standby scheduler to leader")
+	if s.stateManager != nil {
+		s.stateManager.promote()
+	}
+	if s.accounting != nil {
+		s.accounting.promote()
+	}
+	s.standby.Store(false)
+	s.registerActivity()
+}
+
+// Demote stops scheduling after the leadership was lost. The in memory state is not cleaned up and will
+// diverge from the new leader: the scheduler must be restarted to rejoin as a standby.
+func (s *Scheduler) Demote() {
+	if s.standby.Swap(true) {
+		return
+	}
+	log.Log(log.Scheduler).Warn("Scheduler lost leadership, scheduling stopped")
+	if s.stateManager != nil {
+		s.stateManager.setStandby(true)
+	}
+}
+
 // Internal start scheduling service
 func (s *Scheduler) internalSchedule() {
 	for {
 		select {
 		case <-s.stop:
+			s.stopPartitionSchedulers()
 			return
 		case <-s.activityPending:
 			// activity pending
@@ -85,7 +186,13 @@ func (s *Scheduler) internalSchedule() {
 			// timeout, run scheduler anyway
 		}
 
-		if s.clusterContext.schedule() {
+		// the partition schedulers follow the partitions and the setting on each loop
+		if s.clusterContext.isParallelPartitions() {
+			s.syncPartitionSchedulers()
+			continue
+		}
+		s.stopPartitionSchedulers()
+		if !s.IsStandby() && s.clusterContext.schedule() {
 			s.registerActivity()
 		}
 	}
@@ -97,6 +204,9 @@ func (s *Scheduler) internalInspectOutstandingRequests() {
 		case <-s.stop:
 			return
 		case <-time.After(time.Second):
+			if s.IsStandby() {
+				continue
+			}
 			if noRequests, totalResources := s.inspectOutstandingRequests(); noRequests > 0 {
 				log.Log(log.Scheduler).Info("Found outstanding requests that will trigger autoscaling",
 					zap.Int("number of requests", noRequests),
@@ -141,6 +251,8 @@ func (s *Scheduler) handleRMEvent() {
 				s.clusterContext.processRMRegistrationEvent(v)
 			case *rmevent.RMConfigUpdateEvent:
 				s.clusterContext.processRMConfigUpdateEvent(v)
+			case *nodeStateEvent:
+				s.clusterContext.handleNodeStateEvent(v)
 			default:
 				log.Log(log.Scheduler).Error("Received type is not an acceptable type for RM event.",
 					zap.Stringer("received type", reflect.TypeOf(v)))
@@ -153,6 +265,7 @@ func (s *Scheduler) handleRMEvent() {
 }
 
 // registerActivity is used to notify the scheduler that some activity that may impact scheduling results has occurred.
+// The activity is passed on to all partition schedulers.
 func (s *Scheduler) registerActivity() {
 	select {
 	case s.activityPending <- true:
@@ -160,6 +273,42 @@ func (s *Scheduler) registerActivity() {
 	default:
 		// buffer is full, activity will be processed at the next available opportunity
 	}
+	s.psLock.RLock()
+	defer s.psLock.RUnlock()
+	for _, ps := range s.partitionSchedulers {
+		ps.registerActivity()
+	}
+}
+
+// syncPartitionSchedulers starts a partition scheduler for each new partition and stops the schedulers of
+// partitions that were removed.
+func (s *Scheduler) syncPartitionSchedulers() {
+	partitions := s.clusterContext.GetPartitionMapClone()
+	s.psLock.Lock()
+	defer s.psLock.Unlock()
+	for name, ps := range s.partitionSchedulers {
+		if partitions[name] != ps.partition {
+			ps.stopAndWait()
+			delete(s.partitionSchedulers, name)
+		}
+	}
+	for name, partition := range partitions {
+		if _, ok := s.partitionSchedulers[name]; !ok {
+			ps := newPartitionScheduler(partition, s.clusterContext, s.IsStandby)
+			s.partitionSchedulers[name] = ps
+			ps.start()
+		}
+	}
+}
+
+// stopPartitionSchedulers stops all partition schedulers, a no-op if partitions are not scheduled in parallel.
+func (s *Scheduler) stopPartitionSchedulers() {
+	s.psLock.Lock()
+	defer s.psLock.Unlock()
+	for name, ps := range s.partitionSchedulers {
+		ps.stopAndWait()
+		delete(s.partitionSchedulers, name)
+	}
 }
 
 // inspect on the outstanding requests for each of the queues,
@@ -210,7 +359,9 @@ func (s *Scheduler) MultiStepSchedule(nAlloc int) {
 	for i := 0; i < nAlloc; i++ {
 		log.Log(log.Scheduler).Debug("Scheduler manual stepping",
 			zap.Int("count", i))
-		s.clusterContext.schedule()
+		if !s.IsStandby() {
+			s.clusterContext.schedule()
+		}
 
 		// sometimes the smoke tests are failing because they are competing CPU resources.
 		// each scheduling cycle, let's sleep for a small amount of time (100ms),
@@ -225,6 +376,12 @@ func (s *Scheduler) Stop() {
 	log.Log(log.Scheduler).Info("Stopping scheduler & background services")
 	s.healthChecker.Stop()
 	s.nodesMonitor.stop()
+	if s.stateManager != nil {
+		s.stateManager.stop()
+	}
+	if s.accounting != nil {
+		s.accounting.stop()
+	}
 	s.clusterContext.Stop()
 	close(s.stop)
 }
@@ -0,0 +1,149 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package sink
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// FileSink writes the records as JSON lines to a file. The file is rotated when the next line would grow it
+// beyond the maximum size: the file is renamed with the suffix .1, older files move up one number and the
+// oldest file beyond the number of backups is removed.
+type FileSink struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// NewFileSink opens the file for appending, the directory is created if it does not exist.
+// A maximum size of 0 disables the rotation.
+func NewFileSink(path string, maxSize int64, maxBackups int) (*FileSink, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, err
+	}
+	fs := &FileSink{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: max(maxBackups, 0),
+	}
+	if err := fs.open(); err != nil {
+		return nil, err
+	}
+	return fs, nil
+}
+
+func (fs *FileSink) open() error {
+	file, err := os.OpenFile(fs.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	fs.file = file
+	fs.size = info.Size()
+	return nil
+}
+
+func (fs *FileSink) Write(records []*Record) error {
+	if fs.file == nil {
+		// a failed rotation left no file open: try again
+		if err := fs.open(); err != nil {
+			return err
+		}
+	}
+	var buf bytes.Buffer
+	for _, record := range records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			// the record will never encode: writing the batch again does not help
+			return fmt.Errorf("%w: %v", ErrPermanent, err)
+		}
+		line = append(line, '\n')
+		pending := fs.size + int64(buf.Len())
+		if fs.maxSize > 0 && pending > 0 && pending+int64(len(line)) > fs.maxSize {
+			if err = fs.flush(&buf); err != nil {
+				return err
+			}
+			if err = fs.rotate(); err != nil {
+				return err
+			}
+		}
+		buf.Write(line)
+	}
+	return fs.flush(&buf)
+}
+
+func (fs *FileSink) flush(buf *bytes.Buffer) error {
+	if buf.Len() == 0 {
+		return nil
+	}
+	n, err := fs.file.Write(buf.Bytes())
+	fs.size += int64(n)
+	buf.Reset()
+	return err
+}
+
+// rotate moves the current file to the first backup and opens a new file.
+func (fs *FileSink) rotate() error {
+	if err := fs.file.Close(); err != nil {
+		return err
+	}
+	fs.file = nil
+	if fs.maxBackups == 0 {
+		if err := os.Remove(fs.path); err != nil {
+			return err
+		}
+		return fs.open()
+	}
+	if err := os.Remove(fs.backup(fs.maxBackups)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	for i := fs.maxBackups - 1; i > 0; i-- {
+		if err := os.Rename(fs.backup(i), fs.backup(i+1)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	if err := os.Rename(fs.path, fs.backup(1)); err != nil {
+		return err
+	}
+	return fs.open()
+}
+
+func (fs *FileSink) backup(i int) string {
+	return fmt.Sprintf("%s.%d", fs.path, i)
+}
+
+func (fs *FileSink) Close() error {
+	if fs.file == nil {
+		return nil
+	}
+	err := fs.file.Close()
+	fs.file = nil
+	return err
+}
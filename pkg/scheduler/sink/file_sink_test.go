@@ -0,0 +1,102 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package sink
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func readRecords(t *testing.T, path string) []*Record {
+	file, err := os.Open(path)
+	assert.NilError(t, err, "failed to open record file")
+	defer file.Close()
+	var records []*Record
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		record := &Record{}
+		assert.NilError(t, json.Unmarshal(scanner.Bytes(), record), "line is not a JSON record")
+		records = append(records, record)
+	}
+	assert.NilError(t, scanner.Err(), "failed to read record file")
+	return records
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "apps.jsonl")
+	fs, err := NewFileSink(path, 0, 2)
+	assert.NilError(t, err, "failed to create file sink")
+	assert.NilError(t, fs.Write([]*Record{newRecord(0), newRecord(1)}), "write failed")
+	assert.NilError(t, fs.Close(), "close failed")
+
+	// reopening appends to the existing file
+	fs, err = NewFileSink(path, 0, 2)
+	assert.NilError(t, err, "failed to reopen file sink")
+	assert.NilError(t, fs.Write([]*Record{newRecord(2)}), "write failed")
+	assert.NilError(t, fs.Close(), "close failed")
+	records := readRecords(t, path)
+	assert.Equal(t, len(records), 3, "expected all records")
+	assert.Equal(t, records[2].ApplicationID, "app-2")
+	assert.Equal(t, records[2].Type, RecordAppState)
+}
+
+func TestFileSinkRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "apps.jsonl")
+	line, err := json.Marshal(newRecord(0))
+	assert.NilError(t, err, "failed to marshal record")
+	// two lines fit in a file
+	fs, err := NewFileSink(path, int64(2*(len(line)+1)), 2)
+	assert.NilError(t, err, "failed to create file sink")
+	defer fs.Close()
+
+	var batch []*Record
+	for i := 0; i < 7; i++ {
+		batch = append(batch, newRecord(i))
+	}
+	assert.NilError(t, fs.Write(batch), "write failed")
+	// app-0..1 rotated out, app-2..3 in .2, app-4..5 in .1 and app-6 in the file
+	records := readRecords(t, path)
+	assert.Equal(t, len(records), 1)
+	assert.Equal(t, records[0].ApplicationID, "app-6")
+	records = readRecords(t, path+".1")
+	assert.Equal(t, len(records), 2)
+	assert.Equal(t, records[0].ApplicationID, "app-4")
+	records = readRecords(t, path+".2")
+	assert.Equal(t, len(records), 2)
+	assert.Equal(t, records[0].ApplicationID, "app-2")
+	_, err = os.Stat(path + ".3")
+	assert.Assert(t, os.IsNotExist(err), "only two backups should be kept")
+
+	// without backups the file is truncated
+	noBackup := filepath.Join(t.TempDir(), "apps.jsonl")
+	fs, err = NewFileSink(noBackup, int64(len(line)+1), 0)
+	assert.NilError(t, err, "failed to create file sink")
+	defer fs.Close()
+	assert.NilError(t, fs.Write([]*Record{newRecord(0), newRecord(1)}), "write failed")
+	records = readRecords(t, noBackup)
+	assert.Equal(t, len(records), 1)
+	assert.Equal(t, records[0].ApplicationID, "app-1")
+	_, err = os.Stat(noBackup + ".1")
+	assert.Assert(t, os.IsNotExist(err), "no backup should be kept")
+}
@@ -0,0 +1,290 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package sink
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-core/pkg/common"
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-core/pkg/locking"
+	"github.com/apache/yunikorn-core/pkg/log"
+)
+
+// Record types published to the sinks.
+const (
+	RecordAppSummary = "appSummary"
+	RecordAppState   = "appState"
+	RecordAllocation = "allocation"
+)
+
+// Allocation lifecycle events.
+const (
+	AllocationAdded    = "added"
+	AllocationReleased = "released"
+)
+
+// Names of the built-in sinks configured from the config map.
+const (
+	FileSinkName    = "file"
+	WebhookSinkName = "webhook"
+)
+
+// ErrPermanent marks a write failure that will not succeed on a retry. A sink wraps it to drop the batch at once.
+var ErrPermanent = errors.New("permanent sink failure")
+
+var once sync.Once
+var dispatcher *Dispatcher
+
+// Sink receives the application records. A sink is only called from its own routine, never concurrently.
+type Sink interface {
+	// Write delivers a batch of records. A failed write is retried with the same batch,
+	// unless the error wraps ErrPermanent.
+	Write(records []*Record) error
+	// Close releases the resources of the sink, called once after the last write.
+	Close() error
+}
+
+// Record is an application summary, application state change or allocation lifecycle change.
+// Only the details of the record type are set.
+type Record struct {
+	Type          string            `json:"type"`
+	Time          time.Time         `json:"time"`
+	ApplicationID string            `json:"applicationID"`
+	Partition     string            `json:"partition,omitempty"`
+	Queue         string            `json:"queue,omitempty"`
+	User          string            `json:"user,omitempty"`
+	State         string            `json:"state,omitempty"`
+	Summary       *SummaryRecord    `json:"summary,omitempty"`
+	Allocation    *AllocationRecord `json:"allocation,omitempty"`
+}
+
+// SummaryRecord is the summary of a terminated application. The usage is in resource-seconds keyed on the
+// instance type and resource type.
+type SummaryRecord struct {
+	SubmissionTime      time.Time                   `json:"submissionTime"`
+	StartTime           time.Time                   `json:"startTime"`
+	FinishTime          time.Time                   `json:"finishTime"`
+	RmID                string                      `json:"rmID"`
+	ResourceUsage       map[string]map[string]int64 `json:"resourceUsage,omitempty"`
+	PreemptedResource   map[string]map[string]int64 `json:"preemptedResource,omitempty"`
+	PlaceholderResource map[string]map[string]int64 `json:"placeholderResource,omitempty"`
+}
+
+// AllocationRecord is an allocation that was added to or released from the application.
+type AllocationRecord struct {
+	AllocationKey   string           `json:"allocationKey"`
+	Event           string           `json:"event"`
+	NodeID          string           `json:"nodeID,omitempty"`
+	InstanceType    string           `json:"instanceType,omitempty"`
+	Resource        map[string]int64 `json:"resource,omitempty"`
+	Placeholder     bool             `json:"placeholder,omitempty"`
+	Preempted       bool             `json:"preempted,omitempty"`
+	BindTime        time.Time        `json:"bindTime"`
+	TerminationType string           `json:"terminationType,omitempty"`
+}
+
+// Options control the delivery of records to one sink.
+type Options struct {
+	QueueSize     int           // records buffered before new records are dropped
+	BatchSize     int           // maximum number of records in one write
+	FlushInterval time.Duration // maximum time a record waits for a batch to fill
+	MaxRetries    int           // retries of a failed write before the batch is dropped
+	RetryInterval time.Duration // wait before the first retry, doubles on each retry
+}
+
+// Stats are the delivery counters of one sink.
+type Stats struct {
+	Delivered uint64 // records written
+	Dropped   uint64 // records dropped because the queue was full
+	Failed    uint64 // records dropped after the write failed
+	Queued    int    // records waiting in the queue
+}
+
+// builtinConfig is the config of the built-in sinks, compared on a config map change to restart changed sinks.
+type builtinConfig struct {
+	options        Options
+	filePath       string
+	fileMaxSize    uint64
+	fileMaxBackups int
+	webhookURL     string
+	webhookTimeout time.Duration
+}
+
+// Dispatcher hands the records to the registered sinks. Publishing never blocks: every sink has its own
+// bounded queue and routine, records for a sink that cannot keep up are dropped.
+type Dispatcher struct {
+	workers map[string]*worker
+	builtin builtinConfig
+	enabled atomic.Bool // true if at least one sink is registered
+
+	locking.RWMutex
+}
+
+// GetDispatcher returns the dispatcher singleton.
+func GetDispatcher() *Dispatcher {
+	once.Do(func() {
+		dispatcher = &Dispatcher{
+			workers: make(map[string]*worker),
+		}
+	})
+	return dispatcher
+}
+
+// StartService starts the built-in sinks from the config map and restarts them when the config map changes.
+func (d *Dispatcher) StartService() {
+	configs.AddConfigMapCallback("sink", d.updateBuiltinSinks)
+	d.updateBuiltinSinks()
+}
+
+// Stop flushes and closes all sinks.
+func (d *Dispatcher) Stop() {
+	configs.RemoveConfigMapCallback("sink")
+	d.Lock()
+	workers := d.workers
+	d.workers = make(map[string]*worker)
+	d.builtin = builtinConfig{}
+	d.enabled.Store(false)
+	d.Unlock()
+	for _, w := range workers {
+		w.stop()
+	}
+}
+
+// DefaultOptions returns the delivery options from the config map.
+func DefaultOptions() Options {
+	configMap := configs.GetConfigMap()
+	return Options{
+		QueueSize:     common.GetConfigurationInt(configMap, configs.SinkQueueSize, configs.DefaultSinkQueueSize),
+		BatchSize:     common.GetConfigurationInt(configMap, configs.SinkBatchSize, configs.DefaultSinkBatchSize),
+		FlushInterval: common.GetConfigurationDuration(configMap, configs.SinkFlushInterval, configs.DefaultSinkFlushInterval),
+		MaxRetries:    common.GetConfigurationInt(configMap, configs.SinkMaxRetries, configs.DefaultSinkMaxRetries),
+		RetryInterval: common.GetConfigurationDuration(configMap, configs.SinkRetryInterval, configs.DefaultSinkRetryInterval),
+	}
+}
+
+// Register adds a sink under the name. A sink registered under the same name is stopped and replaced.
+func (d *Dispatcher) Register(name string, s Sink, opts Options) {
+	w := newWorker(name, s, opts)
+	d.Lock()
+	old := d.workers[name]
+	d.workers[name] = w
+	d.enabled.Store(true)
+	d.Unlock()
+	if old != nil {
+		old.stop()
+	}
+	log.Log(log.Sink).Info("Registered application record sink",
+		zap.String("name", name),
+		zap.Int("queueSize", w.opts.QueueSize),
+		zap.Int("batchSize", w.opts.BatchSize))
+}
+
+// Unregister flushes, closes and removes the sink.
+func (d *Dispatcher) Unregister(name string) {
+	d.Lock()
+	w := d.workers[name]
+	delete(d.workers, name)
+	d.enabled.Store(len(d.workers) > 0)
+	d.Unlock()
+	if w != nil {
+		w.stop()
+		log.Log(log.Sink).Info("Unregistered application record sink",
+			zap.String("name", name))
+	}
+}
+
+// IsEnabled returns true if a sink is registered. Callers skip building records if not.
+func (d *Dispatcher) IsEnabled() bool {
+	return d.enabled.Load()
+}
+
+// Publish queues the record for all sinks. The record must not be changed after publishing.
+func (d *Dispatcher) Publish(record *Record) {
+	if record == nil || !d.IsEnabled() {
+		return
+	}
+	d.RLock()
+	defer d.RUnlock()
+	for _, w := range d.workers {
+		w.offer(record)
+	}
+}
+
+// GetStats returns the delivery counters keyed on the sink name.
+func (d *Dispatcher) GetStats() map[string]Stats {
+	d.RLock()
+	defer d.RUnlock()
+	stats := make(map[string]Stats, len(d.workers))
+	for name, w := range d.workers {
+		stats[name] = w.getStats()
+	}
+	return stats
+}
+
+// updateBuiltinSinks (re)starts the built-in sinks whose settings changed in the config map.
+func (d *Dispatcher) updateBuiltinSinks() {
+	configMap := configs.GetConfigMap()
+	current := builtinConfig{
+		options:        DefaultOptions(),
+		filePath:       configMap[configs.SinkFilePath],
+		fileMaxSize:    common.GetConfigurationUint(configMap, configs.SinkFileMaxSize, configs.DefaultSinkFileMaxSize),
+		fileMaxBackups: common.GetConfigurationInt(configMap, configs.SinkFileMaxBackups, configs.DefaultSinkFileMaxBackups),
+		webhookURL:     configMap[configs.SinkWebhookURL],
+		webhookTimeout: common.GetConfigurationDuration(configMap, configs.SinkWebhookTimeout, configs.DefaultSinkWebhookTimeout),
+	}
+	d.Lock()
+	previous := d.builtin
+	d.builtin = current
+	d.Unlock()
+
+	if current.options != previous.options || current.filePath != previous.filePath ||
+		current.fileMaxSize != previous.fileMaxSize || current.fileMaxBackups != previous.fileMaxBackups {
+		d.Unregister(FileSinkName)
+		if current.filePath != "" {
+			if err := d.registerFileSink(current); err != nil {
+				log.Log(log.Sink).Error("Failed to start file sink",
+					zap.String("path", current.filePath),
+					zap.Error(err))
+			}
+		}
+	}
+	if current.options != previous.options || current.webhookURL != previous.webhookURL ||
+		current.webhookTimeout != previous.webhookTimeout {
+		d.Unregister(WebhookSinkName)
+		if current.webhookURL != "" {
+			d.Register(WebhookSinkName, NewWebhookSink(current.webhookURL, current.webhookTimeout), current.options)
+		}
+	}
+}
+
+func (d *Dispatcher) registerFileSink(current builtinConfig) error {
+	fs, err := NewFileSink(current.filePath, int64(min(current.fileMaxSize, math.MaxInt64)), current.fileMaxBackups)
+	if err != nil {
+		return err
+	}
+	d.Register(FileSinkName, fs, current.options)
+	return nil
+}
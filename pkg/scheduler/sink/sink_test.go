@@ -0,0 +1,270 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package sink
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-core/pkg/common"
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-core/pkg/locking"
+)
+
+// mockSink records the batches written. Queued errors are returned by the next writes.
+// A non nil block channel holds every write until it is closed.
+type mockSink struct {
+	batches [][]*Record
+	writes  int
+	errs    []error
+	block   chan struct{}
+	closed  bool
+
+	locking.Mutex
+}
+
+func (ms *mockSink) Write(records []*Record) error {
+	if ms.block != nil {
+		<-ms.block
+	}
+	ms.Lock()
+	defer ms.Unlock()
+	ms.writes++
+	if len(ms.errs) > 0 {
+		err := ms.errs[0]
+		ms.errs = ms.errs[1:]
+		if err != nil {
+			return err
+		}
+	}
+	ms.batches = append(ms.batches, records)
+	return nil
+}
+
+func (ms *mockSink) Close() error {
+	ms.Lock()
+	defer ms.Unlock()
+	ms.closed = true
+	return nil
+}
+
+func (ms *mockSink) batchSizes() []int {
+	ms.Lock()
+	defer ms.Unlock()
+	sizes := make([]int, 0, len(ms.batches))
+	for _, batch := range ms.batches {
+		sizes = append(sizes, len(batch))
+	}
+	return sizes
+}
+
+// recordTime is fixed: the length of the JSON line must not depend on the time the record is created
+var recordTime = time.Date(2024, 1, 2, 3, 4, 5, 123456789, time.UTC)
+
+func newRecord(i int) *Record {
+	return &Record{
+		Type:          RecordAppState,
+		Time:          recordTime,
+		ApplicationID: fmt.Sprintf("app-%d", i),
+		State:         "Running",
+	}
+}
+
+func testOptions() Options {
+	return Options{
+		QueueSize:     100,
+		BatchSize:     3,
+		FlushInterval: time.Hour,
+		MaxRetries:    2,
+		RetryInterval: time.Millisecond,
+	}
+}
+
+func TestWorkerBatching(t *testing.T) {
+	ms := &mockSink{}
+	w := newWorker("test", ms, testOptions())
+	for i := 0; i < 7; i++ {
+		w.offer(newRecord(i))
+	}
+	err := common.WaitForCondition(10*time.Millisecond, time.Second, func() bool {
+		return len(ms.batchSizes()) == 2
+	})
+	assert.NilError(t, err, "full batches should be written without waiting for the flush interval")
+	// stop flushes the partial batch and closes the sink
+	w.stop()
+	assert.DeepEqual(t, ms.batchSizes(), []int{3, 3, 1})
+	assert.Assert(t, ms.closed, "sink should be closed")
+	assert.Equal(t, w.getStats(), Stats{Delivered: 7})
+	assert.Equal(t, ms.batches[2][0].ApplicationID, "app-6", "records should be delivered in order")
+
+	// a partial batch is written after the flush interval
+	ms = &mockSink{}
+	opts := testOptions()
+	opts.FlushInterval = 10 * time.Millisecond
+	w = newWorker("test", ms, opts)
+	defer w.stop()
+	w.offer(newRecord(0))
+	err = common.WaitForCondition(10*time.Millisecond, time.Second, func() bool {
+		return w.getStats().Delivered == 1
+	})
+	assert.NilError(t, err, "partial batch should be written after the flush interval")
+}
+
+func TestWorkerRetry(t *testing.T) {
+	// recovers within the retries
+	ms := &mockSink{errs: []error{errors.New("fail 1"), errors.New("fail 2")}}
+	opts := testOptions()
+	opts.BatchSize = 1
+	w := newWorker("test", ms, opts)
+	w.offer(newRecord(0))
+	err := common.WaitForCondition(10*time.Millisecond, time.Second, func() bool {
+		return w.getStats().Delivered == 1
+	})
+	assert.NilError(t, err, "record should be delivered after the retries")
+	w.stop()
+	assert.Equal(t, ms.writes, 3, "expected two retries")
+
+	// a stopped worker does not retry
+	ms = &mockSink{errs: []error{errors.New("fail 1")}}
+	w = newWorker("test", ms, testOptions())
+	w.offer(newRecord(0))
+	w.stop()
+	assert.Equal(t, ms.writes, 1, "no retry expected on stop")
+	assert.Equal(t, w.getStats(), Stats{Failed: 1})
+
+	// dropped after the last retry
+	ms = &mockSink{errs: []error{errors.New("fail 1"), errors.New("fail 2"), errors.New("fail 3")}}
+	w = newWorker("test", ms, testOptions())
+	for i := 0; i < 3; i++ {
+		w.offer(newRecord(i))
+	}
+	err = common.WaitForCondition(10*time.Millisecond, time.Second, func() bool {
+		return w.getStats().Failed == 3
+	})
+	assert.NilError(t, err, "batch should be dropped after the retries")
+	w.stop()
+	assert.Equal(t, ms.writes, 3, "expected two retries")
+
+	// a permanent failure is not retried
+	ms = &mockSink{errs: []error{fmt.Errorf("%w: bad request", ErrPermanent)}}
+	w = newWorker("test", ms, testOptions())
+	w.offer(newRecord(0))
+	w.stop()
+	assert.Equal(t, ms.writes, 1, "permanent failure should not be retried")
+	assert.Equal(t, w.getStats(), Stats{Failed: 1})
+}
+
+func TestWorkerBackPressure(t *testing.T) {
+	ms := &mockSink{block: make(chan struct{})}
+	opts := testOptions()
+	opts.QueueSize = 2
+	opts.BatchSize = 1
+	w := newWorker("test", ms, opts)
+	// the first record blocks the sink, the queue fills up and the rest is dropped without blocking
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 100; i++ {
+			w.offer(newRecord(i))
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("offering records blocked on a slow sink")
+	}
+	stats := w.getStats()
+	assert.Assert(t, stats.Dropped >= 97, "expected records to be dropped, got %d", stats.Dropped)
+	assert.Assert(t, stats.Queued <= 2, "queue should not grow beyond its size")
+	close(ms.block)
+	w.stop()
+	stats = w.getStats()
+	assert.Equal(t, stats.Delivered+stats.Dropped, uint64(100), "every record should be delivered or dropped")
+}
+
+func TestDispatcher(t *testing.T) {
+	d := &Dispatcher{workers: make(map[string]*worker)}
+	assert.Assert(t, !d.IsEnabled(), "no sinks registered")
+	d.Publish(newRecord(0))
+
+	first := &mockSink{}
+	second := &mockSink{}
+	opts := testOptions()
+	opts.BatchSize = 1
+	d.Register("first", first, opts)
+	d.Register("second", second, opts)
+	assert.Assert(t, d.IsEnabled(), "sinks registered")
+	d.Publish(newRecord(1))
+	d.Publish(nil)
+	err := common.WaitForCondition(10*time.Millisecond, time.Second, func() bool {
+		stats := d.GetStats()
+		return stats["first"].Delivered == 1 && stats["second"].Delivered == 1
+	})
+	assert.NilError(t, err, "record should be delivered to all sinks")
+
+	// replacing a sink closes the old one
+	replaced := &mockSink{}
+	d.Register("first", replaced, opts)
+	assert.Assert(t, first.closed, "replaced sink should be closed")
+	d.Unregister("first")
+	assert.Assert(t, replaced.closed, "unregistered sink should be closed")
+	d.Stop()
+	assert.Assert(t, second.closed, "sink should be closed on stop")
+	assert.Assert(t, !d.IsEnabled(), "no sinks left")
+	assert.Equal(t, len(d.GetStats()), 0, "no sinks left")
+}
+
+func TestBuiltinSinks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "records", "apps.jsonl")
+	d := &Dispatcher{workers: make(map[string]*worker)}
+	defer configs.SetConfigMap(map[string]string{})
+	configs.SetConfigMap(map[string]string{})
+	d.StartService()
+	defer d.Stop()
+	assert.Assert(t, !d.IsEnabled(), "no built-in sinks configured")
+
+	configs.SetConfigMap(map[string]string{
+		configs.SinkFilePath:   path,
+		configs.SinkWebhookURL: "http://localhost:1/records",
+	})
+	stats := d.GetStats()
+	assert.Equal(t, len(stats), 2, "file and webhook sink expected")
+	d.Publish(newRecord(0))
+
+	// removing the webhook leaves the file sink running
+	configs.SetConfigMap(map[string]string{configs.SinkFilePath: path})
+	_, ok := d.GetStats()[WebhookSinkName]
+	assert.Assert(t, !ok, "webhook sink should be removed")
+	_, ok = d.GetStats()[FileSinkName]
+	assert.Assert(t, ok, "file sink should still be registered")
+
+	// a changed option restarts the file sink which flushes the record
+	configs.SetConfigMap(map[string]string{configs.SinkFilePath: path, configs.SinkBatchSize: "1"})
+	data, err := os.ReadFile(path)
+	assert.NilError(t, err, "record file should exist")
+	assert.Assert(t, strings.Contains(string(data), `"applicationID":"app-0"`), "record should be written: %s", data)
+	assert.Equal(t, d.GetStats()[FileSinkName].Delivered, uint64(0), "restarted sink should have new counters")
+}
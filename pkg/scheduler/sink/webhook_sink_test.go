@@ -0,0 +1,115 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package sink
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-core/pkg/common"
+	"github.com/apache/yunikorn-core/pkg/locking"
+)
+
+// webhookServer answers with the queued status codes, then with 200, and records the received batches.
+type webhookServer struct {
+	statusCodes []int
+	batches     [][]*Record
+
+	locking.Mutex
+}
+
+func (ws *webhookServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ws.Lock()
+	defer ws.Unlock()
+	if len(ws.statusCodes) > 0 {
+		code := ws.statusCodes[0]
+		ws.statusCodes = ws.statusCodes[1:]
+		w.WriteHeader(code)
+		return
+	}
+	var batch []*Record
+	if r.Header.Get("Content-Type") != "application/json" || json.NewDecoder(r.Body).Decode(&batch) != nil {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		return
+	}
+	ws.batches = append(ws.batches, batch)
+}
+
+func (ws *webhookServer) received() int {
+	ws.Lock()
+	defer ws.Unlock()
+	count := 0
+	for _, batch := range ws.batches {
+		count += len(batch)
+	}
+	return count
+}
+
+func TestWebhookSink(t *testing.T) {
+	handler := &webhookServer{}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+	ws := NewWebhookSink(server.URL, time.Second)
+	defer ws.Close()
+
+	assert.NilError(t, ws.Write([]*Record{newRecord(0), newRecord(1)}), "write failed")
+	assert.Equal(t, len(handler.batches), 1, "expected one post")
+	assert.Equal(t, handler.batches[0][1].ApplicationID, "app-1")
+
+	// server errors, timeouts and throttling are retried, other client errors are not
+	handler.statusCodes = []int{http.StatusInternalServerError, http.StatusTooManyRequests, http.StatusBadRequest}
+	err := ws.Write([]*Record{newRecord(2)})
+	assert.Assert(t, err != nil && !errors.Is(err, ErrPermanent), "server error should be retried: %v", err)
+	err = ws.Write([]*Record{newRecord(2)})
+	assert.Assert(t, err != nil && !errors.Is(err, ErrPermanent), "throttling should be retried: %v", err)
+	err = ws.Write([]*Record{newRecord(2)})
+	assert.Assert(t, errors.Is(err, ErrPermanent), "client error should not be retried: %v", err)
+
+	// unreachable server
+	unreachable := NewWebhookSink("http://localhost:1/records", time.Second)
+	err = unreachable.Write([]*Record{newRecord(3)})
+	assert.Assert(t, err != nil && !errors.Is(err, ErrPermanent), "connection failure should be retried: %v", err)
+}
+
+func TestWebhookSinkDelivery(t *testing.T) {
+	handler := &webhookServer{statusCodes: []int{http.StatusServiceUnavailable}}
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	opts := testOptions()
+	opts.BatchSize = 5
+	opts.FlushInterval = 10 * time.Millisecond
+	w := newWorker(WebhookSinkName, NewWebhookSink(server.URL, time.Second), opts)
+	for i := 0; i < 12; i++ {
+		w.offer(newRecord(i))
+	}
+	err := common.WaitForCondition(10*time.Millisecond, 5*time.Second, func() bool {
+		return handler.received() == 12
+	})
+	assert.NilError(t, err, "all records should be delivered after the retry")
+	w.stop()
+	assert.Equal(t, w.getStats(), Stats{Delivered: 12})
+	assert.Equal(t, len(handler.batches), 3, "records should be posted in batches")
+}
@@ -0,0 +1,186 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package sink
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-core/pkg/log"
+)
+
+// worker delivers the records queued for one sink in batches on its own routine.
+// A full queue drops new records: a slow or failing sink never blocks the publisher.
+type worker struct {
+	name     string
+	sink     Sink
+	opts     Options
+	queue    chan *Record
+	stopChan chan struct{}
+	done     chan struct{}
+
+	delivered atomic.Uint64
+	dropped   atomic.Uint64
+	failed    atomic.Uint64
+}
+
+func newWorker(name string, s Sink, opts Options) *worker {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 1
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 1
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = time.Second
+	}
+	w := &worker{
+		name:     name,
+		sink:     s,
+		opts:     opts,
+		queue:    make(chan *Record, opts.QueueSize),
+		stopChan: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+// offer queues the record without blocking, the record is dropped if the queue is full.
+func (w *worker) offer(record *Record) {
+	select {
+	case w.queue <- record:
+	default:
+		// log the first drop and then every 1000 drops to not flood the log
+		if dropped := w.dropped.Add(1); dropped%1000 == 1 {
+			log.Log(log.Sink).Warn("Sink queue full, dropping application records",
+				zap.String("name", w.name),
+				zap.Uint64("dropped", dropped))
+		}
+	}
+}
+
+// stop flushes the queued records, closes the sink and waits for the routine to finish.
+func (w *worker) stop() {
+	close(w.stopChan)
+	<-w.done
+}
+
+func (w *worker) getStats() Stats {
+	return Stats{
+		Delivered: w.delivered.Load(),
+		Dropped:   w.dropped.Load(),
+		Failed:    w.failed.Load(),
+		Queued:    len(w.queue),
+	}
+}
+
+func (w *worker) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.opts.FlushInterval)
+	defer ticker.Stop()
+	batch := make([]*Record, 0, w.opts.BatchSize)
+	for {
+		select {
+		case record := <-w.queue:
+			batch = append(batch, record)
+			if len(batch) >= w.opts.BatchSize {
+				w.write(batch)
+				batch = make([]*Record, 0, w.opts.BatchSize)
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				w.write(batch)
+				batch = make([]*Record, 0, w.opts.BatchSize)
+			}
+		case <-w.stopChan:
+			w.drain(batch)
+			if err := w.sink.Close(); err != nil {
+				log.Log(log.Sink).Warn("Failed to close sink",
+					zap.String("name", w.name),
+					zap.Error(err))
+			}
+			return
+		}
+	}
+}
+
+// drain writes the partial batch and everything left in the queue.
+func (w *worker) drain(batch []*Record) {
+	for {
+		select {
+		case record := <-w.queue:
+			batch = append(batch, record)
+			if len(batch) >= w.opts.BatchSize {
+				w.write(batch)
+				batch = make([]*Record, 0, w.opts.BatchSize)
+			}
+		default:
+			if len(batch) > 0 {
+				w.write(batch)
+			}
+			return
+		}
+	}
+}
+
+// write delivers the batch, retrying with a doubling interval. The batch is dropped after the last retry,
+// on a permanent failure, or when the worker is stopped while waiting for a retry.
+func (w *worker) write(batch []*Record) {
+	interval := w.opts.RetryInterval
+	for attempt := 0; ; attempt++ {
+		err := w.sink.Write(batch)
+		if err == nil {
+			w.delivered.Add(uint64(len(batch)))
+			return
+		}
+		if errors.Is(err, ErrPermanent) || attempt >= w.opts.MaxRetries || w.isStopped() {
+			w.failed.Add(uint64(len(batch)))
+			log.Log(log.Sink).Error("Failed to write application records, dropping batch",
+				zap.String("name", w.name),
+				zap.Int("records", len(batch)),
+				zap.Int("attempts", attempt+1),
+				zap.Error(err))
+			return
+		}
+		log.Log(log.Sink).Debug("Failed to write application records, retrying",
+			zap.String("name", w.name),
+			zap.Duration("retryInterval", interval),
+			zap.Error(err))
+		timer := time.NewTimer(interval)
+		select {
+		case <-timer.C:
+		case <-w.stopChan:
+			timer.Stop()
+		}
+		interval *= 2
+	}
+}
+
+func (w *worker) isStopped() bool {
+	select {
+	case <-w.stopChan:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,102 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func testSnapshot() *Snapshot {
+	return &Snapshot{
+		Version: SnapshotVersion,
+		Time:    time.Unix(1700000000, 0).UTC(),
+		Partitions: []*PartitionState{{
+			Name: "[rm-1]default",
+			RmID: "rm-1",
+			Applications: []*ApplicationState{{
+				ApplicationID: "app-1",
+				QueuePath:     "root.default",
+				User:          "testuser",
+				State:         "Running",
+				Allocations:   []*AllocationState{{AllocationKey: "alloc-1", NodeID: "node-1", Resource: map[string]int64{"vcore": 1}}},
+			}},
+		}},
+		Users: []*TrackerState{{Name: "testuser", Usage: map[string]map[string]int64{"root": {"vcore": 1}}}},
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "state")
+	store, err := NewFileStore(dir)
+	assert.NilError(t, err, "store create failed")
+
+	// nothing saved yet
+	var snapshot *Snapshot
+	snapshot, err = store.Load()
+	assert.NilError(t, err, "load of empty store should not fail")
+	assert.Assert(t, snapshot == nil, "no snapshot expected")
+
+	expected := testSnapshot()
+	assert.NilError(t, store.Save(expected), "save failed")
+	snapshot, err = store.Load()
+	assert.NilError(t, err, "load failed")
+	assert.DeepEqual(t, snapshot, expected)
+
+	// replace and check no temp files are left behind
+	expected.Partitions = nil
+	assert.NilError(t, store.Save(expected), "second save failed")
+	var entries []os.DirEntry
+	entries, err = os.ReadDir(dir)
+	assert.NilError(t, err, "read dir failed")
+	assert.Equal(t, len(entries), 1, "only the snapshot file expected")
+	snapshot, err = store.Load()
+	assert.NilError(t, err, "load failed")
+	assert.Equal(t, len(snapshot.Partitions), 0, "replaced snapshot expected")
+
+	// corrupt and old snapshots are not restored
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, snapshotFile), []byte("{not json"), 0o600))
+	_, err = store.Load()
+	assert.ErrorContains(t, err, "invalid state snapshot")
+	assert.NilError(t, os.WriteFile(filepath.Join(dir, snapshotFile), []byte(`{"version": 0}`), 0o600))
+	_, err = store.Load()
+	assert.ErrorContains(t, err, "unsupported state snapshot version 0")
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	snapshot, err := store.Load()
+	assert.NilError(t, err, "load of empty store should not fail")
+	assert.Assert(t, snapshot == nil, "no snapshot expected")
+
+	expected := testSnapshot()
+	assert.NilError(t, store.Save(expected), "save failed")
+	snapshot, err = store.Load()
+	assert.NilError(t, err, "load failed")
+	assert.DeepEqual(t, snapshot, expected)
+	// changes after the save must not leak into the store
+	expected.Partitions[0].Name = "changed"
+	snapshot, err = store.Load()
+	assert.NilError(t, err, "load failed")
+	assert.Equal(t, snapshot.Partitions[0].Name, "[rm-1]default")
+}
@@ -0,0 +1,121 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package state
+
+import (
+	"time"
+)
+
+// SnapshotVersion is the version of the snapshot layout written by this scheduler.
+// A snapshot with a different version is not restored.
+const SnapshotVersion = 1
+
+// Store persists the scheduler state snapshot. Only the last saved snapshot needs to be kept.
+// Implementations must be safe for concurrent use.
+type Store interface {
+	// Save replaces the stored snapshot.
+	Save(snapshot *Snapshot) error
+	// Load returns the stored snapshot, or nil without an error if nothing has been stored yet.
+	Load() (*Snapshot, error)
+}
+
+// Snapshot is the state of the scheduler core at a point in time that cannot be recovered from the
+// resource manager after a restart.
+type Snapshot struct {
+	Version    int               `json:"version"`
+	Time       time.Time         `json:"time"`
+	Partitions []*PartitionState `json:"partitions,omitempty"`
+	Users      []*TrackerState   `json:"users,omitempty"`
+	Groups     []*TrackerState   `json:"groups,omitempty"`
+	History    *UsageHistory     `json:"history,omitempty"`
+}
+
+// PartitionState contains the nodes and applications of a partition. The name is the normalised partition name.
+type PartitionState struct {
+	Name                  string              `json:"name"`
+	RmID                  string              `json:"rmID"`
+	Nodes                 []*NodeState        `json:"nodes,omitempty"`
+	Applications          []*ApplicationState `json:"applications,omitempty"`
+	CompletedApplications []*ApplicationState `json:"completedApplications,omitempty"`
+	RejectedApplications  []*ApplicationState `json:"rejectedApplications,omitempty"`
+}
+
+// NodeState is a node registered by the RM.
+type NodeState struct {
+	NodeID      string            `json:"nodeID"`
+	Attributes  map[string]string `json:"attributes,omitempty"`
+	Capacity    map[string]int64  `json:"capacity,omitempty"`
+	Schedulable bool              `json:"schedulable"`
+}
+
+// ApplicationState contains the application details and history.
+// Allocations, pending asks and reservations are only set for active applications.
+type ApplicationState struct {
+	ApplicationID   string              `json:"applicationID"`
+	QueuePath       string              `json:"queuePath"`
+	User            string              `json:"user"`
+	Groups          []string            `json:"groups,omitempty"`
+	Tags            map[string]string   `json:"tags,omitempty"`
+	State           string              `json:"state"`
+	SubmissionTime  time.Time           `json:"submissionTime"`
+	StartTime       time.Time           `json:"startTime,omitempty"`
+	FinishedTime    time.Time           `json:"finishedTime,omitempty"`
+	RejectedMessage string              `json:"rejectedMessage,omitempty"`
+	StateLog        []*StateLogEntry    `json:"stateLog,omitempty"`
+	Allocations     []*AllocationState  `json:"allocations,omitempty"`
+	Asks            []*AllocationState  `json:"asks,omitempty"`
+	Reservations    []*ReservationState `json:"reservations,omitempty"`
+}
+
+// StateLogEntry is one state change of an application.
+type StateLogEntry struct {
+	Time  time.Time `json:"time"`
+	State string    `json:"state"`
+}
+
+// AllocationState is an allocation placed on a node, or a pending ask if the node is not set.
+type AllocationState struct {
+	AllocationKey string            `json:"allocationKey"`
+	NodeID        string            `json:"nodeID,omitempty"`
+	Resource      map[string]int64  `json:"resource,omitempty"`
+	Priority      int32             `json:"priority,omitempty"`
+	TaskGroup     string            `json:"taskGroup,omitempty"`
+	Placeholder   bool              `json:"placeholder,omitempty"`
+	Tags          map[string]string `json:"tags,omitempty"`
+}
+
+// ReservationState is a reservation of a node for a pending ask.
+type ReservationState struct {
+	AllocationKey string `json:"allocationKey"`
+	NodeID        string `json:"nodeID"`
+}
+
+// TrackerState is the usage tracked for a user or group, keyed on the queue path.
+type TrackerState struct {
+	Name  string                      `json:"name"`
+	Usage map[string]map[string]int64 `json:"usage,omitempty"`
+}
+
+// UsageHistory is the decayed usage in resource-seconds per resource type used by fair sorting, keyed on the
+// user, group or queue path. The usage is decayed up to the snapshot time.
+type UsageHistory struct {
+	Users  map[string]map[string]float64 `json:"users,omitempty"`
+	Groups map[string]map[string]float64 `json:"groups,omitempty"`
+	Queues map[string]map[string]float64 `json:"queues,omitempty"`
+}
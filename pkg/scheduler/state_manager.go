@@ -0,0 +1,722 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+	"github.com/apache/yunikorn-core/pkg/common/security"
+	"github.com/apache/yunikorn-core/pkg/locking"
+	"github.com/apache/yunikorn-core/pkg/log"
+	"github.com/apache/yunikorn-core/pkg/scheduler/objects"
+	"github.com/apache/yunikorn-core/pkg/scheduler/state"
+	"github.com/apache/yunikorn-core/pkg/scheduler/ugm"
+	"github.com/apache/yunikorn-core/pkg/webservice/dao"
+	siCommon "github.com/apache/yunikorn-scheduler-interface/lib/go/common"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+// stateManager periodically saves a snapshot of the scheduler state in the store and restores the state
+// from the last snapshot after a restart.
+// The resource manager remains the source of truth for the allocations: after a restart the RM re-sends
+// the applications and allocations through the normal recovery path. The snapshot restores what the RM
+// cannot provide (application history, state logs and reservations) and is reconciled with the
+// allocations the RM reported once the reconcile delay has passed.
+// A standby scheduler does not save: it periodically loads the snapshot of the leader as a replica.
+// When the standby is promoted the replica is applied in full, the RM does not need to re-send its state.
+type stateManager struct {
+	store    state.Store
+	context  *ClusterContext
+	snapshot *state.Snapshot                  // snapshot loaded on start or replica of the leader, nil if there was none
+	restored map[string]*state.PartitionState // partitions restored but not reconciled yet
+	timers   map[string]*time.Timer           // reconcile delay of the restored partitions
+	due      map[string]bool                  // restored partitions the scheduling cycle must reconcile
+	history  bool                             // usage history restored, shared by all partitions
+	standby  bool
+	stopChan chan struct{}
+
+	locking.RWMutex
+}
+
+// reconcileResult summarises the reconciliation of a restored partition.
+type reconcileResult struct {
+	restoredApps           int      // active applications the RM re-added
+	lostApps               []string // active applications the RM did not re-add
+	recoveredAllocations   int      // snapshot allocations the RM reported
+	droppedAllocations     []string // snapshot allocations the RM did not report
+	restoredReservations   int      // reservations placed again
+	unrestoredReservations int      // reservations that could not be placed again
+	changedUserUsage       []string // users with a different usage than before the restart
+	changedGroupUsage      []string // groups with a different usage than before the restart
+}
+
+// newStateManager loads the last snapshot from the store. A snapshot that cannot be loaded fails the start:
+// silently starting without the state would lose the history.
+func newStateManager(cc *ClusterContext, store state.Store) (*stateManager, error) {
+	snapshot, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	if snapshot != nil {
+		log.Log(log.SchedState).Info("Loaded scheduler state snapshot",
+			zap.Time("snapshotTime", snapshot.Time),
+			zap.Int("partitions", len(snapshot.Partitions)))
+	}
+	return &stateManager{
+		store:    store,
+		context:  cc,
+		snapshot: snapshot,
+		restored: make(map[string]*state.PartitionState),
+		timers:   make(map[string]*time.Timer),
+		due:      make(map[string]bool),
+	}, nil
+}
+
+// start the periodic snapshots, the interval is read from the config map.
+func (sm *stateManager) start() {
+	interval := readDuration(configs.StateSnapshotInterval, configs.DefaultStateSnapshotInterval)
+	if interval <= 0 {
+		log.Log(log.SchedState).Info("Periodic state snapshot disabled, state is saved on stop only")
+		return
+	}
+	sm.Lock()
+	defer sm.Unlock()
+	stopChan := make(chan struct{})
+	sm.stopChan = stopChan
+	log.Log(log.SchedState).Info("Starting periodic state snapshot", zap.Duration("interval", interval))
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopChan:
+				return
+			case <-ticker.C:
+				if sm.isStandby() {
+					sm.refreshReplica()
+				} else if err := sm.save(); err != nil {
+					log.Log(log.SchedState).Error("Failed to save state snapshot", zap.Error(err))
+				}
+			}
+		}
+	}()
+}
+
+// stop the periodic snapshots and save the final state. A standby never overwrites the state of the leader.
+func (sm *stateManager) stop() {
+	sm.Lock()
+	if sm.stopChan != nil {
+		close(sm.stopChan)
+		sm.stopChan = nil
+	}
+	for name, timer := range sm.timers {
+		timer.Stop()
+		delete(sm.timers, name)
+	}
+	standby := sm.standby
+	sm.Unlock()
+	if standby {
+		return
+	}
+	if err := sm.save(); err != nil {
+		log.Log(log.SchedState).Error("Failed to save state snapshot on stop", zap.Error(err))
+	}
+}
+
+// save takes a snapshot and writes it to the store.
+// Partitions that have not been reconciled yet are saved as restored: a restart during the reconcile
+// delay must not lose the state of the previous run.
+func (sm *stateManager) save() error {
+	snapshot := sm.buildSnapshot()
+	sm.RLock()
+	for name, ps := range sm.restored {
+		for i, current := range snapshot.Partitions {
+			if current.Name == name {
+				snapshot.Partitions[i] = mergePartitionState(current, ps)
+			}
+		}
+	}
+	sm.RUnlock()
+	if err := sm.store.Save(snapshot); err != nil {
+		return err
+	}
+	log.Log(log.SchedState).Debug("Saved state snapshot",
+		zap.Int("partitions", len(snapshot.Partitions)))
+	return nil
+}
+
+// buildSnapshot collects the current state of all partitions and the user and group trackers.
+func (sm *stateManager) buildSnapshot() *state.Snapshot {
+	snapshot := &state.Snapshot{
+		Version: state.SnapshotVersion,
+		Time:    time.Now(),
+	}
+	for _, pc := range sm.context.GetPartitionMapClone() {
+		ps := &state.PartitionState{
+			Name: pc.Name,
+			RmID: pc.RmID,
+		}
+		for _, node := range pc.GetNodes() {
+			ps.Nodes = append(ps.Nodes, &state.NodeState{
+				NodeID:      node.NodeID,
+				Attributes:  node.GetAttributes(),
+				Capacity:    toQuantityMap(node.GetCapacity()),
+				Schedulable: node.IsSchedulable(),
+			})
+		}
+		sort.Slice(ps.Nodes, func(i, j int) bool {
+			return ps.Nodes[i].NodeID < ps.Nodes[j].NodeID
+		})
+		for _, app := range pc.GetApplications() {
+			ps.Applications = append(ps.Applications, newApplicationState(app, true))
+		}
+		for _, app := range pc.GetCompletedApplications() {
+			ps.CompletedApplications = append(ps.CompletedApplications, newApplicationState(app, false))
+		}
+		for _, app := range pc.GetRejectedApplications() {
+			ps.RejectedApplications = append(ps.RejectedApplications, newApplicationState(app, false))
+		}
+		snapshot.Partitions = append(snapshot.Partitions, ps)
+	}
+	sort.Slice(snapshot.Partitions, func(i, j int) bool {
+		return snapshot.Partitions[i].Name < snapshot.Partitions[j].Name
+	})
+	for _, ut := range ugm.GetUserManager().GetUserTrackers() {
+		usage := ut.GetResourceUsageDAOInfo()
+		snapshot.Users = append(snapshot.Users, newTrackerState(usage.UserName, usage.Queues))
+	}
+	for _, gt := range ugm.GetUserManager().GetGroupTrackers() {
+		usage := gt.GetResourceUsageDAOInfo()
+		snapshot.Groups = append(snapshot.Groups, newTrackerState(usage.GroupName, usage.Queues))
+	}
+	if users, groups, queues := ugm.GetUserManager().GetUsageHistory(); users != nil {
+		snapshot.History = &state.UsageHistory{
+			Users:  users,
+			Groups: groups,
+			Queues: queues,
+		}
+	}
+	return snapshot
+}
+
+// restorePartition restores the application history of a newly created partition from the snapshot.
+// The active applications are kept until the partition is reconciled after the reconcile delay.
+// Nothing is restored while in standby, that happens on promotion.
+// Called while the cluster context lock is held.
+func (sm *stateManager) restorePartition(pc *PartitionContext) *state.PartitionState {
+	snapshot := sm.getSnapshot()
+	if snapshot == nil || sm.isStandby() {
+		return nil
+	}
+	var ps *state.PartitionState
+	for _, p := range snapshot.Partitions {
+		if p.Name == pc.Name {
+			ps = p
+			break
+		}
+	}
+	if ps == nil {
+		return nil
+	}
+	completed := make([]*objects.Application, 0, len(ps.CompletedApplications))
+	for _, as := range ps.CompletedApplications {
+		if app := sm.newTerminatedApplication(pc, as); app != nil {
+			completed = append(completed, app)
+		}
+	}
+	rejected := make([]*objects.Application, 0, len(ps.RejectedApplications))
+	for _, as := range ps.RejectedApplications {
+		if app := sm.newTerminatedApplication(pc, as); app != nil {
+			rejected = append(rejected, app)
+		}
+	}
+	pc.restoreApplicationHistory(completed, rejected)
+	sm.restoreUsageHistory(snapshot)
+
+	delay := readDuration(configs.StateReconcileDelay, configs.DefaultStateReconcileDelay)
+	name := pc.Name
+	sm.Lock()
+	sm.restored[name] = ps
+	if timer, ok := sm.timers[name]; ok {
+		timer.Stop()
+	}
+	// the partition is reconciled by the scheduling cycle, the timer only marks it as due
+	sm.timers[name] = time.AfterFunc(delay, func() {
+		sm.Lock()
+		defer sm.Unlock()
+		delete(sm.timers, name)
+		sm.due[name] = true
+	})
+	sm.Unlock()
+	log.Log(log.SchedState).Info("Restored partition from state snapshot",
+		zap.String("partitionName", pc.Name),
+		zap.Int("activeApplications", len(ps.Applications)),
+		zap.Int("completedApplications", len(completed)),
+		zap.Int("rejectedApplications", len(rejected)),
+		zap.Duration("reconcileDelay", delay))
+	return ps
+}
+
+// restoreUsageHistory restores the usage history of the users, groups and queues once. The usage of the trackers
+// is rebuilt from the allocations the RM recovers, the history of the usage cannot be rebuilt.
+func (sm *stateManager) restoreUsageHistory(snapshot *state.Snapshot) {
+	sm.Lock()
+	restored := sm.history
+	sm.history = true
+	sm.Unlock()
+	if restored || snapshot.History == nil {
+		return
+	}
+	ugm.GetUserManager().RestoreUsageHistory(snapshot.History.Users, snapshot.History.Groups, snapshot.History.Queues, snapshot.Time)
+	log.Log(log.SchedState).Info("Restored usage history from state snapshot",
+		zap.Int("users", len(snapshot.History.Users)),
+		zap.Int("groups", len(snapshot.History.Groups)),
+		zap.Int("queues", len(snapshot.History.Queues)))
+}
+
+// newTerminatedApplication creates a completed, failed or rejected application from the snapshot.
+// Returns nil if the application cannot be restored in its state.
+func (sm *stateManager) newTerminatedApplication(pc *PartitionContext, as *state.ApplicationState) *objects.Application {
+	app := objects.NewApplication(&si.AddApplicationRequest{
+		ApplicationID: as.ApplicationID,
+		QueueName:     as.QueuePath,
+		PartitionName: pc.Name,
+		Tags:          as.Tags,
+	}, security.UserGroup{User: as.User, Groups: as.Groups}, sm.context.rmEventHandler, pc.RmID)
+	app.RestoreHistory(as.SubmissionTime, toStateLog(as.StateLog))
+	if err := app.RestoreTerminated(as.State, as.StartTime, as.FinishedTime, as.RejectedMessage); err != nil {
+		log.Log(log.SchedState).Warn("Failed to restore terminated application from state snapshot",
+			zap.String("partitionName", pc.Name),
+			zap.String("applicationID", as.ApplicationID),
+			zap.Error(err))
+		return nil
+	}
+	return app
+}
+
+// restoreApplication restores the history of an active application the RM added again after the restart.
+func (sm *stateManager) restoreApplication(partitionName string, app *objects.Application) {
+	sm.RLock()
+	defer sm.RUnlock()
+	ps, ok := sm.restored[partitionName]
+	if !ok {
+		return
+	}
+	for _, as := range ps.Applications {
+		if as.ApplicationID == app.ApplicationID {
+			app.RestoreHistory(as.SubmissionTime, toStateLog(as.StateLog))
+			return
+		}
+	}
+}
+
+func (sm *stateManager) getSnapshot() *state.Snapshot {
+	sm.RLock()
+	defer sm.RUnlock()
+	return sm.snapshot
+}
+
+func (sm *stateManager) isStandby() bool {
+	sm.RLock()
+	defer sm.RUnlock()
+	return sm.standby
+}
+
+func (sm *stateManager) setStandby(standby bool) {
+	sm.Lock()
+	defer sm.Unlock()
+	sm.standby = standby
+}
+
+// refreshReplica loads the last snapshot saved by the leader. The previous replica is kept if the store
+// cannot be read: a promotion must still be possible if the store is not available.
+func (sm *stateManager) refreshReplica() {
+	snapshot, err := sm.store.Load()
+	if err != nil {
+		log.Log(log.SchedState).Warn("Failed to refresh state replica, keeping previous replica", zap.Error(err))
+		return
+	}
+	if snapshot == nil {
+		return
+	}
+	sm.Lock()
+	defer sm.Unlock()
+	sm.snapshot = snapshot
+	log.Log(log.SchedState).Debug("Refreshed state replica",
+		zap.Time("snapshotTime", snapshot.Time))
+}
+
+// promote applies the replica to the partitions created by the RM registration: the history is restored
+// and the nodes, applications, asks and allocations of the leader are recreated. Changes made by the RM after
+// the last snapshot of the leader are not part of the replica, the reconcile logs what was recovered.
+func (sm *stateManager) promote() {
+	sm.refreshReplica()
+	sm.setStandby(false)
+	snapshot := sm.getSnapshot()
+	if snapshot == nil {
+		log.Log(log.SchedState).Info("No state replica available on promotion")
+		return
+	}
+	log.Log(log.SchedState).Info("Applying state replica on promotion",
+		zap.Time("snapshotTime", snapshot.Time))
+	for _, pc := range sm.context.GetPartitionMapClone() {
+		if ps := sm.restorePartition(pc); ps != nil {
+			sm.applyPartition(pc, ps)
+		}
+	}
+}
+
+// applyPartition recreates the nodes and active applications of the replica in the partition.
+// Objects that already exist in the partition are left untouched.
+func (sm *stateManager) applyPartition(pc *PartitionContext, ps *state.PartitionState) {
+	for _, ns := range ps.Nodes {
+		if pc.GetNode(ns.NodeID) != nil {
+			continue
+		}
+		attributes := make(map[string]string, len(ns.Attributes)+1)
+		for key, value := range ns.Attributes {
+			attributes[key] = value
+		}
+		attributes[siCommon.NodePartition] = pc.Name
+		nodeInfo := &si.NodeInfo{
+			NodeID:              ns.NodeID,
+			Attributes:          attributes,
+			SchedulableResource: toResource(ns.Capacity).ToProto(),
+		}
+		if err := sm.context.addNode(nodeInfo, ns.Schedulable); err != nil {
+			log.Log(log.SchedState).Warn("Failed to add node from state replica",
+				zap.String("nodeID", ns.NodeID),
+				zap.Error(err))
+		}
+	}
+	for _, as := range ps.Applications {
+		if pc.getApplication(as.ApplicationID) != nil {
+			continue
+		}
+		app := objects.NewApplication(&si.AddApplicationRequest{
+			ApplicationID: as.ApplicationID,
+			QueueName:     as.QueuePath,
+			PartitionName: pc.Name,
+			Tags:          as.Tags,
+		}, security.UserGroup{User: as.User, Groups: as.Groups}, sm.context.rmEventHandler, pc.RmID)
+		if err := pc.AddApplication(app); err != nil {
+			log.Log(log.SchedState).Warn("Failed to add application from state replica",
+				zap.String("applicationID", as.ApplicationID),
+				zap.Error(err))
+			continue
+		}
+		app.RestoreHistory(as.SubmissionTime, toStateLog(as.StateLog))
+		for _, alloc := range append(as.Allocations, as.Asks...) {
+			if _, _, err := pc.UpdateAllocation(objects.NewAllocationFromSI(toSIAllocation(as.ApplicationID, pc.Name, alloc))); err != nil {
+				log.Log(log.SchedState).Warn("Failed to add allocation from state replica",
+					zap.String("applicationID", as.ApplicationID),
+					zap.String("allocationKey", alloc.AllocationKey),
+					zap.Error(err))
+			}
+		}
+	}
+}
+
+// isRestoring returns true if the partition was restored and has not been reconciled yet.
+func (sm *stateManager) isRestoring(partitionName string) bool {
+	sm.RLock()
+	defer sm.RUnlock()
+	_, ok := sm.restored[partitionName]
+	return ok
+}
+
+// reconcileIfDue reconciles the partition if the reconcile delay has passed.
+// Called from the scheduling cycle of the partition: the reconcile does not run in parallel with allocations.
+func (sm *stateManager) reconcileIfDue(pc *PartitionContext) *reconcileResult {
+	sm.Lock()
+	due := sm.due[pc.Name]
+	delete(sm.due, pc.Name)
+	sm.Unlock()
+	if !due {
+		return nil
+	}
+	return sm.reconcilePartition(pc)
+}
+
+// reconcilePartition compares the restored active applications with what the RM recovered.
+// The RM is the source of truth: allocations it did not report are dropped from the restored state,
+// reservations are placed again if the ask is still pending and the node is still registered.
+func (sm *stateManager) reconcilePartition(pc *PartitionContext) *reconcileResult {
+	sm.Lock()
+	ps, ok := sm.restored[pc.Name]
+	delete(sm.restored, pc.Name)
+	sm.Unlock()
+	if !ok {
+		return nil
+	}
+	result := &reconcileResult{}
+	for _, as := range ps.Applications {
+		app := pc.getApplication(as.ApplicationID)
+		if app == nil {
+			result.lostApps = append(result.lostApps, as.ApplicationID)
+			log.Log(log.SchedState).Info("Application from state snapshot was not recovered by the RM",
+				zap.String("partitionName", pc.Name),
+				zap.String("applicationID", as.ApplicationID),
+				zap.String("state", as.State))
+			continue
+		}
+		result.restoredApps++
+		for _, alloc := range as.Allocations {
+			if ask := app.GetAllocationAsk(alloc.AllocationKey); ask != nil && ask.IsAllocated() {
+				result.recoveredAllocations++
+				continue
+			}
+			result.droppedAllocations = append(result.droppedAllocations, alloc.AllocationKey)
+			log.Log(log.SchedState).Info("Allocation from state snapshot was not recovered by the RM",
+				zap.String("partitionName", pc.Name),
+				zap.String("applicationID", as.ApplicationID),
+				zap.String("allocationKey", alloc.AllocationKey),
+				zap.String("nodeID", alloc.NodeID))
+		}
+		for _, res := range as.Reservations {
+			ask := app.GetAllocationAsk(res.AllocationKey)
+			node := pc.GetNode(res.NodeID)
+			if ask == nil || ask.IsAllocated() || node == nil || app.NodeReservedForAsk(res.AllocationKey) != "" {
+				result.unrestoredReservations++
+				continue
+			}
+			pc.reserve(app, node, ask)
+			result.restoredReservations++
+		}
+	}
+	result.changedUserUsage, result.changedGroupUsage = sm.compareTrackers()
+	log.Log(log.SchedState).Info("Reconciled partition with state snapshot",
+		zap.String("partitionName", pc.Name),
+		zap.Int("restoredApplications", result.restoredApps),
+		zap.Strings("lostApplications", result.lostApps),
+		zap.Int("recoveredAllocations", result.recoveredAllocations),
+		zap.Strings("droppedAllocations", result.droppedAllocations),
+		zap.Int("restoredReservations", result.restoredReservations),
+		zap.Int("unrestoredReservations", result.unrestoredReservations),
+		zap.Strings("changedUserUsage", result.changedUserUsage),
+		zap.Strings("changedGroupUsage", result.changedGroupUsage))
+	return result
+}
+
+// compareTrackers returns the users and groups with a different usage than in the snapshot.
+// The trackers are rebuilt from the allocations the RM recovered: a difference means that allocations
+// finished or were started while the scheduler was down.
+func (sm *stateManager) compareTrackers() ([]string, []string) {
+	current := make(map[string]*state.TrackerState)
+	for _, ut := range ugm.GetUserManager().GetUserTrackers() {
+		usage := ut.GetResourceUsageDAOInfo()
+		current[usage.UserName] = newTrackerState(usage.UserName, usage.Queues)
+	}
+	snapshot := sm.getSnapshot()
+	var users []string
+	for _, ts := range snapshot.Users {
+		if !trackerUsageEqual(ts, current[ts.Name]) {
+			users = append(users, ts.Name)
+		}
+	}
+	current = make(map[string]*state.TrackerState)
+	for _, gt := range ugm.GetUserManager().GetGroupTrackers() {
+		usage := gt.GetResourceUsageDAOInfo()
+		current[usage.GroupName] = newTrackerState(usage.GroupName, usage.Queues)
+	}
+	var groups []string
+	for _, ts := range snapshot.Groups {
+		if !trackerUsageEqual(ts, current[ts.Name]) {
+			groups = append(groups, ts.Name)
+		}
+	}
+	return users, groups
+}
+
+// mergePartitionState adds the active applications that are still waiting for the RM to the current state.
+func mergePartitionState(current, restored *state.PartitionState) *state.PartitionState {
+	known := make(map[string]bool, len(current.Applications))
+	for _, as := range current.Applications {
+		known[as.ApplicationID] = true
+	}
+	for _, as := range restored.Applications {
+		if !known[as.ApplicationID] {
+			current.Applications = append(current.Applications, as)
+		}
+	}
+	return current
+}
+
+// newApplicationState converts the application. Allocations and reservations are only added for active applications.
+func newApplicationState(app *objects.Application, active bool) *state.ApplicationState {
+	ugi := app.GetUser()
+	as := &state.ApplicationState{
+		ApplicationID:   app.ApplicationID,
+		QueuePath:       app.GetQueuePath(),
+		User:            ugi.User,
+		Groups:          ugi.Groups,
+		Tags:            app.GetTagsClone(),
+		State:           app.CurrentState(),
+		SubmissionTime:  app.SubmissionTime,
+		StartTime:       app.StartTime(),
+		FinishedTime:    app.FinishedTime(),
+		RejectedMessage: app.GetRejectedMessage(),
+	}
+	for _, entry := range app.GetStateLog() {
+		as.StateLog = append(as.StateLog, &state.StateLogEntry{
+			Time:  entry.Time,
+			State: entry.ApplicationState,
+		})
+	}
+	if !active {
+		return as
+	}
+	for _, alloc := range app.GetAllAllocations() {
+		as.Allocations = append(as.Allocations, newAllocationState(alloc))
+	}
+	for _, ask := range app.GetAllRequests() {
+		if !ask.IsAllocated() {
+			as.Asks = append(as.Asks, newAllocationState(ask))
+		}
+		if nodeID := app.NodeReservedForAsk(ask.GetAllocationKey()); nodeID != "" {
+			as.Reservations = append(as.Reservations, &state.ReservationState{
+				AllocationKey: ask.GetAllocationKey(),
+				NodeID:        nodeID,
+			})
+		}
+	}
+	return as
+}
+
+// newAllocationState converts an allocation or a pending ask.
+func newAllocationState(alloc *objects.Allocation) *state.AllocationState {
+	return &state.AllocationState{
+		AllocationKey: alloc.GetAllocationKey(),
+		NodeID:        alloc.GetNodeID(),
+		Resource:      toQuantityMap(alloc.GetAllocatedResource()),
+		Priority:      alloc.GetPriority(),
+		TaskGroup:     alloc.GetTaskGroup(),
+		Placeholder:   alloc.IsPlaceholder(),
+		Tags:          alloc.GetTagsClone(),
+	}
+}
+
+// toSIAllocation converts the state back into the allocation the RM would have sent.
+func toSIAllocation(appID, partitionName string, alloc *state.AllocationState) *si.Allocation {
+	return &si.Allocation{
+		AllocationKey:    alloc.AllocationKey,
+		ApplicationID:    appID,
+		PartitionName:    partitionName,
+		NodeID:           alloc.NodeID,
+		ResourcePerAlloc: toResource(alloc.Resource).ToProto(),
+		Priority:         alloc.Priority,
+		TaskGroupName:    alloc.TaskGroup,
+		Placeholder:      alloc.Placeholder,
+		AllocationTags:   alloc.Tags,
+	}
+}
+
+// newTrackerState flattens the queue usage tree of a user or group tracker.
+func newTrackerState(name string, queues *dao.ResourceUsageDAOInfo) *state.TrackerState {
+	ts := &state.TrackerState{
+		Name:  name,
+		Usage: make(map[string]map[string]int64),
+	}
+	var collect func(info *dao.ResourceUsageDAOInfo)
+	collect = func(info *dao.ResourceUsageDAOInfo) {
+		if info == nil {
+			return
+		}
+		if len(info.ResourceUsage) != 0 {
+			ts.Usage[info.QueuePath] = info.ResourceUsage
+		}
+		for _, child := range info.Children {
+			collect(child)
+		}
+	}
+	collect(queues)
+	return ts
+}
+
+// trackerUsageEqual compares the usage of two tracker states, a missing tracker has no usage.
+func trackerUsageEqual(left, right *state.TrackerState) bool {
+	var leftUsage, rightUsage map[string]map[string]int64
+	if left != nil {
+		leftUsage = left.Usage
+	}
+	if right != nil {
+		rightUsage = right.Usage
+	}
+	if len(leftUsage) != len(rightUsage) {
+		return false
+	}
+	for queuePath, usage := range leftUsage {
+		if !resources.Equals(toResource(usage), toResource(rightUsage[queuePath])) {
+			return false
+		}
+	}
+	return true
+}
+
+func toStateLog(entries []*state.StateLogEntry) []*objects.StateLogEntry {
+	stateLog := make([]*objects.StateLogEntry, 0, len(entries))
+	for _, entry := range entries {
+		stateLog = append(stateLog, &objects.StateLogEntry{
+			Time:             entry.Time,
+			ApplicationState: entry.State,
+		})
+	}
+	return stateLog
+}
+
+func toQuantityMap(res *resources.Resource) map[string]int64 {
+	if res == nil {
+		return nil
+	}
+	quantities := make(map[string]int64, len(res.Resources))
+	for name, value := range res.Resources {
+		quantities[name] = int64(value)
+	}
+	return quantities
+}
+
+func toResource(quantities map[string]int64) *resources.Resource {
+	res := resources.NewResource()
+	for name, value := range quantities {
+		res.Resources[name] = resources.Quantity(value)
+	}
+	return res
+}
+
+// readDuration reads a duration from the config map, the default is returned if not set or invalid.
+func readDuration(key string, defaultValue time.Duration) time.Duration {
+	value, ok := configs.GetConfigMap()[key]
+	if !ok {
+		return defaultValue
+	}
+	result, err := time.ParseDuration(value)
+	if err != nil {
+		log.Log(log.SchedState).Warn("Failed to parse configuration value",
+			zap.String("key", key),
+			zap.String("value", value),
+			zap.Error(err))
+		return defaultValue
+	}
+	return result
+}
@@ -0,0 +1,266 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+	"github.com/apache/yunikorn-core/pkg/common/security"
+	"github.com/apache/yunikorn-core/pkg/scheduler/objects"
+	"github.com/apache/yunikorn-core/pkg/scheduler/state"
+	"github.com/apache/yunikorn-core/pkg/scheduler/ugm"
+)
+
+func TestStateManager_SaveRestore(t *testing.T) {
+	setupUGM()
+	// keep the reconcile timer from firing during the test
+	configs.SetConfigMap(map[string]string{configs.StateReconcileDelay: "1h"})
+	defer configs.SetConfigMap(map[string]string{})
+	store := state.NewMemoryStore()
+	res, err := resources.NewResourceFromConf(map[string]string{"vcore": "1"})
+	assert.NilError(t, err, "failed to create resource")
+
+	// first run: app-1 running with two allocations and a reservation, app-2 completed, app-3 rejected
+	context := createTestContext(t, pName)
+	partition := context.GetPartition(pName)
+	addStateTestNodes(t, partition)
+	app := newApplication(appID1, pName, defQueue)
+	assert.NilError(t, partition.AddApplication(app), "failed to add app-1")
+	_, _, err = partition.UpdateAllocation(newAllocation(allocKey, appID1, nodeID1, res))
+	assert.NilError(t, err, "failed to add allocation alloc-1")
+	_, _, err = partition.UpdateAllocation(newAllocation(allocKey2, appID1, nodeID2, res))
+	assert.NilError(t, err, "failed to add allocation alloc-2")
+	ask := newAllocationAsk(allocKey3, appID1, res)
+	assert.NilError(t, app.AddAllocationAsk(ask), "failed to add ask alloc-3")
+	partition.reserve(app, partition.GetNode(nodeID2), ask)
+	completed := newApplication(appID2, pName, defQueue)
+	assert.NilError(t, partition.AddApplication(completed), "failed to add app-2")
+	completed.SetState(objects.Completed.String())
+	partition.moveTerminatedApp(appID2)
+	partition.AddRejectedApplication(newApplication(appID3, pName, defQueue), "rejected in test")
+
+	sm, err := newStateManager(context, store)
+	assert.NilError(t, err, "failed to create state manager")
+	assert.Assert(t, sm.snapshot == nil, "empty store should not return a snapshot")
+	assert.NilError(t, sm.save(), "failed to save state")
+	var snapshot *state.Snapshot
+	snapshot, err = store.Load()
+	assert.NilError(t, err, "failed to load state")
+	assert.Equal(t, len(snapshot.Partitions), 1, "expected one partition")
+	ps := snapshot.Partitions[0]
+	assert.Equal(t, len(ps.Applications), 1, "expected one active application")
+	assert.Equal(t, len(ps.Applications[0].Allocations), 2, "expected two allocations")
+	assert.DeepEqual(t, ps.Applications[0].Reservations, []*state.ReservationState{{AllocationKey: allocKey3, NodeID: nodeID2}})
+	assert.Equal(t, len(ps.CompletedApplications), 1, "expected one completed application")
+	assert.Equal(t, len(ps.RejectedApplications), 1, "expected one rejected application")
+	assert.Equal(t, len(snapshot.Users), 1, "expected one user tracker")
+
+	// crash and restart: the RM only recovers alloc-1 and the pending ask
+	setupUGM()
+	context = createTestContext(t, pName)
+	partition = context.GetPartition(pName)
+	sm, err = newStateManager(context, store)
+	assert.NilError(t, err, "failed to create state manager after restart")
+	assert.Assert(t, sm.snapshot != nil, "snapshot should have been loaded")
+	context.stateManager = sm
+	sm.restorePartition(partition)
+
+	history := partition.GetCompletedApplications()
+	assert.Equal(t, len(history), 1, "completed application not restored")
+	assert.Equal(t, history[0].ApplicationID, appID2)
+	assert.Equal(t, history[0].CurrentState(), objects.Completed.String())
+	history = partition.GetRejectedApplications()
+	assert.Equal(t, len(history), 1, "rejected application not restored")
+	assert.Equal(t, history[0].GetRejectedMessage(), "rejected in test")
+
+	addStateTestNodes(t, partition)
+	recovered := newApplication(appID1, pName, defQueue)
+	assert.NilError(t, partition.AddApplication(recovered), "failed to add app-1 after restart")
+	sm.restoreApplication(partition.Name, recovered)
+	assert.Assert(t, recovered.SubmissionTime.Equal(ps.Applications[0].SubmissionTime), "submission time not restored")
+	assert.Assert(t, len(recovered.GetStateLog()) >= len(ps.Applications[0].StateLog), "state log not restored")
+	_, _, err = partition.UpdateAllocation(newAllocation(allocKey, appID1, nodeID1, res))
+	assert.NilError(t, err, "failed to recover allocation alloc-1")
+	assert.NilError(t, recovered.AddAllocationAsk(newAllocationAsk(allocKey3, appID1, res)), "failed to recover ask alloc-3")
+
+	result := sm.reconcilePartition(partition)
+	assert.Assert(t, result != nil, "partition should have been reconciled")
+	assert.Equal(t, result.restoredApps, 1)
+	assert.Equal(t, len(result.lostApps), 0)
+	assert.Equal(t, result.recoveredAllocations, 1)
+	assert.DeepEqual(t, result.droppedAllocations, []string{allocKey2})
+	assert.Equal(t, result.restoredReservations, 1)
+	assert.Equal(t, recovered.NodeReservedForAsk(allocKey3), nodeID2, "reservation not restored")
+	assert.DeepEqual(t, result.changedUserUsage, []string{"testuser"})
+	assert.Assert(t, sm.reconcilePartition(partition) == nil, "partition should only be reconciled once")
+}
+
+func TestStateManager_LostApplication(t *testing.T) {
+	setupUGM()
+	configs.SetConfigMap(map[string]string{configs.StateReconcileDelay: "1h"})
+	defer configs.SetConfigMap(map[string]string{})
+	store := state.NewMemoryStore()
+	context := createTestContext(t, pName)
+	partition := context.GetPartition(pName)
+	assert.NilError(t, partition.AddApplication(newApplication(appID1, pName, defQueue)), "failed to add app-1")
+	sm, err := newStateManager(context, store)
+	assert.NilError(t, err, "failed to create state manager")
+	assert.NilError(t, sm.save(), "failed to save state")
+
+	// restart: RM does not add the app again, saving before reconcile must keep it
+	context = createTestContext(t, pName)
+	partition = context.GetPartition(pName)
+	sm, err = newStateManager(context, store)
+	assert.NilError(t, err, "failed to create state manager after restart")
+	sm.restorePartition(partition)
+	assert.NilError(t, sm.save(), "failed to save state before reconcile")
+	var snapshot *state.Snapshot
+	snapshot, err = store.Load()
+	assert.NilError(t, err, "failed to load state")
+	assert.Equal(t, len(snapshot.Partitions[0].Applications), 1, "unreconciled application should be kept")
+
+	result := sm.reconcilePartition(partition)
+	assert.DeepEqual(t, result.lostApps, []string{appID1})
+	assert.NilError(t, sm.save(), "failed to save state after reconcile")
+	snapshot, err = store.Load()
+	assert.NilError(t, err, "failed to load state")
+	assert.Equal(t, len(snapshot.Partitions[0].Applications), 0, "lost application should be removed")
+}
+
+func TestStateManager_ReconcileTimer(t *testing.T) {
+	setupUGM()
+	configs.SetConfigMap(map[string]string{configs.StateReconcileDelay: "10ms"})
+	defer configs.SetConfigMap(map[string]string{})
+	store := state.NewMemoryStore()
+	context := createTestContext(t, pName)
+	sm, err := newStateManager(context, store)
+	assert.NilError(t, err, "failed to create state manager")
+	assert.NilError(t, sm.save(), "failed to save state")
+
+	sm, err = newStateManager(createTestContext(t, pName), store)
+	assert.NilError(t, err, "failed to create state manager after restart")
+	partition := sm.context.GetPartition(pName)
+	sm.restorePartition(partition)
+	assert.Assert(t, sm.isRestoring(pName), "partition should be waiting for reconcile")
+	assert.Assert(t, sm.reconcileIfDue(partition) == nil, "partition should not be reconciled before the delay")
+	time.Sleep(100 * time.Millisecond)
+	// the timer only marks the partition, the scheduling cycle reconciles
+	assert.Assert(t, sm.isRestoring(pName), "partition should not be reconciled by the timer")
+	sm.context.stateManager = sm
+	sm.context.schedulePartition(partition, 1)
+	assert.Assert(t, !sm.isRestoring(pName), "partition should have been reconciled by the scheduling cycle")
+
+	// stopping the state manager stops the pending reconcile timers
+	configs.SetConfigMap(map[string]string{configs.StateReconcileDelay: "10ms"})
+	sm, err = newStateManager(createTestContext(t, pName), store)
+	assert.NilError(t, err, "failed to create state manager after restart")
+	partition = sm.context.GetPartition(pName)
+	sm.restorePartition(partition)
+	sm.stop()
+	time.Sleep(100 * time.Millisecond)
+	assert.Assert(t, sm.reconcileIfDue(partition) == nil, "stopped timer should not mark the partition")
+}
+
+func TestStateManager_UsageHistory(t *testing.T) {
+	setupUGM()
+	configs.SetConfigMap(map[string]string{configs.StateReconcileDelay: "1h", configs.FairShareHalfLife: "1h"})
+	defer configs.SetConfigMap(map[string]string{})
+	defer ugm.GetUserManager().ClearUsageHistory()
+	store := state.NewMemoryStore()
+	sm, err := newStateManager(createTestContext(t, pName), store)
+	assert.NilError(t, err, "failed to create state manager")
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 100})
+	user := security.UserGroup{User: "testuser", Groups: []string{"testgroup"}}
+	ugm.GetUserManager().IncreaseTrackedResource("root.default", appID1, res, user)
+	time.Sleep(10 * time.Millisecond)
+	ugm.GetUserManager().DecreaseTrackedResource("root.default", appID1, res, user, true)
+	used := ugm.GetUserManager().GetUserUsageHistory("testuser")["memory"]
+	assert.Assert(t, used > 0, "usage history should be tracked")
+	assert.NilError(t, sm.save(), "failed to save state")
+
+	// restart: the history is restored with the partition
+	ugm.GetUserManager().ClearUsageHistory()
+	sm, err = newStateManager(createTestContext(t, pName), store)
+	assert.NilError(t, err, "failed to create state manager after restart")
+	sm.restorePartition(sm.context.GetPartition(pName))
+	restored := ugm.GetUserManager().GetUserUsageHistory("testuser")["memory"]
+	assert.Assert(t, restored > 0 && restored <= used, "usage history not restored: %f", restored)
+	assert.Assert(t, ugm.GetUserManager().GetQueueUsageHistory("root.default") != nil, "queue usage history not restored")
+	// restoring another partition does not add the history again
+	sm.restorePartition(sm.context.GetPartition(pName))
+	assert.Assert(t, ugm.GetUserManager().GetUserUsageHistory("testuser")["memory"] <= restored, "usage history restored twice")
+}
+
+func TestStateManager_Promote(t *testing.T) {
+	setupUGM()
+	configs.SetConfigMap(map[string]string{configs.StateReconcileDelay: "1h"})
+	defer configs.SetConfigMap(map[string]string{})
+	store := state.NewMemoryStore()
+	res, err := resources.NewResourceFromConf(map[string]string{"vcore": "1"})
+	assert.NilError(t, err, "failed to create resource")
+
+	// leader: app-1 with an allocation and a pending ask
+	leader := createTestContext(t, pName)
+	partition := leader.GetPartition(pName)
+	addStateTestNodes(t, partition)
+	app := newApplication(appID1, pName, defQueue)
+	assert.NilError(t, partition.AddApplication(app), "failed to add app-1")
+	_, _, err = partition.UpdateAllocation(newAllocation(allocKey, appID1, nodeID1, res))
+	assert.NilError(t, err, "failed to add allocation alloc-1")
+	assert.NilError(t, app.AddAllocationAsk(newAllocationAsk(allocKey2, appID1, res)), "failed to add ask alloc-2")
+	var sm *stateManager
+	sm, err = newStateManager(leader, store)
+	assert.NilError(t, err, "failed to create leader state manager")
+	assert.NilError(t, sm.save(), "failed to save state")
+
+	// standby: nothing is restored until promotion
+	setupUGM()
+	standby := createTestContext(t, pName)
+	partition = standby.GetPartition(pName)
+	sm, err = newStateManager(standby, store)
+	assert.NilError(t, err, "failed to create standby state manager")
+	sm.setStandby(true)
+	standby.stateManager = sm
+	assert.Assert(t, sm.restorePartition(partition) == nil, "standby should not restore")
+	sm.stop()
+	assert.Equal(t, len(partition.GetNodes()), 0, "standby should not have nodes")
+
+	sm.promote()
+	assert.Assert(t, !sm.isStandby(), "state manager should not be standby after promotion")
+	assert.Equal(t, len(partition.GetNodes()), 2, "nodes not applied")
+	promoted := partition.getApplication(appID1)
+	assert.Assert(t, promoted != nil, "application not applied")
+	assert.Assert(t, promoted.SubmissionTime.Equal(app.SubmissionTime), "history not applied")
+	assert.Assert(t, promoted.GetAllocationAsk(allocKey) != nil && promoted.GetAllocationAsk(allocKey).IsAllocated(), "allocation not applied")
+	assert.Assert(t, promoted.GetAllocationAsk(allocKey2) != nil && !promoted.GetAllocationAsk(allocKey2).IsAllocated(), "pending ask not applied")
+	assert.Assert(t, resources.Equals(partition.GetNode(nodeID1).GetAllocatedResource(), res), "node usage not applied")
+	assert.Assert(t, sm.isRestoring(pName), "promoted partition should be reconciled")
+}
+
+func addStateTestNodes(t *testing.T, partition *PartitionContext) {
+	res, err := resources.NewResourceFromConf(map[string]string{"vcore": "10"})
+	assert.NilError(t, err, "failed to create node resource")
+	assert.NilError(t, partition.AddNode(newNodeMaxResource(nodeID1, res)), "failed to add node-1")
+	assert.NilError(t, partition.AddNode(newNodeMaxResource(nodeID2, res)), "failed to add node-2")
+}
@@ -26,8 +26,10 @@ import (
 	"github.com/apache/yunikorn-core/pkg/common"
 	"github.com/apache/yunikorn-core/pkg/entrypoint"
 	"github.com/apache/yunikorn-core/pkg/events"
+	"github.com/apache/yunikorn-core/pkg/rmproxy"
 	"github.com/apache/yunikorn-core/pkg/scheduler"
 	"github.com/apache/yunikorn-core/pkg/scheduler/objects"
+	"github.com/apache/yunikorn-core/pkg/scheduler/state"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/api"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
 )
@@ -39,6 +41,7 @@ type mockScheduler struct {
 	serviceContext *entrypoint.ServiceContext
 	rmID           string
 	partitionName  string
+	standby        bool // start the scheduler and proxy as a hot standby
 }
 
 // Create the mock scheduler with the config provided.
@@ -47,6 +50,16 @@ type mockScheduler struct {
 // Auto scheduling does not give control over the scheduling steps and should only
 // be used in specific use case testing.
 func (m *mockScheduler) Init(config string, autoSchedule bool, withWebapp bool) error {
+	return m.init(config, autoSchedule, withWebapp, nil, nil)
+}
+
+// InitWithStateStore creates the manual mock scheduler that restores and saves its state in the store.
+// The extra config is passed on registration: it is used to set the state options.
+func (m *mockScheduler) InitWithStateStore(config string, store state.Store, extraConfig map[string]string) error {
+	return m.init(config, false, false, store, extraConfig)
+}
+
+func (m *mockScheduler) init(config string, autoSchedule bool, withWebapp bool, store state.Store, extraConfig map[string]string) error {
 	m.rmID = "rm:123"
 	m.partitionName = common.GetNormalizedPartitionName("default", m.rmID)
 
@@ -60,6 +73,17 @@ func (m *mockScheduler) Init(config string, autoSchedule bool, withWebapp bool)
 	m.scheduler = m.serviceContext.Scheduler
 
 	m.mockRM = newMockRMCallbackHandler()
+	if m.standby {
+		m.scheduler.EnableStandby()
+		if proxy, ok := m.proxy.(*rmproxy.RMProxy); ok {
+			proxy.SetStandby(true)
+		}
+	}
+	if store != nil {
+		if err := m.scheduler.EnableStateStore(store); err != nil {
+			return err
+		}
+	}
 
 	if withWebapp {
 		err := common.WaitForCondition(500*time.Millisecond, 2*time.Second, func() bool {
@@ -82,6 +106,7 @@ func (m *mockScheduler) Init(config string, autoSchedule bool, withWebapp bool)
 			Version:     "0.0.2",
 			BuildInfo:   BuildInfoMap,
 			Config:      config,
+			ExtraConfig: extraConfig,
 		}, m.mockRM)
 	return err
 }
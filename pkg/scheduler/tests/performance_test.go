@@ -26,13 +26,14 @@ import (
 
 	"gotest.tools/v3/assert"
 
+	"github.com/apache/yunikorn-core/pkg/common/configs"
 	"github.com/apache/yunikorn-core/pkg/entrypoint"
 	"github.com/apache/yunikorn-core/pkg/log"
 	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
 )
 
 //nolint:funlen
-func benchmarkScheduling(b *testing.B, numNodes, numPods int) {
+func benchmarkScheduling(b *testing.B, numNodes, numPods, batchSize int) {
 	log.UpdateLoggingConfig(map[string]string{"log.level": "WARN"})
 	defer log.UpdateLoggingConfig(nil)
 
@@ -74,7 +75,8 @@ partitions:
 			BuildInfo:   BuildInfoMap,
 			Config:      configData,
 			ExtraConfig: map[string]string{
-				"log.level": "WARN",
+				"log.level":                 "WARN",
+				configs.SchedulingBatchSize: strconv.Itoa(batchSize),
 			},
 		}, mockRM)
 
@@ -184,6 +186,7 @@ partitions:
 	duration = time.Since(startTime)
 
 	b.Logf("Total time to allocate %d containers in %s, %f per second", numPods, duration, float64(numPods)/duration.Seconds())
+	b.ReportMetric(float64(numPods)/duration.Seconds(), "allocs/s")
 }
 
 func BenchmarkScheduling(b *testing.B) {
@@ -196,7 +199,110 @@ func BenchmarkScheduling(b *testing.B) {
 	for _, test := range tests {
 		name := fmt.Sprintf("%vNodes/%vPods", test.numNodes, test.numPods)
 		b.Run(name, func(b *testing.B) {
-			benchmarkScheduling(b, test.numNodes, test.numPods)
+			benchmarkScheduling(b, test.numNodes, test.numPods, configs.DefaultSchedulingBatchSize)
 		})
 	}
 }
+
+// BenchmarkBatchScheduling compares the single allocation cycle (batch size 1) with batched cycles.
+// The pods are spread over many applications: a cycle sorts all applications with pending requests.
+func BenchmarkBatchScheduling(b *testing.B) {
+	tests := []struct{ numNodes, numApps, numPods, batchSize int }{
+		{numNodes: 1000, numApps: 1000, numPods: 10000, batchSize: 1},
+		{numNodes: 1000, numApps: 1000, numPods: 10000, batchSize: 10},
+		{numNodes: 1000, numApps: 1000, numPods: 10000, batchSize: 100},
+		{numNodes: 1000, numApps: 1000, numPods: 10000, batchSize: 1000},
+	}
+	for _, test := range tests {
+		name := fmt.Sprintf("%vNodes/%vApps/%vPods/batch%v", test.numNodes, test.numApps, test.numPods, test.batchSize)
+		b.Run(name, func(b *testing.B) {
+			benchmarkBatchScheduling(b, test.numNodes, test.numApps, test.numPods, test.batchSize)
+		})
+	}
+}
+
+const configDataBatch = `
+partitions:
+  - name: default
+    queues:
+      - name: root
+        submitacl: "*"
+        queues:
+          - name: leaf-0
+          - name: leaf-1
+`
+
+func benchmarkBatchScheduling(b *testing.B, numNodes, numApps, numPods, batchSize int) {
+	log.UpdateLoggingConfig(map[string]string{"log.level": "WARN"})
+	defer log.UpdateLoggingConfig(nil)
+
+	serviceContext := entrypoint.StartAllServices()
+	defer serviceContext.StopAll()
+	proxy := serviceContext.RMProxy
+	mockRM := newMockRMCallbackHandler()
+	_, err := proxy.RegisterResourceManager(
+		&si.RegisterResourceManagerRequest{
+			RmID:        "rm:123",
+			PolicyGroup: "policygroup",
+			Version:     "0.0.2",
+			Config:      configDataBatch,
+			ExtraConfig: map[string]string{
+				"log.level":                 "WARN",
+				configs.SchedulingBatchSize: strconv.Itoa(batchSize),
+			},
+		}, mockRM)
+	assert.NilError(b, err, "RegisterResourceManager failed")
+
+	// nodes fit all pods
+	numPodsPerNode := numPods/numNodes + 1
+	newNodes := make([]*si.NodeInfo, numNodes)
+	for i := 0; i < numNodes; i++ {
+		newNodes[i] = &si.NodeInfo{
+			NodeID:     fmt.Sprintf("node-%d:1234", i),
+			Attributes: map[string]string{},
+			SchedulableResource: &si.Resource{
+				Resources: map[string]*si.Quantity{
+					"memory": {Value: int64(10 * numPodsPerNode)},
+					"vcore":  {Value: int64(numPodsPerNode)},
+				},
+			},
+			Action: si.NodeInfo_CREATE,
+		}
+	}
+	err = proxy.UpdateNode(&si.NodeRequest{RmID: "rm:123", Nodes: newNodes})
+	assert.NilError(b, err, "NodeRequest nodes failed")
+	mockRM.waitForMinAcceptedNodes(b, numNodes, 5000)
+
+	apps := make(map[string]string, numApps)
+	for i := 0; i < numApps; i++ {
+		apps[fmt.Sprintf("app-%d", i)] = fmt.Sprintf("root.leaf-%d", i%2)
+	}
+	err = proxy.UpdateApplication(&si.ApplicationRequest{New: newAddAppRequest(apps), RmID: "rm:123"})
+	assert.NilError(b, err, "UpdateRequest application failed")
+	for appID := range apps {
+		mockRM.waitForAcceptedApplication(b, appID, 5000)
+	}
+
+	startTime := time.Now()
+	b.ResetTimer()
+	asks := make([]*si.Allocation, numPods)
+	for i := 0; i < numPods; i++ {
+		asks[i] = &si.Allocation{
+			AllocationKey: fmt.Sprintf("alloc-%d", i),
+			ResourcePerAlloc: &si.Resource{
+				Resources: map[string]*si.Quantity{
+					"memory": {Value: 10},
+					"vcore":  {Value: 1},
+				},
+			},
+			ApplicationID: fmt.Sprintf("app-%d", i%numApps),
+		}
+	}
+	err = proxy.UpdateAllocation(&si.AllocationRequest{Allocations: asks, RmID: "rm:123"})
+	assert.NilError(b, err, "AllocationRequest failed")
+	mockRM.waitForMinAllocations(b, numPods, 300000)
+	b.StopTimer()
+	duration := time.Since(startTime)
+	b.Logf("Total time to allocate %d containers over %d apps in %s, %f per second", numPods, numApps, duration, float64(numPods)/duration.Seconds())
+	b.ReportMetric(float64(numPods)/duration.Seconds(), "allocs/s")
+}
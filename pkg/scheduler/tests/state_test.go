@@ -0,0 +1,148 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package tests
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-core/pkg/common"
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-core/pkg/rmproxy"
+	"github.com/apache/yunikorn-core/pkg/scheduler/state"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+func TestSchedulerStateRestart(t *testing.T) {
+	store, err := state.NewFileStore(t.TempDir())
+	assert.NilError(t, err, "failed to create state store")
+	extraConfig := map[string]string{configs.StateReconcileDelay: "100ms"}
+	nodeRes := &si.Resource{Resources: map[string]*si.Quantity{"memory": {Value: 100}, "vcore": {Value: 20}}}
+	askRes := &si.Resource{Resources: map[string]*si.Quantity{"memory": {Value: 10}, "vcore": {Value: 1}}}
+
+	// first run: one running application and one rejected application
+	ms := &mockScheduler{}
+	err = ms.InitWithStateStore(configData, store, extraConfig)
+	assert.NilError(t, err, "RegisterResourceManager failed")
+	assert.NilError(t, ms.addNode("node-1", nodeRes), "NodeRequest failed")
+	ms.mockRM.waitForAcceptedNode(t, "node-1", 1000)
+	assert.NilError(t, ms.addApp(appID1, "root.a", "default"), "ApplicationRequest failed")
+	ms.mockRM.waitForAcceptedApplication(t, appID1, 1000)
+	assert.NilError(t, ms.addApp(appID2, "root.unknown", "default"), "ApplicationRequest failed")
+	ms.mockRM.waitForRejectedApplication(t, appID2, 1000)
+	assert.NilError(t, ms.addAppRequest(appID1, "alloc", askRes, 1), "AllocationRequest failed")
+	ms.scheduler.MultiStepSchedule(5)
+	ms.mockRM.waitForAllocations(t, 1, 1000)
+	submissionTime := ms.getApplication(appID1).SubmissionTime
+	allocation := ms.mockRM.getAllocations()["alloc-0"]
+	assert.Assert(t, allocation != nil, "allocation not found")
+
+	// crash: the state was saved before the scheduler went down
+	assert.NilError(t, ms.scheduler.SaveState(), "failed to save state")
+	ms.Stop()
+
+	// restart: the history is restored on registration, the RM recovers the app and allocation
+	ms = &mockScheduler{}
+	defer ms.Stop()
+	err = ms.InitWithStateStore(configData, store, extraConfig)
+	assert.NilError(t, err, "RegisterResourceManager after restart failed")
+	part := ms.scheduler.GetClusterContext().GetPartition(ms.partitionName)
+	rejected := part.GetRejectedApplications()
+	assert.Equal(t, len(rejected), 1, "rejected application history not restored")
+	assert.Equal(t, rejected[0].ApplicationID, appID2)
+
+	assert.NilError(t, ms.addNode("node-1", nodeRes), "NodeRequest failed")
+	ms.mockRM.waitForAcceptedNode(t, "node-1", 1000)
+	assert.NilError(t, ms.addApp(appID1, "root.a", "default"), "ApplicationRequest failed")
+	ms.mockRM.waitForAcceptedApplication(t, appID1, 1000)
+	assert.NilError(t, ms.proxy.UpdateAllocation(&si.AllocationRequest{
+		Allocations: []*si.Allocation{allocation},
+		RmID:        ms.rmID,
+	}), "recovering allocation failed")
+	app := ms.getApplication(appID1)
+	assert.Assert(t, app.SubmissionTime.Equal(submissionTime), "submission time not restored")
+	waitForAllocatedAppResource(t, app, 10, 1000)
+
+	// after the reconcile the saved state only has the recovered allocation
+	err = common.WaitForCondition(10*time.Millisecond, 2*time.Second, func() bool {
+		if err = ms.scheduler.SaveState(); err != nil {
+			return false
+		}
+		snapshot, loadErr := store.Load()
+		if loadErr != nil || len(snapshot.Partitions) != 1 {
+			return false
+		}
+		apps := snapshot.Partitions[0].Applications
+		return len(apps) == 1 && len(apps[0].Allocations) == 1 && len(snapshot.Partitions[0].RejectedApplications) == 1
+	})
+	assert.NilError(t, err, "state after restart not saved correctly")
+}
+
+func TestSchedulerStandbyFailover(t *testing.T) {
+	store, err := state.NewFileStore(t.TempDir())
+	assert.NilError(t, err, "failed to create state store")
+	extraConfig := map[string]string{configs.StateReconcileDelay: "1h"}
+	nodeRes := &si.Resource{Resources: map[string]*si.Quantity{"memory": {Value: 100}, "vcore": {Value: 20}}}
+	askRes := &si.Resource{Resources: map[string]*si.Quantity{"memory": {Value: 10}, "vcore": {Value: 1}}}
+
+	// leader: one application with an allocation and a pending ask that does not fit
+	ms := &mockScheduler{}
+	err = ms.InitWithStateStore(configData, store, extraConfig)
+	assert.NilError(t, err, "RegisterResourceManager failed")
+	assert.NilError(t, ms.addNode("node-1", nodeRes), "NodeRequest failed")
+	ms.mockRM.waitForAcceptedNode(t, "node-1", 1000)
+	assert.NilError(t, ms.addApp(appID1, "root.a", "default"), "ApplicationRequest failed")
+	ms.mockRM.waitForAcceptedApplication(t, appID1, 1000)
+	assert.NilError(t, ms.addAppRequest(appID1, "alloc", askRes, 1), "AllocationRequest failed")
+	ms.scheduler.MultiStepSchedule(5)
+	ms.mockRM.waitForAllocations(t, 1, 1000)
+	largeRes := &si.Resource{Resources: map[string]*si.Quantity{"memory": {Value: 200}, "vcore": {Value: 1}}}
+	assert.NilError(t, ms.addAppRequest(appID1, "large", largeRes, 1), "AllocationRequest failed")
+	waitForPendingQueueResource(t, ms.getQueue("root.a"), 200, 1000)
+	assert.NilError(t, ms.scheduler.SaveState(), "failed to save state")
+	ms.Stop()
+
+	// standby: registration is accepted, updates are rejected and nothing is scheduled
+	ms = &mockScheduler{standby: true}
+	defer ms.Stop()
+	err = ms.InitWithStateStore(configData, store, extraConfig)
+	assert.NilError(t, err, "RegisterResourceManager on standby failed")
+	assert.ErrorIs(t, ms.addNode("node-2", nodeRes), rmproxy.ErrStandby)
+	assert.Assert(t, ms.scheduler.IsStandby(), "scheduler should be standby")
+	assert.Assert(t, ms.getApplication(appID1) == nil, "standby should not have applied the replica")
+
+	// failover: the replica is applied without a resync from the RM
+	ms.scheduler.Promote()
+	ms.proxy.(*rmproxy.RMProxy).SetStandby(false)
+	assert.Assert(t, !ms.scheduler.IsStandby(), "scheduler should lead after promotion")
+	app := ms.getApplication(appID1)
+	assert.Assert(t, app != nil, "application not applied on promotion")
+	assert.Assert(t, ms.getNode("node-1") != nil, "node not applied on promotion")
+	waitForAllocatedAppResource(t, app, 10, 1000)
+	assert.Assert(t, app.GetAllocationAsk("large-0") != nil, "pending ask not applied on promotion")
+
+	// the promoted scheduler accepts updates and schedules
+	assert.NilError(t, ms.addNode("node-2", nodeRes), "NodeRequest after promotion failed")
+	ms.mockRM.waitForAcceptedNode(t, "node-2", 1000)
+	assert.NilError(t, ms.addAppRequest(appID1, "next", askRes, 1), "AllocationRequest after promotion failed")
+	ms.scheduler.MultiStepSchedule(5)
+	waitForAllocatedAppResource(t, app, 20, 1000)
+}
@@ -20,6 +20,7 @@ package ugm
 
 import (
 	"strings"
+	"time"
 
 	"github.com/apache/yunikorn-core/pkg/common/configs"
 	"github.com/apache/yunikorn-core/pkg/common/resources"
@@ -77,18 +78,18 @@ func (gt *GroupTracker) getTrackedApplications() map[string]string {
 	return gt.applications
 }
 
-func (gt *GroupTracker) setLimits(queuePath string, resource *resources.Resource, maxApps uint64) {
+func (gt *GroupTracker) setLimits(queuePath string, limit *LimitConfig) {
 	gt.Lock()
 	defer gt.Unlock()
 	gt.events.sendLimitSetForGroup(gt.groupName, queuePath)
-	gt.queueTracker.setLimit(strings.Split(queuePath, configs.DOT), resource, maxApps, false, group, false)
+	gt.queueTracker.setLimit(strings.Split(queuePath, configs.DOT), limit, false, group, false)
 }
 
 func (gt *GroupTracker) clearLimits(queuePath string) {
 	gt.Lock()
 	defer gt.Unlock()
 	gt.events.sendLimitRemoveForGroup(gt.groupName, queuePath)
-	gt.queueTracker.setLimit(strings.Split(queuePath, configs.DOT), nil, 0, false, group, false)
+	gt.queueTracker.setLimit(strings.Split(queuePath, configs.DOT), nil, false, group, false)
 }
 
 // headroom calculate the resource headroom for the group in the hierarchy defined
@@ -100,6 +101,39 @@ func (gt *GroupTracker) headroom(hierarchy []string) *resources.Resource {
 	return gt.queueTracker.headroom(hierarchy, group)
 }
 
+// getCountLimits returns the limits on pending asks, allocations and submissions for the group in the hierarchy defined
+// Note: getCountLimits of queue tracker is not read-only.
+// It traverses the queue hierarchy and creates a childQueueTracker if it does not exist.
+func (gt *GroupTracker) getCountLimits(hierarchy []string) []countLimit {
+	gt.Lock()
+	defer gt.Unlock()
+	return gt.queueTracker.getCountLimits(hierarchy, group)
+}
+
+// getGuarantees returns the guaranteed resources and usage for the group in the hierarchy defined
+// Note: getGuarantees of queue tracker is not read-only.
+// It traverses the queue hierarchy and creates a childQueueTracker if it does not exist.
+func (gt *GroupTracker) getGuarantees(hierarchy []string) []guarantee {
+	gt.Lock()
+	defer gt.Unlock()
+	return gt.queueTracker.getGuarantees(hierarchy, group)
+}
+
+// isBelowGuarantee returns true if the group uses less than guaranteed in the queue or any of its parents
+func (gt *GroupTracker) isBelowGuarantee(hierarchy []string) bool {
+	gt.RLock()
+	defer gt.RUnlock()
+	below, _ := gt.queueTracker.isBelowGuarantee(hierarchy)
+	return below
+}
+
+// getGuarantee returns the guaranteed resources and usage for the group in the queue, nil if the queue is not tracked
+func (gt *GroupTracker) getGuarantee(hierarchy []string) *guarantee {
+	gt.RLock()
+	defer gt.RUnlock()
+	return gt.queueTracker.getGuarantee(hierarchy)
+}
+
 // GetResourceUsageDAOInfo returns the DAO object used in the REST API for this group tracker
 func (gt *GroupTracker) GetResourceUsageDAOInfo() *dao.GroupResourceUsageDAOInfo {
 	gt.RLock()
@@ -108,10 +142,14 @@ func (gt *GroupTracker) GetResourceUsageDAOInfo() *dao.GroupResourceUsageDAOInfo
 	for app := range gt.applications {
 		apps = append(apps, app)
 	}
+	queues := gt.queueTracker.getResourceUsageDAOInfo()
+	if m != nil {
+		m.counts.fillDAOInfo(group, gt.groupName, queues, time.Now())
+	}
 	return &dao.GroupResourceUsageDAOInfo{
 		Applications: apps,
 		GroupName:    gt.groupName,
-		Queues:       gt.queueTracker.getResourceUsageDAOInfo(),
+		Queues:       queues,
 	}
 }
 
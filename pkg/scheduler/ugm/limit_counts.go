@@ -0,0 +1,239 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package ugm
+
+import (
+	"time"
+
+	"github.com/apache/yunikorn-core/pkg/common"
+	"github.com/apache/yunikorn-core/pkg/locking"
+	"github.com/apache/yunikorn-core/pkg/webservice/dao"
+)
+
+// window used for the submission rate limit
+const submissionWindow = time.Minute
+
+// limitCounts keeps the number of pending asks, running allocations and recent submissions per user and group
+// for each queue in the hierarchy. A parent queue includes the counts of all its children.
+// The counts are kept outside the trackers: the trackers follow the resource usage and are removed when there
+// is no usage, while an application can have pending asks without any usage.
+type limitCounts struct {
+	apps   map[string]*appCounts              // last counts reported per application
+	users  map[string]map[string]*queueCounts // user name to queue path to counts
+	groups map[string]map[string]*queueCounts // group name to queue path to counts
+
+	locking.Mutex
+}
+
+// appCounts are the counts reported for an application and where they were added.
+type appCounts struct {
+	queuePath   string
+	user        string
+	group       string
+	pendingAsks uint64
+	allocations uint64
+}
+
+type queueCounts struct {
+	pendingAsks uint64
+	allocations uint64
+	submissions []time.Time // accepted submissions inside the window, only tracked for queues with a limit
+}
+
+func newLimitCounts() *limitCounts {
+	return &limitCounts{
+		apps:   make(map[string]*appCounts),
+		users:  make(map[string]map[string]*queueCounts),
+		groups: make(map[string]map[string]*queueCounts),
+	}
+}
+
+func (lc *limitCounts) entries(trackType trackingType) map[string]map[string]*queueCounts {
+	if trackType == group {
+		return lc.groups
+	}
+	return lc.users
+}
+
+// set replaces the counts of the application. The counts reported earlier are removed from the queue, user and
+// group they were added to, which handles a move of the application. Zero counts stop tracking the application.
+func (lc *limitCounts) set(queuePath, applicationID, user, group string, pendingAsks, allocations uint64) {
+	lc.Lock()
+	defer lc.Unlock()
+	if old := lc.apps[applicationID]; old != nil {
+		lc.updateLocked(old, false)
+		delete(lc.apps, applicationID)
+	}
+	if pendingAsks == 0 && allocations == 0 {
+		return
+	}
+	app := &appCounts{
+		queuePath:   queuePath,
+		user:        user,
+		group:       group,
+		pendingAsks: pendingAsks,
+		allocations: allocations,
+	}
+	lc.apps[applicationID] = app
+	lc.updateLocked(app, true)
+}
+
+// getGroup returns the group the counts of the application are tracked against, and false if the application
+// is not tracked.
+func (lc *limitCounts) getGroup(applicationID string) (string, bool) {
+	lc.Lock()
+	defer lc.Unlock()
+	if app := lc.apps[applicationID]; app != nil {
+		return app.group, true
+	}
+	return common.Empty, false
+}
+
+func (lc *limitCounts) updateLocked(app *appCounts, add bool) {
+	lc.updateEntriesLocked(lc.users, app.user, app, add)
+	if app.group != common.Empty {
+		lc.updateEntriesLocked(lc.groups, app.group, app, add)
+	}
+}
+
+func (lc *limitCounts) updateEntriesLocked(entries map[string]map[string]*queueCounts, name string, app *appCounts, add bool) {
+	queues := entries[name]
+	if queues == nil {
+		if !add {
+			return
+		}
+		queues = make(map[string]*queueCounts)
+		entries[name] = queues
+	}
+	for path := app.queuePath; path != common.Empty; path = getParentPath(path) {
+		qc := queues[path]
+		if qc == nil {
+			if !add {
+				continue
+			}
+			qc = &queueCounts{}
+			queues[path] = qc
+		}
+		if add {
+			qc.pendingAsks += app.pendingAsks
+			qc.allocations += app.allocations
+			continue
+		}
+		qc.pendingAsks -= min(qc.pendingAsks, app.pendingAsks)
+		qc.allocations -= min(qc.allocations, app.allocations)
+		if qc.isEmpty() {
+			delete(queues, path)
+		}
+	}
+	if len(queues) == 0 {
+		delete(entries, name)
+	}
+}
+
+// get returns a copy of the counts for the user or group in the queue, the submissions outside the window are
+// removed first.
+func (lc *limitCounts) get(trackType trackingType, name, queuePath string, now time.Time) queueCounts {
+	lc.Lock()
+	defer lc.Unlock()
+	qc := lc.entries(trackType)[name][queuePath]
+	if qc == nil {
+		return queueCounts{}
+	}
+	qc.pruneSubmissions(now)
+	if qc.isEmpty() {
+		delete(lc.entries(trackType)[name], queuePath)
+		return queueCounts{}
+	}
+	return queueCounts{
+		pendingAsks: qc.pendingAsks,
+		allocations: qc.allocations,
+		submissions: append([]time.Time(nil), qc.submissions...),
+	}
+}
+
+// submit records a submission for all the limits if none of the submission rate limits is exceeded.
+// Returns the limit that was exceeded and false if the submission was not recorded.
+func (lc *limitCounts) submit(limits []namedLimit, now time.Time) (namedLimit, bool) {
+	lc.Lock()
+	defer lc.Unlock()
+	for _, limit := range limits {
+		if limit.maxSubmissionsPerMinute == 0 {
+			continue
+		}
+		if qc := lc.entries(limit.trackType)[limit.name][limit.queuePath]; qc != nil {
+			qc.pruneSubmissions(now)
+			if uint64(len(qc.submissions)) >= limit.maxSubmissionsPerMinute {
+				return limit, false
+			}
+		}
+	}
+	for _, limit := range limits {
+		if limit.maxSubmissionsPerMinute == 0 {
+			continue
+		}
+		entries := lc.entries(limit.trackType)
+		if entries[limit.name] == nil {
+			entries[limit.name] = make(map[string]*queueCounts)
+		}
+		qc := entries[limit.name][limit.queuePath]
+		if qc == nil {
+			qc = &queueCounts{}
+			entries[limit.name][limit.queuePath] = qc
+		}
+		qc.submissions = append(qc.submissions, now)
+	}
+	return namedLimit{}, true
+}
+
+// fillDAOInfo adds the counts of the user or group to the REST representation of the queue trackers.
+func (lc *limitCounts) fillDAOInfo(trackType trackingType, name string, info *dao.ResourceUsageDAOInfo, now time.Time) {
+	if info == nil {
+		return
+	}
+	counts := lc.get(trackType, name, info.QueuePath, now)
+	info.PendingAsks = counts.pendingAsks
+	info.RunningAllocations = counts.allocations
+	info.SubmissionsLastMinute = uint64(len(counts.submissions))
+	for _, child := range info.Children {
+		lc.fillDAOInfo(trackType, name, child, now)
+	}
+}
+
+func (lc *limitCounts) clear() {
+	lc.Lock()
+	defer lc.Unlock()
+	lc.apps = make(map[string]*appCounts)
+	lc.users = make(map[string]map[string]*queueCounts)
+	lc.groups = make(map[string]map[string]*queueCounts)
+}
+
+// pruneSubmissions removes the submissions that are outside the window, submissions are added in time order.
+func (qc *queueCounts) pruneSubmissions(now time.Time) {
+	idx := 0
+	for idx < len(qc.submissions) && now.Sub(qc.submissions[idx]) >= submissionWindow {
+		idx++
+	}
+	if idx > 0 {
+		qc.submissions = qc.submissions[idx:]
+	}
+}
+
+func (qc *queueCounts) isEmpty() bool {
+	return qc.pendingAsks == 0 && qc.allocations == 0 && len(qc.submissions) == 0
+}
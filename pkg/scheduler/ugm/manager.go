@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"go.uber.org/zap"
 
@@ -49,6 +50,9 @@ type Manager struct {
 	userLimits                map[string]map[string]*LimitConfig // Holds queue path * user limit config
 	groupLimits               map[string]map[string]*LimitConfig // Holds queue path * group limit config
 	events                    *ugmEvents
+	history                   *usageHistory // decayed usage used by fair sorting, has its own lock
+	counts                    *limitCounts  // pending asks, allocations and submissions for the count limits, has its own lock
+	guaranteesConfigured      bool          // true if any user or group has guaranteed resources configured
 	locking.RWMutex
 }
 
@@ -59,6 +63,8 @@ func newManager() *Manager {
 		userWildCardLimitsConfig:  make(map[string]*LimitConfig),
 		groupWildCardLimitsConfig: make(map[string]*LimitConfig),
 		events:                    newUGMEvents(events.GetEventSystem()),
+		history:                   newUsageHistory(),
+		counts:                    newLimitCounts(),
 	}
 	return manager
 }
@@ -66,14 +72,104 @@ func newManager() *Manager {
 func GetUserManager() *Manager {
 	once.Do(func() {
 		m = newManager()
+		m.updateHistoryConfig()
+		configs.AddConfigMapCallback("ugm", m.updateHistoryConfig)
 	})
 	return m
 }
 
+// updateHistoryConfig reads the half-life of the usage history from the config map.
+func (m *Manager) updateHistoryConfig() {
+	halfLife := common.GetConfigurationDuration(configs.GetConfigMap(), configs.FairShareHalfLife, configs.DefaultFairShareHalfLife)
+	if halfLife < 0 {
+		log.Log(log.SchedUGM).Warn("Negative usage history half-life, disabling usage history",
+			zap.Duration("half-life", halfLife))
+		halfLife = 0
+	}
+	m.history.setHalfLife(halfLife, time.Now())
+}
+
 // LimitConfig Holds limit settings of wild card user/group
 type LimitConfig struct {
-	maxResources    *resources.Resource
-	maxApplications uint64
+	maxResources            *resources.Resource
+	maxApplications         uint64
+	maxPendingAsks          uint64
+	maxAllocations          uint64
+	maxSubmissionsPerMinute uint64
+	guaranteedResources     *resources.Resource
+}
+
+// equals returns true if all limits are the same
+func (lc *LimitConfig) equals(other *LimitConfig) bool {
+	return lc.maxApplications == other.maxApplications && resources.Equals(lc.maxResources, other.maxResources) &&
+		lc.maxPendingAsks == other.maxPendingAsks && lc.maxAllocations == other.maxAllocations &&
+		lc.maxSubmissionsPerMinute == other.maxSubmissionsPerMinute &&
+		resources.Equals(lc.guaranteedResources, other.guaranteedResources)
+}
+
+// Guarantee is the guaranteed resources of a user or group in a queue with the usage of that user or group in the
+// queue. The guaranteed resources are nil if no guarantee is set.
+type Guarantee struct {
+	QueuePath  string
+	Name       string
+	Group      bool
+	Guaranteed *resources.Resource
+	Usage      *resources.Resource
+}
+
+// IsBelow returns true if the usage is below the guaranteed quantity for at least one of the guaranteed resource types.
+func (g *Guarantee) IsBelow() bool {
+	if g == nil || g.Guaranteed == nil {
+		return false
+	}
+	for name, quantity := range g.Guaranteed.Resources {
+		if g.usage(name) < quantity {
+			return true
+		}
+	}
+	return false
+}
+
+// FitsWithin returns true if the usage with the resource added stays within the guaranteed quantity for all
+// guaranteed resource types. Resource types that are not guaranteed are ignored.
+func (g *Guarantee) FitsWithin(res *resources.Resource) bool {
+	if g == nil || g.Guaranteed == nil || res == nil {
+		return false
+	}
+	for name, quantity := range g.Guaranteed.Resources {
+		if g.usage(name)+res.Resources[name] > quantity {
+			return false
+		}
+	}
+	return true
+}
+
+// KeepsAfterRelease returns true if the usage with the resource removed stays at or above the guaranteed quantity
+// for all guaranteed resource types. Without a guarantee any usage can be released.
+func (g *Guarantee) KeepsAfterRelease(res *resources.Resource) bool {
+	if g == nil || g.Guaranteed == nil || res == nil {
+		return true
+	}
+	for name, quantity := range g.Guaranteed.Resources {
+		if g.usage(name)-res.Resources[name] < quantity {
+			return false
+		}
+	}
+	return true
+}
+
+func (g *Guarantee) usage(name string) resources.Quantity {
+	if g.Usage == nil {
+		return 0
+	}
+	return g.Usage.Resources[name]
+}
+
+// namedLimit is a count limit of a user or group for a queue
+type namedLimit struct {
+	trackType trackingType
+	name      string
+	countLimit
 }
 
 // IncreaseTrackedResource Increase the resource usage for the given user group and queue path combination.
@@ -100,6 +196,7 @@ func (m *Manager) IncreaseTrackedResource(queuePath, applicationID string, usage
 	}
 	userTracker.increaseTrackedResource(queuePath, applicationID, usage)
 	appGroup := userTracker.getGroupForApp(applicationID)
+	m.history.update(queuePath, user.User, historyGroup(appGroup, user), usage, true, time.Now())
 	log.Log(log.SchedUGM).Debug("Increasing resource usage for user",
 		zap.String("user", user.User),
 		zap.String("queue path", queuePath),
@@ -149,6 +246,7 @@ func (m *Manager) DecreaseTrackedResource(queuePath, applicationID string, usage
 
 	// get the group now as the decrease might remove the app from the user if removeApp is true
 	appGroup := userTracker.getGroupForApp(applicationID)
+	m.history.update(queuePath, user.User, historyGroup(appGroup, user), usage, false, time.Now())
 	log.Log(log.SchedUGM).Debug("Decreasing resource usage for user",
 		zap.String("user", user.User),
 		zap.String("queue path", queuePath),
@@ -192,6 +290,55 @@ func (m *Manager) DecreaseTrackedResource(queuePath, applicationID string, usage
 	}
 }
 
+// IsUsageHistoryEnabled returns true if the decayed usage history is tracked, which is the case when a
+// half-life is configured.
+func (m *Manager) IsUsageHistoryEnabled() bool {
+	return m.history.isEnabled()
+}
+
+// GetUserUsageHistory returns the decayed usage in resource-seconds per resource type for the user.
+// Returns nil if the history is disabled or nothing is tracked for the user.
+func (m *Manager) GetUserUsageHistory(user string) map[string]float64 {
+	return m.history.get(m.history.users, user, time.Now())
+}
+
+// GetGroupUsageHistory returns the decayed usage in resource-seconds per resource type for the group.
+// Returns nil if the history is disabled or nothing is tracked for the group.
+func (m *Manager) GetGroupUsageHistory(group string) map[string]float64 {
+	return m.history.get(m.history.groups, group, time.Now())
+}
+
+// GetQueueUsageHistory returns the decayed usage in resource-seconds per resource type for the queue,
+// including the usage of all its children.
+// Returns nil if the history is disabled or nothing is tracked for the queue.
+func (m *Manager) GetQueueUsageHistory(queuePath string) map[string]float64 {
+	return m.history.get(m.history.queues, queuePath, time.Now())
+}
+
+// GetUsageHistory returns a copy of the decayed usage of all users, groups and queues, in that order.
+// Returns nil maps if the history is disabled.
+func (m *Manager) GetUsageHistory() (map[string]map[string]float64, map[string]map[string]float64, map[string]map[string]float64) {
+	return m.history.snapshot(time.Now())
+}
+
+// RestoreUsageHistory adds the decayed usage of users, groups and queues saved before a restart to the history.
+// The usage is decayed for the time since it was saved. A no-op if the history is disabled.
+func (m *Manager) RestoreUsageHistory(users, groups, queues map[string]map[string]float64, saved time.Time) {
+	m.history.restore(users, groups, queues, saved, time.Now())
+}
+
+// historyGroup returns the group the usage history of an application is tracked against: the group
+// used for limits if one matched, otherwise the primary group of the user.
+func historyGroup(appGroup string, user security.UserGroup) string {
+	if appGroup != common.Empty && appGroup != common.Wildcard {
+		return appGroup
+	}
+	if len(user.Groups) > 0 {
+		return user.Groups[0]
+	}
+	return common.Empty
+}
+
 func (m *Manager) GetUserTrackers() []*UserTracker {
 	m.RLock()
 	defer m.RUnlock()
@@ -345,7 +492,22 @@ func (m *Manager) internalProcessConfig(cur configs.QueueConfig, queuePath strin
 				zap.Error(err))
 			return errors.Join(fmt.Errorf("problem in using the max resources settings for queuepath: %s, reason: ", queuePath), err)
 		}
-		limitConfig := &LimitConfig{maxResources: maxResource, maxApplications: limit.MaxApplications}
+		limitConfig := &LimitConfig{
+			maxResources:            maxResource,
+			maxApplications:         limit.MaxApplications,
+			maxPendingAsks:          limit.MaxPendingAsks,
+			maxAllocations:          limit.MaxAllocations,
+			maxSubmissionsPerMinute: limit.MaxSubmissionsPerMinute,
+		}
+		if len(limit.GuaranteedResources) != 0 {
+			if limitConfig.guaranteedResources, err = resources.NewResourceFromConf(limit.GuaranteedResources); err != nil {
+				log.Log(log.SchedUGM).Warn("Problem in using the limit guaranteed resources settings.",
+					zap.String("queue path", queuePath),
+					zap.Any("limit guaranteed resources", limit.GuaranteedResources),
+					zap.Error(err))
+				return errors.Join(fmt.Errorf("problem in using the guaranteed resources settings for queuepath: %s, reason: ", queuePath), err)
+			}
+		}
 		for _, user := range limit.Users {
 			if user == common.Empty {
 				continue
@@ -441,15 +603,14 @@ func (m *Manager) clearEarlierSetUserWildCardLimits(newUserWildCardLimits map[st
 		} else if !currentQPExists || !newQPExists {
 			// In case wild card user limit exists, compare the old wild card limits with new limits for existing users already using wild card limits.
 			// In case of difference, set new limits for all those users.
-			if currentLimitConfig.maxApplications != newLimitConfig.maxApplications ||
-				!resources.Equals(currentLimitConfig.maxResources, newLimitConfig.maxResources) {
+			if !currentLimitConfig.equals(newLimitConfig) {
 				for _, ut := range m.userTrackers {
 					log.Log(log.SchedUGM).Debug("Need to update earlier set configs for user because wild card limit applied earlier has been updated",
 						zap.String("user", ut.userName),
 						zap.String("queue path", queuePath))
 					_, exists := m.userLimits[queuePath][ut.userName]
 					if _, ok = newUserLimits[queuePath][ut.userName]; !ok || !exists {
-						ut.setLimits(queuePath, newLimitConfig.maxResources, newLimitConfig.maxApplications, true, true)
+						ut.setLimits(queuePath, newLimitConfig, true, true)
 					}
 				}
 			}
@@ -464,7 +625,7 @@ func (m *Manager) applyWildCardUserLimits(newUserWildCardLimits map[string]*Limi
 	for queuePath, newLimitConfig := range newUserWildCardLimits {
 		for _, ut := range m.userTrackers {
 			if _, ok := newUserLimits[queuePath][ut.userName]; !ok {
-				ut.setLimits(queuePath, newLimitConfig.maxResources, newLimitConfig.maxApplications, true, false)
+				ut.setLimits(queuePath, newLimitConfig, true, false)
 			}
 		}
 	}
@@ -580,6 +741,23 @@ func (m *Manager) replaceLimitConfigs(newUserLimits map[string]map[string]*Limit
 	m.userWildCardLimitsConfig = newUserWildCardLimitsConfig
 	m.groupWildCardLimitsConfig = newGroupWildCardLimitsConfig
 	m.configuredGroups = newConfiguredGroups
+	m.guaranteesConfigured = hasGuarantees(newUserWildCardLimitsConfig) || hasGuarantees(newGroupWildCardLimitsConfig)
+	for _, limits := range newUserLimits {
+		m.guaranteesConfigured = m.guaranteesConfigured || hasGuarantees(limits)
+	}
+	for _, limits := range newGroupLimits {
+		m.guaranteesConfigured = m.guaranteesConfigured || hasGuarantees(limits)
+	}
+}
+
+// hasGuarantees returns true if any of the limits has guaranteed resources set
+func hasGuarantees(limits map[string]*LimitConfig) bool {
+	for _, limit := range limits {
+		if !resources.IsZero(limit.guaranteedResources) {
+			return true
+		}
+	}
+	return false
 }
 
 func (m *Manager) setUserLimits(user string, limitConfig *LimitConfig, queuePath string) error {
@@ -598,7 +776,7 @@ func (m *Manager) setUserLimits(user string, limitConfig *LimitConfig, queuePath
 		userTracker = newUserTracker(user, m.events)
 		m.userTrackers[user] = userTracker
 	}
-	userTracker.setLimits(queuePath, limitConfig.maxResources, limitConfig.maxApplications, false, false)
+	userTracker.setLimits(queuePath, limitConfig, false, false)
 	return nil
 }
 
@@ -618,7 +796,7 @@ func (m *Manager) setGroupLimits(group string, limitConfig *LimitConfig, queuePa
 		groupTracker = newGroupTracker(group, m.events)
 		m.groupTrackers[group] = groupTracker
 	}
-	groupTracker.setLimits(queuePath, limitConfig.maxResources, limitConfig.maxApplications)
+	groupTracker.setLimits(queuePath, limitConfig)
 	return nil
 }
 
@@ -689,6 +867,270 @@ func (m *Manager) CanRunApp(queuePath, applicationID string, user security.UserG
 	return userCanRunApp && groupCanRunApp
 }
 
+// UpdateApplicationCounts sets the number of pending asks and running allocations of the application that runs as
+// the user in the queue. The counts replace the counts reported earlier for the application, also when the
+// application has moved to a different queue. Zero counts stop the tracking of the application.
+func (m *Manager) UpdateApplicationCounts(queuePath, applicationID string, user security.UserGroup, pendingAsks, allocations uint64) {
+	if queuePath == common.Empty || applicationID == common.Empty || user.User == common.Empty {
+		log.Log(log.SchedUGM).Debug("Mandatory parameters are missing to update the application counts")
+		return
+	}
+	var appGroup string
+	if pendingAsks != 0 || allocations != 0 {
+		appGroup = m.ensureGroup(user, queuePath)
+	}
+	m.counts.set(queuePath, applicationID, user.User, appGroup, pendingAsks, allocations)
+}
+
+// CheckPendingAskLimit checks if the application that runs as the user and group can add one more pending ask
+// in the queue. Returns an error describing the limit if the ask would exceed a pending asks limit.
+func (m *Manager) CheckPendingAskLimit(queuePath, applicationID string, user security.UserGroup) error {
+	now := time.Now()
+	for _, limit := range m.getCountLimits(queuePath, applicationID, user) {
+		if limit.maxPendingAsks == 0 {
+			continue
+		}
+		counts := m.counts.get(limit.trackType, limit.name, limit.queuePath, now)
+		if counts.pendingAsks >= limit.maxPendingAsks {
+			err := fmt.Errorf("%s %s has reached the maximum of %d pending asks in queue %s",
+				limit.trackType, limit.name, limit.maxPendingAsks, limit.queuePath)
+			m.sendLimitExceeded(limit, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckAllocationLimit checks if the application that runs as the user and group can have count more running
+// allocations in the queue. Returns an error describing the limit if the allocations would exceed an allocations
+// limit.
+func (m *Manager) CheckAllocationLimit(queuePath, applicationID string, user security.UserGroup, count uint64) error {
+	now := time.Now()
+	for _, limit := range m.getCountLimits(queuePath, applicationID, user) {
+		if limit.maxAllocations == 0 {
+			continue
+		}
+		counts := m.counts.get(limit.trackType, limit.name, limit.queuePath, now)
+		if counts.allocations+count > limit.maxAllocations {
+			return fmt.Errorf("%s %s has %d of the maximum of %d running allocations in queue %s, %d more requested",
+				limit.trackType, limit.name, counts.allocations, limit.maxAllocations, limit.queuePath, count)
+		}
+	}
+	return nil
+}
+
+// CheckSubmissionLimit checks if the user and group can submit the application to the queue without exceeding a
+// submission rate limit. The submission is recorded if it is allowed, otherwise an error describing the limit is
+// returned.
+func (m *Manager) CheckSubmissionLimit(queuePath, applicationID string, user security.UserGroup) error {
+	if queuePath == common.Empty || user.User == common.Empty {
+		return nil
+	}
+	limits := m.getCountLimits(queuePath, applicationID, user)
+	if len(limits) == 0 {
+		return nil
+	}
+	if limit, ok := m.counts.submit(limits, time.Now()); !ok {
+		err := fmt.Errorf("%s %s has reached the maximum of %d application submissions per minute in queue %s",
+			limit.trackType, limit.name, limit.maxSubmissionsPerMinute, limit.queuePath)
+		m.sendLimitExceeded(limit, err)
+		return err
+	}
+	return nil
+}
+
+// getCountLimits returns the count limits of the user and the group in the queue hierarchy. The group is the
+// group the application is counted against, or the group that matches the user if the application is not tracked.
+func (m *Manager) getCountLimits(queuePath, applicationID string, userGroup security.UserGroup) []namedLimit {
+	hierarchy := strings.Split(queuePath, configs.DOT)
+	var limits []namedLimit
+	for _, limit := range m.getUserTracker(userGroup.User).getCountLimits(hierarchy) {
+		limits = append(limits, namedLimit{trackType: user, name: userGroup.User, countLimit: limit})
+	}
+	appGroup, ok := m.counts.getGroup(applicationID)
+	if !ok {
+		appGroup = m.ensureGroup(userGroup, queuePath)
+	}
+	if appGroup == common.Empty {
+		return limits
+	}
+	groupTracker := m.GetGroupTracker(appGroup)
+	if groupTracker == nil {
+		return limits
+	}
+	for _, limit := range groupTracker.getCountLimits(hierarchy) {
+		limits = append(limits, namedLimit{trackType: group, name: appGroup, countLimit: limit})
+	}
+	return limits
+}
+
+func (m *Manager) sendLimitExceeded(limit namedLimit, err error) {
+	log.Log(log.SchedUGM).Info("Limit exceeded",
+		zap.Stringer("tracking type", limit.trackType),
+		zap.String("name", limit.name),
+		zap.String("queue path", limit.queuePath),
+		zap.Error(err))
+	if limit.trackType == group {
+		m.events.sendLimitExceededForGroup(limit.name, limit.queuePath, err.Error())
+		return
+	}
+	m.events.sendLimitExceededForUser(limit.name, limit.queuePath, err.Error())
+}
+
+// HasGuarantees returns true if guaranteed resources are configured for any user or group.
+func (m *Manager) HasGuarantees() bool {
+	m.RLock()
+	defer m.RUnlock()
+	return m.guaranteesConfigured
+}
+
+// GetGuarantees returns the guarantees set for the user and for the group the application is tracked against in
+// the queue hierarchy. The user guarantees are returned first, the guarantees of each are ordered from leaf to root.
+func (m *Manager) GetGuarantees(queuePath, applicationID string, userGroup security.UserGroup) []*Guarantee {
+	if queuePath == common.Empty || userGroup.User == common.Empty || !m.HasGuarantees() {
+		return nil
+	}
+	hierarchy := strings.Split(queuePath, configs.DOT)
+	var guarantees []*Guarantee
+	for _, g := range m.getUserTracker(userGroup.User).getGuarantees(hierarchy) {
+		guarantees = append(guarantees, g.export(userGroup.User, false))
+	}
+	appGroup := m.getApplicationGroup(userGroup.User, applicationID)
+	if appGroup == common.Empty {
+		appGroup = m.ensureGroup(userGroup, queuePath)
+	}
+	if appGroup == common.Empty {
+		return guarantees
+	}
+	if groupTracker := m.GetGroupTracker(appGroup); groupTracker != nil {
+		for _, g := range groupTracker.getGuarantees(hierarchy) {
+			guarantees = append(guarantees, g.export(appGroup, true))
+		}
+	}
+	return guarantees
+}
+
+// IsBelowGuarantee returns true if the user or the group the application is tracked against uses less than
+// guaranteed in the queue or any of its parents. Use a GuaranteeCheck to check multiple applications.
+func (m *Manager) IsBelowGuarantee(queuePath, applicationID string, userGroup security.UserGroup) bool {
+	return m.NewGuaranteeCheck().IsBelowGuarantee(queuePath, applicationID, userGroup)
+}
+
+// GuaranteeCheck checks if users and groups use less than guaranteed. The result is cached per queue for each user
+// and group: a check reflects the usage at the time of the first lookup and must only be used for one pass over the
+// applications, like a sort. Trackers are never created by a check.
+type GuaranteeCheck struct {
+	m      *Manager
+	users  map[string]bool
+	groups map[string]bool
+}
+
+// NewGuaranteeCheck creates a check of the guarantees with an empty cache.
+func (m *Manager) NewGuaranteeCheck() *GuaranteeCheck {
+	return &GuaranteeCheck{
+		m:      m,
+		users:  make(map[string]bool),
+		groups: make(map[string]bool),
+	}
+}
+
+// IsBelowGuarantee returns true if the user or the group the application is tracked against uses less than
+// guaranteed in the queue or any of its parents.
+func (gc *GuaranteeCheck) IsBelowGuarantee(queuePath, applicationID string, userGroup security.UserGroup) bool {
+	if queuePath == common.Empty || userGroup.User == common.Empty || !gc.m.HasGuarantees() {
+		return false
+	}
+	userTracker := gc.m.GetUserTracker(userGroup.User)
+	hierarchy := strings.Split(queuePath, configs.DOT)
+	key := queuePath + configs.DOT + userGroup.User
+	below, ok := gc.users[key]
+	if !ok {
+		tracked := 0
+		if userTracker != nil {
+			below, tracked = userTracker.isBelowGuarantee(hierarchy)
+		}
+		// the queues that are not tracked for the user have no usage
+		below = below || gc.m.hasWildCardGuarantee(hierarchy, tracked)
+		gc.users[key] = below
+	}
+	if below {
+		return true
+	}
+	var appGroup string
+	if userTracker != nil {
+		appGroup = userTracker.getGroupForApp(applicationID)
+	}
+	if appGroup == common.Empty {
+		appGroup = gc.m.ensureGroup(userGroup, queuePath)
+	}
+	if appGroup == common.Empty {
+		return false
+	}
+	key = queuePath + configs.DOT + appGroup
+	below, ok = gc.groups[key]
+	if !ok {
+		if groupTracker := gc.m.GetGroupTracker(appGroup); groupTracker != nil {
+			below = groupTracker.isBelowGuarantee(hierarchy)
+		}
+		gc.groups[key] = below
+	}
+	return below
+}
+
+// hasWildCardGuarantee returns true if a wildcard user guarantee is set for one of the queues in the hierarchy,
+// starting at the level given.
+func (m *Manager) hasWildCardGuarantee(hierarchy []string, level int) bool {
+	m.RLock()
+	defer m.RUnlock()
+	for i := level; i < len(hierarchy); i++ {
+		if config := m.getUserWildCardLimitsConfig(strings.Join(hierarchy[:i+1], configs.DOT)); config != nil && !resources.IsZero(config.guaranteedResources) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetUserGuarantee returns the guarantee of the user in the queue, nil if the user is not tracked in the queue.
+// The guaranteed resources are nil if there is no guarantee set for the user in the queue.
+func (m *Manager) GetUserGuarantee(queuePath, userName string) *Guarantee {
+	userTracker := m.GetUserTracker(userName)
+	if userTracker == nil {
+		return nil
+	}
+	g := userTracker.getGuarantee(strings.Split(queuePath, configs.DOT))
+	if g == nil {
+		return nil
+	}
+	return g.export(userName, false)
+}
+
+// GetGroupGuarantee returns the guarantee of the group in the queue, nil if the group is not tracked in the queue.
+// The guaranteed resources are nil if there is no guarantee set for the group in the queue.
+func (m *Manager) GetGroupGuarantee(queuePath, groupName string) *Guarantee {
+	groupTracker := m.GetGroupTracker(groupName)
+	if groupTracker == nil {
+		return nil
+	}
+	g := groupTracker.getGuarantee(strings.Split(queuePath, configs.DOT))
+	if g == nil {
+		return nil
+	}
+	return g.export(groupName, true)
+}
+
+// GetApplicationGroup returns the group the application of the user is tracked against, empty if not tracked.
+func (m *Manager) GetApplicationGroup(userName, applicationID string) string {
+	return m.getApplicationGroup(userName, applicationID)
+}
+
+func (m *Manager) getApplicationGroup(userName, applicationID string) string {
+	userTracker := m.GetUserTracker(userName)
+	if userTracker == nil {
+		return common.Empty
+	}
+	return userTracker.getGroupForApp(applicationID)
+}
+
 // ClearUserTrackers only for tests
 func (m *Manager) ClearUserTrackers() {
 	m.Lock()
@@ -703,6 +1145,18 @@ func (m *Manager) ClearGroupTrackers() {
 	m.groupTrackers = make(map[string]*GroupTracker)
 }
 
+// ClearApplicationCounts only for tests
+func (m *Manager) ClearApplicationCounts() {
+	m.counts.clear()
+}
+
+// ClearUsageHistory only for tests
+func (m *Manager) ClearUsageHistory() {
+	m.history.Lock()
+	defer m.history.Unlock()
+	m.history.clearLocked()
+}
+
 // ClearConfigLimits only for tests
 func (m *Manager) ClearConfigLimits() {
 	m.Lock()
@@ -712,6 +1166,7 @@ func (m *Manager) ClearConfigLimits() {
 	m.configuredGroups = make(map[string][]string)
 	m.userLimits = make(map[string]map[string]*LimitConfig)
 	m.groupLimits = make(map[string]map[string]*LimitConfig)
+	m.guaranteesConfigured = false
 }
 
 // GetUserResources returns the root queue maxResources for the user
@@ -23,6 +23,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"gotest.tools/v3/assert"
 
@@ -1017,6 +1018,173 @@ func TestSeparateUserGroupHeadroom(t *testing.T) {
 	}
 }
 
+func TestCountLimits(t *testing.T) {
+	setupUGM()
+	manager := GetUserManager()
+	user1 := security.UserGroup{User: "user1", Groups: []string{"group1"}}
+	user2 := security.UserGroup{User: "user2", Groups: []string{"group1"}}
+	conf := createConfigWithLimits([]configs.Limit{
+		{Users: []string{"user1"}, MaxPendingAsks: 2, MaxAllocations: 1},
+		{Groups: []string{"group1"}, MaxPendingAsks: 3},
+	})
+	assert.NilError(t, manager.UpdateConfig(conf.Queues[0], "root"))
+
+	// pending asks are counted in the leaf and checked against the parent limit
+	assert.NilError(t, manager.CheckPendingAskLimit(queuePathLeaf, TestApp1, user1))
+	manager.UpdateApplicationCounts(queuePathLeaf, TestApp1, user1, 2, 0)
+	err := manager.CheckPendingAskLimit(queuePathLeaf, TestApp1, user1)
+	assert.ErrorContains(t, err, "user user1 has reached the maximum of 2 pending asks in queue root.parent")
+	// group limit applies to the other user in the same group
+	assert.NilError(t, manager.CheckPendingAskLimit(queuePathLeaf, TestApp2, user2))
+	manager.UpdateApplicationCounts(queuePathLeaf, TestApp2, user2, 1, 0)
+	err = manager.CheckPendingAskLimit(queuePathLeaf, TestApp2, user2)
+	assert.ErrorContains(t, err, "group group1 has reached the maximum of 3 pending asks in queue root.parent")
+
+	// allocations replace pending asks
+	assert.NilError(t, manager.CheckAllocationLimit(queuePathLeaf, TestApp1, user1, 1))
+	err = manager.CheckAllocationLimit(queuePathLeaf, TestApp1, user1, 2)
+	assert.ErrorContains(t, err, "user user1 has 0 of the maximum of 1 running allocations in queue root.parent, 2 more requested")
+	manager.UpdateApplicationCounts(queuePathLeaf, TestApp1, user1, 1, 1)
+	assert.Assert(t, manager.CheckAllocationLimit(queuePathLeaf, TestApp1, user1, 1) != nil)
+	assert.NilError(t, manager.CheckAllocationLimit(queuePathLeaf, TestApp2, user2, 1))
+	assert.NilError(t, manager.CheckPendingAskLimit(queuePathLeaf, TestApp1, user1))
+
+	// counts show up in the REST info
+	info := manager.GetUserTracker("user1").GetResourceUsageDAOInfo()
+	assert.Equal(t, info.Queues.Children[0].QueuePath, queuePathParent)
+	assert.Equal(t, info.Queues.Children[0].PendingAsks, uint64(1))
+	assert.Equal(t, info.Queues.Children[0].MaxPendingAsks, uint64(2))
+	assert.Equal(t, info.Queues.Children[0].RunningAllocations, uint64(1))
+	assert.Equal(t, info.Queues.Children[0].MaxAllocations, uint64(1))
+
+	// zero counts stop the tracking
+	manager.UpdateApplicationCounts(queuePathLeaf, TestApp1, user1, 0, 0)
+	assert.NilError(t, manager.CheckAllocationLimit(queuePathLeaf, TestApp1, user1, 1))
+	assert.Equal(t, manager.counts.get(user, "user1", queuePathParent, time.Now()).pendingAsks, uint64(0))
+	assert.Equal(t, manager.counts.get(group, "group1", queuePathParent, time.Now()).pendingAsks, uint64(1))
+}
+
+func TestSubmissionLimit(t *testing.T) {
+	setupUGM()
+	manager := GetUserManager()
+	user1 := security.UserGroup{User: "user1", Groups: []string{"group1"}}
+	user2 := security.UserGroup{User: "user2", Groups: []string{"group1"}}
+	conf := createConfigWithLimits([]configs.Limit{
+		{Users: []string{"user1"}, MaxSubmissionsPerMinute: 1},
+		{Groups: []string{"group1"}, MaxSubmissionsPerMinute: 2},
+	})
+	assert.NilError(t, manager.UpdateConfig(conf.Queues[0], "root"))
+
+	assert.NilError(t, manager.CheckSubmissionLimit(queuePathLeaf, TestApp1, user1))
+	err := manager.CheckSubmissionLimit(queuePathLeaf, TestApp2, user1)
+	assert.ErrorContains(t, err, "user user1 has reached the maximum of 1 application submissions per minute in queue root.parent")
+	// the rejected submission is not counted against the group
+	assert.NilError(t, manager.CheckSubmissionLimit(queuePathLeaf, TestApp2, user2))
+	err = manager.CheckSubmissionLimit(queuePathLeaf, TestApp3, user2)
+	assert.ErrorContains(t, err, "group group1 has reached the maximum of 2 application submissions per minute in queue root.parent")
+
+	// submissions outside the window do not count
+	manager.counts.Lock()
+	for _, qc := range manager.counts.users["user1"] {
+		qc.submissions[0] = qc.submissions[0].Add(-submissionWindow)
+	}
+	for _, qc := range manager.counts.groups["group1"] {
+		qc.submissions[0] = qc.submissions[0].Add(-submissionWindow)
+	}
+	manager.counts.Unlock()
+	assert.NilError(t, manager.CheckSubmissionLimit(queuePathLeaf, TestApp3, user1))
+}
+
+func TestGuarantees(t *testing.T) {
+	setupUGM()
+	manager := GetUserManager()
+	user1 := security.UserGroup{User: "user1", Groups: []string{"group1"}}
+	user2 := security.UserGroup{User: "user2", Groups: []string{"group2"}}
+	assert.Assert(t, manager.GetGuarantees(queuePathLeaf, TestApp1, user1) == nil, "no guarantees expected without config")
+
+	conf := createConfigWithLimits([]configs.Limit{
+		{Users: []string{"user1"}, GuaranteedResources: mediumResource},
+		{Groups: []string{"group2"}, GuaranteedResources: tinyResource},
+	})
+	assert.NilError(t, manager.UpdateConfig(conf.Queues[0], "root"))
+	assert.Assert(t, manager.HasGuarantees())
+
+	usage, err := resources.NewResourceFromConf(tinyResource)
+	assert.NilError(t, err)
+	manager.IncreaseTrackedResource(queuePathLeaf, TestApp1, usage, user1)
+	manager.IncreaseTrackedResource(queuePathLeaf, TestApp2, usage, user2)
+
+	guarantees := manager.GetGuarantees(queuePathLeaf, TestApp1, user1)
+	assert.Equal(t, len(guarantees), 1)
+	assert.Equal(t, guarantees[0].QueuePath, queuePathParent)
+	assert.Equal(t, guarantees[0].Name, "user1")
+	assert.Equal(t, guarantees[0].Group, false)
+	assert.Assert(t, resources.Equals(guarantees[0].Usage, usage))
+	assert.Assert(t, manager.IsBelowGuarantee(queuePathLeaf, TestApp1, user1))
+	// group guarantee is reached, user2 has no user guarantee
+	guarantees = manager.GetGuarantees(queuePathLeaf, TestApp2, user2)
+	assert.Equal(t, len(guarantees), 1)
+	assert.Equal(t, guarantees[0].Name, "group2")
+	assert.Equal(t, guarantees[0].Group, true)
+	assert.Assert(t, !manager.IsBelowGuarantee(queuePathLeaf, TestApp2, user2))
+
+	// a check does not create trackers and caches the result per user
+	user3 := security.UserGroup{User: "user3", Groups: []string{"group3"}}
+	check := manager.NewGuaranteeCheck()
+	assert.Assert(t, !check.IsBelowGuarantee(queuePathLeaf, TestApp3, user3))
+	assert.Assert(t, manager.GetUserTracker("user3") == nil, "check should not create a user tracker")
+	assert.Assert(t, check.IsBelowGuarantee(queuePathLeaf, TestApp1, user1))
+	manager.IncreaseTrackedResource(queuePathLeaf, TestApp1, usage, user1)
+	manager.IncreaseTrackedResource(queuePathLeaf, TestApp1, usage, user1)
+	assert.Assert(t, check.IsBelowGuarantee(queuePathLeaf, TestApp1, user1), "cached result expected")
+	assert.Assert(t, !manager.IsBelowGuarantee(queuePathLeaf, TestApp1, user1), "user1 should have reached the guarantee")
+
+	// direct lookups do not need the application
+	g := manager.GetUserGuarantee(queuePathParent, "user2")
+	assert.Assert(t, g != nil && g.Guaranteed == nil)
+	assert.Assert(t, resources.Equals(g.Usage, usage))
+	assert.Assert(t, manager.GetUserGuarantee(queuePathParent, "unknown") == nil)
+	assert.Assert(t, manager.GetUserGuarantee("root.unknown", "user1") == nil)
+	assert.Equal(t, manager.GetApplicationGroup("user2", TestApp2), "group2")
+	g = manager.GetGroupGuarantee(queuePathParent, "group2")
+	assert.Assert(t, g != nil && g.Group)
+	assert.Assert(t, resources.Equals(g.Guaranteed, usage))
+
+	// removing the config clears the guarantees
+	conf = createConfigWithLimits(nil)
+	assert.NilError(t, manager.UpdateConfig(conf.Queues[0], "root"))
+	assert.Assert(t, !manager.HasGuarantees())
+	assert.Assert(t, !manager.IsBelowGuarantee(queuePathLeaf, TestApp1, user1))
+}
+
+func TestGuaranteeChecks(t *testing.T) {
+	guaranteed := resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 10})
+	usage := resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 6, "vcores": 5})
+	small := resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 4, "vcores": 10})
+	large := resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 5})
+
+	var nilGuarantee *Guarantee
+	assert.Assert(t, !nilGuarantee.IsBelow())
+	assert.Assert(t, !nilGuarantee.FitsWithin(small))
+	assert.Assert(t, nilGuarantee.KeepsAfterRelease(small))
+	noGuarantee := &Guarantee{Usage: usage}
+	assert.Assert(t, !noGuarantee.IsBelow())
+	assert.Assert(t, noGuarantee.KeepsAfterRelease(small))
+
+	g := &Guarantee{Guaranteed: guaranteed, Usage: usage}
+	assert.Assert(t, g.IsBelow())
+	assert.Assert(t, g.FitsWithin(small), "types without a guarantee must be ignored")
+	assert.Assert(t, !g.FitsWithin(large))
+	assert.Assert(t, !g.KeepsAfterRelease(small))
+	g = &Guarantee{Guaranteed: guaranteed, Usage: resources.NewResourceFromMap(map[string]resources.Quantity{"memory": 15})}
+	assert.Assert(t, !g.IsBelow())
+	assert.Assert(t, g.KeepsAfterRelease(large))
+	assert.Assert(t, !g.KeepsAfterRelease(resources.Add(large, large)))
+	g = &Guarantee{Guaranteed: guaranteed}
+	assert.Assert(t, g.IsBelow())
+	assert.Assert(t, g.FitsWithin(large))
+}
+
 func TestUserGroupLimit(t *testing.T) { //nolint:funlen
 	testCases := []struct {
 		name                          string
@@ -1989,6 +2157,7 @@ func setupUGM() {
 	manager.ClearUserTrackers()
 	manager.ClearGroupTrackers()
 	manager.ClearConfigLimits()
+	manager.ClearApplicationCounts()
 }
 
 func assertUGM(t *testing.T, userGroup security.UserGroup, expected *resources.Resource, usersCount int) {
@@ -32,14 +32,44 @@ import (
 // The QueueTracker is designed to be lock free and should remain as such.
 // Each QueueTracker object is always only linked to single UserTracker or GroupTracker. The responsibility of managing locks is delegated to those objects.
 type QueueTracker struct {
-	queueName           string
-	queuePath           string
-	resourceUsage       *resources.Resource
-	runningApplications map[string]bool
-	maxResources        *resources.Resource
-	maxRunningApps      uint64
-	childQueueTrackers  map[string]*QueueTracker
-	useWildCard         bool
+	queueName               string
+	queuePath               string
+	resourceUsage           *resources.Resource
+	runningApplications     map[string]bool
+	maxResources            *resources.Resource
+	maxRunningApps          uint64
+	maxPendingAsks          uint64
+	maxAllocations          uint64
+	maxSubmissionsPerMinute uint64
+	guaranteedResources     *resources.Resource
+	childQueueTrackers      map[string]*QueueTracker
+	useWildCard             bool
+}
+
+// countLimit holds the limits on the number of pending asks, running allocations and submissions for one queue.
+// The counts the limits are checked against are tracked outside the queue tracker, see limitCounts.
+type countLimit struct {
+	queuePath               string
+	maxPendingAsks          uint64
+	maxAllocations          uint64
+	maxSubmissionsPerMinute uint64
+}
+
+// guarantee holds the guaranteed resources and the usage of a user or group for one queue.
+type guarantee struct {
+	queuePath  string
+	guaranteed *resources.Resource
+	usage      *resources.Resource
+}
+
+func (g guarantee) export(name string, isGroup bool) *Guarantee {
+	return &Guarantee{
+		QueuePath:  g.queuePath,
+		Name:       name,
+		Group:      isGroup,
+		Guaranteed: g.guaranteed,
+		Usage:      g.usage,
+	}
 }
 
 func newRootQueueTracker(trackType trackingType) *QueueTracker {
@@ -72,6 +102,10 @@ func newQueueTracker(queuePath string, queueName string, trackType trackingType)
 				zap.Stringer("max resources", config.maxResources))
 			queueTracker.maxResources = config.maxResources.Clone()
 			queueTracker.maxRunningApps = config.maxApplications
+			queueTracker.maxPendingAsks = config.maxPendingAsks
+			queueTracker.maxAllocations = config.maxAllocations
+			queueTracker.maxSubmissionsPerMinute = config.maxSubmissionsPerMinute
+			queueTracker.guaranteedResources = config.guaranteedResources.Clone()
 			queueTracker.useWildCard = true
 		}
 	}
@@ -172,20 +206,28 @@ func (qt *QueueTracker) decreaseTrackedResource(hierarchy []string, applicationI
 
 	// Determine if the queue tracker should be removed
 	removeQT := len(qt.childQueueTrackers) == 0 && len(qt.runningApplications) == 0 && resources.IsZero(qt.resourceUsage) &&
-		qt.maxRunningApps == 0 && resources.IsZero(qt.maxResources)
+		qt.maxRunningApps == 0 && resources.IsZero(qt.maxResources) && !qt.hasCountLimits() && resources.IsZero(qt.guaranteedResources)
 	log.Log(log.SchedUGM).Debug("Remove queue tracker",
 		zap.String("queue path ", qt.queuePath),
 		zap.Bool("remove QT", removeQT))
 	return removeQT
 }
 
+// A nil limit clears all limits.
 // Note: Lock free call. The Lock of the linked tracker (UserTracker and GroupTracker) should be held before calling this function.
-func (qt *QueueTracker) setLimit(hierarchy []string, maxResource *resources.Resource, maxApps uint64, useWildCard bool, trackType trackingType, doWildCardCheck bool) {
+func (qt *QueueTracker) setLimit(hierarchy []string, limit *LimitConfig, useWildCard bool, trackType trackingType, doWildCardCheck bool) {
+	if limit == nil {
+		limit = &LimitConfig{}
+	}
 	log.Log(log.SchedUGM).Debug("Setting limits",
 		zap.String("queue path", qt.queuePath),
 		zap.Strings("hierarchy", hierarchy),
-		zap.Uint64("max applications", maxApps),
-		zap.Stringer("max resources", maxResource),
+		zap.Uint64("max applications", limit.maxApplications),
+		zap.Stringer("max resources", limit.maxResources),
+		zap.Uint64("max pending asks", limit.maxPendingAsks),
+		zap.Uint64("max allocations", limit.maxAllocations),
+		zap.Uint64("max submissions per minute", limit.maxSubmissionsPerMinute),
+		zap.Stringer("guaranteed resources", limit.guaranteedResources),
 		zap.Bool("use wild card", useWildCard))
 	// depth first: all the way to the leaf, create if not exists
 	// more than 1 in the slice means we need to recurse down
@@ -194,18 +236,116 @@ func (qt *QueueTracker) setLimit(hierarchy []string, maxResource *resources.Reso
 		if qt.childQueueTrackers[childName] == nil {
 			qt.childQueueTrackers[childName] = newQueueTracker(qt.queuePath, childName, trackType)
 		}
-		qt.childQueueTrackers[childName].setLimit(hierarchy[1:], maxResource, maxApps, useWildCard, trackType, doWildCardCheck)
+		qt.childQueueTrackers[childName].setLimit(hierarchy[1:], limit, useWildCard, trackType, doWildCardCheck)
 	} else if len(hierarchy) == 1 {
 		// don't override named user/group specific limits with wild card limits
 		if doWildCardCheck && !qt.useWildCard {
 			return
 		}
-		qt.maxRunningApps = maxApps
-		qt.maxResources = maxResource
+		qt.maxRunningApps = limit.maxApplications
+		qt.maxResources = limit.maxResources
+		qt.maxPendingAsks = limit.maxPendingAsks
+		qt.maxAllocations = limit.maxAllocations
+		qt.maxSubmissionsPerMinute = limit.maxSubmissionsPerMinute
+		qt.guaranteedResources = limit.guaranteedResources
 		qt.useWildCard = useWildCard
 	}
 }
 
+// hasCountLimits returns true if a limit on pending asks, running allocations or submissions is set.
+// Note: Lock free call. The RLock of the linked tracker (UserTracker and GroupTracker) should be held before calling this function.
+func (qt *QueueTracker) hasCountLimits() bool {
+	return qt.maxPendingAsks != 0 || qt.maxAllocations != 0 || qt.maxSubmissionsPerMinute != 0
+}
+
+// getCountLimits returns the count limits set for the queues in the hierarchy, starting at the leaf.
+// Queues without count limits are skipped.
+// Note: Lock free call. The Lock of the linked tracker (UserTracker and GroupTracker) should be held before calling this function.
+// Note: getCountLimits is not read-only, it also traverses the queue hierarchy and creates childQueueTracker if it does not exist.
+// This makes sure the wild card limits are applied, like for the headroom.
+func (qt *QueueTracker) getCountLimits(hierarchy []string, trackType trackingType) []countLimit {
+	var limits []countLimit
+	if len(hierarchy) > 1 {
+		childName := hierarchy[1]
+		if qt.childQueueTrackers[childName] == nil {
+			qt.childQueueTrackers[childName] = newQueueTracker(qt.queuePath, childName, trackType)
+		}
+		limits = qt.childQueueTrackers[childName].getCountLimits(hierarchy[1:], trackType)
+	}
+	if qt.hasCountLimits() {
+		limits = append(limits, countLimit{
+			queuePath:               qt.queuePath,
+			maxPendingAsks:          qt.maxPendingAsks,
+			maxAllocations:          qt.maxAllocations,
+			maxSubmissionsPerMinute: qt.maxSubmissionsPerMinute,
+		})
+	}
+	return limits
+}
+
+// getGuarantees returns the guaranteed resources and the usage for the queues in the hierarchy that have guaranteed
+// resources set, starting at the leaf.
+// Note: Lock free call. The Lock of the linked tracker (UserTracker and GroupTracker) should be held before calling this function.
+// Note: getGuarantees is not read-only, it also traverses the queue hierarchy and creates childQueueTracker if it does not exist.
+// This makes sure the wild card limits are applied, like for the headroom.
+func (qt *QueueTracker) getGuarantees(hierarchy []string, trackType trackingType) []guarantee {
+	var guarantees []guarantee
+	if len(hierarchy) > 1 {
+		childName := hierarchy[1]
+		if qt.childQueueTrackers[childName] == nil {
+			qt.childQueueTrackers[childName] = newQueueTracker(qt.queuePath, childName, trackType)
+		}
+		guarantees = qt.childQueueTrackers[childName].getGuarantees(hierarchy[1:], trackType)
+	}
+	if !resources.IsZero(qt.guaranteedResources) {
+		guarantees = append(guarantees, guarantee{
+			queuePath:  qt.queuePath,
+			guaranteed: qt.guaranteedResources.Clone(),
+			usage:      qt.resourceUsage.Clone(),
+		})
+	}
+	return guarantees
+}
+
+// getGuarantee returns the guaranteed resources and the usage of the queue in the hierarchy. The queue tracker is not
+// created if it does not exist: nothing is returned.
+// Note: Lock free call. The RLock of the linked tracker (UserTracker and GroupTracker) should be held before calling this function.
+func (qt *QueueTracker) getGuarantee(hierarchy []string) *guarantee {
+	if len(hierarchy) > 1 {
+		child := qt.childQueueTrackers[hierarchy[1]]
+		if child == nil {
+			return nil
+		}
+		return child.getGuarantee(hierarchy[1:])
+	}
+	return &guarantee{
+		queuePath:  qt.queuePath,
+		guaranteed: qt.guaranteedResources.Clone(),
+		usage:      qt.resourceUsage.Clone(),
+	}
+}
+
+// isBelowGuarantee returns true if the usage is below the guaranteed resources in the queue or any of its parents in
+// the hierarchy. The number of levels of the hierarchy that are tracked is returned as well, queue trackers that do
+// not exist are not created.
+// Note: Lock free call. The RLock of the linked tracker (UserTracker and GroupTracker) should be held before calling this function.
+func (qt *QueueTracker) isBelowGuarantee(hierarchy []string) (bool, int) {
+	if !resources.IsZero(qt.guaranteedResources) {
+		for name, quantity := range qt.guaranteedResources.Resources {
+			if qt.resourceUsage == nil || qt.resourceUsage.Resources[name] < quantity {
+				return true, 1
+			}
+		}
+	}
+	if len(hierarchy) > 1 {
+		if child := qt.childQueueTrackers[hierarchy[1]]; child != nil {
+			below, tracked := child.isBelowGuarantee(hierarchy[1:])
+			return below, tracked + 1
+		}
+	}
+	return false, 1
+}
+
 // Note: Lock free call. The Lock of the linked tracker (UserTracker and GroupTracker) should be held before calling this function.
 // Note: headroom is not read-only, it also traverses the queue hierarchy and creates childQueueTracker if it does not exist.
 func (qt *QueueTracker) headroom(hierarchy []string, trackType trackingType) *resources.Resource {
@@ -250,12 +390,16 @@ func (qt *QueueTracker) getResourceUsageDAOInfo() *dao.ResourceUsageDAOInfo {
 		i++
 	}
 	return &dao.ResourceUsageDAOInfo{
-		QueuePath:           qt.queuePath,
-		ResourceUsage:       qt.resourceUsage.DAOMap(),
-		MaxResources:        qt.maxResources.DAOMap(),
-		MaxApplications:     qt.maxRunningApps,
-		RunningApplications: apps,
-		Children:            children,
+		QueuePath:               qt.queuePath,
+		ResourceUsage:           qt.resourceUsage.DAOMap(),
+		MaxResources:            qt.maxResources.DAOMap(),
+		MaxApplications:         qt.maxRunningApps,
+		GuaranteedResources:     qt.guaranteedResources.DAOMap(),
+		MaxPendingAsks:          qt.maxPendingAsks,
+		MaxAllocations:          qt.maxAllocations,
+		MaxSubmissionsPerMinute: qt.maxSubmissionsPerMinute,
+		RunningApplications:     apps,
+		Children:                children,
 	}
 }
 
@@ -470,7 +614,7 @@ func (qt *QueueTracker) canBeRemoved() bool {
 
 func (qt *QueueTracker) canBeRemovedInternal() bool {
 	if len(qt.runningApplications) == 0 && resources.IsZero(qt.resourceUsage) && len(qt.childQueueTrackers) == 0 &&
-		qt.maxRunningApps == 0 && resources.IsZero(qt.maxResources) {
+		qt.maxRunningApps == 0 && resources.IsZero(qt.maxResources) && !qt.hasCountLimits() && resources.IsZero(qt.guaranteedResources) {
 		return true
 	}
 	return false
@@ -415,7 +415,7 @@ func TestSetLimit(t *testing.T) {
 	limit := resources.NewResourceFromMap(map[string]resources.Quantity{
 		"mem":   10,
 		"vcore": 10})
-	root.setLimit(strings.Split(queuePath1, configs.DOT), limit.Clone(), 9, true, user, true)
+	root.setLimit(strings.Split(queuePath1, configs.DOT), &LimitConfig{maxResources: limit.Clone(), maxApplications: 9}, true, user, true)
 
 	// check settings
 	parentQ := root.childQueueTrackers["parent"]
@@ -430,23 +430,23 @@ func TestSetLimit(t *testing.T) {
 	newLimit := resources.NewResourceFromMap(map[string]resources.Quantity{
 		"mem":   20,
 		"vcore": 20})
-	root.setLimit(strings.Split(queuePath1, configs.DOT), newLimit.Clone(), 3, false, user, true) // override
+	root.setLimit(strings.Split(queuePath1, configs.DOT), &LimitConfig{maxResources: newLimit.Clone(), maxApplications: 3}, false, user, true) // override
 	assert.Assert(t, resources.Equals(newLimit, childQ.maxResources))
 	assert.Assert(t, !childQ.useWildCard)
 	newLimit2 := resources.NewResourceFromMap(map[string]resources.Quantity{
 		"mem":   30,
 		"vcore": 30})
-	root.setLimit(strings.Split(queuePath1, configs.DOT), newLimit2.Clone(), 2, true, user, true) // no override
+	root.setLimit(strings.Split(queuePath1, configs.DOT), &LimitConfig{maxResources: newLimit2.Clone(), maxApplications: 2}, true, user, true) // no override
 	assert.Assert(t, !childQ.useWildCard)
 	assert.Assert(t, resources.Equals(newLimit, childQ.maxResources))
 	assert.Equal(t, uint64(3), childQ.maxRunningApps)
 
-	root.setLimit(strings.Split(queuePath1, configs.DOT), newLimit2.Clone(), 4, true, user, false) // override -> changes qt.doWildCardCheck
+	root.setLimit(strings.Split(queuePath1, configs.DOT), &LimitConfig{maxResources: newLimit2.Clone(), maxApplications: 4}, true, user, false) // override -> changes qt.doWildCardCheck
 	assert.Assert(t, childQ.useWildCard)
 	assert.Assert(t, resources.Equals(newLimit2, childQ.maxResources))
 	assert.Equal(t, uint64(4), childQ.maxRunningApps)
 
-	root.setLimit(strings.Split(queuePath1, configs.DOT), newLimit.Clone(), 5, false, user, false) // override
+	root.setLimit(strings.Split(queuePath1, configs.DOT), &LimitConfig{maxResources: newLimit.Clone(), maxApplications: 5}, false, user, false) // override
 	assert.Assert(t, !childQ.useWildCard)
 	assert.Assert(t, resources.Equals(newLimit, childQ.maxResources))
 	assert.Equal(t, uint64(5), childQ.maxRunningApps)
@@ -109,6 +109,22 @@ func (evt *ugmEvents) sendLimitRemoveForGroup(group, queuePath string) {
 	evt.eventSystem.AddEvent(event)
 }
 
+func (evt *ugmEvents) sendLimitExceededForUser(user, queuePath, message string) {
+	if !evt.eventSystem.IsEventTrackingEnabled() {
+		return
+	}
+	event := events.CreateUserGroupEventRecord(user, message, queuePath, si.EventRecord_NONE, si.EventRecord_UG_USER_LIMIT, nil)
+	evt.eventSystem.AddEvent(event)
+}
+
+func (evt *ugmEvents) sendLimitExceededForGroup(group, queuePath, message string) {
+	if !evt.eventSystem.IsEventTrackingEnabled() {
+		return
+	}
+	event := events.CreateUserGroupEventRecord(group, message, queuePath, si.EventRecord_NONE, si.EventRecord_UG_GROUP_LIMIT, nil)
+	evt.eventSystem.AddEvent(event)
+}
+
 func newUGMEvents(evt events.EventSystem) *ugmEvents {
 	return &ugmEvents{
 		eventSystem: evt,
@@ -0,0 +1,267 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package ugm
+
+import (
+	"math"
+	"time"
+
+	"github.com/apache/yunikorn-core/pkg/common"
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+	"github.com/apache/yunikorn-core/pkg/locking"
+)
+
+// number of half-lives an entry without allocations is kept, the usage has decayed to less than 0.1% by then
+const pruneHalfLives = 10
+
+// usageHistory keeps the exponentially decayed resource usage, in resource-seconds, of users, groups and queues.
+// Usage one half-life ago counts for half of the usage now, two half-lives ago for a quarter and so on.
+// The history is kept outside the trackers as it must outlive the allocations that caused it.
+// Only allocations that changed after the history was enabled are taken into account.
+type usageHistory struct {
+	halfLife  time.Duration // zero means the history is disabled
+	users     map[string]*decayedUsage
+	groups    map[string]*decayedUsage
+	queues    map[string]*decayedUsage // keyed on the full queue path, parents include the usage of their children
+	lastPrune time.Time
+
+	locking.Mutex
+}
+
+// decayedUsage is the decayed usage of one user, group or queue and the resources allocated since the last change.
+type decayedUsage struct {
+	usage      map[string]float64
+	allocated  *resources.Resource
+	lastUpdate time.Time // time the usage was last decayed
+	lastChange time.Time // time the allocated resources were last changed
+}
+
+func newUsageHistory() *usageHistory {
+	return &usageHistory{
+		users:  make(map[string]*decayedUsage),
+		groups: make(map[string]*decayedUsage),
+		queues: make(map[string]*decayedUsage),
+	}
+}
+
+// setHalfLife updates the half-life of the history. The existing usage is decayed using the old half-life
+// before the change. Setting a half-life of zero or less disables the history and removes all usage.
+func (h *usageHistory) setHalfLife(halfLife time.Duration, now time.Time) {
+	h.Lock()
+	defer h.Unlock()
+	if halfLife <= 0 {
+		h.halfLife = 0
+		h.clearLocked()
+		return
+	}
+	if h.halfLife > 0 {
+		for _, entries := range []map[string]*decayedUsage{h.users, h.groups, h.queues} {
+			for _, du := range entries {
+				du.decay(now, h.halfLife)
+			}
+		}
+	}
+	h.halfLife = halfLife
+}
+
+func (h *usageHistory) isEnabled() bool {
+	h.Lock()
+	defer h.Unlock()
+	return h.halfLife > 0
+}
+
+// update changes the allocated resources of the user, the group and the queue with all its parents.
+// The usage of each is decayed up to now, using the allocated resources before the change.
+// The group is optional, an empty group is not tracked.
+func (h *usageHistory) update(queuePath, user, group string, delta *resources.Resource, increase bool, now time.Time) {
+	h.Lock()
+	defer h.Unlock()
+	if h.halfLife <= 0 {
+		return
+	}
+	h.updateEntry(h.users, user, delta, increase, now)
+	if group != common.Empty {
+		h.updateEntry(h.groups, group, delta, increase, now)
+	}
+	for path := queuePath; path != common.Empty; path = getParentPath(path) {
+		h.updateEntry(h.queues, path, delta, increase, now)
+	}
+	h.pruneLocked(now)
+}
+
+func (h *usageHistory) updateEntry(entries map[string]*decayedUsage, name string, delta *resources.Resource, increase bool, now time.Time) {
+	du := entries[name]
+	if du == nil {
+		if !increase {
+			// nothing tracked: the allocation was made before the history was enabled
+			return
+		}
+		du = &decayedUsage{
+			usage:      make(map[string]float64),
+			lastUpdate: now,
+		}
+		entries[name] = du
+	}
+	du.decay(now, h.halfLife)
+	if increase {
+		du.allocated = resources.Add(du.allocated, delta)
+	} else {
+		du.allocated = resources.SubEliminateNegative(du.allocated, delta)
+	}
+	du.lastChange = now
+}
+
+// get returns a copy of the usage decayed up to now, nil if nothing is tracked.
+func (h *usageHistory) get(entries map[string]*decayedUsage, name string, now time.Time) map[string]float64 {
+	h.Lock()
+	defer h.Unlock()
+	du := entries[name]
+	if du == nil || h.halfLife <= 0 {
+		return nil
+	}
+	du.decay(now, h.halfLife)
+	usage := make(map[string]float64, len(du.usage))
+	for k, v := range du.usage {
+		usage[k] = v
+	}
+	return usage
+}
+
+// pruneLocked removes the entries without allocated resources that have not changed for a number of half-lives.
+// The check runs at most once per half-life.
+func (h *usageHistory) pruneLocked(now time.Time) {
+	if now.Sub(h.lastPrune) < h.halfLife {
+		return
+	}
+	h.lastPrune = now
+	for _, entries := range []map[string]*decayedUsage{h.users, h.groups, h.queues} {
+		for name, du := range entries {
+			if resources.IsZero(du.allocated) && now.Sub(du.lastChange) > pruneHalfLives*h.halfLife {
+				delete(entries, name)
+			}
+		}
+	}
+}
+
+// snapshot returns a copy of the usage of all users, groups and queues decayed up to now.
+// Returns nil maps if the history is disabled.
+func (h *usageHistory) snapshot(now time.Time) (map[string]map[string]float64, map[string]map[string]float64, map[string]map[string]float64) {
+	h.Lock()
+	defer h.Unlock()
+	if h.halfLife <= 0 {
+		return nil, nil, nil
+	}
+	copyEntries := func(entries map[string]*decayedUsage) map[string]map[string]float64 {
+		result := make(map[string]map[string]float64, len(entries))
+		for name, du := range entries {
+			du.decay(now, h.halfLife)
+			usage := make(map[string]float64, len(du.usage))
+			for k, v := range du.usage {
+				usage[k] = v
+			}
+			result[name] = usage
+		}
+		return result
+	}
+	return copyEntries(h.users), copyEntries(h.groups), copyEntries(h.queues)
+}
+
+// restore adds the usage saved at an earlier time to the history. The saved usage is decayed for the time
+// between saving and now, nothing was allocated during that time.
+func (h *usageHistory) restore(users, groups, queues map[string]map[string]float64, saved, now time.Time) {
+	h.Lock()
+	defer h.Unlock()
+	if h.halfLife <= 0 {
+		return
+	}
+	factor := 1.0
+	if elapsed := now.Sub(saved).Seconds(); elapsed > 0 {
+		factor = math.Exp(-math.Ln2 / h.halfLife.Seconds() * elapsed)
+	}
+	restoreEntries := func(entries map[string]*decayedUsage, restored map[string]map[string]float64) {
+		for name, usage := range restored {
+			du := entries[name]
+			if du == nil {
+				du = &decayedUsage{
+					usage:      make(map[string]float64),
+					lastUpdate: now,
+					lastChange: now,
+				}
+				entries[name] = du
+			}
+			du.decay(now, h.halfLife)
+			for k, v := range usage {
+				du.usage[k] += v * factor
+			}
+		}
+	}
+	restoreEntries(h.users, users)
+	restoreEntries(h.groups, groups)
+	restoreEntries(h.queues, queues)
+}
+
+func (h *usageHistory) clearLocked() {
+	h.users = make(map[string]*decayedUsage)
+	h.groups = make(map[string]*decayedUsage)
+	h.queues = make(map[string]*decayedUsage)
+}
+
+// decay applies the exponential decay to the usage for the time since the last update and adds the
+// allocated resources for that time. The allocation did not change during that time, which means the
+// added usage is the integral of the decayed allocation over the elapsed time.
+func (du *decayedUsage) decay(now time.Time, halfLife time.Duration) {
+	elapsed := now.Sub(du.lastUpdate).Seconds()
+	if elapsed <= 0 {
+		return
+	}
+	du.lastUpdate = now
+	lambda := math.Ln2 / halfLife.Seconds()
+	factor := math.Exp(-lambda * elapsed)
+	for k, v := range du.usage {
+		du.usage[k] = v * factor
+	}
+	if du.allocated == nil {
+		return
+	}
+	gain := (1 - factor) / lambda
+	for k, v := range du.allocated.Resources {
+		if v > 0 {
+			du.usage[k] += float64(v) * gain
+		}
+	}
+}
+
+// UsageShare returns the dominant share of the decayed usage: the largest usage of a resource type relative to
+// the total of that type. Like the other share calculations the share is the usage itself if the total is nil
+// or zero for the resource type.
+// The total is normally the capacity of the partition, a user that used the full partition for a long time
+// has a share that approaches the number of seconds in a half-life divided by ln(2).
+func UsageShare(usage map[string]float64, total *resources.Resource) float64 {
+	var share float64
+	for k, v := range usage {
+		s := v
+		if total != nil && total.Resources[k] > 0 {
+			s /= float64(total.Resources[k])
+		}
+		if s > share {
+			share = s
+		}
+	}
+	return share
+}
@@ -0,0 +1,147 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package ugm
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+	"github.com/apache/yunikorn-core/pkg/common/security"
+)
+
+func TestUsageHistoryDecay(t *testing.T) {
+	start := time.Now()
+	h := newUsageHistory()
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 10, "memory": 100})
+
+	// disabled: nothing is tracked
+	h.update("root.a", "user", "group", res, true, start)
+	assert.Equal(t, len(h.users), 0, "history should not be tracked when disabled")
+
+	h.setHalfLife(time.Hour, start)
+	h.update("root.a", "user", "group", res, true, start)
+	assert.Equal(t, len(h.users), 1, "user not tracked")
+	assert.Equal(t, len(h.groups), 1, "group not tracked")
+	assert.Equal(t, len(h.queues), 2, "queue and parent not tracked")
+	assert.Equal(t, len(h.get(h.users, "user", start)), 0, "no usage expected without elapsed time")
+
+	// after one half-life the usage is the integral of the decayed allocation: alloc * halfLife / ln(2) / 2
+	expected := 10 * 3600 / math.Ln2 / 2
+	usage := h.get(h.users, "user", start.Add(time.Hour))
+	assert.Assert(t, math.Abs(usage["vcore"]-expected) < 0.001, "unexpected vcore usage: %f, expected %f", usage["vcore"], expected)
+	assert.Assert(t, math.Abs(usage["memory"]-10*expected) < 0.01, "unexpected memory usage: %f", usage["memory"])
+	usage = h.get(h.queues, "root", start.Add(time.Hour))
+	assert.Assert(t, math.Abs(usage["vcore"]-expected) < 0.001, "unexpected root queue usage: %f", usage["vcore"])
+
+	// release all: the usage halves every half-life
+	h.update("root.a", "user", "group", res, false, start.Add(time.Hour))
+	usage = h.get(h.users, "user", start.Add(2*time.Hour))
+	assert.Assert(t, math.Abs(usage["vcore"]-expected/2) < 0.001, "usage should halve after release: %f", usage["vcore"])
+	usage = h.get(h.groups, "group", start.Add(3*time.Hour))
+	assert.Assert(t, math.Abs(usage["vcore"]-expected/4) < 0.001, "group usage should halve after release: %f", usage["vcore"])
+
+	// a release of an untracked user should not create an entry
+	h.update("root.a", "other", "", res, false, start.Add(3*time.Hour))
+	assert.Assert(t, h.get(h.users, "other", start.Add(3*time.Hour)) == nil, "untracked user should not be added on release")
+
+	// entries without allocations are pruned after a number of half-lives
+	h.update("root.b", "new", "", res, true, start.Add(20*time.Hour))
+	assert.Assert(t, h.get(h.users, "user", start.Add(20*time.Hour)) == nil, "idle user should have been pruned")
+	assert.Assert(t, h.get(h.queues, "root.a", start.Add(20*time.Hour)) == nil, "idle queue should have been pruned")
+	assert.Assert(t, h.get(h.queues, "root", start.Add(20*time.Hour)) != nil, "root queue has allocations and should be kept")
+
+	// disable removes everything
+	h.setHalfLife(0, start.Add(20*time.Hour))
+	assert.Equal(t, len(h.users)+len(h.groups)+len(h.queues), 0, "history should be cleared when disabled")
+}
+
+func TestUsageHistorySnapshotRestore(t *testing.T) {
+	start := time.Now()
+	h := newUsageHistory()
+	users, groups, queues := h.snapshot(start)
+	assert.Assert(t, users == nil && groups == nil && queues == nil, "disabled history should not return a snapshot")
+
+	h.setHalfLife(time.Hour, start)
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 10})
+	h.update("root.a", "user", "group", res, true, start)
+	h.update("root.a", "user", "group", res, false, start.Add(time.Hour))
+	users, groups, queues = h.snapshot(start.Add(time.Hour))
+	expected := 10 * 3600 / math.Ln2 / 2
+	assert.Assert(t, math.Abs(users["user"]["vcore"]-expected) < 0.001, "unexpected user snapshot: %f", users["user"]["vcore"])
+	assert.Equal(t, len(groups), 1, "group missing from snapshot")
+	assert.Equal(t, len(queues), 2, "queues missing from snapshot")
+
+	// restore in a new history one half-life after saving: the usage has halved
+	restored := newUsageHistory()
+	restored.restore(users, groups, queues, start.Add(time.Hour), start.Add(2*time.Hour))
+	assert.Equal(t, len(restored.users), 0, "disabled history should not restore")
+	restored.setHalfLife(time.Hour, start)
+	restored.restore(users, groups, queues, start.Add(time.Hour), start.Add(2*time.Hour))
+	usage := restored.get(restored.users, "user", start.Add(2*time.Hour))
+	assert.Assert(t, math.Abs(usage["vcore"]-expected/2) < 0.001, "restored usage should be decayed: %f", usage["vcore"])
+	usage = restored.get(restored.queues, "root.a", start.Add(2*time.Hour))
+	assert.Assert(t, math.Abs(usage["vcore"]-expected/2) < 0.001, "restored queue usage should be decayed: %f", usage["vcore"])
+}
+
+func TestUsageShare(t *testing.T) {
+	total := resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 100, "memory": 1000})
+	assert.Equal(t, UsageShare(nil, total), 0.0)
+	assert.Equal(t, UsageShare(map[string]float64{"vcore": 50, "memory": 800}, total), 0.8)
+	assert.Equal(t, UsageShare(map[string]float64{"vcore": 50, "memory": 100}, total), 0.5)
+	// no total: usage is the share
+	assert.Equal(t, UsageShare(map[string]float64{"gpu": 2, "vcore": 50}, total), 2.0)
+	assert.Equal(t, UsageShare(map[string]float64{"vcore": 50}, nil), 50.0)
+}
+
+func TestManagerUsageHistory(t *testing.T) {
+	setupUGM()
+	manager := GetUserManager()
+	defer func() {
+		configs.SetConfigMap(map[string]string{})
+		manager.ClearUsageHistory()
+	}()
+	assert.Assert(t, !manager.IsUsageHistoryEnabled(), "history should be disabled by default")
+
+	configs.SetConfigMap(map[string]string{configs.FairShareHalfLife: "1h"})
+	assert.Assert(t, manager.IsUsageHistoryEnabled(), "history should be enabled by config")
+
+	user := security.UserGroup{User: "user1", Groups: []string{"group1"}}
+	res := resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 10})
+	manager.IncreaseTrackedResource(queuePath1, TestApp1, res, user)
+	time.Sleep(10 * time.Millisecond)
+	assert.Assert(t, manager.GetUserUsageHistory("user1")["vcore"] > 0, "user usage not tracked")
+	assert.Assert(t, manager.GetGroupUsageHistory("group1")["vcore"] > 0, "primary group usage not tracked")
+	assert.Assert(t, manager.GetQueueUsageHistory("root")["vcore"] > 0, "root queue usage not tracked")
+	assert.Assert(t, manager.GetUserUsageHistory("user2") == nil, "unknown user should have no history")
+
+	// the history survives the removal of the trackers
+	manager.DecreaseTrackedResource(queuePath1, TestApp1, res, user, true)
+	assert.Assert(t, manager.GetUserTracker("user1") == nil, "user tracker should have been removed")
+	assert.Assert(t, manager.GetUserUsageHistory("user1")["vcore"] > 0, "user usage history should be kept")
+
+	// negative half-life disables
+	configs.SetConfigMap(map[string]string{configs.FairShareHalfLife: "-1h"})
+	assert.Assert(t, !manager.IsUsageHistoryEnabled(), "negative half-life should disable the history")
+	assert.Assert(t, manager.GetUserUsageHistory("user1") == nil, "history should be cleared when disabled")
+}
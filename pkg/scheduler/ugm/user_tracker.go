@@ -20,6 +20,7 @@ package ugm
 
 import (
 	"strings"
+	"time"
 
 	"github.com/apache/yunikorn-core/pkg/common"
 	"github.com/apache/yunikorn-core/pkg/common/configs"
@@ -107,18 +108,18 @@ func (ut *UserTracker) getTrackedApplications() map[string]*GroupTracker {
 	return ut.appGroupTrackers
 }
 
-func (ut *UserTracker) setLimits(queuePath string, resource *resources.Resource, maxApps uint64, useWildCard bool, doWildCardCheck bool) {
+func (ut *UserTracker) setLimits(queuePath string, limit *LimitConfig, useWildCard bool, doWildCardCheck bool) {
 	ut.Lock()
 	defer ut.Unlock()
 	ut.events.sendLimitSetForUser(ut.userName, queuePath)
-	ut.queueTracker.setLimit(strings.Split(queuePath, configs.DOT), resource, maxApps, useWildCard, user, doWildCardCheck)
+	ut.queueTracker.setLimit(strings.Split(queuePath, configs.DOT), limit, useWildCard, user, doWildCardCheck)
 }
 
 func (ut *UserTracker) clearLimits(queuePath string, doWildCardCheck bool) {
 	ut.Lock()
 	defer ut.Unlock()
 	ut.events.sendLimitRemoveForUser(ut.userName, queuePath)
-	ut.queueTracker.setLimit(strings.Split(queuePath, configs.DOT), nil, 0, false, user, doWildCardCheck)
+	ut.queueTracker.setLimit(strings.Split(queuePath, configs.DOT), nil, false, user, doWildCardCheck)
 }
 
 // headroom calculate the resource headroom for the user in the hierarchy defined
@@ -130,6 +131,39 @@ func (ut *UserTracker) headroom(hierarchy []string) *resources.Resource {
 	return ut.queueTracker.headroom(hierarchy, user)
 }
 
+// getCountLimits returns the limits on pending asks, allocations and submissions for the user in the hierarchy defined
+// Note: getCountLimits of queue tracker is not read-only.
+// It traverses the queue hierarchy and creates a childQueueTracker if it does not exist.
+func (ut *UserTracker) getCountLimits(hierarchy []string) []countLimit {
+	ut.Lock()
+	defer ut.Unlock()
+	return ut.queueTracker.getCountLimits(hierarchy, user)
+}
+
+// getGuarantees returns the guaranteed resources and usage for the user in the hierarchy defined
+// Note: getGuarantees of queue tracker is not read-only.
+// It traverses the queue hierarchy and creates a childQueueTracker if it does not exist.
+func (ut *UserTracker) getGuarantees(hierarchy []string) []guarantee {
+	ut.Lock()
+	defer ut.Unlock()
+	return ut.queueTracker.getGuarantees(hierarchy, user)
+}
+
+// isBelowGuarantee returns true if the user uses less than guaranteed in the queue or any of its parents, and the
+// number of levels of the hierarchy that are tracked for the user
+func (ut *UserTracker) isBelowGuarantee(hierarchy []string) (bool, int) {
+	ut.RLock()
+	defer ut.RUnlock()
+	return ut.queueTracker.isBelowGuarantee(hierarchy)
+}
+
+// getGuarantee returns the guaranteed resources and usage for the user in the queue, nil if the queue is not tracked
+func (ut *UserTracker) getGuarantee(hierarchy []string) *guarantee {
+	ut.RLock()
+	defer ut.RUnlock()
+	return ut.queueTracker.getGuarantee(hierarchy)
+}
+
 // GetResourceUsageDAOInfo returns the DAO object used in the REST API for this user tracker
 func (ut *UserTracker) GetResourceUsageDAOInfo() *dao.UserResourceUsageDAOInfo {
 	ut.RLock()
@@ -141,10 +175,14 @@ func (ut *UserTracker) GetResourceUsageDAOInfo() *dao.UserResourceUsageDAOInfo {
 		}
 	}
 
+	queues := ut.queueTracker.getResourceUsageDAOInfo()
+	if m != nil {
+		m.counts.fillDAOInfo(user, ut.userName, queues, time.Now())
+	}
 	return &dao.UserResourceUsageDAOInfo{
 		Groups:   groups,
 		UserName: ut.userName,
-		Queues:   ut.queueTracker.getResourceUsageDAOInfo(),
+		Queues:   queues,
 	}
 }
 
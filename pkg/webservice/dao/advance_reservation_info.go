@@ -0,0 +1,33 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package dao
+
+import "time"
+
+type AdvanceReservationDAOInfo struct {
+	ID        string           `json:"id"`        // no omitempty, id should not be empty
+	QueuePath string           `json:"queuePath"` // no omitempty, queue path should not be empty
+	Nodes     []string         `json:"nodes,omitempty"`
+	Resource  map[string]int64 `json:"resource"` // no omitempty, resource should not be empty
+	Priority  int32            `json:"priority,omitempty"`
+	Start     time.Time        `json:"start"`
+	End       time.Time        `json:"end"`
+	LeadTime  string           `json:"leadTime,omitempty"` // duration string, e.g. "30m"
+	State     string           `json:"state,omitempty"`    // ignored on create
+}
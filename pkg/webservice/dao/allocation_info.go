@@ -33,6 +33,7 @@ type AllocationDAOInfo struct {
 	TaskGroupName    string            `json:"taskGroupName,omitempty"`
 	Preempted        bool              `json:"preempted,omitempty"`
 	Originator       bool              `json:"originator,omitempty"`
+	BorrowedFrom     string            `json:"borrowedFrom,omitempty"`
 }
 
 type ForeignAllocationDAOInfo struct {
@@ -42,6 +42,7 @@ type ApplicationDAOInfo struct {
 	HasReserved        bool                    `json:"hasReserved,omitempty"`
 	Reservations       []string                `json:"reservations,omitempty"`
 	MaxRequestPriority int32                   `json:"maxRequestPriority,omitempty"`
+	PriorityOverride   *int32                  `json:"priorityOverride,omitempty"`
 	StartTime          int64                   `json:"startTime,omitempty"`
 	ResourceHistory    ResourceHistory         `json:"resourceHistory,omitempty"`
 }
@@ -64,3 +65,11 @@ type ResourceHistory struct {
 	PreemptedResource   map[string]map[string]int64 `json:"preemptedResource,omitempty"`
 	PlaceholderResource map[string]map[string]int64 `json:"placeholderResource,omitempty"`
 }
+
+type ApplicationMoveDAOInfo struct {
+	Queue string `json:"queue"` // no omitempty, the full path of the target leaf queue
+}
+
+type ApplicationPriorityDAOInfo struct {
+	Priority int32 `json:"priority"` // no omitempty, zero is a valid priority
+}
@@ -0,0 +1,35 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package dao
+
+type ChargebackReportDAOInfo struct {
+	Partition   string                    `json:"partition"` // no omitempty, partition name should not be empty
+	PeriodStart int64                     `json:"periodStart"`
+	PeriodEnd   int64                     `json:"periodEnd"`
+	GroupBy     string                    `json:"groupBy"`
+	TotalCost   float64                   `json:"totalCost"`
+	Entries     []*ChargebackEntryDAOInfo `json:"entries,omitempty"`
+}
+
+type ChargebackEntryDAOInfo struct {
+	Name      string             `json:"name"`
+	Usage     map[string]int64   `json:"usage,omitempty"`
+	Cost      map[string]float64 `json:"cost,omitempty"`
+	TotalCost float64            `json:"totalCost"`
+}
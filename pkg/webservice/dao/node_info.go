@@ -38,4 +38,12 @@ type NodeDAOInfo struct {
 	Schedulable        bool                        `json:"schedulable"` // no omitempty, a false value gives a quick way to understand whether a node is schedulable.
 	IsReserved         bool                        `json:"isReserved"`  // no omitempty, a false value gives a quick way to understand whether a node is reserved.
 	Reservations       []string                    `json:"reservations,omitempty"`
+	State              string                      `json:"state,omitempty"`
+}
+
+type NodeStateUpdateDAOInfo struct {
+	Event              string `json:"event"` // no omitempty, one of cordonNode, uncordonNode, drainNode, decommissionNode
+	Reason             string `json:"reason,omitempty"`
+	GracePeriod        string `json:"gracePeriod,omitempty"` // duration string, drain only
+	ReleaseAllocations bool   `json:"releaseAllocations,omitempty"`
 }
@@ -40,4 +40,5 @@ type PartitionCapacity struct {
 type NodeSortingPolicy struct {
 	Type            string             `json:"type,omitempty"`
 	ResourceWeights map[string]float64 `json:"resourceWeights,omitempty"`
+	Scorers         map[string]float64 `json:"scorers,omitempty"`
 }
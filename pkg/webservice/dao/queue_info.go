@@ -34,6 +34,9 @@ type PartitionQueueDAOInfo struct {
 	GuaranteedResource     map[string]int64        `json:"guaranteedResource,omitempty"`
 	AllocatedResource      map[string]int64        `json:"allocatedResource,omitempty"`
 	PreemptingResource     map[string]int64        `json:"preemptingResource,omitempty"`
+	LendableResource       map[string]int64        `json:"lendableResource,omitempty"`
+	LentResource           map[string]int64        `json:"lentResource,omitempty"`
+	BorrowedResource       map[string]int64        `json:"borrowedResource,omitempty"`
 	HeadRoom               map[string]int64        `json:"headroom,omitempty"`
 	IsLeaf                 bool                    `json:"isLeaf"`    // no omitempty, a false value gives a quick way to understand whether it's leaf.
 	IsManaged              bool                    `json:"isManaged"` // no omitempty, a false value gives a quick way to understand whether it's managed.
@@ -54,4 +57,12 @@ type PartitionQueueDAOInfo struct {
 	PreemptionDelay        string                  `json:"preemptionDelay,omitempty"`
 	IsPriorityFence        bool                    `json:"isPriorityFence"` // no omitempty, a false value gives a quick way to understand whether it's fenced.
 	PriorityOffset         int32                   `json:"priorityOffset,omitempty"`
+	Weight                 float64                 `json:"weight,omitempty"`
+	Schedules              []string                `json:"schedules,omitempty"`
+	ActiveSchedule         string                  `json:"activeSchedule,omitempty"`
+	ScheduleTransitionTime int64                   `json:"scheduleTransitionTime,omitempty"`
+}
+
+type QueueStateUpdateDAOInfo struct {
+	Event string `json:"event"` // no omitempty, one of stop or start
 }
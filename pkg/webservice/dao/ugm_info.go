@@ -31,10 +31,17 @@ type GroupResourceUsageDAOInfo struct {
 }
 
 type ResourceUsageDAOInfo struct {
-	QueuePath           string                  `json:"queuePath"` // no omitempty, queue path should not be empty
-	ResourceUsage       map[string]int64        `json:"resourceUsage,omitempty"`
-	RunningApplications []string                `json:"runningApplications,omitempty"`
-	MaxResources        map[string]int64        `json:"maxResources,omitempty"`
-	MaxApplications     uint64                  `json:"maxApplications,omitempty"`
-	Children            []*ResourceUsageDAOInfo `json:"children,omitempty"`
+	QueuePath               string                  `json:"queuePath"` // no omitempty, queue path should not be empty
+	ResourceUsage           map[string]int64        `json:"resourceUsage,omitempty"`
+	RunningApplications     []string                `json:"runningApplications,omitempty"`
+	MaxResources            map[string]int64        `json:"maxResources,omitempty"`
+	MaxApplications         uint64                  `json:"maxApplications,omitempty"`
+	GuaranteedResources     map[string]int64        `json:"guaranteedResources,omitempty"`
+	PendingAsks             uint64                  `json:"pendingAsks,omitempty"`
+	MaxPendingAsks          uint64                  `json:"maxPendingAsks,omitempty"`
+	RunningAllocations      uint64                  `json:"runningAllocations,omitempty"`
+	MaxAllocations          uint64                  `json:"maxAllocations,omitempty"`
+	SubmissionsLastMinute   uint64                  `json:"submissionsLastMinute,omitempty"`
+	MaxSubmissionsPerMinute uint64                  `json:"maxSubmissionsPerMinute,omitempty"`
+	Children                []*ResourceUsageDAOInfo `json:"children,omitempty"`
 }
@@ -20,6 +20,7 @@ package webservice
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math"
@@ -40,6 +41,7 @@ import (
 	"github.com/apache/yunikorn-core/pkg/common"
 	"github.com/apache/yunikorn-core/pkg/common/configs"
 	"github.com/apache/yunikorn-core/pkg/common/resources"
+	"github.com/apache/yunikorn-core/pkg/common/security"
 	"github.com/apache/yunikorn-core/pkg/events"
 	"github.com/apache/yunikorn-core/pkg/locking"
 	"github.com/apache/yunikorn-core/pkg/log"
@@ -47,6 +49,7 @@ import (
 	"github.com/apache/yunikorn-core/pkg/metrics/history"
 	"github.com/apache/yunikorn-core/pkg/plugins"
 	"github.com/apache/yunikorn-core/pkg/scheduler"
+	"github.com/apache/yunikorn-core/pkg/scheduler/accounting"
 	"github.com/apache/yunikorn-core/pkg/scheduler/objects"
 	"github.com/apache/yunikorn-core/pkg/scheduler/ugm"
 	"github.com/apache/yunikorn-core/pkg/webservice/dao"
@@ -62,6 +65,15 @@ const (
 	GroupDoesNotExists       = "Group not found"
 	ApplicationDoesNotExists = "Application not found"
 	NodeDoesNotExists        = "Node not found"
+	ReservationDoesNotExists = "Reservation not found"
+	AllocationDoesNotExists  = "Allocation not found"
+	MissingUserIdentity      = "Missing user identity"
+	UntrustedUserIdentity    = "User identity headers are not trusted"
+
+	// UserHeader and GroupHeader carry the identity of the caller for the admin endpoints. The headers must be set
+	// by an authenticating proxy in front of the web service, and are only used if the proxy is trusted in the config.
+	UserHeader  = "X-Remote-User"
+	GroupHeader = "X-Remote-Group"
 
 	AppStateActive    = "active"
 	AppStateRejected  = "rejected"
@@ -126,6 +138,13 @@ func redirectDebug(w http.ResponseWriter, r *http.Request) {
 	_, _ = fmt.Fprintln(w, body)
 }
 
+// notFound is used by the router for any request that does not match a route.
+// It returns the same JSON formatted error as the handlers do for unknown objects.
+func notFound(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w, r.Method)
+	buildJSONErrorResponse(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+}
+
 func getStackInfo(w http.ResponseWriter, r *http.Request) {
 	writeHeaders(w, r.Method)
 	var stack = func() []byte {
@@ -195,6 +214,16 @@ func writeHeaders(w http.ResponseWriter, method string) {
 	w.Header().Set("Access-Control-Allow-Headers", "X-Requested-With,Content-Type,Accept,Origin")
 }
 
+// writeAdminHeaders sets the headers for the admin endpoints: cross-origin requests are not allowed.
+func writeAdminHeaders(w http.ResponseWriter, method string) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	methods := "OPTIONS, POST"
+	if method == http.MethodDelete {
+		methods = "DELETE, OPTIONS"
+	}
+	w.Header().Set("Access-Control-Allow-Methods", methods)
+}
+
 func buildJSONErrorResponse(w http.ResponseWriter, detail string, code int) {
 	w.WriteHeader(code)
 	errorInfo := dao.NewYAPIError(nil, code, detail)
@@ -271,6 +300,7 @@ func getAllocationDAO(alloc *objects.Allocation) *dao.AllocationDAOInfo {
 		ApplicationID:    alloc.GetApplicationID(),
 		Preempted:        alloc.IsPreempted(),
 		Originator:       alloc.IsOriginator(),
+		BorrowedFrom:     alloc.GetBorrowedFrom(),
 	}
 	return allocDAO
 }
@@ -372,11 +402,20 @@ func getApplicationDAO(app *objects.Application) *dao.ApplicationDAOInfo {
 		HasReserved:        app.HasReserved(),
 		Reservations:       app.GetReservations(),
 		MaxRequestPriority: app.GetAskMaxPriority(),
+		PriorityOverride:   app.GetPriorityOverride(),
 		StartTime:          app.StartTime().UnixMilli(),
 		ResourceHistory:    resHistory,
 	}
 }
 
+func getApplicationsDAOList(apps []*objects.Application) []*dao.ApplicationDAOInfo {
+	appsDao := make([]*dao.ApplicationDAOInfo, 0, len(apps))
+	for _, app := range apps {
+		appsDao = append(appsDao, getApplicationDAO(app))
+	}
+	return appsDao
+}
+
 func getAllocationLogsDAO(logEntries []*objects.AllocationLogEntry) []*dao.AllocationAskLogDAOInfo {
 	logsDAO := make([]*dao.AllocationAskLogDAOInfo, len(logEntries))
 	sort.SliceStable(logEntries, func(i, j int) bool {
@@ -437,6 +476,7 @@ func getNodeDAO(node *objects.Node) *dao.NodeDAOInfo {
 		Schedulable:        node.IsSchedulable(),
 		IsReserved:         node.IsReserved(),
 		Reservations:       node.GetReservationKeys(),
+		State:              node.GetNodeState(),
 	}
 }
 
@@ -758,14 +798,16 @@ func getPartitionNodes(w http.ResponseWriter, r *http.Request) {
 	}
 	partition := vars.ByName("partition")
 	partitionContext := schedulerContext.Load().GetPartitionWithoutClusterID(partition)
-	if partitionContext != nil {
-		nodesDao := getNodesDAO(partitionContext.GetNodes())
-		if err := json.NewEncoder(w).Encode(nodesDao); err != nil {
-			buildJSONErrorResponse(w, err.Error(), http.StatusInternalServerError)
-		}
-	} else {
+	if partitionContext == nil {
 		buildJSONErrorResponse(w, PartitionDoesNotExists, http.StatusNotFound)
+		return
+	}
+	opts, err := parseListOptions(r)
+	if err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
 	}
+	writeList(w, opts, getNodesDAO(opts.filterNodes(partitionContext.GetNodes())))
 }
 
 func getPartitionNode(w http.ResponseWriter, r *http.Request) {
@@ -801,6 +843,11 @@ func getQueueApplications(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	partition := vars.ByName("partition")
+	partitionContext := schedulerContext.Load().GetPartitionWithoutClusterID(partition)
+	if partitionContext == nil {
+		buildJSONErrorResponse(w, PartitionDoesNotExists, http.StatusNotFound)
+		return
+	}
 	queueName := vars.ByName("queue")
 	unescapedQueueName, err := url.QueryUnescape(queueName)
 	if err != nil {
@@ -812,25 +859,22 @@ func getQueueApplications(w http.ResponseWriter, r *http.Request) {
 		buildJSONErrorResponse(w, queueErr.Error(), http.StatusBadRequest)
 		return
 	}
-	partitionContext := schedulerContext.Load().GetPartitionWithoutClusterID(partition)
-	if partitionContext == nil {
-		buildJSONErrorResponse(w, PartitionDoesNotExists, http.StatusNotFound)
-		return
-	}
 	queue := partitionContext.GetQueue(unescapedQueueName)
 	if queue == nil {
 		buildJSONErrorResponse(w, QueueDoesNotExists, http.StatusNotFound)
 		return
 	}
 
-	appsDao := make([]*dao.ApplicationDAOInfo, 0)
-	for _, app := range queue.GetCopyOfApps() {
-		appsDao = append(appsDao, getApplicationDAO(app))
+	opts, err := parseListOptions(r)
+	if err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
 	}
-
-	if err := json.NewEncoder(w).Encode(appsDao); err != nil {
-		buildJSONErrorResponse(w, err.Error(), http.StatusInternalServerError)
+	apps := make([]*objects.Application, 0)
+	for _, app := range queue.GetCopyOfApps() {
+		apps = append(apps, app)
 	}
+	writeList(w, opts, getApplicationsDAOList(opts.filterApplications(apps)))
 }
 
 func getPartitionApplicationsByState(w http.ResponseWriter, r *http.Request) {
@@ -848,6 +892,11 @@ func getPartitionApplicationsByState(w http.ResponseWriter, r *http.Request) {
 		buildJSONErrorResponse(w, PartitionDoesNotExists, http.StatusNotFound)
 		return
 	}
+	opts, err := parseListOptions(r)
+	if err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	var appList []*objects.Application
 	switch appState {
 	case AppStateActive:
@@ -872,13 +921,7 @@ func getPartitionApplicationsByState(w http.ResponseWriter, r *http.Request) {
 		buildJSONErrorResponse(w, fmt.Sprintf("Only following application states are allowed: %s, %s, %s", AppStateActive, AppStateRejected, AppStateCompleted), http.StatusBadRequest)
 		return
 	}
-	appsDao := make([]*dao.ApplicationDAOInfo, 0, len(appList))
-	for _, app := range appList {
-		appsDao = append(appsDao, getApplicationDAO(app))
-	}
-	if err := json.NewEncoder(w).Encode(appsDao); err != nil {
-		buildJSONErrorResponse(w, err.Error(), http.StatusInternalServerError)
-	}
+	writeList(w, opts, getApplicationsDAOList(opts.filterApplications(appList)))
 }
 
 func getApplication(w http.ResponseWriter, r *http.Request) {
@@ -988,17 +1031,19 @@ func getQueueApplicationsByState(w http.ResponseWriter, r *http.Request) {
 		buildJSONErrorResponse(w, allowedActiveStatusMsg, http.StatusBadRequest)
 		return
 	}
+	opts, err := parseListOptions(r)
+	if err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	appsDao := make([]*dao.ApplicationDAOInfo, 0)
+	apps := make([]*objects.Application, 0)
 	for _, app := range queue.GetCopyOfApps() {
 		if status == "" || strings.ToLower(app.CurrentState()) == status {
-			appsDao = append(appsDao, getApplicationDAO(app))
+			apps = append(apps, app)
 		}
 	}
-
-	if err := json.NewEncoder(w).Encode(appsDao); err != nil {
-		buildJSONErrorResponse(w, err.Error(), http.StatusInternalServerError)
-	}
+	writeList(w, opts, getApplicationsDAOList(opts.filterApplications(apps)))
 }
 
 func getPartitionInfoDAO(lists map[string]*scheduler.PartitionContext) []*dao.PartitionInfo {
@@ -1022,6 +1067,7 @@ func getPartitionInfoDAO(lists map[string]*scheduler.PartitionContext) []*dao.Pa
 		partitionInfo.NodeSortingPolicy = dao.NodeSortingPolicy{
 			Type:            partitionContext.GetNodeSortingPolicyType().String(),
 			ResourceWeights: partitionContext.GetNodeSortingResourceWeights(),
+			Scorers:         partitionContext.GetNodeSortingScorers(),
 		}
 
 		partitionInfo.TotalNodes = partitionContext.GetTotalNodeCount()
@@ -1197,6 +1243,80 @@ func getUsersResourceUsage(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// getChargebackReport returns the usage and cost of the partition in a billing period as JSON or CSV.
+// The query parameters select the grouping (groupBy: user, group, queue or instanceType), a time in the
+// billing period (period: RFC3339, defaults to the current period) and the output (format: json or csv).
+func getChargebackReport(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w, r.Method)
+	vars := httprouter.ParamsFromContext(r.Context())
+	if vars == nil {
+		buildJSONErrorResponse(w, MissingParamsName, http.StatusBadRequest)
+		return
+	}
+	partitionContext := schedulerContext.Load().GetPartitionWithoutClusterID(vars.ByName("partition"))
+	if partitionContext == nil {
+		buildJSONErrorResponse(w, PartitionDoesNotExists, http.StatusNotFound)
+		return
+	}
+	query := r.URL.Query()
+	groupBy := accounting.GroupByUser
+	if value := query.Get("groupBy"); value != "" {
+		groupBy = value
+	}
+	var period time.Time
+	if value := query.Get("period"); value != "" {
+		var err error
+		if period, err = time.Parse(time.RFC3339, value); err != nil {
+			buildJSONErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	format := strings.ToLower(query.Get("format"))
+	if format != "" && format != "json" && format != "csv" {
+		buildJSONErrorResponse(w, "unknown format: "+format, http.StatusBadRequest)
+		return
+	}
+	report, err := accounting.GetLedger().GetReport(partitionContext.Name, period, groupBy)
+	switch {
+	case errors.Is(err, accounting.ErrNoPeriod):
+		buildJSONErrorResponse(w, err.Error(), http.StatusNotFound)
+		return
+	case err != nil:
+		buildJSONErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=UTF-8")
+		w.Header().Set("Content-Disposition", "attachment; filename=\"chargeback.csv\"")
+		if err = report.WriteCSV(w); err != nil {
+			buildJSONErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	if err = json.NewEncoder(w).Encode(getChargebackReportDAO(report)); err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func getChargebackReportDAO(report *accounting.Report) *dao.ChargebackReportDAOInfo {
+	result := &dao.ChargebackReportDAOInfo{
+		Partition:   common.GetPartitionNameWithoutClusterID(report.Partition),
+		PeriodStart: report.Start.UnixMilli(),
+		PeriodEnd:   report.End.UnixMilli(),
+		GroupBy:     report.GroupBy,
+	}
+	for _, entry := range report.Entries {
+		result.TotalCost += entry.TotalCost
+		result.Entries = append(result.Entries, &dao.ChargebackEntryDAOInfo{
+			Name:      entry.Name,
+			Usage:     entry.Usage,
+			Cost:      entry.Cost,
+			TotalCost: entry.TotalCost,
+		})
+	}
+	return result
+}
+
 func getUserResourceUsage(w http.ResponseWriter, r *http.Request) {
 	writeHeaders(w, r.Method)
 	vars := httprouter.ParamsFromContext(r.Context())
@@ -1404,3 +1524,316 @@ func getStream(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 }
+
+func getAdvanceReservations(w http.ResponseWriter, r *http.Request) {
+	writeHeaders(w, r.Method)
+	vars := httprouter.ParamsFromContext(r.Context())
+	if vars == nil {
+		buildJSONErrorResponse(w, MissingParamsName, http.StatusBadRequest)
+		return
+	}
+	partitionContext := schedulerContext.Load().GetPartitionWithoutClusterID(vars.ByName("partition"))
+	if partitionContext == nil {
+		buildJSONErrorResponse(w, PartitionDoesNotExists, http.StatusNotFound)
+		return
+	}
+	list, states := partitionContext.GetAdvanceReservations()
+	result := make([]*dao.AdvanceReservationDAOInfo, len(list))
+	for i, ar := range list {
+		result[i] = &dao.AdvanceReservationDAOInfo{
+			ID:        ar.ID,
+			QueuePath: ar.QueuePath,
+			Nodes:     ar.Nodes,
+			Resource:  ar.Resource.DAOMap(),
+			Priority:  ar.Priority,
+			Start:     ar.Start,
+			End:       ar.End,
+			LeadTime:  ar.LeadTime.String(),
+			State:     states[i],
+		}
+	}
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func addAdvanceReservation(w http.ResponseWriter, r *http.Request) {
+	writeAdminHeaders(w, r.Method)
+	_, partitionContext, user, ok := getAdminRequest(w, r)
+	if !ok {
+		return
+	}
+	var info dao.AdvanceReservationDAOInfo
+	if err := json.NewDecoder(r.Body).Decode(&info); err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var leadTime time.Duration
+	if info.LeadTime != "" {
+		var err error
+		if leadTime, err = time.ParseDuration(info.LeadTime); err != nil {
+			buildJSONErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	res := resources.NewResource()
+	for name, value := range info.Resource {
+		res.Resources[name] = resources.Quantity(value)
+	}
+	ar := &scheduler.AdvanceReservation{
+		ID:        info.ID,
+		QueuePath: info.QueuePath,
+		Nodes:     info.Nodes,
+		Resource:  res,
+		Priority:  info.Priority,
+		Start:     info.Start,
+		End:       info.End,
+		LeadTime:  leadTime,
+	}
+	if err := partitionContext.BookAdvanceReservation(ar, time.Now(), user); err != nil {
+		buildAdminErrorResponse(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+func removeAdvanceReservation(w http.ResponseWriter, r *http.Request) {
+	writeAdminHeaders(w, r.Method)
+	vars, partitionContext, user, ok := getAdminRequest(w, r)
+	if !ok {
+		return
+	}
+	if err := partitionContext.CancelAdvanceReservation(vars.ByName("reservation"), user); err != nil {
+		if errors.Is(err, scheduler.ErrAdminAccessDenied) {
+			buildAdminErrorResponse(w, err)
+			return
+		}
+		buildJSONErrorResponse(w, ReservationDoesNotExists, http.StatusNotFound)
+	}
+}
+
+func updateNodeState(w http.ResponseWriter, r *http.Request) {
+	writeAdminHeaders(w, r.Method)
+	vars, partitionContext, user, ok := getAdminRequest(w, r)
+	if !ok {
+		return
+	}
+	nodeID := vars.ByName("node")
+	if partitionContext.GetNode(nodeID) == nil {
+		buildJSONErrorResponse(w, NodeDoesNotExists, http.StatusNotFound)
+		return
+	}
+	var update dao.NodeStateUpdateDAOInfo
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	event, err := objects.NodeEventFromString(update.Event)
+	if err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var gracePeriod time.Duration
+	if update.GracePeriod != "" {
+		if gracePeriod, err = time.ParseDuration(update.GracePeriod); err != nil {
+			buildJSONErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if err = schedulerContext.Load().ChangeNodeState(partitionContext, nodeID, event, update.Reason, gracePeriod, update.ReleaseAllocations, user); err != nil {
+		buildAdminErrorResponse(w, err)
+		return
+	}
+	// a decommissioned node is removed from the partition
+	if node := partitionContext.GetNode(nodeID); node != nil {
+		if err = json.NewEncoder(w).Encode(getNodeDAO(node)); err != nil {
+			buildJSONErrorResponse(w, err.Error(), http.StatusInternalServerError)
+		}
+	}
+}
+
+// getRequestUser returns the identity of the caller as set by the authenticating proxy. Groups can be passed as
+// multiple headers, each header can contain a comma separated list. The headers are ignored unless the proxy is trusted.
+func getRequestUser(r *http.Request) (security.UserGroup, error) {
+	if !common.GetConfigurationBool(configs.GetConfigMap(), configs.WebserviceTrustIdentityHeaders, configs.DefaultTrustIdentityHeaders) {
+		return security.UserGroup{}, errors.New(UntrustedUserIdentity)
+	}
+	user := strings.TrimSpace(r.Header.Get(UserHeader))
+	if user == "" {
+		return security.UserGroup{}, errors.New(MissingUserIdentity)
+	}
+	if !configs.UserRegExp.MatchString(user) {
+		return security.UserGroup{}, errors.New(InvalidUserName)
+	}
+	ug := security.UserGroup{User: user}
+	for _, value := range r.Header.Values(GroupHeader) {
+		for _, group := range strings.Split(value, ",") {
+			group = strings.TrimSpace(group)
+			if group == "" {
+				continue
+			}
+			if !configs.GroupRegExp.MatchString(group) {
+				return security.UserGroup{}, errors.New(InvalidGroupName)
+			}
+			ug.Groups = append(ug.Groups, group)
+		}
+	}
+	return ug, nil
+}
+
+// buildAdminErrorResponse maps the error of an admin action to the response code.
+func buildAdminErrorResponse(w http.ResponseWriter, err error) {
+	if errors.Is(err, scheduler.ErrAdminAccessDenied) {
+		buildJSONErrorResponse(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	buildJSONErrorResponse(w, err.Error(), http.StatusBadRequest)
+}
+
+// getAdminRequest validates the common parts of an admin request: the partition and the identity of the caller.
+// If the validation fails the error response is written and false is returned.
+func getAdminRequest(w http.ResponseWriter, r *http.Request) (httprouter.Params, *scheduler.PartitionContext, security.UserGroup, bool) {
+	vars := httprouter.ParamsFromContext(r.Context())
+	if vars == nil {
+		buildJSONErrorResponse(w, MissingParamsName, http.StatusBadRequest)
+		return nil, nil, security.UserGroup{}, false
+	}
+	partitionContext := schedulerContext.Load().GetPartitionWithoutClusterID(vars.ByName("partition"))
+	if partitionContext == nil {
+		buildJSONErrorResponse(w, PartitionDoesNotExists, http.StatusNotFound)
+		return nil, nil, security.UserGroup{}, false
+	}
+	user, err := getRequestUser(r)
+	if err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusUnauthorized)
+		return nil, nil, security.UserGroup{}, false
+	}
+	return vars, partitionContext, user, true
+}
+
+func updateQueueState(w http.ResponseWriter, r *http.Request) {
+	writeAdminHeaders(w, r.Method)
+	vars, partitionContext, user, ok := getAdminRequest(w, r)
+	if !ok {
+		return
+	}
+	queueName, err := url.QueryUnescape(vars.ByName("queue"))
+	if err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err = validateQueue(queueName); err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	queue := partitionContext.GetQueue(queueName)
+	if queue == nil {
+		buildJSONErrorResponse(w, QueueDoesNotExists, http.StatusNotFound)
+		return
+	}
+	var update dao.QueueStateUpdateDAOInfo
+	if err = json.NewDecoder(r.Body).Decode(&update); err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var event objects.ObjectEvent
+	switch {
+	case strings.EqualFold(update.Event, objects.Stop.String()):
+		event = objects.Stop
+	case strings.EqualFold(update.Event, objects.Start.String()):
+		event = objects.Start
+	default:
+		buildJSONErrorResponse(w, fmt.Sprintf("unknown queue event: %s", update.Event), http.StatusBadRequest)
+		return
+	}
+	if err = partitionContext.ChangeQueueState(queueName, event, user); err != nil {
+		buildAdminErrorResponse(w, err)
+		return
+	}
+	if err = json.NewEncoder(w).Encode(queue.GetPartitionQueueDAOInfo(false)); err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func moveApplication(w http.ResponseWriter, r *http.Request) {
+	writeAdminHeaders(w, r.Method)
+	vars, partitionContext, user, ok := getAdminRequest(w, r)
+	if !ok {
+		return
+	}
+	app := partitionContext.GetApplication(vars.ByName("application"))
+	if app == nil {
+		buildJSONErrorResponse(w, ApplicationDoesNotExists, http.StatusNotFound)
+		return
+	}
+	var move dao.ApplicationMoveDAOInfo
+	if err := json.NewDecoder(r.Body).Decode(&move); err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validateQueue(move.Queue); err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if partitionContext.GetQueue(move.Queue) == nil {
+		buildJSONErrorResponse(w, QueueDoesNotExists, http.StatusNotFound)
+		return
+	}
+	if err := partitionContext.MoveApplication(app.ApplicationID, move.Queue, user); err != nil {
+		buildAdminErrorResponse(w, err)
+		return
+	}
+	if err := json.NewEncoder(w).Encode(getApplicationDAO(app)); err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func setApplicationPriority(w http.ResponseWriter, r *http.Request) {
+	writeAdminHeaders(w, r.Method)
+	vars, partitionContext, user, ok := getAdminRequest(w, r)
+	if !ok {
+		return
+	}
+	app := partitionContext.GetApplication(vars.ByName("application"))
+	if app == nil {
+		buildJSONErrorResponse(w, ApplicationDoesNotExists, http.StatusNotFound)
+		return
+	}
+	var update dao.ApplicationPriorityDAOInfo
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := partitionContext.SetApplicationPriority(app.ApplicationID, update.Priority, user); err != nil {
+		buildAdminErrorResponse(w, err)
+		return
+	}
+	if err := json.NewEncoder(w).Encode(getApplicationDAO(app)); err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func releaseAllocation(w http.ResponseWriter, r *http.Request) {
+	writeAdminHeaders(w, r.Method)
+	vars, partitionContext, user, ok := getAdminRequest(w, r)
+	if !ok {
+		return
+	}
+	app := partitionContext.GetApplication(vars.ByName("application"))
+	if app == nil {
+		buildJSONErrorResponse(w, ApplicationDoesNotExists, http.StatusNotFound)
+		return
+	}
+	allocationKey := vars.ByName("allocation")
+	if app.GetAllocation(allocationKey) == nil {
+		buildJSONErrorResponse(w, AllocationDoesNotExists, http.StatusNotFound)
+		return
+	}
+	if err := schedulerContext.Load().ForceReleaseAllocation(partitionContext, app.ApplicationID, allocationKey, user); err != nil {
+		buildAdminErrorResponse(w, err)
+		return
+	}
+	if err := json.NewEncoder(w).Encode(getApplicationDAO(app)); err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusInternalServerError)
+	}
+}
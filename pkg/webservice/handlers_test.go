@@ -43,6 +43,7 @@ import (
 	"github.com/apache/yunikorn-core/pkg/events"
 	"github.com/apache/yunikorn-core/pkg/metrics/history"
 	"github.com/apache/yunikorn-core/pkg/scheduler"
+	"github.com/apache/yunikorn-core/pkg/scheduler/accounting"
 	"github.com/apache/yunikorn-core/pkg/scheduler/objects"
 	"github.com/apache/yunikorn-core/pkg/scheduler/placement/types"
 	"github.com/apache/yunikorn-core/pkg/scheduler/policies"
@@ -114,6 +115,19 @@ partitions:
           - name: noapps
 `
 
+const configAdmin = `
+partitions:
+  - name: default
+    queues:
+      - name: root
+        submitacl: "*"
+        adminacl: "admin"
+        queues:
+          - name: default
+            adminacl: "queue-admin"
+          - name: noapps
+`
+
 const configMultiPartitions = `
 partitions: 
   - name: gpu
@@ -2144,11 +2158,15 @@ func TestSpecificUserResourceUsage(t *testing.T) {
 				QueuePath:           "root",
 				ResourceUsage:       map[string]int64{"vcore": 1},
 				RunningApplications: []string{"app-1"},
+				PendingAsks:         1,
+				RunningAllocations:  1,
 				Children: []*dao.ResourceUsageDAOInfo{
 					{
 						QueuePath:           "root.default",
 						ResourceUsage:       map[string]int64{"vcore": 1},
 						RunningApplications: []string{"app-1"},
+						PendingAsks:         1,
+						RunningAllocations:  1,
 					},
 				},
 			},
@@ -2215,12 +2233,16 @@ func TestSpecificGroupResourceUsage(t *testing.T) {
 				QueuePath:           "root",
 				ResourceUsage:       map[string]int64{"vcore": 1},
 				RunningApplications: []string{"app-1"},
+				PendingAsks:         1,
+				RunningAllocations:  1,
 				Children: []*dao.ResourceUsageDAOInfo{
 					{
 						QueuePath:           "root.default",
 						ResourceUsage:       map[string]int64{"vcore": 1},
 						MaxResources:        map[string]int64{"cpu": 200},
 						RunningApplications: []string{"app-1"},
+						PendingAsks:         1,
+						RunningAllocations:  1,
 					},
 				},
 			},
@@ -2824,6 +2846,7 @@ func clearUserManager() {
 	userManager := ugm.GetUserManager()
 	userManager.ClearUserTrackers()
 	userManager.ClearGroupTrackers()
+	userManager.ClearApplicationCounts()
 }
 
 func verifyStateDumpJSON(t *testing.T, aggregated *AggregatedStateInfo, partitionCount int) {
@@ -3057,3 +3080,303 @@ func newForeignAlloc(allocationKey string, nodeID string, resAlloc *resources.Re
 		Priority: priority,
 	})
 }
+
+// trustIdentityHeaders enables the identity headers for the admin endpoints for the duration of the test
+func trustIdentityHeaders(t *testing.T) {
+	current := configs.GetConfigMap()
+	configs.SetConfigMap(map[string]string{configs.WebserviceTrustIdentityHeaders: "true"})
+	t.Cleanup(func() {
+		configs.SetConfigMap(current)
+	})
+}
+
+func TestAdvanceReservations(t *testing.T) {
+	partition := setup(t, configAdmin, 1)
+	trustIdentityHeaders(t)
+	nodeRes := resources.NewResourceFromMap(map[string]resources.Quantity{siCommon.Memory: 1000}).ToProto()
+	assert.NilError(t, partition.AddNode(objects.NewNode(&si.NodeInfo{NodeID: nodeID, SchedulableResource: nodeRes})), "node add failed")
+	start := time.Now().Add(2 * time.Hour).Truncate(time.Second)
+	params := httprouter.Params{httprouter.Param{Key: "partition", Value: partitionNameWithoutClusterID}}
+	add := func(user, id, nodes string) *MockResponseWriter {
+		body := fmt.Sprintf(`{"id":"%s","queuePath":"%s","nodes":[%s],"resource":{"memory":100},"start":"%s","end":"%s","leadTime":"30m"}`,
+			id, queueName, nodes, start.Format(time.RFC3339), start.Add(time.Hour).Format(time.RFC3339))
+		req, err := http.NewRequest("POST", "/ws/v1/partition/default/reservations", strings.NewReader(body))
+		assert.NilError(t, err, "HTTP request create failed")
+		if user != "" {
+			req.Header.Set(UserHeader, user)
+		}
+		req = req.WithContext(context.WithValue(req.Context(), httprouter.ParamsKey, params))
+		resp := &MockResponseWriter{}
+		addAdvanceReservation(resp, req)
+		return resp
+	}
+	remove := func(user, id string) *MockResponseWriter {
+		req, err := createRequest(t, "/ws/v1/partition/default/reservation/"+id, map[string]string{"partition": partitionNameWithoutClusterID, "reservation": id})
+		assert.NilError(t, err, "HTTP request create failed")
+		if user != "" {
+			req.Header.Set(UserHeader, user)
+		}
+		resp := &MockResponseWriter{}
+		removeAdvanceReservation(resp, req)
+		return resp
+	}
+	onNode := `"` + nodeID + `"`
+
+	// identity and access checks: node wide bookings need a root admin
+	assert.Equal(t, add("", "ar-1", onNode).statusCode, http.StatusUnauthorized, statusCodeError)
+	assert.Equal(t, add("other", "ar-1", onNode).statusCode, http.StatusForbidden, statusCodeError)
+	assert.Equal(t, add("queue-admin", "ar-1", "").statusCode, http.StatusForbidden, statusCodeError)
+	assert.Equal(t, add("admin", "ar-2", "").statusCode, http.StatusCreated, statusCodeError)
+
+	// add a reservation, same ID again is rejected
+	assert.Equal(t, add("queue-admin", "ar-1", onNode).statusCode, http.StatusCreated, statusCodeError)
+	assert.Equal(t, add("queue-admin", "ar-1", onNode).statusCode, http.StatusBadRequest, statusCodeError)
+
+	// list the reservations
+	req, err := createRequest(t, "/ws/v1/partition/default/reservations", map[string]string{"partition": partitionNameWithoutClusterID})
+	assert.NilError(t, err, "HTTP request create failed")
+	resp := &MockResponseWriter{}
+	getAdvanceReservations(resp, req)
+	var list []*dao.AdvanceReservationDAOInfo
+	err = json.Unmarshal(resp.outputBytes, &list)
+	assert.NilError(t, err, unmarshalError)
+	assert.Equal(t, len(list), 2)
+	assert.Equal(t, list[0].ID, "ar-1")
+	assert.Equal(t, list[0].QueuePath, queueName)
+	assert.DeepEqual(t, list[0].Nodes, []string{nodeID})
+	assert.Equal(t, list[0].Resource["memory"], int64(100))
+	assert.Equal(t, list[0].LeadTime, "30m0s")
+	assert.Equal(t, list[0].State, scheduler.AdvanceReservationPending)
+	assert.Assert(t, list[0].Start.Equal(start))
+
+	// remove the reservations, second time fails
+	assert.Equal(t, remove("queue-admin", "ar-2").statusCode, http.StatusForbidden, statusCodeError)
+	assert.Equal(t, remove("admin", "ar-2").statusCode, 0, statusCodeError)
+	assert.Equal(t, remove("", "ar-1").statusCode, http.StatusUnauthorized, statusCodeError)
+	assert.Equal(t, remove("queue-admin", "ar-1").statusCode, 0, statusCodeError)
+	reservations, _ := partition.GetAdvanceReservations()
+	assert.Equal(t, len(reservations), 0)
+	assert.Equal(t, remove("queue-admin", "ar-1").statusCode, http.StatusNotFound, statusCodeError)
+
+	// unknown partition
+	req, err = createRequest(t, "/ws/v1/partition/unknown/reservations", map[string]string{"partition": "unknown"})
+	assert.NilError(t, err, "HTTP request create failed")
+	resp = &MockResponseWriter{}
+	getAdvanceReservations(resp, req)
+	assertPartitionNotExists(t, resp)
+}
+
+func TestUpdateNodeState(t *testing.T) {
+	partition := setup(t, configAdmin, 1)
+	trustIdentityHeaders(t)
+	nodeRes := resources.NewResourceFromMap(map[string]resources.Quantity{siCommon.Memory: 1000}).ToProto()
+	err := partition.AddNode(objects.NewNode(&si.NodeInfo{NodeID: nodeID, SchedulableResource: nodeRes}))
+	assert.NilError(t, err, "add node to partition should not have failed")
+	params := httprouter.Params{
+		httprouter.Param{Key: "partition", Value: partitionNameWithoutClusterID},
+		httprouter.Param{Key: "node", Value: nodeID},
+	}
+	user := "admin"
+	update := func(body string) *MockResponseWriter {
+		req, err := http.NewRequest("POST", "/ws/v1/partition/default/node/"+nodeID+"/state", strings.NewReader(body))
+		assert.NilError(t, err, "HTTP request create failed")
+		if user != "" {
+			req.Header.Set(UserHeader, user)
+		}
+		req = req.WithContext(context.WithValue(req.Context(), httprouter.ParamsKey, params))
+		resp := &MockResponseWriter{}
+		updateNodeState(resp, req)
+		return resp
+	}
+
+	// identity and access checks: only root admins can change the node state
+	user = ""
+	resp := update(`{"event":"cordonNode"}`)
+	assert.Equal(t, resp.statusCode, http.StatusUnauthorized, statusCodeError)
+	user = "queue-admin"
+	resp = update(`{"event":"cordonNode"}`)
+	assert.Equal(t, resp.statusCode, http.StatusForbidden, statusCodeError)
+	assert.Assert(t, partition.GetNode(nodeID).IsSchedulable(), "node should not be cordoned")
+	user = "admin"
+
+	resp = update(`{"event":"unknown"}`)
+	assert.Equal(t, resp.statusCode, http.StatusBadRequest, statusCodeError)
+	resp = update(`{"event":"drainNode","gracePeriod":"soon"}`)
+	assert.Equal(t, resp.statusCode, http.StatusBadRequest, statusCodeError)
+
+	resp = update(`{"event":"cordonNode","reason":"maintenance"}`)
+	assert.Equal(t, resp.statusCode, 0, statusCodeError)
+	var nodeInfo dao.NodeDAOInfo
+	err = json.Unmarshal(resp.outputBytes, &nodeInfo)
+	assert.NilError(t, err, unmarshalError)
+	assert.Equal(t, nodeInfo.State, objects.NodeCordoned.String())
+	assert.Equal(t, nodeInfo.Schedulable, false)
+
+	// invalid transition
+	resp = update(`{"event":"completeDrain"}`)
+	assert.Equal(t, resp.statusCode, http.StatusBadRequest, statusCodeError)
+
+	resp = update(`{"event":"drainNode","gracePeriod":"10m"}`)
+	assert.Equal(t, resp.statusCode, 0, statusCodeError)
+	assert.Equal(t, partition.GetNode(nodeID).GetNodeState(), objects.NodeDraining.String())
+	assert.Assert(t, !partition.GetNode(nodeID).GetDrainDeadline().IsZero(), "drain deadline not set")
+
+	resp = update(`{"event":"uncordonNode"}`)
+	assert.Equal(t, resp.statusCode, 0, statusCodeError)
+	assert.Assert(t, partition.GetNode(nodeID).IsSchedulable(), "node should be schedulable")
+
+	// unknown node
+	params[1].Value = "unknown"
+	resp = update(`{"event":"cordonNode"}`)
+	assert.Equal(t, resp.statusCode, http.StatusNotFound, statusCodeError)
+}
+
+func TestAdminEndpoints(t *testing.T) {
+	partition := setup(t, configAdmin, 1)
+	trustIdentityHeaders(t)
+	app := newApplication("app-1", partition.Name, queueName, rmID, security.UserGroup{})
+	assert.NilError(t, partition.AddApplication(app), "add application to partition should not have failed")
+	nodeRes := resources.NewResourceFromMap(map[string]resources.Quantity{siCommon.Memory: 1000}).ToProto()
+	assert.NilError(t, partition.AddNode(objects.NewNode(&si.NodeInfo{NodeID: nodeID, SchedulableResource: nodeRes})), "node add failed")
+	resAlloc := resources.NewResourceFromMap(map[string]resources.Quantity{siCommon.Memory: 100})
+	_, _, err := partition.UpdateAllocation(newAlloc("alloc-1", "app-1", nodeID, resAlloc))
+	assert.NilError(t, err, "allocation add failed")
+
+	call := func(handler http.HandlerFunc, user string, body string, params httprouter.Params) *MockResponseWriter {
+		req, err := http.NewRequest("POST", "/ws/v1/admin", strings.NewReader(body))
+		assert.NilError(t, err, "HTTP request create failed")
+		if user != "" {
+			req.Header.Set(UserHeader, user)
+			req.Header.Set(GroupHeader, "group-1, group-2")
+		}
+		req = req.WithContext(context.WithValue(req.Context(), httprouter.ParamsKey, params))
+		resp := &MockResponseWriter{}
+		handler(resp, req)
+		return resp
+	}
+	queueParams := httprouter.Params{
+		httprouter.Param{Key: "partition", Value: partitionNameWithoutClusterID},
+		httprouter.Param{Key: "queue", Value: queueName},
+	}
+	appParams := httprouter.Params{
+		httprouter.Param{Key: "partition", Value: partitionNameWithoutClusterID},
+		httprouter.Param{Key: "application", Value: "app-1"},
+	}
+
+	// identity and access checks
+	resp := call(updateQueueState, "", `{"event":"stop"}`, queueParams)
+	assert.Equal(t, resp.statusCode, http.StatusUnauthorized, statusCodeError)
+	assert.Equal(t, resp.header.Get("Access-Control-Allow-Origin"), "", "cross-origin requests should not be allowed")
+	assert.Equal(t, resp.header.Get("Access-Control-Allow-Methods"), "OPTIONS, POST")
+	resp = call(updateQueueState, "invalid user!", `{"event":"stop"}`, queueParams)
+	assert.Equal(t, resp.statusCode, http.StatusUnauthorized, statusCodeError)
+	resp = call(updateQueueState, "other", `{"event":"stop"}`, queueParams)
+	assert.Equal(t, resp.statusCode, http.StatusForbidden, statusCodeError)
+	// identity headers are ignored unless the proxy is trusted
+	configs.SetConfigMap(map[string]string{})
+	resp = call(updateQueueState, "admin", `{"event":"stop"}`, queueParams)
+	assert.Equal(t, resp.statusCode, http.StatusUnauthorized, statusCodeError)
+	assert.Assert(t, partition.GetQueue(queueName).IsRunning(), "queue should not be stopped")
+	trustIdentityHeaders(t)
+
+	// queue state
+	resp = call(updateQueueState, "admin", `{"event":"pause"}`, queueParams)
+	assert.Equal(t, resp.statusCode, http.StatusBadRequest, statusCodeError)
+	resp = call(updateQueueState, "admin", `{"event":"stop"}`, queueParams)
+	assert.Equal(t, resp.statusCode, 0, statusCodeError)
+	var queueInfo dao.PartitionQueueDAOInfo
+	assert.NilError(t, json.Unmarshal(resp.outputBytes, &queueInfo), unmarshalError)
+	assert.Equal(t, queueInfo.Status, objects.Stopped.String())
+	resp = call(updateQueueState, "admin", `{"event":"start"}`, queueParams)
+	assert.Equal(t, resp.statusCode, 0, statusCodeError)
+	assert.Assert(t, partition.GetQueue(queueName).IsRunning(), "queue not started")
+
+	// priority
+	resp = call(setApplicationPriority, "admin", `{"priority":5}`, appParams)
+	assert.Equal(t, resp.statusCode, 0, statusCodeError)
+	var appInfo dao.ApplicationDAOInfo
+	assert.NilError(t, json.Unmarshal(resp.outputBytes, &appInfo), unmarshalError)
+	assert.Equal(t, *appInfo.PriorityOverride, int32(5))
+
+	// move
+	resp = call(moveApplication, "admin", `{"queue":"root.unknown"}`, appParams)
+	assert.Equal(t, resp.statusCode, http.StatusNotFound, statusCodeError)
+	resp = call(moveApplication, "other", `{"queue":"root.noapps"}`, appParams)
+	assert.Equal(t, resp.statusCode, http.StatusForbidden, statusCodeError)
+	resp = call(moveApplication, "admin", `{"queue":"root.noapps"}`, appParams)
+	assert.Equal(t, resp.statusCode, 0, statusCodeError)
+	assert.NilError(t, json.Unmarshal(resp.outputBytes, &appInfo), unmarshalError)
+	assert.Equal(t, appInfo.QueueName, "root.noapps")
+	assert.Assert(t, resources.Equals(partition.GetQueue("root.noapps").GetAllocatedResource(), resAlloc), "usage not moved")
+
+	// force release
+	allocParams := append(appParams, httprouter.Param{Key: "allocation", Value: "unknown"})
+	resp = call(releaseAllocation, "admin", "", allocParams)
+	assert.Equal(t, resp.statusCode, http.StatusNotFound, statusCodeError)
+	allocParams[2].Value = "alloc-1"
+	resp = call(releaseAllocation, "other", "", allocParams)
+	assert.Equal(t, resp.statusCode, http.StatusForbidden, statusCodeError)
+	assert.Assert(t, app.GetAllocation("alloc-1") != nil, "allocation should not be released")
+}
+
+func TestGetChargebackReport(t *testing.T) {
+	partition := setup(t, configDefault, 1)
+	ledger := accounting.GetLedger()
+	ledger.Clear()
+	defer ledger.Clear()
+	configs.SetConfigMap(map[string]string{configs.AccountingPrices: `{"*": {"vcore": 3.6}}`})
+	defer configs.SetConfigMap(map[string]string{})
+	ledger.Record(partition.Name, &objects.ApplicationSummary{
+		ApplicationID: "app-1",
+		User:          "testuser",
+		Groups:        []string{"testgroup"},
+		Queue:         "root.default",
+		ResourceUsage: resources.NewTrackedResourceFromMap(map[string]map[string]resources.Quantity{
+			"m5.large": {"vcore": 1000},
+		}),
+	}, true)
+
+	get := func(url string, params map[string]string) *MockResponseWriter {
+		req, err := createRequest(t, url, params)
+		assert.NilError(t, err, "HTTP request create failed")
+		resp := &MockResponseWriter{}
+		getChargebackReport(resp, req)
+		return resp
+	}
+	params := map[string]string{"partition": partitionNameWithoutClusterID}
+
+	resp := get("/ws/v1/partition/default/chargeback", params)
+	var report dao.ChargebackReportDAOInfo
+	err := json.Unmarshal(resp.outputBytes, &report)
+	assert.NilError(t, err, unmarshalError)
+	assert.Equal(t, report.Partition, partitionNameWithoutClusterID)
+	assert.Equal(t, report.GroupBy, accounting.GroupByUser)
+	assert.Equal(t, report.TotalCost, 1.0)
+	assert.Equal(t, len(report.Entries), 1, "expected one user")
+	assert.Equal(t, report.Entries[0].Name, "testuser")
+	assert.DeepEqual(t, report.Entries[0].Usage, map[string]int64{"vcore": 1000})
+
+	resp = get("/ws/v1/partition/default/chargeback?groupBy=queue&format=csv", params)
+	assert.Equal(t, resp.header.Get("Content-Type"), "text/csv; charset=UTF-8")
+	lines := strings.Split(strings.TrimSpace(string(resp.outputBytes)), "\n")
+	assert.Equal(t, len(lines), 2, "expected header and one row")
+	assert.Equal(t, lines[0], "periodStart,periodEnd,queue,resource,usage,cost")
+	assert.Assert(t, strings.HasSuffix(lines[1], ",root.default,vcore,1000,1"), "unexpected row %s", lines[1])
+
+	// invalid requests
+	resp = get("/ws/v1/partition/default/chargeback?groupBy=node", params)
+	assert.Equal(t, resp.statusCode, http.StatusBadRequest, statusCodeError)
+	resp = get("/ws/v1/partition/default/chargeback?period=yesterday", params)
+	assert.Equal(t, resp.statusCode, http.StatusBadRequest, statusCodeError)
+	resp = get("/ws/v1/partition/default/chargeback?format=xml", params)
+	assert.Equal(t, resp.statusCode, http.StatusBadRequest, statusCodeError)
+	resp = get("/ws/v1/partition/default/chargeback?period=2000-01-01T00:00:00Z", params)
+	assert.Equal(t, resp.statusCode, http.StatusNotFound, statusCodeError)
+	resp = get("/ws/v1/partition/unknown/chargeback", map[string]string{"partition": "unknown"})
+	assertPartitionNotExists(t, resp)
+	req, err := http.NewRequest("GET", "/ws/v1/partition/default/chargeback", strings.NewReader(""))
+	assert.NilError(t, err, "HTTP request create failed")
+	resp = &MockResponseWriter{}
+	getChargebackReport(resp, req)
+	assertParamsMissing(t, resp)
+}
@@ -0,0 +1,253 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package webservice
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/apache/yunikorn-core/pkg/common/configs"
+	"github.com/apache/yunikorn-core/pkg/scheduler/objects"
+)
+
+const (
+	// query parameters supported by the listing endpoints
+	paramLimit     = "limit"
+	paramContinue  = "continue"
+	paramFields    = "fields"
+	paramUser      = "user"
+	paramQueue     = "queue"
+	paramAttribute = "attribute"
+	paramSince     = "since"
+	paramUntil     = "until"
+
+	// ContinueHeader is set on a listing response if more objects are available. The value must be passed in
+	// as the continue query parameter to retrieve the next page.
+	ContinueHeader = "X-Continue-Token"
+)
+
+// listOptions contains the paging, filter and projection options of a listing request.
+// All filters are optional, an empty value means no filtering.
+type listOptions struct {
+	limit      uint64
+	after      string
+	fields     []string
+	user       string
+	queue      string
+	attributes map[string]string
+	since      time.Time
+	until      time.Time
+}
+
+// parseListOptions reads the listing options from the query parameters of the request.
+// Returns an error if a parameter cannot be parsed, the caller should return a bad request.
+func parseListOptions(r *http.Request) (*listOptions, error) {
+	query := r.URL.Query()
+	opts := &listOptions{
+		user:  query.Get(paramUser),
+		queue: strings.ToLower(query.Get(paramQueue)),
+	}
+	if limitStr := query.Get(paramLimit); limitStr != "" {
+		limit, err := strconv.ParseUint(limitStr, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		if limit == 0 {
+			return nil, fmt.Errorf("0 is not a valid value for %q", paramLimit)
+		}
+		opts.limit = limit
+	}
+	if token := query.Get(paramContinue); token != "" {
+		after, err := base64.RawURLEncoding.DecodeString(token)
+		if err != nil || len(after) == 0 {
+			return nil, fmt.Errorf("invalid %q token: %s", paramContinue, token)
+		}
+		opts.after = string(after)
+	}
+	if fieldStr := query.Get(paramFields); fieldStr != "" {
+		for _, field := range strings.Split(fieldStr, ",") {
+			if field = strings.TrimSpace(field); field != "" {
+				opts.fields = append(opts.fields, field)
+			}
+		}
+	}
+	for _, attr := range query[paramAttribute] {
+		key, value, found := strings.Cut(attr, "=")
+		if !found || key == "" {
+			return nil, fmt.Errorf("invalid %q filter, expected key=value: %s", paramAttribute, attr)
+		}
+		if opts.attributes == nil {
+			opts.attributes = make(map[string]string)
+		}
+		opts.attributes[key] = value
+	}
+	var err error
+	if opts.since, err = parseTimeParam(query.Get(paramSince)); err != nil {
+		return nil, err
+	}
+	if opts.until, err = parseTimeParam(query.Get(paramUntil)); err != nil {
+		return nil, err
+	}
+	return opts, nil
+}
+
+// parseTimeParam parses a time filter in RFC3339 format. An empty value returns the zero time.
+func parseTimeParam(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// matchApplication returns true if the application passes all application filters set.
+func (lo *listOptions) matchApplication(app *objects.Application) bool {
+	if lo.user != "" && app.GetUser().User != lo.user {
+		return false
+	}
+	if lo.queue != "" {
+		// the queue itself or any of its children, a sibling that starts with the same name does not match
+		path := app.GetQueuePath()
+		if path != lo.queue && !strings.HasPrefix(path, lo.queue+configs.DOT) {
+			return false
+		}
+	}
+	if !lo.since.IsZero() && app.SubmissionTime.Before(lo.since) {
+		return false
+	}
+	if !lo.until.IsZero() && app.SubmissionTime.After(lo.until) {
+		return false
+	}
+	return true
+}
+
+// matchNode returns true if the node has all attributes with the requested values.
+func (lo *listOptions) matchNode(node *objects.Node) bool {
+	for key, value := range lo.attributes {
+		if node.GetAttribute(key) != value {
+			return false
+		}
+	}
+	return true
+}
+
+// filterApplications returns the applications that pass the filters, sorted on the application ID.
+func (lo *listOptions) filterApplications(apps []*objects.Application) []*objects.Application {
+	result := make([]*objects.Application, 0, len(apps))
+	for _, app := range apps {
+		if lo.matchApplication(app) {
+			result = append(result, app)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ApplicationID < result[j].ApplicationID
+	})
+	return paginate(lo, result, func(app *objects.Application) string { return app.ApplicationID })
+}
+
+// filterNodes returns the nodes that pass the filters, sorted on the node ID.
+func (lo *listOptions) filterNodes(nodes []*objects.Node) []*objects.Node {
+	result := make([]*objects.Node, 0, len(nodes))
+	for _, node := range nodes {
+		if lo.matchNode(node) {
+			result = append(result, node)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].NodeID < result[j].NodeID
+	})
+	return paginate(lo, result, func(node *objects.Node) string { return node.NodeID })
+}
+
+// paginate returns the page of sorted objects that follows the continue token of the request.
+// If more objects are available after the page the continue token for the next page is stored in the options.
+// The token is based on the ID of the last object returned: objects added or removed between calls do not
+// cause objects to be skipped or returned twice.
+func paginate[T any](lo *listOptions, sorted []T, getID func(T) string) []T {
+	start := 0
+	if lo.after != "" {
+		start = sort.Search(len(sorted), func(i int) bool {
+			return getID(sorted[i]) > lo.after
+		})
+	}
+	sorted = sorted[start:]
+	lo.after = ""
+	if lo.limit != 0 && uint64(len(sorted)) > lo.limit {
+		sorted = sorted[:lo.limit]
+		lo.after = getID(sorted[len(sorted)-1])
+	}
+	return sorted
+}
+
+// continueToken returns the token for the next page, empty if there are no more objects.
+func (lo *listOptions) continueToken() string {
+	if lo.after == "" {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(lo.after))
+}
+
+// writeList writes the listing response. It sets the continue header if more objects are available and
+// projects each object onto the requested fields. The fields are matched against the JSON names of the
+// object, unknown fields are ignored.
+func writeList[T any](w http.ResponseWriter, lo *listOptions, list []T) {
+	if token := lo.continueToken(); token != "" {
+		w.Header().Set(ContinueHeader, token)
+	}
+	var result interface{} = list
+	if len(lo.fields) != 0 {
+		projected := make([]map[string]json.RawMessage, 0, len(list))
+		for _, entry := range list {
+			fields, err := projectFields(entry, lo.fields)
+			if err != nil {
+				buildJSONErrorResponse(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			projected = append(projected, fields)
+		}
+		result = projected
+	}
+	if err := json.NewEncoder(w).Encode(result); err != nil {
+		buildJSONErrorResponse(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// projectFields converts the object into a map with only the requested top level JSON fields.
+func projectFields(entry interface{}, fields []string) (map[string]json.RawMessage, error) {
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	all := make(map[string]json.RawMessage)
+	if err = json.Unmarshal(raw, &all); err != nil {
+		return nil, err
+	}
+	projected := make(map[string]json.RawMessage, len(fields))
+	for _, field := range fields {
+		if value, ok := all[field]; ok {
+			projected[field] = value
+		}
+	}
+	return projected, nil
+}
@@ -0,0 +1,222 @@
+/*
+ Licensed to the Apache Software Foundation (ASF) under one
+ or more contributor license agreements.  See the NOTICE file
+ distributed with this work for additional information
+ regarding copyright ownership.  The ASF licenses this file
+ to you under the Apache License, Version 2.0 (the
+ "License"); you may not use this file except in compliance
+ with the License.  You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package webservice
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/apache/yunikorn-core/pkg/common/security"
+	"github.com/apache/yunikorn-core/pkg/scheduler/objects"
+	"github.com/apache/yunikorn-core/pkg/webservice/dao"
+	"github.com/apache/yunikorn-scheduler-interface/lib/go/si"
+)
+
+func TestParseListOptions(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		err   string
+	}{
+		{"empty", "", ""},
+		{"all options", "limit=10&continue=YXBwLTE&fields=applicationID,user&user=test&queue=root.a&attribute=zone=a&since=2025-01-01T00:00:00Z&until=2025-01-02T00:00:00Z", ""},
+		{"limit not a number", "limit=xyz", `strconv.ParseUint: parsing "xyz": invalid syntax`},
+		{"limit zero", "limit=0", `0 is not a valid value for "limit"`},
+		{"continue invalid", "continue=***", `invalid "continue" token`},
+		{"attribute invalid", "attribute=zone", `invalid "attribute" filter`},
+		{"since invalid", "since=yesterday", `cannot parse "yesterday"`},
+		{"until invalid", "until=1234", `parsing time "1234"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/ws/v1/partition/default/nodes?"+tt.query, strings.NewReader(""))
+			assert.NilError(t, err, httpRequestError)
+			var opts *listOptions
+			opts, err = parseListOptions(req)
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			assert.NilError(t, err, "unexpected error parsing options")
+			assert.Assert(t, opts != nil, "options should have been returned")
+		})
+	}
+
+	req, err := http.NewRequest("GET", "/ws/v1/partition/default/nodes?limit=10&continue=YXBwLTE&fields=applicationID,,%20user&attribute=zone=a&attribute=rack=1", strings.NewReader(""))
+	assert.NilError(t, err, httpRequestError)
+	var opts *listOptions
+	opts, err = parseListOptions(req)
+	assert.NilError(t, err, "unexpected error parsing options")
+	assert.Equal(t, opts.limit, uint64(10), "unexpected limit")
+	assert.Equal(t, opts.after, "app-1", "unexpected continue value")
+	assert.DeepEqual(t, opts.fields, []string{"applicationID", "user"})
+	assert.DeepEqual(t, opts.attributes, map[string]string{"zone": "a", "rack": "1"})
+}
+
+func TestPaginate(t *testing.T) {
+	ids := []string{"a", "b", "c", "d", "e"}
+	getID := func(id string) string { return id }
+
+	// no limit returns all
+	opts := &listOptions{}
+	assert.DeepEqual(t, paginate(opts, ids, getID), ids)
+	assert.Equal(t, opts.continueToken(), "", "no token expected")
+
+	// walk the pages
+	opts = &listOptions{limit: 2}
+	assert.DeepEqual(t, paginate(opts, ids, getID), []string{"a", "b"})
+	token := opts.continueToken()
+	assert.Assert(t, token != "", "token expected for first page")
+	opts = &listOptions{limit: 2, after: "b"}
+	assert.DeepEqual(t, paginate(opts, ids, getID), []string{"c", "d"})
+	opts = &listOptions{limit: 2, after: "d"}
+	assert.DeepEqual(t, paginate(opts, ids, getID), []string{"e"})
+	assert.Equal(t, opts.continueToken(), "", "no token expected for last page")
+
+	// the object the token points to is removed
+	opts = &listOptions{limit: 2, after: "b"}
+	assert.DeepEqual(t, paginate(opts, []string{"a", "c", "d"}, getID), []string{"c", "d"})
+	assert.Equal(t, opts.continueToken(), "", "no token expected for exact last page")
+	// token past the end
+	opts = &listOptions{after: "z"}
+	assert.Equal(t, len(paginate(opts, ids, getID)), 0, "expected empty page")
+}
+
+func TestProjectFields(t *testing.T) {
+	appDAO := &dao.ApplicationDAOInfo{
+		ApplicationID: "app-1",
+		QueueName:     "root.default",
+		User:          "test",
+	}
+	projected, err := projectFields(appDAO, []string{"applicationID", "user", "unknown"})
+	assert.NilError(t, err, "unexpected projection error")
+	assert.Equal(t, len(projected), 2, "unknown fields should be ignored")
+	assert.Equal(t, string(projected["applicationID"]), `"app-1"`)
+	assert.Equal(t, string(projected["user"]), `"test"`)
+}
+
+func TestListApplicationsPaged(t *testing.T) {
+	part := setup(t, configDefault, 1)
+	for _, id := range []string{"app-3", "app-1", "app-2"} {
+		addAppWithUserGroup(t, id, part, "root.default", false, security.UserGroup{User: "user-" + id})
+	}
+	NewWebApp(schedulerContext.Load(), nil)
+
+	// first page: sorted on ID
+	resp := getAppsPage(t, "limit=2")
+	assert.Equal(t, resp.statusCode, 0, statusCodeError)
+	var appsDao []*dao.ApplicationDAOInfo
+	err := json.Unmarshal(resp.outputBytes, &appsDao)
+	assert.NilError(t, err, unmarshalError)
+	assert.Equal(t, len(appsDao), 2)
+	assert.Equal(t, appsDao[0].ApplicationID, "app-1")
+	assert.Equal(t, appsDao[1].ApplicationID, "app-2")
+	token := resp.Header().Get(ContinueHeader)
+	assert.Assert(t, token != "", "continue token expected")
+
+	// second page: last app no token
+	resp = getAppsPage(t, "limit=2&continue="+token)
+	err = json.Unmarshal(resp.outputBytes, &appsDao)
+	assert.NilError(t, err, unmarshalError)
+	assert.Equal(t, len(appsDao), 1)
+	assert.Equal(t, appsDao[0].ApplicationID, "app-3")
+	assert.Equal(t, resp.Header().Get(ContinueHeader), "", "no continue token expected")
+
+	// filters and projection
+	resp = getAppsPage(t, "user=user-app-2&queue=root&fields=applicationID,queueName")
+	var projected []map[string]interface{}
+	err = json.Unmarshal(resp.outputBytes, &projected)
+	assert.NilError(t, err, unmarshalError)
+	assert.DeepEqual(t, projected, []map[string]interface{}{{"applicationID": "app-2", "queueName": "root.default"}})
+	// a sibling queue with the same prefix does not match
+	resp = getAppsPage(t, "queue=root.def")
+	err = json.Unmarshal(resp.outputBytes, &appsDao)
+	assert.NilError(t, err, unmarshalError)
+	assert.Equal(t, len(appsDao), 0)
+	resp = getAppsPage(t, "queue=root.default")
+	err = json.Unmarshal(resp.outputBytes, &appsDao)
+	assert.NilError(t, err, unmarshalError)
+	assert.Equal(t, len(appsDao), 3)
+	resp = getAppsPage(t, "queue=root.other")
+	err = json.Unmarshal(resp.outputBytes, &appsDao)
+	assert.NilError(t, err, unmarshalError)
+	assert.Equal(t, len(appsDao), 0)
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	resp = getAppsPage(t, "since="+future)
+	err = json.Unmarshal(resp.outputBytes, &appsDao)
+	assert.NilError(t, err, unmarshalError)
+	assert.Equal(t, len(appsDao), 0)
+	resp = getAppsPage(t, "until="+future)
+	err = json.Unmarshal(resp.outputBytes, &appsDao)
+	assert.NilError(t, err, unmarshalError)
+	assert.Equal(t, len(appsDao), 3)
+
+	// illegal option
+	resp = getAppsPage(t, "limit=-1")
+	assert.Equal(t, resp.statusCode, http.StatusBadRequest, statusCodeError)
+}
+
+func TestListNodesFiltered(t *testing.T) {
+	part := setup(t, configDefault, 1)
+	for _, id := range []string{"node-2", "node-1", "node-3"} {
+		attributes := map[string]string{"zone": "a"}
+		if id == "node-2" {
+			attributes["zone"] = "b"
+		}
+		node := objects.NewNode(&si.NodeInfo{NodeID: id, Attributes: attributes, SchedulableResource: &si.Resource{}})
+		assert.NilError(t, part.AddNode(node), "adding node to partition should not fail")
+	}
+	NewWebApp(schedulerContext.Load(), nil)
+
+	req, err := createRequest(t, "/ws/v1/partition/default/nodes?attribute=zone=a&limit=1", map[string]string{"partition": partitionNameWithoutClusterID})
+	assert.NilError(t, err)
+	resp := &MockResponseWriter{}
+	getPartitionNodes(resp, req)
+	var nodesDao []*dao.NodeDAOInfo
+	err = json.Unmarshal(resp.outputBytes, &nodesDao)
+	assert.NilError(t, err, unmarshalError)
+	assert.Equal(t, len(nodesDao), 1)
+	assert.Equal(t, nodesDao[0].NodeID, "node-1")
+	token := resp.Header().Get(ContinueHeader)
+	assert.Assert(t, token != "", "continue token expected")
+
+	req, err = createRequest(t, "/ws/v1/partition/default/nodes?attribute=zone=a&limit=1&continue="+token, map[string]string{"partition": partitionNameWithoutClusterID})
+	assert.NilError(t, err)
+	resp = &MockResponseWriter{}
+	getPartitionNodes(resp, req)
+	err = json.Unmarshal(resp.outputBytes, &nodesDao)
+	assert.NilError(t, err, unmarshalError)
+	assert.Equal(t, len(nodesDao), 1)
+	assert.Equal(t, nodesDao[0].NodeID, "node-3", "node-2 should have been filtered")
+	assert.Equal(t, resp.Header().Get(ContinueHeader), "", "no continue token expected")
+}
+
+func getAppsPage(t *testing.T, query string) *MockResponseWriter {
+	t.Helper()
+	req, err := createRequest(t, "/ws/v1/partition/default/applications/active?"+query, map[string]string{"partition": partitionNameWithoutClusterID, "state": AppStateActive})
+	assert.NilError(t, err)
+	resp := &MockResponseWriter{}
+	getPartitionApplicationsByState(resp, req)
+	return resp
+}
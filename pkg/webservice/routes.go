@@ -108,6 +108,12 @@ var webRoutes = routes{
 		"/ws/v1/partition/:partition/node/:node",
 		getPartitionNode,
 	},
+	route{
+		"Scheduler",
+		"POST",
+		"/ws/v1/partition/:partition/node/:node/state",
+		updateNodeState,
+	},
 	route{
 		"Scheduler",
 		"GET",
@@ -138,6 +144,30 @@ var webRoutes = routes{
 		"/ws/v1/partition/:partition/queue/:queue/applications/:state",
 		getQueueApplicationsByState,
 	},
+	route{
+		"Scheduler",
+		"POST",
+		"/ws/v1/partition/:partition/queue/:queue/state",
+		updateQueueState,
+	},
+	route{
+		"Scheduler",
+		"POST",
+		"/ws/v1/partition/:partition/application/:application/queue",
+		moveApplication,
+	},
+	route{
+		"Scheduler",
+		"POST",
+		"/ws/v1/partition/:partition/application/:application/priority",
+		setApplicationPriority,
+	},
+	route{
+		"Scheduler",
+		"POST",
+		"/ws/v1/partition/:partition/application/:application/allocation/:allocation/release",
+		releaseAllocation,
+	},
 	route{
 		"Scheduler",
 		"GET",
@@ -162,6 +192,30 @@ var webRoutes = routes{
 		"/ws/v1/partition/:partition/usage/group/:group",
 		getGroupResourceUsage,
 	},
+	route{
+		"Scheduler",
+		"GET",
+		"/ws/v1/partition/:partition/chargeback",
+		getChargebackReport,
+	},
+	route{
+		"Scheduler",
+		"GET",
+		"/ws/v1/partition/:partition/reservations",
+		getAdvanceReservations,
+	},
+	route{
+		"Scheduler",
+		"POST",
+		"/ws/v1/partition/:partition/reservations",
+		addAdvanceReservation,
+	},
+	route{
+		"Scheduler",
+		"DELETE",
+		"/ws/v1/partition/:partition/reservation/:reservation",
+		removeAdvanceReservation,
+	},
 	route{
 		"Scheduler",
 		"GET",
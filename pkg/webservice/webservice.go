@@ -47,6 +47,7 @@ func newRouter() *httprouter.Router {
 		handler := loggingHandler(webRoute.HandlerFunc, webRoute.Name)
 		router.Handler(webRoute.Method, webRoute.Pattern, handler)
 	}
+	router.NotFound = loggingHandler(http.HandlerFunc(notFound), "NotFound")
 	return router
 }
 
@@ -19,15 +19,21 @@
 package webservice
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"testing"
 
 	"gotest.tools/v3/assert"
 
+	"github.com/apache/yunikorn-core/pkg/common/resources"
+	"github.com/apache/yunikorn-core/pkg/events"
 	"github.com/apache/yunikorn-core/pkg/metrics/history"
 	"github.com/apache/yunikorn-core/pkg/scheduler"
+	"github.com/apache/yunikorn-core/pkg/webservice/dao"
 )
 
 const base = "http://localhost:9080"
@@ -150,3 +156,86 @@ func Test_HeaderChecks(t *testing.T) {
 		})
 	}
 }
+
+// Test_PartitionRoutes runs requests for all partition related routes through the router against a
+// cluster context with a node and an application. It checks the routes are reachable with their path
+// parameters and that unknown objects return a JSON formatted not found error.
+func Test_PartitionRoutes(t *testing.T) {
+	defer ResetIMHistory()
+	part := setup(t, configDefault, 1)
+	addNode(t, part, nodeID, resources.NewResourceFromMap(map[string]resources.Quantity{"vcore": 10}))
+	addApp(t, "app-1", part, queueName, false)
+	NewWebApp(schedulerContext.Load(), history.NewInternalMetricsHistory(5))
+	events.Init()
+	ev := events.GetEventSystem().(*events.EventSystemImpl) //nolint:errcheck
+	ev.StartServiceWithPublisher(false)
+	defer ev.Stop()
+
+	server := httptest.NewServer(newRouter())
+	defer server.Close()
+
+	escapedQueue := url.QueryEscape(queueName)
+	tests := []struct {
+		name    string
+		reqURL  string
+		code    int
+		message string
+	}{
+		{"partitions", "/ws/v1/partitions", http.StatusOK, ""},
+		{"placement rules", "/ws/v1/partition/default/placementrules", http.StatusOK, ""},
+		{"placement rules unknown partition", "/ws/v1/partition/unknown/placementrules", http.StatusNotFound, PartitionDoesNotExists},
+		{"queues", "/ws/v1/partition/default/queues", http.StatusOK, ""},
+		{"queues unknown partition", "/ws/v1/partition/unknown/queues", http.StatusNotFound, PartitionDoesNotExists},
+		{"queue", "/ws/v1/partition/default/queue/" + escapedQueue, http.StatusOK, ""},
+		{"queue subtree", "/ws/v1/partition/default/queue/root?subtree", http.StatusOK, ""},
+		{"queue unknown partition", "/ws/v1/partition/unknown/queue/" + escapedQueue, http.StatusNotFound, PartitionDoesNotExists},
+		{"queue unknown", "/ws/v1/partition/default/queue/root.unknown", http.StatusNotFound, QueueDoesNotExists},
+		{"nodes", "/ws/v1/partition/default/nodes", http.StatusOK, ""},
+		{"nodes unknown partition", "/ws/v1/partition/unknown/nodes", http.StatusNotFound, PartitionDoesNotExists},
+		{"node", "/ws/v1/partition/default/node/" + nodeID, http.StatusOK, ""},
+		{"node unknown partition", "/ws/v1/partition/unknown/node/" + nodeID, http.StatusNotFound, PartitionDoesNotExists},
+		{"node unknown", "/ws/v1/partition/default/node/unknown", http.StatusNotFound, NodeDoesNotExists},
+		{"queue apps", "/ws/v1/partition/default/queue/" + escapedQueue + "/applications", http.StatusOK, ""},
+		{"queue apps unknown partition", "/ws/v1/partition/unknown/queue/" + escapedQueue + "/applications", http.StatusNotFound, PartitionDoesNotExists},
+		{"queue apps unknown queue", "/ws/v1/partition/default/queue/root.unknown/applications", http.StatusNotFound, QueueDoesNotExists},
+		{"queue apps by state", "/ws/v1/partition/default/queue/" + escapedQueue + "/applications/active", http.StatusOK, ""},
+		{"queue apps by state unknown queue", "/ws/v1/partition/default/queue/root.unknown/applications/active", http.StatusNotFound, QueueDoesNotExists},
+		{"queue app", "/ws/v1/partition/default/queue/" + escapedQueue + "/application/app-1", http.StatusOK, ""},
+		{"queue app unknown", "/ws/v1/partition/default/queue/" + escapedQueue + "/application/unknown", http.StatusNotFound, ApplicationDoesNotExists},
+		{"queue app unknown queue", "/ws/v1/partition/default/queue/root.unknown/application/app-1", http.StatusNotFound, QueueDoesNotExists},
+		{"app", "/ws/v1/partition/default/application/app-1", http.StatusOK, ""},
+		{"app unknown", "/ws/v1/partition/default/application/unknown", http.StatusNotFound, ApplicationDoesNotExists},
+		{"app unknown partition", "/ws/v1/partition/unknown/application/app-1", http.StatusNotFound, PartitionDoesNotExists},
+		{"apps active", "/ws/v1/partition/default/applications/active", http.StatusOK, ""},
+		{"apps completed", "/ws/v1/partition/default/applications/completed", http.StatusOK, ""},
+		{"apps rejected", "/ws/v1/partition/default/applications/rejected", http.StatusOK, ""},
+		{"apps unknown partition", "/ws/v1/partition/unknown/applications/active", http.StatusNotFound, PartitionDoesNotExists},
+		{"users usage", "/ws/v1/partition/default/usage/users", http.StatusOK, ""},
+		{"user usage unknown", "/ws/v1/partition/default/usage/user/unknown", http.StatusNotFound, UserDoesNotExists},
+		{"groups usage", "/ws/v1/partition/default/usage/groups", http.StatusOK, ""},
+		{"group usage unknown", "/ws/v1/partition/default/usage/group/unknown", http.StatusNotFound, GroupDoesNotExists},
+		{"app history", "/ws/v1/history/apps", http.StatusOK, ""},
+		{"container history", "/ws/v1/history/containers", http.StatusOK, ""},
+		{"events", "/ws/v1/events/batch", http.StatusOK, ""},
+		{"unknown route", "/ws/v1/partition/default/unknown", http.StatusNotFound, http.StatusText(http.StatusNotFound)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Get(server.URL + tt.reqURL)
+			assert.NilError(t, err, "unexpected error returned")
+			var body []byte
+			body, err = io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			assert.NilError(t, err, "unexpected error reading body")
+			assert.Equal(t, resp.StatusCode, tt.code, "unexpected status code: %s", string(body))
+			assert.Equal(t, resp.Header.Get("Content-Type"), "application/json; charset=UTF-8", "expected JSON response")
+			if tt.message != "" {
+				var errInfo dao.YAPIError
+				err = json.Unmarshal(body, &errInfo)
+				assert.NilError(t, err, unmarshalError)
+				assert.Equal(t, errInfo.Message, tt.message, jsonMessageError)
+				assert.Equal(t, errInfo.StatusCode, tt.code)
+			}
+		})
+	}
+}